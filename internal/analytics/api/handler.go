@@ -8,20 +8,28 @@ import (
 	"ms-ticketing/internal/auth"
 	"ms-ticketing/internal/logger"
 	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/utils"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-redis/redis/v8"
 )
 
+// maxBatchEventIDs caps how many events one batch analytics request may cover; each needs an ownership check and its own queries
+const maxBatchEventIDs = 100
+
 // Handler handles analytics HTTP endpoints
 type Handler struct {
 	Service     *analytics.Service
 	Logger      *logger.Logger
 	Client      *http.Client
-	RedisClient *redis.Client
+	RedisClient redis.UniversalClient
+	SeatHolds   SeatHoldLister // Live seat locks; the held-seats endpoint answers 503 without it
+	SeatingURL  *url.URL       // Base URL of the event seating service that verifies ownership
 }
 
 // NewHandler creates a new analytics handler
@@ -34,30 +42,82 @@ func NewHandler(service *analytics.Service, logger *logger.Logger) *Handler {
 }
 
 // NewHandlerWithRedis creates a new analytics handler with Redis client for token caching
-func NewHandlerWithRedis(service *analytics.Service, logger *logger.Logger, redisClient *redis.Client) *Handler {
+func NewHandlerWithRedis(service *analytics.Service, logger *logger.Logger, redisClient redis.UniversalClient, httpClient *http.Client) *Handler {
 	return &Handler{
 		Service:     service,
 		Logger:      logger,
-		Client:      &http.Client{Timeout: 10 * time.Second},
+		Client:      httpClient,
 		RedisClient: redisClient,
 	}
 }
 
+// seatingServiceEndpoint builds a URL on the event seating service from a path and query parameters
+func (h *Handler) seatingServiceEndpoint(path string, query url.Values) (string, error) {
+	if h.SeatingURL == nil {
+		h.Logger.Error("CONFIG", "Event seating service URL is not configured")
+		return "", fmt.Errorf("event seating service URL not configured")
+	}
+	endpoint := h.SeatingURL.JoinPath(path)
+	endpoint.RawQuery = query.Encode()
+	return endpoint.String(), nil
+}
+
 // RegisterRoutes registers the analytics routes on a chi router
 func (h *Handler) RegisterRoutes(r chi.Router) {
 	r.Route("/order/analytics", func(r chi.Router) {
+		r.Use(includeArchivedMiddleware)
+		r.Use(compedModeMiddleware)
+		r.Use(currencyMiddleware)
 		r.Get("/events/{eventId}", h.GetEventAnalytics)
 		r.Get("/events/{eventId}/discounts", h.GetEventDiscountAnalytics)
 		r.Get("/events/{eventId}/sessions", h.GetEventSessionsAnalytics)
 		r.Get("/events/{eventId}/sessions/{sessionId}", h.GetSessionAnalytics)
+		r.Get("/events/{eventId}/compare", h.CompareSessions)
+		r.Get("/events/{eventId}/top-customers", h.GetTopCustomers)
 		r.Get("/events/{eventId}/orders", h.GetEventOrders)
 		r.Get("/sessions/{sessionId}/tickets", h.GetSessionTickets)
+		r.Get("/sessions/{sessionId}/checkin-stats", h.GetSessionCheckinStats)
+		r.Get("/sessions/{sessionId}/held-seats", h.GetSessionHeldSeats)
 		r.Post("/events/batch", h.GetBatchEventAnalytics)
 		r.Post("/events/batch/individual", h.GetBatchEventAnalyticsIndividual)
 		r.Get("/organizations/{organizationId}", h.GetOrganizationAnalytics)
 	})
 }
 
+// includeArchivedMiddleware lets callers opt in to archived orders with ?include_archived=true
+func includeArchivedMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if include, _ := strconv.ParseBool(r.URL.Query().Get("include_archived")); include {
+			r = r.WithContext(analytics.WithArchived(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// compedModeMiddleware reads ?comped=separate|exclude|include to control how comped orders are reported
+func compedModeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mode, err := analytics.ParseCompedMode(r.URL.Query().Get("comped"))
+		if err != nil {
+			sendJSONResponse(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(analytics.WithCompedMode(r.Context(), mode)))
+	})
+}
+
+// currencyMiddleware reads ?currency= to pick the currency revenue is reported in, the default currency otherwise
+func currencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, err := models.ValidateCurrency(r.URL.Query().Get("currency"))
+		if err != nil {
+			sendJSONResponse(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(analytics.WithCurrency(r.Context(), code)))
+	})
+}
+
 // sendJSONResponse is a helper function to send JSON responses
 func sendJSONResponse(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -90,15 +150,11 @@ func (h *Handler) verifyEventOwnership(eventID string, userID string) (bool, err
 	}
 
 	// Create and execute HTTP request to verify ownership
-	seatingServiceURL := os.Getenv("EVENT_SEATING_SERVICE_URL")
-	if seatingServiceURL == "" {
-		h.Logger.Error("CONFIG", "EVENT_SEATING_SERVICE_URL environment variable not set")
-		return false, fmt.Errorf("EVENT_SEATING_SERVICE_URL not set")
+	requestURL, err := h.seatingServiceEndpoint("internal/v1/events/verify-ownership", url.Values{"eventId": {eventID}, "userId": {userID}})
+	if err != nil {
+		return false, err
 	}
 
-	requestURL := fmt.Sprintf("%s/internal/v1/events/verify-ownership?eventId=%s&userId=%s",
-		seatingServiceURL, eventID, userID)
-
 	req, err := http.NewRequest("GET", requestURL, nil)
 	if err != nil {
 		h.Logger.Error("HTTP", fmt.Sprintf("Failed to create ownership verification request: %v", err))
@@ -154,15 +210,11 @@ func (h *Handler) verifySessionOwnership(sessionID string, userID string) (bool,
 	}
 
 	// Create and execute HTTP request to verify ownership
-	seatingServiceURL := os.Getenv("EVENT_SEATING_SERVICE_URL")
-	if seatingServiceURL == "" {
-		h.Logger.Error("CONFIG", "EVENT_SEATING_SERVICE_URL environment variable not set")
-		return false, fmt.Errorf("EVENT_SEATING_SERVICE_URL not set")
+	requestURL, err := h.seatingServiceEndpoint("internal/v1/sessions/verify-ownership", url.Values{"sessionId": {sessionID}, "userId": {userID}})
+	if err != nil {
+		return false, err
 	}
 
-	requestURL := fmt.Sprintf("%s/internal/v1/sessions/verify-ownership?sessionId=%s&userId=%s",
-		seatingServiceURL, sessionID, userID)
-
 	req, err := http.NewRequest("GET", requestURL, nil)
 	if err != nil {
 		h.Logger.Error("HTTP", fmt.Sprintf("Failed to create session ownership verification request: %v", err))
@@ -219,14 +271,11 @@ func (h *Handler) verifyBatchEventOwnership(eventIDs []string, userID string) ([
 	}
 
 	// Create and execute HTTP request to verify batch ownership
-	seatingServiceURL := os.Getenv("EVENT_SEATING_SERVICE_URL")
-	if seatingServiceURL == "" {
-		h.Logger.Error("CONFIG", "EVENT_SEATING_SERVICE_URL environment variable not set")
-		return nil, fmt.Errorf("EVENT_SEATING_SERVICE_URL not set")
+	requestURL, err := h.seatingServiceEndpoint("internal/v1/events/verify-batch-ownership", nil)
+	if err != nil {
+		return nil, err
 	}
 
-	requestURL := fmt.Sprintf("%s/internal/v1/events/verify-batch-ownership", seatingServiceURL)
-
 	// Create request body
 	requestBody := models.BatchOwnershipRequest{
 		EventIDs: eventIDs,
@@ -466,8 +515,12 @@ func (h *Handler) GetBatchEventAnalytics(w http.ResponseWriter, r *http.Request)
 		EventIDs []string `json:"eventIds"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+	if err := utils.DecodeJSONBody(w, r, &request); err != nil {
 		h.Logger.Error("ANALYTICS", "Failed to parse request body: "+err.Error())
+		if utils.IsBodyTooLarge(err) {
+			sendJSONResponse(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "Request body is too large"})
+			return
+		}
 		sendJSONResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
 		return
 	}
@@ -477,6 +530,11 @@ func (h *Handler) GetBatchEventAnalytics(w http.ResponseWriter, r *http.Request)
 		sendJSONResponse(w, http.StatusBadRequest, map[string]string{"error": "No event IDs provided"})
 		return
 	}
+	if len(request.EventIDs) > maxBatchEventIDs {
+		h.Logger.Warn("ANALYTICS", fmt.Sprintf("Batch analytics request for %d events exceeds the limit of %d", len(request.EventIDs), maxBatchEventIDs))
+		sendJSONResponse(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("At most %d event IDs may be requested at once", maxBatchEventIDs)})
+		return
+	}
 
 	// Extract user ID from context (injected by auth middleware)
 	userID := auth.UserID(r.Context())
@@ -522,8 +580,12 @@ func (h *Handler) GetBatchEventAnalyticsIndividual(w http.ResponseWriter, r *htt
 		EventIDs []string `json:"eventIds"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+	if err := utils.DecodeJSONBody(w, r, &request); err != nil {
 		h.Logger.Error("ANALYTICS", "Failed to parse request body: "+err.Error())
+		if utils.IsBodyTooLarge(err) {
+			sendJSONResponse(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "Request body is too large"})
+			return
+		}
 		sendJSONResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
 		return
 	}
@@ -533,6 +595,11 @@ func (h *Handler) GetBatchEventAnalyticsIndividual(w http.ResponseWriter, r *htt
 		sendJSONResponse(w, http.StatusBadRequest, map[string]string{"error": "No event IDs provided"})
 		return
 	}
+	if len(request.EventIDs) > maxBatchEventIDs {
+		h.Logger.Warn("ANALYTICS", fmt.Sprintf("Batch analytics request for %d events exceeds the limit of %d", len(request.EventIDs), maxBatchEventIDs))
+		sendJSONResponse(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("At most %d event IDs may be requested at once", maxBatchEventIDs)})
+		return
+	}
 
 	// Extract user ID from context (injected by auth middleware)
 	userID := auth.UserID(r.Context())
@@ -605,10 +672,25 @@ func (h *Handler) GetEventOrders(w http.ResponseWriter, r *http.Request) {
 	options := analytics.EventOrderOptions{
 		SessionID: r.URL.Query().Get("sessionId"),
 		Status:    r.URL.Query().Get("status"),
+		Search:    r.URL.Query().Get("search"),
 		SortBy:    r.URL.Query().Get("sort"),
 		SortDesc:  r.URL.Query().Get("order") == "desc",
 	}
 
+	// Parse the created_at range; a plain date for "to" includes that whole day
+	if options.From, err = parseOrderTime(r.URL.Query().Get("from"), false); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, map[string]string{"error": "from must be an RFC 3339 time or YYYY-MM-DD date"})
+		return
+	}
+	if options.To, err = parseOrderTime(r.URL.Query().Get("to"), true); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, map[string]string{"error": "to must be an RFC 3339 time or YYYY-MM-DD date"})
+		return
+	}
+	if !options.From.IsZero() && !options.To.IsZero() && !options.From.Before(options.To) {
+		sendJSONResponse(w, http.StatusBadRequest, map[string]string{"error": "from must be before to"})
+		return
+	}
+
 	// Parse pagination parameters
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		var limit int
@@ -626,14 +708,33 @@ func (h *Handler) GetEventOrders(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	orders, err := h.Service.GetEventOrders(r.Context(), eventID, options)
+	page, err := h.Service.GetEventOrders(r.Context(), eventID, options)
 	if err != nil {
 		h.Logger.Error("ANALYTICS", "Error getting event orders: "+err.Error())
 		sendJSONResponse(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get orders"})
 		return
 	}
 
-	sendJSONResponse(w, http.StatusOK, orders)
+	sendJSONResponse(w, http.StatusOK, page)
+}
+
+// parseOrderTime reads an RFC 3339 time or a YYYY-MM-DD date (UTC). With endOfDay a date is moved
+// to the following midnight so an exclusive upper bound still covers it. Empty values give the zero time.
+func parseOrderTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
 }
 
 // GetSessionTickets handles request to get all tickets for a session
@@ -0,0 +1,50 @@
+package analytics_api
+
+import (
+	"fmt"
+	"ms-ticketing/internal/auth"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetSessionCheckinStats handles the check-in statistics request for a session
+func (h *Handler) GetSessionCheckinStats(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionId")
+	if sessionID == "" {
+		h.Logger.Error("ANALYTICS", "session_id is required")
+		sendJSONResponse(w, http.StatusBadRequest, map[string]string{"error": "session_id is required"})
+		return
+	}
+
+	// Extract user ID from context (injected by auth middleware)
+	userID := auth.UserID(r.Context())
+	if userID == "" {
+		h.Logger.Error("ANALYTICS", "User ID not found in context")
+		sendJSONResponse(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized access"})
+		return
+	}
+
+	// Verify session ownership
+	isOwner, err := h.verifySessionOwnership(sessionID, userID)
+	if err != nil {
+		h.Logger.Error("ANALYTICS", "Error verifying session ownership: "+err.Error())
+		sendJSONResponse(w, http.StatusInternalServerError, map[string]string{"error": "Failed to verify session ownership"})
+		return
+	}
+
+	if !isOwner {
+		h.Logger.Warn("ANALYTICS", fmt.Sprintf("User %s attempted to access check-in stats for session %s without ownership", userID, sessionID))
+		sendJSONResponse(w, http.StatusForbidden, map[string]string{"error": "You do not have permission to access these analytics"})
+		return
+	}
+
+	stats, err := h.Service.GetSessionCheckinStats(r.Context(), sessionID)
+	if err != nil {
+		h.Logger.Error("ANALYTICS", "Error getting session check-in stats: "+err.Error())
+		sendJSONResponse(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get check-in stats"})
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, stats)
+}
@@ -0,0 +1,80 @@
+package analytics_api
+
+import (
+	"fmt"
+	"ms-ticketing/internal/auth"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CompareSessions handles side by side revenue comparison of two sessions of an event
+// Expected query: ?sessions=sessionA,sessionB
+func (h *Handler) CompareSessions(w http.ResponseWriter, r *http.Request) {
+	eventID := chi.URLParam(r, "eventId")
+	if eventID == "" {
+		h.Logger.Error("ANALYTICS", "event_id is required")
+		sendJSONResponse(w, http.StatusBadRequest, map[string]string{"error": "event_id is required"})
+		return
+	}
+
+	var sessionIDs []string
+	for _, id := range strings.Split(r.URL.Query().Get("sessions"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			sessionIDs = append(sessionIDs, id)
+		}
+	}
+	if len(sessionIDs) != 2 || sessionIDs[0] == sessionIDs[1] {
+		h.Logger.Error("ANALYTICS", fmt.Sprintf("Invalid sessions parameter for comparison: %v", sessionIDs))
+		sendJSONResponse(w, http.StatusBadRequest, map[string]string{"error": "sessions must contain exactly two distinct session IDs"})
+		return
+	}
+
+	// Extract user ID from context (injected by auth middleware)
+	userID := auth.UserID(r.Context())
+	if userID == "" {
+		h.Logger.Error("ANALYTICS", "User ID not found in context")
+		sendJSONResponse(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized access"})
+		return
+	}
+
+	isEventOwner, err := h.verifyEventOwnership(eventID, userID)
+	if err != nil {
+		h.Logger.Error("ANALYTICS", "Error verifying event ownership: "+err.Error())
+		sendJSONResponse(w, http.StatusInternalServerError, map[string]string{"error": "Failed to verify event ownership"})
+		return
+	}
+
+	if !isEventOwner {
+		h.Logger.Warn("ANALYTICS", fmt.Sprintf("User %s attempted to compare sessions of event %s without event ownership", userID, eventID))
+		sendJSONResponse(w, http.StatusForbidden, map[string]string{"error": "You do not have permission to access these analytics"})
+		return
+	}
+
+	// Both sessions must be owned as well, otherwise any owner could read foreign sessions
+	for _, sessionID := range sessionIDs {
+		isSessionOwner, err := h.verifySessionOwnership(sessionID, userID)
+		if err != nil {
+			h.Logger.Error("ANALYTICS", "Error verifying session ownership: "+err.Error())
+			sendJSONResponse(w, http.StatusInternalServerError, map[string]string{"error": "Failed to verify session ownership"})
+			return
+		}
+
+		if !isSessionOwner {
+			h.Logger.Warn("ANALYTICS", fmt.Sprintf("User %s attempted to compare session %s without session ownership", userID, sessionID))
+			sendJSONResponse(w, http.StatusForbidden, map[string]string{"error": "You do not have permission to access these analytics"})
+			return
+		}
+	}
+
+	// Only consider orders with status "completed"
+	comparison, err := h.Service.CompareSessions(r.Context(), eventID, sessionIDs[0], sessionIDs[1], "completed")
+	if err != nil {
+		h.Logger.Error("ANALYTICS", "Error comparing sessions: "+err.Error())
+		sendJSONResponse(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get analytics"})
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, comparison)
+}
@@ -0,0 +1,70 @@
+package analytics_api
+
+import (
+	"context"
+	"fmt"
+	"ms-ticketing/internal/auth"
+	"ms-ticketing/internal/models"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SeatHoldLister lists the seats a session's pending orders currently hold in Redis
+type SeatHoldLister interface {
+	SessionHeldSeats(ctx context.Context, sessionID string) ([]models.HeldSeat, error)
+}
+
+// SessionHeldSeats is the response of the held-seats endpoint
+type SessionHeldSeats struct {
+	SessionID string            `json:"session_id"`
+	HeldCount int               `json:"held_count"`
+	Seats     []models.HeldSeat `json:"seats"`
+}
+
+// GetSessionHeldSeats handles the request for the seats currently held in carts for a session
+func (h *Handler) GetSessionHeldSeats(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionId")
+	if sessionID == "" {
+		h.Logger.Error("ANALYTICS", "session_id is required")
+		sendJSONResponse(w, http.StatusBadRequest, map[string]string{"error": "session_id is required"})
+		return
+	}
+
+	// Extract user ID from context (injected by auth middleware)
+	userID := auth.UserID(r.Context())
+	if userID == "" {
+		h.Logger.Error("ANALYTICS", "User ID not found in context")
+		sendJSONResponse(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized access"})
+		return
+	}
+
+	if h.SeatHolds == nil {
+		h.Logger.Error("ANALYTICS", "Seat hold listing is not configured")
+		sendJSONResponse(w, http.StatusServiceUnavailable, map[string]string{"error": "Held seats are not available"})
+		return
+	}
+
+	// Verify session ownership
+	isOwner, err := h.verifySessionOwnership(sessionID, userID)
+	if err != nil {
+		h.Logger.Error("ANALYTICS", "Error verifying session ownership: "+err.Error())
+		sendJSONResponse(w, http.StatusInternalServerError, map[string]string{"error": "Failed to verify session ownership"})
+		return
+	}
+
+	if !isOwner {
+		h.Logger.Warn("ANALYTICS", fmt.Sprintf("User %s attempted to access held seats for session %s without ownership", userID, sessionID))
+		sendJSONResponse(w, http.StatusForbidden, map[string]string{"error": "You do not have permission to access these analytics"})
+		return
+	}
+
+	seats, err := h.SeatHolds.SessionHeldSeats(r.Context(), sessionID)
+	if err != nil {
+		h.Logger.Error("ANALYTICS", "Error listing held seats: "+err.Error())
+		sendJSONResponse(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get held seats"})
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, SessionHeldSeats{SessionID: sessionID, HeldCount: len(seats), Seats: seats})
+}
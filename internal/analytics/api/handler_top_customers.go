@@ -0,0 +1,61 @@
+package analytics_api
+
+import (
+	"fmt"
+	"ms-ticketing/internal/auth"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetTopCustomers handles the request for the highest spending customers of an event
+func (h *Handler) GetTopCustomers(w http.ResponseWriter, r *http.Request) {
+	eventID := chi.URLParam(r, "eventId")
+	if eventID == "" {
+		h.Logger.Error("ANALYTICS", "event_id is required")
+		sendJSONResponse(w, http.StatusBadRequest, map[string]string{"error": "event_id is required"})
+		return
+	}
+
+	// Extract user ID from context (injected by auth middleware)
+	userID := auth.UserID(r.Context())
+	if userID == "" {
+		h.Logger.Error("ANALYTICS", "User ID not found in context")
+		sendJSONResponse(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized access"})
+		return
+	}
+
+	// Verify ownership before proceeding
+	isOwner, err := h.verifyEventOwnership(eventID, userID)
+	if err != nil {
+		h.Logger.Error("ANALYTICS", "Error verifying event ownership: "+err.Error())
+		sendJSONResponse(w, http.StatusInternalServerError, map[string]string{"error": "Failed to verify event ownership"})
+		return
+	}
+
+	if !isOwner {
+		h.Logger.Warn("ANALYTICS", fmt.Sprintf("User %s attempted to access top customers for event %s without ownership", userID, eventID))
+		sendJSONResponse(w, http.StatusForbidden, map[string]string{"error": "You do not have permission to access these analytics"})
+		return
+	}
+
+	var limit int
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if _, err := fmt.Sscanf(limitStr, "%d", &limit); err != nil || limit <= 0 {
+			sendJSONResponse(w, http.StatusBadRequest, map[string]string{"error": "limit must be a positive integer"})
+			return
+		}
+	}
+
+	customers, err := h.Service.GetTopCustomers(r.Context(), eventID, limit)
+	if err != nil {
+		h.Logger.Error("ANALYTICS", "Error getting top customers: "+err.Error())
+		sendJSONResponse(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get analytics"})
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"event_id":  eventID,
+		"customers": customers,
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"ms-ticketing/internal/auth"
 	"ms-ticketing/internal/models"
 	"net/http"
+	"net/url"
 	"os"
 )
 
@@ -29,15 +30,11 @@ func (h *Handler) verifyOrganizationOwnership(organizationID string, userID stri
 	}
 
 	// Create and execute HTTP request to verify organization ownership
-	seatingServiceURL := os.Getenv("EVENT_SEATING_SERVICE_URL")
-	if seatingServiceURL == "" {
-		h.Logger.Error("CONFIG", "EVENT_SEATING_SERVICE_URL environment variable not set")
-		return false, fmt.Errorf("EVENT_SEATING_SERVICE_URL not set")
+	requestURL, err := h.seatingServiceEndpoint("internal/v1/organizations/verify-ownership", url.Values{"organizationId": {organizationID}, "userId": {userID}})
+	if err != nil {
+		return false, err
 	}
 
-	requestURL := fmt.Sprintf("%s/internal/v1/organizations/verify-ownership?organizationId=%s&userId=%s",
-		seatingServiceURL, organizationID, userID)
-
 	req, err := http.NewRequest("GET", requestURL, nil)
 	if err != nil {
 		h.Logger.Error("HTTP", fmt.Sprintf("Failed to create organization ownership verification request: %v", err))
@@ -0,0 +1,25 @@
+package analytics
+
+import "context"
+
+type includeArchivedKey struct{}
+
+// WithArchived returns a context under which analytics queries also count archived orders.
+// By default archived orders are left out so dashboards only reflect recent sales.
+func WithArchived(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeArchivedKey{}, true)
+}
+
+func includeArchived(ctx context.Context) bool {
+	include, _ := ctx.Value(includeArchivedKey{}).(bool)
+	return include
+}
+
+// archivedFilter returns the predicate appended to raw order queries, or nothing when
+// archived orders were requested. column is the qualified archived column, e.g. "o.archived".
+func archivedFilter(ctx context.Context, column string) string {
+	if includeArchived(ctx) {
+		return ""
+	}
+	return " AND " + column + " = FALSE"
+}
@@ -4,12 +4,12 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"time"
 )
 
 // BatchEventAnalytics represents aggregated analytics data for multiple events
 type BatchEventAnalytics struct {
 	EventIDs         []string            `json:"event_ids"`
+	Currency         string              `json:"currency"`
 	TotalRevenue     float64             `json:"total_revenue"`
 	TotalBeforeDisc  float64             `json:"total_before_discounts"`
 	TotalTicketsSold int                 `json:"total_tickets_sold"`
@@ -20,7 +20,7 @@ type BatchEventAnalytics struct {
 // GetBatchEventAnalytics returns aggregated analytics data for multiple events
 func (s *Service) GetBatchEventAnalytics(ctx context.Context, eventIDs []string, status string) (*BatchEventAnalytics, error) {
 	if len(eventIDs) == 0 {
-		return &BatchEventAnalytics{EventIDs: []string{}}, nil
+		return &BatchEventAnalytics{EventIDs: []string{}, Currency: Currency(ctx)}, nil
 	}
 
 	// Create placeholder for SQL IN clause
@@ -39,8 +39,8 @@ func (s *Service) GetBatchEventAnalytics(ctx context.Context, eventIDs []string,
 	}
 	rawSQL := fmt.Sprintf(`
 		SELECT 
-			SUM(price) AS total_revenue,
-			SUM(subtotal) AS total_before_disc
+			SUM(price) / 100.0 AS total_revenue,
+			SUM(subtotal) / 100.0 AS total_before_disc
 		FROM 
 			orders
 		WHERE 
@@ -50,6 +50,7 @@ func (s *Service) GetBatchEventAnalytics(ctx context.Context, eventIDs []string,
 		rawSQL += " AND status = ?"
 		args = append(args, status)
 	}
+	rawSQL += archivedFilter(ctx, "archived") + compedFilter(ctx, "is_comped") + currencyFilter(ctx, "currency")
 
 	err := s.db.NewRaw(rawSQL, args...).Scan(ctx, &orders)
 	if err != nil {
@@ -68,13 +69,14 @@ func (s *Service) GetBatchEventAnalytics(ctx context.Context, eventIDs []string,
 		WHERE 
 			o.event_id IN (%s)`, inClause)
 
-	ticketArgs := make([]interface{}, len(args))
-	copy(ticketArgs, args)
+	ticketArgs := make([]interface{}, len(eventIDs))
+	copy(ticketArgs, args[:len(eventIDs)])
 
 	if status != "" {
 		rawSQL += " AND o.status = ?"
 		ticketArgs = append(ticketArgs, status)
 	}
+	rawSQL += archivedFilter(ctx, "o.archived") + compedFilter(ctx, "o.is_comped") + currencyFilter(ctx, "o.currency")
 
 	err = s.db.NewRaw(rawSQL, ticketArgs...).Scan(ctx, &ticketCount)
 	if err != nil {
@@ -83,16 +85,16 @@ func (s *Service) GetBatchEventAnalytics(ctx context.Context, eventIDs []string,
 
 	// Get daily sales metrics
 	type dailySalesRaw struct {
-		SalesDate     time.Time `bun:"sales_date"`
-		DailyRevenue  float64   `bun:"daily_revenue"`
-		DailyQuantity int       `bun:"tickets_sold_on_date"`
+		SalesDate     string  `bun:"sales_date"`
+		DailyRevenue  float64 `bun:"daily_revenue"`
+		DailyQuantity int     `bun:"tickets_sold_on_date"`
 	}
 
 	var dailySales []dailySalesRaw
-	rawSQL = fmt.Sprintf(`
+	rawSQL = `
 		SELECT
-			DATE(o.created_at) AS sales_date,
-			SUM(o.price) AS daily_revenue,
+			` + dayExpr(s.db, "o.created_at") + ` AS sales_date,
+			SUM(o.price) / 100.0 AS daily_revenue,
 			COALESCE(SUM(ticket_count), 0) AS tickets_sold_on_date
 		FROM (
 			SELECT
@@ -103,7 +105,7 @@ func (s *Service) GetBatchEventAnalytics(ctx context.Context, eventIDs []string,
 				created_at
 			FROM orders
 			WHERE
-				event_id IN (%s)`, inClause)
+				event_id IN (` + inClause + `)`
 
 	dailyArgs := make([]interface{}, len(eventIDs))
 	copy(dailyArgs, args[:len(eventIDs)])
@@ -112,6 +114,7 @@ func (s *Service) GetBatchEventAnalytics(ctx context.Context, eventIDs []string,
 		rawSQL += " AND status = ?"
 		dailyArgs = append(dailyArgs, status)
 	}
+	rawSQL += archivedFilter(ctx, "archived") + compedFilter(ctx, "is_comped") + currencyFilter(ctx, "currency")
 
 	rawSQL += `
 		) o
@@ -123,7 +126,7 @@ func (s *Service) GetBatchEventAnalytics(ctx context.Context, eventIDs []string,
 			GROUP BY order_id
 		) t ON t.order_id = o.order_id
 		GROUP BY
-			DATE(o.created_at)
+			` + dayExpr(s.db, "o.created_at") + `
 		ORDER BY
 			sales_date
 	`
@@ -157,13 +160,14 @@ func (s *Service) GetBatchEventAnalytics(ctx context.Context, eventIDs []string,
 		WHERE 
 			o.event_id IN (%s)`, inClause)
 
-	tierArgs := make([]interface{}, len(args))
-	copy(tierArgs, args)
+	tierArgs := make([]interface{}, len(eventIDs))
+	copy(tierArgs, args[:len(eventIDs)])
 
 	if status != "" {
 		rawSQL += " AND o.status = ?"
 		tierArgs = append(tierArgs, status)
 	}
+	rawSQL += archivedFilter(ctx, "o.archived") + compedFilter(ctx, "o.is_comped") + currencyFilter(ctx, "o.currency")
 
 	rawSQL += `
 		GROUP BY 
@@ -190,6 +194,7 @@ func (s *Service) GetBatchEventAnalytics(ctx context.Context, eventIDs []string,
 	// Format results
 	result := &BatchEventAnalytics{
 		EventIDs:         eventIDs,
+		Currency:         Currency(ctx),
 		TotalRevenue:     totalRevenue,
 		TotalBeforeDisc:  totalBeforeDisc,
 		TotalTicketsSold: ticketCount,
@@ -199,7 +204,7 @@ func (s *Service) GetBatchEventAnalytics(ctx context.Context, eventIDs []string,
 
 	for _, ds := range dailySales {
 		result.DailySales = append(result.DailySales, DailySalesMetrics{
-			Date:        ds.SalesDate.Format("2006-01-02"),
+			Date:        ds.SalesDate,
 			Revenue:     ds.DailyRevenue,
 			TicketsSold: ds.DailyQuantity,
 		})
@@ -0,0 +1,80 @@
+package analytics
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// CheckinBucketSize is the width of each bucket in the arrival histogram
+const CheckinBucketSize = 15 * time.Minute
+
+// CheckinBucket counts arrivals within a 15-minute window starting at BucketStart
+type CheckinBucket struct {
+	BucketStart time.Time `json:"bucket_start"`
+	CheckedIn   int       `json:"checked_in"`
+}
+
+// SessionCheckinStats summarizes door activity for a session
+type SessionCheckinStats struct {
+	SessionID         string          `json:"session_id"`
+	TotalIssued       int             `json:"total_issued"`
+	CheckedIn         int             `json:"checked_in"`
+	NotArrived        int             `json:"not_arrived"`
+	CheckedInPercent  float64         `json:"checked_in_percentage"`
+	ArrivalsHistogram []CheckinBucket `json:"arrivals_histogram"`
+}
+
+// GetSessionCheckinStats returns check-in totals and a 15-minute arrival histogram for a session.
+// Only tickets of completed orders count as issued.
+func (s *Service) GetSessionCheckinStats(ctx context.Context, sessionID string) (*SessionCheckinStats, error) {
+	type checkinRaw struct {
+		CheckedIn     bool      `bun:"checked_in"`
+		CheckedInTime time.Time `bun:"checked_in_time"`
+	}
+
+	var rows []checkinRaw
+	err := s.db.NewSelect().
+		TableExpr("tickets t").
+		ColumnExpr("t.checked_in, t.checked_in_time").
+		Join("INNER JOIN orders o ON o.order_id = t.order_id").
+		Where("o.session_id = ?", sessionID).
+		Where("o.status = ?", "completed").
+		Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SessionCheckinStats{
+		SessionID:         sessionID,
+		TotalIssued:       len(rows),
+		ArrivalsHistogram: []CheckinBucket{},
+	}
+
+	// Bucketing happens here rather than in SQL so it works the same on every dialect
+	buckets := make(map[time.Time]int)
+	for _, row := range rows {
+		if !row.CheckedIn {
+			continue
+		}
+		result.CheckedIn++
+		if row.CheckedInTime.IsZero() {
+			continue
+		}
+		buckets[row.CheckedInTime.UTC().Truncate(CheckinBucketSize)]++
+	}
+
+	result.NotArrived = result.TotalIssued - result.CheckedIn
+	if result.TotalIssued > 0 {
+		result.CheckedInPercent = float64(result.CheckedIn) / float64(result.TotalIssued) * 100
+	}
+
+	for start, count := range buckets {
+		result.ArrivalsHistogram = append(result.ArrivalsHistogram, CheckinBucket{BucketStart: start, CheckedIn: count})
+	}
+	sort.Slice(result.ArrivalsHistogram, func(i, j int) bool {
+		return result.ArrivalsHistogram[i].BucketStart.Before(result.ArrivalsHistogram[j].BucketStart)
+	})
+
+	return result, nil
+}
@@ -0,0 +1,68 @@
+package analytics_test
+
+import (
+	"context"
+	"ms-ticketing/internal/analytics"
+	"ms-ticketing/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSessionCheckinStats(t *testing.T) {
+	bunDB := setupAnalyticsDB(t)
+	doors := time.Date(2025, 3, 1, 18, 0, 0, 0, time.UTC)
+
+	orders := []models.Order{
+		{OrderID: "o1", EventID: "event1", SessionID: "s1", Status: "completed", CreatedAt: doors.Add(-24 * time.Hour)},
+		{OrderID: "o2", EventID: "event1", SessionID: "s1", Status: "cancelled", CreatedAt: doors.Add(-24 * time.Hour)},
+		{OrderID: "o3", EventID: "event1", SessionID: "s2", Status: "completed", CreatedAt: doors.Add(-24 * time.Hour)},
+	}
+	_, err := bunDB.NewInsert().Model(&orders).Exec(context.Background())
+	require.NoError(t, err)
+
+	ticket := func(id, orderID string, checkedInAt time.Time, checkedIn bool) models.Ticket {
+		return models.Ticket{TicketID: id, OrderID: orderID, SeatID: "seat-" + id, TierID: "GA", TierName: "GA", CheckedIn: checkedIn, CheckedInTime: checkedInAt, IssuedAt: doors.Add(-24 * time.Hour)}
+	}
+	tickets := []models.Ticket{
+		ticket("t1", "o1", doors.Add(2*time.Minute), true),
+		ticket("t2", "o1", doors.Add(14*time.Minute), true),
+		ticket("t3", "o1", doors.Add(31*time.Minute), true),
+		// Checked in before check-in times were recorded: counted, but not placed in the histogram
+		ticket("t4", "o1", time.Time{}, true),
+		ticket("t5", "o1", time.Time{}, false),
+		// Tickets of cancelled orders and other sessions are not issued for s1
+		ticket("t6", "o2", doors.Add(5*time.Minute), true),
+		ticket("t7", "o3", doors.Add(5*time.Minute), true),
+	}
+	_, err = bunDB.NewInsert().Model(&tickets).Exec(context.Background())
+	require.NoError(t, err)
+
+	stats, err := analytics.NewService(bunDB).GetSessionCheckinStats(context.Background(), "s1")
+	require.NoError(t, err)
+
+	assert.Equal(t, "s1", stats.SessionID)
+	assert.Equal(t, 5, stats.TotalIssued)
+	assert.Equal(t, 4, stats.CheckedIn)
+	assert.Equal(t, 1, stats.NotArrived)
+	assert.InDelta(t, 80, stats.CheckedInPercent, 0.001)
+	assert.Equal(t, []analytics.CheckinBucket{
+		{BucketStart: doors, CheckedIn: 2},
+		{BucketStart: doors.Add(30 * time.Minute), CheckedIn: 1},
+	}, stats.ArrivalsHistogram)
+}
+
+func TestGetSessionCheckinStatsWithoutTickets(t *testing.T) {
+	bunDB := setupAnalyticsDB(t)
+
+	stats, err := analytics.NewService(bunDB).GetSessionCheckinStats(context.Background(), "empty")
+	require.NoError(t, err)
+
+	assert.Zero(t, stats.TotalIssued)
+	assert.Zero(t, stats.CheckedInPercent)
+	// An empty histogram is still a list, not null
+	assert.NotNil(t, stats.ArrivalsHistogram)
+	assert.Empty(t, stats.ArrivalsHistogram)
+}
@@ -0,0 +1,87 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+)
+
+// CompedMode decides how complimentary (comped) orders show up in revenue analytics
+type CompedMode string
+
+const (
+	// CompedSeparate leaves comped orders out of the sales figures and reports them in their own bucket
+	CompedSeparate CompedMode = "separate"
+	// CompedExclude leaves comped orders out entirely
+	CompedExclude CompedMode = "exclude"
+	// CompedInclude counts comped orders as sales, as analytics did before comps were flagged
+	CompedInclude CompedMode = "include"
+)
+
+type compedModeKey struct{}
+
+// ParseCompedMode reads the ?comped= option; an empty value means CompedSeparate
+func ParseCompedMode(value string) (CompedMode, error) {
+	switch mode := CompedMode(value); mode {
+	case "":
+		return CompedSeparate, nil
+	case CompedSeparate, CompedExclude, CompedInclude:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("comped must be one of %s, %s or %s", CompedSeparate, CompedExclude, CompedInclude)
+	}
+}
+
+// WithCompedMode returns a context under which analytics queries treat comped orders as mode says
+func WithCompedMode(ctx context.Context, mode CompedMode) context.Context {
+	return context.WithValue(ctx, compedModeKey{}, mode)
+}
+
+func compedMode(ctx context.Context) CompedMode {
+	if mode, ok := ctx.Value(compedModeKey{}).(CompedMode); ok {
+		return mode
+	}
+	return CompedSeparate
+}
+
+func includeComped(ctx context.Context) bool {
+	return compedMode(ctx) == CompedInclude
+}
+
+// compedFilter returns the predicate appended to raw order queries so "tickets sold" means paid tickets.
+// column is the qualified is_comped column, e.g. "o.is_comped".
+func compedFilter(ctx context.Context, column string) string {
+	if includeComped(ctx) {
+		return ""
+	}
+	return " AND " + column + " = FALSE"
+}
+
+// CompedMetrics is the separate bucket of complimentary orders
+type CompedMetrics struct {
+	TotalOrders  int `json:"total_orders"`
+	TotalTickets int `json:"total_tickets"`
+}
+
+// getCompedMetrics counts the comped orders and tickets matching column = id, or returns nil
+// unless comped orders were asked to be reported separately
+func (s *Service) getCompedMetrics(ctx context.Context, column, id, status string) (*CompedMetrics, error) {
+	if compedMode(ctx) != CompedSeparate {
+		return nil, nil
+	}
+
+	rawSQL := "SELECT COUNT(DISTINCT o.order_id) AS total_orders, COUNT(t.ticket_id) AS total_tickets " +
+		"FROM orders o LEFT JOIN tickets t ON t.order_id = o.order_id " +
+		"WHERE o." + column + " = ? AND o.is_comped = TRUE"
+	args := []interface{}{id}
+	if status != "" {
+		rawSQL += " AND o.status = ?"
+		args = append(args, status)
+	}
+	rawSQL += archivedFilter(ctx, "o.archived")
+
+	var metrics CompedMetrics
+	if err := s.db.NewRaw(rawSQL, args...).Scan(ctx, &metrics.TotalOrders, &metrics.TotalTickets); err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
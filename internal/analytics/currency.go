@@ -0,0 +1,41 @@
+package analytics
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+)
+
+type currencyKey struct{}
+
+// WithCurrency returns a context under which analytics only count orders charged in the currency.
+// Amounts of different currencies can't be summed, so revenue is always reported for one currency;
+// without this the default currency is used.
+func WithCurrency(ctx context.Context, code string) context.Context {
+	return context.WithValue(ctx, currencyKey{}, code)
+}
+
+// Currency is the ISO 4217 code analytics under ctx report revenue in
+func Currency(ctx context.Context) string {
+	if code, ok := ctx.Value(currencyKey{}).(string); ok {
+		if _, supported := models.CurrencyExponent(code); supported {
+			return models.NormalizeCurrency(code)
+		}
+	}
+	return models.DefaultCurrency()
+}
+
+// currencyPredicate matches the orders charged in the analytics currency. column is the qualified
+// currency column, e.g. "o.currency"; orders stored without a currency are in the default one.
+// The code is inlined rather than bound, which is safe because Currency only returns supported codes.
+func currencyPredicate(ctx context.Context, column string) string {
+	code := Currency(ctx)
+	if code == models.DefaultCurrency() {
+		return "(" + column + " = '" + code + "' OR " + column + " IS NULL)"
+	}
+	return column + " = '" + code + "'"
+}
+
+// currencyFilter returns the predicate appended to raw order queries to keep a single currency
+func currencyFilter(ctx context.Context, column string) string {
+	return " AND " + currencyPredicate(ctx, column)
+}
@@ -0,0 +1,46 @@
+package analytics_test
+
+import (
+	"context"
+	"ms-ticketing/internal/analytics"
+	"ms-ticketing/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevenueIsReportedPerCurrency(t *testing.T) {
+	t.Setenv("CURRENCY", "")
+	bunDB := setupAnalyticsDB(t)
+	created := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	orders := []models.Order{
+		// Orders stored before currencies were recorded are in the default currency
+		{OrderID: "o1", EventID: "event1", SessionID: "s1", Status: "completed", Price: models.MoneyFromFloat(100), CreatedAt: created},
+		{OrderID: "o2", EventID: "event1", SessionID: "s1", Status: "completed", Currency: "LKR", Price: models.MoneyFromFloat(50), CreatedAt: created},
+		{OrderID: "o3", EventID: "event1", SessionID: "s1", Status: "completed", Currency: "USD", Price: models.MoneyFromFloat(20), CreatedAt: created},
+	}
+	_, err := bunDB.NewInsert().Model(&orders).Exec(context.Background())
+	require.NoError(t, err)
+	svc := analytics.NewService(bunDB)
+
+	result, err := svc.GetEventAnalytics(context.Background(), "event1", "")
+	require.NoError(t, err)
+	assert.Equal(t, "LKR", result.Currency)
+	assert.InDelta(t, 150, result.TotalRevenue, 0.001)
+	assert.Equal(t, 2, result.TotalOrders)
+
+	usd := analytics.WithCurrency(context.Background(), "USD")
+	result, err = svc.GetEventAnalytics(usd, "event1", "")
+	require.NoError(t, err)
+	assert.Equal(t, "USD", result.Currency)
+	assert.InDelta(t, 20, result.TotalRevenue, 0.001)
+	assert.Equal(t, 1, result.TotalOrders)
+
+	batch, err := svc.GetBatchEventAnalytics(usd, []string{"event1"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "USD", batch.Currency)
+	assert.InDelta(t, 20, batch.TotalRevenue, 0.001)
+}
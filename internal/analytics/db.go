@@ -3,7 +3,6 @@ package analytics
 import (
 	"context"
 	"ms-ticketing/internal/models"
-	"time"
 
 	"github.com/uptrace/bun"
 )
@@ -60,9 +59,9 @@ func (db *DB) GetTicketCountBySessionID(ctx context.Context, sessionID string) (
 
 // DailySalesData represents raw daily sales metrics from the database
 type DailySalesData struct {
-	SalesDate     time.Time `bun:"sales_date"`
-	DailyRevenue  float64   `bun:"daily_revenue"`
-	DailyQuantity int       `bun:"daily_quantity"`
+	SalesDate     string  `bun:"sales_date"`
+	DailyRevenue  float64 `bun:"daily_revenue"`
+	DailyQuantity int     `bun:"daily_quantity"`
 }
 
 // GetDailySalesByEventID retrieves daily sales metrics for an event
@@ -71,8 +70,8 @@ func (db *DB) GetDailySalesByEventID(ctx context.Context, eventID string) ([]Dai
 	// Use raw SQL to count tickets per day rather than orders
 	err := db.bun.NewRaw(`
 		SELECT 
-			DATE(o.created_at) AS sales_date,
-			SUM(o.price) AS daily_revenue,
+			`+dayExpr(db.bun, "o.created_at")+` AS sales_date,
+			SUM(o.price) / 100.0 AS daily_revenue,
 			COUNT(t.ticket_id) AS daily_quantity
 		FROM 
 			orders o
@@ -81,9 +80,9 @@ func (db *DB) GetDailySalesByEventID(ctx context.Context, eventID string) ([]Dai
 		WHERE 
 			o.event_id = ?
 		GROUP BY 
-			DATE(o.created_at)
+			`+dayExpr(db.bun, "o.created_at")+`
 		ORDER BY 
-			DATE(o.created_at)
+			sales_date
 	`, eventID).Scan(ctx, &dailySales)
 
 	return dailySales, err
@@ -95,8 +94,8 @@ func (db *DB) GetDailySalesBySessionID(ctx context.Context, sessionID string) ([
 	// Use raw SQL to count tickets per day rather than orders
 	err := db.bun.NewRaw(`
 		SELECT 
-			DATE(o.created_at) AS sales_date,
-			SUM(o.price) AS daily_revenue,
+			`+dayExpr(db.bun, "o.created_at")+` AS sales_date,
+			SUM(o.price) / 100.0 AS daily_revenue,
 			COUNT(t.ticket_id) AS daily_quantity
 		FROM 
 			orders o
@@ -105,9 +104,9 @@ func (db *DB) GetDailySalesBySessionID(ctx context.Context, sessionID string) ([
 		WHERE 
 			o.session_id = ?
 		GROUP BY 
-			DATE(o.created_at)
+			`+dayExpr(db.bun, "o.created_at")+`
 		ORDER BY 
-			DATE(o.created_at)
+			sales_date
 	`, sessionID).Scan(ctx, &dailySales)
 
 	return dailySales, err
@@ -115,24 +114,24 @@ func (db *DB) GetDailySalesBySessionID(ctx context.Context, sessionID string) ([
 
 // DiscountUsageData represents raw discount usage metrics from the database
 type DiscountUsageData struct {
-	UsageDate         time.Time `bun:"usage_date"`
-	DiscountCode      string    `bun:"discount_code"`
-	CodeUsageCount    int       `bun:"code_usage_count"`
-	DiscountAmountSum float64   `bun:"discount_amount_sum"`
+	UsageDate         string  `bun:"usage_date"`
+	DiscountCode      string  `bun:"discount_code"`
+	CodeUsageCount    int     `bun:"code_usage_count"`
+	DiscountAmountSum float64 `bun:"discount_amount_sum"`
 }
 
 // GetDiscountUsageByEventID retrieves discount usage metrics for an event
 func (db *DB) GetDiscountUsageByEventID(ctx context.Context, eventID string) ([]DiscountUsageData, error) {
 	var discountUsage []DiscountUsageData
 	err := db.bun.NewSelect().
-		ColumnExpr("DATE(orders.created_at) AS usage_date").
+		ColumnExpr(dayExpr(db.bun, "orders.created_at")+" AS usage_date").
 		ColumnExpr("orders.discount_code").
 		ColumnExpr("COUNT(*) AS code_usage_count").
-		ColumnExpr("SUM(orders.discount_amount) AS discount_amount_sum").
+		ColumnExpr("SUM(orders.discount_amount) / 100.0 AS discount_amount_sum").
 		TableExpr("orders").
 		Where("orders.event_id = ? AND orders.discount_code IS NOT NULL AND orders.discount_code != ''", eventID).
-		GroupExpr("DATE(orders.created_at), orders.discount_code").
-		OrderExpr("DATE(orders.created_at), orders.discount_code").
+		GroupExpr(dayExpr(db.bun, "orders.created_at")+", orders.discount_code").
+		OrderExpr("usage_date, orders.discount_code").
 		Scan(ctx, &discountUsage)
 
 	return discountUsage, err
@@ -0,0 +1,20 @@
+package analytics
+
+import (
+	"fmt"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+// dayExpr buckets a timestamp column by calendar day as 'YYYY-MM-DD' text.
+// DATE() returns a date on Postgres but text on SQLite, so each dialect formats the day itself
+// and callers scan the bucket into a string on both.
+func dayExpr(db bun.IDB, column string) string {
+	switch db.Dialect().Name() {
+	case dialect.SQLite:
+		return fmt.Sprintf("strftime('%%Y-%%m-%%d', %s)", column)
+	default:
+		return fmt.Sprintf("TO_CHAR(%s, 'YYYY-MM-DD')", column)
+	}
+}
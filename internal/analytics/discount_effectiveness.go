@@ -0,0 +1,117 @@
+package analytics
+
+import (
+	"context"
+	"sort"
+)
+
+// DiscountEffectiveness compares orders that used a discount code with those that didn't
+type DiscountEffectiveness struct {
+	OrdersWithDiscount       int                         `json:"orders_with_discount"`
+	OrdersWithoutDiscount    int                         `json:"orders_without_discount"`
+	AvgOrderValueWithCode    float64                     `json:"avg_order_value_with_discount"`
+	AvgOrderValueWithoutCode float64                     `json:"avg_order_value_without_discount"`
+	RevenueWithDiscount      float64                     `json:"revenue_with_discount"` // net revenue from discounted orders
+	GrossRevenue             float64                     `json:"gross_revenue"`         // all orders, before discounts
+	TotalDiscountAmount      float64                     `json:"total_discount_amount"`
+	DiscountPercentOfGross   float64                     `json:"discount_percent_of_gross"`
+	ByCode                   []DiscountCodeEffectiveness `json:"by_code"`
+}
+
+// DiscountCodeEffectiveness is the revenue impact of a single discount code
+type DiscountCodeEffectiveness struct {
+	DiscountCode           string  `json:"discount_code"`
+	Orders                 int     `json:"orders"`
+	Revenue                float64 `json:"revenue"`
+	GrossRevenue           float64 `json:"gross_revenue"`
+	TotalDiscountAmount    float64 `json:"total_discount_amount"`
+	AvgOrderValue          float64 `json:"avg_order_value"`
+	DiscountPercentOfGross float64 `json:"discount_percent_of_gross"`
+}
+
+// discountEffectivenessRaw is one aggregate row per discount code; orders without a code share the "" row
+type discountEffectivenessRaw struct {
+	DiscountCode  string  `bun:"discount_code"`
+	OrderCount    int     `bun:"order_count"`
+	NetRevenue    float64 `bun:"net_revenue"`
+	GrossRevenue  float64 `bun:"gross_revenue"`
+	DiscountTotal float64 `bun:"discount_total"`
+}
+
+// getDiscountEffectiveness aggregates an event's orders by discount code and derives the with/without comparison
+func (s *Service) getDiscountEffectiveness(ctx context.Context, eventID string, status string) (*DiscountEffectiveness, error) {
+	var rows []discountEffectivenessRaw
+	query := s.db.NewSelect().
+		ColumnExpr("COALESCE(orders.discount_code, '') AS discount_code").
+		ColumnExpr("COUNT(*) AS order_count").
+		ColumnExpr("COALESCE(SUM(orders.price), 0) / 100.0 AS net_revenue").
+		ColumnExpr("COALESCE(SUM(orders.subtotal), 0) / 100.0 AS gross_revenue").
+		ColumnExpr("COALESCE(SUM(orders.discount_amount), 0) / 100.0 AS discount_total").
+		TableExpr("orders").
+		Where("orders.event_id = ?", eventID)
+
+	if status != "" {
+		query = query.Where("orders.status = ?", status)
+	}
+	if !includeArchived(ctx) {
+		query = query.Where("orders.archived = ?", false)
+	}
+	query = query.Where(currencyPredicate(ctx, "orders.currency"))
+
+	err := query.
+		GroupExpr("COALESCE(orders.discount_code, '')").
+		Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return summarizeDiscountEffectiveness(rows), nil
+}
+
+func summarizeDiscountEffectiveness(rows []discountEffectivenessRaw) *DiscountEffectiveness {
+	result := &DiscountEffectiveness{ByCode: make([]DiscountCodeEffectiveness, 0, len(rows))}
+	var revenueWithout float64
+
+	for _, row := range rows {
+		result.GrossRevenue += row.GrossRevenue
+		if row.DiscountCode == "" {
+			result.OrdersWithoutDiscount += row.OrderCount
+			revenueWithout += row.NetRevenue
+			continue
+		}
+
+		result.OrdersWithDiscount += row.OrderCount
+		result.RevenueWithDiscount += row.NetRevenue
+		result.TotalDiscountAmount += row.DiscountTotal
+		result.ByCode = append(result.ByCode, DiscountCodeEffectiveness{
+			DiscountCode:           row.DiscountCode,
+			Orders:                 row.OrderCount,
+			Revenue:                row.NetRevenue,
+			GrossRevenue:           row.GrossRevenue,
+			TotalDiscountAmount:    row.DiscountTotal,
+			AvgOrderValue:          ratio(row.NetRevenue, float64(row.OrderCount)),
+			DiscountPercentOfGross: ratio(row.DiscountTotal, row.GrossRevenue) * 100,
+		})
+	}
+
+	result.AvgOrderValueWithCode = ratio(result.RevenueWithDiscount, float64(result.OrdersWithDiscount))
+	result.AvgOrderValueWithoutCode = ratio(revenueWithout, float64(result.OrdersWithoutDiscount))
+	result.DiscountPercentOfGross = ratio(result.TotalDiscountAmount, result.GrossRevenue) * 100
+
+	// Most used codes first
+	sort.Slice(result.ByCode, func(i, j int) bool {
+		if result.ByCode[i].Orders != result.ByCode[j].Orders {
+			return result.ByCode[i].Orders > result.ByCode[j].Orders
+		}
+		return result.ByCode[i].DiscountCode < result.ByCode[j].DiscountCode
+	})
+	return result
+}
+
+// ratio divides, reporting zero instead of NaN when there is nothing to divide by
+func ratio(numerator, denominator float64) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return numerator / denominator
+}
@@ -0,0 +1,80 @@
+package analytics_test
+
+import (
+	"context"
+	"database/sql"
+	"ms-ticketing/internal/analytics"
+	"ms-ticketing/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	_ "github.com/uptrace/bun/driver/sqliteshim"
+)
+
+func setupAnalyticsDB(t *testing.T) *bun.DB {
+	sqldb, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to connect to in-memory database: %v", err)
+	}
+	bunDB := bun.NewDB(sqldb, sqlitedialect.New())
+	bunDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { bunDB.Close() })
+
+	for _, model := range []interface{}{(*models.Order)(nil), (*models.Ticket)(nil)} {
+		if _, err := bunDB.NewCreateTable().Model(model).Exec(context.Background()); err != nil {
+			t.Fatalf("Failed to create table: %v", err)
+		}
+	}
+	return bunDB
+}
+
+func TestGetEventDiscountAnalyticsEffectiveness(t *testing.T) {
+	bunDB := setupAnalyticsDB(t)
+	now := time.Now()
+	order := func(id, code string, subtotal, discount float64, status string) models.Order {
+		return models.Order{
+			OrderID:        id,
+			EventID:        "event1",
+			Status:         status,
+			DiscountCode:   code,
+			SubTotal:       models.MoneyFromFloat(subtotal),
+			DiscountAmount: models.MoneyFromFloat(discount),
+			Price:          models.MoneyFromFloat(subtotal - discount),
+			CreatedAt:      now,
+		}
+	}
+	orders := []models.Order{
+		order("o1", "SAVE10", 1000, 100, "completed"),
+		order("o2", "SAVE10", 3000, 300, "completed"),
+		order("o3", "VIP", 5000, 1000, "completed"),
+		order("o4", "", 2000, 0, "completed"),
+		order("o5", "", 4000, 0, "completed"),
+		order("o6", "SAVE10", 9999, 999, "cancelled"),
+	}
+	_, err := bunDB.NewInsert().Model(&orders).Exec(context.Background())
+	assert.NoError(t, err)
+
+	result, err := analytics.NewService(bunDB).GetEventDiscountAnalytics(context.Background(), "event1", "completed")
+	assert.NoError(t, err)
+
+	e := result.Effectiveness
+	assert.Equal(t, 3, e.OrdersWithDiscount)
+	assert.Equal(t, 2, e.OrdersWithoutDiscount)
+	assert.InDelta(t, 2533.33, e.AvgOrderValueWithCode, 0.01) // (900 + 2700 + 4000) / 3
+	assert.InDelta(t, 3000.0, e.AvgOrderValueWithoutCode, 0.001)
+	assert.InDelta(t, 7600.0, e.RevenueWithDiscount, 0.001)
+	assert.InDelta(t, 15000.0, e.GrossRevenue, 0.001)
+	assert.InDelta(t, 1400.0, e.TotalDiscountAmount, 0.001)
+	assert.InDelta(t, 9.333, e.DiscountPercentOfGross, 0.001)
+
+	if assert.Len(t, e.ByCode, 2) {
+		assert.Equal(t, "SAVE10", e.ByCode[0].DiscountCode)
+		assert.Equal(t, 2, e.ByCode[0].Orders)
+		assert.InDelta(t, 1800.0, e.ByCode[0].AvgOrderValue, 0.001)
+		assert.InDelta(t, 10.0, e.ByCode[0].DiscountPercentOfGross, 0.001)
+		assert.Equal(t, "VIP", e.ByCode[1].DiscountCode)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"ms-ticketing/internal/models"
 	"strings"
+	"time"
 
 	"github.com/uptrace/bun"
 )
@@ -16,8 +17,16 @@ const (
 	OrderSortByCreatedAt OrderSortField = "created_at"
 )
 
+// EventOrdersPage is one page of an event's orders with the number of orders matching the filters
+type EventOrdersPage struct {
+	Orders []models.OrderWithTickets `json:"orders"`
+	Total  int                       `json:"total"`
+	Limit  int                       `json:"limit"`
+	Offset int                       `json:"offset"`
+}
+
 // GetEventOrders returns orders for a specific event with optional filters
-func (s *Service) GetEventOrders(ctx context.Context, eventID string, options EventOrderOptions) ([]models.OrderWithTickets, error) {
+func (s *Service) GetEventOrders(ctx context.Context, eventID string, options EventOrderOptions) (*EventOrdersPage, error) {
 	// Start with base query for orders by event_id
 	q := s.db.NewSelect().
 		Model((*models.Order)(nil)).
@@ -33,6 +42,34 @@ func (s *Service) GetEventOrders(ctx context.Context, eventID string, options Ev
 		q = q.Where("status = ?", options.Status)
 	}
 
+	// Apply created_at range if provided; From is inclusive, To exclusive
+	if !options.From.IsZero() {
+		q = q.Where("created_at >= ?", options.From)
+	}
+	if !options.To.IsZero() {
+		q = q.Where("created_at < ?", options.To)
+	}
+
+	// Match the search text anywhere in the order or user ID, ignoring case
+	if search := strings.TrimSpace(options.Search); search != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(search)) + "%"
+		q = q.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("LOWER(CAST(order_id AS TEXT)) LIKE ? ESCAPE '\\'", pattern).
+				WhereOr("LOWER(CAST(user_id AS TEXT)) LIKE ? ESCAPE '\\'", pattern)
+		})
+	}
+
+	if !includeArchived(ctx) {
+		q = q.Where("archived = ?", false)
+	}
+
+	// Count every match before sorting and pagination are applied
+	total, err := q.Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	page := &EventOrdersPage{Orders: []models.OrderWithTickets{}, Total: total, Limit: options.Limit, Offset: options.Offset}
+
 	// Apply sorting
 	if options.SortBy != "" {
 		direction := "ASC"
@@ -65,13 +102,13 @@ func (s *Service) GetEventOrders(ctx context.Context, eventID string, options Ev
 
 	// Execute the query
 	var orders []models.Order
-	err := q.Scan(ctx, &orders)
+	err = q.Scan(ctx, &orders)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(orders) == 0 {
-		return []models.OrderWithTickets{}, nil
+		return page, nil
 	}
 
 	// Collect all order IDs to fetch tickets
@@ -97,22 +134,28 @@ func (s *Service) GetEventOrders(ctx context.Context, eventID string, options Ev
 	}
 
 	// Combine orders with their tickets
-	result := make([]models.OrderWithTickets, len(orders))
+	page.Orders = make([]models.OrderWithTickets, len(orders))
 	for i, order := range orders {
 		orderWithTickets := models.OrderWithTickets{
 			Order:   order,
 			Tickets: ticketsByOrderID[order.OrderID],
 		}
-		result[i] = orderWithTickets
+		page.Orders[i] = orderWithTickets
 	}
 
-	return result, nil
+	return page, nil
 }
 
+// likeEscaper escapes LIKE wildcards so search text matches literally
+var likeEscaper = strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_")
+
 // EventOrderOptions contains options for filtering and sorting orders
 type EventOrderOptions struct {
 	SessionID string
 	Status    string
+	From      time.Time // Orders created at or after this time
+	To        time.Time // Orders created before this time
+	Search    string    // Substring of the order ID or user ID
 	SortBy    string
 	SortDesc  bool
 	Limit     int
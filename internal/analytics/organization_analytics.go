@@ -2,12 +2,13 @@ package analytics
 
 import (
 	"context"
-	"time"
 )
 
 // OrganizationAnalytics represents aggregated analytics data for all events in an organization
 type OrganizationAnalytics struct {
 	OrganizationID   string              `json:"organization_id"`
+	EventIDs         []string            `json:"event_ids"`
+	Currency         string              `json:"currency"`
 	TotalRevenue     float64             `json:"total_revenue"`
 	TotalBeforeDisc  float64             `json:"total_before_discounts"`
 	TotalTicketsSold int                 `json:"total_tickets_sold"`
@@ -15,187 +16,56 @@ type OrganizationAnalytics struct {
 	SalesByTier      []TierSalesMetrics  `json:"sales_by_tier"`
 }
 
-// GetOrganizationAnalytics returns revenue analytics for all events in an organization
-func (s *Service) GetOrganizationAnalytics(ctx context.Context, organizationID string, status string) (*OrganizationAnalytics, error) {
-	// Query orders by organization_id field and optionally by status
-	var orders []struct {
-		TotalRevenue    float64 `bun:"total_revenue"`
-		TotalBeforeDisc float64 `bun:"total_before_disc"`
-	}
-
-	rawSQL := `
-		SELECT 
-			SUM(price) AS total_revenue,
-			SUM(subtotal) AS total_before_disc
-		FROM 
-			orders
-		WHERE 
-			organization_id = ?`
-
-	args := []interface{}{organizationID}
-
-	if status != "" {
-		rawSQL += " AND status = ?"
-		args = append(args, status)
+// GetOrganizationEventIDs returns the distinct events the organization has sold orders for
+func (s *Service) GetOrganizationEventIDs(ctx context.Context, organizationID string) ([]string, error) {
+	var eventIDs []string
+	q := s.db.NewSelect().
+		TableExpr("orders").
+		ColumnExpr("DISTINCT event_id").
+		Where("organization_id = ?", organizationID).
+		Where("event_id IS NOT NULL")
+
+	if !includeArchived(ctx) {
+		q = q.Where("archived = ?", false)
 	}
 
-	err := s.db.NewRaw(rawSQL, args...).Scan(ctx, &orders)
+	err := q.OrderExpr("event_id").Scan(ctx, &eventIDs)
 	if err != nil {
 		return nil, err
 	}
+	return eventIDs, nil
+}
 
-	// Count tickets for this organization
-	var ticketCount int
-	rawSQL = `
-		SELECT 
-			COUNT(*)
-		FROM 
-			tickets t
-		JOIN 
-			orders o ON t.order_id = o.order_id
-		WHERE 
-			o.organization_id = ?`
-
-	ticketArgs := []interface{}{organizationID}
-
-	if status != "" {
-		rawSQL += " AND o.status = ?"
-		ticketArgs = append(ticketArgs, status)
-	}
-
-	err = s.db.NewRaw(rawSQL, ticketArgs...).Scan(ctx, &ticketCount)
-	if err != nil {
-		return nil, err
-	}
-
-	// Get daily sales metrics
-	type dailySalesRaw struct {
-		SalesDate     time.Time `bun:"sales_date"`
-		DailyRevenue  float64   `bun:"daily_revenue"`
-		DailyQuantity int       `bun:"tickets_sold_on_date"`
-	}
-
-	var dailySales []dailySalesRaw
-	rawSQL = `
-		SELECT
-			DATE(o.created_at) AS sales_date,
-			SUM(o.price) AS daily_revenue,
-			COALESCE(SUM(ticket_count), 0) AS tickets_sold_on_date
-		FROM (
-			SELECT
-				order_id,
-				price,
-				status,
-				created_at
-			FROM orders
-			WHERE
-				organization_id = ?`
-
-	dailyArgs := []interface{}{organizationID}
-
-	if status != "" {
-		rawSQL += " AND status = ?"
-		dailyArgs = append(dailyArgs, status)
-	}
-
-	rawSQL += `
-		) o
-		LEFT JOIN (
-			SELECT
-				order_id,
-				COUNT(ticket_id) AS ticket_count
-			FROM tickets
-			GROUP BY order_id
-		) t ON t.order_id = o.order_id
-		GROUP BY
-			DATE(o.created_at)
-		ORDER BY
-			sales_date
-	`
-
-	err = s.db.NewRaw(rawSQL, dailyArgs...).Scan(ctx, &dailySales)
+// GetOrganizationAnalytics returns revenue analytics for all events in an organization.
+// The organization's events are resolved from its orders and aggregated with the batch logic.
+func (s *Service) GetOrganizationAnalytics(ctx context.Context, organizationID string, status string) (*OrganizationAnalytics, error) {
+	eventIDs, err := s.GetOrganizationEventIDs(ctx, organizationID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get sales by tier
-	type tierSalesRaw struct {
-		TierID      string  `bun:"tier_id"`
-		TierName    string  `bun:"tier_name"`
-		TierColor   string  `bun:"tier_color"`
-		TicketCount int     `bun:"ticket_count"`
-		TierRevenue float64 `bun:"tier_revenue"`
-	}
-
-	var tierSales []tierSalesRaw
-	rawSQL = `
-		SELECT 
-			t.tier_id,
-			t.tier_name,
-			t.colour AS tier_color,
-			COUNT(t.ticket_id) AS ticket_count,
-			SUM(t.price_at_purchase) AS tier_revenue
-		FROM 
-			tickets t
-		JOIN 
-			orders o ON t.order_id = o.order_id
-		WHERE 
-			o.organization_id = ?`
-
-	tierArgs := []interface{}{organizationID}
-
-	if status != "" {
-		rawSQL += " AND o.status = ?"
-		tierArgs = append(tierArgs, status)
-	}
-
-	rawSQL += `
-		GROUP BY 
-			t.tier_id, t.tier_name, t.colour
-		ORDER BY 
-			t.tier_name
-	`
-
-	err = s.db.NewRaw(rawSQL, tierArgs...).Scan(ctx, &tierSales)
+	batch, err := s.GetBatchEventAnalytics(ctx, eventIDs, status)
 	if err != nil {
 		return nil, err
 	}
 
-	// Calculate total values from the orders query
-	var totalRevenue float64
-	var totalBeforeDisc float64
-
-	if len(orders) > 0 {
-		totalRevenue = orders[0].TotalRevenue
-		totalBeforeDisc = orders[0].TotalBeforeDisc
-	}
-
-	// Format results
 	result := &OrganizationAnalytics{
 		OrganizationID:   organizationID,
-		TotalRevenue:     totalRevenue,
-		TotalBeforeDisc:  totalBeforeDisc,
-		TotalTicketsSold: ticketCount,
-		DailySales:       make([]DailySalesMetrics, 0, len(dailySales)),
-		SalesByTier:      make([]TierSalesMetrics, 0, len(tierSales)),
+		EventIDs:         batch.EventIDs,
+		Currency:         batch.Currency,
+		TotalRevenue:     batch.TotalRevenue,
+		TotalBeforeDisc:  batch.TotalBeforeDisc,
+		TotalTicketsSold: batch.TotalTicketsSold,
+		DailySales:       batch.DailySales,
+		SalesByTier:      batch.SalesByTier,
 	}
 
-	for _, ds := range dailySales {
-		result.DailySales = append(result.DailySales, DailySalesMetrics{
-			Date:        ds.SalesDate.Format("2006-01-02"),
-			Revenue:     ds.DailyRevenue,
-			TicketsSold: ds.DailyQuantity,
-		})
+	// Keep the JSON shape stable for organizations without any orders yet
+	if result.DailySales == nil {
+		result.DailySales = []DailySalesMetrics{}
 	}
-
-	for _, ts := range tierSales {
-		result.SalesByTier = append(result.SalesByTier, TierSalesMetrics{
-			TierID:      ts.TierID,
-			TierName:    ts.TierName,
-			TierColor:   ts.TierColor,
-			TicketsSold: ts.TicketCount,
-			Revenue:     ts.TierRevenue,
-		})
+	if result.SalesByTier == nil {
+		result.SalesByTier = []TierSalesMetrics{}
 	}
 
 	return result, nil
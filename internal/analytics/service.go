@@ -3,12 +3,13 @@ package analytics
 import (
 	"context"
 	"ms-ticketing/internal/models"
-	"time"
 
 	"github.com/uptrace/bun"
 )
 
-// Service handles analytics operations
+// Service handles analytics operations.
+// Order amounts are stored in minor units; SQL aggregates divide by 100.0 only after
+// summing, so totals are exact while the API keeps reporting decimal amounts.
 type Service struct {
 	db *bun.DB
 }
@@ -20,18 +21,25 @@ func NewService(db *bun.DB) *Service {
 
 // EventAnalytics represents aggregated analytics data for an event
 type EventAnalytics struct {
-	EventID          string              `json:"event_id"`
-	TotalRevenue     float64             `json:"total_revenue"`
-	TotalBeforeDisc  float64             `json:"total_before_discounts"`
-	TotalTicketsSold int                 `json:"total_tickets_sold"`
-	DailySales       []DailySalesMetrics `json:"daily_sales"`
-	SalesByTier      []TierSalesMetrics  `json:"sales_by_tier"`
+	EventID           string              `json:"event_id"`
+	Currency          string              `json:"currency"` // Every amount is in this ISO 4217 currency
+	TotalRevenue      float64             `json:"total_revenue"`
+	TotalBeforeDisc   float64             `json:"total_before_discounts"`
+	TotalTicketsSold  int                 `json:"total_tickets_sold"`
+	TotalOrders       int                 `json:"total_orders"`
+	AverageOrderValue float64             `json:"average_order_value"`
+	CancellationRate  float64             `json:"cancellation_rate"` // cancelled / (completed + cancelled)
+	DailySales        []DailySalesMetrics `json:"daily_sales"`
+	SalesByTier       []TierSalesMetrics  `json:"sales_by_tier"`
+	Comped            *CompedMetrics      `json:"comped,omitempty"` // Only set when comped orders are reported separately
 }
 
 // EventDiscountAnalytics represents discount usage data for an event
 type EventDiscountAnalytics struct {
-	EventID       string          `json:"event_id"`
-	DiscountUsage []DiscountUsage `json:"discount_usage"`
+	EventID       string                 `json:"event_id"`
+	Currency      string                 `json:"currency"`
+	DiscountUsage []DiscountUsage        `json:"discount_usage"`
+	Effectiveness *DiscountEffectiveness `json:"effectiveness"`
 }
 
 // TierSalesMetrics contains sales metrics for a specific tier
@@ -47,11 +55,13 @@ type TierSalesMetrics struct {
 type SessionAnalytics struct {
 	EventID          string              `json:"event_id"`
 	SessionID        string              `json:"session_id"`
+	Currency         string              `json:"currency"`
 	TotalRevenue     float64             `json:"total_revenue"`
 	TotalBeforeDisc  float64             `json:"total_before_discounts"`
 	TotalTicketsSold int                 `json:"total_tickets_sold"`
 	DailySales       []DailySalesMetrics `json:"daily_sales"`
 	SalesByTier      []TierSalesMetrics  `json:"sales_by_tier"`
+	Comped           *CompedMetrics      `json:"comped,omitempty"` // Only set when comped orders are reported separately
 }
 
 // SessionSummary contains basic revenue information for a session
@@ -65,6 +75,7 @@ type SessionSummary struct {
 // EventSessionsAnalytics represents all sessions summary for an event
 type EventSessionsAnalytics struct {
 	EventID  string           `json:"event_id"`
+	Currency string           `json:"currency"`
 	Sessions []SessionSummary `json:"sessions"`
 }
 
@@ -94,6 +105,13 @@ func (s *Service) GetEventAnalytics(ctx context.Context, eventID string, status
 	if status != "" {
 		query = query.Where("status = ?", status)
 	}
+	if !includeArchived(ctx) {
+		query = query.Where("archived = ?", false)
+	}
+	if !includeComped(ctx) {
+		query = query.Where("is_comped = ?", false)
+	}
+	query = query.Where(currencyPredicate(ctx, "currency"))
 
 	err := query.Scan(ctx)
 	if err != nil {
@@ -109,6 +127,7 @@ func (s *Service) GetEventAnalytics(ctx context.Context, eventID string, status
 		rawSQL += " AND o.status = ?"
 		args = append(args, status)
 	}
+	rawSQL += archivedFilter(ctx, "o.archived") + compedFilter(ctx, "o.is_comped") + currencyFilter(ctx, "o.currency")
 
 	err = s.db.NewRaw(rawSQL, args...).
 		Scan(ctx, &ticketCount)
@@ -118,17 +137,17 @@ func (s *Service) GetEventAnalytics(ctx context.Context, eventID string, status
 
 	// Get daily sales
 	type dailySalesRaw struct {
-		SalesDate     time.Time `bun:"sales_date"`
-		DailyRevenue  float64   `bun:"daily_revenue"`
-		DailyQuantity int       `bun:"tickets_sold_on_date"`
+		SalesDate     string  `bun:"sales_date"`
+		DailyRevenue  float64 `bun:"daily_revenue"`
+		DailyQuantity int     `bun:"tickets_sold_on_date"`
 	}
 
 	var dailySales []dailySalesRaw
 	// Use raw SQL to count tickets per day with proper status filtering
 	rawSQL = `
 		SELECT
-			DATE(o.created_at) AS sales_date,
-			SUM(o.price) AS daily_revenue,
+			` + dayExpr(s.db, "o.created_at") + ` AS sales_date,
+			SUM(o.price) / 100.0 AS daily_revenue,
 			COALESCE(SUM(ticket_count), 0) AS tickets_sold_on_date
 		FROM (
 			SELECT
@@ -147,6 +166,7 @@ func (s *Service) GetEventAnalytics(ctx context.Context, eventID string, status
 		rawSQL += " AND status = ?"
 		args = append(args, status)
 	}
+	rawSQL += archivedFilter(ctx, "archived") + compedFilter(ctx, "is_comped") + currencyFilter(ctx, "currency")
 
 	rawSQL += `
 		) o
@@ -158,7 +178,7 @@ func (s *Service) GetEventAnalytics(ctx context.Context, eventID string, status
 			GROUP BY order_id
 		) t ON t.order_id = o.order_id
 		GROUP BY
-			DATE(o.created_at)
+			` + dayExpr(s.db, "o.created_at") + `
 		ORDER BY
 			sales_date
 	`
@@ -168,9 +188,9 @@ func (s *Service) GetEventAnalytics(ctx context.Context, eventID string, status
 		return nil, err
 	}
 
-	// Calculate total revenue and subtotal (before discounts)
-	var totalRevenue float64
-	var totalBeforeDisc float64
+	// Calculate total revenue and subtotal (before discounts) in minor units
+	var totalRevenue models.Money
+	var totalBeforeDisc models.Money
 	for _, order := range orders {
 		totalRevenue += order.Price
 		totalBeforeDisc += order.SubTotal
@@ -206,6 +226,7 @@ func (s *Service) GetEventAnalytics(ctx context.Context, eventID string, status
 		rawSQL += " AND o.status = ?"
 		args = append(args, status)
 	}
+	rawSQL += archivedFilter(ctx, "o.archived") + compedFilter(ctx, "o.is_comped") + currencyFilter(ctx, "o.currency")
 
 	rawSQL += `
 		GROUP BY 
@@ -219,19 +240,39 @@ func (s *Service) GetEventAnalytics(ctx context.Context, eventID string, status
 		return nil, err
 	}
 
+	cancellationRate, err := s.getCancellationRate(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	comped, err := s.getCompedMetrics(ctx, "event_id", eventID, status)
+	if err != nil {
+		return nil, err
+	}
+
+	var averageOrderValue float64
+	if len(orders) > 0 {
+		averageOrderValue = totalRevenue.Float64() / float64(len(orders))
+	}
+
 	// Format results
 	result := &EventAnalytics{
-		EventID:          eventID,
-		TotalRevenue:     totalRevenue,
-		TotalBeforeDisc:  totalBeforeDisc,
-		TotalTicketsSold: ticketCount,
-		DailySales:       make([]DailySalesMetrics, 0, len(dailySales)),
-		SalesByTier:      make([]TierSalesMetrics, 0, len(tierSales)),
+		EventID:           eventID,
+		Currency:          Currency(ctx),
+		TotalRevenue:      totalRevenue.Float64(),
+		TotalBeforeDisc:   totalBeforeDisc.Float64(),
+		TotalTicketsSold:  ticketCount,
+		TotalOrders:       len(orders),
+		AverageOrderValue: averageOrderValue,
+		CancellationRate:  cancellationRate,
+		DailySales:        make([]DailySalesMetrics, 0, len(dailySales)),
+		SalesByTier:       make([]TierSalesMetrics, 0, len(tierSales)),
+		Comped:            comped,
 	}
 
 	for _, ds := range dailySales {
 		result.DailySales = append(result.DailySales, DailySalesMetrics{
-			Date:        ds.SalesDate.Format("2006-01-02"),
+			Date:        ds.SalesDate,
 			Revenue:     ds.DailyRevenue,
 			TicketsSold: ds.DailyQuantity,
 		})
@@ -250,33 +291,70 @@ func (s *Service) GetEventAnalytics(ctx context.Context, eventID string, status
 	return result, nil
 }
 
+// getCancellationRate returns the share of finished orders of an event that were cancelled.
+// It looks at both statuses itself since the analytics endpoints only pass "completed".
+func (s *Service) getCancellationRate(ctx context.Context, eventID string) (float64, error) {
+	var counts []struct {
+		Status string `bun:"status"`
+		Count  int    `bun:"order_count"`
+	}
+	rawSQL := "SELECT status, COUNT(*) AS order_count FROM orders WHERE event_id = ? AND status IN (?, ?)" +
+		archivedFilter(ctx, "archived") + compedFilter(ctx, "is_comped") + currencyFilter(ctx, "currency") + " GROUP BY status"
+	err := s.db.NewRaw(rawSQL, eventID, "completed", "cancelled").Scan(ctx, &counts)
+	if err != nil {
+		return 0, err
+	}
+
+	var completed, cancelled int
+	for _, c := range counts {
+		switch c.Status {
+		case "completed":
+			completed = c.Count
+		case "cancelled":
+			cancelled = c.Count
+		}
+	}
+
+	if completed+cancelled == 0 {
+		return 0, nil
+	}
+	return float64(cancelled) / float64(completed+cancelled), nil
+}
+
 // GetEventDiscountAnalytics returns discount usage analytics for a specific event
 func (s *Service) GetEventDiscountAnalytics(ctx context.Context, eventID string, status string) (*EventDiscountAnalytics, error) {
 	// Query orders directly by event_id field
 	// Get discount usage
 	type discountUsageRaw struct {
-		UsageDate         time.Time `bun:"usage_date"`
-		DiscountCode      string    `bun:"discount_code"`
-		CodeUsageCount    int       `bun:"code_usage_count"`
-		DiscountAmountSum float64   `bun:"discount_amount_sum"`
+		UsageDate         string  `bun:"usage_date"`
+		DiscountCode      string  `bun:"discount_code"`
+		CodeUsageCount    int     `bun:"code_usage_count"`
+		DiscountAmountSum float64 `bun:"discount_amount_sum"`
 	}
 
 	var discountUsage []discountUsageRaw
 	query := s.db.NewSelect().
-		ColumnExpr("DATE(orders.created_at) AS usage_date").
+		ColumnExpr(dayExpr(s.db, "orders.created_at")+" AS usage_date").
 		ColumnExpr("orders.discount_code").
 		ColumnExpr("COUNT(*) AS code_usage_count").
-		ColumnExpr("SUM(orders.discount_amount) AS discount_amount_sum").
+		ColumnExpr("SUM(orders.discount_amount) / 100.0 AS discount_amount_sum").
 		TableExpr("orders").
 		Where("orders.event_id = ? AND orders.discount_code IS NOT NULL AND orders.discount_code != ''", eventID)
 
 	if status != "" {
 		query = query.Where("orders.status = ?", status)
 	}
+	if !includeArchived(ctx) {
+		query = query.Where("orders.archived = ?", false)
+	}
+	if !includeComped(ctx) {
+		query = query.Where("orders.is_comped = ?", false)
+	}
+	query = query.Where(currencyPredicate(ctx, "orders.currency"))
 
 	err := query.
-		GroupExpr("DATE(orders.created_at), orders.discount_code").
-		OrderExpr("DATE(orders.created_at), orders.discount_code").
+		GroupExpr(dayExpr(s.db, "orders.created_at")+", orders.discount_code").
+		OrderExpr("usage_date, orders.discount_code").
 		Scan(ctx, &discountUsage)
 	if err != nil {
 		return nil, err
@@ -285,18 +363,25 @@ func (s *Service) GetEventDiscountAnalytics(ctx context.Context, eventID string,
 	// Format results
 	result := &EventDiscountAnalytics{
 		EventID:       eventID,
+		Currency:      Currency(ctx),
 		DiscountUsage: make([]DiscountUsage, 0, len(discountUsage)),
 	}
 
 	for _, du := range discountUsage {
 		result.DiscountUsage = append(result.DiscountUsage, DiscountUsage{
-			Date:          du.UsageDate.Format("2006-01-02"),
+			Date:          du.UsageDate,
 			DiscountCode:  du.DiscountCode,
 			UsageCount:    du.CodeUsageCount,
 			TotalDiscount: du.DiscountAmountSum,
 		})
 	}
 
+	// Revenue impact compares discounted orders with the rest of the event's orders
+	result.Effectiveness, err = s.getDiscountEffectiveness(ctx, eventID, status)
+	if err != nil {
+		return nil, err
+	}
+
 	return result, nil
 }
 
@@ -317,8 +402,8 @@ func (s *Service) GetEventSessionsAnalytics(ctx context.Context, eventID string,
             -- First, calculate the total revenue and subtotal from the orders table
             SELECT
                 session_id,
-                SUM(price) AS total_revenue,
-                SUM(subtotal) AS total_before_disc
+                SUM(price) / 100.0 AS total_revenue,
+                SUM(subtotal) / 100.0 AS total_before_disc
             FROM
                 orders
             WHERE
@@ -330,6 +415,7 @@ func (s *Service) GetEventSessionsAnalytics(ctx context.Context, eventID string,
 		rawSQL += " AND status = ?"
 		args = append(args, status)
 	}
+	rawSQL += archivedFilter(ctx, "archived") + compedFilter(ctx, "is_comped") + currencyFilter(ctx, "currency")
 
 	rawSQL += `
             GROUP BY
@@ -353,6 +439,7 @@ func (s *Service) GetEventSessionsAnalytics(ctx context.Context, eventID string,
 		rawSQL += " AND o.status = ?"
 		args = append(args, status)
 	}
+	rawSQL += archivedFilter(ctx, "o.archived") + compedFilter(ctx, "o.is_comped") + currencyFilter(ctx, "o.currency")
 
 	rawSQL += `
             GROUP BY
@@ -381,6 +468,7 @@ func (s *Service) GetEventSessionsAnalytics(ctx context.Context, eventID string,
 	// Format results into the final structure
 	result := &EventSessionsAnalytics{
 		EventID:  eventID,
+		Currency: Currency(ctx),
 		Sessions: make([]SessionSummary, 0, len(sessionSummaries)),
 	}
 
@@ -402,6 +490,13 @@ func (s *Service) GetSessionAnalytics(ctx context.Context, eventID, sessionID st
 	if status != "" {
 		query = query.Where("status = ?", status)
 	}
+	if !includeArchived(ctx) {
+		query = query.Where("archived = ?", false)
+	}
+	if !includeComped(ctx) {
+		query = query.Where("is_comped = ?", false)
+	}
+	query = query.Where(currencyPredicate(ctx, "currency"))
 
 	var err error
 	err = query.Scan(ctx)
@@ -418,6 +513,7 @@ func (s *Service) GetSessionAnalytics(ctx context.Context, eventID, sessionID st
 		rawSQL += " AND o.status = ?"
 		args = append(args, status)
 	}
+	rawSQL += archivedFilter(ctx, "o.archived") + compedFilter(ctx, "o.is_comped") + currencyFilter(ctx, "o.currency")
 
 	err = s.db.NewRaw(rawSQL, args...).
 		Scan(ctx, &ticketCount)
@@ -427,17 +523,17 @@ func (s *Service) GetSessionAnalytics(ctx context.Context, eventID, sessionID st
 
 	// Get daily sales
 	type dailySalesRaw struct {
-		SalesDate     time.Time `bun:"sales_date"`
-		DailyRevenue  float64   `bun:"daily_revenue"`
-		DailyQuantity int       `bun:"tickets_sold_on_date"`
+		SalesDate     string  `bun:"sales_date"`
+		DailyRevenue  float64 `bun:"daily_revenue"`
+		DailyQuantity int     `bun:"tickets_sold_on_date"`
 	}
 
 	var dailySales []dailySalesRaw
 	// Use raw SQL to count tickets per day with proper status filtering
 	rawSQL = `
 		SELECT
-			DATE(o.created_at) AS sales_date,
-			SUM(o.price) AS daily_revenue,
+			` + dayExpr(s.db, "o.created_at") + ` AS sales_date,
+			SUM(o.price) / 100.0 AS daily_revenue,
 			COALESCE(SUM(ticket_count), 0) AS tickets_sold_on_date
 		FROM (
 			SELECT
@@ -456,6 +552,7 @@ func (s *Service) GetSessionAnalytics(ctx context.Context, eventID, sessionID st
 		rawSQL += " AND status = ?"
 		args = append(args, status)
 	}
+	rawSQL += archivedFilter(ctx, "archived") + compedFilter(ctx, "is_comped") + currencyFilter(ctx, "currency")
 
 	rawSQL += `
 		) o
@@ -467,7 +564,7 @@ func (s *Service) GetSessionAnalytics(ctx context.Context, eventID, sessionID st
 			GROUP BY order_id
 		) t ON t.order_id = o.order_id
 		GROUP BY
-			DATE(o.created_at)
+			` + dayExpr(s.db, "o.created_at") + `
 		ORDER BY
 			sales_date
 	`
@@ -477,9 +574,9 @@ func (s *Service) GetSessionAnalytics(ctx context.Context, eventID, sessionID st
 		return nil, err
 	}
 
-	// Calculate total revenue and subtotal (before discounts)
-	var totalRevenue float64
-	var totalBeforeDisc float64
+	// Calculate total revenue and subtotal (before discounts) in minor units
+	var totalRevenue models.Money
+	var totalBeforeDisc models.Money
 	for _, order := range orders {
 		totalRevenue += order.Price
 		totalBeforeDisc += order.SubTotal
@@ -515,6 +612,7 @@ func (s *Service) GetSessionAnalytics(ctx context.Context, eventID, sessionID st
 		rawSQL += " AND o.status = ?"
 		args = append(args, status)
 	}
+	rawSQL += archivedFilter(ctx, "o.archived") + compedFilter(ctx, "o.is_comped") + currencyFilter(ctx, "o.currency")
 
 	rawSQL += `
 		GROUP BY 
@@ -528,20 +626,27 @@ func (s *Service) GetSessionAnalytics(ctx context.Context, eventID, sessionID st
 		return nil, err
 	}
 
+	comped, err := s.getCompedMetrics(ctx, "session_id", sessionID, status)
+	if err != nil {
+		return nil, err
+	}
+
 	// Format results
 	result := &SessionAnalytics{
 		EventID:          eventID,
 		SessionID:        sessionID,
-		TotalRevenue:     totalRevenue,
-		TotalBeforeDisc:  totalBeforeDisc,
+		Currency:         Currency(ctx),
+		TotalRevenue:     totalRevenue.Float64(),
+		TotalBeforeDisc:  totalBeforeDisc.Float64(),
 		TotalTicketsSold: ticketCount,
 		DailySales:       make([]DailySalesMetrics, 0, len(dailySales)),
 		SalesByTier:      make([]TierSalesMetrics, 0, len(tierSales)),
+		Comped:           comped,
 	}
 
 	for _, ds := range dailySales {
 		result.DailySales = append(result.DailySales, DailySalesMetrics{
-			Date:        ds.SalesDate.Format("2006-01-02"),
+			Date:        ds.SalesDate,
 			Revenue:     ds.DailyRevenue,
 			TicketsSold: ds.DailyQuantity,
 		})
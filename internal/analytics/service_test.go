@@ -0,0 +1,247 @@
+package analytics_test
+
+import (
+	"context"
+	"ms-ticketing/internal/analytics"
+	"ms-ticketing/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+// seedEventSales inserts two days of orders for event1 across two sessions, with one ticket per seat
+func seedEventSales(t *testing.T, bunDB *bun.DB) {
+	day1 := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	day2 := time.Date(2025, 3, 2, 23, 30, 0, 0, time.UTC)
+	orders := []models.Order{
+		{OrderID: "o1", EventID: "event1", SessionID: "s1", Status: "completed", SubTotal: models.MoneyFromFloat(100), Price: models.MoneyFromFloat(90), DiscountCode: "SAVE10", DiscountAmount: models.MoneyFromFloat(10), CreatedAt: day1},
+		{OrderID: "o2", EventID: "event1", SessionID: "s1", Status: "completed", SubTotal: models.MoneyFromFloat(50), Price: models.MoneyFromFloat(50), CreatedAt: day1.Add(2 * time.Hour)},
+		{OrderID: "o3", EventID: "event1", SessionID: "s2", Status: "completed", SubTotal: models.MoneyFromFloat(75), Price: models.MoneyFromFloat(75), CreatedAt: day2},
+		{OrderID: "o4", EventID: "event1", SessionID: "s2", Status: "cancelled", SubTotal: models.MoneyFromFloat(40), Price: models.MoneyFromFloat(40), CreatedAt: day2},
+		{OrderID: "o6", EventID: "event2", SessionID: "s9", Status: "completed", SubTotal: models.MoneyFromFloat(10), Price: models.MoneyFromFloat(10), CreatedAt: day1},
+	}
+	_, err := bunDB.NewInsert().Model(&orders).Exec(context.Background())
+	require.NoError(t, err)
+
+	// Inserted on its own: on SQLite bun drops default-valued columns from multi-row inserts
+	archived := models.Order{OrderID: "o5", EventID: "event1", SessionID: "s1", Status: "completed", SubTotal: models.MoneyFromFloat(999), Price: models.MoneyFromFloat(999), CreatedAt: day1, Archived: true}
+	_, err = bunDB.NewInsert().Model(&archived).Exec(context.Background())
+	require.NoError(t, err)
+
+	ticket := func(id, orderID, tier string, price float64) models.Ticket {
+		return models.Ticket{TicketID: id, OrderID: orderID, SeatID: "seat-" + id, TierID: tier, TierName: tier, Colour: "#fff", PriceAtPurchase: price, IssuedAt: day1}
+	}
+	tickets := []models.Ticket{
+		ticket("t1", "o1", "VIP", 50),
+		ticket("t2", "o1", "VIP", 50),
+		ticket("t3", "o2", "GA", 50),
+		ticket("t4", "o3", "GA", 75),
+		ticket("t5", "o4", "GA", 40),
+		ticket("t6", "o5", "VIP", 999),
+	}
+	_, err = bunDB.NewInsert().Model(&tickets).Exec(context.Background())
+	require.NoError(t, err)
+}
+
+func TestGetEventAnalytics(t *testing.T) {
+	bunDB := setupAnalyticsDB(t)
+	seedEventSales(t, bunDB)
+
+	result, err := analytics.NewService(bunDB).GetEventAnalytics(context.Background(), "event1", "completed")
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.TotalOrders)
+	assert.Equal(t, 4, result.TotalTicketsSold)
+	assert.InDelta(t, 215, result.TotalRevenue, 0.001)
+	assert.InDelta(t, 225, result.TotalBeforeDisc, 0.001)
+	assert.InDelta(t, 0.25, result.CancellationRate, 0.001)
+
+	// Orders are bucketed by calendar day, archived ones left out
+	assert.Equal(t, []analytics.DailySalesMetrics{
+		{Date: "2025-03-01", Revenue: 140, TicketsSold: 3},
+		{Date: "2025-03-02", Revenue: 75, TicketsSold: 1},
+	}, result.DailySales)
+
+	require.Len(t, result.SalesByTier, 2)
+	assert.Equal(t, "GA", result.SalesByTier[0].TierName)
+	assert.Equal(t, 2, result.SalesByTier[0].TicketsSold)
+	assert.Equal(t, "VIP", result.SalesByTier[1].TierName)
+	assert.InDelta(t, 100, result.SalesByTier[1].Revenue, 0.001)
+
+	// Archived orders come back when asked for
+	withArchived, err := analytics.NewService(bunDB).GetEventAnalytics(analytics.WithArchived(context.Background()), "event1", "completed")
+	require.NoError(t, err)
+	assert.Equal(t, 4, withArchived.TotalOrders)
+	assert.Equal(t, 4, withArchived.DailySales[0].TicketsSold)
+}
+
+func TestGetSessionAndDiscountAnalyticsDailyBuckets(t *testing.T) {
+	bunDB := setupAnalyticsDB(t)
+	seedEventSales(t, bunDB)
+	svc := analytics.NewService(bunDB)
+
+	session, err := svc.GetSessionAnalytics(context.Background(), "event1", "s1", "completed")
+	require.NoError(t, err)
+	assert.Equal(t, []analytics.DailySalesMetrics{{Date: "2025-03-01", Revenue: 140, TicketsSold: 3}}, session.DailySales)
+
+	discounts, err := svc.GetEventDiscountAnalytics(context.Background(), "event1", "completed")
+	require.NoError(t, err)
+	assert.Equal(t, []analytics.DiscountUsage{{Date: "2025-03-01", DiscountCode: "SAVE10", UsageCount: 1, TotalDiscount: 10}}, discounts.DiscountUsage)
+
+	sessions, err := svc.GetEventSessionsAnalytics(context.Background(), "event1", "completed")
+	require.NoError(t, err)
+	assert.Len(t, sessions.Sessions, 2)
+
+	batch, err := svc.GetBatchEventAnalytics(context.Background(), []string{"event1", "event2"}, "completed")
+	require.NoError(t, err)
+	require.Len(t, batch.DailySales, 2)
+	assert.Equal(t, "2025-03-01", batch.DailySales[0].Date)
+	assert.InDelta(t, 150, batch.DailySales[0].Revenue, 0.001)
+}
+
+func TestGetEventAnalyticsReportsCompedOrdersSeparately(t *testing.T) {
+	bunDB := setupAnalyticsDB(t)
+	seedEventSales(t, bunDB)
+
+	comped := models.Order{OrderID: "o7", EventID: "event1", SessionID: "s1", Status: "completed", CreatedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC), IsComped: true}
+	_, err := bunDB.NewInsert().Model(&comped).Exec(context.Background())
+	require.NoError(t, err)
+	compTicket := models.Ticket{TicketID: "t7", OrderID: "o7", SeatID: "seat-t7", TierID: "VIP", TierName: "VIP", Colour: "#fff", IssuedAt: comped.CreatedAt}
+	_, err = bunDB.NewInsert().Model(&compTicket).Exec(context.Background())
+	require.NoError(t, err)
+
+	service := analytics.NewService(bunDB)
+
+	// By default comps stay out of the sales figures and get their own bucket
+	result, err := service.GetEventAnalytics(context.Background(), "event1", "completed")
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.TotalOrders)
+	assert.Equal(t, 4, result.TotalTicketsSold)
+	assert.Equal(t, &analytics.CompedMetrics{TotalOrders: 1, TotalTickets: 1}, result.Comped)
+
+	session, err := service.GetSessionAnalytics(context.Background(), "event1", "s1", "completed")
+	require.NoError(t, err)
+	assert.Equal(t, &analytics.CompedMetrics{TotalOrders: 1, TotalTickets: 1}, session.Comped)
+
+	included, err := service.GetEventAnalytics(analytics.WithCompedMode(context.Background(), analytics.CompedInclude), "event1", "completed")
+	require.NoError(t, err)
+	assert.Equal(t, 4, included.TotalOrders)
+	assert.Equal(t, 5, included.TotalTicketsSold)
+	assert.Nil(t, included.Comped)
+
+	excluded, err := service.GetEventAnalytics(analytics.WithCompedMode(context.Background(), analytics.CompedExclude), "event1", "completed")
+	require.NoError(t, err)
+	assert.Equal(t, 3, excluded.TotalOrders)
+	assert.Nil(t, excluded.Comped)
+}
+
+func TestParseCompedMode(t *testing.T) {
+	mode, err := analytics.ParseCompedMode("")
+	require.NoError(t, err)
+	assert.Equal(t, analytics.CompedSeparate, mode)
+
+	mode, err = analytics.ParseCompedMode("exclude")
+	require.NoError(t, err)
+	assert.Equal(t, analytics.CompedExclude, mode)
+
+	_, err = analytics.ParseCompedMode("sometimes")
+	assert.Error(t, err)
+}
+
+func TestGetEventOrdersDateRangeAndSearch(t *testing.T) {
+	bunDB := setupAnalyticsDB(t)
+	seedEventSales(t, bunDB)
+	_, err := bunDB.NewUpdate().Model((*models.Order)(nil)).Set("user_id = ?", "Alice-42").Where("order_id = ?", "o3").Exec(context.Background())
+	require.NoError(t, err)
+
+	service := analytics.NewService(bunDB)
+	orderIDs := func(options analytics.EventOrderOptions) []string {
+		page, err := service.GetEventOrders(context.Background(), "event1", options)
+		require.NoError(t, err)
+		ids := make([]string, 0, len(page.Orders))
+		for _, o := range page.Orders {
+			ids = append(ids, o.OrderID)
+		}
+		return ids
+	}
+
+	// Existing options keep working on their own
+	assert.ElementsMatch(t, []string{"o1", "o2", "o3", "o4"}, orderIDs(analytics.EventOrderOptions{}))
+
+	day2 := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+	assert.ElementsMatch(t, []string{"o3", "o4"}, orderIDs(analytics.EventOrderOptions{From: day2}))
+	assert.ElementsMatch(t, []string{"o1", "o2"}, orderIDs(analytics.EventOrderOptions{To: day2}))
+	assert.Equal(t, []string{"o3"}, orderIDs(analytics.EventOrderOptions{From: day2, Status: "completed"}))
+
+	// Search matches order and user IDs case-insensitively, and wildcards are literal
+	assert.Equal(t, []string{"o3"}, orderIDs(analytics.EventOrderOptions{Search: "alice"}))
+	assert.Equal(t, []string{"o2"}, orderIDs(analytics.EventOrderOptions{Search: "O2"}))
+	assert.Empty(t, orderIDs(analytics.EventOrderOptions{Search: "%"}))
+}
+
+func TestGetEventOrdersReportsTotalAcrossPages(t *testing.T) {
+	bunDB := setupAnalyticsDB(t)
+	seedEventSales(t, bunDB)
+
+	page, err := analytics.NewService(bunDB).GetEventOrders(context.Background(), "event1", analytics.EventOrderOptions{Status: "completed", Limit: 2, Offset: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, page.Total)
+	assert.Equal(t, 2, page.Limit)
+	assert.Equal(t, 2, page.Offset)
+	assert.Len(t, page.Orders, 1)
+
+	// Past the last page the total is still reported
+	page, err = analytics.NewService(bunDB).GetEventOrders(context.Background(), "event1", analytics.EventOrderOptions{Limit: 2, Offset: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 4, page.Total)
+	assert.Empty(t, page.Orders)
+}
+
+func TestGetEventAnalyticsCancellationRate(t *testing.T) {
+	bunDB := setupAnalyticsDB(t)
+	created := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	insert := func(order models.Order) {
+		order.EventID = "event1"
+		order.CreatedAt = created
+		_, err := bunDB.NewInsert().Model(&order).Exec(context.Background())
+		require.NoError(t, err)
+	}
+	svc := analytics.NewService(bunDB)
+
+	// Only pending orders: nothing has finished, so nothing was cancelled
+	insert(models.Order{OrderID: "p1", Status: "pending", Price: models.MoneyFromFloat(10)})
+	result, err := svc.GetEventAnalytics(context.Background(), "event1", "completed")
+	require.NoError(t, err)
+	assert.Zero(t, result.CancellationRate)
+	assert.Zero(t, result.TotalOrders)
+	assert.Zero(t, result.AverageOrderValue)
+
+	insert(models.Order{OrderID: "c1", Status: "completed", Price: models.MoneyFromFloat(30)})
+	insert(models.Order{OrderID: "c2", Status: "completed", Price: models.MoneyFromFloat(50)})
+	insert(models.Order{OrderID: "c3", Status: "completed", Price: models.MoneyFromFloat(10)})
+	insert(models.Order{OrderID: "x1", Status: "cancelled", Price: models.MoneyFromFloat(20)})
+	insert(models.Order{OrderID: "x2", Status: "cancelled", Price: models.MoneyFromFloat(20), Archived: true})
+	insert(models.Order{OrderID: "x3", Status: "cancelled", IsComped: true})
+
+	// 1 of 4 finished orders; the rate ignores the status filter, archived orders and comps
+	result, err = svc.GetEventAnalytics(context.Background(), "event1", "completed")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.25, result.CancellationRate, 0.001)
+	assert.Equal(t, 3, result.TotalOrders)
+	assert.InDelta(t, 30, result.AverageOrderValue, 0.001)
+
+	result, err = svc.GetEventAnalytics(context.Background(), "event1", "")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.25, result.CancellationRate, 0.001)
+
+	result, err = svc.GetEventAnalytics(analytics.WithArchived(context.Background()), "event1", "completed")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.4, result.CancellationRate, 0.001)
+
+	result, err = svc.GetEventAnalytics(analytics.WithCompedMode(context.Background(), analytics.CompedInclude), "event1", "completed")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.4, result.CancellationRate, 0.001)
+}
@@ -0,0 +1,115 @@
+package analytics
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// MetricDelta is the difference between two values, B relative to A
+type MetricDelta struct {
+	Absolute float64 `json:"absolute"`
+	// Percent is nil when A is zero and the change has no meaningful percentage
+	Percent *float64 `json:"percent"`
+}
+
+// TierComparison contains the side by side sales of one tier in both sessions
+type TierComparison struct {
+	TierID       string      `json:"tier_id"`
+	TierName     string      `json:"tier_name"`
+	TierColor    string      `json:"tier_color"`
+	TicketsSoldA int         `json:"tickets_sold_a"`
+	TicketsSoldB int         `json:"tickets_sold_b"`
+	RevenueA     float64     `json:"revenue_a"`
+	RevenueB     float64     `json:"revenue_b"`
+	TicketsDelta MetricDelta `json:"tickets_sold_delta"`
+	RevenueDelta MetricDelta `json:"revenue_delta"`
+}
+
+// SessionComparison compares the analytics of two sessions of the same event
+type SessionComparison struct {
+	EventID      string            `json:"event_id"`
+	Currency     string            `json:"currency"`
+	SessionA     *SessionAnalytics `json:"session_a"`
+	SessionB     *SessionAnalytics `json:"session_b"`
+	RevenueDelta MetricDelta       `json:"revenue_delta"`
+	TicketsDelta MetricDelta       `json:"tickets_sold_delta"`
+	SalesByTier  []TierComparison  `json:"sales_by_tier"`
+}
+
+// CompareSessions returns analytics for two sessions along with the deltas of B against A
+func (s *Service) CompareSessions(ctx context.Context, eventID, sessionA, sessionB string, status string) (*SessionComparison, error) {
+	a, err := s.GetSessionAnalytics(ctx, eventID, sessionA, status)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.GetSessionAnalytics(ctx, eventID, sessionB, status)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionComparison{
+		EventID:      eventID,
+		Currency:     Currency(ctx),
+		SessionA:     a,
+		SessionB:     b,
+		RevenueDelta: newMetricDelta(a.TotalRevenue, b.TotalRevenue),
+		TicketsDelta: newMetricDelta(float64(a.TotalTicketsSold), float64(b.TotalTicketsSold)),
+		SalesByTier:  compareTiers(a.SalesByTier, b.SalesByTier),
+	}, nil
+}
+
+// compareTiers merges both tier breakdowns so tiers sold in only one session still show up
+func compareTiers(a, b []TierSalesMetrics) []TierComparison {
+	byTier := make(map[string]*TierComparison)
+	var order []string
+
+	entry := func(tier TierSalesMetrics) *TierComparison {
+		tc, ok := byTier[tier.TierID]
+		if !ok {
+			tc = &TierComparison{TierID: tier.TierID, TierName: tier.TierName, TierColor: tier.TierColor}
+			byTier[tier.TierID] = tc
+			order = append(order, tier.TierID)
+		}
+		return tc
+	}
+
+	for _, tier := range a {
+		tc := entry(tier)
+		tc.TicketsSoldA = tier.TicketsSold
+		tc.RevenueA = tier.Revenue
+	}
+	for _, tier := range b {
+		tc := entry(tier)
+		tc.TicketsSoldB = tier.TicketsSold
+		tc.RevenueB = tier.Revenue
+	}
+
+	result := make([]TierComparison, 0, len(order))
+	for _, id := range order {
+		tc := byTier[id]
+		tc.TicketsDelta = newMetricDelta(float64(tc.TicketsSoldA), float64(tc.TicketsSoldB))
+		tc.RevenueDelta = newMetricDelta(tc.RevenueA, tc.RevenueB)
+		result = append(result, *tc)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].TierName < result[j].TierName
+	})
+
+	return result
+}
+
+func newMetricDelta(a, b float64) MetricDelta {
+	delta := MetricDelta{Absolute: roundTo2(b - a)}
+	if a != 0 {
+		percent := roundTo2((b - a) / a * 100)
+		delta.Percent = &percent
+	}
+	return delta
+}
+
+func roundTo2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
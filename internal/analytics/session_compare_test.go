@@ -0,0 +1,76 @@
+package analytics_test
+
+import (
+	"context"
+	"ms-ticketing/internal/analytics"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareSessionsDeltas(t *testing.T) {
+	bunDB := setupAnalyticsDB(t)
+	seedEventSales(t, bunDB)
+
+	// s1: VIP 2 tickets / 100, GA 1 ticket / 50. s2: GA 1 ticket / 75
+	result, err := analytics.NewService(bunDB).CompareSessions(context.Background(), "event1", "s1", "s2", "completed")
+	require.NoError(t, err)
+
+	assert.Equal(t, "event1", result.EventID)
+	assert.InDelta(t, -65, result.RevenueDelta.Absolute, 0.001)
+	require.NotNil(t, result.RevenueDelta.Percent)
+	assert.InDelta(t, -46.43, *result.RevenueDelta.Percent, 0.001)
+	assert.InDelta(t, -2, result.TicketsDelta.Absolute, 0.001)
+	require.NotNil(t, result.TicketsDelta.Percent)
+	assert.InDelta(t, -66.67, *result.TicketsDelta.Percent, 0.001)
+
+	// Tiers sold in only one session are merged in, sorted by name
+	require.Len(t, result.SalesByTier, 2)
+	ga, vip := result.SalesByTier[0], result.SalesByTier[1]
+	assert.Equal(t, "GA", ga.TierName)
+	assert.Equal(t, 1, ga.TicketsSoldA)
+	assert.Equal(t, 1, ga.TicketsSoldB)
+	assert.InDelta(t, 25, ga.RevenueDelta.Absolute, 0.001)
+	require.NotNil(t, ga.RevenueDelta.Percent)
+	assert.InDelta(t, 50, *ga.RevenueDelta.Percent, 0.001)
+
+	assert.Equal(t, "VIP", vip.TierName)
+	assert.Equal(t, "#fff", vip.TierColor)
+	assert.Equal(t, 2, vip.TicketsSoldA)
+	assert.Zero(t, vip.TicketsSoldB)
+	assert.InDelta(t, 100, vip.RevenueA, 0.001)
+	assert.Zero(t, vip.RevenueB)
+	require.NotNil(t, vip.TicketsDelta.Percent)
+	assert.InDelta(t, -100, *vip.TicketsDelta.Percent, 0.001)
+}
+
+func TestCompareSessionsWithoutBaseHasNoPercent(t *testing.T) {
+	bunDB := setupAnalyticsDB(t)
+	seedEventSales(t, bunDB)
+	svc := analytics.NewService(bunDB)
+
+	// VIP only sold in B, so its change against nothing has no percentage
+	result, err := svc.CompareSessions(context.Background(), "event1", "s2", "s1", "completed")
+	require.NoError(t, err)
+	require.Len(t, result.SalesByTier, 2)
+	vip := result.SalesByTier[1]
+	assert.Equal(t, "VIP", vip.TierName)
+	assert.Zero(t, vip.TicketsSoldA)
+	assert.Equal(t, 2, vip.TicketsSoldB)
+	assert.InDelta(t, 2, vip.TicketsDelta.Absolute, 0.001)
+	assert.Nil(t, vip.TicketsDelta.Percent)
+	assert.InDelta(t, 100, vip.RevenueDelta.Absolute, 0.001)
+	assert.Nil(t, vip.RevenueDelta.Percent)
+
+	// A session without sales as the base leaves the totals without a percentage too
+	result, err = svc.CompareSessions(context.Background(), "event1", "no-sales", "s2", "completed")
+	require.NoError(t, err)
+	assert.InDelta(t, 75, result.RevenueDelta.Absolute, 0.001)
+	assert.Nil(t, result.RevenueDelta.Percent)
+	assert.Nil(t, result.TicketsDelta.Percent)
+	require.Len(t, result.SalesByTier, 1)
+	assert.Equal(t, "GA", result.SalesByTier[0].TierName)
+	assert.Zero(t, result.SalesByTier[0].TicketsSoldA)
+	assert.Nil(t, result.SalesByTier[0].TicketsDelta.Percent)
+}
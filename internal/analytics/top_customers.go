@@ -0,0 +1,66 @@
+package analytics
+
+import "context"
+
+const (
+	// DefaultTopCustomersLimit is used when no limit is requested
+	DefaultTopCustomersLimit = 10
+	// MaxTopCustomersLimit caps how many customers a single request can list
+	MaxTopCustomersLimit = 100
+)
+
+// TopCustomer is a user's total spend on an event. Only the user ID is returned;
+// resolving names or emails is left to the user service.
+type TopCustomer struct {
+	UserID      string  `json:"user_id"`
+	TotalSpent  float64 `json:"total_spent"`
+	Currency    string  `json:"currency"`
+	OrderCount  int     `json:"order_count"`
+	TicketCount int     `json:"ticket_count"`
+}
+
+// GetTopCustomers returns the users that spent the most on completed orders of an event
+func (s *Service) GetTopCustomers(ctx context.Context, eventID string, limit int) ([]TopCustomer, error) {
+	if limit <= 0 {
+		limit = DefaultTopCustomersLimit
+	}
+	if limit > MaxTopCustomersLimit {
+		limit = MaxTopCustomersLimit
+	}
+
+	// Tickets are counted per order first so joining them does not multiply the order price
+	rawSQL := `
+		SELECT
+			o.user_id,
+			SUM(o.price) / 100.0 AS total_spent,
+			COUNT(o.order_id) AS order_count,
+			COALESCE(SUM(t.ticket_count), 0) AS ticket_count
+		FROM orders o
+		LEFT JOIN (
+			SELECT
+				order_id,
+				COUNT(ticket_id) AS ticket_count
+			FROM tickets
+			GROUP BY order_id
+		) t ON t.order_id = o.order_id
+		WHERE
+			o.event_id = ?
+			AND o.status = ?` + archivedFilter(ctx, "o.archived") + compedFilter(ctx, "o.is_comped") + currencyFilter(ctx, "o.currency") + `
+		GROUP BY
+			o.user_id
+		ORDER BY
+			total_spent DESC,
+			o.user_id
+		LIMIT ?`
+
+	customers := []TopCustomer{}
+	err := s.db.NewRaw(rawSQL, eventID, "completed", limit).Scan(ctx, &customers)
+	if err != nil {
+		return nil, err
+	}
+	for i := range customers {
+		customers[i].Currency = Currency(ctx)
+	}
+
+	return customers, nil
+}
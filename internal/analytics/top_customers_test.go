@@ -0,0 +1,82 @@
+package analytics_test
+
+import (
+	"context"
+	"fmt"
+	"ms-ticketing/internal/analytics"
+	"ms-ticketing/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTopCustomers(t *testing.T) {
+	bunDB := setupAnalyticsDB(t)
+	created := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	orders := []models.Order{
+		{OrderID: "o1", UserID: "alice", EventID: "event1", Status: "completed", Price: models.MoneyFromFloat(19.99), CreatedAt: created},
+		{OrderID: "o2", UserID: "alice", EventID: "event1", Status: "completed", Price: models.MoneyFromFloat(30.01), CreatedAt: created},
+		{OrderID: "o3", UserID: "bob", EventID: "event1", Status: "completed", Price: models.MoneyFromFloat(75.50), CreatedAt: created},
+		// Pending orders and other events don't count
+		{OrderID: "o4", UserID: "carol", EventID: "event1", Status: "pending", Price: models.MoneyFromFloat(500), CreatedAt: created},
+		{OrderID: "o5", UserID: "alice", EventID: "event2", Status: "completed", Price: models.MoneyFromFloat(500), CreatedAt: created},
+	}
+	_, err := bunDB.NewInsert().Model(&orders).Exec(context.Background())
+	require.NoError(t, err)
+
+	tickets := []models.Ticket{
+		{TicketID: "t1", OrderID: "o1", SeatID: "seat-1", IssuedAt: created},
+		{TicketID: "t2", OrderID: "o1", SeatID: "seat-2", IssuedAt: created},
+		{TicketID: "t3", OrderID: "o2", SeatID: "seat-3", IssuedAt: created},
+		{TicketID: "t4", OrderID: "o3", SeatID: "seat-4", IssuedAt: created},
+	}
+	_, err = bunDB.NewInsert().Model(&tickets).Exec(context.Background())
+	require.NoError(t, err)
+
+	customers, err := analytics.NewService(bunDB).GetTopCustomers(context.Background(), "event1", 0)
+	require.NoError(t, err)
+
+	// Prices are stored in minor units and reported in major units; two tickets don't double the order price
+	assert.Equal(t, []analytics.TopCustomer{
+		{UserID: "bob", TotalSpent: 75.5, Currency: "LKR", OrderCount: 1, TicketCount: 1},
+		{UserID: "alice", TotalSpent: 50, Currency: "LKR", OrderCount: 2, TicketCount: 3},
+	}, customers)
+}
+
+func TestGetTopCustomersLimit(t *testing.T) {
+	bunDB := setupAnalyticsDB(t)
+	created := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	orders := make([]models.Order, 0, analytics.MaxTopCustomersLimit+5)
+	for i := 0; i < analytics.MaxTopCustomersLimit+5; i++ {
+		orders = append(orders, models.Order{
+			OrderID:   fmt.Sprintf("o%03d", i),
+			UserID:    fmt.Sprintf("user%03d", i),
+			EventID:   "event1",
+			Status:    "completed",
+			Price:     models.Money(100 + i),
+			CreatedAt: created,
+		})
+	}
+	_, err := bunDB.NewInsert().Model(&orders).Exec(context.Background())
+	require.NoError(t, err)
+	svc := analytics.NewService(bunDB)
+
+	customers, err := svc.GetTopCustomers(context.Background(), "event1", 3)
+	require.NoError(t, err)
+	require.Len(t, customers, 3)
+	assert.Equal(t, "user104", customers[0].UserID)
+	assert.InDelta(t, 2.04, customers[0].TotalSpent, 0.0001)
+
+	customers, err = svc.GetTopCustomers(context.Background(), "event1", 0)
+	require.NoError(t, err)
+	assert.Len(t, customers, analytics.DefaultTopCustomersLimit)
+
+	// Requests above the cap get the cap
+	customers, err = svc.GetTopCustomers(context.Background(), "event1", 1000)
+	require.NoError(t, err)
+	assert.Len(t, customers, analytics.MaxTopCustomersLimit)
+}
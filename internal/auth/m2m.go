@@ -17,7 +17,7 @@ import (
 
 // GetM2MToken retrieves a machine-to-machine token from Keycloak
 // If redisClient is provided, it will try to get the token from Redis cache first
-func GetM2MToken(cfg models.Config, client *http.Client, redisClient *redis.Client, logger *logger.Logger) (string, error) {
+func GetM2MToken(cfg models.Config, client *http.Client, redisClient redis.UniversalClient, logger *logger.Logger) (string, error) {
 	// Use standard logger if custom logger is not provided
 	logInfo := log.Printf
 	logError := log.Printf
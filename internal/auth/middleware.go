@@ -12,7 +12,10 @@ import (
 
 type contextKey string
 
-const userIDKey contextKey = "user_id"
+const (
+	userIDKey contextKey = "user_id"
+	rolesKey  contextKey = "roles"
+)
 
 func Middleware() func(http.Handler) http.Handler {
 	issuer := os.Getenv("OIDC_ISSUER") // e.g. http://auth.ticketly.com:8080/realms/event-ticketing
@@ -54,22 +57,30 @@ func Middleware() func(http.Handler) http.Handler {
 				return
 			}
 
-			// Extract claims (we only need sub for now)
+			// Extract claims: the subject and Keycloak realm roles
 			var claims struct {
-				Sub string `json:"sub"`
+				Sub         string `json:"sub"`
+				RealmAccess struct {
+					Roles []string `json:"roles"`
+				} `json:"realm_access"`
 			}
 			if err := idToken.Claims(&claims); err != nil {
 				http.Error(w, "failed to parse claims", http.StatusUnauthorized)
 				return
 			}
 
-			// Add user ID into context
-			ctx := context.WithValue(r.Context(), userIDKey, claims.Sub)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			// Add user ID and roles into context
+			next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), claims.Sub, claims.RealmAccess.Roles...)))
 		})
 	}
 }
 
+// WithUser returns a context carrying the authenticated user and their realm roles, as Middleware sets them
+func WithUser(ctx context.Context, userID string, roles ...string) context.Context {
+	ctx = context.WithValue(ctx, userIDKey, userID)
+	return context.WithValue(ctx, rolesKey, roles)
+}
+
 // Helper to extract user ID in handlers
 func UserID(ctx context.Context) string {
 	if uid, ok := ctx.Value(userIDKey).(string); ok {
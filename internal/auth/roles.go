@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"os"
+)
+
+// AdminRole returns the realm role that grants access to admin endpoints (ADMIN_ROLE, default "admin")
+func AdminRole() string {
+	if role := os.Getenv("ADMIN_ROLE"); role != "" {
+		return role
+	}
+	return "admin"
+}
+
+// SupportRole returns the realm role that grants access to support tooling such as order notes (SUPPORT_ROLE, default "support")
+func SupportRole() string {
+	if role := os.Getenv("SUPPORT_ROLE"); role != "" {
+		return role
+	}
+	return "support"
+}
+
+// OrganizerRole returns the realm role of event organizers, who may hold seats back from sale (ORGANIZER_ROLE, default "organizer")
+func OrganizerRole() string {
+	if role := os.Getenv("ORGANIZER_ROLE"); role != "" {
+		return role
+	}
+	return "organizer"
+}
+
+// Roles returns the realm roles of the authenticated user
+func Roles(ctx context.Context) []string {
+	if roles, ok := ctx.Value(rolesKey).([]string); ok {
+		return roles
+	}
+	return nil
+}
+
+// HasRole reports whether the authenticated user holds the given realm role
+func HasRole(ctx context.Context, role string) bool {
+	for _, r := range Roles(ctx) {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole rejects requests whose token lacks the given realm role.
+// It must run after Middleware, which puts the roles into the context.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return RequireAnyRole(role)
+}
+
+// RequireAnyRole rejects requests whose token holds none of the given realm roles
+func RequireAnyRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, role := range roles {
+				if HasRole(r.Context(), role) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			http.Error(w, "forbidden: missing required role", http.StatusForbidden)
+		})
+	}
+}
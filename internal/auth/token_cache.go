@@ -33,11 +33,11 @@ func (tc *TokenCache) IsValid() bool {
 
 // RedisTokenCache implements token caching using Redis
 type RedisTokenCache struct {
-	Client *redis.Client
+	Client redis.UniversalClient
 }
 
 // NewRedisTokenCache creates a new Redis token cache
-func NewRedisTokenCache(client *redis.Client) *RedisTokenCache {
+func NewRedisTokenCache(client redis.UniversalClient) *RedisTokenCache {
 	return &RedisTokenCache{
 		Client: client,
 	}
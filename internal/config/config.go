@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,13 +14,40 @@ type Config struct {
 	Kafka               KafkaConfig
 	Database            DatabaseConfig // Added database configuration
 	EventSeatingService EventSeatingServiceConfig
+	EventQueryService   EventQueryServiceConfig
 	Auth                AuthConfig
+	CORS                CORSConfig
+	HTTPClient          HTTPClientConfig
+}
+
+// CORSConfig controls which browser origins may call the API
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int // Seconds browsers may cache a preflight response
+}
+
+// AllowsOrigin reports whether a browser origin is on the allowlist
+func (c CORSConfig) AllowsOrigin(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
 }
 
 type EventSeatingServiceConfig struct {
 	URL string
 }
 
+type EventQueryServiceConfig struct {
+	URL string
+}
+
 type AuthConfig struct {
 	KeycloakURL   string
 	KeycloakRealm string
@@ -35,7 +63,18 @@ type ServerConfig struct {
 }
 
 type RedisConfig struct {
-	Addr string
+	Mode                  string   // standalone, sentinel or cluster
+	Addr                  string   // Standalone server address
+	SentinelMaster        string   // Master name monitored by Sentinel
+	SentinelAddrs         []string // Sentinel addresses
+	SentinelPassword      string
+	ClusterAddrs          []string // Seed nodes of a cluster
+	Username              string
+	Password              string
+	DB                    int // Database index; keyspace notifications are subscribed on the same one
+	TLS                   bool
+	TLSServerName         string // Overrides the name checked against the server certificate
+	TLSInsecureSkipVerify bool   // Only for local testing against self-signed certificates
 }
 type KafkaConfig struct {
 	Brokers  []string
@@ -88,7 +127,18 @@ func Load() *Config {
 			SMTPPassword: getEnv("SMTP_PASSWORD", "yotp eehv mcnq osnh"),
 		},
 		Redis: RedisConfig{
-			Addr: getEnv("REDIS_ADDR", "localhost:6379"),
+			Mode:                  getEnv("REDIS_MODE", RedisModeStandalone),
+			Addr:                  getEnv("REDIS_ADDR", "localhost:6379"),
+			SentinelMaster:        getEnv("REDIS_SENTINEL_MASTER", ""),
+			SentinelAddrs:         getEnvList("REDIS_SENTINEL_ADDRS", nil),
+			SentinelPassword:      getEnv("REDIS_SENTINEL_PASSWORD", ""),
+			ClusterAddrs:          getEnvList("REDIS_CLUSTER_ADDRS", nil),
+			Username:              getEnv("REDIS_USERNAME", ""),
+			Password:              getEnv("REDIS_PASSWORD", ""),
+			DB:                    getEnvInt("REDIS_DB", 0),
+			TLS:                   getEnvBool("REDIS_TLS", false),
+			TLSServerName:         getEnv("REDIS_TLS_SERVER_NAME", ""),
+			TLSInsecureSkipVerify: getEnvBool("REDIS_TLS_INSECURE_SKIP_VERIFY", false),
 		},
 
 		Database: DatabaseConfig{
@@ -102,7 +152,10 @@ func Load() *Config {
 			MaxLifetime:  time.Duration(getEnvInt("DB_MAX_LIFETIME_MINUTES", 5)) * time.Minute,
 		},
 		EventSeatingService: EventSeatingServiceConfig{
-			URL: getEnv("EVENT_SEATING_SERVICE_URL", "http://localhost:8081"),
+			URL: getEnv("EVENT_SEATING_SERVICE_URL", ""),
+		},
+		EventQueryService: EventQueryServiceConfig{
+			URL: getEnv("EVENT_QUERY_SERVICE_URL", ""),
 		},
 		Auth: AuthConfig{
 			KeycloakURL:   getEnv("KEYCLOAK_URL", "http://localhost:8080"),
@@ -110,6 +163,25 @@ func Load() *Config {
 			ClientID:      getEnv("TICKET_CLIENT_ID", "ticket-service-client"),
 			ClientSecret:  getEnv("TICKET_CLIENT_SECRET", "KEXBfroAvRIVp6fi2svsQeKKjZTu4wnu"),
 		},
+		CORS: CORSConfig{
+			AllowedOrigins:   getEnvList("CORS_ALLOWED_ORIGINS", []string{"http://localhost:8090", "http://ticketly.test:8090", "http://www.localhost:8090", "https://ticketly.dpiyumal.me"}),
+			AllowedMethods:   getEnvList("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
+			AllowedHeaders:   getEnvList("CORS_ALLOWED_HEADERS", []string{"Accept", "Authorization", "Content-Type", "Last-Event-ID"}),
+			ExposedHeaders:   getEnvList("CORS_EXPOSED_HEADERS", []string{"Retry-After"}),
+			AllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", true),
+			MaxAge:           getEnvInt("CORS_MAX_AGE_SECONDS", 3600),
+		},
+		HTTPClient: HTTPClientConfig{
+			Timeout:               time.Duration(getEnvInt("HTTP_CLIENT_TIMEOUT_SECONDS", 10)) * time.Second,
+			DialTimeout:           time.Duration(getEnvInt("HTTP_CLIENT_DIAL_TIMEOUT_SECONDS", 5)) * time.Second,
+			KeepAlive:             30 * time.Second,
+			TLSHandshakeTimeout:   time.Duration(getEnvInt("HTTP_CLIENT_TLS_TIMEOUT_SECONDS", 5)) * time.Second,
+			ResponseHeaderTimeout: time.Duration(getEnvInt("HTTP_CLIENT_RESPONSE_HEADER_TIMEOUT_SECONDS", 8)) * time.Second,
+			MaxIdleConns:          getEnvInt("HTTP_CLIENT_MAX_IDLE_CONNS", 100),
+			MaxIdleConnsPerHost:   getEnvInt("HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST", 20),
+			MaxConnsPerHost:       getEnvInt("HTTP_CLIENT_MAX_CONNS_PER_HOST", 0),
+			IdleConnTimeout:       time.Duration(getEnvInt("HTTP_CLIENT_IDLE_CONN_TIMEOUT_SECONDS", 90)) * time.Second,
+		},
 		Kafka: KafkaConfig{
 			Brokers:  []string{getEnv("KAFKA_BROKERS", "localhost:9092")},
 			GroupID:  getEnv("KAFKA_GROUP_ID", "payment-gateway-group"),
@@ -132,6 +204,20 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvList reads a comma-separated list, ignoring blank entries
+func getEnvList(key string, defaultValue []string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if parsed, err := strconv.ParseBool(value); err == nil {
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// HTTPClientConfig tunes the client shared by every upstream call (Keycloak, event query, seating)
+type HTTPClientConfig struct {
+	Timeout               time.Duration // Whole request, including reading the body
+	DialTimeout           time.Duration
+	KeepAlive             time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int // Zero means no limit
+	IdleConnTimeout       time.Duration
+}
+
+// NewClient builds a pooled client from the configuration. Create it once and share it,
+// since each client keeps its own pool of idle connections.
+func (c HTTPClientConfig) NewClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   c.DialTimeout,
+		KeepAlive: c.KeepAlive,
+	}
+	return &http.Client{
+		Timeout: c.Timeout,
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			TLSHandshakeTimeout:   c.TLSHandshakeTimeout,
+			ResponseHeaderTimeout: c.ResponseHeaderTimeout,
+			ExpectContinueTimeout: time.Second,
+			MaxIdleConns:          c.MaxIdleConns,
+			MaxIdleConnsPerHost:   c.MaxIdleConnsPerHost,
+			MaxConnsPerHost:       c.MaxConnsPerHost,
+			IdleConnTimeout:       c.IdleConnTimeout,
+		},
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis topologies selectable with REDIS_MODE
+const (
+	RedisModeStandalone = "standalone"
+	RedisModeSentinel   = "sentinel"
+	RedisModeCluster    = "cluster"
+)
+
+// Options builds the client options for a standalone Redis server
+func (c RedisConfig) Options() *redis.Options {
+	return &redis.Options{
+		Addr:      c.Addr,
+		Username:  c.Username,
+		Password:  c.Password,
+		DB:        c.DB,
+		TLSConfig: c.tlsConfig(),
+	}
+}
+
+// NewClient connects to Redis in the configured topology: a single server, a Sentinel-managed
+// master that is followed across failovers, or a cluster.
+func (c RedisConfig) NewClient() (redis.UniversalClient, error) {
+	switch c.Mode {
+	case "", RedisModeStandalone:
+		return redis.NewClient(c.Options()), nil
+	case RedisModeSentinel:
+		if c.SentinelMaster == "" || len(c.SentinelAddrs) == 0 {
+			return nil, fmt.Errorf("REDIS_MODE=sentinel requires REDIS_SENTINEL_MASTER and REDIS_SENTINEL_ADDRS")
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       c.SentinelMaster,
+			SentinelAddrs:    c.SentinelAddrs,
+			SentinelPassword: c.SentinelPassword,
+			Username:         c.Username,
+			Password:         c.Password,
+			DB:               c.DB,
+			TLSConfig:        c.tlsConfig(),
+		}), nil
+	case RedisModeCluster:
+		if len(c.ClusterAddrs) == 0 {
+			return nil, fmt.Errorf("REDIS_MODE=cluster requires REDIS_CLUSTER_ADDRS")
+		}
+		if c.DB != 0 {
+			return nil, fmt.Errorf("redis cluster only has database 0, got REDIS_DB=%d", c.DB)
+		}
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     c.ClusterAddrs,
+			Username:  c.Username,
+			Password:  c.Password,
+			TLSConfig: c.tlsConfig(),
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown REDIS_MODE %q, expected %s, %s or %s", c.Mode, RedisModeStandalone, RedisModeSentinel, RedisModeCluster)
+	}
+}
+
+func (c RedisConfig) tlsConfig() *tls.Config {
+	if !c.TLS {
+		return nil
+	}
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.TLSServerName,
+		InsecureSkipVerify: c.TLSInsecureSkipVerify,
+	}
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ServiceURLs are the parsed base URLs of the event services, validated once at startup
+type ServiceURLs struct {
+	EventQuery   *url.URL
+	EventSeating *url.URL
+}
+
+// ServiceURLs validates EVENT_QUERY_SERVICE_URL and EVENT_SEATING_SERVICE_URL and returns them parsed.
+// The seating URL in the config is normalized too, so callers formatting paths onto it don't double slashes.
+func (c *Config) ServiceURLs() (ServiceURLs, error) {
+	eventQuery, err := ParseServiceURL("EVENT_QUERY_SERVICE_URL", c.EventQueryService.URL)
+	if err != nil {
+		return ServiceURLs{}, err
+	}
+	eventSeating, err := ParseServiceURL("EVENT_SEATING_SERVICE_URL", c.EventSeatingService.URL)
+	if err != nil {
+		return ServiceURLs{}, err
+	}
+	c.EventQueryService.URL = eventQuery.String()
+	c.EventSeatingService.URL = eventSeating.String()
+	return ServiceURLs{EventQuery: eventQuery, EventSeating: eventSeating}, nil
+}
+
+// ParseServiceURL parses the base URL of an upstream service named by its setting. It must be an absolute
+// http or https URL without query or fragment; a trailing slash is dropped.
+func ParseServiceURL(name, raw string) (*url.URL, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, fmt.Errorf("%s is not set", name)
+	}
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a valid URL: %w", name, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("%s must be an http or https URL, got %q", name, raw)
+	}
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("%s has no host: %q", name, raw)
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return nil, fmt.Errorf("%s must not have a query or fragment: %q", name, raw)
+	}
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	parsed.RawPath = strings.TrimRight(parsed.RawPath, "/")
+	return parsed, nil
+}
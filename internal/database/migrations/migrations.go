@@ -5,13 +5,20 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"testing/fstest"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/uptrace/bun"
 )
 
+// skippedSeedSQL replaces seed migrations when SeedData is off. The version is still recorded,
+// so later schema migrations apply on top of it as usual.
+const skippedSeedSQL = "-- seed data skipped (SEED_DATA is not enabled)\nSELECT 1;\n"
+
 // MigrateOptions defines configuration options for migration
 type MigrateOptions struct {
 	// MigrationsDir is the directory containing migration files
@@ -62,10 +69,17 @@ func (r *Runner) Initialize() error {
 		return fmt.Errorf("migrations directory does not exist: %s", r.options.MigrationsDir)
 	}
 
+	files, err := loadMigrations(r.options.MigrationsDir, r.options.SeedData)
+	if err != nil {
+		return err
+	}
+	source, err := iofs.New(files, ".")
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
+	}
+
 	// Create migration instance
-	migrator, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", r.options.MigrationsDir),
-		"postgres", driver)
+	migrator, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
 	if err != nil {
 		return fmt.Errorf("failed to create migrator: %w", err)
 	}
@@ -82,37 +96,30 @@ func (r *Runner) RunMigrations() error {
 		}
 	}
 
-	// Run migrations
+	version, dirty, err := r.migrator.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("failed to get migration version: %w", err)
+	}
+	if dirty {
+		// Fix dirty migrations
+		log.Println("Detected dirty migration, attempting to fix...")
+		if err := r.migrator.Force(int(version)); err != nil {
+			return fmt.Errorf("failed to fix dirty migration: %w", err)
+		}
+	}
+
+	// Seed migrations were swapped for no-ops in Initialize unless SeedData is set,
+	// so every pending migration can be applied in order
 	if r.options.SeedData {
-		// If seed data option is true, run all migrations
 		log.Println("Running all migrations including seed data...")
-		if err := r.migrator.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-			return fmt.Errorf("failed to run migrations: %w", err)
-		}
 	} else {
-		// If seed data option is false, run only schema migrations (run up to but excluding any seed migrations)
-		// We assume schema migrations come before seed migrations
 		log.Println("Running schema migrations only...")
-		version, dirty, err := r.migrator.Version()
-		if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
-			return fmt.Errorf("failed to get migration version: %w", err)
-		}
-
-		// If no migrations have been run yet, run the schema migration (first one)
-		if errors.Is(err, migrate.ErrNilVersion) || version == 0 {
-			if err := r.migrator.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-				return fmt.Errorf("failed to run schema migration: %w", err)
-			}
-		} else if dirty {
-			// Fix dirty migrations
-			log.Println("Detected dirty migration, attempting to fix...")
-			if err := r.migrator.Force(int(version)); err != nil {
-				return fmt.Errorf("failed to fix dirty migration: %w", err)
-			}
-		}
+	}
+	if err := r.migrator.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	version, _, err := r.migrator.Version()
+	version, _, err = r.migrator.Version()
 	if err == nil {
 		log.Printf("Current schema version: %d", version)
 	} else if !errors.Is(err, migrate.ErrNilVersion) {
@@ -195,3 +202,35 @@ func (r *Runner) Close() error {
 	}
 	return nil
 }
+
+// isSeedMigration reports whether a migration file only inserts sample data
+func isSeedMigration(name string) bool {
+	return strings.Contains(name, "_seed")
+}
+
+// loadMigrations reads the .sql files in dir into memory. Unless seed is set, seed migrations are
+// replaced with no-ops so their sample rows never reach a real database.
+func loadMigrations(dir string, seed bool) (fstest.MapFS, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory %s: %w", dir, err)
+	}
+
+	files := fstest.MapFS{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".sql" {
+			continue
+		}
+		if !seed && isSeedMigration(name) {
+			files[name] = &fstest.MapFile{Data: []byte(skippedSeedSQL)}
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		files[name] = &fstest.MapFile{Data: data}
+	}
+	return files, nil
+}
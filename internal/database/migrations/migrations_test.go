@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrationsSkipsSeedData(t *testing.T) {
+	dir := t.TempDir()
+	for name, sql := range map[string]string{
+		"000001_init_schema.up.sql":   "CREATE TABLE IF NOT EXISTS orders (order_id UUID);",
+		"000001_init_schema.down.sql": "DROP TABLE IF EXISTS orders;",
+		"000002_seed_data.up.sql":     "INSERT INTO orders VALUES ('x');",
+		"000002_seed_data.down.sql":   "DELETE FROM orders;",
+		"README.md":                   "not a migration",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(sql), 0o644))
+	}
+
+	files, err := loadMigrations(dir, false)
+	require.NoError(t, err)
+	assert.Len(t, files, 4)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS orders (order_id UUID);", string(files["000001_init_schema.up.sql"].Data))
+	// The seed version stays in the sequence but inserts nothing
+	assert.Equal(t, skippedSeedSQL, string(files["000002_seed_data.up.sql"].Data))
+
+	files, err = loadMigrations(dir, true)
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO orders VALUES ('x');", string(files["000002_seed_data.up.sql"].Data))
+}
+
+func TestRepositoryMigrationsLoad(t *testing.T) {
+	files, err := loadMigrations(filepath.Join("..", "..", "..", "migrations"), false)
+	require.NoError(t, err)
+	assert.Contains(t, files, "000001_init_schema.up.sql")
+	assert.Equal(t, skippedSeedSQL, string(files["000002_seed_data.up.sql"].Data))
+
+	source, err := iofs.New(files, ".")
+	require.NoError(t, err)
+	first, err := source.First()
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), first)
+}
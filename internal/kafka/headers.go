@@ -0,0 +1,52 @@
+package kafka
+
+import (
+	"sort"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Header names set on published events so consumers can route them without decoding the value
+const (
+	HeaderEventType     = "event-type"
+	HeaderSchemaVersion = "schema-version"
+	HeaderOrderID       = "order-id"
+)
+
+// SchemaVersion is the version of the event payloads this service publishes. Bump it when a payload
+// changes in a way consumers have to handle.
+const SchemaVersion = "1"
+
+// Headers are message headers keyed by name
+type Headers map[string]string
+
+// EventHeaders returns the standard headers of an event. The order-id header is left out when orderID
+// is empty, e.g. for seat events not tied to an order.
+func EventHeaders(eventType, orderID string) Headers {
+	headers := Headers{
+		HeaderEventType:     eventType,
+		HeaderSchemaVersion: SchemaVersion,
+	}
+	if orderID != "" {
+		headers[HeaderOrderID] = orderID
+	}
+	return headers
+}
+
+// toKafka converts the headers in name order so identical events produce identical messages
+func (h Headers) toKafka() []kafka.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers := make([]kafka.Header, 0, len(names))
+	for _, name := range names {
+		headers = append(headers, kafka.Header{Key: name, Value: []byte(h[name])})
+	}
+	return headers
+}
@@ -0,0 +1,103 @@
+package kafka
+
+import (
+	"errors"
+	"sync"
+)
+
+// Message is a message captured by InMemoryProducer
+type Message struct {
+	Topic   string
+	Key     string
+	Value   []byte
+	Headers Headers
+}
+
+// InMemoryProducer records published messages instead of sending them to a broker.
+// It is meant for tests that need to assert on what a service published.
+type InMemoryProducer struct {
+	// PublishErr, when set, is returned by Publish and the message is not recorded
+	PublishErr error
+
+	mu       sync.Mutex
+	messages []Message
+	closed   bool
+}
+
+func NewInMemoryProducer() *InMemoryProducer {
+	return &InMemoryProducer{}
+}
+
+func (p *InMemoryProducer) Publish(topic string, key string, value []byte) error {
+	return p.PublishWithHeaders(topic, key, value, nil)
+}
+
+func (p *InMemoryProducer) PublishWithHeaders(topic string, key string, value []byte, headers Headers) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return errors.New("producer is closed")
+	}
+	if p.PublishErr != nil {
+		return p.PublishErr
+	}
+	var recorded Headers
+	if len(headers) > 0 {
+		recorded = make(Headers, len(headers))
+		for name, v := range headers {
+			recorded[name] = v
+		}
+	}
+	p.messages = append(p.messages, Message{Topic: topic, Key: key, Value: append([]byte(nil), value...), Headers: recorded})
+	return nil
+}
+
+func (p *InMemoryProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+// Messages returns every recorded message in publish order
+func (p *InMemoryProducer) Messages() []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Message(nil), p.messages...)
+}
+
+// MessagesOn returns the recorded messages for one topic in publish order
+func (p *InMemoryProducer) MessagesOn(topic string) []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var matched []Message
+	for _, msg := range p.messages {
+		if msg.Topic == topic {
+			matched = append(matched, msg)
+		}
+	}
+	return matched
+}
+
+// Topics returns the topic of every recorded message in publish order
+func (p *InMemoryProducer) Topics() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	topics := make([]string, 0, len(p.messages))
+	for _, msg := range p.messages {
+		topics = append(topics, msg.Topic)
+	}
+	return topics
+}
+
+// Reset discards recorded messages and reopens a closed producer
+func (p *InMemoryProducer) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = nil
+	p.closed = false
+	p.PublishErr = nil
+}
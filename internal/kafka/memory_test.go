@@ -0,0 +1,55 @@
+package kafka_test
+
+import (
+	"errors"
+	"ms-ticketing/internal/kafka"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInMemoryProducer(t *testing.T) {
+	producer := kafka.NewInMemoryProducer()
+
+	assert.NoError(t, producer.Publish("ticketly.order.created", "order1", []byte(`{"a":1}`)))
+	assert.NoError(t, producer.Publish("ticketly.seats.status", "session1", []byte(`{"b":2}`)))
+	assert.NoError(t, producer.Publish("ticketly.order.created", "order2", []byte(`{"c":3}`)))
+
+	assert.Equal(t, []string{"ticketly.order.created", "ticketly.seats.status", "ticketly.order.created"}, producer.Topics())
+	created := producer.MessagesOn("ticketly.order.created")
+	if assert.Len(t, created, 2) {
+		assert.Equal(t, "order1", created[0].Key)
+		assert.Equal(t, "order2", created[1].Key)
+	}
+	assert.Empty(t, producer.MessagesOn("ticketly.order.canceled"))
+
+	// Failures are returned without recording anything
+	producer.PublishErr = errors.New("broker down")
+	assert.Error(t, producer.Publish("ticketly.order.updated", "order1", nil))
+	assert.Empty(t, producer.MessagesOn("ticketly.order.updated"))
+
+	assert.NoError(t, producer.Close())
+	producer.Reset()
+	assert.Empty(t, producer.Messages())
+	assert.NoError(t, producer.Publish("ticketly.order.updated", "order1", nil))
+	assert.Len(t, producer.Messages(), 1)
+}
+
+func TestInMemoryProducerRecordsHeaders(t *testing.T) {
+	producer := kafka.NewInMemoryProducer()
+
+	headers := kafka.EventHeaders("order.completed", "order1")
+	assert.NoError(t, producer.PublishWithHeaders("ticketly.order.completed", "order1", nil, headers))
+	assert.NoError(t, producer.Publish("ticketly.order.completed", "order2", nil))
+
+	messages := producer.Messages()
+	assert.Equal(t, kafka.Headers{"event-type": "order.completed", "schema-version": kafka.SchemaVersion, "order-id": "order1"}, messages[0].Headers)
+	assert.Nil(t, messages[1].Headers)
+
+	// The recorded headers are a copy
+	headers["event-type"] = "changed"
+	assert.Equal(t, "order.completed", producer.Messages()[0].Headers[kafka.HeaderEventType])
+
+	// Events without an order carry no order-id header
+	assert.NotContains(t, kafka.EventHeaders("seats.reserved", ""), kafka.HeaderOrderID)
+}
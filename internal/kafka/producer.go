@@ -25,6 +25,10 @@ func NewProducer(brokers []string) *Producer {
 				Brokers: brokers,
 				Topic:   "ticketly.order.updated",
 			}),
+			"ticketly.order.completed": kafka.NewWriter(kafka.WriterConfig{
+				Brokers: brokers,
+				Topic:   "ticketly.order.completed",
+			}),
 			"ticketly.order.canceled": kafka.NewWriter(kafka.WriterConfig{
 				Brokers: brokers,
 				Topic:   "ticketly.order.canceled",
@@ -64,6 +68,11 @@ func (p *Producer) getOrCreateWriter(topic string) (*kafka.Writer, error) {
 }
 
 func (p *Producer) Publish(topic string, key string, value []byte) error {
+	return p.PublishWithHeaders(topic, key, value, nil)
+}
+
+// PublishWithHeaders publishes a message carrying the given headers, see EventHeaders
+func (p *Producer) PublishWithHeaders(topic string, key string, value []byte, headers Headers) error {
 	writer, err := p.getOrCreateWriter(topic)
 	if err != nil {
 		return fmt.Errorf("failed to get writer for topic %s: %w", topic, err)
@@ -74,7 +83,7 @@ func (p *Producer) Publish(topic string, key string, value []byte) error {
 		topic, key, len(value))
 
 	return writer.WriteMessages(context.Background(),
-		kafka.Message{Key: []byte(key), Value: value},
+		kafka.Message{Key: []byte(key), Value: value, Headers: headers.toKafka()},
 	)
 }
 
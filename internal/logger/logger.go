@@ -8,7 +8,9 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fatih/color"
@@ -33,10 +35,41 @@ type LogEntry struct {
 	Line      int    `json:"line,omitempty"`
 }
 
+// Output formats for the console, chosen with LOG_FORMAT
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
 type Logger struct {
 	fileLogger   *log.Logger
 	logFile      *os.File
 	colorEnabled bool
+	minLevel     LogLevel // Entries below this level are dropped
+	format       string   // Console format; the log file is always JSON
+
+	// Debug sampling: 1 in debugSampleRate debug entries per category is kept
+	debugSampleRate int
+	sampleMu        sync.Mutex
+	debugCounts     map[string]int
+}
+
+// ParseLevel reads a level name such as "debug" or "WARN"
+func ParseLevel(name string) (LogLevel, bool) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "DEBUG":
+		return DEBUG, true
+	case "INFO":
+		return INFO, true
+	case "WARN", "WARNING":
+		return WARN, true
+	case "ERROR":
+		return ERROR, true
+	case "FATAL":
+		return FATAL, true
+	default:
+		return DEBUG, false
+	}
 }
 
 func NewLogger() *Logger {
@@ -58,20 +91,48 @@ func NewLogger() *Logger {
 	multiWriter := io.MultiWriter(logFile, os.Stdout)
 	fileLogger := log.New(multiWriter, "", 0)
 
+	// Everything is logged unless LOG_LEVEL raises the bar, e.g. to info in production
+	minLevel, ok := ParseLevel(os.Getenv("LOG_LEVEL"))
+	format := strings.ToLower(os.Getenv("LOG_FORMAT"))
+	if format != FormatJSON {
+		format = FormatText
+	}
+
+	// LOG_DEBUG_SAMPLE_RATE=N keeps the first and then every Nth debug entry of each category
+	sampleRate, err := strconv.Atoi(os.Getenv("LOG_DEBUG_SAMPLE_RATE"))
+	if err != nil || sampleRate < 1 {
+		sampleRate = 1
+	}
+
 	logger := &Logger{
-		fileLogger:   fileLogger,
-		logFile:      logFile,
-		colorEnabled: true,
+		fileLogger:      fileLogger,
+		logFile:         logFile,
+		colorEnabled:    format == FormatText,
+		minLevel:        minLevel,
+		format:          format,
+		debugSampleRate: sampleRate,
+		debugCounts:     map[string]int{},
 	}
 
 	// Log startup message
 	logger.Info("LOGGER", "Enhanced logging system initialized")
 	logger.Info("LOGGER", fmt.Sprintf("Log file: %s", logFileName))
+	logger.Info("LOGGER", fmt.Sprintf("Log level: %s, format: %s, debug sample rate: 1/%d", logger.levelToString(minLevel), format, sampleRate))
+	if !ok && os.Getenv("LOG_LEVEL") != "" {
+		logger.Warn("LOGGER", fmt.Sprintf("Unknown LOG_LEVEL %q, logging everything", os.Getenv("LOG_LEVEL")))
+	}
 
 	return logger
 }
 
 func (l *Logger) log(level LogLevel, category, message string) {
+	if level < l.minLevel {
+		return
+	}
+	if level == DEBUG && !l.sampleDebug(category) {
+		return
+	}
+
 	// Get caller information
 	_, file, line, ok := runtime.Caller(2)
 	if ok {
@@ -82,19 +143,20 @@ func (l *Logger) log(level LogLevel, category, message string) {
 		Timestamp: time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
 		Level:     l.levelToString(level),
 		Category:  strings.ToUpper(category),
-		Message:   message,
+		Message:   Redact(message),
 		File:      file,
 		Line:      line,
 	}
 
-	// Format for terminal output with colors
-	terminalOutput := l.formatTerminalOutput(entry)
-
 	// Format for file output (JSON)
 	jsonOutput := l.formatJSONOutput(entry)
 
-	// Write to terminal (colored)
-	fmt.Print(terminalOutput)
+	// Write to terminal, colored text or one JSON object per line for log aggregators
+	if l.format == FormatJSON {
+		fmt.Println(jsonOutput)
+	} else {
+		fmt.Print(l.formatTerminalOutput(entry))
+	}
 
 	// Write to file (JSON format)
 	if l.logFile != nil {
@@ -102,6 +164,18 @@ func (l *Logger) log(level LogLevel, category, message string) {
 	}
 }
 
+// sampleDebug reports whether the next debug entry of category is kept under the sample rate
+func (l *Logger) sampleDebug(category string) bool {
+	if l.debugSampleRate <= 1 {
+		return true
+	}
+	l.sampleMu.Lock()
+	defer l.sampleMu.Unlock()
+	n := l.debugCounts[category]
+	l.debugCounts[category] = n + 1
+	return n%l.debugSampleRate == 0
+}
+
 func (l *Logger) formatTerminalOutput(entry LogEntry) string {
 	timestamp := entry.Timestamp[11:19] // Extract time part
 
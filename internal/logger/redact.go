@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// maxBodyLogBytes caps how much of a request or response body RedactBody keeps
+const maxBodyLogBytes = 1024
+
+// sensitiveKeys are JSON fields and headers whose values never reach the logs
+var sensitiveKeys = map[string]bool{
+	"authorization": true,
+	"m2m_token":     true,
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+	"token":         true,
+	"client_secret": true,
+	"password":      true,
+	"secret":        true,
+	// Card data, in case a payment payload is ever logged
+	"card":        true,
+	"card_number": true,
+	"cvc":         true,
+	"cvv":         true,
+	"exp_month":   true,
+	"exp_year":    true,
+}
+
+var (
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]+=*`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	keyPattern    = regexp.MustCompile(`(?i)("?\b(?:authorization|m2m_token|access_token|refresh_token|id_token|client_secret|password|secret|token|card_number|cvc|cvv|exp_month|exp_year)"?\s*[:=]\s*"?)([^"\s,}&]+)`)
+	// Candidate card numbers: 13-19 digits, optionally grouped in fours by spaces or dashes
+	cardPattern = regexp.MustCompile(`\b\d{4}[ -]?\d{4}[ -]?\d{4}[ -]?\d{1,7}\b`)
+)
+
+// Redact masks bearer tokens, JWTs, card numbers and sensitive key/value pairs in a log message.
+// Every entry goes through it, so a token that slips into a message is still kept out of the logs.
+func Redact(message string) string {
+	message = bearerPattern.ReplaceAllString(message, "${1}"+redacted)
+	message = jwtPattern.ReplaceAllString(message, redacted)
+	message = cardPattern.ReplaceAllStringFunc(message, func(match string) string {
+		if luhnValid(match) {
+			return redacted
+		}
+		return match
+	})
+	return keyPattern.ReplaceAllString(message, "${1}"+redacted)
+}
+
+// luhnValid reports whether the digits pass the card number checksum, so IDs and timestamps that
+// merely look like card numbers stay readable
+func luhnValid(number string) bool {
+	sum, digits := 0, 0
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if digits%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+// RedactBody prepares a request or response body for a debug log: sensitive JSON fields are masked at
+// any depth and the result is truncated. Bodies are only ever logged at Debug.
+func RedactBody(body []byte) string {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err == nil {
+		if masked, err := json.Marshal(redactValue(value)); err == nil {
+			body = masked
+		}
+	}
+
+	out := Redact(string(body))
+	if len(out) > maxBodyLogBytes {
+		out = out[:maxBodyLogBytes] + "...(truncated)"
+	}
+	return out
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if sensitiveKeys[strings.ToLower(key)] {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactValue(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
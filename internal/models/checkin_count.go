@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// CheckinCount is a running total of checked-in tickets for a session.
+// It is maintained on check-in and revoke so door dashboards avoid counting the tickets+orders join.
+type CheckinCount struct {
+	bun.BaseModel `bun:"table:checkin_counts,alias:cc"`
+
+	SessionID string    `bun:"session_id,pk"`
+	CheckedIn int       `bun:"checked_in,notnull,default:0"`
+	UpdatedAt time.Time `bun:"updated_at,notnull,default:current_timestamp"`
+}
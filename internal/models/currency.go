@@ -0,0 +1,126 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	DefaultCurrencyCode = "LKR"
+	DefaultLocaleTag    = "en-LK"
+)
+
+// ErrUnsupportedCurrency is returned for currencies orders can't be priced or charged in
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+// moneyMinorDigits is the number of minor-unit digits Money stores
+const moneyMinorDigits = 2
+
+// supportedCurrencies maps the ISO 4217 codes orders may use to their minor-unit exponent.
+// Money always keeps two minor digits and is charged as-is, so a zero-decimal currency like JPY
+// would be charged 100x; such currencies need their own conversion before they can be listed.
+var supportedCurrencies = map[string]int{
+	"AUD": 2,
+	"CAD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"INR": 2,
+	"LKR": 2,
+	"SGD": 2,
+	"USD": 2,
+}
+
+// separators are the grouping and decimal marks used when formatting amounts for a language
+type separators struct {
+	group   string
+	decimal string
+}
+
+// localeSeparators covers the languages whose number formatting differs from English
+var localeSeparators = map[string]separators{
+	"de": {group: ".", decimal: ","},
+	"es": {group: ".", decimal: ","},
+	"id": {group: ".", decimal: ","},
+	"it": {group: ".", decimal: ","},
+	"nl": {group: ".", decimal: ","},
+	"pt": {group: ".", decimal: ","},
+	"fr": {group: " ", decimal: ","},
+}
+
+// DefaultCurrency is the ISO 4217 code from CURRENCY, used for orders that don't carry their own currency.
+// Unsupported codes fall back to DefaultCurrencyCode.
+func DefaultCurrency() string {
+	code := strings.ToUpper(strings.TrimSpace(os.Getenv("CURRENCY")))
+	if _, ok := supportedCurrencies[code]; !ok {
+		return DefaultCurrencyCode
+	}
+	return code
+}
+
+// CurrencyExponent returns the number of minor-unit digits of a supported currency
+func CurrencyExponent(code string) (int, bool) {
+	exponent, ok := supportedCurrencies[strings.ToUpper(strings.TrimSpace(code))]
+	return exponent, ok
+}
+
+// ValidateCurrency normalizes the code and rejects currencies whose amounts Money can't represent
+func ValidateCurrency(code string) (string, error) {
+	code = NormalizeCurrency(code)
+	if exponent, ok := supportedCurrencies[code]; !ok || exponent != moneyMinorDigits {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedCurrency, code)
+	}
+	return code, nil
+}
+
+// DefaultLocale is the BCP 47 tag from LOCALE that decides how amounts are formatted server-side
+func DefaultLocale() string {
+	if tag := strings.TrimSpace(os.Getenv("LOCALE")); tag != "" {
+		return tag
+	}
+	return DefaultLocaleTag
+}
+
+// NormalizeCurrency upper-cases an ISO 4217 code, falling back to the default currency when it is blank
+func NormalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrency()
+	}
+	return code
+}
+
+// Format renders the amount with its currency code using the default locale, e.g. "LKR 1,234.50"
+func (m Money) Format(currency string) string {
+	return fmt.Sprintf("%s %s", NormalizeCurrency(currency), m.formatLocale(DefaultLocale()))
+}
+
+func (m Money) formatLocale(locale string) string {
+	seps := separators{group: ",", decimal: "."}
+	language := strings.ToLower(strings.SplitN(strings.ReplaceAll(locale, "_", "-"), "-", 2)[0])
+	if s, ok := localeSeparators[language]; ok {
+		seps = s
+	}
+
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	whole := fmt.Sprintf("%d", v/100)
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteString(seps.group)
+		}
+		grouped.WriteRune(digit)
+	}
+	return fmt.Sprintf("%s%s%s%02d", sign, grouped.String(), seps.decimal, v%100)
+}
+
+// CurrencyCode is the order's currency, or the default currency for orders placed before currencies were stored
+func (o Order) CurrencyCode() string {
+	return NormalizeCurrency(o.Currency)
+}
@@ -0,0 +1,61 @@
+package models_test
+
+import (
+	"ms-ticketing/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultCurrency(t *testing.T) {
+	t.Setenv("CURRENCY", "")
+	assert.Equal(t, "LKR", models.DefaultCurrency())
+
+	t.Setenv("CURRENCY", " usd ")
+	assert.Equal(t, "USD", models.DefaultCurrency())
+
+	t.Setenv("CURRENCY", "dollars")
+	assert.Equal(t, "LKR", models.DefaultCurrency(), "codes that aren't three letters fall back to LKR")
+
+	t.Setenv("CURRENCY", "JPY")
+	assert.Equal(t, "LKR", models.DefaultCurrency(), "unsupported currencies fall back to LKR")
+}
+
+func TestValidateCurrency(t *testing.T) {
+	t.Setenv("CURRENCY", "")
+	for input, want := range map[string]string{"": "LKR", " usd ": "USD", "eur": "EUR", "LKR": "LKR"} {
+		code, err := models.ValidateCurrency(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, want, code)
+
+		exponent, ok := models.CurrencyExponent(code)
+		assert.True(t, ok)
+		assert.Equal(t, 2, exponent)
+	}
+
+	// Zero- and three-decimal currencies would be charged at the wrong scale
+	for _, input := range []string{"JPY", "krw", "KWD", "XYZ", "dollars"} {
+		_, err := models.ValidateCurrency(input)
+		assert.ErrorIs(t, err, models.ErrUnsupportedCurrency, input)
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	t.Setenv("CURRENCY", "")
+	t.Setenv("LOCALE", "")
+	assert.Equal(t, "LKR 1,234,567.05", models.Money(123456705).Format(""))
+	assert.Equal(t, "USD 999.90", models.Money(99990).Format("usd"))
+	assert.Equal(t, "LKR -1,000.00", models.Money(-100000).Format("LKR"))
+
+	t.Setenv("LOCALE", "de_DE")
+	assert.Equal(t, "EUR 1.234,50", models.Money(123450).Format("EUR"))
+
+	t.Setenv("LOCALE", "fr-FR")
+	assert.Equal(t, "EUR 1 234,50", models.Money(123450).Format("EUR"))
+}
+
+func TestOrderCurrencyCode(t *testing.T) {
+	t.Setenv("CURRENCY", "SGD")
+	assert.Equal(t, "SGD", models.Order{}.CurrencyCode())
+	assert.Equal(t, "USD", models.Order{Currency: "usd"}.CurrencyCode())
+}
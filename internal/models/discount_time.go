@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Layouts accepted for discount validity bounds. Timestamps without an offset are read as UTC
+// so a "midnight" cutoff means the same instant on every pod, whatever its local timezone.
+var discountTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// ParseDiscountTime parses a discount validity bound, treating offset-less values as UTC
+func ParseDiscountTime(value string) (time.Time, error) {
+	for _, layout := range discountTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized discount time %q", value)
+}
+
+// UnmarshalJSON reads a discount, accepting activeFrom/expiresAt with or without a UTC offset
+func (d *Discount) UnmarshalJSON(data []byte) error {
+	type plain Discount
+	aux := struct {
+		*plain
+		ActiveFrom *string `json:"activeFrom"`
+		ExpiresAt  *string `json:"expiresAt"`
+	}{plain: (*plain)(d)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	if d.ActiveFrom, err = parseOptionalDiscountTime(aux.ActiveFrom); err != nil {
+		return fmt.Errorf("activeFrom: %w", err)
+	}
+	if d.ExpiresAt, err = parseOptionalDiscountTime(aux.ExpiresAt); err != nil {
+		return fmt.Errorf("expiresAt: %w", err)
+	}
+	return nil
+}
+
+func parseOptionalDiscountTime(value *string) (*time.Time, error) {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return nil, nil
+	}
+	t, err := ParseDiscountTime(strings.TrimSpace(*value))
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,42 @@
+package models_test
+
+import (
+	"encoding/json"
+	"ms-ticketing/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscountUnmarshalValidityWindow(t *testing.T) {
+	var d models.Discount
+	err := json.Unmarshal([]byte(`{
+		"id": "discount1",
+		"code": "NEWYEAR",
+		"activeFrom": "2025-01-01T00:00:00",
+		"expiresAt": "2025-01-08T00:00:00+05:30",
+		"active": true
+	}`), &d)
+	assert.NoError(t, err)
+	assert.Equal(t, "NEWYEAR", d.Code)
+	assert.True(t, d.Active)
+
+	// Offset-less bounds are midnight UTC, explicit offsets are honoured
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), *d.ActiveFrom)
+	assert.Equal(t, time.Date(2025, 1, 7, 18, 30, 0, 0, time.UTC), d.ExpiresAt.UTC())
+
+	var open models.Discount
+	assert.NoError(t, json.Unmarshal([]byte(`{"id": "discount2", "activeFrom": null, "expiresAt": ""}`), &open))
+	assert.Nil(t, open.ActiveFrom)
+	assert.Nil(t, open.ExpiresAt)
+
+	var broken models.Discount
+	assert.Error(t, json.Unmarshal([]byte(`{"id": "discount3", "expiresAt": "next tuesday"}`), &broken))
+}
+
+func TestParseDiscountTimeDateOnly(t *testing.T) {
+	parsed, err := models.ParseDiscountTime("2025-03-31")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC), parsed)
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Money is an amount in minor currency units (cents for LKR).
+// It is stored as an integer so sums never pick up float drift, and it is
+// written to and read from JSON as a decimal number (e.g. 19.99).
+type Money int64
+
+// MoneyFromFloat converts a decimal amount, rounding to the nearest minor unit
+func MoneyFromFloat(amount float64) Money {
+	return Money(math.Round(amount * 100))
+}
+
+// MinorUnits returns the amount in minor units, as Stripe expects it
+func (m Money) MinorUnits() int64 {
+	return int64(m)
+}
+
+// Float64 returns the amount in major units
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// String formats the amount with two decimals, e.g. "19.99"
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/100, v%100)
+}
+
+// MarshalJSON writes the amount as a decimal number
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a decimal number or a quoted decimal string
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(data, `"`)
+	if len(data) == 0 || string(data) == "null" {
+		*m = 0
+		return nil
+	}
+	amount, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return fmt.Errorf("invalid money amount %q: %w", data, err)
+	}
+	*m = MoneyFromFloat(amount)
+	return nil
+}
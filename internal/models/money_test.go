@@ -0,0 +1,39 @@
+package models_test
+
+import (
+	"encoding/json"
+	"ms-ticketing/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoneyJSONRoundTrip(t *testing.T) {
+	// The API keeps exposing decimal amounts
+	data, err := json.Marshal(struct {
+		Price models.Money `json:"price"`
+	}{Price: models.MoneyFromFloat(19.99)})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"price": 19.99}`, string(data))
+
+	var decoded struct {
+		Price models.Money `json:"price"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"price": 8.7}`), &decoded))
+	assert.Equal(t, int64(870), decoded.Price.MinorUnits())
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"price": "1.15"}`), &decoded))
+	assert.Equal(t, int64(115), decoded.Price.MinorUnits())
+
+	assert.Error(t, json.Unmarshal([]byte(`{"price": "abc"}`), &decoded))
+}
+
+func TestMoneySumsWithoutDrift(t *testing.T) {
+	var total models.Money
+	for i := 0; i < 10; i++ {
+		total += models.MoneyFromFloat(0.1)
+	}
+	assert.Equal(t, models.MoneyFromFloat(1), total)
+	assert.Equal(t, "1.00", total.String())
+	assert.Equal(t, "-0.05", models.Money(-5).String())
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"math"
 	"time"
 
 	"github.com/uptrace/bun"
@@ -12,24 +13,53 @@ type OrderRequest struct {
 	OrganizationID string   `json:"organization_id"`
 	SeatIDs        []string `json:"seat_ids"`
 	DiscountID     string   `json:"discount_id"`
+	DiscountCode   string   `json:"discount_code,omitempty"` // Code as typed by the user, matched case-insensitively
 }
 
 type Order struct {
 	bun.BaseModel `bun:"table:orders"`
 
-	OrderID         string    `bun:"order_id,pk"`
-	UserID          string    `bun:"user_id"`
-	EventID         string    `bun:"event_id"`
-	OrganizationID  string    `bun:"organization_id"`
-	SessionID       string    `bun:"session_id"`
-	Status          string    `bun:"status"`
-	SubTotal        float64   `bun:"subtotal"`               // Price before discount
-	DiscountID      string    `bun:"discount_id,nullzero"`   // ID of applied discount code
-	DiscountCode    string    `bun:"discount_code,nullzero"` // Code of applied discount
-	DiscountAmount  float64   `bun:"discount_amount"`        // Amount of discount applied
-	Price           float64   `bun:"price"`                  // Final price after discount
-	CreatedAt       time.Time `bun:"created_at"`
-	PaymentIntentID string    `bun:"payment_intent_id,nullzero"`
+	OrderID              string                    `bun:"order_id,pk"`
+	OrderNumber          string                    `bun:"order_number,nullzero"` // Human-readable number for support, e.g. EVT-20240617-000123
+	UserID               string                    `bun:"user_id"`
+	EventID              string                    `bun:"event_id"`
+	OrganizationID       string                    `bun:"organization_id"`
+	SessionID            string                    `bun:"session_id"`
+	Status               string                    `bun:"status"`
+	SubTotal             Money                     `bun:"subtotal"`               // Price before discount, in minor units
+	DiscountID           string                    `bun:"discount_id,nullzero"`   // ID of applied discount code
+	DiscountCode         string                    `bun:"discount_code,nullzero"` // Code of applied discount
+	DiscountAmount       Money                     `bun:"discount_amount"`        // Amount of discount applied, in minor units
+	Price                Money                     `bun:"price"`                  // Final price after discount, in minor units
+	Currency             string                    `bun:"currency,nullzero"`      // ISO 4217 code; empty means the default currency
+	CreatedAt            time.Time                 `bun:"created_at"`
+	PaymentIntentID      string                    `bun:"payment_intent_id,nullzero"`                // Payment ID at the order's provider
+	PaymentProvider      string                    `bun:"payment_provider,nullzero"`                 // Gateway the order is paid through; empty means Stripe
+	PaymentDueAt         time.Time                 `bun:"payment_due_at,nullzero"`                   // End of the payment window; zero leaves the order to its seat locks
+	CancellationReason   string                    `bun:"cancellation_reason,nullzero"`              // Why a cancelled order was cancelled, e.g. abandoned or requested_by_customer
+	AvailabilitySnapshot *SeatAvailabilitySnapshot `bun:"availability_snapshot,type:jsonb" json:"-"` // Seat availability at placement; shown to staff only
+	Archived             bool                      `bun:"archived,notnull,default:false"`            // Hidden from analytics unless requested
+	IsComped             bool                      `bun:"is_comped,notnull,default:false"`           // Complimentary order issued by an admin without payment
+}
+
+// DiscountPercentage is the discount as a percentage of the subtotal, rounded to two decimals
+func (o Order) DiscountPercentage() float64 {
+	if o.SubTotal <= 0 || o.DiscountAmount <= 0 {
+		return 0
+	}
+	return math.Round(float64(o.DiscountAmount)*10000/float64(o.SubTotal)) / 100
+}
+
+// PricedOrder is an Order as returned by GetOrder, with the computed discount percentage
+// next to SubTotal, DiscountAmount and Price so receipts can show how the total was reached
+type PricedOrder struct {
+	Order
+	DiscountPercentage float64
+}
+
+// NewPricedOrder builds the GetOrder response for an order
+func NewPricedOrder(order Order) PricedOrder {
+	return PricedOrder{Order: order, DiscountPercentage: order.DiscountPercentage()}
 }
 
 // OrderWithSeats extends the Order model with seat information
@@ -102,12 +132,27 @@ type SeatDetails struct {
 
 type OrderResponse struct {
 	OrderID        string   `json:"order_id"`
+	OrderNumber    string   `json:"order_number,omitempty"`
 	SessionID      string   `json:"session_id"`
 	OrganizationID string   `json:"organization_id"`
 	SeatIDs        []string `json:"seat_ids"`
 	UserID         string   `json:"user_id"`
 }
 
+// OrderQuote is what an order would cost, returned by a dry run instead of placing it
+type OrderQuote struct {
+	DryRun         bool     `json:"dry_run"`
+	Valid          bool     `json:"valid"`
+	SessionID      string   `json:"session_id"`
+	SeatIDs        []string `json:"seat_ids"`
+	SubTotal       Money    `json:"subtotal"`
+	DiscountAmount Money    `json:"discount_amount"`
+	Price          Money    `json:"price"`
+	Currency       string   `json:"currency"`
+	DiscountCode   string   `json:"discount_code,omitempty"`
+	DiscountCapped bool     `json:"discount_capped,omitempty"` // The discount hit the maximum discount per order
+}
+
 type DiscountType string
 
 const (
@@ -144,4 +189,40 @@ type Discount struct {
 type OrderDetailsDTO struct {
 	Seats    []SeatDetails `json:"seats"`
 	Discount *Discount     `json:"discount,omitempty"`
+	// MaxSeatsPerOrder is the event's cap on seats in one order; 0 falls back to MAX_SEATS_PER_ORDER
+	MaxSeatsPerOrder int `json:"maxSeatsPerOrder,omitempty"`
+	// Currency is the event's ISO 4217 currency; empty falls back to CURRENCY
+	Currency string `json:"currency,omitempty"`
+	// PaymentWindowMinutes is how long the event gives buyers to pay; 0 falls back to ORDER_PAYMENT_WINDOW_MINUTES
+	PaymentWindowMinutes int `json:"paymentWindowMinutes,omitempty"`
+	// MaxDiscountAmount caps the discount of one order for the event; 0 falls back to MAX_DISCOUNT_AMOUNT
+	MaxDiscountAmount float64 `json:"maxDiscountAmount,omitempty"`
+	// Event, session and venue details passed on in ticketly.order.created; all optional
+	EventName        string     `json:"eventName,omitempty"`
+	SessionStartTime *time.Time `json:"sessionStartTime,omitempty"`
+	VenueName        string     `json:"venueName,omitempty"`
+	VenueAddress     string     `json:"venueAddress,omitempty"`
+}
+
+// OrderEventDetails describes what an order is for, as captured from the pre-order validation response,
+// so consumers of order events such as the confirmation email don't have to look it up
+type OrderEventDetails struct {
+	EventName        string     `json:"event_name,omitempty"`
+	SessionStartTime *time.Time `json:"session_start_time,omitempty"`
+	VenueName        string     `json:"venue_name,omitempty"`
+	VenueAddress     string     `json:"venue_address,omitempty"`
+}
+
+// EventDetails returns the DTO's event, session and venue details, or nil if it carries none
+func (d OrderDetailsDTO) EventDetails() *OrderEventDetails {
+	details := OrderEventDetails{
+		EventName:        d.EventName,
+		SessionStartTime: d.SessionStartTime,
+		VenueName:        d.VenueName,
+		VenueAddress:     d.VenueAddress,
+	}
+	if details == (OrderEventDetails{}) {
+		return nil
+	}
+	return &details
 }
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// OrderNote is an internal comment left on an order by a support agent.
+// Notes are only served by the support endpoints and never included in customer-facing order responses.
+type OrderNote struct {
+	bun.BaseModel `bun:"table:order_notes,alias:n"`
+
+	NoteID       string    `bun:"note_id,pk" json:"note_id"`
+	OrderID      string    `bun:"order_id,notnull" json:"order_id"`
+	AuthorUserID string    `bun:"author_user_id,notnull" json:"author_user_id"`
+	Note         string    `bun:"note,notnull" json:"note"`
+	CreatedAt    time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"github.com/uptrace/bun"
+)
+
+// OrderNumberCounter is the last order number handed out on a day.
+// Order numbers restart at 1 every day, so one row per day is enough to keep them sequential.
+type OrderNumberCounter struct {
+	bun.BaseModel `bun:"table:order_number_counters,alias:onc"`
+
+	Day        string `bun:"day,pk"` // YYYYMMDD in UTC
+	LastNumber int64  `bun:"last_number,notnull,default:0"`
+}
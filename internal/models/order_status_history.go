@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// OrderStatusChange is one row of an order's status timeline. OldStatus is empty for the row
+// written when the order is created.
+type OrderStatusChange struct {
+	bun.BaseModel `bun:"table:order_status_history,alias:osh"`
+
+	ChangeID  string    `bun:"change_id,pk" json:"change_id"`
+	OrderID   string    `bun:"order_id,notnull" json:"order_id"`
+	OldStatus string    `bun:"old_status,nullzero" json:"old_status,omitempty"`
+	NewStatus string    `bun:"new_status,notnull" json:"new_status"`
+	Actor     string    `bun:"actor,notnull" json:"actor"`
+	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+}
@@ -0,0 +1,47 @@
+package models_test
+
+import (
+	"encoding/json"
+	"ms-ticketing/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPricedOrderJSON(t *testing.T) {
+	discounted := models.Order{
+		OrderID:        "order1",
+		SubTotal:       models.MoneyFromFloat(150),
+		DiscountID:     "disc1",
+		DiscountCode:   "SUMMER",
+		DiscountAmount: models.MoneyFromFloat(22.5),
+		Price:          models.MoneyFromFloat(127.5),
+	}
+	data, err := json.Marshal(models.NewPricedOrder(discounted))
+	assert.NoError(t, err)
+
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &body))
+	assert.Equal(t, 150.0, body["SubTotal"])
+	assert.Equal(t, 22.5, body["DiscountAmount"])
+	assert.Equal(t, "SUMMER", body["DiscountCode"])
+	assert.Equal(t, 127.5, body["Price"])
+	assert.Equal(t, 15.0, body["DiscountPercentage"])
+
+	// Without a discount the breakdown keys are still present, as zero values
+	data, err = json.Marshal(models.NewPricedOrder(models.Order{OrderID: "order2", SubTotal: models.MoneyFromFloat(40), Price: models.MoneyFromFloat(40)}))
+	assert.NoError(t, err)
+	body = nil
+	assert.NoError(t, json.Unmarshal(data, &body))
+	for key, want := range map[string]interface{}{"DiscountID": "", "DiscountCode": "", "DiscountAmount": 0.0, "DiscountPercentage": 0.0} {
+		if assert.Contains(t, body, key) {
+			assert.Equal(t, want, body[key], key)
+		}
+	}
+}
+
+func TestOrderDiscountPercentage(t *testing.T) {
+	assert.Equal(t, 0.0, models.Order{}.DiscountPercentage())
+	assert.Equal(t, 33.33, models.Order{SubTotal: 300, DiscountAmount: 100}.DiscountPercentage())
+	assert.Equal(t, 100.0, models.Order{SubTotal: 500, DiscountAmount: 500}.DiscountPercentage())
+}
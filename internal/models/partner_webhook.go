@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// PartnerWebhook is a callback URL an organization registered to receive order events over HTTP
+type PartnerWebhook struct {
+	bun.BaseModel `bun:"table:webhooks,alias:wh"`
+
+	WebhookID      string    `bun:"webhook_id,pk" json:"webhook_id"`
+	OrganizationID string    `bun:"organization_id,notnull" json:"organization_id"`
+	URL            string    `bun:"url,notnull" json:"url"`
+	Secret         string    `bun:"secret,notnull" json:"-"` // Only returned once, when the webhook is registered
+	CreatedBy      string    `bun:"created_by" json:"created_by"`
+	CreatedAt      time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+}
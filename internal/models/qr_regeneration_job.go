@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// QRRegenerationJob reports the progress of regenerating the QR codes of an event. It is stored so every
+// instance sees the same jobs; UpdatedAt moves with each batch so a job whose instance died can be told apart.
+type QRRegenerationJob struct {
+	bun.BaseModel `bun:"table:qr_regeneration_jobs,alias:qrj"`
+
+	JobID       string     `bun:"job_id,pk" json:"job_id"`
+	EventID     string     `bun:"event_id,notnull" json:"event_id"`
+	Status      string     `bun:"status,notnull" json:"status"`
+	KeyID       string     `bun:"key_id" json:"key_id"`
+	Total       int        `bun:"total,notnull" json:"total"`
+	Processed   int        `bun:"processed,notnull" json:"processed"`
+	Failed      int        `bun:"failed,notnull" json:"failed"`
+	Error       string     `bun:"error,nullzero" json:"error,omitempty"`
+	StartedAt   time.Time  `bun:"started_at,notnull" json:"started_at"`
+	UpdatedAt   time.Time  `bun:"updated_at,notnull" json:"updated_at"`
+	CompletedAt *time.Time `bun:"completed_at" json:"completed_at,omitempty"`
+}
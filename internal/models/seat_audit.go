@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Seat audit actions
+const (
+	SeatAuditLocked        = "LOCKED"
+	SeatAuditUnlocked      = "UNLOCKED"
+	SeatAuditExpired       = "EXPIRED"
+	SeatAuditForceReleased = "FORCE_RELEASED"
+	SeatAuditHeld          = "HELD"
+	SeatAuditHoldReleased  = "HOLD_RELEASED"
+)
+
+// SeatAuditEvent is one row of the seat lock trail kept for availability disputes
+type SeatAuditEvent struct {
+	bun.BaseModel `bun:"table:audit_seat_events,alias:sa"`
+
+	EventID   string    `bun:"event_id,pk" json:"event_id"`
+	SeatID    string    `bun:"seat_id,notnull" json:"seat_id"`
+	OrderID   string    `bun:"order_id,nullzero" json:"order_id,omitempty"`
+	SessionID string    `bun:"session_id,nullzero" json:"session_id,omitempty"`
+	Action    string    `bun:"action,notnull" json:"action"`
+	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// AdminHoldOrderID stands in for the order ID on seats an admin holds back from sale, e.g. for VIPs or press
+const AdminHoldOrderID = "admin-hold"
+
+// SeatLock is the value stored under a seat_lock:{seatID} Redis key while an order holds the seat
+type SeatLock struct {
+	SessionID string    `json:"session_id,omitempty"` // Empty for admin holds and locks taken before sessions were recorded
+	OrderID   string    `json:"order_id"`
+	UserID    string    `json:"user_id,omitempty"`
+	LockedAt  time.Time `json:"locked_at"`
+}
+
+// HeldSeat is a seat lock as listed for a session: the seat, the order holding it and the time left on the lock
+type HeldSeat struct {
+	SeatID     string     `json:"seat_id"`
+	OrderID    string     `json:"order_id"`
+	UserID     string     `json:"user_id,omitempty"`
+	LockedAt   *time.Time `json:"locked_at,omitempty"`
+	TTLSeconds int64      `json:"ttl_seconds"` // 0 for locks without an expiry, such as admin holds
+}
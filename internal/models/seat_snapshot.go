@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Seat states recorded in an availability snapshot
+const (
+	SeatSnapshotAvailable = "available"
+	SeatSnapshotLocked    = "locked"
+)
+
+// SeatAvailabilitySnapshot is what the availability check reported for an order's seats when it was
+// placed, kept to settle disputes about what the buyer saw
+type SeatAvailabilitySnapshot struct {
+	CheckedAt time.Time          `json:"checked_at"`
+	LockedAt  time.Time          `json:"locked_at"` // When the order's own seat locks were taken
+	Seats     []SeatAvailability `json:"seats"`
+}
+
+// SeatAvailability is one seat of a snapshot
+type SeatAvailability struct {
+	SeatID string `json:"seat_id"`
+	Status string `json:"status"`
+}
+
+// NewSeatAvailabilitySnapshot records the requested seats, marking those the check reported unavailable as locked
+func NewSeatAvailabilitySnapshot(seatIDs, unavailable []string, checkedAt time.Time) *SeatAvailabilitySnapshot {
+	locked := make(map[string]bool, len(unavailable))
+	for _, seatID := range unavailable {
+		locked[seatID] = true
+	}
+
+	snapshot := &SeatAvailabilitySnapshot{CheckedAt: checkedAt, Seats: make([]SeatAvailability, 0, len(seatIDs))}
+	for _, seatID := range seatIDs {
+		status := SeatSnapshotAvailable
+		if locked[seatID] {
+			status = SeatSnapshotLocked
+		}
+		snapshot.Seats = append(snapshot.Seats, SeatAvailability{SeatID: seatID, Status: status})
+	}
+	return snapshot
+}
@@ -0,0 +1,21 @@
+package models_test
+
+import (
+	"ms-ticketing/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSeatAvailabilitySnapshot(t *testing.T) {
+	checkedAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	snapshot := models.NewSeatAvailabilitySnapshot([]string{"s1", "s2", "s3"}, []string{"s2"}, checkedAt)
+
+	assert.Equal(t, checkedAt, snapshot.CheckedAt)
+	assert.Equal(t, []models.SeatAvailability{
+		{SeatID: "s1", Status: models.SeatSnapshotAvailable},
+		{SeatID: "s2", Status: models.SeatSnapshotLocked},
+		{SeatID: "s3", Status: models.SeatSnapshotAvailable},
+	}, snapshot.Seats)
+}
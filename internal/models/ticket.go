@@ -3,9 +3,17 @@ package models
 import (
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/uptrace/bun"
 )
 
+// Resale listing states of a ticket
+const (
+	ResaleStatusNone   = "none"
+	ResaleStatusListed = "listed"
+	ResaleStatusSold   = "sold"
+)
+
 type Ticket struct {
 	bun.BaseModel `bun:"table:tickets"`
 
@@ -21,6 +29,18 @@ type Ticket struct {
 	IssuedAt        time.Time `bun:"issued_at"`
 	CheckedIn       bool      `bun:"checked_in"`
 	CheckedInTime   time.Time `bun:"checked_in_time"`
+	ResaleStatus    string    `bun:"resale_status,nullzero,notnull,default:'none'"`
+	ResalePrice     float64   `bun:"resale_price,nullzero"`
+	ResaleListedAt  time.Time `bun:"resale_listed_at,nullzero"`
+}
+
+// ticketIDNamespace scopes the name-based UUIDs derived by TicketIDFor
+var ticketIDNamespace = uuid.MustParse("c7bcf005-e968-44b9-a715-90fc19f2393f")
+
+// TicketIDFor derives the ID of an order's ticket for a seat. The same order and seat always give the same
+// ID, so placing an order's tickets again updates them instead of adding duplicates.
+func TicketIDFor(orderID, seatID string) string {
+	return uuid.NewSHA1(ticketIDNamespace, []byte(orderID+":"+seatID)).String()
 }
 
 // ToStreamingTicket converts a Ticket to TicketForStreaming by excluding the QR code
@@ -8,11 +8,11 @@ import (
 
 // TicketCount represents a daily count of tickets issued for a specific event/session
 type TicketCount struct {
-	bun.BaseModel `bun:"table:ticket_counts"`
+	bun.BaseModel `bun:"table:ticket_counts,alias:tc"`
 
 	ID        int64     `bun:"id,pk,autoincrement"`
-	EventID   string    `bun:"event_id"`
-	SessionID string    `bun:"session_id"`
+	EventID   string    `bun:"event_id,unique:ticket_counts_event_session_date"`
+	SessionID string    `bun:"session_id,unique:ticket_counts_event_session_date"`
 	Count     int       `bun:"count"`
-	Date      time.Time `bun:"date"`
+	Date      time.Time `bun:"date,unique:ticket_counts_event_session_date"`
 }
\ No newline at end of file
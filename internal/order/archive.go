@@ -0,0 +1,43 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ArchiveOrdersBefore flags completed and cancelled orders created before t as archived.
+// Archived orders stay queryable by ID but are left out of analytics unless explicitly requested.
+// It returns the number of orders archived.
+func (s *OrderService) ArchiveOrdersBefore(ctx context.Context, t time.Time) (int, error) {
+	archived, err := s.DB.ArchiveOrdersBefore(ctx, t)
+	if err != nil {
+		s.logger.Error("ARCHIVE", fmt.Sprintf("Failed to archive orders created before %s: %v", t.Format(time.RFC3339), err))
+		return 0, fmt.Errorf("failed to archive orders: %w", err)
+	}
+
+	if archived > 0 {
+		s.logger.Info("ARCHIVE", fmt.Sprintf("Archived %d orders created before %s", archived, t.Format(time.RFC3339)))
+	}
+	return archived, nil
+}
+
+// StartOrderArchival archives orders older than retention every interval until ctx is cancelled
+func (s *OrderService) StartOrderArchival(ctx context.Context, interval, retention time.Duration) {
+	s.logger.Info("ARCHIVE", fmt.Sprintf("Order archival enabled: retention %s, checking every %s", retention, interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("ARCHIVE", "Stopping order archival loop")
+			return
+		case <-ticker.C:
+			if _, err := s.ArchiveOrdersBefore(ctx, time.Now().Add(-retention)); err != nil {
+				s.logger.Error("ARCHIVE", fmt.Sprintf("Order archival run failed: %v", err))
+			}
+		}
+	}
+}
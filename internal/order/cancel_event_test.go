@@ -0,0 +1,36 @@
+package order_test
+
+import (
+	"context"
+	"encoding/json"
+	"ms-ticketing/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderCancelledEventCarriesSeatLabels(t *testing.T) {
+	seats := []models.SeatDetails{
+		{SeatID: uuid.NewString(), Label: "A1", Tier: models.Tier{ID: "tier-std", Name: "Standard", Price: 10}},
+		{SeatID: uuid.NewString(), Label: "A2", Tier: models.Tier{ID: "tier-std", Name: "Standard", Price: 10}},
+	}
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+
+	resp, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+		SessionID: uuid.NewString(), EventID: "event-1", OrganizationID: "org-1", SeatIDs: []string{seats[0].SeatID, seats[1].SeatID},
+	})
+	require.NoError(t, err)
+	require.NoError(t, env.OrderService.CancelOrder(context.Background(), resp.OrderID))
+
+	cancelled := env.Producer.MessagesOn("ticketly.order.canceled")
+	require.Len(t, cancelled, 1)
+	var event struct {
+		SeatIDs    []string          `json:"seat_ids"`
+		SeatLabels map[string]string `json:"seat_labels"`
+	}
+	require.NoError(t, json.Unmarshal(cancelled[0].Value, &event))
+	assert.ElementsMatch(t, []string{seats[0].SeatID, seats[1].SeatID}, event.SeatIDs)
+	assert.Equal(t, map[string]string{seats[0].SeatID: "A1", seats[1].SeatID: "A2"}, event.SeatLabels)
+}
@@ -0,0 +1,31 @@
+package order
+
+import (
+	"context"
+
+	"github.com/stripe/stripe-go/v74"
+)
+
+// Reasons an order is cancelled, as passed to Stripe when cancelling its payment intent
+const (
+	CancellationAbandoned           = string(stripe.PaymentIntentCancellationReasonAbandoned)
+	CancellationDuplicate           = string(stripe.PaymentIntentCancellationReasonDuplicate)
+	CancellationFraudulent          = string(stripe.PaymentIntentCancellationReasonFraudulent)
+	CancellationRequestedByCustomer = string(stripe.PaymentIntentCancellationReasonRequestedByCustomer)
+)
+
+type cancellationReasonKey struct{}
+
+// WithCancellationReason returns a context under which orders are cancelled for reason
+func WithCancellationReason(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, cancellationReasonKey{}, reason)
+}
+
+// CancellationReason is why orders cancelled under ctx are cancelled: the reason set with
+// WithCancellationReason, else CancellationAbandoned, as for orders nobody paid in time
+func CancellationReason(ctx context.Context) string {
+	if reason, ok := ctx.Value(cancellationReasonKey{}).(string); ok && reason != "" {
+		return reason
+	}
+	return CancellationAbandoned
+}
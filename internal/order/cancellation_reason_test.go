@@ -0,0 +1,49 @@
+package order_test
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancellationReasonIsStoredOnOrder(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(3)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	t.Setenv("ORDER_EXPIRY_PAYMENT_GRACE_SECONDS", "0")
+	ctx := context.Background()
+
+	place := func(seatID string) string {
+		resp, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+			SessionID: uuid.NewString(), EventID: "event-1", SeatIDs: []string{seatID},
+		})
+		require.NoError(t, err)
+		return resp.OrderID
+	}
+	deleted, expired, fraud := place(seatIDs[0]), place(seatIDs[1]), place(seatIDs[2])
+
+	require.NoError(t, env.OrderService.CancelOrder(order.WithCancellationReason(ctx, order.CancellationRequestedByCustomer), deleted))
+	_, err := env.OrderService.CancelExpiredOrder(ctx, expired)
+	require.NoError(t, err)
+	require.NoError(t, env.OrderService.CancelOrder(order.WithCancellationReason(ctx, order.CancellationFraudulent), fraud))
+
+	for orderID, reason := range map[string]string{
+		deleted: "requested_by_customer",
+		expired: "abandoned",
+		fraud:   "fraudulent",
+	} {
+		o, err := env.OrderService.GetOrder(ctx, orderID)
+		require.NoError(t, err)
+		assert.Equal(t, "cancelled", o.Status)
+		assert.Equal(t, reason, o.CancellationReason)
+	}
+}
+
+func TestCancellationReasonDefaultsToAbandoned(t *testing.T) {
+	assert.Equal(t, order.CancellationAbandoned, order.CancellationReason(context.Background()))
+	assert.Equal(t, order.CancellationDuplicate, order.CancellationReason(order.WithCancellationReason(context.Background(), order.CancellationDuplicate)))
+}
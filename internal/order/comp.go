@@ -0,0 +1,178 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCompRequest is returned when a comp request lacks its session, event or seats
+var ErrInvalidCompRequest = errors.New("session_id, event_id and seats are required")
+
+// CompTicketsRequest issues free tickets for the given seats. Seats are validated like a paid order's,
+// but their labels and tiers come from the caller.
+type CompTicketsRequest struct {
+	SessionID      string `json:"session_id"`
+	EventID        string `json:"event_id"`
+	OrganizationID string `json:"organization_id"`
+	// UserID is the attendee who receives the tickets; it defaults to the issuing admin
+	UserID string               `json:"user_id"`
+	Seats  []models.SeatDetails `json:"seats"`
+}
+
+func (req CompTicketsRequest) validate() error {
+	if req.SessionID == "" || req.EventID == "" || len(req.Seats) == 0 {
+		return ErrInvalidCompRequest
+	}
+	for _, seat := range req.Seats {
+		if seat.SeatID == "" {
+			return ErrInvalidCompRequest
+		}
+	}
+	return nil
+}
+
+// CompTickets creates a completed zero-price order flagged as comped and issues a ticket with QR for every seat,
+// bypassing payment. Seats must be free or under an admin hold; held seats are released into the order.
+func (s *OrderService) CompTickets(ctx context.Context, req CompTicketsRequest, issuedBy string) (*models.OrderWithTickets, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	if s.TicketService == nil {
+		return nil, fmt.Errorf("ticket service not configured")
+	}
+	attendee := req.UserID
+	if attendee == "" {
+		attendee = issuedBy
+	}
+
+	// Split the seats into admin-held and free; anything locked by an order can't be comped
+	var held, free []string
+	for _, seat := range req.Seats {
+		lock, _, err := s.Redis.GetSeatLock(seat.SeatID)
+		if err != nil {
+			s.logger.Error("REDIS", fmt.Sprintf("Failed to read lock for seat %s: %v", seat.SeatID, err))
+			return nil, err
+		}
+		switch {
+		case lock == nil:
+			free = append(free, seat.SeatID)
+		case lock.OrderID == models.AdminHoldOrderID:
+			held = append(held, seat.SeatID)
+		default:
+			return nil, ErrSeatsUnavailable
+		}
+	}
+
+	// A sold seat whose lock has expired looks free here, so the seat services check it like a paid order
+	seatIDs := make([]string, 0, len(req.Seats))
+	for _, seat := range req.Seats {
+		seatIDs = append(seatIDs, seat.SeatID)
+	}
+	reqBody, err := json.Marshal(models.OrderRequest{
+		SessionID:      req.SessionID,
+		EventID:        req.EventID,
+		OrganizationID: req.OrganizationID,
+		SeatIDs:        seatIDs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal seat validation request: %w", err)
+	}
+	m2mToken, err := s.getM2MToken()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.preValidateOrder(reqBody, m2mToken); err != nil {
+		return nil, err
+	}
+
+	orderID := uuid.NewString()
+	if len(free) > 0 {
+		ok, err := s.Redis.LockSeats(free, req.SessionID, orderID, attendee)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock seats: %w", err)
+		}
+		if !ok {
+			return nil, ErrSeatsUnavailable
+		}
+		s.auditSeats(ctx, models.SeatAuditLocked, req.SessionID, orderID, free)
+	}
+
+	order := models.Order{
+		OrderID:        orderID,
+		UserID:         attendee,
+		EventID:        req.EventID,
+		OrganizationID: req.OrganizationID,
+		SessionID:      req.SessionID,
+		Status:         "completed",
+		Currency:       models.DefaultCurrency(),
+		IsComped:       true,
+		CreatedAt:      time.Now(),
+	}
+	// Seats locked for the comp order are released if it can't be saved; held seats keep their hold
+	release := func() {
+		if len(free) == 0 {
+			return
+		}
+		if err := s.Redis.UnlockSeats(free, orderID); err == nil {
+			s.auditSeats(ctx, models.SeatAuditUnlocked, req.SessionID, orderID, free)
+		}
+	}
+	if err := s.validateLockedSeats(reqBody, m2mToken); err != nil {
+		release()
+		return nil, err
+	}
+
+	issued := make([]models.Ticket, 0, len(req.Seats))
+	for _, seat := range req.Seats {
+		ticket, err := s.TicketService.PrepareTicket(models.Ticket{
+			TicketID:  models.TicketIDFor(orderID, seat.SeatID),
+			OrderID:   orderID,
+			SeatID:    seat.SeatID,
+			SeatLabel: seat.Label,
+			TierID:    seat.Tier.ID,
+			TierName:  seat.Tier.Name,
+			Colour:    seat.Tier.Color,
+			IssuedAt:  time.Now(),
+		})
+		if err != nil {
+			s.logger.Error("TICKET", fmt.Sprintf("Failed to create comp ticket for seat %s of order %s: %v", seat.SeatID, orderID, err))
+			release()
+			return nil, fmt.Errorf("failed to create ticket for seat %s: %w", seat.SeatID, err)
+		}
+		issued = append(issued, ticket)
+	}
+	if err := s.SaveOrderWithTickets(ctx, order, issued); err != nil {
+		release()
+		return nil, fmt.Errorf("failed to save comp order: %w", err)
+	}
+
+	tickets := make([]models.TicketForStreaming, 0, len(issued))
+	for _, ticket := range issued {
+		tickets = append(tickets, ticket.ToStreamingTicket())
+	}
+
+	// Held seats now belong to the comp order, so the hold no longer needs to block them
+	if len(held) > 0 {
+		if err := s.Redis.UnlockSeats(held, models.AdminHoldOrderID); err != nil {
+			s.logger.Error("REDIS", fmt.Sprintf("Failed to clear admin hold on comped seats of order %s: %v", orderID, err))
+		} else {
+			s.auditSeats(ctx, models.SeatAuditHoldReleased, req.SessionID, orderID, held)
+		}
+	}
+	s.logger.Info("ORDER", fmt.Sprintf("User %s comped order %s with %d tickets for user %s", issuedBy, orderID, len(tickets), attendee))
+
+	if err := s.publishSeatsBooked(ctx, models.OrderWithSeats{Order: order, SeatIDs: seatIDs}); err != nil {
+		s.logger.Error("KAFKA", fmt.Sprintf("Failed to publish seats booked event for comp order %s: %v", orderID, err))
+	}
+	comped := models.OrderWithTickets{Order: order, Tickets: tickets}
+	if err := s.publishOrderCreatedWithTickets(comped, nil); err != nil {
+		s.logger.Error("KAFKA", fmt.Sprintf("Failed to publish order created event for comp order %s: %v", orderID, err))
+	}
+	return &comped, nil
+}
@@ -0,0 +1,106 @@
+package order_test
+
+import (
+	"context"
+	"encoding/json"
+	"ms-ticketing/internal/config"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompTicketsIssuesFreeCompletedOrder(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(3)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	ctx := context.Background()
+	sessionID := uuid.NewString()
+
+	// One seat was held for press beforehand, the other is free
+	_, err := env.OrderService.HoldSeats(ctx, order.SeatHoldRequest{SessionID: sessionID, SeatIDs: seatIDs[:1]}, "admin-1")
+	require.NoError(t, err)
+	env.Producer.Reset()
+
+	comped, err := env.OrderService.CompTickets(ctx, order.CompTicketsRequest{
+		SessionID: sessionID,
+		EventID:   "event-1",
+		UserID:    "press-1",
+		Seats:     seats[:2],
+	}, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, "press-1", comped.UserID)
+	assert.Len(t, comped.Tickets, 2)
+
+	stored, err := env.OrderService.GetOrder(ctx, comped.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", stored.Status)
+	assert.True(t, stored.IsComped)
+	assert.Zero(t, stored.Price)
+	assert.Empty(t, stored.PaymentIntentID)
+
+	issued, err := env.TicketService.GetTicketsByOrder(comped.OrderID)
+	require.NoError(t, err)
+	if assert.Len(t, issued, 2) {
+		for _, ticket := range issued {
+			assert.NotEmpty(t, ticket.QRCode)
+			assert.Zero(t, ticket.PriceAtPurchase)
+		}
+	}
+
+	// The admin hold gives way to the comp order
+	lock, _, err := env.Locks.GetSeatLock(seatIDs[0])
+	require.NoError(t, err)
+	assert.Nil(t, lock)
+
+	assert.Equal(t, []string{"ticketly.seats.status", "ticketly.order.created"}, env.Producer.Topics())
+	assert.Equal(t, []models.SeatStatus{models.SeatStatusBooked}, seatStatuses(t, env.Producer.Messages()))
+	var created models.OrderWithTickets
+	require.NoError(t, json.Unmarshal(env.Producer.MessagesOn("ticketly.order.created")[0].Value, &created))
+	assert.True(t, created.IsComped)
+	assert.Len(t, created.Tickets, 2)
+
+	// Seats locked by a customer order can't be comped
+	locked, err := env.Locks.LockSeats(seatIDs[2:], sessionID, "order-1", "user-1")
+	require.NoError(t, err)
+	require.True(t, locked)
+	_, err = env.OrderService.CompTickets(ctx, order.CompTicketsRequest{SessionID: sessionID, EventID: "event-1", Seats: seats[2:]}, "admin-1")
+	assert.ErrorIs(t, err, order.ErrSeatsUnavailable)
+
+	_, err = env.OrderService.CompTickets(ctx, order.CompTicketsRequest{SessionID: sessionID, Seats: seats}, "admin-1")
+	assert.ErrorIs(t, err, order.ErrInvalidCompRequest)
+}
+
+func TestCompTicketsRejectsSeatsTheSeatServicesRefuse(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(1)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	ctx := context.Background()
+	sessionID := uuid.NewString()
+
+	// The seat was sold, but its lock has long expired
+	upstream := newUpstreamServer(t, models.OrderDetailsDTO{Seats: seats})
+	seating := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"message": "seat already booked"})
+	}))
+	t.Cleanup(seating.Close)
+	eventQuery, err := config.ParseServiceURL("EVENT_QUERY_SERVICE_URL", upstream.URL+"/event-query")
+	require.NoError(t, err)
+	eventSeating, err := config.ParseServiceURL("EVENT_SEATING_SERVICE_URL", seating.URL)
+	require.NoError(t, err)
+	env.OrderService.SetServiceURLs(eventQuery, eventSeating)
+
+	_, err = env.OrderService.CompTickets(ctx, order.CompTicketsRequest{SessionID: sessionID, EventID: "event-1", Seats: seats}, "admin-1")
+	var validationErr *order.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, http.StatusConflict, validationErr.StatusCode)
+
+	// The seat is not left locked by the refused comp
+	lock, _, err := env.Locks.GetSeatLock(seatIDs[0])
+	require.NoError(t, err)
+	assert.Nil(t, lock)
+}
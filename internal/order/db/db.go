@@ -2,7 +2,10 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"ms-ticketing/internal/models"
+	ticket_db "ms-ticketing/internal/tickets/db"
+	"time"
 
 	"github.com/uptrace/bun"
 )
@@ -14,13 +17,13 @@ type DB struct {
 // ---------------- ORDERS ----------------
 
 // GetOrderByID → fetch one order by its ID
-func (d *DB) GetOrderByID(id string) (*models.Order, error) {
+func (d *DB) GetOrderByID(ctx context.Context, id string) (*models.Order, error) {
 	var order models.Order
 	err := d.Bun.NewSelect().
 		Model(&order).
 		Where("order_id = ?", id).
 		Limit(1).
-		Scan(context.Background())
+		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -28,14 +31,14 @@ func (d *DB) GetOrderByID(id string) (*models.Order, error) {
 }
 
 // GetOrderWithSeats retrieves an order and its associated seat IDs
-func (d *DB) GetOrderWithSeats(id string) (*models.OrderWithSeats, error) {
+func (d *DB) GetOrderWithSeats(ctx context.Context, id string) (*models.OrderWithSeats, error) {
 	// First get the order
 	var order models.Order
 	err := d.Bun.NewSelect().
 		Model(&order).
 		Where("order_id = ?", id).
 		Limit(1).
-		Scan(context.Background())
+		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -46,7 +49,7 @@ func (d *DB) GetOrderWithSeats(id string) (*models.OrderWithSeats, error) {
 		Column("seat_id").
 		Table("tickets").
 		Where("order_id = ?", id).
-		Scan(context.Background(), &seatIDs)
+		Scan(ctx, &seatIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -58,69 +61,203 @@ func (d *DB) GetOrderWithSeats(id string) (*models.OrderWithSeats, error) {
 	}, nil
 }
 
-func (d *DB) UpdateOrder(order models.Order) error {
+// UpdateOrder → persist every mutable column of an order (everything except the primary key)
+func (d *DB) UpdateOrder(ctx context.Context, order models.Order) error {
 	_, err := d.Bun.NewUpdate().
 		Model(&order).
-		Column("session_id", "event_id", "user_id", "status", "price", "created_at", "payment_intent_id").
+		Column("order_number", "user_id", "event_id", "organization_id", "session_id", "status",
+			"subtotal", "discount_id", "discount_code", "discount_amount", "price", "currency",
+			"created_at", "payment_intent_id", "payment_provider", "payment_due_at", "cancellation_reason",
+			"availability_snapshot", "is_comped").
 		Where("order_id = ?", order.OrderID).
-		Exec(context.Background())
+		Exec(ctx)
 	return err
 }
 
 // CancelOrder → delete an order by ID
-func (d *DB) CancelOrder(id string) error {
+func (d *DB) CancelOrder(ctx context.Context, id string) error {
 	_, err := d.Bun.NewDelete().
 		Model((*models.Order)(nil)).
 		Where("order_id = ?", id).
-		Exec(context.Background())
+		Exec(ctx)
 	return err
 }
 
 // CreateOrder → insert new order
-func (d *DB) CreateOrder(order models.Order) error {
-	_, err := d.Bun.NewInsert().Model(&order).Exec(context.Background())
+func (d *DB) CreateOrder(ctx context.Context, order models.Order) error {
+	_, err := d.Bun.NewInsert().Model(&order).Exec(ctx)
 	return err
 }
 
+// CreateOrderWithTickets → insert an order and its tickets in one transaction, so a failure
+// leaves neither behind
+func (d *DB) CreateOrderWithTickets(ctx context.Context, order models.Order, tickets []models.Ticket) error {
+	return d.Bun.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(&order).Exec(ctx); err != nil {
+			return fmt.Errorf("failed to insert order %s: %w", order.OrderID, err)
+		}
+		for _, ticket := range tickets {
+			if err := ticket_db.InsertTicket(ctx, tx, ticket); err != nil {
+				return fmt.Errorf("failed to insert ticket for seat %s: %w", ticket.SeatID, err)
+			}
+		}
+		return nil
+	})
+}
+
 // ---------------- RELATION QUERIES ----------------
 
 // GetOrderBySeat → find an order that contains a given seat ID
-func (d *DB) GetOrderBySeat(seatID string) (*models.Order, error) {
+func (d *DB) GetOrderBySeat(ctx context.Context, seatID string) (*models.Order, error) {
 	var order models.Order
 	err := d.Bun.NewSelect().
 		Model(&order).
 		Join("JOIN tickets t ON t.order_id = \"order\".order_id").
 		Where("t.seat_id = ?", seatID).
 		Limit(1).
-		Scan(context.Background())
+		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return &order, nil
 }
 
+// GetOrdersBySeats → resolve the order holding each seat with a single join query.
+// Seats without an order are absent from the map. When a seat appears in several orders,
+// a completed order wins, otherwise the most recent one.
+func (d *DB) GetOrdersBySeats(ctx context.Context, seatIDs []string) (map[string]*models.Order, error) {
+	result := make(map[string]*models.Order, len(seatIDs))
+	if len(seatIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		models.Order `bun:",extend"`
+		SeatID       string `bun:"seat_id"`
+	}
+	err := d.Bun.NewSelect().
+		Model(&rows).
+		ColumnExpr("\"order\".*").
+		ColumnExpr("t.seat_id").
+		Join("JOIN tickets t ON t.order_id = \"order\".order_id").
+		Where("t.seat_id IN (?)", bun.In(seatIDs)).
+		Order("order.created_at ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rows {
+		row := rows[i]
+		if existing, ok := result[row.SeatID]; ok && existing.Status == "completed" && row.Status != "completed" {
+			continue
+		}
+		order := row.Order
+		result[row.SeatID] = &order
+	}
+	return result, nil
+}
+
 // GetPendingOrdersBySeat retrieves all pending orders that have a ticket with the given seat ID
-func (d *DB) GetPendingOrdersBySeat(seatID string) ([]*models.Order, error) {
+func (d *DB) GetPendingOrdersBySeat(ctx context.Context, seatID string) ([]*models.Order, error) {
 	var orders []*models.Order
 	err := d.Bun.NewSelect().
 		Model(&orders).
 		Join("JOIN tickets t ON t.order_id = \"order\".order_id").
 		Where("t.seat_id = ?", seatID).
 		Where("\"order\".status = ?", "pending").
-		Scan(context.Background())
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// GetOverduePendingOrders → pending orders whose payment window closed before dueBefore, oldest first
+func (d *DB) GetOverduePendingOrders(ctx context.Context, dueBefore time.Time) ([]models.Order, error) {
+	var orders []models.Order
+	err := d.Bun.NewSelect().
+		Model(&orders).
+		Where("status = ?", "pending").
+		Where("payment_due_at IS NOT NULL").
+		Where("payment_due_at <= ?", dueBefore).
+		Order("payment_due_at ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// CountPendingOrdersByUserSession → number of pending orders a user holds for one session
+func (d *DB) CountPendingOrdersByUserSession(ctx context.Context, userID, sessionID string) (int, error) {
+	return d.Bun.NewSelect().
+		Model((*models.Order)(nil)).
+		Where("user_id = ?", userID).
+		Where("session_id = ?", sessionID).
+		Where("status = ?", "pending").
+		Count(ctx)
+}
+
+// GetUnpaidPendingOrders → pending orders without a payment intent created within [createdAfter, createdBefore]
+func (d *DB) GetUnpaidPendingOrders(ctx context.Context, createdAfter, createdBefore time.Time) ([]models.Order, error) {
+	var orders []models.Order
+	err := d.Bun.NewSelect().
+		Model(&orders).
+		Where("status = ?", "pending").
+		Where("(payment_intent_id IS NULL OR payment_intent_id = '')").
+		Where("created_at >= ?", createdAfter).
+		Where("created_at <= ?", createdBefore).
+		Order("created_at ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// GetCompletedOrdersBetween → completed orders created within [from, to)
+func (d *DB) GetCompletedOrdersBetween(ctx context.Context, from, to time.Time) ([]models.Order, error) {
+	var orders []models.Order
+	err := d.Bun.NewSelect().
+		Model(&orders).
+		Where("status = ?", "completed").
+		Where("created_at >= ?", from).
+		Where("created_at < ?", to).
+		Order("created_at ASC").
+		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return orders, nil
 }
 
+// ArchiveOrdersBefore → mark completed and cancelled orders created before the cutoff as archived
+func (d *DB) ArchiveOrdersBefore(ctx context.Context, before time.Time) (int, error) {
+	res, err := d.Bun.NewUpdate().
+		Model((*models.Order)(nil)).
+		Set("archived = ?", true).
+		Where("archived = ?", false).
+		Where("status IN (?)", bun.In([]string{"completed", "cancelled"})).
+		Where("created_at < ?", before).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
+
 // GetTicketsByOrder → fetch all tickets linked to an order
-func (d *DB) GetTicketsByOrder(orderID string) ([]models.Ticket, error) {
+func (d *DB) GetTicketsByOrder(ctx context.Context, orderID string) ([]models.Ticket, error) {
 	var tickets []models.Ticket
 	err := d.Bun.NewSelect().
 		Model(&tickets).
 		Where("order_id = ?", orderID).
-		Scan(context.Background())
+		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -128,20 +265,20 @@ func (d *DB) GetTicketsByOrder(orderID string) ([]models.Ticket, error) {
 }
 
 // GetSeatsByOrder → fetch all seat IDs linked to an order
-func (d *DB) GetSeatsByOrder(orderID string) ([]string, error) {
+func (d *DB) GetSeatsByOrder(ctx context.Context, orderID string) ([]string, error) {
 	var seatIDs []string
 	err := d.Bun.NewSelect().
 		Column("seat_id").
 		Table("tickets").
 		Where("order_id = ?", orderID).
-		Scan(context.Background(), &seatIDs)
+		Scan(ctx, &seatIDs)
 	if err != nil {
 		return nil, err
 	}
 	return seatIDs, nil
 }
 
-func (d *DB) GetSessionIdBySeat(seatID string) (string, error) {
+func (d *DB) GetSessionIdBySeat(ctx context.Context, seatID string) (string, error) {
 	// Get session ID by joining orders and tickets tables
 	var sessionID string
 	err := d.Bun.NewSelect().
@@ -150,7 +287,7 @@ func (d *DB) GetSessionIdBySeat(seatID string) (string, error) {
 		Join("JOIN tickets ON tickets.order_id = orders.order_id").
 		Where("tickets.seat_id = ?", seatID).
 		Limit(1).
-		Scan(context.Background(), &sessionID)
+		Scan(ctx, &sessionID)
 
 	if err != nil {
 		return "", err
@@ -160,14 +297,14 @@ func (d *DB) GetSessionIdBySeat(seatID string) (string, error) {
 }
 
 // GetOrdersWithTicketsByUserID → fetch all orders with tickets for a given user_id
-func (d *DB) GetOrdersWithTicketsByUserID(userID string) ([]models.OrderWithTickets, error) {
+func (d *DB) GetOrdersWithTicketsByUserID(ctx context.Context, userID string) ([]models.OrderWithTickets, error) {
 	// First get all orders for the user
 	var orders []models.Order
 	err := d.Bun.NewSelect().
 		Model(&orders).
 		Where("user_id = ?", userID).
 		Order("created_at DESC").
-		Scan(context.Background())
+		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -189,7 +326,7 @@ func (d *DB) GetOrdersWithTicketsByUserID(userID string) ([]models.OrderWithTick
 		Model(&tickets).
 		Where("order_id IN (?)", bun.In(orderIDs)).
 		Order("order_id", "issued_at").
-		Scan(context.Background())
+		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -218,14 +355,14 @@ func (d *DB) GetOrdersWithTicketsByUserID(userID string) ([]models.OrderWithTick
 }
 
 // GetOrdersWithTicketsAndQRByUserID → fetch all orders with tickets including QR codes for a given user_id
-func (d *DB) GetOrdersWithTicketsAndQRByUserID(userID string) ([]models.OrderWithTicketsAndQR, error) {
+func (d *DB) GetOrdersWithTicketsAndQRByUserID(ctx context.Context, userID string) ([]models.OrderWithTicketsAndQR, error) {
 	// First get all orders for the user
 	var orders []models.Order
 	err := d.Bun.NewSelect().
 		Model(&orders).
 		Where("user_id = ?", userID).
 		Order("created_at DESC").
-		Scan(context.Background())
+		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -247,7 +384,7 @@ func (d *DB) GetOrdersWithTicketsAndQRByUserID(userID string) ([]models.OrderWit
 		Model(&tickets).
 		Where("order_id IN (?)", bun.In(orderIDs)).
 		Order("order_id", "issued_at").
-		Scan(context.Background())
+		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
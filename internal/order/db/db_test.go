@@ -53,7 +53,7 @@ func TestGetOrderByID(t *testing.T) {
 		EventID:   "event456",
 		SessionID: "session789",
 		Status:    "pending",
-		Price:     100.0,
+		Price:     models.MoneyFromFloat(100.0),
 		CreatedAt: time.Now(),
 	}
 
@@ -62,7 +62,7 @@ func TestGetOrderByID(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Test case: Get existing order
-	order, err := orderDB.GetOrderByID(orderID)
+	order, err := orderDB.GetOrderByID(context.Background(), orderID)
 	assert.NoError(t, err)
 	assert.NotNil(t, order)
 	assert.Equal(t, orderID, order.OrderID)
@@ -70,7 +70,7 @@ func TestGetOrderByID(t *testing.T) {
 	assert.Equal(t, "pending", order.Status)
 
 	// Test case: Get non-existent order
-	order, err = orderDB.GetOrderByID("non-existent")
+	order, err = orderDB.GetOrderByID(context.Background(), "non-existent")
 	assert.Error(t, err)
 	assert.Nil(t, order)
 }
@@ -88,12 +88,12 @@ func TestCreateAndUpdateOrder(t *testing.T) {
 		EventID:   "event456",
 		SessionID: "session789",
 		Status:    "pending",
-		Price:     100.0,
+		Price:     models.MoneyFromFloat(100.0),
 		CreatedAt: time.Now(),
 	}
 
 	// Create the order
-	err := orderDB.CreateOrder(newOrder)
+	err := orderDB.CreateOrder(context.Background(), newOrder)
 	assert.NoError(t, err)
 
 	// Verify the order was created
@@ -112,7 +112,7 @@ func TestCreateAndUpdateOrder(t *testing.T) {
 	order.PaymentIntentID = "pi_test123"
 
 	// Update the order
-	err = orderDB.UpdateOrder(order)
+	err = orderDB.UpdateOrder(context.Background(), order)
 	assert.NoError(t, err)
 
 	// Verify the order was updated
@@ -140,7 +140,7 @@ func TestCancelOrder(t *testing.T) {
 		EventID:   "event456",
 		SessionID: "session789",
 		Status:    "pending",
-		Price:     100.0,
+		Price:     models.MoneyFromFloat(100.0),
 		CreatedAt: time.Now(),
 	}
 
@@ -149,7 +149,7 @@ func TestCancelOrder(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Test case: Cancel the order
-	err = orderDB.CancelOrder(orderID)
+	err = orderDB.CancelOrder(context.Background(), orderID)
 	assert.NoError(t, err)
 
 	// Verify the order was deleted
@@ -175,7 +175,7 @@ func TestGetOrderWithSeats(t *testing.T) {
 		EventID:   "event456",
 		SessionID: "session789",
 		Status:    "pending",
-		Price:     100.0,
+		Price:     models.MoneyFromFloat(100.0),
 		CreatedAt: time.Now(),
 	}
 
@@ -211,7 +211,7 @@ func TestGetOrderWithSeats(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Test case: Get order with seats
-	orderWithSeats, err := orderDB.GetOrderWithSeats(orderID)
+	orderWithSeats, err := orderDB.GetOrderWithSeats(context.Background(), orderID)
 	assert.NoError(t, err)
 	assert.NotNil(t, orderWithSeats)
 	assert.Equal(t, orderID, orderWithSeats.OrderID)
@@ -233,7 +233,7 @@ func TestGetOrderBySeat(t *testing.T) {
 		EventID:   "event456",
 		SessionID: "session789",
 		Status:    "pending",
-		Price:     100.0,
+		Price:     models.MoneyFromFloat(100.0),
 		CreatedAt: time.Now(),
 	}
 
@@ -257,17 +257,55 @@ func TestGetOrderBySeat(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Test case: Get order by seat
-	order, err := orderDB.GetOrderBySeat("seat1")
+	order, err := orderDB.GetOrderBySeat(context.Background(), "seat1")
 	assert.NoError(t, err)
 	assert.NotNil(t, order)
 	assert.Equal(t, orderID, order.OrderID)
 
 	// Test case: Get order by non-existent seat
-	order, err = orderDB.GetOrderBySeat("non-existent")
+	order, err = orderDB.GetOrderBySeat(context.Background(), "non-existent")
 	assert.Error(t, err)
 	assert.Nil(t, order)
 }
 
+func TestGetOrdersBySeats(t *testing.T) {
+	orderDB, bunDB := setupTestDB(t)
+	defer bunDB.Close()
+
+	now := time.Now()
+	orders := []models.Order{
+		{OrderID: "order-cancelled", UserID: "u1", EventID: "e1", SessionID: "s1", Status: "cancelled", CreatedAt: now.Add(-2 * time.Hour)},
+		{OrderID: "order-completed", UserID: "u2", EventID: "e1", SessionID: "s1", Status: "completed", CreatedAt: now.Add(-time.Hour)},
+		{OrderID: "order-pending", UserID: "u3", EventID: "e1", SessionID: "s1", Status: "pending", CreatedAt: now},
+	}
+	for i := range orders {
+		_, err := bunDB.NewInsert().Model(&orders[i]).Exec(context.Background())
+		assert.NoError(t, err)
+	}
+
+	tickets := []models.Ticket{
+		{TicketID: uuid.New().String(), OrderID: "order-cancelled", SeatID: "seat1", IssuedAt: now},
+		{TicketID: uuid.New().String(), OrderID: "order-completed", SeatID: "seat1", IssuedAt: now},
+		{TicketID: uuid.New().String(), OrderID: "order-pending", SeatID: "seat1", IssuedAt: now},
+		{TicketID: uuid.New().String(), OrderID: "order-pending", SeatID: "seat2", IssuedAt: now},
+	}
+	for i := range tickets {
+		_, err := bunDB.NewInsert().Model(&tickets[i]).Exec(context.Background())
+		assert.NoError(t, err)
+	}
+
+	result, err := orderDB.GetOrdersBySeats(context.Background(), []string{"seat1", "seat2", "seat3"})
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "order-completed", result["seat1"].OrderID)
+	assert.Equal(t, "order-pending", result["seat2"].OrderID)
+	assert.NotContains(t, result, "seat3")
+
+	result, err = orderDB.GetOrdersBySeats(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+}
+
 func TestGetPendingOrdersBySeat(t *testing.T) {
 	// Set up test DB
 	orderDB, bunDB := setupTestDB(t)
@@ -284,7 +322,7 @@ func TestGetPendingOrdersBySeat(t *testing.T) {
 			EventID:   "event456",
 			SessionID: "session789",
 			Status:    "pending",
-			Price:     100.0,
+			Price:     models.MoneyFromFloat(100.0),
 			CreatedAt: time.Now(),
 		},
 		{
@@ -293,7 +331,7 @@ func TestGetPendingOrdersBySeat(t *testing.T) {
 			EventID:   "event456",
 			SessionID: "session789",
 			Status:    "completed",
-			Price:     100.0,
+			Price:     models.MoneyFromFloat(100.0),
 			CreatedAt: time.Now(),
 		},
 	}
@@ -330,7 +368,7 @@ func TestGetPendingOrdersBySeat(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Test case: Get pending orders by seat
-	orders, err := orderDB.GetPendingOrdersBySeat("seat1")
+	orders, err := orderDB.GetPendingOrdersBySeat(context.Background(), "seat1")
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(orders))
 	assert.Equal(t, pendingOrderID, orders[0].OrderID)
@@ -356,7 +394,7 @@ func TestGetOrdersWithTicketsByUserID(t *testing.T) {
 			EventID:   "event456",
 			SessionID: "session789",
 			Status:    "completed",
-			Price:     100.0,
+			Price:     models.MoneyFromFloat(100.0),
 			CreatedAt: time.Now(),
 		},
 		{
@@ -365,7 +403,7 @@ func TestGetOrdersWithTicketsByUserID(t *testing.T) {
 			EventID:   "event456",
 			SessionID: "session789",
 			Status:    "pending",
-			Price:     150.0,
+			Price:     models.MoneyFromFloat(150.0),
 			CreatedAt: time.Now(),
 		},
 	}
@@ -412,7 +450,7 @@ func TestGetOrdersWithTicketsByUserID(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Test case: Get orders with tickets by user ID
-	ordersWithTickets, err := orderDB.GetOrdersWithTicketsByUserID(userID)
+	ordersWithTickets, err := orderDB.GetOrdersWithTicketsByUserID(context.Background(), userID)
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(ordersWithTickets))
 	
@@ -431,4 +469,284 @@ func TestGetOrdersWithTicketsByUserID(t *testing.T) {
 	
 	assert.Equal(t, 2, len(order1.Tickets))
 	assert.Equal(t, 1, len(order2.Tickets))
-}
\ No newline at end of file
+}
+func TestGetUnpaidPendingOrders(t *testing.T) {
+	// Set up test DB
+	orderDB, bunDB := setupTestDB(t)
+	defer bunDB.Close()
+
+	now := time.Now()
+	dueOrderID := uuid.New().String()
+
+	testOrders := []models.Order{
+		{
+			// Pending, unpaid and inside the window
+			OrderID:   dueOrderID,
+			UserID:    "user123",
+			EventID:   "event456",
+			SessionID: "session789",
+			Status:    "pending",
+			Price:     models.MoneyFromFloat(100.0),
+			CreatedAt: now.Add(-3 * time.Minute),
+		},
+		{
+			// Already has a payment intent
+			OrderID:         uuid.New().String(),
+			UserID:          "user123",
+			EventID:         "event456",
+			SessionID:       "session789",
+			Status:          "pending",
+			Price:           models.MoneyFromFloat(100.0),
+			CreatedAt:       now.Add(-3 * time.Minute),
+			PaymentIntentID: "pi_test123",
+		},
+		{
+			// Too recent to remind
+			OrderID:   uuid.New().String(),
+			UserID:    "user123",
+			EventID:   "event456",
+			SessionID: "session789",
+			Status:    "pending",
+			Price:     models.MoneyFromFloat(100.0),
+			CreatedAt: now,
+		},
+		{
+			// Seat lock already expired
+			OrderID:   uuid.New().String(),
+			UserID:    "user123",
+			EventID:   "event456",
+			SessionID: "session789",
+			Status:    "pending",
+			Price:     models.MoneyFromFloat(100.0),
+			CreatedAt: now.Add(-10 * time.Minute),
+		},
+		{
+			// Not pending
+			OrderID:   uuid.New().String(),
+			UserID:    "user123",
+			EventID:   "event456",
+			SessionID: "session789",
+			Status:    "completed",
+			Price:     models.MoneyFromFloat(100.0),
+			CreatedAt: now.Add(-3 * time.Minute),
+		},
+	}
+
+	_, err := bunDB.NewInsert().Model(&testOrders).Exec(context.Background())
+	assert.NoError(t, err)
+
+	// Test case: only the due order is returned
+	orders, err := orderDB.GetUnpaidPendingOrders(context.Background(), now.Add(-5*time.Minute), now.Add(-2*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(orders))
+	assert.Equal(t, dueOrderID, orders[0].OrderID)
+}
+
+func TestArchiveOrdersBefore(t *testing.T) {
+	// Set up test DB
+	orderDB, bunDB := setupTestDB(t)
+	defer bunDB.Close()
+
+	now := time.Now()
+	cutoff := now.Add(-30 * 24 * time.Hour)
+	completedID := uuid.New().String()
+	cancelledID := uuid.New().String()
+	pendingID := uuid.New().String()
+	recentID := uuid.New().String()
+
+	testOrders := []models.Order{
+		{OrderID: completedID, UserID: "user123", EventID: "event456", Status: "completed", CreatedAt: cutoff.Add(-time.Hour)},
+		{OrderID: cancelledID, UserID: "user123", EventID: "event456", Status: "cancelled", CreatedAt: cutoff.Add(-time.Hour)},
+		// Old but never finished, so it is left alone
+		{OrderID: pendingID, UserID: "user123", EventID: "event456", Status: "pending", CreatedAt: cutoff.Add(-time.Hour)},
+		// Finished but still inside the retention window
+		{OrderID: recentID, UserID: "user123", EventID: "event456", Status: "completed", CreatedAt: now},
+	}
+
+	_, err := bunDB.NewInsert().Model(&testOrders).Exec(context.Background())
+	assert.NoError(t, err)
+
+	archived, err := orderDB.ArchiveOrdersBefore(context.Background(), cutoff)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, archived)
+
+	expected := map[string]bool{completedID: true, cancelledID: true, pendingID: false, recentID: false}
+	for orderID, want := range expected {
+		order, err := orderDB.GetOrderByID(context.Background(), orderID)
+		assert.NoError(t, err)
+		assert.Equal(t, want, order.Archived, "order %s", orderID)
+	}
+
+	// Running again archives nothing new
+	archived, err = orderDB.ArchiveOrdersBefore(context.Background(), cutoff)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, archived)
+}
+
+func TestUpdateOrderRoundTripsAllFields(t *testing.T) {
+	// Set up test DB
+	orderDB, bunDB := setupTestDB(t)
+	defer bunDB.Close()
+
+	orderID := uuid.New().String()
+	err := orderDB.CreateOrder(context.Background(), models.Order{
+		OrderID:   orderID,
+		UserID:    "user123",
+		EventID:   "event456",
+		SessionID: "session789",
+		Status:    "pending",
+		SubTotal:  models.MoneyFromFloat(100.0),
+		Price:     models.MoneyFromFloat(100.0),
+		CreatedAt: time.Now(),
+	})
+	assert.NoError(t, err)
+
+	// Change every mutable column
+	snapshot := &models.SeatAvailabilitySnapshot{
+		CheckedAt: time.Now().Add(-time.Hour).UTC().Truncate(time.Second),
+		LockedAt:  time.Now().Add(-time.Hour).UTC().Truncate(time.Second),
+		Seats:     []models.SeatAvailability{{SeatID: "seat-1", Status: "available"}},
+	}
+	updated := models.Order{
+		OrderID:              orderID,
+		OrderNumber:          "EVT-20240617-000123",
+		UserID:               "user999",
+		EventID:              "event999",
+		OrganizationID:       "org999",
+		SessionID:            "session999",
+		Status:               "completed",
+		SubTotal:             models.MoneyFromFloat(200.0),
+		DiscountID:           "discount123",
+		DiscountCode:         "SAVE20",
+		DiscountAmount:       models.MoneyFromFloat(40.0),
+		Price:                models.MoneyFromFloat(160.0),
+		Currency:             "USD",
+		CreatedAt:            time.Now().Add(-time.Hour).UTC().Truncate(time.Second),
+		PaymentIntentID:      "pi_test123",
+		PaymentProvider:      "payhere",
+		PaymentDueAt:         time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+		CancellationReason:   "abandoned",
+		AvailabilitySnapshot: snapshot,
+		Archived:             true,
+		IsComped:             true,
+	}
+	err = orderDB.UpdateOrder(context.Background(), updated)
+	assert.NoError(t, err)
+
+	stored, err := orderDB.GetOrderByID(context.Background(), orderID)
+	assert.NoError(t, err)
+	assert.Equal(t, updated.UserID, stored.UserID)
+	assert.Equal(t, updated.EventID, stored.EventID)
+	assert.Equal(t, updated.OrganizationID, stored.OrganizationID)
+	assert.Equal(t, updated.SessionID, stored.SessionID)
+	assert.Equal(t, updated.Status, stored.Status)
+	assert.Equal(t, updated.SubTotal, stored.SubTotal)
+	assert.Equal(t, updated.DiscountID, stored.DiscountID)
+	assert.Equal(t, updated.DiscountCode, stored.DiscountCode)
+	assert.Equal(t, updated.DiscountAmount, stored.DiscountAmount)
+	assert.Equal(t, updated.Price, stored.Price)
+	assert.True(t, updated.CreatedAt.Equal(stored.CreatedAt))
+	assert.Equal(t, updated.PaymentIntentID, stored.PaymentIntentID)
+	assert.Equal(t, updated.OrderNumber, stored.OrderNumber)
+	assert.Equal(t, updated.Currency, stored.Currency)
+	assert.Equal(t, updated.PaymentProvider, stored.PaymentProvider)
+	assert.True(t, updated.PaymentDueAt.Equal(stored.PaymentDueAt))
+	assert.Equal(t, updated.CancellationReason, stored.CancellationReason)
+	if assert.NotNil(t, stored.AvailabilitySnapshot) {
+		assert.Equal(t, snapshot.Seats, stored.AvailabilitySnapshot.Seats)
+		assert.True(t, snapshot.CheckedAt.Equal(stored.AvailabilitySnapshot.CheckedAt))
+	}
+	// Only the archive job marks orders archived
+	assert.False(t, stored.Archived)
+	assert.True(t, stored.IsComped)
+
+	// A later status-only change must not wipe the payment and discount fields
+	stored.Status = "cancelled"
+	err = orderDB.UpdateOrder(context.Background(), *stored)
+	assert.NoError(t, err)
+
+	final, err := orderDB.GetOrderByID(context.Background(), orderID)
+	assert.NoError(t, err)
+	assert.Equal(t, "cancelled", final.Status)
+	assert.Equal(t, "pi_test123", final.PaymentIntentID)
+	assert.Equal(t, "SAVE20", final.DiscountCode)
+	assert.Equal(t, models.MoneyFromFloat(40), final.DiscountAmount)
+	assert.Equal(t, "EVT-20240617-000123", final.OrderNumber)
+	assert.Equal(t, "USD", final.Currency)
+	assert.True(t, updated.PaymentDueAt.Equal(final.PaymentDueAt))
+	assert.NotNil(t, final.AvailabilitySnapshot)
+	assert.True(t, final.IsComped)
+}
+
+func TestQueriesHonourContextCancellation(t *testing.T) {
+	orderDB, bunDB := setupTestDB(t)
+	defer bunDB.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A request that has gone away must not keep running queries
+	_, err := orderDB.GetOrderByID(ctx, "any-order")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	err = orderDB.CreateOrder(ctx, models.Order{OrderID: uuid.New().String(), Status: "pending", CreatedAt: time.Now()})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestOrderNotes(t *testing.T) {
+	orderDB, bunDB := setupTestDB(t)
+	defer bunDB.Close()
+	ctx := context.Background()
+
+	_, err := bunDB.NewCreateTable().Model((*models.OrderNote)(nil)).Exec(ctx)
+	assert.NoError(t, err)
+
+	orderID := uuid.New().String()
+	notes, err := orderDB.GetOrderNotes(ctx, orderID)
+	assert.NoError(t, err)
+	assert.Empty(t, notes)
+
+	first := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	assert.NoError(t, orderDB.CreateOrderNote(ctx, models.OrderNote{NoteID: uuid.New().String(), OrderID: orderID, AuthorUserID: "agent2", Note: "refund approved", CreatedAt: first.Add(time.Minute)}))
+	assert.NoError(t, orderDB.CreateOrderNote(ctx, models.OrderNote{NoteID: uuid.New().String(), OrderID: orderID, AuthorUserID: "agent1", Note: "customer called", CreatedAt: first}))
+	assert.NoError(t, orderDB.CreateOrderNote(ctx, models.OrderNote{NoteID: uuid.New().String(), OrderID: uuid.New().String(), AuthorUserID: "agent1", Note: "other order", CreatedAt: first}))
+
+	notes, err = orderDB.GetOrderNotes(ctx, orderID)
+	assert.NoError(t, err)
+	if assert.Len(t, notes, 2) {
+		assert.Equal(t, "customer called", notes[0].Note)
+		assert.Equal(t, "agent1", notes[0].AuthorUserID)
+		assert.Equal(t, "refund approved", notes[1].Note)
+	}
+}
+
+func TestCreateOrderWithTickets(t *testing.T) {
+	orderDB, bunDB := setupTestDB(t)
+	defer bunDB.Close()
+	ctx := context.Background()
+
+	order := models.Order{OrderID: uuid.New().String(), UserID: "user1", Status: "pending", CreatedAt: time.Now()}
+	tickets := []models.Ticket{
+		{TicketID: uuid.New().String(), OrderID: order.OrderID, SeatID: "seat1", IssuedAt: time.Now()},
+		{TicketID: uuid.New().String(), OrderID: order.OrderID, SeatID: "seat2", IssuedAt: time.Now()},
+	}
+	assert.NoError(t, orderDB.CreateOrderWithTickets(ctx, order, tickets))
+
+	seats, err := orderDB.GetSeatsByOrder(ctx, order.OrderID)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"seat1", "seat2"}, seats)
+
+	// A ticket ID owned by another order fails the insert midway, so nothing of the order is kept
+	failing := models.Order{OrderID: uuid.New().String(), UserID: "user1", Status: "pending", CreatedAt: time.Now()}
+	err = orderDB.CreateOrderWithTickets(ctx, failing, []models.Ticket{
+		{TicketID: uuid.New().String(), OrderID: failing.OrderID, SeatID: "seat3", IssuedAt: time.Now()},
+		{TicketID: tickets[0].TicketID, OrderID: failing.OrderID, SeatID: "seat1", IssuedAt: time.Now()},
+	})
+	assert.Error(t, err)
+
+	_, err = orderDB.GetOrderByID(ctx, failing.OrderID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	count, err := bunDB.NewSelect().Model((*models.Ticket)(nil)).Where("order_id = ?", failing.OrderID).Count(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+}
@@ -0,0 +1,26 @@
+package db
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+)
+
+// CreateOrderNote → insert a support note for an order
+func (d *DB) CreateOrderNote(ctx context.Context, note models.OrderNote) error {
+	_, err := d.Bun.NewInsert().Model(&note).Exec(ctx)
+	return err
+}
+
+// GetOrderNotes → all notes for an order, oldest first
+func (d *DB) GetOrderNotes(ctx context.Context, orderID string) ([]models.OrderNote, error) {
+	notes := []models.OrderNote{}
+	err := d.Bun.NewSelect().
+		Model(&notes).
+		Where("order_id = ?", orderID).
+		Order("created_at ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
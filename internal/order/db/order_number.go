@@ -0,0 +1,35 @@
+package db
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+)
+
+// NextOrderNumber → atomically take the next sequence number of a day, starting at 1
+func (d *DB) NextOrderNumber(ctx context.Context, day string) (int64, error) {
+	counter := models.OrderNumberCounter{Day: day, LastNumber: 1}
+	_, err := d.Bun.NewInsert().
+		Model(&counter).
+		On("CONFLICT (day) DO UPDATE").
+		Set("last_number = onc.last_number + 1").
+		Returning("last_number").
+		Exec(ctx, &counter.LastNumber)
+	if err != nil {
+		return 0, err
+	}
+	return counter.LastNumber, nil
+}
+
+// GetOrderByNumber → find an order by its human-readable order number
+func (d *DB) GetOrderByNumber(ctx context.Context, number string) (*models.Order, error) {
+	var order models.Order
+	err := d.Bun.NewSelect().
+		Model(&order).
+		Where("order_number = ?", number).
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
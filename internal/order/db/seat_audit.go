@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+)
+
+// CreateSeatAuditEvents → insert a batch of seat lock audit rows
+func (d *DB) CreateSeatAuditEvents(ctx context.Context, events []models.SeatAuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	_, err := d.Bun.NewInsert().Model(&events).Exec(ctx)
+	return err
+}
+
+// GetSeatAuditEvents → audit rows for a seat and/or session, newest first.
+// Empty filters are ignored; limit caps the number of rows.
+func (d *DB) GetSeatAuditEvents(ctx context.Context, seatID, sessionID string, limit int) ([]models.SeatAuditEvent, error) {
+	events := []models.SeatAuditEvent{}
+	q := d.Bun.NewSelect().Model(&events)
+	if seatID != "" {
+		q = q.Where("seat_id = ?", seatID)
+	}
+	if sessionID != "" {
+		q = q.Where("session_id = ?", sessionID)
+	}
+	err := q.Order("created_at DESC").Limit(limit).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,26 @@
+package db
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+)
+
+// CreateOrderStatusChange → append a row to an order's status timeline
+func (d *DB) CreateOrderStatusChange(ctx context.Context, change models.OrderStatusChange) error {
+	_, err := d.Bun.NewInsert().Model(&change).Exec(ctx)
+	return err
+}
+
+// GetOrderStatusHistory → an order's status timeline, oldest first
+func (d *DB) GetOrderStatusHistory(ctx context.Context, orderID string) ([]models.OrderStatusChange, error) {
+	changes := []models.OrderStatusChange{}
+	err := d.Bun.NewSelect().
+		Model(&changes).
+		Where("order_id = ?", orderID).
+		Order("created_at ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"ms-ticketing/internal/logger"
 	"ms-ticketing/internal/models"
+	"os"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -27,13 +30,96 @@ type ApplyDiscountResult struct {
 	DiscountAmount  float64  // Amount of the discount to be applied
 	Reason          string   // Reason why discount was not applied (if invalid)
 	ApplicableTiers []string // Tiers to which the discount applies
+	CapApplied      bool     // Whether DiscountAmount was clamped to the maximum discount per order
+	UncappedAmount  float64  // Discount the code alone would have given, when the cap was applied
 }
 
-// ValidateAndCalculateDiscount validates and calculates the discount for an order
+// MaxDiscountAmount is the most one order may be discounted, whatever its code: the event's own cap
+// when it sets one, else MAX_DISCOUNT_AMOUNT. Zero means no cap.
+func MaxDiscountAmount(eventCap float64) float64 {
+	if eventCap > 0 {
+		return eventCap
+	}
+	amount, err := strconv.ParseFloat(os.Getenv("MAX_DISCOUNT_AMOUNT"), 64)
+	if err != nil || amount < 0 {
+		return 0
+	}
+	return amount
+}
+
+// ErrInvalidDiscountConfig wraps every rejection caused by a malformed discount definition,
+// as opposed to a valid discount that simply doesn't apply to the order
+var ErrInvalidDiscountConfig = errors.New("invalid discount configuration")
+
+func invalidConfig(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidDiscountConfig, fmt.Sprintf(format, args...))
+}
+
+// ValidateParameters checks that a discount's type is known and its parameters are within bounds
+func ValidateParameters(params models.DiscountParameters) error {
+	if params.MinSpend != nil && *params.MinSpend < 0 {
+		return invalidConfig("minSpend must not be negative, got %.2f", *params.MinSpend)
+	}
+	if params.MaxDiscount != nil && *params.MaxDiscount < 0 {
+		return invalidConfig("maxDiscount must not be negative, got %.2f", *params.MaxDiscount)
+	}
+
+	switch params.Type {
+	case models.PERCENTAGE:
+		if params.Percentage == nil {
+			return invalidConfig("percentage parameter is required for PERCENTAGE discount type")
+		}
+		if *params.Percentage < 0 || *params.Percentage > 100 {
+			return invalidConfig("percentage must be between 0 and 100, got %.2f", *params.Percentage)
+		}
+	case models.FLAT_OFF:
+		if params.Amount == nil {
+			return invalidConfig("amount parameter is required for FLAT_OFF discount type")
+		}
+		if *params.Amount < 0 {
+			return invalidConfig("amount must not be negative, got %.2f", *params.Amount)
+		}
+	case models.BUY_N_GET_N_FREE:
+		if params.BuyQuantity == nil || params.GetQuantity == nil {
+			return invalidConfig("buyQuantity and getQuantity parameters are required for BUY_N_GET_N_FREE discount type")
+		}
+		if *params.BuyQuantity <= 0 || *params.GetQuantity <= 0 {
+			return invalidConfig("buyQuantity and getQuantity must be positive, got %d and %d", *params.BuyQuantity, *params.GetQuantity)
+		}
+	case "":
+		return invalidConfig("discount type is required")
+	default:
+		return invalidConfig("unsupported discount type: %s", params.Type)
+	}
+	return nil
+}
+
+// NormalizeCode trims surrounding whitespace and uppercases a discount code so
+// " save10 ", "Save10" and "SAVE10" all compare and store the same way
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// ValidateAndCalculateDiscount validates and calculates the discount for an order.
+// enteredCode is the code the user typed, if any; it must match the discount's code after normalization.
+// The discount never exceeds MAX_DISCOUNT_AMOUNT.
 func (s *DiscountService) ValidateAndCalculateDiscount(
 	discount *models.Discount,
 	seats []models.SeatDetails,
 	orderSessionID string,
+	enteredCode string,
+) (*ApplyDiscountResult, error) {
+	return s.ValidateAndCalculateEventDiscount(discount, seats, orderSessionID, enteredCode, 0)
+}
+
+// ValidateAndCalculateEventDiscount is ValidateAndCalculateDiscount for an event with its own
+// maximum discount per order, which takes precedence over MAX_DISCOUNT_AMOUNT
+func (s *DiscountService) ValidateAndCalculateEventDiscount(
+	discount *models.Discount,
+	seats []models.SeatDetails,
+	orderSessionID string,
+	enteredCode string,
+	eventCap float64,
 ) (*ApplyDiscountResult, error) {
 	// Step 0: Initialize the result
 	result := &ApplyDiscountResult{
@@ -48,23 +134,36 @@ func (s *DiscountService) ValidateAndCalculateDiscount(
 		return result, nil
 	}
 
+	// A malformed definition is a configuration bug, not a reason to quietly apply some other amount
+	if err := ValidateParameters(discount.Parameters); err != nil {
+		s.logger.Error("DISCOUNT", fmt.Sprintf("Discount %s is misconfigured: %v", discount.ID, err))
+		return nil, err
+	}
+
 	// Step 1: Perform universal pre-condition checks
+	// The code the user typed must be the discount they were given, ignoring case and stray spaces
+	if enteredCode != "" && NormalizeCode(enteredCode) != NormalizeCode(discount.Code) {
+		result.Reason = "Discount code does not match"
+		return result, nil
+	}
+
 	// Check if discount is active
 	if !discount.Active {
 		result.Reason = "Discount is not active"
 		return result, nil
 	}
 
-	// Check if current date is within the discount window
-	now := time.Now()
+	// Check if current time is within the discount window [activeFrom, expiresAt).
+	// Everything is compared in UTC so a midnight cutoff is the same instant on every pod.
+	now := time.Now().UTC()
 	// Only check activeFrom if it's not null
-	if discount.ActiveFrom != nil && now.Before(*discount.ActiveFrom) {
-		result.Reason = "Discount is not yet active"
+	if discount.ActiveFrom != nil && now.Before(discount.ActiveFrom.UTC()) {
+		result.Reason = "Discount not yet active"
 		return result, nil
 	}
-	// Only check expiresAt if it's not null
-	if discount.ExpiresAt != nil && now.After(*discount.ExpiresAt) {
-		result.Reason = "Discount has expired"
+	// Only check expiresAt if it's not null; the discount stops working at the expiry instant itself
+	if discount.ExpiresAt != nil && !now.Before(discount.ExpiresAt.UTC()) {
+		result.Reason = "Discount expired"
 		return result, nil
 	}
 
@@ -118,10 +217,10 @@ func (s *DiscountService) ValidateAndCalculateDiscount(
 		return result, nil
 	}
 
-	// Check minimum spend for PERCENTAGE and FLAT_OFF types
-	if discount.Parameters.Type == models.PERCENTAGE || discount.Parameters.Type == models.FLAT_OFF {
-		if discount.Parameters.MinSpend != nil && cartSubtotal < *discount.Parameters.MinSpend {
-			result.Reason = fmt.Sprintf("Cart subtotal does not meet minimum spend requirement of %.2f", *discount.Parameters.MinSpend)
+	// Check the minimum purchase threshold against the whole cart, in minor units so 4999.999 can't pass as 5000
+	if minSpend := discount.Parameters.MinSpend; minSpend != nil {
+		if models.MoneyFromFloat(cartSubtotal) < models.MoneyFromFloat(*minSpend) {
+			result.Reason = fmt.Sprintf("minimum purchase not met: requires %.2f, cart subtotal is %.2f", *minSpend, cartSubtotal)
 			return result, nil
 		}
 	}
@@ -130,20 +229,15 @@ func (s *DiscountService) ValidateAndCalculateDiscount(
 	var discountAmount float64
 	switch discount.Parameters.Type {
 	case models.FLAT_OFF:
-		// FLAT_OFF: Fixed amount off, capped at the applicable items subtotal
-		if discount.Parameters.Amount == nil {
-			return nil, errors.New("amount parameter is required for FLAT_OFF discount type")
-		}
+		// FLAT_OFF: Fixed amount off, which may not exceed the applicable items subtotal
 		discountAmount = *discount.Parameters.Amount
 		if discountAmount > applicableItemsSubtotal {
-			discountAmount = applicableItemsSubtotal
+			result.Reason = fmt.Sprintf("Fixed discount of %.2f exceeds the order subtotal of %.2f", discountAmount, applicableItemsSubtotal)
+			return result, nil
 		}
 
 	case models.PERCENTAGE:
 		// PERCENTAGE: Percentage off the applicable items subtotal, optionally capped
-		if discount.Parameters.Percentage == nil {
-			return nil, errors.New("percentage parameter is required for PERCENTAGE discount type")
-		}
 		discountAmount = applicableItemsSubtotal * (*discount.Parameters.Percentage / 100)
 
 		// Apply max discount cap if specified
@@ -153,10 +247,6 @@ func (s *DiscountService) ValidateAndCalculateDiscount(
 
 	case models.BUY_N_GET_N_FREE:
 		// BUY_N_GET_N_FREE: Buy N items, get M items free (cheapest items are free)
-		if discount.Parameters.BuyQuantity == nil || discount.Parameters.GetQuantity == nil {
-			return nil, errors.New("buyQuantity and getQuantity parameters are required for BUY_N_GET_N_FREE discount type")
-		}
-
 		buyQty := *discount.Parameters.BuyQuantity
 		getQty := *discount.Parameters.GetQuantity
 
@@ -186,9 +276,18 @@ func (s *DiscountService) ValidateAndCalculateDiscount(
 		return nil, fmt.Errorf("unsupported discount type: %s", discount.Parameters.Type)
 	}
 
-	// Final validation: discount cannot exceed the cart subtotal
-	if discountAmount > cartSubtotal {
-		discountAmount = cartSubtotal
+	// Final validation: only eligible seats can be discounted, so seats outside the
+	// applicable tiers always keep their full price
+	if discountAmount > applicableItemsSubtotal {
+		discountAmount = applicableItemsSubtotal
+	}
+
+	// A finance ceiling on top of the code's own rules, so a misconfigured code can't give away a premium order
+	if maxAmount := MaxDiscountAmount(eventCap); maxAmount > 0 && models.MoneyFromFloat(discountAmount) > models.MoneyFromFloat(maxAmount) {
+		s.logger.Warn("DISCOUNT", fmt.Sprintf("Discount %s of %.2f exceeds the maximum of %.2f per order, capping it", discount.ID, discountAmount, maxAmount))
+		result.CapApplied = true
+		result.UncappedAmount = discountAmount
+		discountAmount = maxAmount
 	}
 
 	// Set result values
@@ -0,0 +1,281 @@
+package discount_test
+
+import (
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order/discount"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+func seatsAt(prices ...float64) []models.SeatDetails {
+	seats := make([]models.SeatDetails, len(prices))
+	for i, price := range prices {
+		seats[i] = models.SeatDetails{Tier: models.Tier{ID: "tier-general", Price: price}}
+	}
+	return seats
+}
+
+func TestNormalizeCode(t *testing.T) {
+	for _, code := range []string{" save10 ", "Save10", "SAVE10", "\tsave10\n"} {
+		assert.Equal(t, "SAVE10", discount.NormalizeCode(code), "code %q", code)
+	}
+}
+
+func TestValidateAndCalculateDiscountMatchesCodeCaseInsensitively(t *testing.T) {
+	svc := discount.NewDiscountService()
+	configured := &models.Discount{
+		ID:     "discount1",
+		Code:   "SAVE10",
+		Active: true,
+		Parameters: models.DiscountParameters{
+			Type:       models.PERCENTAGE,
+			Percentage: float64Ptr(10),
+		},
+	}
+
+	for _, entered := range []string{" save10 ", "Save10", "SAVE10"} {
+		result, err := svc.ValidateAndCalculateDiscount(configured, seatsAt(1000), "session1", entered)
+		assert.NoError(t, err)
+		assert.True(t, result.IsValid, "code %q should match", entered)
+		assert.Equal(t, 100.0, result.DiscountAmount)
+	}
+
+	result, err := svc.ValidateAndCalculateDiscount(configured, seatsAt(1000), "session1", "SAVE20")
+	assert.NoError(t, err)
+	assert.False(t, result.IsValid)
+	assert.Equal(t, "Discount code does not match", result.Reason)
+}
+
+func TestValidateAndCalculateDiscountRejectsMisconfiguredPercentage(t *testing.T) {
+	svc := discount.NewDiscountService()
+	misconfigured := &models.Discount{
+		ID:     "discount1",
+		Code:   "HALFPLUS",
+		Active: true,
+		Parameters: models.DiscountParameters{
+			Type:       models.PERCENTAGE,
+			Percentage: float64Ptr(150),
+		},
+	}
+
+	result, err := svc.ValidateAndCalculateDiscount(misconfigured, seatsAt(1000), "session1", "")
+	assert.ErrorIs(t, err, discount.ErrInvalidDiscountConfig)
+	assert.Contains(t, err.Error(), "between 0 and 100")
+	assert.Nil(t, result)
+}
+
+func TestValidateAndCalculateDiscountRejectsFixedAmountAboveSubtotal(t *testing.T) {
+	svc := discount.NewDiscountService()
+	flat := &models.Discount{
+		ID:     "discount1",
+		Code:   "BIGFLAT",
+		Active: true,
+		Parameters: models.DiscountParameters{
+			Type:   models.FLAT_OFF,
+			Amount: float64Ptr(5000),
+		},
+	}
+
+	result, err := svc.ValidateAndCalculateDiscount(flat, seatsAt(1500, 1500), "session1", "")
+	assert.NoError(t, err)
+	assert.False(t, result.IsValid)
+	assert.Contains(t, result.Reason, "exceeds the order subtotal")
+	assert.Zero(t, result.DiscountAmount)
+
+	// Within the subtotal the full amount applies
+	result, err = svc.ValidateAndCalculateDiscount(flat, seatsAt(3000, 3000), "session1", "")
+	assert.NoError(t, err)
+	assert.True(t, result.IsValid)
+	assert.Equal(t, 5000.0, result.DiscountAmount)
+}
+
+func TestValidateParameters(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	cases := map[string]models.DiscountParameters{
+		"missing type":          {},
+		"unknown type":          {Type: "MYSTERY"},
+		"missing percentage":    {Type: models.PERCENTAGE},
+		"negative percentage":   {Type: models.PERCENTAGE, Percentage: float64Ptr(-5)},
+		"missing amount":        {Type: models.FLAT_OFF},
+		"negative amount":       {Type: models.FLAT_OFF, Amount: float64Ptr(-1)},
+		"zero buy quantity":     {Type: models.BUY_N_GET_N_FREE, BuyQuantity: intPtr(0), GetQuantity: intPtr(1)},
+		"negative min spend":    {Type: models.FLAT_OFF, Amount: float64Ptr(10), MinSpend: float64Ptr(-1)},
+		"negative max discount": {Type: models.PERCENTAGE, Percentage: float64Ptr(10), MaxDiscount: float64Ptr(-1)},
+	}
+	for name, params := range cases {
+		assert.ErrorIs(t, discount.ValidateParameters(params), discount.ErrInvalidDiscountConfig, name)
+	}
+
+	assert.NoError(t, discount.ValidateParameters(models.DiscountParameters{Type: models.PERCENTAGE, Percentage: float64Ptr(100)}))
+	assert.NoError(t, discount.ValidateParameters(models.DiscountParameters{Type: models.BUY_N_GET_N_FREE, BuyQuantity: intPtr(2), GetQuantity: intPtr(1)}))
+}
+
+func TestValidateAndCalculateDiscountMinimumPurchase(t *testing.T) {
+	svc := discount.NewDiscountService()
+	spendAndSave := &models.Discount{
+		ID:     "discount1",
+		Code:   "SPEND5000",
+		Active: true,
+		Parameters: models.DiscountParameters{
+			Type:     models.FLAT_OFF,
+			Amount:   float64Ptr(500),
+			MinSpend: float64Ptr(5000),
+		},
+	}
+
+	result, err := svc.ValidateAndCalculateDiscount(spendAndSave, seatsAt(2000, 2000), "session1", "")
+	assert.NoError(t, err)
+	assert.False(t, result.IsValid)
+	assert.Equal(t, "minimum purchase not met: requires 5000.00, cart subtotal is 4000.00", result.Reason)
+
+	// Exactly reaching the threshold qualifies
+	result, err = svc.ValidateAndCalculateDiscount(spendAndSave, seatsAt(2500, 2500), "session1", "")
+	assert.NoError(t, err)
+	assert.True(t, result.IsValid)
+	assert.Equal(t, 500.0, result.DiscountAmount)
+
+	// The threshold applies to every discount type, not just flat and percentage ones
+	bogo := &models.Discount{
+		ID:     "discount2",
+		Code:   "BOGO",
+		Active: true,
+		Parameters: models.DiscountParameters{
+			Type:        models.BUY_N_GET_N_FREE,
+			BuyQuantity: func() *int { v := 2; return &v }(),
+			GetQuantity: func() *int { v := 1; return &v }(),
+			MinSpend:    float64Ptr(5000),
+		},
+	}
+	result, err = svc.ValidateAndCalculateDiscount(bogo, seatsAt(1000, 1000), "session1", "")
+	assert.NoError(t, err)
+	assert.False(t, result.IsValid)
+	assert.Contains(t, result.Reason, "minimum purchase not met")
+}
+
+func TestValidateAndCalculateDiscountTierRestricted(t *testing.T) {
+	svc := discount.NewDiscountService()
+	vip := models.Tier{ID: "tier-vip", Name: "VIP", Price: 4000}
+	general := models.Tier{ID: "tier-general", Name: "General", Price: 1000}
+	seats := []models.SeatDetails{
+		{SeatID: "seat1", Tier: vip},
+		{SeatID: "seat2", Tier: vip},
+		{SeatID: "seat3", Tier: general},
+		{SeatID: "seat4", Tier: general},
+	}
+
+	vipOnly := &models.Discount{
+		ID:              "discount1",
+		Code:            "VIP25",
+		Active:          true,
+		ApplicableTiers: []models.Tier{{ID: "tier-vip"}},
+		Parameters: models.DiscountParameters{
+			Type:       models.PERCENTAGE,
+			Percentage: float64Ptr(25),
+		},
+	}
+
+	// 25% of the two VIP seats only; the general seats keep full price
+	result, err := svc.ValidateAndCalculateDiscount(vipOnly, seats, "session1", "")
+	assert.NoError(t, err)
+	assert.True(t, result.IsValid)
+	assert.Equal(t, 2000.0, result.DiscountAmount)
+	assert.Equal(t, []string{"tier-vip"}, result.ApplicableTiers)
+
+	// A flat amount can't spill over onto ineligible seats either
+	vipOnly.Parameters = models.DiscountParameters{Type: models.FLAT_OFF, Amount: float64Ptr(9000)}
+	result, err = svc.ValidateAndCalculateDiscount(vipOnly, seats, "session1", "")
+	assert.NoError(t, err)
+	assert.False(t, result.IsValid)
+
+	// An order without any eligible seat is rejected
+	vipOnly.Parameters = models.DiscountParameters{Type: models.PERCENTAGE, Percentage: float64Ptr(25)}
+	result, err = svc.ValidateAndCalculateDiscount(vipOnly, seats[2:], "session1", "")
+	assert.NoError(t, err)
+	assert.False(t, result.IsValid)
+	assert.Equal(t, "No items in cart match the discount's applicable tiers", result.Reason)
+}
+
+func TestValidateAndCalculateDiscountValidityWindow(t *testing.T) {
+	svc := discount.NewDiscountService()
+	newDiscount := func(activeFrom, expiresAt *time.Time) *models.Discount {
+		return &models.Discount{
+			ID:         "discount1",
+			Code:       "WINDOW",
+			Active:     true,
+			ActiveFrom: activeFrom,
+			ExpiresAt:  expiresAt,
+			Parameters: models.DiscountParameters{Type: models.PERCENTAGE, Percentage: float64Ptr(10)},
+		}
+	}
+	at := func(d time.Duration) *time.Time {
+		t := time.Now().UTC().Add(d)
+		return &t
+	}
+
+	result, err := svc.ValidateAndCalculateDiscount(newDiscount(at(time.Hour), nil), seatsAt(1000), "session1", "")
+	assert.NoError(t, err)
+	assert.False(t, result.IsValid)
+	assert.Equal(t, "Discount not yet active", result.Reason)
+
+	result, err = svc.ValidateAndCalculateDiscount(newDiscount(nil, at(-time.Second)), seatsAt(1000), "session1", "")
+	assert.NoError(t, err)
+	assert.False(t, result.IsValid)
+	assert.Equal(t, "Discount expired", result.Reason)
+
+	// The same instant expressed in another zone must give the same answer
+	colombo := time.FixedZone("Asia/Colombo", 5*3600+1800)
+	expiredLocal := at(-time.Second).In(colombo)
+	result, err = svc.ValidateAndCalculateDiscount(newDiscount(nil, &expiredLocal), seatsAt(1000), "session1", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "Discount expired", result.Reason)
+
+	result, err = svc.ValidateAndCalculateDiscount(newDiscount(at(-time.Hour), at(time.Hour)), seatsAt(1000), "session1", "")
+	assert.NoError(t, err)
+	assert.True(t, result.IsValid)
+}
+
+func TestValidateAndCalculateDiscountAppliesMaximumDiscount(t *testing.T) {
+	svc := discount.NewDiscountService()
+	ninetyOff := &models.Discount{
+		ID:     "discount-90",
+		Code:   "VIP90",
+		Active: true,
+		Parameters: models.DiscountParameters{
+			Type:       models.PERCENTAGE,
+			Percentage: float64Ptr(90),
+		},
+	}
+	seats := seatsAt(10000, 10000)
+
+	t.Setenv("MAX_DISCOUNT_AMOUNT", "")
+	result, err := svc.ValidateAndCalculateDiscount(ninetyOff, seats, "session1", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 18000.0, result.DiscountAmount)
+	assert.False(t, result.CapApplied)
+
+	t.Setenv("MAX_DISCOUNT_AMOUNT", "5000")
+	result, err = svc.ValidateAndCalculateDiscount(ninetyOff, seats, "session1", "")
+	assert.NoError(t, err)
+	assert.True(t, result.IsValid)
+	assert.Equal(t, 5000.0, result.DiscountAmount)
+	assert.True(t, result.CapApplied)
+	assert.Equal(t, 18000.0, result.UncappedAmount)
+
+	// The event's own cap wins over the global one
+	result, err = svc.ValidateAndCalculateEventDiscount(ninetyOff, seats, "session1", "", 2500)
+	assert.NoError(t, err)
+	assert.Equal(t, 2500.0, result.DiscountAmount)
+	assert.True(t, result.CapApplied)
+
+	// Discounts under the cap are untouched
+	result, err = svc.ValidateAndCalculateEventDiscount(ninetyOff, seatsAt(1000), "session1", "", 2500)
+	assert.NoError(t, err)
+	assert.Equal(t, 900.0, result.DiscountAmount)
+	assert.False(t, result.CapApplied)
+}
@@ -0,0 +1,81 @@
+package order
+
+import (
+	"encoding/json"
+	"fmt"
+	"ms-ticketing/internal/auth"
+	"ms-ticketing/internal/models"
+	"net/http"
+)
+
+// DryRunOrder runs the checks of SeatValidationAndPlaceOrder that have no side effects: seat availability,
+// the pending order limit, pre-validation, the seat cap and discount pricing. It returns what the order
+// would cost, or the same error placing it would fail with. Seats are never locked, so the post-lock
+// seating validation is skipped, and nothing is saved or published.
+func (s *OrderService) DryRunOrder(r *http.Request, orderReq models.OrderRequest) (*models.OrderQuote, error) {
+	s.logger.Info("ORDER", "Starting dry run of order placement")
+	ctx := r.Context()
+
+	available, unavailableSeats, err := s.Redis.CheckSeatsAvailability(orderReq.SeatIDs)
+	if err != nil {
+		s.logger.Error("REDIS", fmt.Sprintf("Failed to check seat availability: %v", err))
+		return nil, fmt.Errorf("failed to check seat availability: %w", err)
+	}
+	if !available {
+		return nil, fmt.Errorf("one or more seats are already locked: %v", unavailableSeats)
+	}
+
+	userToken, err := auth.ExtractTokenFromRequest(r)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+	userID, err := auth.ExtractUserIDFromJWT(userToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	if err := s.checkPendingOrderLimit(ctx, userID, orderReq.SessionID); err != nil {
+		return nil, err
+	}
+
+	m2mToken, err := s.getM2MToken()
+	if err != nil {
+		return nil, err
+	}
+
+	reqBody, err := json.Marshal(orderReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	orderDetailsDTO, err := s.preValidateOrder(reqBody, m2mToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkSeatLimit(len(orderReq.SeatIDs), orderDetailsDTO.MaxSeatsPerOrder); err != nil {
+		return nil, err
+	}
+	currency, err := models.ValidateCurrency(orderDetailsDTO.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	price, err := s.priceOrder(orderDetailsDTO, orderReq)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("ORDER", fmt.Sprintf("Dry run for user %s passed, order would cost %s", userID, price.Price))
+	return &models.OrderQuote{
+		DryRun:         true,
+		Valid:          true,
+		SessionID:      orderReq.SessionID,
+		SeatIDs:        orderReq.SeatIDs,
+		SubTotal:       price.SubTotal,
+		DiscountAmount: price.DiscountAmount,
+		Price:          price.Price,
+		Currency:       currency,
+		DiscountCode:   price.DiscountCode,
+		DiscountCapped: price.DiscountCapped,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package order_test
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunOrderQuotesWithoutSideEffects(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(2)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats, Currency: "usd"})
+	req := models.OrderRequest{SessionID: uuid.NewString(), EventID: "event-1", OrganizationID: "org-1", SeatIDs: seatIDs}
+
+	quote, err := env.OrderService.DryRunOrder(placeOrderRequest(t, "user-1"), req)
+	require.NoError(t, err)
+	assert.True(t, quote.DryRun)
+	assert.True(t, quote.Valid)
+	assert.Equal(t, models.MoneyFromFloat(20), quote.SubTotal)
+	assert.Equal(t, models.MoneyFromFloat(20), quote.Price)
+	assert.Zero(t, quote.DiscountAmount)
+	assert.Equal(t, seatIDs, quote.SeatIDs)
+
+	// Nothing was locked, stored or published
+	available, _, err := env.Locks.CheckSeatsAvailability(seatIDs)
+	require.NoError(t, err)
+	assert.True(t, available)
+	count, err := env.DB.NewSelect().Model((*models.Order)(nil)).Count(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	assert.Empty(t, env.Producer.Messages())
+
+	// A seat held by a real order fails the dry run like placement would
+	_, err = env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-2"), req)
+	require.NoError(t, err)
+	_, err = env.OrderService.DryRunOrder(placeOrderRequest(t, "user-1"), req)
+	assert.ErrorContains(t, err, "already locked")
+}
+
+func TestOrderRejectsUnsupportedCurrency(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(1)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats, Currency: "jpy"})
+	req := models.OrderRequest{SessionID: uuid.NewString(), EventID: "event-1", OrganizationID: "org-1", SeatIDs: seatIDs}
+
+	_, err := env.OrderService.DryRunOrder(placeOrderRequest(t, "user-1"), req)
+	assert.ErrorIs(t, err, models.ErrUnsupportedCurrency)
+
+	_, err = env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), req)
+	assert.ErrorIs(t, err, models.ErrUnsupportedCurrency)
+
+	// The rejection comes before any seat is locked
+	available, _, err := env.Locks.CheckSeatsAvailability(seatIDs)
+	require.NoError(t, err)
+	assert.True(t, available)
+}
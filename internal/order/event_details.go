@@ -0,0 +1,30 @@
+package order
+
+import (
+	"ms-ticketing/internal/models"
+	"os"
+	"strconv"
+)
+
+// OrderEventDetailsEnabled reports whether ticketly.order.created carries the event, session and venue
+// details from the pre-order validation response. ORDER_CREATED_EVENT_DETAILS=false turns it off for
+// consumers that can't take the extra fields; it defaults to on.
+func OrderEventDetailsEnabled() bool {
+	enabled, err := strconv.ParseBool(os.Getenv("ORDER_CREATED_EVENT_DETAILS"))
+	return err != nil || enabled
+}
+
+// orderCreatedEvent is the ticketly.order.created payload: the order and its tickets, plus the event
+// details flattened alongside when enrichment is on and the validation response provided them
+type orderCreatedEvent struct {
+	models.OrderWithTickets
+	*models.OrderEventDetails
+}
+
+// newOrderCreatedEvent builds the payload, leaving the details out when enrichment is switched off
+func newOrderCreatedEvent(orderWithTickets models.OrderWithTickets, details *models.OrderEventDetails) orderCreatedEvent {
+	if !OrderEventDetailsEnabled() {
+		details = nil
+	}
+	return orderCreatedEvent{OrderWithTickets: orderWithTickets, OrderEventDetails: details}
+}
@@ -0,0 +1,72 @@
+package order_test
+
+import (
+	"encoding/json"
+	"ms-ticketing/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderCreatedCarriesEventDetails(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(2)
+	startsAt := time.Date(2026, 12, 31, 19, 30, 0, 0, time.UTC)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{
+		Seats:            seats,
+		EventName:        "New Year Gala",
+		SessionStartTime: &startsAt,
+		VenueName:        "Nelum Pokuna",
+		VenueAddress:     "110 Ananda Coomaraswamy Mawatha, Colombo 07",
+	})
+
+	_, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+		SessionID: uuid.NewString(),
+		EventID:   "event-1",
+		SeatIDs:   seatIDs,
+	})
+	require.NoError(t, err)
+
+	messages := env.Producer.MessagesOn("ticketly.order.created")
+	require.Len(t, messages, 1)
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(messages[0].Value, &created))
+	assert.Equal(t, "New Year Gala", created["event_name"])
+	assert.Equal(t, "2026-12-31T19:30:00Z", created["session_start_time"])
+	assert.Equal(t, "Nelum Pokuna", created["venue_name"])
+	assert.Equal(t, "110 Ananda Coomaraswamy Mawatha, Colombo 07", created["venue_address"])
+	assert.Len(t, created["tickets"], 2)
+	assert.NotEmpty(t, created["OrderID"])
+}
+
+func TestOrderCreatedEventDetailsCanBeSwitchedOff(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(1)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats, EventName: "New Year Gala", VenueName: "Nelum Pokuna"})
+	t.Setenv("ORDER_CREATED_EVENT_DETAILS", "false")
+
+	_, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+		SessionID: uuid.NewString(),
+		EventID:   "event-1",
+		SeatIDs:   seatIDs,
+	})
+	require.NoError(t, err)
+
+	messages := env.Producer.MessagesOn("ticketly.order.created")
+	require.Len(t, messages, 1)
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(messages[0].Value, &created))
+	assert.NotContains(t, created, "event_name")
+	assert.NotContains(t, created, "venue_name")
+	assert.Contains(t, created, "tickets")
+}
+
+func TestOrderDetailsDTOEventDetails(t *testing.T) {
+	assert.Nil(t, models.OrderDetailsDTO{}.EventDetails(), "a response without details adds nothing to the event")
+
+	details := models.OrderDetailsDTO{EventName: "New Year Gala"}.EventDetails()
+	require.NotNil(t, details)
+	assert.Equal(t, "New Year Gala", details.EventName)
+	assert.Nil(t, details.SessionStartTime)
+}
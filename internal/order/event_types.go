@@ -0,0 +1,24 @@
+package order
+
+import (
+	"ms-ticketing/internal/models"
+	"strings"
+)
+
+// OrderCompletedTopic receives orders that were paid and checked out, with their tickets
+const OrderCompletedTopic = "ticketly.order.completed"
+
+// Event types sent in the event-type header of order events
+const (
+	EventTypeOrderCreated   = "order.created"
+	EventTypeOrderUpdated   = "order.updated"
+	EventTypeOrderCompleted = "order.completed"
+	EventTypeOrderCancelled = "order.cancelled"
+	EventTypeOrderReminder  = "order.reminder"
+	EventTypeOrderResend    = "order.resend"
+)
+
+// SeatStatusEventType returns the event-type header of a seat status event, e.g. "seats.locked"
+func SeatStatusEventType(status models.SeatStatus) string {
+	return "seats." + strings.ToLower(string(status))
+}
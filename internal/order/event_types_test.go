@@ -0,0 +1,32 @@
+package order_test
+
+import (
+	"context"
+	"ms-ticketing/internal/kafka"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishedEventsCarryHeaders(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(1)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+
+	resp, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+		SessionID: uuid.NewString(), EventID: "event-1", OrganizationID: "org-1", SeatIDs: seatIDs,
+	})
+	require.NoError(t, err)
+	require.NoError(t, env.OrderService.CancelOrder(context.Background(), resp.OrderID))
+
+	var eventTypes []string
+	for _, msg := range env.Producer.Messages() {
+		assert.Equal(t, kafka.SchemaVersion, msg.Headers[kafka.HeaderSchemaVersion], msg.Topic)
+		assert.Equal(t, resp.OrderID, msg.Headers[kafka.HeaderOrderID], msg.Topic)
+		eventTypes = append(eventTypes, msg.Headers[kafka.HeaderEventType])
+	}
+	assert.Equal(t, []string{"seats.locked", order.EventTypeOrderCreated, order.EventTypeOrderCancelled, "seats.available"}, eventTypes)
+}
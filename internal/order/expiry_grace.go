@@ -0,0 +1,120 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/models"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/stripe/stripe-go/v74"
+)
+
+// DefaultExpiryPaymentGrace is how long an expired order whose payment is still processing is given to settle
+const DefaultExpiryPaymentGrace = 5 * time.Second
+
+// ExpiryPaymentGrace returns the grace period from ORDER_EXPIRY_PAYMENT_GRACE_SECONDS or the default.
+// Zero turns off waiting for processing payments; the payment intent is still checked once.
+func ExpiryPaymentGrace() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("ORDER_EXPIRY_PAYMENT_GRACE_SECONDS"))
+	if err != nil || seconds < 0 {
+		return DefaultExpiryPaymentGrace
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// CancelExpiredOrder cancels a pending order whose seat lock expired, unless Stripe reports its payment
+// went through, in which case the order is checked out instead. A payment that is still processing gets
+// one more look after the grace period. It reports whether the order was completed.
+func (s *OrderService) CancelExpiredOrder(ctx context.Context, orderID string) (bool, error) {
+	outcome := s.CancelExpiredOrders(ctx, []string{orderID})[0]
+	return outcome.Completed, outcome.Err
+}
+
+// ExpiredOrderOutcome is what CancelExpiredOrders did with one expired order
+type ExpiredOrderOutcome struct {
+	OrderID   string
+	Completed bool
+	Err       error
+}
+
+// expiredPayment is the Stripe view of an expired order's payment
+type expiredPayment struct {
+	order  *models.Order
+	intent *stripe.PaymentIntent
+	err    error
+}
+
+func (p *expiredPayment) processing() bool {
+	return p.err == nil && p.intent != nil && p.intent.Status == stripe.PaymentIntentStatusProcessing
+}
+
+// CancelExpiredOrders handles a batch of expired orders like CancelExpiredOrder. Payments still processing
+// share a single grace period, so a mass expiry waits once rather than once per order. Outcomes are in
+// the order of orderIDs.
+func (s *OrderService) CancelExpiredOrders(ctx context.Context, orderIDs []string) []ExpiredOrderOutcome {
+	ctx = WithCancellationReason(withDefaultStatusActor(ctx, ActorSeatExpiry), CancellationAbandoned)
+	outcomes := make([]ExpiredOrderOutcome, len(orderIDs))
+	payments := make([]*expiredPayment, len(orderIDs))
+	processing := 0
+	for i, orderID := range orderIDs {
+		outcomes[i].OrderID = orderID
+		order, err := s.DB.GetOrderByID(ctx, orderID)
+		if err != nil {
+			outcomes[i].Err = fmt.Errorf("failed to get order: %w", err)
+			continue
+		}
+		payment := &expiredPayment{order: order}
+		if order.Status == "pending" && order.PaymentIntentID != "" && orderPaymentProvider(order) == PaymentProviderStripe && !IsMockPaymentIntent(order.PaymentIntentID) {
+			payment.intent, payment.err = s.PaymentIntents.GetPaymentIntent(order.PaymentIntentID)
+			if payment.processing() {
+				processing++
+			}
+		}
+		payments[i] = payment
+	}
+
+	if grace := ExpiryPaymentGrace(); processing > 0 && grace > 0 {
+		s.logger.Info("SEAT_UNLOCK", fmt.Sprintf("%d payments of expired orders are processing, waiting %s", processing, grace))
+		select {
+		case <-time.After(grace):
+		case <-ctx.Done():
+			for i, payment := range payments {
+				if payment != nil {
+					outcomes[i].Err = ctx.Err()
+				}
+			}
+			return outcomes
+		}
+		for _, payment := range payments {
+			if payment != nil && payment.processing() {
+				payment.intent, payment.err = s.PaymentIntents.GetPaymentIntent(payment.order.PaymentIntentID)
+			}
+		}
+	}
+
+	for i, payment := range payments {
+		if payment != nil {
+			outcomes[i].Completed, outcomes[i].Err = s.settleExpiredOrder(ctx, payment)
+		}
+	}
+	return outcomes
+}
+
+// settleExpiredOrder checks out an expired order its payment intent pays for and cancels it otherwise
+func (s *OrderService) settleExpiredOrder(ctx context.Context, payment *expiredPayment) (bool, error) {
+	orderID := payment.order.OrderID
+	switch {
+	case payment.err != nil:
+		s.logger.Warn("SEAT_UNLOCK", fmt.Sprintf("Could not check payment intent %s of expired order %s, cancelling: %v", payment.order.PaymentIntentID, orderID, payment.err))
+	case payment.intent != nil && verifyIntentPaysOrder(payment.intent, orderID, payment.order.Price.MinorUnits()) == nil:
+		s.logger.Warn("SEAT_UNLOCK", fmt.Sprintf("Order %s was paid as its seat lock expired, completing it instead of cancelling", orderID))
+		if err := s.Checkout(ctx, orderID); err != nil && !errors.Is(err, ErrAlreadyCompleted) {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, s.CancelOrder(ctx, orderID)
+}
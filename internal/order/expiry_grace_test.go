@@ -0,0 +1,125 @@
+package order_test
+
+import (
+	"context"
+	"fmt"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v74"
+)
+
+func TestCancelExpiredOrderCompletesPaidOrders(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(3)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	t.Setenv("ORDER_EXPIRY_PAYMENT_GRACE_SECONDS", "0")
+	ctx := context.Background()
+	sessionID := uuid.NewString()
+
+	// Each seat gets its own pending order whose intent Stripe reports differently
+	place := func(seatID, intentID string) string {
+		resp, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+			SessionID: sessionID, EventID: "event-1", OrganizationID: "org-1", SeatIDs: []string{seatID},
+		})
+		require.NoError(t, err)
+		_, err = env.DB.NewUpdate().Model((*models.Order)(nil)).Set("payment_intent_id = ?", intentID).Where("order_id = ?", resp.OrderID).Exec(ctx)
+		require.NoError(t, err)
+		return resp.OrderID
+	}
+	paidID := place(seatIDs[0], "pi_paid")
+	processingID := place(seatIDs[1], "pi_processing")
+	unpaidID := place(seatIDs[2], "pi_unpaid")
+
+	paid, err := env.OrderService.GetOrder(ctx, paidID)
+	require.NoError(t, err)
+	env.OrderService.PaymentIntents = &fakeIntentSource{byID: map[string]*stripe.PaymentIntent{
+		"pi_paid":       {ID: "pi_paid", Status: stripe.PaymentIntentStatusSucceeded, AmountReceived: paid.Price.MinorUnits(), Metadata: map[string]string{"order_id": paidID}},
+		"pi_processing": {ID: "pi_processing", Status: stripe.PaymentIntentStatusProcessing, Metadata: map[string]string{"order_id": processingID}},
+		"pi_unpaid":     {ID: "pi_unpaid", Status: stripe.PaymentIntentStatusRequiresPaymentMethod, Metadata: map[string]string{"order_id": unpaidID}},
+	}}
+
+	completed, err := env.OrderService.CancelExpiredOrder(ctx, paidID)
+	require.NoError(t, err)
+	assert.True(t, completed)
+
+	for _, orderID := range []string{processingID, unpaidID} {
+		completed, err := env.OrderService.CancelExpiredOrder(ctx, orderID)
+		require.NoError(t, err)
+		assert.False(t, completed, orderID)
+	}
+
+	statuses := map[string]string{}
+	for _, orderID := range []string{paidID, processingID, unpaidID} {
+		o, err := env.OrderService.GetOrder(ctx, orderID)
+		require.NoError(t, err)
+		statuses[orderID] = o.Status
+	}
+	assert.Equal(t, map[string]string{paidID: "completed", processingID: "cancelled", unpaidID: "cancelled"}, statuses)
+}
+
+// timedIntentSource records when each payment intent was fetched
+type timedIntentSource struct {
+	fakeIntentSource
+	calls []time.Time
+}
+
+func (f *timedIntentSource) GetPaymentIntent(id string) (*stripe.PaymentIntent, error) {
+	f.calls = append(f.calls, time.Now())
+	return f.fakeIntentSource.GetPaymentIntent(id)
+}
+
+func TestCancelExpiredOrdersWaitsOnceForTheBatch(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(3)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	t.Setenv("ORDER_EXPIRY_PAYMENT_GRACE_SECONDS", "1")
+	ctx := context.Background()
+	sessionID := uuid.NewString()
+
+	intents := map[string]*stripe.PaymentIntent{}
+	var orderIDs []string
+	for i, seatID := range seatIDs {
+		resp, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+			SessionID: sessionID, EventID: "event-1", OrganizationID: "org-1", SeatIDs: []string{seatID},
+		})
+		require.NoError(t, err)
+		intentID := fmt.Sprintf("pi_processing_%d", i)
+		_, err = env.DB.NewUpdate().Model((*models.Order)(nil)).Set("payment_intent_id = ?", intentID).Where("order_id = ?", resp.OrderID).Exec(ctx)
+		require.NoError(t, err)
+		intents[intentID] = &stripe.PaymentIntent{ID: intentID, Status: stripe.PaymentIntentStatusProcessing, Metadata: map[string]string{"order_id": resp.OrderID}}
+		orderIDs = append(orderIDs, resp.OrderID)
+	}
+	source := &timedIntentSource{fakeIntentSource: fakeIntentSource{byID: intents}}
+	env.OrderService.PaymentIntents = source
+
+	outcomes := env.OrderService.CancelExpiredOrders(ctx, orderIDs)
+	// Three processing payments share one grace period rather than waiting a second each
+	require.Len(t, source.calls, 6)
+	assert.GreaterOrEqual(t, source.calls[3].Sub(source.calls[2]), time.Second)
+	assert.Less(t, source.calls[5].Sub(source.calls[0]), 2*time.Second)
+
+	require.Len(t, outcomes, len(orderIDs))
+	for i, outcome := range outcomes {
+		assert.Equal(t, orderIDs[i], outcome.OrderID)
+		assert.NoError(t, outcome.Err)
+		assert.False(t, outcome.Completed)
+		o, err := env.OrderService.GetOrder(ctx, outcome.OrderID)
+		require.NoError(t, err)
+		assert.Equal(t, "cancelled", o.Status)
+	}
+}
+
+func TestExpiryPaymentGrace(t *testing.T) {
+	t.Setenv("ORDER_EXPIRY_PAYMENT_GRACE_SECONDS", "")
+	assert.Equal(t, order.DefaultExpiryPaymentGrace, order.ExpiryPaymentGrace())
+
+	t.Setenv("ORDER_EXPIRY_PAYMENT_GRACE_SECONDS", "0")
+	assert.Zero(t, order.ExpiryPaymentGrace())
+
+	t.Setenv("ORDER_EXPIRY_PAYMENT_GRACE_SECONDS", "30")
+	assert.Equal(t, 30*time.Second, order.ExpiryPaymentGrace())
+}
@@ -0,0 +1,367 @@
+package order_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"ms-ticketing/internal/config"
+	"ms-ticketing/internal/kafka"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	order_db "ms-ticketing/internal/order/db"
+	ticket_db "ms-ticketing/internal/tickets/db"
+	tickets "ms-ticketing/internal/tickets/service"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v74/webhook"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	_ "github.com/uptrace/bun/driver/sqliteshim"
+)
+
+// memorySeatLocks is an in-process stand-in for the Redis seat locks with the same all-or-nothing semantics
+type memorySeatLocks struct {
+	mu    sync.Mutex
+	locks map[string]models.SeatLock
+	marks map[string]bool
+}
+
+func newMemorySeatLocks() *memorySeatLocks {
+	return &memorySeatLocks{locks: make(map[string]models.SeatLock), marks: make(map[string]bool)}
+}
+
+func (m *memorySeatLocks) CheckSeatsAvailability(seatIDs []string) (bool, []string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var locked []string
+	for _, seatID := range seatIDs {
+		if _, ok := m.locks[seatID]; ok {
+			locked = append(locked, seatID)
+		}
+	}
+	return len(locked) == 0, locked, nil
+}
+
+func (m *memorySeatLocks) LockSeats(seatIDs []string, sessionID, orderID, userID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, seatID := range seatIDs {
+		if _, ok := m.locks[seatID]; ok {
+			return false, nil
+		}
+	}
+	for _, seatID := range seatIDs {
+		m.locks[seatID] = models.SeatLock{SessionID: sessionID, OrderID: orderID, UserID: userID, LockedAt: time.Now()}
+	}
+	return true, nil
+}
+
+func (m *memorySeatLocks) HoldSeats(seatIDs []string, userID string) (bool, error) {
+	return m.LockSeats(seatIDs, "", models.AdminHoldOrderID, userID)
+}
+
+func (m *memorySeatLocks) UnlockSeats(seatIDs []string, orderID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, seatID := range seatIDs {
+		if lock, ok := m.locks[seatID]; ok && lock.OrderID == orderID {
+			delete(m.locks, seatID)
+		}
+	}
+	return nil
+}
+
+func (m *memorySeatLocks) MarkOrderReminded(orderID string, ttl time.Duration) (bool, error) {
+	return m.mark("reminded:" + orderID), nil
+}
+
+func (m *memorySeatLocks) MarkConfirmationResent(orderID string, cooldown time.Duration) (bool, error) {
+	return m.mark("resent:" + orderID), nil
+}
+
+func (m *memorySeatLocks) mark(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.marks[key] {
+		return false
+	}
+	m.marks[key] = true
+	return true
+}
+
+func (m *memorySeatLocks) GetSeatLock(seatID string) (*models.SeatLock, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lock, ok := m.locks[seatID]
+	if !ok {
+		return nil, 0, nil
+	}
+	return &lock, 5 * time.Minute, nil
+}
+
+func (m *memorySeatLocks) ForceUnlockSeat(seatID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lock, ok := m.locks[seatID]
+	if !ok {
+		return "", nil
+	}
+	delete(m.locks, seatID)
+	return lock.OrderID, nil
+}
+
+func (m *memorySeatLocks) SessionSeatLocks(sessionID string) ([]models.HeldSeat, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	held := make([]models.HeldSeat, 0, len(m.locks))
+	for seatID, lock := range m.locks {
+		if lock.SessionID != sessionID {
+			continue
+		}
+		lockedAt := lock.LockedAt
+		held = append(held, models.HeldSeat{SeatID: seatID, OrderID: lock.OrderID, UserID: lock.UserID, LockedAt: &lockedAt, TTLSeconds: 300})
+	}
+	return held, nil
+}
+
+func (m *memorySeatLocks) PruneSessionLockSets() (int, error) {
+	// Locks are only ever deleted here, never left to expire, so there is nothing stale to prune
+	return 0, nil
+}
+
+// seatStatuses returns the status of every ticketly.seats.status message in publish order
+func seatStatuses(t *testing.T, messages []kafka.Message) []models.SeatStatus {
+	var statuses []models.SeatStatus
+	for _, msg := range messages {
+		if msg.Topic != "ticketly.seats.status" {
+			continue
+		}
+		var event models.SeatStatusChangeEventDto
+		require.NoError(t, json.Unmarshal(msg.Value, &event))
+		statuses = append(statuses, event.Status)
+	}
+	return statuses
+}
+
+// sessionOwnerID is the organizer the fake event seating service reports as owning every session
+const sessionOwnerID = "organizer-1"
+
+// newUpstreamServer fakes Keycloak plus the event-query and event-seating pre-order validation and
+// session ownership endpoints
+func newUpstreamServer(t *testing.T, details models.OrderDetailsDTO) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/keycloak/realms/ticketly/protocol/openid-connect/token", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(models.M2MTokenResponse{AccessToken: "m2m-token", ExpiresIn: 300, TokenType: "Bearer"})
+	})
+	mux.HandleFunc("/event-query/internal/v1/validate-pre-order", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer m2m-token", r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode(details)
+	})
+	mux.HandleFunc("/event-seating/internal/v1/validate-pre-order", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer m2m-token", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	})
+	// Every session belongs to the organization of sessionOwnerID
+	mux.HandleFunc("/event-seating/internal/v1/sessions/verify-ownership", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer m2m-token", r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode(r.URL.Query().Get("userId") == sessionOwnerID)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func setupLifecycleDB(t *testing.T) *bun.DB {
+	sqldb, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	// Every pooled connection to :memory: would otherwise get its own empty database
+	sqldb.SetMaxOpenConns(1)
+
+	bunDB := bun.NewDB(sqldb, sqlitedialect.New())
+	t.Cleanup(func() { bunDB.Close() })
+
+	_, err = bunDB.NewCreateTable().Model((*models.Order)(nil)).Exec(context.Background())
+	require.NoError(t, err)
+	_, err = bunDB.NewCreateTable().Model((*models.Ticket)(nil)).Exec(context.Background())
+	require.NoError(t, err)
+	_, err = bunDB.NewCreateTable().Model((*models.OrderNumberCounter)(nil)).Exec(context.Background())
+	require.NoError(t, err)
+	_, err = bunDB.NewCreateTable().Model((*models.OrderStatusChange)(nil)).Exec(context.Background())
+	require.NoError(t, err)
+	return bunDB
+}
+
+// lifecycleEnv wires a real OrderService to SQLite, in-memory seat locks and Kafka, and a fake upstream
+type lifecycleEnv struct {
+	OrderService  *order.OrderService
+	TicketService *tickets.TicketService
+	Locks         *memorySeatLocks
+	Producer      *kafka.InMemoryProducer
+	DB            *bun.DB
+}
+
+func newLifecycleEnv(t *testing.T, details models.OrderDetailsDTO) *lifecycleEnv {
+	t.Setenv("PAYMENT_MODE", "mock")
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("STRIPE_SECRET_KEY", "")
+	t.Setenv("STRIPE_WEBHOOK_SECRET", "")
+	t.Setenv("PAYMENT_MOCK_WEBHOOK_SECRET", "whsec_test")
+	t.Setenv("QR_SECRET_KEY", "lifecycle-test-secret")
+
+	upstream := newUpstreamServer(t, details)
+	t.Setenv("KEYCLOAK_URL", upstream.URL+"/keycloak")
+	t.Setenv("KEYCLOAK_REALM", "ticketly")
+	eventQuery, err := config.ParseServiceURL("EVENT_QUERY_SERVICE_URL", upstream.URL+"/event-query")
+	require.NoError(t, err)
+	eventSeating, err := config.ParseServiceURL("EVENT_SEATING_SERVICE_URL", upstream.URL+"/event-seating/")
+	require.NoError(t, err)
+
+	env := &lifecycleEnv{
+		DB:       setupLifecycleDB(t),
+		Locks:    newMemorySeatLocks(),
+		Producer: kafka.NewInMemoryProducer(),
+	}
+	env.TicketService = tickets.NewTicketService(&ticket_db.DB{Bun: env.DB})
+	env.OrderService = order.NewOrderService(&order_db.DB{Bun: env.DB}, env.Locks, env.Producer, env.TicketService, upstream.Client())
+	env.OrderService.SetServiceURLs(eventQuery, eventSeating)
+	return env
+}
+
+// placeOrderRequest builds the incoming request of a signed-in user placing an order
+func placeOrderRequest(t *testing.T, userID string) *http.Request {
+	userToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": userID}).SignedString([]byte("test"))
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/api/order", nil)
+	req.Header.Set("Authorization", "Bearer "+userToken)
+	return req
+}
+
+func signedPaymentSucceededWebhook(intentID, orderID string) *http.Request {
+	payload := []byte(fmt.Sprintf(
+		`{"id":"evt_lifecycle","object":"event","type":"payment_intent.succeeded","data":{"object":{"id":%q,"object":"payment_intent","status":"succeeded","metadata":{"order_id":%q}}}}`,
+		intentID, orderID,
+	))
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: payload, Secret: "whsec_test"})
+	req := httptest.NewRequest(http.MethodPost, "/api/order/webhook/stripe", bytes.NewReader(signed.Payload))
+	req.Header.Set("Stripe-Signature", signed.Header)
+	return req
+}
+
+func TestOrderLifecyclePlacePayCheckout(t *testing.T) {
+	sessionID := uuid.NewString()
+	seats := []models.SeatDetails{
+		{SeatID: uuid.NewString(), Label: "A1", Tier: models.Tier{ID: "tier-vip", Name: "VIP", Price: 120.50, Color: "#FFD700"}},
+		{SeatID: uuid.NewString(), Label: "A2", Tier: models.Tier{ID: "tier-std", Name: "Standard", Price: 79.50, Color: "#C0C0C0"}},
+	}
+	seatIDs := []string{seats[0].SeatID, seats[1].SeatID}
+
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	orderSvc, locks, producer, ticketSvc := env.OrderService, env.Locks, env.Producer, env.TicketService
+
+	// Place the order as a signed-in user
+	resp, err := orderSvc.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-42"), models.OrderRequest{
+		SessionID:      sessionID,
+		EventID:        "event-1",
+		OrganizationID: "org-1",
+		SeatIDs:        seatIDs,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "user-42", resp.UserID)
+
+	placed, err := orderSvc.GetOrder(context.Background(), resp.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", placed.Status)
+	assert.Equal(t, models.MoneyFromFloat(200), placed.Price)
+
+	// While the order is pending every seat is held by it
+	for _, seatID := range seatIDs {
+		lock, _, err := locks.GetSeatLock(seatID)
+		require.NoError(t, err)
+		if assert.NotNil(t, lock, "seat %s should be locked", seatID) {
+			assert.Equal(t, resp.OrderID, lock.OrderID)
+			assert.Equal(t, "user-42", lock.UserID)
+		}
+	}
+	available, _, err := locks.CheckSeatsAvailability(seatIDs)
+	require.NoError(t, err)
+	assert.False(t, available)
+
+	issued, err := ticketSvc.GetTicketsByOrder(resp.OrderID)
+	require.NoError(t, err)
+	if assert.Len(t, issued, 2) {
+		for _, ticket := range issued {
+			assert.NotEmpty(t, ticket.QRCode)
+			assert.False(t, ticket.CheckedIn)
+		}
+	}
+
+	// Pay: create the (mock) intent, then deliver Stripe's success webhook
+	intent, err := orderSvc.CreatePaymentIntent(context.Background(), resp.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(20000), intent.Amount)
+
+	require.NoError(t, orderSvc.HandleStripeWebhook(signedPaymentSucceededWebhook(intent.ID, resp.OrderID)))
+
+	completed, err := orderSvc.GetOrder(context.Background(), resp.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", completed.Status)
+	assert.Equal(t, intent.ID, completed.PaymentIntentID)
+
+	// A redelivered webhook is acknowledged without a second checkout
+	before := len(producer.Messages())
+	require.NoError(t, orderSvc.HandleStripeWebhook(signedPaymentSucceededWebhook(intent.ID, resp.OrderID)))
+	assert.Len(t, producer.Messages(), before)
+
+	messages := producer.Messages()
+	assert.Equal(t, []string{
+		"ticketly.seats.status",
+		"ticketly.order.created",
+		"ticketly.seats.status",
+		"ticketly.order.completed",
+	}, producer.Topics())
+	assert.Equal(t, []models.SeatStatus{models.SeatStatusLocked, models.SeatStatusBooked}, seatStatuses(t, messages))
+
+	for _, msg := range messages {
+		if msg.Topic == "ticketly.seats.status" {
+			assert.Equal(t, sessionID, msg.Key)
+			continue
+		}
+		assert.Equal(t, resp.OrderID, msg.Key)
+	}
+
+	var completedEvent models.OrderWithTickets
+	require.NoError(t, json.Unmarshal(messages[3].Value, &completedEvent))
+	assert.Equal(t, "completed", completedEvent.Status)
+	assert.Len(t, completedEvent.Tickets, 2)
+}
+
+func TestPlaceOrderWithoutServiceURLs(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(2)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	eventQuery, eventSeating := env.OrderService.EventQueryURL, env.OrderService.EventSeatingURL
+	orderReq := models.OrderRequest{SessionID: uuid.NewString(), EventID: "event-1", SeatIDs: seatIDs}
+
+	env.OrderService.SetServiceURLs(nil, eventSeating)
+	_, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), orderReq)
+	require.ErrorContains(t, err, "event query service URL not configured")
+
+	// The seating service is only asked after locking, so the seats must be released again
+	env.OrderService.SetServiceURLs(eventQuery, nil)
+	_, err = env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), orderReq)
+	require.ErrorContains(t, err, "event seating service URL not configured")
+	for _, seatID := range seatIDs {
+		lock, _, err := env.Locks.GetSeatLock(seatID)
+		require.NoError(t, err)
+		assert.Nil(t, lock)
+	}
+}
@@ -0,0 +1,69 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/stripe/stripe-go/v74"
+)
+
+// ErrPaymentNotConfirmed is returned by CompleteOrderManually when Stripe does not confirm the order was paid
+var ErrPaymentNotConfirmed = errors.New("payment is not confirmed by Stripe")
+
+// ErrOrderNotPending is returned when an order can no longer be completed
+var ErrOrderNotPending = errors.New("order is not pending")
+
+// CompleteOrderManually completes an order whose payment succeeded but whose webhook never arrived.
+// Stripe is asked directly for the payment intent, and the order is only checked out if the intent
+// succeeded, belongs to this order and covers its price.
+func (s *OrderService) CompleteOrderManually(ctx context.Context, orderID string) error {
+	order, err := s.DB.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if order.Status == "completed" {
+		return ErrAlreadyCompleted
+	}
+	if order.Status != "pending" {
+		return fmt.Errorf("%w: current status is %s", ErrOrderNotPending, order.Status)
+	}
+	if order.PaymentIntentID == "" {
+		return fmt.Errorf("%w: order has no payment intent", ErrPaymentNotConfirmed)
+	}
+
+	// Mock intents never reach Stripe, so there is nothing that could vouch for them
+	if IsMockPaymentIntent(order.PaymentIntentID) {
+		if !MockPaymentsEnabled() {
+			return fmt.Errorf("%w: mock payment intent %s outside mock mode", ErrPaymentNotConfirmed, order.PaymentIntentID)
+		}
+	} else {
+		intent, err := s.PaymentIntents.GetPaymentIntent(order.PaymentIntentID)
+		if err != nil {
+			s.logger.Error("PAYMENT", fmt.Sprintf("Failed to retrieve payment intent %s for order %s: %v", order.PaymentIntentID, orderID, err))
+			return fmt.Errorf("failed to retrieve payment intent: %w", err)
+		}
+		if err := verifyIntentPaysOrder(intent, orderID, order.Price.MinorUnits()); err != nil {
+			s.logger.Warn("PAYMENT", fmt.Sprintf("Refusing manual completion of order %s: %v", orderID, err))
+			return err
+		}
+	}
+
+	s.logger.Warn("ORDER", fmt.Sprintf("Order %s is being completed manually with payment intent %s", orderID, order.PaymentIntentID))
+	return s.Checkout(ctx, orderID)
+}
+
+// verifyIntentPaysOrder checks that a payment intent succeeded for the given order and amount
+func verifyIntentPaysOrder(intent *stripe.PaymentIntent, orderID string, amount int64) error {
+	if intent.Status != stripe.PaymentIntentStatusSucceeded {
+		return fmt.Errorf("%w: payment intent %s is %s", ErrPaymentNotConfirmed, intent.ID, intent.Status)
+	}
+	if intentOrderID := intent.Metadata["order_id"]; intentOrderID != orderID {
+		return fmt.Errorf("%w: payment intent %s belongs to order %q", ErrPaymentNotConfirmed, intent.ID, intentOrderID)
+	}
+	if intent.AmountReceived < amount {
+		return fmt.Errorf("%w: payment intent %s received %d of %d", ErrPaymentNotConfirmed, intent.ID, intent.AmountReceived, amount)
+	}
+	return nil
+}
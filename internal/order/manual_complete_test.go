@@ -0,0 +1,45 @@
+package order_test
+
+import (
+	"context"
+	"ms-ticketing/internal/kafka"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	tickets "ms-ticketing/internal/tickets/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stripe/stripe-go/v74"
+)
+
+func TestCompleteOrderManuallyRefusesUnpaidIntents(t *testing.T) {
+	t.Setenv("PAYMENT_MODE", "stripe")
+	mockDB := new(MockDBLayer)
+	orderSvc := order.NewOrderService(mockDB, new(MockRedisLock), kafka.NewInMemoryProducer(), &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+	orderSvc.PaymentIntents = &fakeIntentSource{byID: map[string]*stripe.PaymentIntent{
+		"pi_unpaid":  {ID: "pi_unpaid", Status: stripe.PaymentIntentStatusRequiresPaymentMethod, Metadata: map[string]string{"order_id": "order_unpaid"}},
+		"pi_foreign": {ID: "pi_foreign", Status: stripe.PaymentIntentStatusSucceeded, AmountReceived: 1000, Metadata: map[string]string{"order_id": "someone_else"}},
+		"pi_short":   {ID: "pi_short", Status: stripe.PaymentIntentStatusSucceeded, AmountReceived: 500, Metadata: map[string]string{"order_id": "order_short"}},
+	}}
+
+	pending := func(orderID, intentID string) *models.Order {
+		return &models.Order{OrderID: orderID, Status: "pending", PaymentIntentID: intentID, Price: models.MoneyFromFloat(10)}
+	}
+	mockDB.On("GetOrderByID", "order_unpaid").Return(pending("order_unpaid", "pi_unpaid"), nil)
+	mockDB.On("GetOrderByID", "order_foreign").Return(pending("order_foreign", "pi_foreign"), nil)
+	mockDB.On("GetOrderByID", "order_short").Return(pending("order_short", "pi_short"), nil)
+	mockDB.On("GetOrderByID", "order_mock").Return(pending("order_mock", "pi_mock_1"), nil)
+	mockDB.On("GetOrderByID", "order_no_intent").Return(pending("order_no_intent", ""), nil)
+	mockDB.On("GetOrderByID", "order_done").Return(&models.Order{OrderID: "order_done", Status: "completed", PaymentIntentID: "pi_done"}, nil)
+	mockDB.On("GetOrderByID", "order_cancelled").Return(&models.Order{OrderID: "order_cancelled", Status: "cancelled", PaymentIntentID: "pi_x"}, nil)
+
+	for _, orderID := range []string{"order_unpaid", "order_foreign", "order_short", "order_mock", "order_no_intent"} {
+		assert.ErrorIs(t, orderSvc.CompleteOrderManually(context.Background(), orderID), order.ErrPaymentNotConfirmed, orderID)
+	}
+	assert.ErrorIs(t, orderSvc.CompleteOrderManually(context.Background(), "order_done"), order.ErrAlreadyCompleted)
+	assert.ErrorIs(t, orderSvc.CompleteOrderManually(context.Background(), "order_cancelled"), order.ErrOrderNotPending)
+
+	// Nothing may be marked completed without Stripe's confirmation
+	mockDB.AssertNotCalled(t, "UpdateOrder", mock.Anything)
+}
@@ -0,0 +1,71 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxOrderNoteLength caps a single support note, in characters
+const MaxOrderNoteLength = 2000
+
+var (
+	ErrOrderNotFound = errors.New("order not found")
+	ErrInvalidNote   = fmt.Errorf("note must be between 1 and %d characters", MaxOrderNoteLength)
+)
+
+// AddOrderNote attaches an internal support note to an order
+func (s *OrderService) AddOrderNote(ctx context.Context, orderID, authorUserID, text string) (*models.OrderNote, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || len([]rune(text)) > MaxOrderNoteLength {
+		return nil, ErrInvalidNote
+	}
+	if _, err := s.lookupOrder(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	note := models.OrderNote{
+		NoteID:       uuid.NewString(),
+		OrderID:      orderID,
+		AuthorUserID: authorUserID,
+		Note:         text,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := s.DB.CreateOrderNote(ctx, note); err != nil {
+		s.logger.Error("ORDER", fmt.Sprintf("Failed to save note on order %s: %v", orderID, err))
+		return nil, fmt.Errorf("failed to save note: %w", err)
+	}
+
+	s.logger.Info("ORDER", fmt.Sprintf("Note %s added to order %s by %s", note.NoteID, orderID, authorUserID))
+	return &note, nil
+}
+
+// GetOrderNotes lists the support notes of an order, oldest first
+func (s *OrderService) GetOrderNotes(ctx context.Context, orderID string) ([]models.OrderNote, error) {
+	if _, err := s.lookupOrder(ctx, orderID); err != nil {
+		return nil, err
+	}
+	notes, err := s.DB.GetOrderNotes(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notes: %w", err)
+	}
+	return notes, nil
+}
+
+// lookupOrder fetches an order, reporting a missing one as ErrOrderNotFound
+func (s *OrderService) lookupOrder(ctx context.Context, orderID string) (*models.Order, error) {
+	order, err := s.DB.GetOrderByID(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	return order, nil
+}
@@ -0,0 +1,59 @@
+package order_test
+
+import (
+	"context"
+	"database/sql"
+	"ms-ticketing/internal/kafka"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	tickets "ms-ticketing/internal/tickets/service"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAddOrderNote(t *testing.T) {
+	mockDB := new(MockDBLayer)
+	orderSvc := order.NewOrderService(mockDB, new(MockRedisLock), kafka.NewInMemoryProducer(), &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+
+	mockDB.On("GetOrderByID", "order1").Return(&models.Order{OrderID: "order1", Status: "completed"}, nil)
+	mockDB.On("GetOrderByID", "missing").Return(nil, sql.ErrNoRows)
+	mockDB.On("CreateOrderNote", mock.MatchedBy(func(n models.OrderNote) bool {
+		return n.OrderID == "order1" && n.AuthorUserID == "agent1" && n.Note == "customer called, refund approved by X" && n.NoteID != ""
+	})).Return(nil).Once()
+
+	note, err := orderSvc.AddOrderNote(context.Background(), "order1", "agent1", "  customer called, refund approved by X \n")
+	assert.NoError(t, err)
+	assert.Equal(t, "customer called, refund approved by X", note.Note)
+	assert.False(t, note.CreatedAt.IsZero())
+
+	_, err = orderSvc.AddOrderNote(context.Background(), "order1", "agent1", "   ")
+	assert.ErrorIs(t, err, order.ErrInvalidNote)
+	_, err = orderSvc.AddOrderNote(context.Background(), "order1", "agent1", strings.Repeat("x", order.MaxOrderNoteLength+1))
+	assert.ErrorIs(t, err, order.ErrInvalidNote)
+
+	_, err = orderSvc.AddOrderNote(context.Background(), "missing", "agent1", "hello")
+	assert.ErrorIs(t, err, order.ErrOrderNotFound)
+
+	mockDB.AssertExpectations(t)
+}
+
+func TestGetOrderNotes(t *testing.T) {
+	mockDB := new(MockDBLayer)
+	orderSvc := order.NewOrderService(mockDB, new(MockRedisLock), kafka.NewInMemoryProducer(), &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+
+	mockDB.On("GetOrderByID", "order1").Return(&models.Order{OrderID: "order1"}, nil)
+	mockDB.On("GetOrderByID", "missing").Return(nil, sql.ErrNoRows)
+	mockDB.On("GetOrderNotes", "order1").Return([]models.OrderNote{{NoteID: "n1", OrderID: "order1", AuthorUserID: "agent1", Note: "first"}}, nil)
+
+	notes, err := orderSvc.GetOrderNotes(context.Background(), "order1")
+	assert.NoError(t, err)
+	if assert.Len(t, notes, 1) {
+		assert.Equal(t, "agent1", notes[0].AuthorUserID)
+	}
+
+	_, err = orderSvc.GetOrderNotes(context.Background(), "missing")
+	assert.ErrorIs(t, err, order.ErrOrderNotFound)
+}
@@ -0,0 +1,57 @@
+package order_api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/auth"
+	"ms-ticketing/internal/order"
+	"ms-ticketing/internal/utils"
+	"net/http"
+)
+
+// CompTickets issues complimentary tickets with QR codes for the given seats without taking payment
+func (h *Handler) CompTickets(w http.ResponseWriter, r *http.Request) {
+	issuedBy := auth.UserID(r.Context())
+
+	var req order.CompTicketsRequest
+	if err := utils.DecodeJSONBody(w, r, &req); err != nil {
+		h.Logger.Warn("API", fmt.Sprintf("CompTickets: invalid request body: %v", err))
+		writeBodyError(w, err, "Invalid request body")
+		return
+	}
+	h.Logger.Info("API", fmt.Sprintf("CompTickets: %d seats of session %s by userId=%s", len(req.Seats), req.SessionID, issuedBy))
+	if !h.requireSessionOwner(w, r, req.SessionID, "CompTickets") {
+		return
+	}
+
+	comped, err := h.OrderService.CompTickets(r.Context(), req, issuedBy)
+	var validationErr *order.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		h.Logger.Warn("API", fmt.Sprintf("CompTickets: rejected by %s: %v", validationErr.Stage, validationErr))
+		utils.WriteErrorDetails(w, "seat_validation_failed", "Seat validation failed", validationErr.HTTPStatus(), map[string]interface{}{
+			"stage":           validationErr.Stage,
+			"reason":          validationErr.Reason,
+			"upstream_status": validationErr.StatusCode,
+			"details":         validationErr.Details,
+		})
+		return
+	case errors.Is(err, order.ErrInvalidCompRequest):
+		writeError(w, utils.ErrCodeInvalidRequest, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, order.ErrSeatsUnavailable):
+		writeError(w, utils.ErrCodeConflict, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		h.Logger.Error("API", fmt.Sprintf("CompTickets: failed: %v", err))
+		writeError(w, utils.ErrCodeInternal, "Failed to issue comp tickets", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(comped); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("CompTickets: failed to encode response: %v", err))
+	}
+}
@@ -2,11 +2,15 @@ package order_api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"ms-ticketing/internal/auth"
 	"ms-ticketing/internal/models"
 	"ms-ticketing/internal/order"
 	tickets "ms-ticketing/internal/tickets/service"
+	"ms-ticketing/internal/utils"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 
@@ -32,16 +36,16 @@ func (h *Handler) GetOrder(w http.ResponseWriter, r *http.Request) {
 	orderID := chi.URLParam(r, "orderId")
 	h.Logger.Info("API", fmt.Sprintf("GetOrder: orderId=%s", orderID))
 
-	orderData, err := h.OrderService.GetOrder(orderID)
+	orderData, err := h.OrderService.GetOrder(r.Context(), orderID)
 	if err != nil {
 		h.Logger.Error("API", fmt.Sprintf("GetOrder: order not found: %v", err))
-		http.Error(w, "Order not found", http.StatusNotFound)
+		writeError(w, utils.ErrCodeNotFound, "Order not found", http.StatusNotFound)
 		return
 	}
 	h.Logger.Debug("API", fmt.Sprintf("GetOrder: found order: %+v", orderData))
 
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(orderData)
+	err = json.NewEncoder(w).Encode(models.NewPricedOrder(*orderData))
 	if err != nil {
 		h.Logger.Error("API", fmt.Sprintf("GetOrder: failed to encode response: %v", err))
 		return
@@ -49,14 +53,53 @@ func (h *Handler) GetOrder(w http.ResponseWriter, r *http.Request) {
 	h.Logger.Info("API", "GetOrder: response sent successfully")
 }
 
+// LookupOrderByNumber resolves a human-readable order number such as EVT-20240617-000123,
+// e.g. one read out to support over the phone, and returns the order like GetOrder. Numbers are
+// sequential, so buyers only find their own orders; anyone else's number is reported as not found.
+func (h *Handler) LookupOrderByNumber(w http.ResponseWriter, r *http.Request) {
+	number := r.URL.Query().Get("number")
+	h.Logger.Info("API", fmt.Sprintf("LookupOrderByNumber: number=%s", number))
+
+	ownerID, ok := requestOrderOwner(w, r)
+	if !ok {
+		return
+	}
+
+	orderData, err := h.OrderService.GetOrderByNumber(r.Context(), number)
+	switch {
+	case errors.Is(err, order.ErrInvalidOrderNumber):
+		writeError(w, utils.ErrCodeInvalidRequest, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		h.Logger.Warn("API", fmt.Sprintf("LookupOrderByNumber: order not found: %v", err))
+		writeError(w, utils.ErrCodeNotFound, "Order not found", http.StatusNotFound)
+		return
+	case ownerID != "" && orderData.UserID != ownerID:
+		h.Logger.Warn("API", fmt.Sprintf("LookupOrderByNumber: order %s is not owned by the caller", orderData.OrderID))
+		writeError(w, utils.ErrCodeNotFound, "Order not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(models.NewPricedOrder(*orderData)); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("LookupOrderByNumber: failed to encode response: %v", err))
+	}
+}
+
 func (h *Handler) DeleteOrder(w http.ResponseWriter, r *http.Request) {
 	orderID := chi.URLParam(r, "orderId")
 	h.Logger.Info("API", fmt.Sprintf("DeleteOrder: orderId=%s", orderID))
 
-	err := h.OrderService.CancelOrder(orderID)
+	// Only the buyer, support or admins may cancel an order and its payment
+	if h.loadOwnedOrder(w, r, orderID, "DeleteOrder") == nil {
+		return
+	}
+
+	ctx := order.WithCancellationReason(r.Context(), order.CancellationRequestedByCustomer)
+	err := h.OrderService.CancelOrder(ctx, orderID)
 	if err != nil {
 		h.Logger.Error("API", fmt.Sprintf("DeleteOrder: failed to cancel order: %v", err))
-		http.Error(w, "Could not cancel order: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, utils.ErrCodeInternal, "Could not cancel order: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 	h.Logger.Info("API", "DeleteOrder: order cancelled successfully")
@@ -103,20 +146,40 @@ func (h *Handler) SeatValidationAndPlaceOrder(w http.ResponseWriter, r *http.Req
 	// Parse the JSON request body
 	var orderReq models.OrderRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&orderReq); err != nil {
+	if err := utils.DecodeJSONBody(w, r, &orderReq); err != nil {
 		h.Logger.Error("API", fmt.Sprintf("SeatValidationAndPlaceOrder: failed to decode request body: %v", err))
-		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		writeBodyError(w, err, "Invalid request body: "+err.Error())
 		return
 	}
 
 	h.Logger.Debug("API", fmt.Sprintf("SeatValidationAndPlaceOrder: SessionID: %s", orderReq.SessionID))
 	h.Logger.Debug("API", fmt.Sprintf("SeatValidationAndPlaceOrder: SeatIDs: %v", orderReq.SeatIDs))
 
+	// ?dry_run=true validates and prices the order without locking seats or creating anything
+	dryRun := false
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, utils.ErrCodeInvalidRequest, "dry_run must be true or false", http.StatusBadRequest)
+			return
+		}
+		dryRun = parsed
+	}
+	if dryRun {
+		quote, err := h.OrderService.DryRunOrder(r, orderReq)
+		if err != nil {
+			h.writePlaceOrderError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(quote)
+		return
+	}
+
 	// Call service
 	response, err := h.OrderService.SeatValidationAndPlaceOrder(r, orderReq)
 	if err != nil {
-		h.Logger.Error("API", fmt.Sprintf("SeatValidationAndPlaceOrder: seat validation failed: %v", err))
-		http.Error(w, "Seat validation failed: "+err.Error(), http.StatusBadRequest)
+		h.writePlaceOrderError(w, err)
 		return
 	}
 
@@ -131,21 +194,55 @@ func (h *Handler) SeatValidationAndPlaceOrder(w http.ResponseWriter, r *http.Req
 	h.Logger.Info("API", "SeatValidationAndPlaceOrder: order created successfully")
 }
 
+// writePlaceOrderError maps a failed placement or dry run to its error response
+func (h *Handler) writePlaceOrderError(w http.ResponseWriter, err error) {
+	var validationErr *order.ValidationError
+	if errors.As(err, &validationErr) {
+		h.Logger.Warn("API", fmt.Sprintf("SeatValidationAndPlaceOrder: rejected by %s: %v", validationErr.Stage, validationErr))
+		utils.WriteErrorDetails(w, "seat_validation_failed", "Seat validation failed", validationErr.HTTPStatus(), map[string]interface{}{
+			"stage":           validationErr.Stage,
+			"reason":          validationErr.Reason,
+			"upstream_status": validationErr.StatusCode,
+			"details":         validationErr.Details,
+		})
+		return
+	}
+
+	if errors.Is(err, order.ErrTooManySeats) {
+		h.Logger.Warn("API", fmt.Sprintf("SeatValidationAndPlaceOrder: %v", err))
+		writeError(w, "too_many_seats", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, models.ErrUnsupportedCurrency) {
+		h.Logger.Warn("API", fmt.Sprintf("SeatValidationAndPlaceOrder: %v", err))
+		writeError(w, "unsupported_currency", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, order.ErrTooManyPendingOrders) {
+		h.Logger.Warn("API", fmt.Sprintf("SeatValidationAndPlaceOrder: %v", err))
+		writeError(w, "too_many_pending_orders", err.Error(), http.StatusConflict)
+		return
+	}
+
+	h.Logger.Error("API", fmt.Sprintf("SeatValidationAndPlaceOrder: seat validation failed: %v", err))
+	writeError(w, utils.ErrCodeInvalidRequest, "Seat validation failed: "+err.Error(), http.StatusBadRequest)
+}
+
 func (h *Handler) GetOrdersWithTicketsByUserID(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "userId")
 	h.Logger.Info("API", fmt.Sprintf("GetOrdersWithTicketsByUserID: userId=%s", userID))
 
 	if userID == "" {
 		h.Logger.Error("API", "GetOrdersWithTicketsByUserID: user ID is required")
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+		writeError(w, utils.ErrCodeInvalidRequest, "User ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Use the new method that includes QR codes
-	ordersWithTicketsAndQR, err := h.OrderService.GetOrdersWithTicketsAndQRByUserID(userID)
+	ordersWithTicketsAndQR, err := h.OrderService.GetOrdersWithTicketsAndQRByUserID(r.Context(), userID)
 	if err != nil {
 		h.Logger.Error("API", fmt.Sprintf("GetOrdersWithTicketsByUserID: failed to get orders with tickets and QR codes: %v", err))
-		http.Error(w, "Failed to retrieve orders: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, utils.ErrCodeInternal, "Failed to retrieve orders: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -159,3 +256,35 @@ func (h *Handler) GetOrdersWithTicketsByUserID(w http.ResponseWriter, r *http.Re
 	}
 	h.Logger.Info("API", fmt.Sprintf("GetOrdersWithTicketsByUserID: response sent successfully for user %s", userID))
 }
+
+// ResendConfirmation asks the notification service to send the order confirmation and tickets again
+func (h *Handler) ResendConfirmation(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderId")
+	userID := auth.UserID(r.Context())
+	h.Logger.Info("API", fmt.Sprintf("ResendConfirmation: orderId=%s userId=%s", orderID, userID))
+
+	if userID == "" {
+		writeError(w, utils.ErrCodeUnauthorized, "Unauthorized access", http.StatusUnauthorized)
+		return
+	}
+
+	err := h.OrderService.ResendConfirmation(r.Context(), orderID, userID)
+	if err != nil {
+		h.Logger.Warn("API", fmt.Sprintf("ResendConfirmation: failed for order %s: %v", orderID, err))
+		switch {
+		case errors.Is(err, order.ErrNotOrderOwner):
+			writeError(w, utils.ErrCodeNotOrderOwner, err.Error(), http.StatusForbidden)
+		case errors.Is(err, order.ErrOrderNotCompleted):
+			writeError(w, "order_not_completed", err.Error(), http.StatusConflict)
+		case errors.Is(err, order.ErrResendTooSoon):
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(order.ResendCooldown().Seconds())))
+			writeError(w, "resend_too_soon", err.Error(), http.StatusTooManyRequests)
+		default:
+			writeError(w, utils.ErrCodeInternal, "Could not resend confirmation: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	h.Logger.Info("API", fmt.Sprintf("ResendConfirmation: confirmation resend queued for order %s", orderID))
+}
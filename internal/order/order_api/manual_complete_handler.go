@@ -0,0 +1,47 @@
+package order_api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/auth"
+	"ms-ticketing/internal/order"
+	"ms-ticketing/internal/utils"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CompleteOrder completes a pending order after confirming with Stripe that its payment succeeded.
+// Support uses it when the payment went through but the webhook was lost.
+func (h *Handler) CompleteOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderId")
+	h.Logger.Info("API", fmt.Sprintf("CompleteOrder: orderId=%s by userId=%s", orderID, auth.UserID(r.Context())))
+
+	if orderID == "" {
+		writeError(w, utils.ErrCodeInvalidRequest, "Order ID is required", http.StatusBadRequest)
+		return
+	}
+
+	err := h.OrderService.CompleteOrderManually(r.Context(), orderID)
+	switch {
+	case errors.Is(err, order.ErrAlreadyCompleted):
+		writeError(w, "order_already_completed", err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, order.ErrOrderNotPending):
+		writeError(w, "order_not_pending", err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, order.ErrPaymentNotConfirmed):
+		writeError(w, "payment_not_confirmed", err.Error(), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		h.Logger.Error("API", fmt.Sprintf("CompleteOrder: failed: %v", err))
+		writeError(w, utils.ErrCodeUpstream, "Failed to complete order: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"order_id": orderID, "status": "completed"}); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("CompleteOrder: failed to encode response: %v", err))
+	}
+}
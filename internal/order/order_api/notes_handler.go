@@ -0,0 +1,75 @@
+package order_api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/auth"
+	"ms-ticketing/internal/order"
+	"ms-ticketing/internal/utils"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AddOrderNote attaches an internal note to an order on behalf of the authenticated support agent
+func (h *Handler) AddOrderNote(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderId")
+	authorID := auth.UserID(r.Context())
+	h.Logger.Info("API", fmt.Sprintf("AddOrderNote: orderId=%s by userId=%s", orderID, authorID))
+
+	if authorID == "" {
+		writeError(w, utils.ErrCodeUnauthorized, "Unauthorized access", http.StatusUnauthorized)
+		return
+	}
+
+	var requestBody struct {
+		Note string `json:"note"`
+	}
+	if err := utils.DecodeJSONBody(w, r, &requestBody); err != nil {
+		h.Logger.Warn("API", fmt.Sprintf("AddOrderNote: invalid request body: %v", err))
+		writeBodyError(w, err, "Invalid request body")
+		return
+	}
+
+	note, err := h.OrderService.AddOrderNote(r.Context(), orderID, authorID, requestBody.Note)
+	if err != nil {
+		h.writeNoteError(w, "AddOrderNote", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(note); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("AddOrderNote: failed to encode response: %v", err))
+	}
+}
+
+// GetOrderNotes lists an order's internal notes with their authors and timestamps
+func (h *Handler) GetOrderNotes(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderId")
+	h.Logger.Info("API", fmt.Sprintf("GetOrderNotes: orderId=%s by userId=%s", orderID, auth.UserID(r.Context())))
+
+	notes, err := h.OrderService.GetOrderNotes(r.Context(), orderID)
+	if err != nil {
+		h.writeNoteError(w, "GetOrderNotes", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"order_id": orderID, "notes": notes}); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("GetOrderNotes: failed to encode response: %v", err))
+	}
+}
+
+func (h *Handler) writeNoteError(w http.ResponseWriter, handler string, err error) {
+	switch {
+	case errors.Is(err, order.ErrOrderNotFound):
+		writeError(w, utils.ErrCodeNotFound, "Order not found", http.StatusNotFound)
+	case errors.Is(err, order.ErrInvalidNote):
+		writeError(w, utils.ErrCodeInvalidRequest, err.Error(), http.StatusBadRequest)
+	default:
+		h.Logger.Error("API", fmt.Sprintf("%s: failed: %v", handler, err))
+		writeError(w, utils.ErrCodeInternal, "Failed to process order notes", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,273 @@
+package order_api_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"ms-ticketing/internal/auth"
+	"ms-ticketing/internal/kafka"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	"ms-ticketing/internal/order/db"
+	"ms-ticketing/internal/order/order_api"
+	ticket_db "ms-ticketing/internal/tickets/db"
+	tickets "ms-ticketing/internal/tickets/service"
+	"ms-ticketing/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	_ "github.com/uptrace/bun/driver/sqliteshim"
+)
+
+// newOwnershipRouter serves the buyer's order endpoints over an in-memory database holding one
+// pending order of "buyer"
+func newOwnershipRouter(t *testing.T) (http.Handler, *bun.DB) {
+	t.Helper()
+	t.Setenv("PAYMENT_MODE", "mock")
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("STRIPE_SECRET_KEY", "")
+	t.Setenv("STRIPE_PUBLISHABLE_KEY", "pk_test_wallet")
+	t.Setenv("ADMIN_ROLE", "")
+	t.Setenv("SUPPORT_ROLE", "")
+
+	sqldb, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	sqldb.SetMaxOpenConns(1)
+	bunDB := bun.NewDB(sqldb, sqlitedialect.New())
+	t.Cleanup(func() { bunDB.Close() })
+	for _, model := range []interface{}{(*models.Order)(nil), (*models.Ticket)(nil)} {
+		_, err := bunDB.NewCreateTable().Model(model).Exec(context.Background())
+		require.NoError(t, err)
+	}
+
+	pending := models.Order{OrderID: "order-1", OrderNumber: "EVT-20240617-000123", UserID: "buyer", EventID: "event-1", SessionID: "session-1", Status: "pending", Price: models.MoneyFromFloat(25), Currency: "LKR", CreatedAt: time.Now()}
+	_, err = bunDB.NewInsert().Model(&pending).Exec(context.Background())
+	require.NoError(t, err)
+
+	ticketService := tickets.NewTicketService(&ticket_db.DB{Bun: bunDB})
+	orderService := order.NewOrderService(&db.DB{Bun: bunDB}, nil, kafka.NewInMemoryProducer(), ticketService, http.DefaultClient)
+	handler := order_api.NewHandler(orderService, ticketService)
+
+	r := chi.NewRouter()
+	r.Get("/api/order/{orderId}/summary", handler.GetOrderSummary)
+	r.Get("/api/order/{orderId}/payment-status", handler.GetPaymentStatus)
+	r.Post("/api/order/{orderId}/create-payment-intent", handler.CreatePaymentIntent)
+	r.Post("/api/order/{orderId}/wallet-payment-intent", handler.CreateWalletPaymentIntent)
+	r.Get("/api/order/lookup", handler.LookupOrderByNumber)
+	r.Delete("/api/order/{orderId}", handler.DeleteOrder)
+	r.Post("/api/order/seats/lookup", handler.LookupSeatOrders)
+	return r, bunDB
+}
+
+// serveAs sends the request as the given user, or anonymously when userID is empty
+func serveAs(router http.Handler, method, path, userID string, roles ...string) *httptest.ResponseRecorder {
+	return serveBodyAs(router, method, path, "", userID, roles...)
+}
+
+func serveBodyAs(router http.Handler, method, path, body, userID string, roles ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if userID != "" {
+		req = req.WithContext(auth.WithUser(req.Context(), userID, roles...))
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var envelope utils.ErrorEnvelope
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&envelope))
+	return envelope.Error.Code
+}
+
+func TestOrderEndpointsRejectOtherUsers(t *testing.T) {
+	router, bunDB := newOwnershipRouter(t)
+
+	endpoints := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/api/order/order-1/summary"},
+		{http.MethodGet, "/api/order/order-1/payment-status"},
+		{http.MethodPost, "/api/order/order-1/create-payment-intent"},
+		{http.MethodPost, "/api/order/order-1/wallet-payment-intent"},
+	}
+	for _, ep := range endpoints {
+		t.Run(ep.path, func(t *testing.T) {
+			rec := serveAs(router, ep.method, ep.path, "")
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+			rec = serveAs(router, ep.method, ep.path, "someone-else")
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, utils.ErrCodeNotOrderOwner, errorCode(t, rec))
+
+			rec = serveAs(router, ep.method, "/api/order/missing/"+ep.path[len("/api/order/order-1/"):], "buyer")
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		})
+	}
+
+	// Rejected callers never got an intent created for the order
+	stored := new(models.Order)
+	require.NoError(t, bunDB.NewSelect().Model(stored).Where("order_id = ?", "order-1").Scan(context.Background()))
+	assert.Empty(t, stored.PaymentIntentID)
+}
+
+func TestOrderEndpointsServeTheBuyer(t *testing.T) {
+	router, _ := newOwnershipRouter(t)
+
+	rec := serveAs(router, http.MethodPost, "/api/order/order-1/wallet-payment-intent", "buyer")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var wallet struct {
+		ClientSecret   string `json:"client_secret"`
+		PublishableKey string `json:"publishable_key"`
+		Amount         int64  `json:"amount"`
+		Currency       string `json:"currency"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&wallet))
+	assert.NotEmpty(t, wallet.ClientSecret)
+	assert.Equal(t, "pk_test_wallet", wallet.PublishableKey)
+	assert.Equal(t, int64(2500), wallet.Amount)
+	assert.Equal(t, "lkr", wallet.Currency)
+
+	rec = serveAs(router, http.MethodPost, "/api/order/order-1/create-payment-intent", "buyer")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = serveAs(router, http.MethodGet, "/api/order/order-1/payment-status", "buyer")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var status order.PaymentStatus
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&status))
+	assert.Equal(t, "order-1", status.OrderID)
+	assert.Equal(t, order.PaymentStatusRequiresPayment, status.PaymentStatus)
+
+	rec = serveAs(router, http.MethodGet, "/api/order/order-1/summary", "buyer")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var summary order.OrderSummary
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&summary))
+	assert.Equal(t, "order-1", summary.Order.OrderID)
+	assert.Nil(t, summary.AvailabilitySnapshot)
+}
+
+func TestOrderEndpointsServeStaff(t *testing.T) {
+	router, _ := newOwnershipRouter(t)
+
+	for _, role := range []string{auth.SupportRole(), auth.AdminRole()} {
+		rec := serveAs(router, http.MethodGet, "/api/order/order-1/summary", "staff-user", role)
+		assert.Equal(t, http.StatusOK, rec.Code, role)
+
+		rec = serveAs(router, http.MethodGet, "/api/order/order-1/payment-status", "staff-user", role)
+		assert.Equal(t, http.StatusOK, rec.Code, role)
+	}
+}
+
+func TestCancelOrderRejectsOtherUsers(t *testing.T) {
+	router, bunDB := newOwnershipRouter(t)
+
+	rec := serveAs(router, http.MethodDelete, "/api/order/order-1", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = serveAs(router, http.MethodDelete, "/api/order/order-1", "someone-else")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, utils.ErrCodeNotOrderOwner, errorCode(t, rec))
+
+	rec = serveAs(router, http.MethodDelete, "/api/order/missing", "buyer")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	stored := new(models.Order)
+	require.NoError(t, bunDB.NewSelect().Model(stored).Where("order_id = ?", "order-1").Scan(context.Background()))
+	assert.Equal(t, "pending", stored.Status)
+}
+
+func TestSeatLookupOnlyShowsOwnOrders(t *testing.T) {
+	router, bunDB := newOwnershipRouter(t)
+	ticket := models.Ticket{TicketID: "ticket-1", OrderID: "order-1", SeatID: "seat-1", IssuedAt: time.Now()}
+	_, err := bunDB.NewInsert().Model(&ticket).Exec(context.Background())
+	require.NoError(t, err)
+
+	lookup := func(userID string, roles ...string) map[string]map[string]interface{} {
+		rec := serveBodyAs(router, http.MethodPost, "/api/order/seats/lookup", `{"seat_ids":["seat-1","seat-2"]}`, userID, roles...)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var seats map[string]map[string]interface{}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&seats))
+		return seats
+	}
+
+	// Another buyer never learns the order holding the seat
+	assert.Empty(t, lookup("someone-else"))
+
+	seats := lookup("buyer")
+	require.Contains(t, seats, "seat-1")
+	assert.Equal(t, "order-1", seats["seat-1"]["order_id"])
+	assert.Equal(t, true, seats["seat-1"]["owned_by_caller"])
+
+	seats = lookup("staff-user", auth.SupportRole())
+	require.Contains(t, seats, "seat-1")
+	assert.Equal(t, false, seats["seat-1"]["owned_by_caller"])
+}
+
+func TestLookupOrderByNumberOnlyFindsOwnOrders(t *testing.T) {
+	router, _ := newOwnershipRouter(t)
+	const path = "/api/order/lookup?number=EVT-20240617-000123"
+
+	rec := serveAs(router, http.MethodGet, path, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Someone else's number looks like one that doesn't exist
+	rec = serveAs(router, http.MethodGet, path, "someone-else")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, utils.ErrCodeNotFound, errorCode(t, rec))
+
+	rec = serveAs(router, http.MethodGet, path, "buyer")
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = serveAs(router, http.MethodGet, path, "staff-user", auth.SupportRole())
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestSeatHoldsRequireTheSessionOwner(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/token"):
+			json.NewEncoder(w).Encode(models.M2MTokenResponse{AccessToken: "m2m-token", ExpiresIn: 300, TokenType: "Bearer"})
+		case strings.HasSuffix(r.URL.Path, "/sessions/verify-ownership"):
+			json.NewEncoder(w).Encode(r.URL.Query().Get("userId") == "organizer-1")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(upstream.Close)
+	t.Setenv("KEYCLOAK_URL", upstream.URL)
+	t.Setenv("KEYCLOAK_REALM", "ticketly")
+	t.Setenv("ORGANIZER_ROLE", "")
+	seating, err := url.Parse(upstream.URL + "/event-seating/")
+	require.NoError(t, err)
+
+	orderService := order.NewOrderService(&db.DB{}, nil, kafka.NewInMemoryProducer(), nil, upstream.Client())
+	orderService.SetServiceURLs(nil, seating)
+	handler := order_api.NewHandler(orderService, nil)
+	r := chi.NewRouter()
+	r.Post("/hold-seats", handler.HoldSeats)
+	r.Post("/release-seats", handler.ReleaseHeldSeats)
+	r.Post("/comp-tickets", handler.CompTickets)
+
+	bodies := map[string]string{
+		"/hold-seats":    `{"session_id":"session-1","seat_ids":["seat-1"]}`,
+		"/release-seats": `{"session_id":"session-1","seat_ids":["seat-1"]}`,
+		"/comp-tickets":  `{"session_id":"session-1","event_id":"event-1","seats":[{"seatId":"seat-1"}]}`,
+	}
+	for path, body := range bodies {
+		// An organizer of another organization can't touch the session's seats
+		rec := serveBodyAs(r, http.MethodPost, path, body, "organizer-2", auth.OrganizerRole())
+		assert.Equal(t, http.StatusForbidden, rec.Code, path)
+		assert.Equal(t, utils.ErrCodeForbidden, errorCode(t, rec), path)
+	}
+}
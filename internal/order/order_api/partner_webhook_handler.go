@@ -0,0 +1,153 @@
+package order_api
+
+import (
+	"encoding/json"
+	"fmt"
+	"ms-ticketing/internal/auth"
+	"ms-ticketing/internal/logger"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/utils"
+	"ms-ticketing/internal/webhooks"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// PartnerWebhookHandler lets organization members manage the HTTP callbacks that receive order events
+type PartnerWebhookHandler struct {
+	Webhooks webhooks.Store
+	Logger   *logger.Logger
+	// VerifyMembership reports whether the user belongs to the organization
+	VerifyMembership func(organizationID, userID string) (bool, error)
+}
+
+func NewPartnerWebhookHandler(store webhooks.Store, logger *logger.Logger, redisClient redis.UniversalClient, httpClient *http.Client) *PartnerWebhookHandler {
+	return &PartnerWebhookHandler{
+		Webhooks: store,
+		Logger:   logger,
+		VerifyMembership: func(organizationID, userID string) (bool, error) {
+			return verifyOrganizationMembership(logger, redisClient, httpClient, organizationID, userID)
+		},
+	}
+}
+
+// createdPartnerWebhook is the registration response; the secret is only ever shown here
+type createdPartnerWebhook struct {
+	models.PartnerWebhook
+	Secret string `json:"secret"`
+}
+
+// RegisterWebhook registers a callback URL for the organization and returns its signing secret
+func (h *PartnerWebhookHandler) RegisterWebhook(w http.ResponseWriter, r *http.Request) {
+	organizationID, userID, ok := h.authorize(w, r, "RegisterWebhook")
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		URL string `json:"url"`
+	}
+	if err := utils.DecodeJSONBody(w, r, &requestBody); err != nil {
+		h.Logger.Warn("API", fmt.Sprintf("RegisterWebhook: invalid request body: %v", err))
+		writeBodyError(w, err, "Invalid request body")
+		return
+	}
+	if err := webhooks.ValidateCallbackURL(r.Context(), requestBody.URL); err != nil {
+		h.Logger.Warn("API", fmt.Sprintf("RegisterWebhook: rejected url for organization %s: %v", organizationID, err))
+		writeError(w, utils.ErrCodeInvalidRequest, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		h.Logger.Error("API", fmt.Sprintf("RegisterWebhook: failed to generate secret: %v", err))
+		writeError(w, utils.ErrCodeInternal, "Failed to register webhook", http.StatusInternalServerError)
+		return
+	}
+	webhook := models.PartnerWebhook{
+		WebhookID:      uuid.New().String(),
+		OrganizationID: organizationID,
+		URL:            requestBody.URL,
+		Secret:         secret,
+		CreatedBy:      userID,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err := h.Webhooks.CreateWebhook(r.Context(), webhook); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("RegisterWebhook: failed to save webhook for organization %s: %v", organizationID, err))
+		writeError(w, utils.ErrCodeInternal, "Failed to register webhook", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(createdPartnerWebhook{PartnerWebhook: webhook, Secret: secret}); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("RegisterWebhook: failed to encode response: %v", err))
+	}
+}
+
+// ListWebhooks lists the organization's callbacks without their secrets
+func (h *PartnerWebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	organizationID, _, ok := h.authorize(w, r, "ListWebhooks")
+	if !ok {
+		return
+	}
+
+	hooks, err := h.Webhooks.ListWebhooks(r.Context(), organizationID)
+	if err != nil {
+		h.Logger.Error("API", fmt.Sprintf("ListWebhooks: failed for organization %s: %v", organizationID, err))
+		writeError(w, utils.ErrCodeInternal, "Failed to list webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"organization_id": organizationID, "webhooks": hooks}); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("ListWebhooks: failed to encode response: %v", err))
+	}
+}
+
+// DeleteWebhook removes one of the organization's callbacks
+func (h *PartnerWebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	organizationID, _, ok := h.authorize(w, r, "DeleteWebhook")
+	if !ok {
+		return
+	}
+	webhookID := chi.URLParam(r, "webhookId")
+
+	deleted, err := h.Webhooks.DeleteWebhook(r.Context(), organizationID, webhookID)
+	if err != nil {
+		h.Logger.Error("API", fmt.Sprintf("DeleteWebhook: failed to delete webhook %s: %v", webhookID, err))
+		writeError(w, utils.ErrCodeInternal, "Failed to delete webhook", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		writeError(w, utils.ErrCodeNotFound, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorize resolves the organization from the URL and checks the caller belongs to it
+func (h *PartnerWebhookHandler) authorize(w http.ResponseWriter, r *http.Request, handler string) (string, string, bool) {
+	organizationID := chi.URLParam(r, "organizationID")
+	userID := auth.UserID(r.Context())
+	h.Logger.Info("API", fmt.Sprintf("%s: organizationId=%s by userId=%s", handler, organizationID, userID))
+
+	if userID == "" {
+		writeError(w, utils.ErrCodeUnauthorized, "Unauthorized access", http.StatusUnauthorized)
+		return "", "", false
+	}
+	isMember, err := h.VerifyMembership(organizationID, userID)
+	if err != nil {
+		h.Logger.Error("API", fmt.Sprintf("%s: membership check failed: %v", handler, err))
+		writeError(w, utils.ErrCodeUpstream, "Failed to verify organization membership", http.StatusBadGateway)
+		return "", "", false
+	}
+	if !isMember {
+		writeError(w, utils.ErrCodeForbidden, "You are not a member of this organization", http.StatusForbidden)
+		return "", "", false
+	}
+	return organizationID, userID, true
+}
@@ -0,0 +1,61 @@
+package order_api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/order"
+	"ms-ticketing/internal/utils"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CreatePayHereCheckout signs a PayHere checkout for the order. The client posts the returned
+// fields to checkout_url; PayHere then reports the outcome to PayHereWebhook.
+func (h *Handler) CreatePayHereCheckout(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderId")
+	h.Logger.Info("API", fmt.Sprintf("CreatePayHereCheckout: orderId=%s", orderID))
+
+	payment, err := h.OrderService.CreateProviderPayment(r.Context(), orderID, order.PaymentProviderPayHere)
+	switch {
+	case errors.Is(err, order.ErrUnknownPaymentProvider):
+		writeError(w, utils.ErrCodeNotFound, "PayHere payments are not enabled", http.StatusNotFound)
+		return
+	case errors.Is(err, order.ErrOrderNotFound):
+		writeError(w, utils.ErrCodeNotFound, "Order not found", http.StatusNotFound)
+		return
+	case errors.Is(err, order.ErrOrderNotPending):
+		writeError(w, utils.ErrCodeConflict, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		h.Logger.Error("API", fmt.Sprintf("CreatePayHereCheckout: failed: %v", err))
+		writeError(w, utils.ErrCodeInternal, "Failed to create PayHere checkout", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(payment); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("CreatePayHereCheckout: failed to encode response: %v", err))
+	}
+}
+
+// PayHereWebhook handles PayHere's payment notifications
+func (h *Handler) PayHereWebhook(w http.ResponseWriter, r *http.Request) {
+	h.Logger.Info("API", "PayHereWebhook: received payment notification")
+
+	utils.LimitBody(w, r)
+
+	if err := h.OrderService.HandlePaymentWebhook(r, order.PaymentProviderPayHere); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("PayHereWebhook: failed to process notification: %v", err))
+		var webhookErr *order.WebhookError
+		if errors.As(err, &webhookErr) {
+			writeError(w, utils.ErrorCodeForStatus(webhookErr.StatusCode), webhookErr.PublicError, webhookErr.StatusCode)
+			return
+		}
+		writeError(w, utils.ErrCodeInvalidRequest, "Webhook processing error", http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"ms-ticketing/internal/models"
 	"ms-ticketing/internal/order"
+	"ms-ticketing/internal/utils"
 	"net/http"
 	"os"
 	"strconv"
@@ -20,20 +21,23 @@ func (h *Handler) CreatePaymentIntent(w http.ResponseWriter, r *http.Request) {
 
 	if orderID == "" {
 		h.Logger.Error("API", "CreatePaymentIntent: order ID is required")
-		http.Error(w, "Order ID is required", http.StatusBadRequest)
+		writeError(w, utils.ErrCodeInvalidRequest, "Order ID is required", http.StatusBadRequest)
+		return
+	}
+	if h.loadOwnedOrder(w, r, orderID, "CreatePaymentIntent") == nil {
 		return
 	}
 
 	// Create payment intent
-	intent, err := h.OrderService.CreatePaymentIntent(orderID)
+	intent, err := h.OrderService.CreatePaymentIntent(r.Context(), orderID)
 	if err != nil {
 		h.Logger.Error("API", fmt.Sprintf("CreatePaymentIntent: failed to create payment intent: %v", err))
-		http.Error(w, "Failed to create payment intent: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, utils.ErrCodeInternal, "Failed to create payment intent: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Get order with tickets for comprehensive response
-	orderWithTickets, err := h.OrderService.GetOrderWithTickets(orderID)
+	orderWithTickets, err := h.OrderService.GetOrderWithTickets(r.Context(), orderID)
 	if err != nil {
 		h.Logger.Error("API", fmt.Sprintf("CreatePaymentIntent: failed to get order with tickets: %v", err))
 		// Continue anyway as we have the payment intent
@@ -54,11 +58,11 @@ func (h *Handler) CreatePaymentIntent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Calculate remaining time based on order's CreatedAt
-	order, err := h.OrderService.GetOrder(orderID)
+	order, err := h.OrderService.GetOrder(r.Context(), orderID)
 	if err != nil {
 		h.Logger.Error("API", fmt.Sprintf("CreatePaymentIntent: failed to get order for remaining time calculation: %v", err))
 		// Fallback to total duration if we can't get the order
-		http.Error(w, "Failed to calculate remaining time", http.StatusInternalServerError)
+		writeError(w, utils.ErrCodeInternal, "Failed to calculate remaining time", http.StatusInternalServerError)
 		return
 	}
 
@@ -66,8 +70,11 @@ func (h *Handler) CreatePaymentIntent(w http.ResponseWriter, r *http.Request) {
 	elapsedTime := time.Since(order.CreatedAt)
 	elapsedMinutes := int(elapsedTime.Minutes())
 
-	// Calculate remaining time
+	// Calculate remaining time; an order with its own payment window is held until the window closes
 	remainingMinutes := totalLockDurationMinutes - elapsedMinutes
+	if !order.PaymentDueAt.IsZero() {
+		remainingMinutes = int(time.Until(order.PaymentDueAt).Minutes())
+	}
 	if remainingMinutes < 0 {
 		remainingMinutes = 0 // Seat lock has expired
 		h.Logger.Warn("API", fmt.Sprintf("Order %s seat lock has expired (elapsed: %d mins, limit: %d mins)", orderID, elapsedMinutes, totalLockDurationMinutes))
@@ -97,10 +104,96 @@ func (h *Handler) CreatePaymentIntent(w http.ResponseWriter, r *http.Request) {
 	h.Logger.Info("API", fmt.Sprintf("CreatePaymentIntent: created payment intent for order %s", orderID))
 }
 
+// CreateWalletPaymentIntent creates (or reuses) the order's payment intent and returns the fields
+// the Stripe Payment Request API needs to mount Apple Pay / Google Pay buttons
+func (h *Handler) CreateWalletPaymentIntent(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderId")
+	h.Logger.Info("API", fmt.Sprintf("CreateWalletPaymentIntent: orderId=%s", orderID))
+
+	if orderID == "" {
+		h.Logger.Error("API", "CreateWalletPaymentIntent: order ID is required")
+		writeError(w, utils.ErrCodeInvalidRequest, "Order ID is required", http.StatusBadRequest)
+		return
+	}
+
+	publishableKey := os.Getenv("STRIPE_PUBLISHABLE_KEY")
+	if publishableKey == "" {
+		h.Logger.Error("API", "CreateWalletPaymentIntent: STRIPE_PUBLISHABLE_KEY is not configured")
+		writeError(w, utils.ErrCodeInternal, "Wallet payments are not configured", http.StatusInternalServerError)
+		return
+	}
+
+	// The client secret lets whoever holds it pay for the order, so only its buyer may get one
+	if h.loadOwnedOrder(w, r, orderID, "CreateWalletPaymentIntent") == nil {
+		return
+	}
+
+	intent, err := h.OrderService.CreatePaymentIntent(r.Context(), orderID)
+	if err != nil {
+		h.Logger.Error("API", fmt.Sprintf("CreateWalletPaymentIntent: failed to create payment intent: %v", err))
+		writeError(w, utils.ErrCodeInternal, "Failed to create payment intent: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Amount stays in the smallest currency unit, which is what the Payment Request API expects
+	response := struct {
+		ClientSecret   string `json:"client_secret"`
+		PublishableKey string `json:"publishable_key"`
+		Amount         int64  `json:"amount"`
+		Currency       string `json:"currency"`
+	}{
+		ClientSecret:   intent.ClientSecret,
+		PublishableKey: publishableKey,
+		Amount:         intent.Amount,
+		Currency:       string(intent.Currency),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		h.Logger.Error("API", fmt.Sprintf("CreateWalletPaymentIntent: failed to encode response: %v", err))
+		return
+	}
+	h.Logger.Info("API", fmt.Sprintf("CreateWalletPaymentIntent: payment intent %s ready for order %s", intent.ID, orderID))
+}
+
+// GetPaymentStatus returns the normalized payment intent status for an order so the frontend can poll it
+func (h *Handler) GetPaymentStatus(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderId")
+	h.Logger.Info("API", fmt.Sprintf("GetPaymentStatus: orderId=%s", orderID))
+
+	if orderID == "" {
+		h.Logger.Error("API", "GetPaymentStatus: order ID is required")
+		writeError(w, utils.ErrCodeInvalidRequest, "Order ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if h.loadOwnedOrder(w, r, orderID, "GetPaymentStatus") == nil {
+		return
+	}
+
+	status, err := h.OrderService.GetPaymentStatus(r.Context(), orderID)
+	if err != nil {
+		h.Logger.Error("API", fmt.Sprintf("GetPaymentStatus: failed to get payment status: %v", err))
+		writeError(w, utils.ErrCodeUpstream, "Failed to get payment status", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(status)
+	if err != nil {
+		h.Logger.Error("API", fmt.Sprintf("GetPaymentStatus: failed to encode response: %v", err))
+		return
+	}
+	h.Logger.Info("API", fmt.Sprintf("GetPaymentStatus: order %s payment status %s", orderID, status.PaymentStatus))
+}
+
 // StripeWebhook handles webhook events from Stripe
 func (h *Handler) StripeWebhook(w http.ResponseWriter, r *http.Request) {
 	h.Logger.Info("API", "StripeWebhook: received webhook event")
 
+	utils.LimitBody(w, r)
+
 	// Process the webhook
 	err := h.OrderService.HandleStripeWebhook(r)
 	if err != nil {
@@ -113,12 +206,12 @@ func (h *Handler) StripeWebhook(w http.ResponseWriter, r *http.Request) {
 				webhookErr.Category, webhookErr.StatusCode))
 
 			// Return the public error message
-			http.Error(w, webhookErr.PublicError, webhookErr.StatusCode)
+			writeError(w, utils.ErrorCodeForStatus(webhookErr.StatusCode), webhookErr.PublicError, webhookErr.StatusCode)
 			return
 		}
 
 		// Default error handling
-		http.Error(w, "Webhook processing error", http.StatusBadRequest)
+		writeError(w, utils.ErrCodeInvalidRequest, "Webhook processing error", http.StatusBadRequest)
 		return
 	}
 
@@ -0,0 +1,48 @@
+package order_api
+
+import (
+	"encoding/json"
+	"fmt"
+	"ms-ticketing/internal/order"
+	"ms-ticketing/internal/utils"
+	"net/http"
+	"time"
+)
+
+// ReconcilePayments compares Stripe payment intents against local orders for a date range
+// and returns a report of discrepancies. It never modifies any order.
+// Expected POST request body: {"from": "2025-01-01T00:00:00Z", "to": "2025-01-08T00:00:00Z"}
+func (h *Handler) ReconcilePayments(w http.ResponseWriter, r *http.Request) {
+	var requestBody struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	}
+	if err := utils.DecodeJSONBody(w, r, &requestBody); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("ReconcilePayments: invalid request body: %v", err))
+		writeBodyError(w, err, "Invalid request body: from and to must be RFC3339 timestamps")
+		return
+	}
+	if requestBody.From.IsZero() || requestBody.To.IsZero() {
+		writeError(w, utils.ErrCodeInvalidRequest, "from and to are required", http.StatusBadRequest)
+		return
+	}
+
+	h.Logger.Info("API", fmt.Sprintf("ReconcilePayments: from=%s to=%s", requestBody.From.Format(time.RFC3339), requestBody.To.Format(time.RFC3339)))
+
+	if !requestBody.To.After(requestBody.From) || requestBody.To.Sub(requestBody.From) > order.MaxReconcileRange {
+		writeError(w, utils.ErrCodeInvalidRequest, fmt.Sprintf("to must be after from and the range must not exceed %s", order.MaxReconcileRange), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.OrderService.ReconcilePayments(r.Context(), requestBody.From, requestBody.To)
+	if err != nil {
+		h.Logger.Error("API", fmt.Sprintf("ReconcilePayments: failed: %v", err))
+		writeError(w, utils.ErrCodeUpstream, "Failed to reconcile payments: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("ReconcilePayments: failed to encode response: %v", err))
+	}
+}
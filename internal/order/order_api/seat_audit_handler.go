@@ -0,0 +1,44 @@
+package order_api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/order"
+	"ms-ticketing/internal/utils"
+	"net/http"
+	"strconv"
+)
+
+// GetSeatAuditEvents lists recent lock, unlock and expiry events filtered by seat_id and/or session_id
+func (h *Handler) GetSeatAuditEvents(w http.ResponseWriter, r *http.Request) {
+	seatID := r.URL.Query().Get("seat_id")
+	sessionID := r.URL.Query().Get("session_id")
+	h.Logger.Info("API", fmt.Sprintf("GetSeatAuditEvents: seatId=%s sessionId=%s", seatID, sessionID))
+
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			writeError(w, utils.ErrCodeInvalidRequest, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	events, err := h.OrderService.GetSeatAuditEvents(r.Context(), seatID, sessionID, limit)
+	if errors.Is(err, order.ErrSeatAuditFilterRequired) {
+		writeError(w, utils.ErrCodeInvalidRequest, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("API", fmt.Sprintf("GetSeatAuditEvents: failed: %v", err))
+		writeError(w, utils.ErrCodeInternal, "Failed to read seat audit events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"events": events}); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("GetSeatAuditEvents: failed to encode response: %v", err))
+	}
+}
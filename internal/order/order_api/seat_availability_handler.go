@@ -0,0 +1,44 @@
+package order_api
+
+import (
+	"encoding/json"
+	"fmt"
+	"ms-ticketing/internal/utils"
+	"net/http"
+)
+
+// CheckSeatsAvailability tells the seat map which seats are currently locked. It is read-only
+// and never takes a lock, so the frontend can call it before the user selects anything.
+// Expected POST request body: {"seat_ids": ["seat-1", "seat-2"]}
+func (h *Handler) CheckSeatsAvailability(w http.ResponseWriter, r *http.Request) {
+	var requestBody struct {
+		SeatIDs []string `json:"seat_ids"`
+	}
+	if err := utils.DecodeJSONBody(w, r, &requestBody); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("CheckSeatsAvailability: invalid request body: %v", err))
+		writeBodyError(w, err, "Invalid request body")
+		return
+	}
+	if len(requestBody.SeatIDs) == 0 {
+		writeError(w, utils.ErrCodeInvalidRequest, "seat_ids is required", http.StatusBadRequest)
+		return
+	}
+	if len(requestBody.SeatIDs) > maxSeatLookup {
+		writeError(w, utils.ErrCodeInvalidRequest, fmt.Sprintf("at most %d seat_ids may be checked at once", maxSeatLookup), http.StatusBadRequest)
+		return
+	}
+
+	h.Logger.Debug("API", fmt.Sprintf("CheckSeatsAvailability: seats=%d", len(requestBody.SeatIDs)))
+
+	availability, err := h.OrderService.CheckSeatsAvailability(requestBody.SeatIDs)
+	if err != nil {
+		h.Logger.Error("API", fmt.Sprintf("CheckSeatsAvailability: failed: %v", err))
+		writeError(w, utils.ErrCodeInternal, "Failed to check seat availability", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(availability); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("CheckSeatsAvailability: failed to encode response: %v", err))
+	}
+}
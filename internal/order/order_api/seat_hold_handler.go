@@ -0,0 +1,80 @@
+package order_api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/auth"
+	"ms-ticketing/internal/order"
+	"ms-ticketing/internal/utils"
+	"net/http"
+)
+
+// HoldSeats blocks seats off for VIPs or press without creating an order
+func (h *Handler) HoldSeats(w http.ResponseWriter, r *http.Request) {
+	h.handleSeatHold(w, r, "HoldSeats", h.OrderService.HoldSeats)
+}
+
+// ReleaseHeldSeats frees seats previously held with HoldSeats
+func (h *Handler) ReleaseHeldSeats(w http.ResponseWriter, r *http.Request) {
+	h.handleSeatHold(w, r, "ReleaseHeldSeats", h.OrderService.ReleaseHeldSeats)
+}
+
+func (h *Handler) handleSeatHold(w http.ResponseWriter, r *http.Request, handler string,
+	apply func(ctx context.Context, req order.SeatHoldRequest, userID string) (*order.SeatHoldResult, error)) {
+	userID := auth.UserID(r.Context())
+
+	var req order.SeatHoldRequest
+	if err := utils.DecodeJSONBody(w, r, &req); err != nil {
+		h.Logger.Warn("API", fmt.Sprintf("%s: invalid request body: %v", handler, err))
+		writeBodyError(w, err, "Invalid request body")
+		return
+	}
+	h.Logger.Info("API", fmt.Sprintf("%s: %d seats of session %s by userId=%s", handler, len(req.SeatIDs), req.SessionID, userID))
+	if !h.requireSessionOwner(w, r, req.SessionID, handler) {
+		return
+	}
+
+	result, err := apply(r.Context(), req, userID)
+	switch {
+	case errors.Is(err, order.ErrInvalidSeatHold):
+		writeError(w, utils.ErrCodeInvalidRequest, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, order.ErrSeatsUnavailable):
+		writeError(w, utils.ErrCodeConflict, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		h.Logger.Error("API", fmt.Sprintf("%s: failed: %v", handler, err))
+		writeError(w, utils.ErrCodeInternal, "Failed to update seat hold", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("%s: failed to encode response: %v", handler, err))
+	}
+}
+
+// requireSessionOwner lets admins act on any session and organizers only on sessions their organization
+// owns, since the organizer role is shared by every organization. It writes the error response and
+// returns false when the caller may not act on the session.
+func (h *Handler) requireSessionOwner(w http.ResponseWriter, r *http.Request, sessionID, handler string) bool {
+	if auth.HasRole(r.Context(), auth.AdminRole()) || sessionID == "" {
+		// Requests without a session are rejected by the service
+		return true
+	}
+	userID := auth.UserID(r.Context())
+	isOwner, err := h.OrderService.VerifySessionOwnership(r.Context(), sessionID, userID)
+	if err != nil {
+		h.Logger.Error("API", fmt.Sprintf("%s: failed to verify ownership of session %s: %v", handler, sessionID, err))
+		writeError(w, utils.ErrCodeUpstream, "Failed to verify session ownership", http.StatusBadGateway)
+		return false
+	}
+	if !isOwner {
+		h.Logger.Warn("API", fmt.Sprintf("%s: user %s does not own session %s", handler, userID, sessionID))
+		writeError(w, utils.ErrCodeForbidden, "You do not have permission to manage seats of this session", http.StatusForbidden)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package order_api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/auth"
+	"ms-ticketing/internal/order"
+	"ms-ticketing/internal/utils"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetSeatLock shows which order holds a seat lock and its remaining TTL, for debugging stuck seats
+func (h *Handler) GetSeatLock(w http.ResponseWriter, r *http.Request) {
+	seatID := chi.URLParam(r, "seatId")
+	h.Logger.Info("API", fmt.Sprintf("GetSeatLock: seatId=%s", seatID))
+
+	if seatID == "" {
+		writeError(w, utils.ErrCodeInvalidRequest, "Seat ID is required", http.StatusBadRequest)
+		return
+	}
+
+	info, err := h.OrderService.InspectSeatLock(seatID)
+	if err != nil {
+		h.Logger.Error("API", fmt.Sprintf("GetSeatLock: failed: %v", err))
+		writeError(w, utils.ErrCodeInternal, "Failed to read seat lock", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(info); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("GetSeatLock: failed to encode response: %v", err))
+	}
+}
+
+// ForceReleaseSeatLock deletes a seat lock regardless of the holding order and publishes the seat as available
+func (h *Handler) ForceReleaseSeatLock(w http.ResponseWriter, r *http.Request) {
+	seatID := chi.URLParam(r, "seatId")
+	h.Logger.Info("API", fmt.Sprintf("ForceReleaseSeatLock: seatId=%s by userId=%s", seatID, auth.UserID(r.Context())))
+
+	if seatID == "" {
+		writeError(w, utils.ErrCodeInvalidRequest, "Seat ID is required", http.StatusBadRequest)
+		return
+	}
+
+	info, err := h.OrderService.ForceReleaseSeatLock(r.Context(), seatID)
+	if errors.Is(err, order.ErrSeatNotLocked) {
+		writeError(w, "seat_not_locked", err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("API", fmt.Sprintf("ForceReleaseSeatLock: failed: %v", err))
+		writeError(w, utils.ErrCodeInternal, "Failed to release seat lock", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(info); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("ForceReleaseSeatLock: failed to encode response: %v", err))
+	}
+}
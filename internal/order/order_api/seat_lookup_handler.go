@@ -0,0 +1,75 @@
+package order_api
+
+import (
+	"encoding/json"
+	"fmt"
+	"ms-ticketing/internal/auth"
+	"ms-ticketing/internal/utils"
+	"net/http"
+)
+
+// maxSeatLookup bounds how many seats a single lookup may resolve.
+const maxSeatLookup = 500
+
+// seatOrderInfo is the per-seat view returned by LookupSeatOrders. It deliberately omits
+// pricing and user details.
+type seatOrderInfo struct {
+	OrderID       string `json:"order_id"`
+	Status        string `json:"status"`
+	OwnedByCaller bool   `json:"owned_by_caller"`
+}
+
+// LookupSeatOrders resolves the order holding each requested seat in one query so the
+// frontend can color a seat map without a request per seat. Buyers only see seats of their
+// own orders, so other people's order IDs never leak; support and admins see every seat.
+// Seats without a visible order are omitted from the response.
+// Expected POST request body: {"seat_ids": ["seat-1", "seat-2"]}
+func (h *Handler) LookupSeatOrders(w http.ResponseWriter, r *http.Request) {
+	var requestBody struct {
+		SeatIDs []string `json:"seat_ids"`
+	}
+	if err := utils.DecodeJSONBody(w, r, &requestBody); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("LookupSeatOrders: invalid request body: %v", err))
+		writeBodyError(w, err, "Invalid request body")
+		return
+	}
+	if len(requestBody.SeatIDs) == 0 {
+		writeError(w, utils.ErrCodeInvalidRequest, "seat_ids is required", http.StatusBadRequest)
+		return
+	}
+	if len(requestBody.SeatIDs) > maxSeatLookup {
+		writeError(w, utils.ErrCodeInvalidRequest, fmt.Sprintf("at most %d seat_ids may be looked up at once", maxSeatLookup), http.StatusBadRequest)
+		return
+	}
+
+	ownerID, ok := requestOrderOwner(w, r)
+	if !ok {
+		return
+	}
+	userID := auth.UserID(r.Context())
+	h.Logger.Info("API", fmt.Sprintf("LookupSeatOrders: seats=%d userId=%s", len(requestBody.SeatIDs), userID))
+
+	orders, err := h.OrderService.GetOrdersBySeats(r.Context(), requestBody.SeatIDs)
+	if err != nil {
+		h.Logger.Error("API", fmt.Sprintf("LookupSeatOrders: failed: %v", err))
+		writeError(w, utils.ErrCodeInternal, "Failed to look up seats", http.StatusInternalServerError)
+		return
+	}
+
+	response := make(map[string]seatOrderInfo, len(orders))
+	for seatID, o := range orders {
+		if ownerID != "" && o.UserID != ownerID {
+			continue
+		}
+		response[seatID] = seatOrderInfo{
+			OrderID:       o.OrderID,
+			Status:        o.Status,
+			OwnedByCaller: o.UserID == userID,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("LookupSeatOrders: failed to encode response: %v", err))
+	}
+}
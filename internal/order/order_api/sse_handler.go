@@ -7,26 +7,37 @@ import (
 	"ms-ticketing/internal/logger"
 	"ms-ticketing/internal/models"
 	"ms-ticketing/internal/sse"
+	"ms-ticketing/internal/utils"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-redis/redis/v8"
 )
 
+// sseHeartbeatInterval keeps idle streams below the load balancer's 60s idle timeout
+const sseHeartbeatInterval = 30 * time.Second
+
 // SSEHandler manages Server-Sent Events endpoints for checkout events
 type SSEHandler struct {
 	Logger       *logger.Logger
 	EventEmitter *sse.CheckoutEventEmitter
-	RedisClient  *redis.Client
+	RedisClient  redis.UniversalClient
+	HTTPClient   *http.Client // Shared client for ownership checks against the seating service
+	Limiter      *sse.ConnectionLimiter
+	AllowOrigin  func(origin string) bool // Checks WebSocket upgrades against the CORS allowlist; nil allows all
 }
 
 // NewSSEHandler creates a new SSE handler for checkout events
-func NewSSEHandler(logger *logger.Logger, redisClient *redis.Client) *SSEHandler {
+func NewSSEHandler(logger *logger.Logger, redisClient redis.UniversalClient, httpClient *http.Client) *SSEHandler {
 	return &SSEHandler{
 		Logger:       logger,
 		EventEmitter: sse.NewCheckoutEventEmitter(),
 		RedisClient:  redisClient,
+		HTTPClient:   httpClient,
+		Limiter:      sse.NewConnectionLimiterFromEnv(),
 	}
 }
 
@@ -35,15 +46,27 @@ func (h *SSEHandler) HandleOrganizationCheckouts(w http.ResponseWriter, r *http.
 	// Extract organization ID from URL
 	organizationID := chi.URLParam(r, "organizationID")
 	if organizationID == "" {
-		http.Error(w, "Organization ID is required", http.StatusBadRequest)
+		writeError(w, utils.ErrCodeInvalidRequest, "Organization ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Verify ownership/permissions
-	err := h.verifyOrganizationAccess(r, organizationID)
+	userID, err := h.verifyOrganizationAccess(r, organizationID)
 	if err != nil {
 		h.Logger.Error("SSE", fmt.Sprintf("Organization access verification failed: %v", err))
-		http.Error(w, "Unauthorized access", http.StatusUnauthorized)
+		writeError(w, utils.ErrCodeUnauthorized, "Unauthorized access", http.StatusUnauthorized)
+		return
+	}
+
+	release, ok := h.acquireConnection(w, userID, "organization:"+organizationID)
+	if !ok {
+		return
+	}
+	defer release()
+
+	cursor, err := parseReplayCursor(r)
+	if err != nil {
+		writeError(w, utils.ErrCodeInvalidRequest, err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -54,7 +77,7 @@ func (h *SSEHandler) HandleOrganizationCheckouts(w http.ResponseWriter, r *http.
 	ctx := r.Context()
 
 	// Subscribe to events for this organization
-	eventChan := h.EventEmitter.SubscribeToOrganization(ctx, organizationID)
+	eventChan, replay := h.EventEmitter.SubscribeToOrganizationFrom(ctx, organizationID, cursor)
 
 	// Send initial connection established message
 	fmt.Fprintf(w, "event: connected\ndata: {\"status\":\"connected\",\"organizationID\":\"%s\"}\n\n", organizationID)
@@ -62,25 +85,29 @@ func (h *SSEHandler) HandleOrganizationCheckouts(w http.ResponseWriter, r *http.
 
 	h.Logger.Info("SSE", fmt.Sprintf("Client connected to organization checkout events for organization: %s", organizationID))
 
+	// Catch the client up on recent checkouts before streaming live ones
+	for _, ev := range replay {
+		h.writeCheckoutEvent(w, ev)
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
 	// Stream events
 	for {
 		select {
-		case order, ok := <-eventChan:
+		case <-heartbeat.C:
+			// SSE comment lines are ignored by EventSource but keep the connection active
+			fmt.Fprint(w, ": ping\n\n")
+			w.(http.Flusher).Flush()
+
+		case ev, ok := <-eventChan:
 			if !ok {
 				h.Logger.Debug("SSE", fmt.Sprintf("Channel closed for organization: %s", organizationID))
 				return
 			}
 
-			// Serialize the order
-			jsonData, err := json.Marshal(order)
-			if err != nil {
-				h.Logger.Error("SSE", fmt.Sprintf("Failed to serialize checkout event: %v", err))
-				continue
-			}
-
-			// Send the event
-			fmt.Fprintf(w, "event: checkout\ndata: %s\n\n", jsonData)
-			w.(http.Flusher).Flush()
+			h.writeCheckoutEvent(w, ev)
 
 		case <-ctx.Done():
 			h.Logger.Debug("SSE", fmt.Sprintf("Client disconnected from organization checkout events for: %s", organizationID))
@@ -94,15 +121,27 @@ func (h *SSEHandler) HandleEventCheckouts(w http.ResponseWriter, r *http.Request
 	// Extract event ID from URL
 	eventID := chi.URLParam(r, "eventID")
 	if eventID == "" {
-		http.Error(w, "Event ID is required", http.StatusBadRequest)
+		writeError(w, utils.ErrCodeInvalidRequest, "Event ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Verify ownership/permissions for this event
-	err := h.verifyEventAccess(r, eventID)
+	userID, err := h.verifyEventAccess(r, eventID)
 	if err != nil {
 		h.Logger.Error("SSE", fmt.Sprintf("Event access verification failed: %v", err))
-		http.Error(w, "Unauthorized access", http.StatusUnauthorized)
+		writeError(w, utils.ErrCodeUnauthorized, "Unauthorized access", http.StatusUnauthorized)
+		return
+	}
+
+	release, ok := h.acquireConnection(w, userID, "event:"+eventID)
+	if !ok {
+		return
+	}
+	defer release()
+
+	cursor, err := parseReplayCursor(r)
+	if err != nil {
+		writeError(w, utils.ErrCodeInvalidRequest, err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -113,7 +152,7 @@ func (h *SSEHandler) HandleEventCheckouts(w http.ResponseWriter, r *http.Request
 	ctx := r.Context()
 
 	// Subscribe to events for this event
-	eventChan := h.EventEmitter.SubscribeToEvent(ctx, eventID)
+	eventChan, replay := h.EventEmitter.SubscribeToEventFrom(ctx, eventID, cursor)
 
 	// Send initial connection established message
 	fmt.Fprintf(w, "event: connected\ndata: {\"status\":\"connected\",\"eventID\":\"%s\"}\n\n", eventID)
@@ -121,25 +160,29 @@ func (h *SSEHandler) HandleEventCheckouts(w http.ResponseWriter, r *http.Request
 
 	h.Logger.Info("SSE", fmt.Sprintf("Client connected to event checkout events for event: %s", eventID))
 
+	// Catch the client up on recent checkouts before streaming live ones
+	for _, ev := range replay {
+		h.writeCheckoutEvent(w, ev)
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
 	// Stream events
 	for {
 		select {
-		case order, ok := <-eventChan:
+		case <-heartbeat.C:
+			// SSE comment lines are ignored by EventSource but keep the connection active
+			fmt.Fprint(w, ": ping\n\n")
+			w.(http.Flusher).Flush()
+
+		case ev, ok := <-eventChan:
 			if !ok {
 				h.Logger.Debug("SSE", fmt.Sprintf("Channel closed for event: %s", eventID))
 				return
 			}
 
-			// Serialize the order
-			jsonData, err := json.Marshal(order)
-			if err != nil {
-				h.Logger.Error("SSE", fmt.Sprintf("Failed to serialize checkout event: %v", err))
-				continue
-			}
-
-			// Send the event
-			fmt.Fprintf(w, "event: checkout\ndata: %s\n\n", jsonData)
-			w.(http.Flusher).Flush()
+			h.writeCheckoutEvent(w, ev)
 
 		case <-ctx.Done():
 			h.Logger.Debug("SSE", fmt.Sprintf("Client disconnected from event checkout events for: %s", eventID))
@@ -154,6 +197,55 @@ func (h *SSEHandler) EmitCheckoutEvent(order models.OrderWithTickets) {
 	h.EventEmitter.EmitCheckoutEvent(order)
 }
 
+// EmitOrderCancelledEvent broadcasts a cancelled order to all subscribed clients
+// This method should be called after an order is cancelled and its seats released
+func (h *SSEHandler) EmitOrderCancelledEvent(order models.OrderWithTickets, releasedSeatIDs []string) {
+	h.EventEmitter.EmitOrderCancelledEvent(order, releasedSeatIDs)
+}
+
+// writeCheckoutEvent writes a single event frame and flushes it to the client.
+// The id field lets EventSource send Last-Event-ID when it reconnects.
+func (h *SSEHandler) writeCheckoutEvent(w http.ResponseWriter, ev sse.CheckoutEvent) {
+	jsonData, err := json.Marshal(ev.Payload())
+	if err != nil {
+		h.Logger.Error("SSE", fmt.Sprintf("Failed to serialize %s event: %v", ev.Type, err))
+		return
+	}
+
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, jsonData)
+	w.(http.Flusher).Flush()
+}
+
+// parseReplayCursor decides which buffered events a connecting client is sent.
+// A Last-Event-ID header (or lastEventId query parameter for clients that cannot set headers)
+// resumes after that event; otherwise ?since= is read as RFC3339 or unix seconds.
+// Without either, all buffered events are replayed.
+func parseReplayCursor(r *http.Request) (sse.ReplayCursor, error) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	if lastEventID != "" {
+		id, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			return sse.ReplayCursor{}, fmt.Errorf("Last-Event-ID must be a numeric event ID")
+		}
+		return sse.ReplayCursor{AfterID: id}, nil
+	}
+
+	raw := r.URL.Query().Get("since")
+	if raw == "" {
+		return sse.ReplayCursor{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return sse.ReplayCursor{Since: t}, nil
+	}
+	if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return sse.ReplayCursor{Since: time.Unix(seconds, 0)}, nil
+	}
+	return sse.ReplayCursor{}, fmt.Errorf("since must be an RFC3339 timestamp or unix seconds")
+}
+
 // Helper function to set up SSE headers
 func (h *SSEHandler) setupSSEHeaders(w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "text/event-stream;charset=UTF-8")
@@ -167,58 +259,74 @@ func (h *SSEHandler) setupSSEHeaders(w http.ResponseWriter) {
 	w.Header().Set("Referrer-Policy", "no-referrer")
 }
 
+// acquireConnection reserves a stream slot for the user, writing a 429 when a cap is reached.
+// The returned release func must be deferred by the caller.
+func (h *SSEHandler) acquireConnection(w http.ResponseWriter, userID, streamKey string) (func(), bool) {
+	if h.Limiter == nil {
+		return func() {}, true
+	}
+
+	release, err := h.Limiter.Acquire(userID, streamKey)
+	if err != nil {
+		h.Logger.Warn("SSE", fmt.Sprintf("Connection limit reached for user %s on %s", userID, streamKey))
+		writeError(w, utils.ErrCodeRateLimited, err.Error(), http.StatusTooManyRequests)
+		return nil, false
+	}
+	return release, true
+}
+
 // Helper function to verify organization access
-func (h *SSEHandler) verifyOrganizationAccess(r *http.Request, organizationID string) error {
+func (h *SSEHandler) verifyOrganizationAccess(r *http.Request, organizationID string) (string, error) {
 	// Extract JWT token
 	token, err := auth.ExtractTokenFromRequest(r)
 	if err != nil {
-		return fmt.Errorf("failed to extract token: %w", err)
+		return "", fmt.Errorf("failed to extract token: %w", err)
 	}
 
 	// Get user ID from token
 	userID, err := auth.ExtractUserIDFromJWT(token)
 	if err != nil {
-		return fmt.Errorf("failed to extract user ID: %w", err)
+		return "", fmt.Errorf("failed to extract user ID: %w", err)
 	}
 
 	// Use our standard verification method
 	isMember, err := h.verifyOrganizationOwnership(organizationID, userID)
 	if err != nil {
-		return fmt.Errorf("failed to verify organization ownership: %w", err)
+		return "", fmt.Errorf("failed to verify organization ownership: %w", err)
 	}
 
 	if !isMember {
-		return fmt.Errorf("user %s is not a member of organization %s", userID, organizationID)
+		return "", fmt.Errorf("user %s is not a member of organization %s", userID, organizationID)
 	}
 
-	return nil
+	return userID, nil
 }
 
 // Helper function to verify event access
-func (h *SSEHandler) verifyEventAccess(r *http.Request, eventID string) error {
+func (h *SSEHandler) verifyEventAccess(r *http.Request, eventID string) (string, error) {
 	// Extract JWT token
 	token, err := auth.ExtractTokenFromRequest(r)
 	if err != nil {
-		return fmt.Errorf("failed to extract token: %w", err)
+		return "", fmt.Errorf("failed to extract token: %w", err)
 	}
 
 	// Get user ID from token
 	userID, err := auth.ExtractUserIDFromJWT(token)
 	if err != nil {
-		return fmt.Errorf("failed to extract user ID: %w", err)
+		return "", fmt.Errorf("failed to extract user ID: %w", err)
 	}
 
 	// Use our standard verification method
 	isOwner, err := h.verifyEventOwnership(eventID, userID)
 	if err != nil {
-		return fmt.Errorf("failed to verify event ownership: %w", err)
+		return "", fmt.Errorf("failed to verify event ownership: %w", err)
 	}
 
 	if !isOwner {
-		return fmt.Errorf("user %s does not have access to event %s", userID, eventID)
+		return "", fmt.Errorf("user %s does not have access to event %s", userID, eventID)
 	}
 
-	return nil
+	return userID, nil
 }
 
 // Helper function to get config from environment variables
@@ -0,0 +1,36 @@
+package order_api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/auth"
+	"ms-ticketing/internal/order"
+	"ms-ticketing/internal/utils"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetOrderStatusHistory lists an order's status transitions with who made them, oldest first,
+// so support can follow an order through payment, webhook and checkout
+func (h *Handler) GetOrderStatusHistory(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderId")
+	h.Logger.Info("API", fmt.Sprintf("GetOrderStatusHistory: orderId=%s by userId=%s", orderID, auth.UserID(r.Context())))
+
+	changes, err := h.OrderService.GetOrderStatusHistory(r.Context(), orderID)
+	switch {
+	case errors.Is(err, order.ErrOrderNotFound):
+		writeError(w, utils.ErrCodeNotFound, "Order not found", http.StatusNotFound)
+		return
+	case err != nil:
+		h.Logger.Error("API", fmt.Sprintf("GetOrderStatusHistory: failed: %v", err))
+		writeError(w, utils.ErrCodeInternal, "Failed to load order history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"order_id": orderID, "history": changes}); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("GetOrderStatusHistory: failed to encode response: %v", err))
+	}
+}
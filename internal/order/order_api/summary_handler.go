@@ -0,0 +1,85 @@
+package order_api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/auth"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	"ms-ticketing/internal/utils"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetOrderSummary returns an order with the live status of its Stripe payment, so support doesn't
+// have to cross-reference the order, its payment and Stripe. Buyers may view their own orders;
+// support and admins may view any.
+func (h *Handler) GetOrderSummary(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderId")
+	userID := auth.UserID(r.Context())
+	h.Logger.Info("API", fmt.Sprintf("GetOrderSummary: orderId=%s by userId=%s", orderID, userID))
+
+	ownerID, ok := requestOrderOwner(w, r)
+	if !ok {
+		return
+	}
+
+	summary, err := h.OrderService.GetOrderSummary(r.Context(), orderID, ownerID)
+	switch {
+	case errors.Is(err, order.ErrOrderNotFound):
+		writeError(w, utils.ErrCodeNotFound, "Order not found", http.StatusNotFound)
+		return
+	case errors.Is(err, order.ErrNotOrderOwner):
+		writeError(w, utils.ErrCodeNotOrderOwner, err.Error(), http.StatusForbidden)
+		return
+	case err != nil:
+		h.Logger.Error("API", fmt.Sprintf("GetOrderSummary: failed: %v", err))
+		writeError(w, utils.ErrCodeInternal, "Failed to load order summary", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		h.Logger.Error("API", fmt.Sprintf("GetOrderSummary: failed to encode response: %v", err))
+	}
+}
+
+// requestOrderOwner returns the user whose orders the caller may access, or "" for support and admins
+// who may access any. It writes a 401 and returns false when the request carries no user.
+func requestOrderOwner(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID := auth.UserID(r.Context())
+	if userID == "" {
+		writeError(w, utils.ErrCodeUnauthorized, "Unauthorized access", http.StatusUnauthorized)
+		return "", false
+	}
+	if auth.HasRole(r.Context(), auth.SupportRole()) || auth.HasRole(r.Context(), auth.AdminRole()) {
+		return "", true
+	}
+	return userID, true
+}
+
+// loadOwnedOrder loads the order when the caller owns it or is staff, otherwise it writes the
+// 401/403/404 response and returns nil
+func (h *Handler) loadOwnedOrder(w http.ResponseWriter, r *http.Request, orderID, endpoint string) *models.Order {
+	ownerID, ok := requestOrderOwner(w, r)
+	if !ok {
+		return nil
+	}
+
+	o, err := h.OrderService.GetOwnedOrder(r.Context(), orderID, ownerID)
+	switch {
+	case errors.Is(err, order.ErrOrderNotFound):
+		writeError(w, utils.ErrCodeNotFound, "Order not found", http.StatusNotFound)
+		return nil
+	case errors.Is(err, order.ErrNotOrderOwner):
+		writeError(w, utils.ErrCodeNotOrderOwner, err.Error(), http.StatusForbidden)
+		return nil
+	case err != nil:
+		h.Logger.Error("API", fmt.Sprintf("%s: failed to load order %s: %v", endpoint, orderID, err))
+		writeError(w, utils.ErrCodeInternal, "Failed to load order", http.StatusInternalServerError)
+		return nil
+	}
+	return o
+}
@@ -4,29 +4,36 @@ import (
 	"encoding/json"
 	"fmt"
 	"ms-ticketing/internal/auth"
+	"ms-ticketing/internal/logger"
 	"net/http"
 	"os"
-	"time"
+
+	"github.com/go-redis/redis/v8"
 )
 
 // verifyOrganizationOwnership checks if the user is a member of the organization
 func (h *SSEHandler) verifyOrganizationOwnership(organizationID string, userID string) (bool, error) {
-	h.Logger.Debug("SSE", fmt.Sprintf("Verifying ownership for organization %s by user %s", organizationID, userID))
+	return verifyOrganizationMembership(h.Logger, h.RedisClient, h.HTTPClient, organizationID, userID)
+}
+
+// verifyOrganizationMembership asks the event seating service whether the user belongs to the organization
+func verifyOrganizationMembership(log *logger.Logger, redisClient redis.UniversalClient, client *http.Client, organizationID string, userID string) (bool, error) {
+	log.Debug("AUTH", fmt.Sprintf("Verifying ownership for organization %s by user %s", organizationID, userID))
 
 	// Get the M2M token
 	config := getConfigFromEnv()
 
 	// Use the Redis client if available
-	token, err := auth.GetM2MToken(config, &http.Client{Timeout: 10 * time.Second}, h.RedisClient, h.Logger)
+	token, err := auth.GetM2MToken(config, client, redisClient, log)
 	if err != nil {
-		h.Logger.Error("AUTH", fmt.Sprintf("Failed to get M2M token: %v", err))
+		log.Error("AUTH", fmt.Sprintf("Failed to get M2M token: %v", err))
 		return false, err
 	}
 
 	// Create and execute HTTP request to verify organization ownership
 	seatingServiceURL := os.Getenv("EVENT_SEATING_SERVICE_URL")
 	if seatingServiceURL == "" {
-		h.Logger.Error("CONFIG", "EVENT_SEATING_SERVICE_URL environment variable not set")
+		log.Error("CONFIG", "EVENT_SEATING_SERVICE_URL environment variable not set")
 		return false, fmt.Errorf("EVENT_SEATING_SERVICE_URL not set")
 	}
 
@@ -35,27 +42,24 @@ func (h *SSEHandler) verifyOrganizationOwnership(organizationID string, userID s
 
 	req, err := http.NewRequest("GET", requestURL, nil)
 	if err != nil {
-		h.Logger.Error("HTTP", fmt.Sprintf("Failed to create organization ownership verification request: %v", err))
+		log.Error("HTTP", fmt.Sprintf("Failed to create organization ownership verification request: %v", err))
 		return false, err
 	}
 
 	// Add token to the request
 	req.Header.Add("Authorization", "Bearer "+token)
 
-	// Create a client for the request
-	client := &http.Client{Timeout: 10 * time.Second}
-
 	// Execute the request
 	resp, err := client.Do(req)
 	if err != nil {
-		h.Logger.Error("HTTP", fmt.Sprintf("Failed to execute organization ownership verification request: %v", err))
+		log.Error("HTTP", fmt.Sprintf("Failed to execute organization ownership verification request: %v", err))
 		return false, err
 	}
 	defer resp.Body.Close()
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
-		h.Logger.Error("HTTP", fmt.Sprintf("Organization ownership verification failed with status: %s", resp.Status))
+		log.Error("HTTP", fmt.Sprintf("Organization ownership verification failed with status: %s", resp.Status))
 		return false, fmt.Errorf("organization ownership verification failed with status: %s", resp.Status)
 	}
 
@@ -63,11 +67,11 @@ func (h *SSEHandler) verifyOrganizationOwnership(organizationID string, userID s
 	var isMember bool
 	err = json.NewDecoder(resp.Body).Decode(&isMember)
 	if err != nil {
-		h.Logger.Error("HTTP", fmt.Sprintf("Failed to parse organization ownership verification response: %v", err))
+		log.Error("HTTP", fmt.Sprintf("Failed to parse organization ownership verification response: %v", err))
 		return false, err
 	}
 
-	h.Logger.Debug("SSE", fmt.Sprintf("User %s membership of organization %s: %v", userID, organizationID, isMember))
+	log.Debug("AUTH", fmt.Sprintf("User %s membership of organization %s: %v", userID, organizationID, isMember))
 	return isMember, nil
 }
 
@@ -79,8 +83,7 @@ func (h *SSEHandler) verifyEventOwnership(eventID string, userID string) (bool,
 	config := getConfigFromEnv()
 
 	// Use the Redis client if available
-	client := &http.Client{Timeout: 10 * time.Second}
-	token, err := auth.GetM2MToken(config, client, h.RedisClient, h.Logger)
+	token, err := auth.GetM2MToken(config, h.HTTPClient, h.RedisClient, h.Logger)
 	if err != nil {
 		h.Logger.Error("AUTH", fmt.Sprintf("Failed to get M2M token: %v", err))
 		return false, err
@@ -106,7 +109,7 @@ func (h *SSEHandler) verifyEventOwnership(eventID string, userID string) (bool,
 	req.Header.Add("Authorization", "Bearer "+token)
 
 	// Execute the request
-	resp, err := client.Do(req)
+	resp, err := h.HTTPClient.Do(req)
 	if err != nil {
 		h.Logger.Error("HTTP", fmt.Sprintf("Failed to execute ownership verification request: %v", err))
 		return false, err
@@ -0,0 +1,143 @@
+package order_api
+
+import (
+	"context"
+	"fmt"
+	"ms-ticketing/internal/sse"
+	"ms-ticketing/internal/utils"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/net/websocket"
+)
+
+// wsMessage is the frame sent to WebSocket clients; ID and Event mirror the SSE "id:" and "event:" fields
+type wsMessage struct {
+	ID    uint64      `json:"id,omitempty"`
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"`
+}
+
+// HandleOrganizationCheckoutsWS streams checkout events for an organization over a WebSocket.
+// It is the WebSocket twin of HandleOrganizationCheckouts for clients behind buffering proxies.
+func (h *SSEHandler) HandleOrganizationCheckoutsWS(w http.ResponseWriter, r *http.Request) {
+	organizationID := chi.URLParam(r, "organizationID")
+	if organizationID == "" {
+		writeError(w, utils.ErrCodeInvalidRequest, "Organization ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Verify before upgrading so unauthorized clients get a plain HTTP error
+	userID, err := h.verifyOrganizationAccess(r, organizationID)
+	if err != nil {
+		h.Logger.Error("WS", fmt.Sprintf("Organization access verification failed: %v", err))
+		writeError(w, utils.ErrCodeUnauthorized, "Unauthorized access", http.StatusUnauthorized)
+		return
+	}
+
+	release, ok := h.acquireConnection(w, userID, "organization:"+organizationID)
+	if !ok {
+		return
+	}
+	defer release()
+
+	h.serveWebSocket(w, r, "organizationID", organizationID, h.EventEmitter.SubscribeToOrganizationFrom)
+}
+
+// HandleEventCheckoutsWS streams checkout events for an event over a WebSocket
+func (h *SSEHandler) HandleEventCheckoutsWS(w http.ResponseWriter, r *http.Request) {
+	eventID := chi.URLParam(r, "eventID")
+	if eventID == "" {
+		writeError(w, utils.ErrCodeInvalidRequest, "Event ID is required", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := h.verifyEventAccess(r, eventID)
+	if err != nil {
+		h.Logger.Error("WS", fmt.Sprintf("Event access verification failed: %v", err))
+		writeError(w, utils.ErrCodeUnauthorized, "Unauthorized access", http.StatusUnauthorized)
+		return
+	}
+
+	release, ok := h.acquireConnection(w, userID, "event:"+eventID)
+	if !ok {
+		return
+	}
+	defer release()
+
+	h.serveWebSocket(w, r, "eventID", eventID, h.EventEmitter.SubscribeToEventFrom)
+}
+
+// serveWebSocket upgrades the connection and forwards events from the subscription until either side closes
+func (h *SSEHandler) serveWebSocket(w http.ResponseWriter, r *http.Request, idField, id string,
+	subscribe func(ctx context.Context, id string, cursor sse.ReplayCursor) (chan sse.CheckoutEvent, []sse.CheckoutEvent)) {
+	cursor, err := parseReplayCursor(r)
+	if err != nil {
+		writeError(w, utils.ErrCodeInvalidRequest, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// websocket.Server without a Handshake skips the Origin check and browsers don't apply CORS to
+	// WebSockets, so the upgrade is refused here for browser origins outside the CORS allowlist
+	if origin := r.Header.Get("Origin"); origin != "" && h.AllowOrigin != nil && !h.AllowOrigin(origin) {
+		h.Logger.Warn("WS", fmt.Sprintf("Rejected WebSocket upgrade from origin %s", origin))
+		writeError(w, utils.ErrCodeForbidden, "Origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	server := websocket.Server{Handler: func(conn *websocket.Conn) {
+		defer conn.Close()
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		// Clients never send data, but reading is how a closed socket gets noticed
+		go func() {
+			defer cancel()
+			var discard string
+			for {
+				if err := websocket.Message.Receive(conn, &discard); err != nil {
+					return
+				}
+			}
+		}()
+
+		eventChan, replay := subscribe(ctx, id, cursor)
+
+		connected := wsMessage{Event: "connected", Data: map[string]string{"status": "connected", idField: id}}
+		if err := websocket.JSON.Send(conn, connected); err != nil {
+			h.Logger.Error("WS", fmt.Sprintf("Failed to send connected message: %v", err))
+			return
+		}
+
+		h.Logger.Info("WS", fmt.Sprintf("Client connected to checkout events for %s: %s", idField, id))
+
+		for _, ev := range replay {
+			if err := websocket.JSON.Send(conn, wsMessage{ID: ev.ID, Event: ev.Type, Data: ev.Payload()}); err != nil {
+				h.Logger.Error("WS", fmt.Sprintf("Failed to replay %s event: %v", ev.Type, err))
+				return
+			}
+		}
+
+		for {
+			select {
+			case ev, ok := <-eventChan:
+				if !ok {
+					h.Logger.Debug("WS", fmt.Sprintf("Channel closed for %s: %s", idField, id))
+					return
+				}
+
+				if err := websocket.JSON.Send(conn, wsMessage{ID: ev.ID, Event: ev.Type, Data: ev.Payload()}); err != nil {
+					h.Logger.Error("WS", fmt.Sprintf("Failed to send %s event: %v", ev.Type, err))
+					return
+				}
+
+			case <-ctx.Done():
+				h.Logger.Debug("WS", fmt.Sprintf("Client disconnected from checkout events for %s: %s", idField, id))
+				return
+			}
+		}
+	}}
+
+	server.ServeHTTP(w, r)
+}
@@ -0,0 +1,77 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"ms-ticketing/internal/models"
+)
+
+const (
+	// DefaultOrderNumberPrefix starts every order number unless ORDER_NUMBER_PREFIX is set
+	DefaultOrderNumberPrefix = "EVT"
+	// DefaultOrderNumberDigits pads the daily sequence, e.g. 000123
+	DefaultOrderNumberDigits = 6
+	// orderNumberDayLayout is the date part of an order number; days are counted in UTC
+	orderNumberDayLayout = "20060102"
+)
+
+var ErrInvalidOrderNumber = errors.New("invalid order number")
+
+var orderNumberPrefixPattern = regexp.MustCompile(`^[A-Z0-9]{1,10}$`)
+
+// OrderNumberPrefix returns ORDER_NUMBER_PREFIX in upper case, or the default when it is unset or
+// not 1-10 letters and digits
+func OrderNumberPrefix() string {
+	prefix := strings.ToUpper(strings.TrimSpace(os.Getenv("ORDER_NUMBER_PREFIX")))
+	if !orderNumberPrefixPattern.MatchString(prefix) {
+		return DefaultOrderNumberPrefix
+	}
+	return prefix
+}
+
+// OrderNumberDigits returns the zero padding of the daily sequence from ORDER_NUMBER_DIGITS or the default
+func OrderNumberDigits() int {
+	digits, err := strconv.Atoi(os.Getenv("ORDER_NUMBER_DIGITS"))
+	if err != nil || digits < 1 || digits > 12 {
+		return DefaultOrderNumberDigits
+	}
+	return digits
+}
+
+// FormatOrderNumber builds a human-readable order number such as EVT-20240617-000123.
+// Sequences beyond the padding simply grow longer.
+func FormatOrderNumber(prefix string, day time.Time, sequence int64, digits int) string {
+	return fmt.Sprintf("%s-%s-%0*d", prefix, day.UTC().Format(orderNumberDayLayout), digits, sequence)
+}
+
+// NormalizeOrderNumber tidies an order number read out by a customer: surrounding spaces are
+// dropped and letters are upper-cased
+func NormalizeOrderNumber(number string) string {
+	return strings.ToUpper(strings.TrimSpace(number))
+}
+
+// nextOrderNumber allocates the next order number of the current day
+func (s *OrderService) nextOrderNumber(ctx context.Context, now time.Time) (string, error) {
+	sequence, err := s.DB.NextOrderNumber(ctx, now.UTC().Format(orderNumberDayLayout))
+	if err != nil {
+		return "", fmt.Errorf("failed to allocate order number: %w", err)
+	}
+	return FormatOrderNumber(OrderNumberPrefix(), now, sequence, OrderNumberDigits()), nil
+}
+
+// GetOrderByNumber resolves a human-readable order number to its order
+func (s *OrderService) GetOrderByNumber(ctx context.Context, number string) (*models.Order, error) {
+	number = NormalizeOrderNumber(number)
+	if number == "" {
+		return nil, fmt.Errorf("%w: number is required", ErrInvalidOrderNumber)
+	}
+	s.logger.Debug("ORDER", fmt.Sprintf("Getting order by number: %s", number))
+	return s.DB.GetOrderByNumber(ctx, number)
+}
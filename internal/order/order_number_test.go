@@ -0,0 +1,67 @@
+package order_test
+
+import (
+	"context"
+	"fmt"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatOrderNumber(t *testing.T) {
+	day := time.Date(2024, 6, 17, 23, 30, 0, 0, time.UTC)
+	assert.Equal(t, "EVT-20240617-000123", order.FormatOrderNumber("EVT", day, 123, 6))
+	assert.Equal(t, "EVT-20240617-1234567", order.FormatOrderNumber("EVT", day, 1234567, 6))
+	// Days are counted in UTC
+	colombo := time.FixedZone("+0530", 5*60*60+30*60)
+	assert.Equal(t, "EVT-20240617-01", order.FormatOrderNumber("EVT", day.In(colombo), 1, 2))
+}
+
+func TestOrderNumberPrefix(t *testing.T) {
+	t.Setenv("ORDER_NUMBER_PREFIX", "tix")
+	assert.Equal(t, "TIX", order.OrderNumberPrefix())
+	t.Setenv("ORDER_NUMBER_PREFIX", "no-dashes")
+	assert.Equal(t, order.DefaultOrderNumberPrefix, order.OrderNumberPrefix())
+}
+
+func TestPlacedOrdersGetSequentialOrderNumbers(t *testing.T) {
+	t.Setenv("ORDER_NUMBER_PREFIX", "TIX")
+	seats, seatIDs := seatsForLimitTest(2)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	ctx := context.Background()
+	sessionID := uuid.NewString()
+	today := time.Now().UTC().Format("20060102")
+
+	var responses []*models.OrderResponse
+	for _, seatID := range seatIDs {
+		resp, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+			SessionID: sessionID,
+			EventID:   "event-1",
+			SeatIDs:   []string{seatID},
+		})
+		require.NoError(t, err)
+		responses = append(responses, resp)
+	}
+	assert.Equal(t, fmt.Sprintf("TIX-%s-000001", today), responses[0].OrderNumber)
+	assert.Equal(t, fmt.Sprintf("TIX-%s-000002", today), responses[1].OrderNumber)
+
+	// The UUID stays the key and the number is stored next to it
+	stored, err := env.OrderService.GetOrder(ctx, responses[1].OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, responses[1].OrderNumber, stored.OrderNumber)
+
+	// Numbers read out over the phone resolve regardless of case and stray spaces
+	found, err := env.OrderService.GetOrderByNumber(ctx, fmt.Sprintf("  tix-%s-000002 ", today))
+	require.NoError(t, err)
+	assert.Equal(t, responses[1].OrderID, found.OrderID)
+
+	_, err = env.OrderService.GetOrderByNumber(ctx, "TIX-19700101-000001")
+	assert.Error(t, err)
+	_, err = env.OrderService.GetOrderByNumber(ctx, " ")
+	assert.ErrorIs(t, err, order.ErrInvalidOrderNumber)
+}
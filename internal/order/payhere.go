@@ -0,0 +1,185 @@
+package order
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"ms-ticketing/internal/models"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PayHere hosted checkout endpoints
+const (
+	PayHereSandboxCheckoutURL = "https://sandbox.payhere.lk/pay/checkout"
+	PayHereLiveCheckoutURL    = "https://www.payhere.lk/pay/checkout"
+)
+
+// PayHere status_code values sent to the notify URL
+const (
+	payHereStatusSuccess     = "2"
+	payHereStatusPending     = "0"
+	payHereStatusCanceled    = "-1"
+	payHereStatusFailed      = "-2"
+	payHereStatusChargedBack = "-3"
+)
+
+// PayHereProvider takes payments through PayHere's hosted checkout. The buyer's browser posts the
+// checkout fields to PayHere, which reports the outcome to NotifyURL; the payment ID is only known then.
+type PayHereProvider struct {
+	MerchantID     string
+	MerchantSecret string
+	NotifyURL      string
+	ReturnURL      string
+	CancelURL      string
+	Sandbox        bool
+}
+
+// NewPayHereProviderFromEnv configures PayHere from PAYHERE_* variables. It reports false when
+// PAYHERE_MERCHANT_ID or PAYHERE_MERCHANT_SECRET is unset, leaving PayHere disabled.
+// PAYHERE_SANDBOX defaults to true so the pilot can't take live payments by accident.
+func NewPayHereProviderFromEnv() (*PayHereProvider, bool) {
+	provider := &PayHereProvider{
+		MerchantID:     os.Getenv("PAYHERE_MERCHANT_ID"),
+		MerchantSecret: os.Getenv("PAYHERE_MERCHANT_SECRET"),
+		NotifyURL:      os.Getenv("PAYHERE_NOTIFY_URL"),
+		ReturnURL:      os.Getenv("PAYHERE_RETURN_URL"),
+		CancelURL:      os.Getenv("PAYHERE_CANCEL_URL"),
+		Sandbox:        true,
+	}
+	if sandbox, err := strconv.ParseBool(os.Getenv("PAYHERE_SANDBOX")); err == nil {
+		provider.Sandbox = sandbox
+	}
+	if provider.MerchantID == "" || provider.MerchantSecret == "" {
+		return nil, false
+	}
+	return provider, true
+}
+
+func (p *PayHereProvider) Name() string {
+	return PaymentProviderPayHere
+}
+
+// CreatePayment signs the checkout fields for the order. PayHere has no server-side payment
+// object before checkout, so no payment ID is returned.
+func (p *PayHereProvider) CreatePayment(ctx context.Context, order *models.Order) (*ProviderPayment, error) {
+	amount := order.Price.String()
+	currency := order.CurrencyCode()
+	items := "Order " + order.OrderID
+	if order.OrderNumber != "" {
+		items = "Order " + order.OrderNumber
+	}
+	checkoutURL := PayHereLiveCheckoutURL
+	if p.Sandbox {
+		checkoutURL = PayHereSandboxCheckoutURL
+	}
+
+	return &ProviderPayment{
+		Provider:    PaymentProviderPayHere,
+		OrderID:     order.OrderID,
+		Status:      PaymentStatusRequiresPayment,
+		CheckoutURL: checkoutURL,
+		Fields: map[string]string{
+			"merchant_id": p.MerchantID,
+			"order_id":    order.OrderID,
+			"items":       items,
+			"amount":      amount,
+			"currency":    currency,
+			"notify_url":  p.NotifyURL,
+			"return_url":  p.ReturnURL,
+			"cancel_url":  p.CancelURL,
+			"hash":        p.sign(order.OrderID, amount, currency),
+		},
+	}, nil
+}
+
+// CancelPayment is a no-op: an unfinished PayHere checkout simply expires
+func (p *PayHereProvider) CancelPayment(paymentID string, reason string) error {
+	return nil
+}
+
+func (p *PayHereProvider) MapStatus(status string) string {
+	switch status {
+	case payHereStatusSuccess:
+		return PaymentStatusSucceeded
+	case payHereStatusPending:
+		return PaymentStatusProcessing
+	case payHereStatusCanceled:
+		return PaymentStatusCanceled
+	case payHereStatusFailed, payHereStatusChargedBack:
+		return PaymentStatusFailed
+	default:
+		return PaymentStatusRequiresPayment
+	}
+}
+
+// VerifyWebhook checks the md5sig of a PayHere notification, a form post of the payment's outcome
+func (p *PayHereProvider) VerifyWebhook(r *http.Request, payload []byte) (*PaymentUpdate, error) {
+	form, err := url.ParseQuery(string(payload))
+	if err != nil {
+		return nil, &WebhookError{
+			Category:      "validation",
+			StatusCode:    http.StatusBadRequest,
+			PublicError:   "Invalid webhook payload",
+			InternalError: fmt.Sprintf("Failed to parse PayHere notification: %v", err),
+			OriginalErr:   err,
+		}
+	}
+
+	merchantID := form.Get("merchant_id")
+	orderID := form.Get("order_id")
+	amount := form.Get("payhere_amount")
+	currency := form.Get("payhere_currency")
+	statusCode := form.Get("status_code")
+
+	expected := p.sign(orderID, amount, currency+statusCode)
+	if merchantID != p.MerchantID || subtle.ConstantTimeCompare([]byte(strings.ToUpper(form.Get("md5sig"))), []byte(expected)) != 1 {
+		return nil, &WebhookError{
+			Category:      "validation",
+			StatusCode:    http.StatusBadRequest,
+			PublicError:   "Webhook signature verification failed",
+			InternalError: fmt.Sprintf("PayHere notification for order %q has an invalid md5sig", orderID),
+		}
+	}
+	if orderID == "" {
+		return nil, &WebhookError{
+			Category:      "processing",
+			StatusCode:    http.StatusBadRequest,
+			PublicError:   "Invalid payment data",
+			InternalError: "PayHere notification has no order_id",
+		}
+	}
+
+	paid, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return nil, &WebhookError{
+			Category:      "processing",
+			StatusCode:    http.StatusBadRequest,
+			PublicError:   "Invalid payment data",
+			InternalError: fmt.Sprintf("PayHere notification for order %s has invalid amount %q", orderID, amount),
+			OriginalErr:   err,
+		}
+	}
+
+	return &PaymentUpdate{
+		OrderID:        orderID,
+		PaymentID:      form.Get("payment_id"),
+		Status:         p.MapStatus(statusCode),
+		ProviderStatus: statusCode,
+		Amount:         models.MoneyFromFloat(paid).MinorUnits(),
+		Currency:       models.NormalizeCurrency(currency),
+	}, nil
+}
+
+// sign computes PayHere's hash: the uppercase MD5 of the merchant ID, order ID, amount and the given
+// suffix (the currency, plus the status code for notifications) followed by the uppercase MD5 of the secret
+func (p *PayHereProvider) sign(orderID, amount, suffix string) string {
+	secretHash := md5.Sum([]byte(p.MerchantSecret))
+	sum := md5.Sum([]byte(p.MerchantID + orderID + amount + suffix + strings.ToUpper(hex.EncodeToString(secretHash[:]))))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
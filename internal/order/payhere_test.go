@@ -0,0 +1,112 @@
+package order_test
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func payHereHash(parts ...string) string {
+	secret := md5.Sum([]byte("payhere-secret"))
+	sum := md5.Sum([]byte(strings.Join(parts, "") + strings.ToUpper(hex.EncodeToString(secret[:]))))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func payHereNotification(orderID, paymentID, amount, statusCode string) *http.Request {
+	form := url.Values{
+		"merchant_id":      {"1211149"},
+		"order_id":         {orderID},
+		"payment_id":       {paymentID},
+		"payhere_amount":   {amount},
+		"payhere_currency": {"LKR"},
+		"status_code":      {statusCode},
+		"md5sig":           {payHereHash("1211149", orderID, amount, "LKR", statusCode)},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/order/webhook/payhere", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestPayHerePaymentCompletesOrder(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(3)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	env.OrderService.RegisterPaymentProvider(&order.PayHereProvider{MerchantID: "1211149", MerchantSecret: "payhere-secret", Sandbox: true})
+	ctx := context.Background()
+
+	place := func(seatID string) *models.Order {
+		resp, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+			SessionID: uuid.NewString(), EventID: "event-1", SeatIDs: []string{seatID},
+		})
+		require.NoError(t, err)
+		placed, err := env.OrderService.GetOrder(ctx, resp.OrderID)
+		require.NoError(t, err)
+		return placed
+	}
+	paid, failed, tampered := place(seatIDs[0]), place(seatIDs[1]), place(seatIDs[2])
+	amount := paid.Price.String()
+
+	checkout, err := env.OrderService.CreateProviderPayment(ctx, paid.OrderID, order.PaymentProviderPayHere)
+	require.NoError(t, err)
+	assert.Equal(t, order.PayHereSandboxCheckoutURL, checkout.CheckoutURL)
+	assert.Equal(t, amount, checkout.Fields["amount"])
+	assert.Equal(t, payHereHash("1211149", paid.OrderID, amount, "LKR"), checkout.Fields["hash"])
+
+	// A forged notification is refused
+	forged := payHereNotification(paid.OrderID, "320025", amount, "2")
+	forged.Body = http.NoBody
+	var webhookErr *order.WebhookError
+	require.ErrorAs(t, env.OrderService.HandlePaymentWebhook(forged, order.PaymentProviderPayHere), &webhookErr)
+	assert.Equal(t, http.StatusBadRequest, webhookErr.StatusCode)
+
+	require.NoError(t, env.OrderService.HandlePaymentWebhook(payHereNotification(paid.OrderID, "320025", amount, "2"), order.PaymentProviderPayHere))
+	completed, err := env.OrderService.GetOrder(ctx, paid.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", completed.Status)
+	assert.Equal(t, order.PaymentProviderPayHere, completed.PaymentProvider)
+	assert.Equal(t, "320025", completed.PaymentIntentID)
+
+	// Redelivery and a later chargeback notice leave the completed order alone
+	require.NoError(t, env.OrderService.HandlePaymentWebhook(payHereNotification(paid.OrderID, "320025", amount, "2"), order.PaymentProviderPayHere))
+	require.NoError(t, env.OrderService.HandlePaymentWebhook(payHereNotification(paid.OrderID, "320025", amount, "-3"), order.PaymentProviderPayHere))
+
+	// A failed payment cancels the order
+	_, err = env.OrderService.CreateProviderPayment(ctx, failed.OrderID, order.PaymentProviderPayHere)
+	require.NoError(t, err)
+	require.NoError(t, env.OrderService.HandlePaymentWebhook(payHereNotification(failed.OrderID, "320026", amount, "-2"), order.PaymentProviderPayHere))
+	cancelled, err := env.OrderService.GetOrder(ctx, failed.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", cancelled.Status)
+
+	// A correctly signed notification for less than the order costs does not complete it
+	err = env.OrderService.HandlePaymentWebhook(payHereNotification(tampered.OrderID, "320027", "1.00", "2"), order.PaymentProviderPayHere)
+	require.ErrorAs(t, err, &webhookErr)
+	assert.ErrorIs(t, webhookErr.OriginalErr, order.ErrPaymentAmountMismatch)
+	// Nor does one reporting no amount at all
+	err = env.OrderService.HandlePaymentWebhook(payHereNotification(tampered.OrderID, "320028", "0.00", "2"), order.PaymentProviderPayHere)
+	require.ErrorAs(t, err, &webhookErr)
+	assert.ErrorIs(t, webhookErr.OriginalErr, order.ErrPaymentAmountMismatch)
+	pending, err := env.OrderService.GetOrder(ctx, tampered.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", pending.Status)
+}
+
+func TestPayHereDisabledUntilRegistered(t *testing.T) {
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{})
+	_, err := env.OrderService.CreateProviderPayment(context.Background(), "order-1", order.PaymentProviderPayHere)
+	assert.ErrorIs(t, err, order.ErrUnknownPaymentProvider)
+
+	t.Setenv("PAYHERE_MERCHANT_ID", "")
+	_, ok := order.NewPayHereProviderFromEnv()
+	assert.False(t, ok)
+}
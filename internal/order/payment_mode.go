@@ -0,0 +1,90 @@
+package order
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v74"
+)
+
+// Payment modes selectable through PAYMENT_MODE
+const (
+	PaymentModeStripe = "stripe"
+	PaymentModeMock   = "mock"
+)
+
+// mockIntentPrefix marks payment intents minted locally in mock mode; Stripe never issues IDs like this
+const mockIntentPrefix = "pi_mock_"
+
+// mockPaymentEnvironments are the APP_ENV values mock payments may run under
+var mockPaymentEnvironments = []string{"development", "local", "test", "qa", "staging"}
+
+// ErrMockPaymentsInProduction is returned when PAYMENT_MODE=mock is combined with production configuration
+var ErrMockPaymentsInProduction = errors.New("PAYMENT_MODE=mock requires APP_ENV set to a non-production environment (" +
+	strings.Join(mockPaymentEnvironments, ", ") + ") and no live Stripe key")
+
+// ValidatePaymentMode rejects configurations where mock payments could reach production.
+// main calls it at startup so a misconfigured deployment refuses to boot instead of giving away orders.
+func ValidatePaymentMode() error {
+	if !strings.EqualFold(os.Getenv("PAYMENT_MODE"), PaymentModeMock) {
+		return nil
+	}
+	if !mockPaymentsAllowed() {
+		return ErrMockPaymentsInProduction
+	}
+	return nil
+}
+
+// MockPaymentsEnabled reports whether payment intents are synthesized locally instead of created in Stripe.
+// It re-checks the production guard on every call so mock mode can't be switched on at runtime either.
+func MockPaymentsEnabled() bool {
+	return strings.EqualFold(os.Getenv("PAYMENT_MODE"), PaymentModeMock) && mockPaymentsAllowed()
+}
+
+// mockPaymentsAllowed requires APP_ENV to name a non-production environment and no live Stripe key.
+// An unset or unknown APP_ENV counts as production, so mock mode never runs by omission.
+func mockPaymentsAllowed() bool {
+	appEnv := strings.ToLower(strings.TrimSpace(os.Getenv("APP_ENV")))
+	if !slices.Contains(mockPaymentEnvironments, appEnv) {
+		return false
+	}
+	key := os.Getenv("STRIPE_SECRET_KEY")
+	return !strings.HasPrefix(key, "sk_live_") && !strings.HasPrefix(key, "rk_live_")
+}
+
+// IsMockPaymentIntent reports whether the intent ID was minted by mock mode
+func IsMockPaymentIntent(id string) bool {
+	return strings.HasPrefix(id, mockIntentPrefix)
+}
+
+// newMockPaymentIntent builds a synthetic intent shaped like the one Stripe would return for the order
+func newMockPaymentIntent(id string, orderID string, amount int64, currency string) *stripe.PaymentIntent {
+	if id == "" {
+		id = mockIntentPrefix + strings.ReplaceAll(uuid.New().String(), "-", "")
+	}
+	return &stripe.PaymentIntent{
+		ID:           id,
+		Object:       "payment_intent",
+		Amount:       amount,
+		Currency:     stripe.Currency(strings.ToLower(currency)),
+		ClientSecret: id + "_secret_mock",
+		Status:       stripe.PaymentIntentStatusRequiresPaymentMethod,
+		Metadata:     map[string]string{"order_id": orderID},
+		Livemode:     false,
+	}
+}
+
+// webhookSigningSecret returns the secret used to verify webhook signatures. In mock mode QA signs
+// test payloads with PAYMENT_MOCK_WEBHOOK_SECRET when no real Stripe webhook secret is configured.
+func webhookSigningSecret() string {
+	if secret := os.Getenv("STRIPE_WEBHOOK_SECRET"); secret != "" {
+		return secret
+	}
+	if MockPaymentsEnabled() {
+		return os.Getenv("PAYMENT_MOCK_WEBHOOK_SECRET")
+	}
+	return ""
+}
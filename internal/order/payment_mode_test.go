@@ -0,0 +1,202 @@
+package order_test
+
+import (
+	"bytes"
+	"context"
+	"ms-ticketing/internal/kafka"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	tickets "ms-ticketing/internal/tickets/service"
+	"ms-ticketing/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stripe/stripe-go/v74/webhook"
+)
+
+func TestValidatePaymentMode(t *testing.T) {
+	t.Setenv("PAYMENT_MODE", "mock")
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("STRIPE_SECRET_KEY", "")
+	assert.NoError(t, order.ValidatePaymentMode())
+	assert.True(t, order.MockPaymentsEnabled())
+
+	// Production config must never run with mock payments
+	t.Setenv("APP_ENV", "production")
+	assert.ErrorIs(t, order.ValidatePaymentMode(), order.ErrMockPaymentsInProduction)
+	assert.False(t, order.MockPaymentsEnabled())
+
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("STRIPE_SECRET_KEY", "sk_live_abc")
+	assert.ErrorIs(t, order.ValidatePaymentMode(), order.ErrMockPaymentsInProduction)
+	assert.False(t, order.MockPaymentsEnabled())
+
+	// Mock mode needs an explicit non-production environment; unset or unknown ones count as production
+	t.Setenv("STRIPE_SECRET_KEY", "")
+	for _, appEnv := range []string{"", "prod", "live"} {
+		t.Setenv("APP_ENV", appEnv)
+		assert.ErrorIs(t, order.ValidatePaymentMode(), order.ErrMockPaymentsInProduction, appEnv)
+		assert.False(t, order.MockPaymentsEnabled(), appEnv)
+	}
+
+	// The real Stripe mode is always fine
+	t.Setenv("PAYMENT_MODE", "stripe")
+	assert.NoError(t, order.ValidatePaymentMode())
+	assert.False(t, order.MockPaymentsEnabled())
+}
+
+func TestCreatePaymentIntentMockMode(t *testing.T) {
+	t.Setenv("PAYMENT_MODE", "mock")
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("STRIPE_SECRET_KEY", "")
+
+	mockDB := new(MockDBLayer)
+	orderSvc := order.NewOrderService(mockDB, new(MockRedisLock), kafka.NewInMemoryProducer(), &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+
+	pending := &models.Order{OrderID: "order1", Status: "pending", Price: models.MoneyFromFloat(1500.50)}
+	mockDB.On("GetOrderByID", "order1").Return(pending, nil)
+	mockDB.On("UpdateOrder", mock.MatchedBy(func(o models.Order) bool {
+		return order.IsMockPaymentIntent(o.PaymentIntentID)
+	})).Return(nil).Once()
+
+	intent, err := orderSvc.CreatePaymentIntent(context.Background(), "order1")
+	assert.NoError(t, err)
+	assert.True(t, order.IsMockPaymentIntent(intent.ID))
+	assert.Equal(t, int64(150050), intent.Amount)
+	assert.Equal(t, "order1", intent.Metadata["order_id"])
+	assert.NotEmpty(t, intent.ClientSecret)
+
+	// A second call reuses the stored mock intent instead of minting another one
+	again, err := orderSvc.CreatePaymentIntent(context.Background(), "order1")
+	assert.NoError(t, err)
+	assert.Equal(t, intent.ID, again.ID)
+	mockDB.AssertExpectations(t)
+}
+
+func TestCreatePaymentIntentUsesOrderCurrency(t *testing.T) {
+	t.Setenv("PAYMENT_MODE", "mock")
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("STRIPE_SECRET_KEY", "")
+	t.Setenv("CURRENCY", "LKR")
+
+	mockDB := new(MockDBLayer)
+	orderSvc := order.NewOrderService(mockDB, new(MockRedisLock), kafka.NewInMemoryProducer(), &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+
+	mockDB.On("GetOrderByID", "usd-order").Return(&models.Order{OrderID: "usd-order", Status: "pending", Price: models.MoneyFromFloat(20), Currency: "USD"}, nil)
+	mockDB.On("GetOrderByID", "legacy-order").Return(&models.Order{OrderID: "legacy-order", Status: "pending", Price: models.MoneyFromFloat(20)}, nil)
+	mockDB.On("UpdateOrder", mock.Anything).Return(nil)
+
+	intent, err := orderSvc.CreatePaymentIntent(context.Background(), "usd-order")
+	assert.NoError(t, err)
+	assert.Equal(t, "usd", string(intent.Currency))
+
+	// Orders without a stored currency are charged in the configured default
+	intent, err = orderSvc.CreatePaymentIntent(context.Background(), "legacy-order")
+	assert.NoError(t, err)
+	assert.Equal(t, "lkr", string(intent.Currency))
+}
+
+func TestCreatePaymentIntentRejectsUnsupportedCurrency(t *testing.T) {
+	t.Setenv("PAYMENT_MODE", "mock")
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("STRIPE_SECRET_KEY", "")
+
+	mockDB := new(MockDBLayer)
+	orderSvc := order.NewOrderService(mockDB, new(MockRedisLock), kafka.NewInMemoryProducer(), &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+
+	// 2000 minor units of a zero-decimal currency would be charged as JPY 2000 instead of JPY 20
+	mockDB.On("GetOrderByID", "jpy-order").Return(&models.Order{OrderID: "jpy-order", Status: "pending", Price: models.MoneyFromFloat(20), Currency: "JPY"}, nil)
+
+	_, err := orderSvc.CreatePaymentIntent(context.Background(), "jpy-order")
+	assert.ErrorIs(t, err, models.ErrUnsupportedCurrency)
+	mockDB.AssertNotCalled(t, "UpdateOrder", mock.Anything)
+}
+
+func TestHandleStripeWebhookMockSecret(t *testing.T) {
+	t.Setenv("PAYMENT_MODE", "mock")
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("STRIPE_SECRET_KEY", "")
+	t.Setenv("STRIPE_WEBHOOK_SECRET", "")
+	t.Setenv("PAYMENT_MOCK_WEBHOOK_SECRET", "whsec_test")
+
+	orderSvc := order.NewOrderService(new(MockDBLayer), new(MockRedisLock), kafka.NewInMemoryProducer(), &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+	payload := []byte(`{"id":"evt_mock_1","object":"event","type":"charge.updated","data":{"object":{}}}`)
+
+	newRequest := func(secret string) *http.Request {
+		signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: payload, Secret: secret})
+		req := httptest.NewRequest(http.MethodPost, "/api/order/webhook/stripe", bytes.NewReader(signed.Payload))
+		req.Header.Set("Stripe-Signature", signed.Header)
+		return req
+	}
+
+	assert.NoError(t, orderSvc.HandleStripeWebhook(newRequest("whsec_test")))
+
+	err := orderSvc.HandleStripeWebhook(newRequest("whsec_wrong"))
+	var webhookErr *order.WebhookError
+	if assert.ErrorAs(t, err, &webhookErr) {
+		assert.Equal(t, http.StatusBadRequest, webhookErr.StatusCode)
+	}
+
+	// Outside mock mode the mock secret is ignored
+	t.Setenv("PAYMENT_MODE", "stripe")
+	err = orderSvc.HandleStripeWebhook(newRequest("whsec_test"))
+	if assert.ErrorAs(t, err, &webhookErr) {
+		assert.Equal(t, "configuration", webhookErr.Category)
+	}
+}
+
+func TestHandleStripeWebhookRejectsStaleSignature(t *testing.T) {
+	t.Setenv("PAYMENT_MODE", "mock")
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("STRIPE_SECRET_KEY", "")
+	t.Setenv("STRIPE_WEBHOOK_SECRET", "")
+	t.Setenv("PAYMENT_MOCK_WEBHOOK_SECRET", "whsec_test")
+	t.Setenv("STRIPE_WEBHOOK_TOLERANCE_SECONDS", "60")
+
+	orderSvc := order.NewOrderService(new(MockDBLayer), new(MockRedisLock), kafka.NewInMemoryProducer(), &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+	payload := []byte(`{"id":"evt_mock_2","object":"event","type":"charge.updated","data":{"object":{}}}`)
+
+	newRequest := func(signedAt time.Time) *http.Request {
+		signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: payload, Secret: "whsec_test", Timestamp: signedAt})
+		req := httptest.NewRequest(http.MethodPost, "/api/order/webhook/stripe", bytes.NewReader(signed.Payload))
+		req.Header.Set("Stripe-Signature", signed.Header)
+		return req
+	}
+
+	assert.NoError(t, orderSvc.HandleStripeWebhook(newRequest(time.Now().Add(-30*time.Second))))
+
+	// A correctly signed payload replayed after the tolerance window is refused
+	err := orderSvc.HandleStripeWebhook(newRequest(time.Now().Add(-2 * time.Minute)))
+	var webhookErr *order.WebhookError
+	if assert.ErrorAs(t, err, &webhookErr) {
+		assert.Equal(t, http.StatusBadRequest, webhookErr.StatusCode)
+		assert.Equal(t, "Webhook timestamp outside tolerance", webhookErr.PublicError)
+		assert.ErrorIs(t, webhookErr.OriginalErr, webhook.ErrTooOld)
+	}
+}
+
+func TestHandleStripeWebhookRejectsOversizedPayload(t *testing.T) {
+	t.Setenv("PAYMENT_MODE", "mock")
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("STRIPE_SECRET_KEY", "")
+	t.Setenv("STRIPE_WEBHOOK_SECRET", "")
+	t.Setenv("PAYMENT_MOCK_WEBHOOK_SECRET", "whsec_test")
+	t.Setenv("MAX_REQUEST_BODY_BYTES", "32")
+
+	orderSvc := order.NewOrderService(new(MockDBLayer), new(MockRedisLock), kafka.NewInMemoryProducer(), &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+	payload := []byte(`{"id":"evt_mock_3","object":"event","type":"charge.updated","data":{"object":{}}}`)
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: payload, Secret: "whsec_test"})
+	req := httptest.NewRequest(http.MethodPost, "/api/order/webhook/stripe", bytes.NewReader(signed.Payload))
+	req.Header.Set("Stripe-Signature", signed.Header)
+	utils.LimitBody(httptest.NewRecorder(), req)
+
+	err := orderSvc.HandleStripeWebhook(req)
+	var webhookErr *order.WebhookError
+	if assert.ErrorAs(t, err, &webhookErr) {
+		assert.Equal(t, http.StatusRequestEntityTooLarge, webhookErr.StatusCode)
+	}
+}
@@ -0,0 +1,270 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/utils"
+	"net/http"
+)
+
+// Payment providers an order can be paid through
+const (
+	PaymentProviderStripe  = "stripe"
+	PaymentProviderPayHere = "payhere"
+)
+
+// PaymentStatusFailed is reported by webhooks for payments that failed; the order is cancelled
+const PaymentStatusFailed = "failed"
+
+var (
+	ErrUnknownPaymentProvider = errors.New("payment provider is not configured")
+	ErrPaymentAmountMismatch  = errors.New("paid amount does not match the order")
+)
+
+// PaymentProvider is a gateway orders can be paid through. Each provider reports payments in its own
+// vocabulary; OrderService only acts on the normalized PaymentStatus* values it maps them to.
+type PaymentProvider interface {
+	Name() string
+	// CreatePayment starts paying the order's price and returns what the client needs to complete it
+	CreatePayment(ctx context.Context, order *models.Order) (*ProviderPayment, error)
+	// CancelPayment voids a payment that was started but not completed
+	CancelPayment(paymentID string, reason string) error
+	// VerifyWebhook authenticates a webhook delivery and returns the payment update it carries,
+	// or nil for events that don't concern an order. Errors are *WebhookError.
+	VerifyWebhook(r *http.Request, payload []byte) (*PaymentUpdate, error)
+	// MapStatus normalizes one of the provider's payment statuses to a PaymentStatus* value
+	MapStatus(status string) string
+}
+
+// ProviderPayment is a started payment as handed to the client
+type ProviderPayment struct {
+	Provider     string            `json:"provider"`
+	OrderID      string            `json:"order_id"`
+	PaymentID    string            `json:"payment_id,omitempty"`
+	Status       string            `json:"status"`
+	ClientSecret string            `json:"client_secret,omitempty"` // Stripe
+	CheckoutURL  string            `json:"checkout_url,omitempty"`  // Hosted checkout the client posts Fields to
+	Fields       map[string]string `json:"fields,omitempty"`
+}
+
+// PaymentUpdate is what a provider's webhook says happened to an order's payment
+type PaymentUpdate struct {
+	OrderID        string
+	PaymentID      string
+	Status         string // Normalized PaymentStatus* value
+	ProviderStatus string
+	Amount         int64  // Minor units; zero when the provider doesn't report it
+	Currency       string // ISO 4217; empty when Amount is zero
+}
+
+// RegisterPaymentProvider makes a provider available for payments and its webhook
+func (s *OrderService) RegisterPaymentProvider(provider PaymentProvider) {
+	if s.PaymentProviders == nil {
+		s.PaymentProviders = map[string]PaymentProvider{}
+	}
+	s.PaymentProviders[provider.Name()] = provider
+}
+
+// PaymentProvider returns a registered provider by name
+func (s *OrderService) PaymentProvider(name string) (PaymentProvider, error) {
+	provider, ok := s.PaymentProviders[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPaymentProvider, name)
+	}
+	return provider, nil
+}
+
+// orderPaymentProvider is the provider an order is being paid through; orders that predate
+// providers were all paid with Stripe
+func orderPaymentProvider(order *models.Order) string {
+	if order.PaymentProvider == "" {
+		return PaymentProviderStripe
+	}
+	return order.PaymentProvider
+}
+
+// CreateProviderPayment starts paying a pending order through the named provider and records the
+// provider on the order, so its webhook and cancellation are routed there. A payment already started
+// with another provider is cancelled first.
+func (s *OrderService) CreateProviderPayment(ctx context.Context, orderID, providerName string) (*ProviderPayment, error) {
+	provider, err := s.PaymentProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	order, err := s.lookupOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != "pending" {
+		return nil, fmt.Errorf("%w: current status is %s", ErrOrderNotPending, order.Status)
+	}
+
+	if previous := orderPaymentProvider(order); previous != providerName && order.PaymentIntentID != "" {
+		if err := s.cancelOrderPayment(order, CancellationAbandoned); err != nil {
+			s.logger.Error("PAYMENT", fmt.Sprintf("Failed to cancel %s payment %s of order %s: %v", previous, order.PaymentIntentID, orderID, err))
+		}
+		order.PaymentIntentID = ""
+	}
+
+	payment, err := provider.CreatePayment(ctx, order)
+	if err != nil {
+		s.logger.Error("PAYMENT", fmt.Sprintf("Failed to create %s payment for order %s: %v", providerName, orderID, err))
+		return nil, err
+	}
+
+	order.PaymentProvider = providerName
+	if payment.PaymentID != "" {
+		order.PaymentIntentID = payment.PaymentID
+	}
+	if err := s.DB.UpdateOrder(ctx, *order); err != nil {
+		s.logger.Error("PAYMENT", fmt.Sprintf("Failed to record %s payment on order %s: %v", providerName, orderID, err))
+		return nil, err
+	}
+
+	s.logger.Info("PAYMENT", fmt.Sprintf("Started %s payment for order %s (%s)", providerName, orderID, order.Price.Format(order.CurrencyCode())))
+	return payment, nil
+}
+
+// cancelOrderPayment voids the order's started payment with the provider it was started with
+func (s *OrderService) cancelOrderPayment(order *models.Order, reason string) error {
+	provider, err := s.PaymentProvider(orderPaymentProvider(order))
+	if err != nil {
+		return err
+	}
+	return provider.CancelPayment(order.PaymentIntentID, reason)
+}
+
+// HandlePaymentWebhook verifies a webhook from the named provider and applies the payment update it
+// carries: a succeeded payment checks the order out, a failed or cancelled one cancels it. Other
+// statuses are acknowledged without changes, since the provider will report the outcome later.
+func (s *OrderService) HandlePaymentWebhook(r *http.Request, providerName string) error {
+	provider, err := s.PaymentProvider(providerName)
+	if err != nil {
+		s.logger.Error("WEBHOOK", err.Error())
+		return &WebhookError{
+			Category:      "configuration",
+			StatusCode:    http.StatusNotFound,
+			PublicError:   "Unknown payment provider",
+			InternalError: err.Error(),
+			OriginalErr:   err,
+		}
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.logger.Error("WEBHOOK", fmt.Sprintf("Failed to read %s webhook payload: %v", providerName, err))
+		if utils.IsBodyTooLarge(err) {
+			return &WebhookError{
+				Category:      "validation",
+				StatusCode:    http.StatusRequestEntityTooLarge,
+				PublicError:   "Webhook payload too large",
+				InternalError: fmt.Sprintf("Webhook payload exceeds %d bytes: %v", utils.MaxBodyBytes(), err),
+				OriginalErr:   err,
+			}
+		}
+		return &WebhookError{
+			Category:      "validation",
+			StatusCode:    http.StatusBadRequest,
+			PublicError:   "Invalid webhook payload",
+			InternalError: fmt.Sprintf("Failed to read webhook payload: %v", err),
+			OriginalErr:   err,
+		}
+	}
+
+	update, err := provider.VerifyWebhook(r, payload)
+	if err != nil {
+		s.logger.Error("WEBHOOK", fmt.Sprintf("Rejected %s webhook: %v", providerName, err))
+		return err
+	}
+	if update == nil {
+		return nil
+	}
+
+	ctx := WithStatusActor(r.Context(), providerName+"_webhook")
+	return s.applyPaymentUpdate(ctx, providerName, update)
+}
+
+func (s *OrderService) applyPaymentUpdate(ctx context.Context, providerName string, update *PaymentUpdate) error {
+	switch update.Status {
+	case PaymentStatusSucceeded:
+		if err := s.recordProviderPayment(ctx, providerName, update); err != nil {
+			s.logger.Error("WEBHOOK", fmt.Sprintf("Refusing %s payment %s for order %s: %v", providerName, update.PaymentID, update.OrderID, err))
+			return &WebhookError{
+				Category:      "processing",
+				StatusCode:    http.StatusBadRequest,
+				PublicError:   "Payment does not match the order",
+				InternalError: fmt.Sprintf("Refusing %s payment %s for order %s: %v", providerName, update.PaymentID, update.OrderID, err),
+				OriginalErr:   err,
+			}
+		}
+
+		err := s.Checkout(ctx, update.OrderID)
+		if errors.Is(err, ErrAlreadyCompleted) {
+			// Providers retry deliveries, so a repeat of an already-handled payment is a success
+			s.logger.Info("WEBHOOK", fmt.Sprintf("Order %s was already completed, acknowledging duplicate webhook", update.OrderID))
+			return nil
+		}
+		if err != nil {
+			s.logger.Error("WEBHOOK", fmt.Sprintf("Failed to checkout order %s: %v", update.OrderID, err))
+			return &WebhookError{
+				Category:      "processing",
+				StatusCode:    http.StatusInternalServerError,
+				PublicError:   "Failed to process payment",
+				InternalError: fmt.Sprintf("Failed to checkout order %s: %v", update.OrderID, err),
+				OriginalErr:   err,
+			}
+		}
+		s.logger.Info("WEBHOOK", fmt.Sprintf("Successfully processed %s payment for order %s", providerName, update.OrderID))
+
+	case PaymentStatusFailed, PaymentStatusCanceled:
+		if order, err := s.lookupOrder(ctx, update.OrderID); err == nil && order.Status != "pending" {
+			// Already settled, e.g. expired before the failure arrived or charged back after completion
+			s.logger.Warn("WEBHOOK", fmt.Sprintf("Ignoring %s payment %s for %s order %s", providerName, update.ProviderStatus, order.Status, update.OrderID))
+			return nil
+		}
+		if err := s.CancelOrder(ctx, update.OrderID); err != nil {
+			s.logger.Error("WEBHOOK", fmt.Sprintf("Failed to cancel order %s after payment failure: %v", update.OrderID, err))
+			return &WebhookError{
+				Category:      "processing",
+				StatusCode:    http.StatusInternalServerError,
+				PublicError:   "Failed to cancel order after payment failure",
+				InternalError: fmt.Sprintf("Failed to cancel order %s after payment failure: %v", update.OrderID, err),
+				OriginalErr:   err,
+			}
+		}
+		s.logger.Info("WEBHOOK", fmt.Sprintf("Cancelled order %s due to %s payment %s", update.OrderID, providerName, update.ProviderStatus))
+
+	default:
+		s.logger.Info("WEBHOOK", fmt.Sprintf("%s payment of order %s is %s, waiting for its outcome", providerName, update.OrderID, update.ProviderStatus))
+	}
+	return nil
+}
+
+// recordProviderPayment checks a succeeded payment against the order and stores its payment ID when
+// the order learns it only now, as with hosted checkouts that assign the ID on completion
+func (s *OrderService) recordProviderPayment(ctx context.Context, providerName string, update *PaymentUpdate) error {
+	if providerName == PaymentProviderStripe {
+		// Stripe intents are created and stored before they can succeed
+		return nil
+	}
+
+	order, err := s.lookupOrder(ctx, update.OrderID)
+	if err != nil {
+		return err
+	}
+	// A payment that doesn't report what it paid, e.g. a zero amount, can't complete a paid order either
+	if !order.IsComped && (update.Amount != order.Price.MinorUnits() || update.Currency != order.CurrencyCode()) {
+		return fmt.Errorf("%w: paid %d %s, order costs %d %s", ErrPaymentAmountMismatch,
+			update.Amount, update.Currency, order.Price.MinorUnits(), order.CurrencyCode())
+	}
+	if order.Status != "pending" || order.PaymentIntentID == update.PaymentID {
+		return nil
+	}
+
+	order.PaymentProvider = providerName
+	order.PaymentIntentID = update.PaymentID
+	return s.DB.UpdateOrder(ctx, *order)
+}
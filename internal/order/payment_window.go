@@ -0,0 +1,82 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"ms-ticketing/internal/models"
+	"os"
+	"strconv"
+	"time"
+)
+
+// PaymentWindow is how long a buyer has to pay for an order: the event's own window when it sets one,
+// else ORDER_PAYMENT_WINDOW_MINUTES. Zero means orders have no payment deadline of their own and are
+// cancelled when their seat locks expire.
+func PaymentWindow(eventMinutes int) time.Duration {
+	if eventMinutes > 0 {
+		return time.Duration(eventMinutes) * time.Minute
+	}
+	minutes, err := strconv.Atoi(os.Getenv("ORDER_PAYMENT_WINDOW_MINUTES"))
+	if err != nil || minutes < 0 {
+		return 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// paymentWindowOpen reports whether the order still has time to pay under its own payment window
+func paymentWindowOpen(order *models.Order, now time.Time) bool {
+	return !order.PaymentDueAt.IsZero() && now.Before(order.PaymentDueAt)
+}
+
+// CancelOverdueOrders cancels every pending order whose payment window has closed and releases its seats,
+// however long their seat locks still have to run. An order Stripe reports as paid is checked out instead,
+// as when its seat locks expire. It returns the number of orders cancelled.
+func (s *OrderService) CancelOverdueOrders(ctx context.Context, now time.Time) (int, error) {
+	orders, err := s.DB.GetOverduePendingOrders(ctx, now)
+	if err != nil {
+		s.logger.Error("PAYMENT_WINDOW", fmt.Sprintf("Failed to fetch overdue orders: %v", err))
+		return 0, fmt.Errorf("failed to fetch overdue orders: %w", err)
+	}
+
+	ctx = WithStatusActor(WithSeatStatusDedupe(ctx), ActorPaymentWindow)
+	orderIDs := make([]string, len(orders))
+	for i, order := range orders {
+		orderIDs[i] = order.OrderID
+	}
+	cancelled := 0
+	for _, outcome := range s.CancelExpiredOrders(ctx, orderIDs) {
+		switch {
+		case outcome.Err != nil:
+			s.logger.Error("PAYMENT_WINDOW", fmt.Sprintf("Failed to cancel overdue order %s: %v", outcome.OrderID, outcome.Err))
+		case outcome.Completed:
+			s.logger.Info("PAYMENT_WINDOW", fmt.Sprintf("Overdue order %s was paid and completed instead of cancelled", outcome.OrderID))
+		default:
+			cancelled++
+		}
+	}
+
+	if cancelled > 0 {
+		s.logger.Info("PAYMENT_WINDOW", fmt.Sprintf("Cancelled %d orders whose payment window closed", cancelled))
+	}
+	return cancelled, nil
+}
+
+// StartPaymentWindowSweeper runs CancelOverdueOrders every interval until ctx is cancelled
+func (s *OrderService) StartPaymentWindowSweeper(ctx context.Context, interval time.Duration) {
+	s.logger.Info("PAYMENT_WINDOW", fmt.Sprintf("Payment window sweeper enabled, checking every %s", interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("PAYMENT_WINDOW", "Stopping payment window sweeper")
+			return
+		case <-ticker.C:
+			if _, err := s.CancelOverdueOrders(ctx, time.Now()); err != nil {
+				s.logger.Error("PAYMENT_WINDOW", fmt.Sprintf("Payment window sweep failed: %v", err))
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package order_test
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	order_db "ms-ticketing/internal/order/db"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentWindow(t *testing.T) {
+	t.Setenv("ORDER_PAYMENT_WINDOW_MINUTES", "")
+	assert.Zero(t, order.PaymentWindow(0))
+
+	t.Setenv("ORDER_PAYMENT_WINDOW_MINUTES", "15")
+	assert.Equal(t, 15*time.Minute, order.PaymentWindow(0))
+	// The event's own window wins
+	assert.Equal(t, 3*time.Minute, order.PaymentWindow(3))
+}
+
+func TestExpiredSeatLockKeepsOrderInsideItsPaymentWindow(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(2)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats, PaymentWindowMinutes: 10})
+	t.Setenv("ORDER_EXPIRY_PAYMENT_GRACE_SECONDS", "0")
+	env.OrderService.SetStatusHistory(&order_db.DB{Bun: env.DB})
+	ctx := context.Background()
+
+	resp, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+		SessionID: uuid.NewString(), EventID: "event-1", SeatIDs: seatIDs,
+	})
+	require.NoError(t, err)
+	placed, err := env.OrderService.GetOrder(ctx, resp.OrderID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, placed.CreatedAt.Add(10*time.Minute), placed.PaymentDueAt, time.Second)
+
+	// The seat locks run out first: the order keeps its seats
+	for _, seatID := range seatIDs {
+		_, err := env.Locks.ForceUnlockSeat(seatID)
+		require.NoError(t, err)
+	}
+	result := env.OrderService.HandleExpiredSeats(ctx, seatIDs)
+	assert.Equal(t, order.SeatExpiryResult{Seats: 2, SeatsRelocked: 2}, result)
+	for _, seatID := range seatIDs {
+		lock, _, err := env.Locks.GetSeatLock(seatID)
+		require.NoError(t, err)
+		require.NotNil(t, lock)
+		assert.Equal(t, resp.OrderID, lock.OrderID)
+	}
+
+	// Nothing is overdue until the window closes
+	cancelled, err := env.OrderService.CancelOverdueOrders(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, cancelled)
+
+	cancelled, err = env.OrderService.CancelOverdueOrders(ctx, time.Now().Add(11*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, cancelled)
+
+	o, err := env.OrderService.GetOrder(ctx, resp.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", o.Status)
+	available, _, err := env.Locks.CheckSeatsAvailability(seatIDs)
+	require.NoError(t, err)
+	assert.True(t, available)
+
+	history, err := env.OrderService.GetOrderStatusHistory(ctx, resp.OrderID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, order.ActorPaymentWindow, history[1].Actor)
+}
+
+func TestPaymentWindowCancelsBeforeSeatLocksExpire(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(1)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	t.Setenv("ORDER_PAYMENT_WINDOW_MINUTES", "2")
+	t.Setenv("ORDER_EXPIRY_PAYMENT_GRACE_SECONDS", "0")
+	ctx := context.Background()
+
+	resp, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+		SessionID: uuid.NewString(), EventID: "event-1", SeatIDs: seatIDs,
+	})
+	require.NoError(t, err)
+
+	// The seat is still locked, but the order's time to pay is up
+	cancelled, err := env.OrderService.CancelOverdueOrders(ctx, time.Now().Add(3*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, cancelled)
+
+	o, err := env.OrderService.GetOrder(ctx, resp.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", o.Status)
+	available, _, err := env.Locks.CheckSeatsAvailability(seatIDs)
+	require.NoError(t, err)
+	assert.True(t, available)
+}
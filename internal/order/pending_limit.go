@@ -0,0 +1,47 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// DefaultMaxPendingOrdersPerUser is how many unpaid orders one user may hold for a session at once
+const DefaultMaxPendingOrdersPerUser = 3
+
+// ErrTooManyPendingOrders is returned when a user already holds the maximum number of pending orders for a session
+var ErrTooManyPendingOrders = errors.New("too many pending orders for this session")
+
+// MaxPendingOrdersPerUser returns the limit from MAX_PENDING_ORDERS_PER_USER or the default; 0 disables it
+func MaxPendingOrdersPerUser() int {
+	value, ok := os.LookupEnv("MAX_PENDING_ORDERS_PER_USER")
+	if !ok {
+		return DefaultMaxPendingOrdersPerUser
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return DefaultMaxPendingOrdersPerUser
+	}
+	return limit
+}
+
+// checkPendingOrderLimit rejects a new order when the user's pending orders for the session already reach the limit
+func (s *OrderService) checkPendingOrderLimit(ctx context.Context, userID, sessionID string) error {
+	limit := MaxPendingOrdersPerUser()
+	if limit == 0 {
+		return nil
+	}
+
+	pending, err := s.DB.CountPendingOrdersByUserSession(ctx, userID, sessionID)
+	if err != nil {
+		s.logger.Error("ORDER", fmt.Sprintf("Failed to count pending orders for user %s: %v", userID, err))
+		return fmt.Errorf("failed to count pending orders: %w", err)
+	}
+	if pending >= limit {
+		s.logger.Warn("ORDER", fmt.Sprintf("User %s already has %d pending orders for session %s", userID, pending, sessionID))
+		return fmt.Errorf("%w: %d of %d allowed, pay for or cancel an existing order first", ErrTooManyPendingOrders, pending, limit)
+	}
+	return nil
+}
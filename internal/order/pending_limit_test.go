@@ -0,0 +1,58 @@
+package order_test
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaceOrderLimitsPendingOrdersPerUser(t *testing.T) {
+	seats, _ := seatsForLimitTest(1)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	t.Setenv("MAX_PENDING_ORDERS_PER_USER", "2")
+
+	sessionID := uuid.NewString()
+	place := func(userID, sessionID string) (*models.OrderResponse, error) {
+		return env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, userID), models.OrderRequest{
+			SessionID: sessionID,
+			EventID:   "event-1",
+			SeatIDs:   []string{uuid.NewString()},
+		})
+	}
+
+	first, err := place("user-1", sessionID)
+	require.NoError(t, err)
+	_, err = place("user-1", sessionID)
+	require.NoError(t, err)
+
+	// A third checkout tab is refused before any seat is locked
+	lockedBefore := len(env.Producer.MessagesOn("ticketly.seats.status"))
+	_, err = place("user-1", sessionID)
+	assert.ErrorIs(t, err, order.ErrTooManyPendingOrders)
+	assert.Len(t, env.Producer.MessagesOn("ticketly.seats.status"), lockedBefore)
+
+	// The limit is per user and per session
+	_, err = place("user-2", sessionID)
+	assert.NoError(t, err)
+	_, err = place("user-1", uuid.NewString())
+	assert.NoError(t, err)
+
+	// Finishing or abandoning a pending order frees a slot
+	firstOrder, err := env.OrderService.GetOrder(context.Background(), first.OrderID)
+	require.NoError(t, err)
+	firstOrder.Status = "cancelled"
+	_, err = env.DB.NewUpdate().Model(firstOrder).Column("status").WherePK().Exec(context.Background())
+	require.NoError(t, err)
+	_, err = place("user-1", sessionID)
+	assert.NoError(t, err)
+
+	// 0 turns the limit off
+	t.Setenv("MAX_PENDING_ORDERS_PER_USER", "0")
+	_, err = place("user-1", sessionID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,203 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"ms-ticketing/internal/models"
+	"time"
+
+	"github.com/stripe/stripe-go/v74"
+	"github.com/stripe/stripe-go/v74/paymentintent"
+)
+
+// Discrepancy types reported by ReconcilePayments
+const (
+	// DiscrepancyPaidNotCompleted is a succeeded Stripe payment whose order is missing or not completed
+	DiscrepancyPaidNotCompleted = "paid_not_completed"
+	// DiscrepancyCompletedWithoutPayment is a completed order without a succeeded Stripe payment
+	DiscrepancyCompletedWithoutPayment = "completed_without_payment"
+)
+
+// MaxReconcileRange bounds how much Stripe history a single reconciliation may page through
+const MaxReconcileRange = 31 * 24 * time.Hour
+
+// PaymentIntentSource lists and fetches Stripe payment intents
+type PaymentIntentSource interface {
+	ListPaymentIntents(from, to time.Time) ([]*stripe.PaymentIntent, error)
+	GetPaymentIntent(id string) (*stripe.PaymentIntent, error)
+}
+
+// stripeIntentSource reads payment intents from the Stripe API
+type stripeIntentSource struct{}
+
+func (stripeIntentSource) ListPaymentIntents(from, to time.Time) ([]*stripe.PaymentIntent, error) {
+	params := &stripe.PaymentIntentListParams{
+		CreatedRange: &stripe.RangeQueryParams{
+			GreaterThanOrEqual: from.Unix(),
+			LesserThan:         to.Unix(),
+		},
+	}
+	params.Limit = stripe.Int64(100)
+
+	var intents []*stripe.PaymentIntent
+	iter := paymentintent.List(params)
+	for iter.Next() {
+		intents = append(intents, iter.PaymentIntent())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return intents, nil
+}
+
+func (stripeIntentSource) GetPaymentIntent(id string) (*stripe.PaymentIntent, error) {
+	return paymentintent.Get(id, nil)
+}
+
+// PaymentDiscrepancy is one mismatch between Stripe and the local order records
+type PaymentDiscrepancy struct {
+	Type            string       `json:"type"`
+	OrderID         string       `json:"order_id,omitempty"`
+	PaymentIntentID string       `json:"payment_intent_id,omitempty"`
+	OrderStatus     string       `json:"order_status,omitempty"`
+	IntentStatus    string       `json:"intent_status,omitempty"`
+	OrderPrice      models.Money `json:"order_price,omitempty"`
+	AmountReceived  int64        `json:"amount_received,omitempty"` // minor units as reported by Stripe
+	Detail          string       `json:"detail"`
+}
+
+// PaymentReconciliationReport summarizes a reconciliation run; nothing is changed by producing it
+type PaymentReconciliationReport struct {
+	From           time.Time            `json:"from"`
+	To             time.Time            `json:"to"`
+	IntentsChecked int                  `json:"intents_checked"`
+	OrdersChecked  int                  `json:"orders_checked"`
+	Discrepancies  []PaymentDiscrepancy `json:"discrepancies"`
+}
+
+// ReconcilePayments compares Stripe payment intents created in [from, to) with local orders.
+// Intents are matched to orders through their order_id metadata. Orders hold the local
+// payment record, so a completed order must point at a succeeded intent unless it was comped.
+func (s *OrderService) ReconcilePayments(ctx context.Context, from, to time.Time) (*PaymentReconciliationReport, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("reconciliation range end must be after its start")
+	}
+	if to.Sub(from) > MaxReconcileRange {
+		return nil, fmt.Errorf("reconciliation range must not exceed %s", MaxReconcileRange)
+	}
+
+	s.logger.Info("RECONCILE", fmt.Sprintf("Reconciling payments from %s to %s", from.Format(time.RFC3339), to.Format(time.RFC3339)))
+
+	intents, err := s.PaymentIntents.ListPaymentIntents(from, to)
+	if err != nil {
+		s.logger.Error("RECONCILE", fmt.Sprintf("Failed to list Stripe payment intents: %v", err))
+		return nil, fmt.Errorf("failed to list payment intents: %w", err)
+	}
+
+	report := &PaymentReconciliationReport{
+		From:           from,
+		To:             to,
+		IntentsChecked: len(intents),
+		Discrepancies:  []PaymentDiscrepancy{},
+	}
+
+	succeeded := make(map[string]*stripe.PaymentIntent)
+	for _, intent := range intents {
+		if intent.Status != stripe.PaymentIntentStatusSucceeded {
+			continue
+		}
+		succeeded[intent.ID] = intent
+
+		orderID := intent.Metadata["order_id"]
+		if orderID == "" {
+			// Not created by this service
+			continue
+		}
+
+		order, err := s.DB.GetOrderByID(ctx, orderID)
+		if err != nil {
+			report.Discrepancies = append(report.Discrepancies, PaymentDiscrepancy{
+				Type:            DiscrepancyPaidNotCompleted,
+				OrderID:         orderID,
+				PaymentIntentID: intent.ID,
+				IntentStatus:    string(intent.Status),
+				AmountReceived:  intent.AmountReceived,
+				Detail:          "payment succeeded but the order does not exist locally",
+			})
+			continue
+		}
+		if order.Status != "completed" {
+			report.Discrepancies = append(report.Discrepancies, PaymentDiscrepancy{
+				Type:            DiscrepancyPaidNotCompleted,
+				OrderID:         orderID,
+				PaymentIntentID: intent.ID,
+				OrderStatus:     order.Status,
+				IntentStatus:    string(intent.Status),
+				OrderPrice:      order.Price,
+				AmountReceived:  intent.AmountReceived,
+				Detail:          fmt.Sprintf("payment succeeded but the order is %s", order.Status),
+			})
+		}
+	}
+
+	orders, err := s.DB.GetCompletedOrdersBetween(ctx, from, to)
+	if err != nil {
+		s.logger.Error("RECONCILE", fmt.Sprintf("Failed to load completed orders: %v", err))
+		return nil, fmt.Errorf("failed to load completed orders: %w", err)
+	}
+	report.OrdersChecked = len(orders)
+
+	for _, order := range orders {
+		if order.IsComped {
+			// Issued free by an admin, so there is no payment to find
+			continue
+		}
+		if orderPaymentProvider(&order) != PaymentProviderStripe {
+			// Paid through another provider, which Stripe knows nothing about
+			continue
+		}
+		if order.PaymentIntentID == "" {
+			report.Discrepancies = append(report.Discrepancies, PaymentDiscrepancy{
+				Type:        DiscrepancyCompletedWithoutPayment,
+				OrderID:     order.OrderID,
+				OrderStatus: order.Status,
+				OrderPrice:  order.Price,
+				Detail:      "order is completed but has no payment intent",
+			})
+			continue
+		}
+		if _, ok := succeeded[order.PaymentIntentID]; ok {
+			continue
+		}
+
+		// The intent may predate the range, so look it up directly before flagging the order
+		intent, err := s.PaymentIntents.GetPaymentIntent(order.PaymentIntentID)
+		if err != nil {
+			report.Discrepancies = append(report.Discrepancies, PaymentDiscrepancy{
+				Type:            DiscrepancyCompletedWithoutPayment,
+				OrderID:         order.OrderID,
+				PaymentIntentID: order.PaymentIntentID,
+				OrderStatus:     order.Status,
+				OrderPrice:      order.Price,
+				Detail:          fmt.Sprintf("order is completed but its payment intent could not be retrieved: %v", err),
+			})
+			continue
+		}
+		if intent.Status != stripe.PaymentIntentStatusSucceeded {
+			report.Discrepancies = append(report.Discrepancies, PaymentDiscrepancy{
+				Type:            DiscrepancyCompletedWithoutPayment,
+				OrderID:         order.OrderID,
+				PaymentIntentID: intent.ID,
+				OrderStatus:     order.Status,
+				IntentStatus:    string(intent.Status),
+				OrderPrice:      order.Price,
+				AmountReceived:  intent.AmountReceived,
+				Detail:          fmt.Sprintf("order is completed but its payment intent is %s", intent.Status),
+			})
+		}
+	}
+
+	s.logger.Info("RECONCILE", fmt.Sprintf("Reconciliation checked %d intents and %d orders, found %d discrepancies",
+		report.IntentsChecked, report.OrdersChecked, len(report.Discrepancies)))
+	return report, nil
+}
@@ -0,0 +1,100 @@
+package order_test
+
+import (
+	"context"
+	"errors"
+	"ms-ticketing/internal/kafka"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	tickets "ms-ticketing/internal/tickets/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v74"
+)
+
+// fakeIntentSource serves payment intents from memory instead of the Stripe API
+type fakeIntentSource struct {
+	listed []*stripe.PaymentIntent
+	byID   map[string]*stripe.PaymentIntent
+}
+
+func (f *fakeIntentSource) ListPaymentIntents(from, to time.Time) ([]*stripe.PaymentIntent, error) {
+	return f.listed, nil
+}
+
+func (f *fakeIntentSource) GetPaymentIntent(id string) (*stripe.PaymentIntent, error) {
+	if intent, ok := f.byID[id]; ok {
+		return intent, nil
+	}
+	return nil, errors.New("no such payment intent")
+}
+
+func TestReconcilePayments(t *testing.T) {
+	mockDB := new(MockDBLayer)
+	orderSvc := order.NewOrderService(mockDB, new(MockRedisLock), kafka.NewInMemoryProducer(), &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(7 * 24 * time.Hour)
+
+	succeeded := func(id, orderID string) *stripe.PaymentIntent {
+		return &stripe.PaymentIntent{ID: id, Status: stripe.PaymentIntentStatusSucceeded, AmountReceived: 1000, Metadata: map[string]string{"order_id": orderID}}
+	}
+	orderSvc.PaymentIntents = &fakeIntentSource{
+		listed: []*stripe.PaymentIntent{
+			succeeded("pi_ok", "order_ok"),
+			succeeded("pi_pending", "order_pending"),
+			succeeded("pi_orphan", "order_missing"),
+			{ID: "pi_other", Status: stripe.PaymentIntentStatusSucceeded},
+			{ID: "pi_failed", Status: stripe.PaymentIntentStatusCanceled, Metadata: map[string]string{"order_id": "order_failed"}},
+		},
+		byID: map[string]*stripe.PaymentIntent{
+			"pi_old":     succeeded("pi_old", "order_old"),
+			"pi_refused": {ID: "pi_refused", Status: stripe.PaymentIntentStatusRequiresPaymentMethod},
+		},
+	}
+
+	mockDB.On("GetOrderByID", "order_ok").Return(&models.Order{OrderID: "order_ok", Status: "completed"}, nil)
+	mockDB.On("GetOrderByID", "order_pending").Return(&models.Order{OrderID: "order_pending", Status: "pending", Price: models.MoneyFromFloat(10)}, nil)
+	mockDB.On("GetOrderByID", "order_missing").Return(nil, errors.New("not found"))
+	mockDB.On("GetCompletedOrdersBetween", from, to).Return([]models.Order{
+		{OrderID: "order_ok", Status: "completed", PaymentIntentID: "pi_ok"},
+		// Paid before the range started
+		{OrderID: "order_old", Status: "completed", PaymentIntentID: "pi_old"},
+		{OrderID: "order_unpaid", Status: "completed"},
+		{OrderID: "order_refused", Status: "completed", PaymentIntentID: "pi_refused"},
+		// Comped by an admin, so no payment is expected
+		{OrderID: "order_comped", Status: "completed", IsComped: true},
+	}, nil)
+
+	report, err := orderSvc.ReconcilePayments(context.Background(), from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, report.IntentsChecked)
+	assert.Equal(t, 5, report.OrdersChecked)
+
+	byOrder := make(map[string]string)
+	for _, d := range report.Discrepancies {
+		byOrder[d.OrderID] = d.Type
+	}
+	assert.Equal(t, map[string]string{
+		"order_pending": order.DiscrepancyPaidNotCompleted,
+		"order_missing": order.DiscrepancyPaidNotCompleted,
+		"order_unpaid":  order.DiscrepancyCompletedWithoutPayment,
+		"order_refused": order.DiscrepancyCompletedWithoutPayment,
+	}, byOrder)
+
+	mockDB.AssertNotCalled(t, "GetOrderByID", "order_failed")
+}
+
+func TestReconcilePaymentsRejectsBadRange(t *testing.T) {
+	orderSvc := order.NewOrderService(new(MockDBLayer), new(MockRedisLock), kafka.NewInMemoryProducer(), &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+	orderSvc.PaymentIntents = &fakeIntentSource{}
+
+	now := time.Now()
+	_, err := orderSvc.ReconcilePayments(context.Background(), now, now.Add(-time.Hour))
+	assert.Error(t, err)
+
+	_, err = orderSvc.ReconcilePayments(context.Background(), now, now.Add(order.MaxReconcileRange+time.Hour))
+	assert.Error(t, err)
+}
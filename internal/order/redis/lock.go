@@ -2,24 +2,28 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"log"
 	kafka "ms-ticketing/internal/kafka"
+	"ms-ticketing/internal/models"
 
 	"github.com/go-redis/redis/v8"
 )
 
 type Redis struct {
-	Client   *redis.Client
+	Client   redis.UniversalClient
 	Producer *kafka.Producer
 	Logger   *log.Logger
 }
 
-func NewRedis(client *redis.Client, producer *kafka.Producer) *Redis {
+func NewRedis(client redis.UniversalClient, producer *kafka.Producer) *Redis {
 	return &Redis{
 		Client:   client,
 		Producer: producer,
@@ -51,6 +55,37 @@ func (r *Redis) getSeatLockDuration() time.Duration {
 	return time.Duration(lockTTLMin) * time.Minute
 }
 
+// SeatLockTTL returns the configured seat lock TTL without logging, for callers outside the lock path
+func SeatLockTTL() time.Duration {
+	lockTTLMin, err := strconv.Atoi(os.Getenv("SEAT_LOCK_TTL_MINUTES"))
+	if err != nil || lockTTLMin <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(lockTTLMin) * time.Minute
+}
+
+// MarkOrderReminded records that a payment reminder was sent for an order.
+// It returns false if the order had already been marked.
+func (r *Redis) MarkOrderReminded(orderID string, ttl time.Duration) (bool, error) {
+	key := "order_reminder:" + orderID
+	ok, err := r.Client.SetNX(context.Background(), key, time.Now().Unix(), ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark order %s as reminded: %w", orderID, err)
+	}
+	return ok, nil
+}
+
+// MarkConfirmationResent starts the resend cooldown for an order's confirmation.
+// It returns false while a previous resend is still cooling down.
+func (r *Redis) MarkConfirmationResent(orderID string, cooldown time.Duration) (bool, error) {
+	key := "order_resend:" + orderID
+	ok, err := r.Client.SetNX(context.Background(), key, time.Now().Unix(), cooldown).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark confirmation resent for order %s: %w", orderID, err)
+	}
+	return ok, nil
+}
+
 // CheckSeatAvailability checks if a seat is available (not locked) without locking it
 func (r *Redis) CheckSeatAvailability(seatID string) (bool, error) {
 	key := "seat_lock:" + seatID
@@ -67,16 +102,34 @@ func (r *Redis) CheckSeatAvailability(seatID string) (bool, error) {
 	return false, nil
 }
 
-// CheckSeatsAvailability checks if multiple seats are available without locking them
+// CheckSeatsAvailability checks if multiple seats are available without locking them.
+// All lock keys are read with a single MGET so large seat maps cost one round-trip.
 func (r *Redis) CheckSeatsAvailability(seatIDs []string) (bool, []string, error) {
+	if len(seatIDs) == 0 {
+		return true, nil, nil
+	}
+
+	keys := make([]string, len(seatIDs))
+	for i, seatID := range seatIDs {
+		keys[i] = "seat_lock:" + seatID
+	}
+
+	var values []interface{}
+	var err error
+	if r.isCluster() {
+		values, err = r.getEach(context.Background(), keys)
+	} else {
+		values, err = r.Client.MGet(context.Background(), keys...).Result()
+	}
+	if err != nil {
+		return false, nil, err
+	}
+
 	unavailableSeats := []string{}
-	for _, seatID := range seatIDs {
-		available, err := r.CheckSeatAvailability(seatID)
-		if err != nil {
-			return false, nil, err
-		}
-		if !available {
-			unavailableSeats = append(unavailableSeats, seatID)
+	for i, value := range values {
+		// MGET returns nil for keys that don't exist, i.e. unlocked seats
+		if value != nil {
+			unavailableSeats = append(unavailableSeats, seatIDs[i])
 		}
 	}
 	if len(unavailableSeats) > 0 {
@@ -85,15 +138,93 @@ func (r *Redis) CheckSeatsAvailability(seatIDs []string) (bool, []string, error)
 	return true, nil, nil
 }
 
+// isCluster reports whether the client talks to a Redis cluster, where a multi-key command or script
+// fails unless all its keys hash to the same slot, which seat lock keys don't
+func (r *Redis) isCluster() bool {
+	_, ok := r.Client.(*redis.ClusterClient)
+	return ok
+}
+
+// getEach reads keys with one pipelined GET per key, the cluster-safe form of MGET.
+// Missing keys come back as nil like they do from MGET.
+func (r *Redis) getEach(ctx context.Context, keys []string) ([]interface{}, error) {
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err := r.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Get(ctx, key)
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(keys))
+	for i, cmd := range cmds {
+		value, err := cmd.Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// SeatIDFromLockKey extracts the seat ID from a seat_lock:{seatID} key, e.g. one reported by a keyspace expiry event
+func SeatIDFromLockKey(key string) (string, bool) {
+	if !strings.HasPrefix(key, "seat_lock:") {
+		return "", false
+	}
+	seatID := strings.TrimPrefix(key, "seat_lock:")
+	return seatID, seatID != ""
+}
+
+// sessionLocksKey names the set of seat IDs locked by a session's orders. It lets a session's
+// held seats be listed with SMEMBERS instead of a scan over every seat_lock key.
+func sessionLocksKey(sessionID string) string {
+	return "session_locks:" + sessionID
+}
+
+// encodeSeatLock serializes the lock metadata stored as the seat_lock value
+func encodeSeatLock(sessionID, orderID, userID string) (string, error) {
+	value, err := json.Marshal(models.SeatLock{
+		SessionID: sessionID,
+		OrderID:   orderID,
+		UserID:    userID,
+		LockedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode seat lock for order %s: %w", orderID, err)
+	}
+	return string(value), nil
+}
+
+// decodeSeatLock parses a seat_lock value. Locks taken before the value became JSON hold
+// just the order ID, so anything that isn't a JSON object is treated as one.
+func decodeSeatLock(value string) models.SeatLock {
+	var lock models.SeatLock
+	if strings.HasPrefix(value, "{") && json.Unmarshal([]byte(value), &lock) == nil && lock.OrderID != "" {
+		return lock
+	}
+	return models.SeatLock{OrderID: value}
+}
+
 // Lock a single seat
-func (r *Redis) LockSeat(seatID, orderID string) (bool, error) {
+func (r *Redis) LockSeat(seatID, orderID, userID string) (bool, error) {
 	key := "seat_lock:" + seatID
+	value, err := encodeSeatLock("", orderID, userID)
+	if err != nil {
+		return false, err
+	}
 	lockDuration := r.getSeatLockDuration()
-	ok, err := r.Client.SetNX(context.Background(), key, orderID, lockDuration).Result()
+	ok, err := r.Client.SetNX(context.Background(), key, value, lockDuration).Result()
 	return ok, err
 }
 
-// Unlock a single seat
+// Unlock a single seat, only if it is still held by the given order
 func (r *Redis) UnlockSeat(seatID, orderID string) error {
 	ctx := context.Background()
 	key := fmt.Sprintf("seat_lock:%s", seatID)
@@ -104,37 +235,408 @@ func (r *Redis) UnlockSeat(seatID, orderID string) error {
 	if err != nil {
 		return err
 	}
-	if val == orderID {
-		_, err := r.Client.Del(ctx, key).Result()
+	lock := decodeSeatLock(val)
+	if lock.OrderID != orderID {
+		return nil
+	}
+	if _, err := r.Client.Del(ctx, key).Result(); err != nil {
 		return err
 	}
+	r.untrackSessionSeats(ctx, lock.SessionID, seatID)
 	return nil
 }
 
-// Lock multiple seats atomically
-func (r *Redis) LockSeats(seatIDs []string, orderID string) (bool, error) {
-	locked := []string{}
+// GetSeatLock returns the lock metadata for a seat and the lock's remaining TTL.
+// A nil lock means the seat is not locked.
+func (r *Redis) GetSeatLock(seatID string) (*models.SeatLock, time.Duration, error) {
+	ctx := context.Background()
+	key := "seat_lock:" + seatID
+
+	var getCmd *redis.StringCmd
+	var ttlCmd *redis.DurationCmd
+	_, err := r.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		getCmd = pipe.Get(ctx, key)
+		ttlCmd = pipe.TTL(ctx, key)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, 0, err
+	}
+
+	value, err := getCmd.Result()
+	if err == redis.Nil {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	lock := decodeSeatLock(value)
+	return &lock, ttlCmd.Val(), nil
+}
+
+// ForceUnlockSeat deletes a seat lock regardless of which order holds it and returns the previous holder's order ID.
+// It is meant for operators clearing stuck seats; regular flows must use UnlockSeat.
+func (r *Redis) ForceUnlockSeat(seatID string) (string, error) {
+	ctx := context.Background()
+	key := "seat_lock:" + seatID
+
+	// Read and delete in one MULTI so a lock taken in between isn't removed by mistake
+	var getCmd *redis.StringCmd
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		getCmd = pipe.Get(ctx, key)
+		pipe.Del(ctx, key)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return "", err
+	}
+
+	value, err := getCmd.Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	lock := decodeSeatLock(value)
+	r.untrackSessionSeats(ctx, lock.SessionID, seatID)
+	return lock.OrderID, nil
+}
+
+// lockSeatsScript locks every key in KEYS with the value ARGV[1] and a TTL of ARGV[2] milliseconds, or none of them
+// if any is already held. A TTL of 0 keeps the locks until they are deleted. Redis runs scripts atomically,
+// so no other client can slip in between the check and the set.
+var lockSeatsScript = redis.NewScript(`
+for _, key in ipairs(KEYS) do
+	if redis.call("EXISTS", key) == 1 then
+		return 0
+	end
+end
+local ttl = tonumber(ARGV[2])
+for _, key in ipairs(KEYS) do
+	if ttl > 0 then
+		redis.call("SET", key, ARGV[1], "PX", ttl)
+	else
+		redis.call("SET", key, ARGV[1])
+	end
+end
+return 1
+`)
+
+// LockSeatsAtomic locks all seats for the order in a single server-side Lua script and adds them to
+// the session's lock set. It returns false without locking anything if any seat is already locked.
+func (r *Redis) LockSeatsAtomic(seatIDs []string, sessionID, orderID, userID string) (bool, error) {
+	if len(seatIDs) == 0 {
+		return true, nil
+	}
+
+	value, err := encodeSeatLock(sessionID, orderID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	keys := make([]string, len(seatIDs))
+	for i, seatID := range seatIDs {
+		keys[i] = "seat_lock:" + seatID
+	}
+
+	ttl := r.getSeatLockDuration()
+	ok, err := r.runLockSeats(keys, value, ttl)
+	if err != nil || !ok {
+		return ok, err
+	}
+	r.trackSessionSeats(context.Background(), sessionID, seatIDs, ttl)
+	return true, nil
+}
+
+// trackSessionSeats adds locked seats to the session's lock set. The set's own TTL is pushed out to the
+// newest lock's, so it can't outlive its locks by more than one lock duration even if removals are missed.
+// The seats are already locked at this point, so a failure only costs the held-seat listing and is logged.
+func (r *Redis) trackSessionSeats(ctx context.Context, sessionID string, seatIDs []string, ttl time.Duration) {
+	if sessionID == "" {
+		return
+	}
+	key := sessionLocksKey(sessionID)
+	members := make([]interface{}, len(seatIDs))
+	for i, seatID := range seatIDs {
+		members[i] = seatID
+	}
+	_, err := r.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, key, members...)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		r.Logger.Println(fmt.Sprintf("REDIS: Failed to add %d seats to %s: %v", len(seatIDs), key, err))
+	}
+}
+
+// untrackSessionSeats removes released seats from the session's lock set; like trackSessionSeats it only logs failures
+func (r *Redis) untrackSessionSeats(ctx context.Context, sessionID string, seatIDs ...string) {
+	if sessionID == "" || len(seatIDs) == 0 {
+		return
+	}
+	key := sessionLocksKey(sessionID)
+	members := make([]interface{}, len(seatIDs))
+	for i, seatID := range seatIDs {
+		members[i] = seatID
+	}
+	if err := r.Client.SRem(ctx, key, members...).Err(); err != nil {
+		r.Logger.Println(fmt.Sprintf("REDIS: Failed to remove %d seats from %s: %v", len(seatIDs), key, err))
+	}
+}
+
+// UntrackExpiredSeat removes a seat whose lock expired from the session's lock set. The seat stays in
+// the set if it has been locked again since, e.g. relocked for an order still inside its payment window.
+// It reports whether the seat was removed.
+func (r *Redis) UntrackExpiredSeat(sessionID, seatID string) (bool, error) {
+	ctx := context.Background()
+	exists, err := r.Client.Exists(ctx, "seat_lock:"+seatID).Result()
+	if err != nil {
+		return false, err
+	}
+	if exists > 0 {
+		return false, nil
+	}
+	removed, err := r.Client.SRem(ctx, sessionLocksKey(sessionID), seatID).Result()
+	if err != nil {
+		return false, err
+	}
+	return removed > 0, nil
+}
+
+// sessionLocksScanCount is the COUNT hint for each SCAN batch when looking for session lock sets
+const sessionLocksScanCount = 200
+
+// PruneSessionLockSets removes members of every session lock set whose seat_lock key no longer exists,
+// e.g. expiries the keyspace subscriber missed while no instance was listening. The sets are found with
+// SCAN, which walks the keyspace in batches instead of blocking Redis like KEYS; on a cluster each master
+// is scanned. It returns the number of members removed.
+func (r *Redis) PruneSessionLockSets() (int, error) {
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var keys []string
+	scan := func(ctx context.Context, client redis.Cmdable) error {
+		iter := client.Scan(ctx, 0, "session_locks:*", sessionLocksScanCount).Iterator()
+		for iter.Next(ctx) {
+			mu.Lock()
+			keys = append(keys, iter.Val())
+			mu.Unlock()
+		}
+		return iter.Err()
+	}
+
+	var err error
+	if cluster, ok := r.Client.(*redis.ClusterClient); ok {
+		err = cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+			return scan(ctx, master)
+		})
+	} else {
+		err = scan(ctx, r.Client)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan session lock sets: %w", err)
+	}
+
+	pruned := 0
+	for _, key := range keys {
+		removed, err := r.pruneSessionLockSet(ctx, key)
+		if err != nil {
+			return pruned, err
+		}
+		pruned += removed
+	}
+	return pruned, nil
+}
+
+// pruneSessionLockSet removes the set's members whose lock is gone. A seat locked again between the
+// check and the removal is added back, since its LockSeats may have added it before the removal ran.
+func (r *Redis) pruneSessionLockSet(ctx context.Context, key string) (int, error) {
+	seatIDs, err := r.Client.SMembers(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	live, err := r.lockedSeats(ctx, seatIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check locks of %s: %w", key, err)
+	}
+
+	var stale []interface{}
+	var staleIDs []string
 	for _, seatID := range seatIDs {
-		ok, err := r.LockSeat(seatID, orderID)
+		if !live[seatID] {
+			stale = append(stale, seatID)
+			staleIDs = append(staleIDs, seatID)
+		}
+	}
+	if len(stale) == 0 {
+		return 0, nil
+	}
+	if err := r.Client.SRem(ctx, key, stale...).Err(); err != nil {
+		return 0, fmt.Errorf("failed to prune %s: %w", key, err)
+	}
+
+	relocked, err := r.lockedSeats(ctx, staleIDs)
+	if err != nil {
+		return len(stale), fmt.Errorf("failed to recheck locks of %s: %w", key, err)
+	}
+	var readd []interface{}
+	for _, seatID := range staleIDs {
+		if relocked[seatID] {
+			readd = append(readd, seatID)
+		}
+	}
+	if len(readd) > 0 {
+		if err := r.Client.SAdd(ctx, key, readd...).Err(); err != nil {
+			return len(stale), fmt.Errorf("failed to restore relocked seats in %s: %w", key, err)
+		}
+	}
+	return len(stale) - len(readd), nil
+}
+
+// lockedSeats reports which of the seats currently have a seat_lock key, with one pipelined EXISTS per seat
+func (r *Redis) lockedSeats(ctx context.Context, seatIDs []string) (map[string]bool, error) {
+	cmds := make([]*redis.IntCmd, len(seatIDs))
+	_, err := r.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, seatID := range seatIDs {
+			cmds[i] = pipe.Exists(ctx, "seat_lock:"+seatID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	locked := make(map[string]bool, len(seatIDs))
+	for i, seatID := range seatIDs {
+		locked[seatID] = cmds[i].Val() > 0
+	}
+	return locked, nil
+}
+
+// SessionSeatLocks returns the session's current seat locks with their remaining TTL, read from the
+// session's lock set. Members whose lock has already gone are left out.
+func (r *Redis) SessionSeatLocks(sessionID string) ([]models.HeldSeat, error) {
+	ctx := context.Background()
+	seatIDs, err := r.Client.SMembers(ctx, sessionLocksKey(sessionID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seat locks of session %s: %w", sessionID, err)
+	}
+	if len(seatIDs) == 0 {
+		return nil, nil
+	}
+
+	getCmds := make([]*redis.StringCmd, len(seatIDs))
+	ttlCmds := make([]*redis.DurationCmd, len(seatIDs))
+	_, err = r.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, seatID := range seatIDs {
+			getCmds[i] = pipe.Get(ctx, "seat_lock:"+seatID)
+			ttlCmds[i] = pipe.TTL(ctx, "seat_lock:"+seatID)
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read seat locks of session %s: %w", sessionID, err)
+	}
+
+	held := make([]models.HeldSeat, 0, len(seatIDs))
+	for i, seatID := range seatIDs {
+		value, err := getCmds[i].Result()
+		if err == redis.Nil {
+			continue
+		}
 		if err != nil {
-			// Unlock all previously locked seats
-			for _, l := range locked {
-				_ = r.UnlockSeat(l, orderID)
+			return nil, fmt.Errorf("failed to read lock of seat %s: %w", seatID, err)
+		}
+
+		lock := decodeSeatLock(value)
+		seat := models.HeldSeat{SeatID: seatID, OrderID: lock.OrderID, UserID: lock.UserID}
+		if !lock.LockedAt.IsZero() {
+			lockedAt := lock.LockedAt
+			seat.LockedAt = &lockedAt
+		}
+		// TTL reports -1 for keys without an expiry
+		if ttl := ttlCmds[i].Val(); ttl > 0 {
+			seat.TTLSeconds = int64(ttl / time.Second)
+		}
+		held = append(held, seat)
+	}
+	return held, nil
+}
+
+// HoldSeats locks seats for an admin hold with no TTL, all or nothing like LockSeats.
+// The seats stay unavailable until released with UnlockSeats(seatIDs, models.AdminHoldOrderID).
+// Holds are not in any session's lock set, since no buyer is holding them.
+func (r *Redis) HoldSeats(seatIDs []string, userID string) (bool, error) {
+	if len(seatIDs) == 0 {
+		return true, nil
+	}
+
+	value, err := encodeSeatLock("", models.AdminHoldOrderID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	keys := make([]string, len(seatIDs))
+	for i, seatID := range seatIDs {
+		keys[i] = "seat_lock:" + seatID
+	}
+	return r.runLockSeats(keys, value, 0)
+}
+
+// unlockIfValueScript deletes KEYS[1] only while it still holds ARGV[1]
+var unlockIfValueScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func (r *Redis) runLockSeats(keys []string, value string, ttl time.Duration) (bool, error) {
+	if r.isCluster() {
+		return r.lockEach(keys, value, ttl)
+	}
+	res, err := lockSeatsScript.Run(context.Background(), r.Client, keys, value, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+// lockEach is the cluster fallback for lockSeatsScript. Seat keys live in different slots, so they are
+// taken one by one and any already taken are released again if a later seat is held. Unlike the script
+// this is not atomic: a concurrent checker may briefly see some of the seats locked.
+func (r *Redis) lockEach(keys []string, value string, ttl time.Duration) (bool, error) {
+	ctx := context.Background()
+	locked := make([]string, 0, len(keys))
+	release := func() {
+		for _, key := range locked {
+			if err := unlockIfValueScript.Run(ctx, r.Client, []string{key}, value).Err(); err != nil {
+				r.Logger.Println(fmt.Sprintf("REDIS: Failed to release %s after a partial lock: %v", key, err))
 			}
+		}
+	}
+
+	for _, key := range keys {
+		ok, err := r.Client.SetNX(ctx, key, value, ttl).Result()
+		if err != nil {
+			release()
 			return false, err
 		}
 		if !ok {
-			// Unlock all previously locked seats
-			for _, l := range locked {
-				_ = r.UnlockSeat(l, orderID)
-			}
+			release()
 			return false, nil
 		}
-		locked = append(locked, seatID)
+		locked = append(locked, key)
 	}
 	return true, nil
 }
 
+// Lock multiple seats atomically
+func (r *Redis) LockSeats(seatIDs []string, sessionID, orderID, userID string) (bool, error) {
+	return r.LockSeatsAtomic(seatIDs, sessionID, orderID, userID)
+}
+
 // Unlock multiple seats
 func (r *Redis) UnlockSeats(seatIDs []string, orderID string) error {
 	var firstErr error
@@ -0,0 +1,310 @@
+package redis_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"ms-ticketing/internal/models"
+	rediswrap "ms-ticketing/internal/order/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRedis runs the locks against an in-process miniredis, which evaluates the Lua
+// scripts like a real server, so the atomicity tests run without a Redis to connect to.
+func newTestRedis(tb testing.TB) *rediswrap.Redis {
+	tb.Helper()
+
+	server := miniredis.RunT(tb)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	tb.Cleanup(func() { client.Close() })
+	return rediswrap.NewRedis(client, nil)
+}
+
+func seatIDs(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("seat-%d", i)
+	}
+	return ids
+}
+
+func TestCheckSeatsAvailability(t *testing.T) {
+	r := newTestRedis(t)
+
+	ok, err := r.LockSeats([]string{"seat-1", "seat-3"}, "session1", "order1", "user1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	available, unavailable, err := r.CheckSeatsAvailability([]string{"seat-0", "seat-1", "seat-2", "seat-3"})
+	assert.NoError(t, err)
+	assert.False(t, available)
+	assert.Equal(t, []string{"seat-1", "seat-3"}, unavailable)
+
+	available, unavailable, err = r.CheckSeatsAvailability([]string{"seat-0", "seat-2"})
+	assert.NoError(t, err)
+	assert.True(t, available)
+	assert.Empty(t, unavailable)
+
+	available, _, err = r.CheckSeatsAvailability(nil)
+	assert.NoError(t, err)
+	assert.True(t, available)
+}
+
+func TestLockSeatsAtomicAllOrNothing(t *testing.T) {
+	r := newTestRedis(t)
+	ctx := context.Background()
+
+	ok, err := r.LockSeatsAtomic([]string{"seat-2"}, "session1", "order1", "user1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// seat-2 is taken, so none of the other seats may be left locked by order2
+	ok, err = r.LockSeatsAtomic([]string{"seat-1", "seat-2", "seat-3"}, "session1", "order2", "user2")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	for _, seatID := range []string{"seat-1", "seat-3"} {
+		exists, err := r.Client.Exists(ctx, "seat_lock:"+seatID).Result()
+		assert.NoError(t, err)
+		assert.Zero(t, exists, "seat %s was partially locked", seatID)
+	}
+
+	holder, ttl, err := r.GetSeatLock("seat-2")
+	assert.NoError(t, err)
+	assert.Equal(t, "order1", holder.OrderID)
+	assert.Greater(t, ttl.Seconds(), 0.0)
+}
+
+func TestLockSeatsAtomicConcurrent(t *testing.T) {
+	r := newTestRedis(t)
+
+	// Every order wants an overlapping window of seats; each must end up with all of them or none
+	seats := seatIDs(20)
+	const orders = 16
+	won := make([]bool, orders)
+	var wg sync.WaitGroup
+	for i := 0; i < orders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			start := i % 15
+			ok, err := r.LockSeatsAtomic(seats[start:start+5], "session1", fmt.Sprintf("order%d", i), "user")
+			assert.NoError(t, err)
+			won[i] = ok
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < orders; i++ {
+		orderID := fmt.Sprintf("order%d", i)
+		start := i % 15
+		held := 0
+		for _, seatID := range seats[start : start+5] {
+			lock, _, err := r.GetSeatLock(seatID)
+			if err == nil && lock != nil && lock.OrderID == orderID {
+				held++
+			}
+		}
+		if won[i] {
+			assert.Equal(t, 5, held, "%s won but doesn't hold all its seats", orderID)
+		} else {
+			assert.Zero(t, held, "%s lost but holds %d seats", orderID, held)
+		}
+	}
+}
+
+func TestSeatLockMetadata(t *testing.T) {
+	r := newTestRedis(t)
+	ctx := context.Background()
+
+	before := time.Now().Add(-time.Second)
+	ok, err := r.LockSeats([]string{"seat-1"}, "session1", "order1", "user1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	lock, _, err := r.GetSeatLock("seat-1")
+	assert.NoError(t, err)
+	if assert.NotNil(t, lock) {
+		assert.Equal(t, "order1", lock.OrderID)
+		assert.Equal(t, "user1", lock.UserID)
+		assert.True(t, lock.LockedAt.After(before))
+	}
+
+	// Ownership is still checked by order ID
+	assert.NoError(t, r.UnlockSeats([]string{"seat-1"}, "order2"))
+	lock, _, _ = r.GetSeatLock("seat-1")
+	assert.NotNil(t, lock)
+	assert.NoError(t, r.UnlockSeats([]string{"seat-1"}, "order1"))
+	lock, _, _ = r.GetSeatLock("seat-1")
+	assert.Nil(t, lock)
+
+	// Locks written before the JSON format held only the order ID
+	assert.NoError(t, r.Client.Set(ctx, "seat_lock:seat-2", "legacy-order", time.Minute).Err())
+	lock, _, err = r.GetSeatLock("seat-2")
+	assert.NoError(t, err)
+	assert.Equal(t, "legacy-order", lock.OrderID)
+	assert.NoError(t, r.UnlockSeats([]string{"seat-2"}, "legacy-order"))
+	lock, _, _ = r.GetSeatLock("seat-2")
+	assert.Nil(t, lock)
+}
+
+func TestHoldSeatsHasNoTTL(t *testing.T) {
+	r := newTestRedis(t)
+
+	ok, err := r.HoldSeats([]string{"seat-1", "seat-2"}, "admin1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	lock, ttl, err := r.GetSeatLock("seat-1")
+	assert.NoError(t, err)
+	if assert.NotNil(t, lock) {
+		assert.Equal(t, models.AdminHoldOrderID, lock.OrderID)
+		assert.Equal(t, "admin1", lock.UserID)
+	}
+	assert.Equal(t, time.Duration(-1), ttl, "held seats never expire")
+
+	// Held seats can't be locked by an order, and a hold can't take a locked seat
+	ok, err = r.LockSeats([]string{"seat-2", "seat-3"}, "session1", "order1", "user1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = r.LockSeats([]string{"seat-3"}, "session1", "order1", "user1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = r.HoldSeats([]string{"seat-3"}, "admin1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, r.UnlockSeats([]string{"seat-1", "seat-2"}, models.AdminHoldOrderID))
+	available, _, err := r.CheckSeatsAvailability([]string{"seat-1", "seat-2"})
+	assert.NoError(t, err)
+	assert.True(t, available)
+}
+
+func TestSessionSeatLocks(t *testing.T) {
+	r := newTestRedis(t)
+	ctx := context.Background()
+
+	ok, err := r.LockSeats([]string{"seat-1", "seat-2"}, "session1", "order1", "user1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = r.LockSeats([]string{"seat-3"}, "session2", "order2", "user2")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = r.HoldSeats([]string{"seat-4"}, "admin1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	members, err := r.Client.SMembers(ctx, "session_locks:session1").Result()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"seat-1", "seat-2"}, members)
+	ttl, err := r.Client.TTL(ctx, "session_locks:session1").Result()
+	assert.NoError(t, err)
+	assert.Greater(t, ttl, time.Duration(0), "the set expires with its locks")
+
+	held, err := r.SessionSeatLocks("session1")
+	assert.NoError(t, err)
+	if assert.Len(t, held, 2) {
+		for _, seat := range held {
+			assert.Equal(t, "order1", seat.OrderID)
+			assert.Equal(t, "user1", seat.UserID)
+			assert.NotNil(t, seat.LockedAt)
+			assert.Greater(t, seat.TTLSeconds, int64(0))
+		}
+	}
+
+	// Unlocking and force-unlocking take seats out of the set
+	assert.NoError(t, r.UnlockSeats([]string{"seat-1"}, "order1"))
+	_, err = r.ForceUnlockSeat("seat-3")
+	assert.NoError(t, err)
+	members, _ = r.Client.SMembers(ctx, "session_locks:session1").Result()
+	assert.Equal(t, []string{"seat-2"}, members)
+	members, _ = r.Client.SMembers(ctx, "session_locks:session2").Result()
+	assert.Empty(t, members)
+
+	// A member whose lock is gone is not reported, and expiry cleanup keeps seats locked again
+	r.Client.Del(ctx, "seat_lock:seat-2")
+	held, err = r.SessionSeatLocks("session1")
+	assert.NoError(t, err)
+	assert.Empty(t, held)
+	ok, err = r.LockSeats([]string{"seat-5"}, "session1", "order3", "user3")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	removed, err := r.UntrackExpiredSeat("session1", "seat-5")
+	assert.NoError(t, err)
+	assert.False(t, removed)
+	removed, err = r.UntrackExpiredSeat("session1", "seat-2")
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	members, _ = r.Client.SMembers(ctx, "session_locks:session1").Result()
+	assert.Equal(t, []string{"seat-5"}, members)
+}
+
+func TestPruneSessionLockSets(t *testing.T) {
+	r := newTestRedis(t)
+	ctx := context.Background()
+
+	ok, err := r.LockSeats([]string{"seat-1", "seat-2"}, "session1", "order1", "user1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = r.LockSeats([]string{"seat-3"}, "session2", "order2", "user2")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// Expiries nobody removed from the sets
+	r.Client.Del(ctx, "seat_lock:seat-2", "seat_lock:seat-3")
+
+	pruned, err := r.PruneSessionLockSets()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pruned)
+	members, _ := r.Client.SMembers(ctx, "session_locks:session1").Result()
+	assert.Equal(t, []string{"seat-1"}, members)
+	exists, _ := r.Client.Exists(ctx, "session_locks:session2").Result()
+	assert.Zero(t, exists, "a set left empty is gone")
+
+	pruned, err = r.PruneSessionLockSets()
+	assert.NoError(t, err)
+	assert.Zero(t, pruned)
+}
+
+func TestSeatIDFromLockKey(t *testing.T) {
+	seatID, ok := rediswrap.SeatIDFromLockKey("seat_lock:7c9e6679-7425-40de-944b-e07fc1f90ae7")
+	assert.True(t, ok)
+	assert.Equal(t, "7c9e6679-7425-40de-944b-e07fc1f90ae7", seatID)
+
+	for _, key := range []string{"order_reminder:order1", "seat_lock:", "lock:seat_unlock:seat1"} {
+		_, ok := rediswrap.SeatIDFromLockKey(key)
+		assert.False(t, ok, key)
+	}
+}
+
+// BenchmarkCheckSeatsAvailability compares one GET per seat with the single MGET for a 200-seat map
+func BenchmarkCheckSeatsAvailability(b *testing.B) {
+	r := newTestRedis(b)
+	seats := seatIDs(200)
+	if _, err := r.LockSeats(seats[:50], "session1", "order1", "user1"); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("PerKeyGET", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, seatID := range seats {
+				if _, err := r.CheckSeatAvailability(seatID); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("MGET", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, _, err := r.CheckSeatsAvailability(seats); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
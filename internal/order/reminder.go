@@ -0,0 +1,108 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"ms-ticketing/internal/kafka"
+	"ms-ticketing/internal/models"
+	"time"
+)
+
+// OrderReminderEvent is published to ticketly.order.reminder so the notification
+// service can nudge users who reserved seats but have not started paying yet
+type OrderReminderEvent struct {
+	OrderID        string       `json:"order_id"`
+	UserID         string       `json:"user_id"`
+	EventID        string       `json:"event_id"`
+	SessionID      string       `json:"session_id"`
+	OrganizationID string       `json:"organization_id"`
+	Price          models.Money `json:"price"`
+	CreatedAt      time.Time    `json:"created_at"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+}
+
+// SendPaymentReminders publishes a reminder for every pending order that was created
+// at least remindAfter ago, has no payment intent yet and whose seat lock is still alive.
+// Each order is reminded at most once. It returns the number of reminders published.
+func (s *OrderService) SendPaymentReminders(ctx context.Context, remindAfter, lockTTL time.Duration) (int, error) {
+	if remindAfter >= lockTTL {
+		return 0, fmt.Errorf("reminder delay %s must be shorter than seat lock TTL %s", remindAfter, lockTTL)
+	}
+
+	now := time.Now()
+	orders, err := s.DB.GetUnpaidPendingOrders(ctx, now.Add(-lockTTL), now.Add(-remindAfter))
+	if err != nil {
+		s.logger.Error("REMINDER", fmt.Sprintf("Failed to fetch unpaid pending orders: %v", err))
+		return 0, fmt.Errorf("failed to fetch unpaid pending orders: %w", err)
+	}
+
+	sent := 0
+	for _, order := range orders {
+		// The dedup marker only needs to outlive the seat lock; after that the order no longer qualifies
+		firstTime, err := s.Redis.MarkOrderReminded(order.OrderID, lockTTL)
+		if err != nil {
+			s.logger.Warn("REMINDER", fmt.Sprintf("Skipping reminder for order %s: %v", order.OrderID, err))
+			continue
+		}
+		if !firstTime {
+			continue
+		}
+
+		event := OrderReminderEvent{
+			OrderID:        order.OrderID,
+			UserID:         order.UserID,
+			EventID:        order.EventID,
+			SessionID:      order.SessionID,
+			OrganizationID: order.OrganizationID,
+			Price:          order.Price,
+			CreatedAt:      order.CreatedAt,
+			ExpiresAt:      order.CreatedAt.Add(lockTTL),
+		}
+		if err := s.publishOrderReminder(event); err != nil {
+			continue
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		s.logger.Info("REMINDER", fmt.Sprintf("Published %d payment reminders", sent))
+	}
+	return sent, nil
+}
+
+// StartPaymentReminders runs SendPaymentReminders every interval until ctx is cancelled
+func (s *OrderService) StartPaymentReminders(ctx context.Context, interval, remindAfter, lockTTL time.Duration) {
+	s.logger.Info("REMINDER", fmt.Sprintf("Payment reminders enabled: after %s, checking every %s", remindAfter, interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("REMINDER", "Stopping payment reminder loop")
+			return
+		case <-ticker.C:
+			if _, err := s.SendPaymentReminders(ctx, remindAfter, lockTTL); err != nil {
+				s.logger.Error("REMINDER", fmt.Sprintf("Payment reminder run failed: %v", err))
+			}
+		}
+	}
+}
+
+func (s *OrderService) publishOrderReminder(event OrderReminderEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Error("KAFKA", fmt.Sprintf("Failed to marshal order reminder event: %v", err))
+		return fmt.Errorf("failed to marshal order reminder event: %w", err)
+	}
+
+	err = s.Kafka.PublishWithHeaders("ticketly.order.reminder", event.OrderID, payload, kafka.EventHeaders(EventTypeOrderReminder, event.OrderID))
+	if err != nil {
+		s.logger.Error("KAFKA", fmt.Sprintf("Failed to publish order reminder event: %v", err))
+	} else {
+		s.logger.Info("KAFKA", fmt.Sprintf("Published order reminder event for order: %s", event.OrderID))
+	}
+	return err
+}
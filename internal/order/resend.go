@@ -0,0 +1,82 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/kafka"
+	"ms-ticketing/internal/models"
+	"os"
+	"strconv"
+	"time"
+)
+
+// OrderResendTopic receives orders whose confirmation should be sent again by the notification service
+const OrderResendTopic = "ticketly.order.resend"
+
+// DefaultResendCooldown is the minimum time between two confirmation resends of the same order
+const DefaultResendCooldown = 5 * time.Minute
+
+var (
+	ErrNotOrderOwner     = errors.New("order does not belong to the requesting user")
+	ErrOrderNotCompleted = errors.New("only completed orders can be resent")
+	ErrResendTooSoon     = errors.New("confirmation was resent recently, please try again later")
+)
+
+// ResendCooldown returns the resend cooldown from ORDER_RESEND_COOLDOWN_MINUTES or the default
+func ResendCooldown() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("ORDER_RESEND_COOLDOWN_MINUTES"))
+	if err != nil || minutes <= 0 {
+		return DefaultResendCooldown
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// ResendConfirmation republishes a completed order with its tickets so the confirmation is sent again.
+// Resends are limited to one per order per cooldown.
+func (s *OrderService) ResendConfirmation(ctx context.Context, orderID, userID string) error {
+	order, err := s.DB.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("order %s not found: %w", orderID, err)
+	}
+
+	if order.UserID != userID {
+		s.logger.Warn("ORDER", fmt.Sprintf("User %s attempted to resend confirmation for order %s", userID, orderID))
+		return ErrNotOrderOwner
+	}
+	if order.Status != "completed" {
+		return ErrOrderNotCompleted
+	}
+
+	allowed, err := s.Redis.MarkConfirmationResent(orderID, ResendCooldown())
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrResendTooSoon
+	}
+
+	orderWithTickets, err := s.GetOrderWithTickets(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("could not get tickets for order %s: %w", orderID, err)
+	}
+
+	return s.publishOrderResend(*orderWithTickets)
+}
+
+func (s *OrderService) publishOrderResend(orderWithTickets models.OrderWithTickets) error {
+	payload, err := json.Marshal(orderWithTickets)
+	if err != nil {
+		s.logger.Error("KAFKA", fmt.Sprintf("Failed to marshal order resend event: %v", err))
+		return fmt.Errorf("failed to marshal order resend event: %w", err)
+	}
+
+	err = s.Kafka.PublishWithHeaders(OrderResendTopic, orderWithTickets.OrderID, payload, kafka.EventHeaders(EventTypeOrderResend, orderWithTickets.OrderID))
+	if err != nil {
+		s.logger.Error("KAFKA", fmt.Sprintf("Failed to publish order resend event: %v", err))
+	} else {
+		s.logger.Info("KAFKA", fmt.Sprintf("Published order resend event for order: %s", orderWithTickets.OrderID))
+	}
+	return err
+}
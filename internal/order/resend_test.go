@@ -0,0 +1,42 @@
+package order_test
+
+import (
+	"context"
+	"ms-ticketing/internal/kafka"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	tickets "ms-ticketing/internal/tickets/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResendConfirmation(t *testing.T) {
+	mockDB := new(MockDBLayer)
+	mockRedis := new(MockRedisLock)
+	mockKafka := kafka.NewInMemoryProducer()
+	ts := &tickets.TicketService{DB: NewMockTicketService().DB}
+	orderSvc := order.NewOrderService(mockDB, mockRedis, mockKafka, ts, NewMockHTTPClient())
+
+	completed := &models.Order{OrderID: "order1", UserID: "user1", Status: "completed"}
+	pending := &models.Order{OrderID: "order2", UserID: "user1", Status: "pending"}
+	mockDB.On("GetOrderByID", "order1").Return(completed, nil)
+	mockDB.On("GetOrderByID", "order2").Return(pending, nil)
+	ts.DB.(*MockTicketDBLayer).On("GetTicketsByOrder", "order1").Return([]models.Ticket{{TicketID: "ticket1", OrderID: "order1"}}, nil)
+
+	// First resend goes out, the second one within the cooldown is rejected
+	mockRedis.On("MarkConfirmationResent", "order1", order.ResendCooldown()).Return(true, nil).Once()
+	mockRedis.On("MarkConfirmationResent", "order1", order.ResendCooldown()).Return(false, nil).Once()
+
+	assert.NoError(t, orderSvc.ResendConfirmation(context.Background(), "order1", "user1"))
+	assert.ErrorIs(t, orderSvc.ResendConfirmation(context.Background(), "order1", "user1"), order.ErrResendTooSoon)
+
+	// Ownership and status are checked before the cooldown is consumed
+	assert.ErrorIs(t, orderSvc.ResendConfirmation(context.Background(), "order1", "someone-else"), order.ErrNotOrderOwner)
+	assert.ErrorIs(t, orderSvc.ResendConfirmation(context.Background(), "order2", "user1"), order.ErrOrderNotCompleted)
+
+	mockRedis.AssertExpectations(t)
+	if resent := mockKafka.MessagesOn(order.OrderResendTopic); assert.Len(t, resent, 1) {
+		assert.Equal(t, "order1", resent[0].Key)
+	}
+}
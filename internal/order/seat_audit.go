@@ -0,0 +1,75 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultSeatAuditLimit = 100
+	MaxSeatAuditLimit     = 1000
+)
+
+// ErrSeatAuditFilterRequired is returned when the audit trail is queried without a seat or session
+var ErrSeatAuditFilterRequired = errors.New("seat_id or session_id is required")
+
+// SeatAuditLog stores the seat lock trail
+type SeatAuditLog interface {
+	CreateSeatAuditEvents(ctx context.Context, events []models.SeatAuditEvent) error
+	GetSeatAuditEvents(ctx context.Context, seatID, sessionID string, limit int) ([]models.SeatAuditEvent, error)
+}
+
+// SetSeatAuditLog enables the seat lock audit trail
+func (s *OrderService) SetSeatAuditLog(log SeatAuditLog) {
+	s.SeatAudit = log
+}
+
+// NewSeatAuditEvents builds one audit row per seat for a lock state change
+func NewSeatAuditEvents(action, sessionID, orderID string, seatIDs []string) []models.SeatAuditEvent {
+	now := time.Now().UTC()
+	events := make([]models.SeatAuditEvent, 0, len(seatIDs))
+	for _, seatID := range seatIDs {
+		events = append(events, models.SeatAuditEvent{
+			EventID:   uuid.New().String(),
+			SeatID:    seatID,
+			OrderID:   orderID,
+			SessionID: sessionID,
+			Action:    action,
+			CreatedAt: now,
+		})
+	}
+	return events
+}
+
+// auditSeats records a lock state change. The trail is best effort: a failed write is logged
+// but never fails the booking flow that triggered it.
+func (s *OrderService) auditSeats(ctx context.Context, action, sessionID, orderID string, seatIDs []string) {
+	if s.SeatAudit == nil || len(seatIDs) == 0 {
+		return
+	}
+	if err := s.SeatAudit.CreateSeatAuditEvents(ctx, NewSeatAuditEvents(action, sessionID, orderID, seatIDs)); err != nil {
+		s.logger.Error("AUDIT", fmt.Sprintf("Failed to record %s for %d seats of order %s: %v", action, len(seatIDs), orderID, err))
+	}
+}
+
+// GetSeatAuditEvents returns the most recent lock events for a seat or a session
+func (s *OrderService) GetSeatAuditEvents(ctx context.Context, seatID, sessionID string, limit int) ([]models.SeatAuditEvent, error) {
+	if seatID == "" && sessionID == "" {
+		return nil, ErrSeatAuditFilterRequired
+	}
+	if s.SeatAudit == nil {
+		return []models.SeatAuditEvent{}, nil
+	}
+	if limit <= 0 {
+		limit = DefaultSeatAuditLimit
+	}
+	if limit > MaxSeatAuditLimit {
+		limit = MaxSeatAuditLimit
+	}
+	return s.SeatAudit.GetSeatAuditEvents(ctx, seatID, sessionID, limit)
+}
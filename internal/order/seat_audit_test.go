@@ -0,0 +1,54 @@
+package order_test
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	"ms-ticketing/internal/order/db"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeatLocksAreAudited(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(2)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	ctx := context.Background()
+
+	_, err := env.DB.NewCreateTable().Model((*models.SeatAuditEvent)(nil)).Exec(ctx)
+	require.NoError(t, err)
+	env.OrderService.SetSeatAuditLog(&db.DB{Bun: env.DB})
+
+	sessionID := uuid.NewString()
+	resp, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+		SessionID: sessionID,
+		EventID:   "event-1",
+		SeatIDs:   seatIDs,
+	})
+	require.NoError(t, err)
+
+	events, err := env.OrderService.GetSeatAuditEvents(ctx, "", sessionID, 0)
+	require.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		for _, ev := range events {
+			assert.Equal(t, models.SeatAuditLocked, ev.Action)
+			assert.Equal(t, resp.OrderID, ev.OrderID)
+		}
+	}
+
+	require.NoError(t, env.OrderService.CancelOrder(ctx, resp.OrderID))
+
+	events, err = env.OrderService.GetSeatAuditEvents(ctx, seatIDs[0], "", 0)
+	require.NoError(t, err)
+	actions := make([]string, 0, len(events))
+	for _, ev := range events {
+		assert.Equal(t, seatIDs[0], ev.SeatID)
+		actions = append(actions, ev.Action)
+	}
+	assert.ElementsMatch(t, []string{models.SeatAuditLocked, models.SeatAuditUnlocked}, actions)
+
+	_, err = env.OrderService.GetSeatAuditEvents(ctx, "", "", 0)
+	assert.ErrorIs(t, err, order.ErrSeatAuditFilterRequired)
+}
@@ -0,0 +1,178 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"ms-ticketing/internal/models"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultSeatExpiryBatchWindow is how long expired seat locks are collected before a batch is processed
+	DefaultSeatExpiryBatchWindow = 500 * time.Millisecond
+	// DefaultSeatExpiryBatchSize processes a batch early once this many seats are waiting
+	DefaultSeatExpiryBatchSize = 500
+)
+
+// SeatExpiryBatchWindow returns the collection window from SEAT_EXPIRY_BATCH_WINDOW_MS or the default.
+// Zero processes every expired seat on its own.
+func SeatExpiryBatchWindow() time.Duration {
+	ms, err := strconv.Atoi(os.Getenv("SEAT_EXPIRY_BATCH_WINDOW_MS"))
+	if err != nil || ms < 0 {
+		return DefaultSeatExpiryBatchWindow
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// SeatExpiryBatchSize returns the batch size cap from SEAT_EXPIRY_BATCH_SIZE or the default
+func SeatExpiryBatchSize() int {
+	size, err := strconv.Atoi(os.Getenv("SEAT_EXPIRY_BATCH_SIZE"))
+	if err != nil || size < 1 {
+		return DefaultSeatExpiryBatchSize
+	}
+	return size
+}
+
+// BatchSeatIDs groups seat IDs read from in and hands each group to flush. A batch is flushed once
+// window has passed since its first seat or once it holds maxSize seats, whichever comes first.
+// Duplicates within a batch are dropped. It returns after in is closed and the last batch is flushed.
+func BatchSeatIDs(in <-chan string, window time.Duration, maxSize int, flush func(seatIDs []string)) {
+	var batch []string
+	seen := map[string]bool{}
+	var timer <-chan time.Time
+
+	emit := func() {
+		if len(batch) > 0 {
+			flush(batch)
+		}
+		batch, seen, timer = nil, map[string]bool{}, nil
+	}
+
+	for {
+		select {
+		case seatID, ok := <-in:
+			if !ok {
+				emit()
+				return
+			}
+			if seen[seatID] {
+				continue
+			}
+			seen[seatID] = true
+			batch = append(batch, seatID)
+			if len(batch) >= maxSize || window <= 0 {
+				emit()
+			} else if timer == nil {
+				timer = time.After(window)
+			}
+		case <-timer:
+			emit()
+		}
+	}
+}
+
+// SeatExpiryResult summarizes one processed batch of expired seat locks
+type SeatExpiryResult struct {
+	Seats           int
+	SeatsRelocked   int
+	OrdersCancelled int
+	OrdersCompleted int
+	OrdersFailed    int
+}
+
+// HandleExpiredSeats processes a batch of seats whose locks expired together. Each pending order holding
+// any of the seats is cancelled once, however many of its seats are in the batch, and seats left without
+// an order to cancel are announced as available in one event per session instead of one per seat.
+// A seat whose order is still inside its payment window is locked for that order again instead.
+func (s *OrderService) HandleExpiredSeats(ctx context.Context, seatIDs []string) SeatExpiryResult {
+	ctx = WithSeatStatusDedupe(ctx)
+	result := SeatExpiryResult{Seats: len(seatIDs)}
+	now := time.Now()
+
+	var orderIDs []string
+	orders := map[string]bool{}
+	released := map[string][]string{} // session → seats to announce directly
+
+	for _, seatID := range seatIDs {
+		sessionID, err := s.DB.GetSessionIdBySeat(ctx, seatID)
+		if err != nil {
+			s.logger.Error("SEAT_UNLOCK", fmt.Sprintf("Failed to get session ID for seat %s: %v", seatID, err))
+			continue
+		}
+
+		pendingOrders, err := s.DB.GetPendingOrdersBySeat(ctx, seatID)
+		if err != nil {
+			s.logger.Error("SEAT_UNLOCK", fmt.Sprintf("Failed to get pending orders for seat %s: %v", seatID, err))
+			continue
+		}
+
+		if len(pendingOrders) == 0 {
+			s.auditSeats(ctx, models.SeatAuditExpired, sessionID, "", []string{seatID})
+			released[sessionID] = append(released[sessionID], seatID)
+			continue
+		}
+		for _, pending := range pendingOrders {
+			s.auditSeats(ctx, models.SeatAuditExpired, sessionID, pending.OrderID, []string{seatID})
+			// An order still inside its own payment window keeps the seat; the payment window sweeper
+			// cancels it once the window closes
+			if paymentWindowOpen(pending, now) && s.relockSeat(ctx, sessionID, seatID, pending) {
+				result.SeatsRelocked++
+				continue
+			}
+			if !orders[pending.OrderID] {
+				orders[pending.OrderID] = true
+				orderIDs = append(orderIDs, pending.OrderID)
+			}
+		}
+	}
+
+	for _, outcome := range s.CancelExpiredOrders(ctx, orderIDs) {
+		switch {
+		case outcome.Err != nil:
+			// The order still holds its seats, so they are not announced as available
+			s.logger.Error("SEAT_UNLOCK", fmt.Sprintf("Failed to cancel order %s: %v", outcome.OrderID, outcome.Err))
+			result.OrdersFailed++
+		case outcome.Completed:
+			s.logger.Info("SEAT_UNLOCK", fmt.Sprintf("Order %s was paid and completed instead of cancelled", outcome.OrderID))
+			result.OrdersCompleted++
+		default:
+			result.OrdersCancelled++
+		}
+	}
+
+	// Seats a cancelled order already announced are filtered out by the dedupe context
+	sessionIDs := make([]string, 0, len(released))
+	for sessionID := range released {
+		sessionIDs = append(sessionIDs, sessionID)
+	}
+	sort.Strings(sessionIDs)
+	for _, sessionID := range sessionIDs {
+		err := s.publishSeatsReleased(ctx, models.OrderWithSeats{
+			Order:   models.Order{SessionID: sessionID},
+			SeatIDs: released[sessionID],
+		})
+		if err != nil {
+			s.logger.Error("SEAT_UNLOCK", fmt.Sprintf("Failed to publish released seats of session %s: %v", sessionID, err))
+		}
+	}
+
+	s.logger.Info("SEAT_UNLOCK", fmt.Sprintf("Processed %d expired seats: %d relocked, %d orders cancelled, %d completed, %d failed",
+		result.Seats, result.SeatsRelocked, result.OrdersCancelled, result.OrdersCompleted, result.OrdersFailed))
+	return result
+}
+
+// relockSeat locks an expired seat again for its order. It fails when the seat was taken in the meantime,
+// in which case the order is cancelled like any other expired order.
+func (s *OrderService) relockSeat(ctx context.Context, sessionID, seatID string, pending *models.Order) bool {
+	ok, err := s.Redis.LockSeats([]string{seatID}, sessionID, pending.OrderID, pending.UserID)
+	if err != nil || !ok {
+		s.logger.Warn("SEAT_UNLOCK", fmt.Sprintf("Could not relock seat %s for order %s within its payment window: ok=%v err=%v", seatID, pending.OrderID, ok, err))
+		return false
+	}
+	s.auditSeats(ctx, models.SeatAuditLocked, sessionID, pending.OrderID, []string{seatID})
+	s.logger.Info("SEAT_UNLOCK", fmt.Sprintf("Relocked seat %s for order %s, payment due at %s", seatID, pending.OrderID, pending.PaymentDueAt.Format(time.RFC3339)))
+	return true
+}
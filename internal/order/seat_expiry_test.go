@@ -0,0 +1,105 @@
+package order_test
+
+import (
+	"context"
+	"encoding/json"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchSeatIDs(t *testing.T) {
+	in := make(chan string)
+	batches := make(chan []string, 10)
+	done := make(chan struct{})
+	go func() {
+		order.BatchSeatIDs(in, 50*time.Millisecond, 3, func(seatIDs []string) { batches <- seatIDs })
+		close(done)
+	}()
+
+	// A full batch goes out without waiting for the window, duplicates dropped
+	for _, seatID := range []string{"s1", "s2", "s1", "s3"} {
+		in <- seatID
+	}
+	assert.Equal(t, []string{"s1", "s2", "s3"}, <-batches)
+
+	// A partial batch goes out once the window passes
+	in <- "s4"
+	select {
+	case batch := <-batches:
+		assert.Equal(t, []string{"s4"}, batch)
+	case <-time.After(time.Second):
+		t.Fatal("partial batch was not flushed after the window")
+	}
+
+	// Closing the input flushes what is left
+	in <- "s5"
+	close(in)
+	<-done
+	assert.Equal(t, []string{"s5"}, <-batches)
+}
+
+func TestHandleExpiredSeatsCancelsEachOrderOnce(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(3)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	t.Setenv("ORDER_EXPIRY_PAYMENT_GRACE_SECONDS", "0")
+
+	resp, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+		SessionID: uuid.NewString(), EventID: "event-1", OrganizationID: "org-1", SeatIDs: seatIDs,
+	})
+	require.NoError(t, err)
+	env.Producer.Reset()
+
+	// Every seat of the order expires in one batch, along with a seat no order ever held
+	result := env.OrderService.HandleExpiredSeats(context.Background(), append(seatIDs, uuid.NewString()))
+	assert.Equal(t, order.SeatExpiryResult{Seats: 4, OrdersCancelled: 1}, result)
+
+	o, err := env.OrderService.GetOrder(context.Background(), resp.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", o.Status)
+
+	assert.Len(t, env.Producer.MessagesOn("ticketly.order.canceled"), 1)
+	seatMessages := env.Producer.MessagesOn("ticketly.seats.status")
+	require.Len(t, seatMessages, 1)
+	var event models.SeatStatusChangeEventDto
+	require.NoError(t, json.Unmarshal(seatMessages[0].Value, &event))
+	assert.Equal(t, models.SeatStatusAvailable, event.Status)
+	var announced []string
+	for _, seatID := range event.SeatIDs {
+		announced = append(announced, seatID.String())
+	}
+	assert.ElementsMatch(t, seatIDs, announced)
+}
+
+func TestHandleExpiredSeatsKeepsSeatsOfFailedCancels(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(2)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	t.Setenv("ORDER_EXPIRY_PAYMENT_GRACE_SECONDS", "0")
+	ctx := context.Background()
+
+	resp, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+		SessionID: uuid.NewString(), EventID: "event-1", OrganizationID: "org-1", SeatIDs: seatIDs,
+	})
+	require.NoError(t, err)
+	env.Producer.Reset()
+
+	// The order can't be cancelled
+	_, err = env.DB.ExecContext(ctx, `CREATE TRIGGER refuse_cancel BEFORE UPDATE ON orders
+		WHEN NEW.status = 'cancelled' BEGIN SELECT RAISE(ABORT, 'refused'); END`)
+	require.NoError(t, err)
+
+	result := env.OrderService.HandleExpiredSeats(ctx, seatIDs)
+	assert.Equal(t, order.SeatExpiryResult{Seats: 2, OrdersFailed: 1}, result)
+
+	o, err := env.OrderService.GetOrder(ctx, resp.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", o.Status)
+
+	// The seats still belong to the pending order, so none are announced as available
+	assert.Empty(t, env.Producer.MessagesOn("ticketly.seats.status"))
+}
@@ -0,0 +1,120 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/kafka"
+	"ms-ticketing/internal/models"
+)
+
+var (
+	// ErrSeatsUnavailable is returned when a hold would cover a seat that is already locked
+	ErrSeatsUnavailable = errors.New("one or more seats are already locked")
+	// ErrInvalidSeatHold is returned when a hold or release request lacks a session or seats
+	ErrInvalidSeatHold = errors.New("session_id and seat_ids are required")
+)
+
+// SeatHoldRequest names the seats of one session an admin holds back or releases
+type SeatHoldRequest struct {
+	SessionID string   `json:"session_id"`
+	SeatIDs   []string `json:"seat_ids"`
+}
+
+// SeatHoldResult lists the seats a hold or release changed
+type SeatHoldResult struct {
+	SessionID string   `json:"session_id"`
+	SeatIDs   []string `json:"seat_ids"`
+}
+
+func (req SeatHoldRequest) validate() error {
+	if req.SessionID == "" || len(req.SeatIDs) == 0 {
+		return ErrInvalidSeatHold
+	}
+	for _, seatID := range req.SeatIDs {
+		if seatID == "" {
+			return ErrInvalidSeatHold
+		}
+	}
+	return nil
+}
+
+// HoldSeats takes seats off sale without an order, e.g. for VIPs or press. The hold has no TTL,
+// so the seats stay unavailable until ReleaseHeldSeats. Seats are published as RESERVED.
+func (s *OrderService) HoldSeats(ctx context.Context, req SeatHoldRequest, userID string) (*SeatHoldResult, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	ok, err := s.Redis.HoldSeats(req.SeatIDs, userID)
+	if err != nil {
+		s.logger.Error("REDIS", fmt.Sprintf("Failed to hold %d seats for session %s: %v", len(req.SeatIDs), req.SessionID, err))
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrSeatsUnavailable
+	}
+	s.logger.Info("ORDER", fmt.Sprintf("User %s held %d seats of session %s", userID, len(req.SeatIDs), req.SessionID))
+	s.auditSeats(ctx, models.SeatAuditHeld, req.SessionID, "", req.SeatIDs)
+
+	if err := s.publishSeatsReserved(req.SessionID, req.SeatIDs); err != nil {
+		s.logger.Error("ORDER", fmt.Sprintf("Seats held but the seat reserved event failed: %v", err))
+	}
+	return &SeatHoldResult{SessionID: req.SessionID, SeatIDs: req.SeatIDs}, nil
+}
+
+// ReleaseHeldSeats frees admin-held seats and publishes them as available.
+// Seats that are not under an admin hold, including ones locked by a customer order, are left alone.
+func (s *OrderService) ReleaseHeldSeats(ctx context.Context, req SeatHoldRequest, userID string) (*SeatHoldResult, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	held := make([]string, 0, len(req.SeatIDs))
+	for _, seatID := range req.SeatIDs {
+		lock, _, err := s.Redis.GetSeatLock(seatID)
+		if err != nil {
+			s.logger.Error("REDIS", fmt.Sprintf("Failed to read lock for seat %s: %v", seatID, err))
+			return nil, err
+		}
+		if lock != nil && lock.OrderID == models.AdminHoldOrderID {
+			held = append(held, seatID)
+		}
+	}
+
+	result := &SeatHoldResult{SessionID: req.SessionID, SeatIDs: held}
+	if len(held) == 0 {
+		return result, nil
+	}
+	if err := s.Redis.UnlockSeats(held, models.AdminHoldOrderID); err != nil {
+		s.logger.Error("REDIS", fmt.Sprintf("Failed to release held seats of session %s: %v", req.SessionID, err))
+		return nil, err
+	}
+	s.logger.Info("ORDER", fmt.Sprintf("User %s released %d held seats of session %s", userID, len(held), req.SessionID))
+	s.auditSeats(ctx, models.SeatAuditHoldReleased, req.SessionID, "", held)
+
+	released := models.OrderWithSeats{Order: models.Order{SessionID: req.SessionID}, SeatIDs: held}
+	if err := s.publishSeatsReleased(ctx, released); err != nil {
+		s.logger.Error("ORDER", fmt.Sprintf("Held seats released but the seat available event failed: %v", err))
+	}
+	return result, nil
+}
+
+func (s *OrderService) publishSeatsReserved(sessionID string, seatIDs []string) error {
+	seatEvent, err := models.NewSeatStatusChangeEventDto(sessionID, seatIDs, models.SeatStatusReserved)
+	if err != nil {
+		return fmt.Errorf("failed to create seat status event DTO: %w", err)
+	}
+
+	payload, err := json.Marshal(seatEvent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal seat status event: %w", err)
+	}
+
+	if err := s.Kafka.PublishWithHeaders("ticketly.seats.status", sessionID, payload, kafka.EventHeaders(SeatStatusEventType(models.SeatStatusReserved), "")); err != nil {
+		return err
+	}
+	s.logger.Info("KAFKA", fmt.Sprintf("Published seat status (RESERVED) event for %d seats", len(seatIDs)))
+	return nil
+}
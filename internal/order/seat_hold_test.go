@@ -0,0 +1,70 @@
+package order_test
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHoldAndReleaseSeats(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(3)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	ctx := context.Background()
+	sessionID := uuid.NewString()
+
+	held, err := env.OrderService.HoldSeats(ctx, order.SeatHoldRequest{SessionID: sessionID, SeatIDs: seatIDs[:2]}, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, seatIDs[:2], held.SeatIDs)
+
+	availability, err := env.OrderService.CheckSeatsAvailability(seatIDs)
+	require.NoError(t, err)
+	assert.Equal(t, seatIDs[:2], availability.Locked)
+	assert.Equal(t, seatIDs[2:], availability.Available)
+
+	// Customers can't order a held seat, and a held seat can't be held twice
+	_, err = env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+		SessionID: sessionID,
+		EventID:   "event-1",
+		SeatIDs:   seatIDs[1:],
+	})
+	assert.Error(t, err)
+	_, err = env.OrderService.HoldSeats(ctx, order.SeatHoldRequest{SessionID: sessionID, SeatIDs: seatIDs[1:2]}, "admin-1")
+	assert.ErrorIs(t, err, order.ErrSeatsUnavailable)
+
+	// Releasing skips seats that aren't under an admin hold
+	locked, err := env.Locks.LockSeats(seatIDs[2:], sessionID, "order-1", "user-1")
+	require.True(t, locked)
+	require.NoError(t, err)
+	released, err := env.OrderService.ReleaseHeldSeats(ctx, order.SeatHoldRequest{SessionID: sessionID, SeatIDs: seatIDs}, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, seatIDs[:2], released.SeatIDs)
+
+	availability, err = env.OrderService.CheckSeatsAvailability(seatIDs)
+	require.NoError(t, err)
+	assert.Equal(t, seatIDs[2:], availability.Locked)
+
+	assert.Equal(t, []models.SeatStatus{models.SeatStatusReserved, models.SeatStatusAvailable},
+		seatStatuses(t, env.Producer.MessagesOn("ticketly.seats.status")))
+
+	_, err = env.OrderService.HoldSeats(ctx, order.SeatHoldRequest{SeatIDs: seatIDs}, "admin-1")
+	assert.ErrorIs(t, err, order.ErrInvalidSeatHold)
+}
+
+func TestVerifySessionOwnership(t *testing.T) {
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{})
+	ctx := context.Background()
+
+	isOwner, err := env.OrderService.VerifySessionOwnership(ctx, "session-1", sessionOwnerID)
+	require.NoError(t, err)
+	assert.True(t, isOwner)
+
+	// An organizer of another organization doesn't own the session
+	isOwner, err = env.OrderService.VerifySessionOwnership(ctx, "session-1", "organizer-2")
+	require.NoError(t, err)
+	assert.False(t, isOwner)
+}
@@ -0,0 +1,33 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// ErrTooManySeats is returned when an order asks for more seats than the event allows
+var ErrTooManySeats = errors.New("too many seats in one order")
+
+// DefaultMaxSeatsPerOrder returns the seat cap for events that don't set their own
+// (MAX_SEATS_PER_ORDER, default 0 meaning unlimited)
+func DefaultMaxSeatsPerOrder() int {
+	limit, err := strconv.Atoi(os.Getenv("MAX_SEATS_PER_ORDER"))
+	if err != nil || limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// checkSeatLimit rejects seat counts above the event's cap, falling back to the env default
+func checkSeatLimit(requested, eventLimit int) error {
+	limit := eventLimit
+	if limit <= 0 {
+		limit = DefaultMaxSeatsPerOrder()
+	}
+	if limit > 0 && requested > limit {
+		return fmt.Errorf("%w: requested %d, maximum is %d", ErrTooManySeats, requested, limit)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package order_test
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seatsForLimitTest(n int) ([]models.SeatDetails, []string) {
+	seats := make([]models.SeatDetails, n)
+	seatIDs := make([]string, n)
+	for i := range seats {
+		seats[i] = models.SeatDetails{SeatID: uuid.NewString(), Label: "S", Tier: models.Tier{ID: "tier-std", Name: "Standard", Price: 10}}
+		seatIDs[i] = seats[i].SeatID
+	}
+	return seats, seatIDs
+}
+
+func TestPlaceOrderRejectsTooManySeatsBeforeLocking(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(3)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats, MaxSeatsPerOrder: 2})
+	// The event's own cap wins over the env default
+	t.Setenv("MAX_SEATS_PER_ORDER", "10")
+
+	_, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+		SessionID: uuid.NewString(),
+		EventID:   "event-1",
+		SeatIDs:   seatIDs,
+	})
+	assert.ErrorIs(t, err, order.ErrTooManySeats)
+
+	// Nothing was locked, published or stored
+	available, _, err := env.Locks.CheckSeatsAvailability(seatIDs)
+	require.NoError(t, err)
+	assert.True(t, available)
+	assert.Empty(t, env.Producer.Messages())
+	count, err := env.DB.NewSelect().Model((*models.Order)(nil)).Count(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestPlaceOrderSeatLimitFallsBackToEnvDefault(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(3)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	orderReq := models.OrderRequest{SessionID: uuid.NewString(), EventID: "event-1", SeatIDs: seatIDs}
+
+	t.Setenv("MAX_SEATS_PER_ORDER", "2")
+	_, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), orderReq)
+	assert.ErrorIs(t, err, order.ErrTooManySeats)
+
+	t.Setenv("MAX_SEATS_PER_ORDER", "3")
+	_, err = env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), orderReq)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,162 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/models"
+	"sort"
+	"time"
+)
+
+// ErrSeatNotLocked is returned when an operator tries to release a seat that has no lock
+var ErrSeatNotLocked = errors.New("seat is not locked")
+
+// SeatLockInfo describes who currently holds a seat lock
+type SeatLockInfo struct {
+	SeatID     string     `json:"seat_id"`
+	Locked     bool       `json:"locked"`
+	OrderID    string     `json:"order_id,omitempty"`
+	UserID     string     `json:"user_id,omitempty"`
+	LockedAt   *time.Time `json:"locked_at,omitempty"`
+	TTLSeconds int64      `json:"ttl_seconds,omitempty"`
+}
+
+// InspectSeatLock reports the order holding a seat's lock and how long the lock has left
+func (s *OrderService) InspectSeatLock(seatID string) (*SeatLockInfo, error) {
+	lock, ttl, err := s.Redis.GetSeatLock(seatID)
+	if err != nil {
+		s.logger.Error("REDIS", fmt.Sprintf("Failed to read lock for seat %s: %v", seatID, err))
+		return nil, err
+	}
+
+	info := &SeatLockInfo{SeatID: seatID, Locked: lock != nil}
+	if lock != nil {
+		info.OrderID = lock.OrderID
+		info.UserID = lock.UserID
+		if !lock.LockedAt.IsZero() {
+			info.LockedAt = &lock.LockedAt
+		}
+		info.TTLSeconds = int64(ttl / time.Second)
+	}
+	return info, nil
+}
+
+// ForceReleaseSeatLock removes a seat lock whoever holds it and announces the seat as available again.
+// Unlike UnlockSeats it skips the order-ownership check, so it is only exposed to admins.
+func (s *OrderService) ForceReleaseSeatLock(ctx context.Context, seatID string) (*SeatLockInfo, error) {
+	orderID, err := s.Redis.ForceUnlockSeat(seatID)
+	if err != nil {
+		s.logger.Error("REDIS", fmt.Sprintf("Failed to force-release lock for seat %s: %v", seatID, err))
+		return nil, err
+	}
+	if orderID == "" {
+		return nil, ErrSeatNotLocked
+	}
+	s.logger.Warn("ORDER", fmt.Sprintf("Seat %s lock held by order %s was force-released", seatID, orderID))
+
+	// The seat status event is keyed by session, which comes from the holding order
+	sessionID := ""
+	if holder, err := s.DB.GetOrderByID(ctx, orderID); err == nil {
+		sessionID = holder.SessionID
+	} else if sid, err := s.DB.GetSessionIdBySeat(ctx, seatID); err == nil {
+		sessionID = sid
+	}
+
+	s.auditSeats(ctx, models.SeatAuditForceReleased, sessionID, orderID, []string{seatID})
+
+	if sessionID == "" {
+		s.logger.Warn("ORDER", fmt.Sprintf("No session found for seat %s, skipping seat available event", seatID))
+	} else {
+		released := models.OrderWithSeats{
+			Order:   models.Order{OrderID: orderID, SessionID: sessionID},
+			SeatIDs: []string{seatID},
+		}
+		if err := s.publishSeatsReleased(ctx, released); err != nil {
+			s.logger.Error("ORDER", fmt.Sprintf("Seat %s released but the seat available event failed: %v", seatID, err))
+		}
+	}
+
+	return &SeatLockInfo{SeatID: seatID, Locked: false, OrderID: orderID}, nil
+}
+
+// SessionHeldSeats lists the seats currently locked by the session's pending orders, i.e. sitting in
+// buyers' carts, with the order holding each and the time left on its lock. Locks of orders that have
+// since completed are left out, as are admin holds, which are not tracked per session.
+func (s *OrderService) SessionHeldSeats(ctx context.Context, sessionID string) ([]models.HeldSeat, error) {
+	locks, err := s.Redis.SessionSeatLocks(sessionID)
+	if err != nil {
+		s.logger.Error("REDIS", fmt.Sprintf("Failed to list seat locks for session %s: %v", sessionID, err))
+		return nil, err
+	}
+
+	inSession := make(map[string]bool)
+	held := []models.HeldSeat{}
+	for _, lock := range locks {
+		matches, seen := inSession[lock.OrderID]
+		if !seen {
+			order, err := s.DB.GetOrderByID(ctx, lock.OrderID)
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				// A lock can briefly outlive a failed placement
+			case err != nil:
+				s.logger.Error("ORDER", fmt.Sprintf("Failed to look up order %s holding seat %s: %v", lock.OrderID, lock.SeatID, err))
+				return nil, err
+			default:
+				matches = order.SessionID == sessionID && order.Status == "pending"
+			}
+			inSession[lock.OrderID] = matches
+		}
+		if matches {
+			held = append(held, lock)
+		}
+	}
+
+	sort.Slice(held, func(i, j int) bool { return held[i].SeatID < held[j].SeatID })
+	return held, nil
+}
+
+// SeatAvailability splits a set of seats into those free to lock and those currently locked
+type SeatAvailability struct {
+	Available []string `json:"available"`
+	Locked    []string `json:"locked"`
+}
+
+// CheckSeatsAvailability reports which of the given seats are locked without locking anything.
+// Duplicate seat IDs are collapsed and the input order is kept.
+func (s *OrderService) CheckSeatsAvailability(seatIDs []string) (*SeatAvailability, error) {
+	unique := make([]string, 0, len(seatIDs))
+	seen := make(map[string]bool, len(seatIDs))
+	for _, id := range seatIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+
+	result := &SeatAvailability{Available: []string{}, Locked: []string{}}
+	if len(unique) == 0 {
+		return result, nil
+	}
+
+	_, locked, err := s.Redis.CheckSeatsAvailability(unique)
+	if err != nil {
+		s.logger.Error("REDIS", fmt.Sprintf("Failed to check availability of %d seats: %v", len(unique), err))
+		return nil, err
+	}
+
+	isLocked := make(map[string]bool, len(locked))
+	for _, id := range locked {
+		isLocked[id] = true
+	}
+	for _, id := range unique {
+		if isLocked[id] {
+			result.Locked = append(result.Locked, id)
+		} else {
+			result.Available = append(result.Available, id)
+		}
+	}
+	return result, nil
+}
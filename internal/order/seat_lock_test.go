@@ -0,0 +1,112 @@
+package order_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"ms-ticketing/internal/kafka"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	tickets "ms-ticketing/internal/tickets/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspectSeatLock(t *testing.T) {
+	mockRedis := new(MockRedisLock)
+	orderSvc := order.NewOrderService(new(MockDBLayer), mockRedis, kafka.NewInMemoryProducer(), &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+
+	lockedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	mockRedis.On("GetSeatLock", "seat1").Return(&models.SeatLock{OrderID: "order1", UserID: "user1", LockedAt: lockedAt}, 90*time.Second, nil)
+	mockRedis.On("GetSeatLock", "seat2").Return(nil, time.Duration(0), nil)
+
+	info, err := orderSvc.InspectSeatLock("seat1")
+	assert.NoError(t, err)
+	assert.True(t, info.Locked)
+	assert.Equal(t, "order1", info.OrderID)
+	assert.Equal(t, "user1", info.UserID)
+	assert.Equal(t, lockedAt, *info.LockedAt)
+	assert.Equal(t, int64(90), info.TTLSeconds)
+
+	info, err = orderSvc.InspectSeatLock("seat2")
+	assert.NoError(t, err)
+	assert.False(t, info.Locked)
+	assert.Empty(t, info.OrderID)
+}
+
+func TestForceReleaseSeatLock(t *testing.T) {
+	mockDB := new(MockDBLayer)
+	mockRedis := new(MockRedisLock)
+	mockKafka := kafka.NewInMemoryProducer()
+	orderSvc := order.NewOrderService(mockDB, mockRedis, mockKafka, &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+
+	seatID := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	sessionID := "550e8400-e29b-41d4-a716-446655440000"
+	mockRedis.On("ForceUnlockSeat", seatID).Return("order1", nil)
+	mockRedis.On("ForceUnlockSeat", "free-seat").Return("", nil)
+	mockRedis.On("ForceUnlockSeat", "broken-seat").Return("", errors.New("redis down"))
+	mockDB.On("GetOrderByID", "order1").Return(&models.Order{OrderID: "order1", SessionID: sessionID}, nil)
+
+	info, err := orderSvc.ForceReleaseSeatLock(context.Background(), seatID)
+	assert.NoError(t, err)
+	assert.Equal(t, "order1", info.OrderID)
+	assert.False(t, info.Locked)
+
+	_, err = orderSvc.ForceReleaseSeatLock(context.Background(), "free-seat")
+	assert.ErrorIs(t, err, order.ErrSeatNotLocked)
+
+	_, err = orderSvc.ForceReleaseSeatLock(context.Background(), "broken-seat")
+	assert.Error(t, err)
+
+	if released := mockKafka.MessagesOn("ticketly.seats.status"); assert.Len(t, released, 1) {
+		assert.Equal(t, sessionID, released[0].Key)
+	}
+}
+
+func TestSessionHeldSeats(t *testing.T) {
+	mockDB := new(MockDBLayer)
+	mockRedis := new(MockRedisLock)
+	orderSvc := order.NewOrderService(mockDB, mockRedis, kafka.NewInMemoryProducer(), &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+
+	mockRedis.On("SessionSeatLocks", "session1").Return([]models.HeldSeat{
+		{SeatID: "seat-b", OrderID: "order1", UserID: "user1", TTLSeconds: 120},
+		{SeatID: "seat-a", OrderID: "order1", UserID: "user1", TTLSeconds: 120},
+		{SeatID: "seat-d", OrderID: "paid", TTLSeconds: 30},
+		{SeatID: "seat-e", OrderID: "vanished", TTLSeconds: 10},
+	}, nil)
+	mockDB.On("GetOrderByID", "order1").Return(&models.Order{OrderID: "order1", SessionID: "session1", Status: "pending"}, nil).Once()
+	mockDB.On("GetOrderByID", "paid").Return(&models.Order{OrderID: "paid", SessionID: "session1", Status: "completed"}, nil)
+	mockDB.On("GetOrderByID", "vanished").Return(nil, sql.ErrNoRows)
+
+	held, err := orderSvc.SessionHeldSeats(context.Background(), "session1")
+	assert.NoError(t, err)
+	if assert.Len(t, held, 2) {
+		assert.Equal(t, "seat-a", held[0].SeatID)
+		assert.Equal(t, "seat-b", held[1].SeatID)
+		assert.Equal(t, "order1", held[0].OrderID)
+		assert.Equal(t, int64(120), held[0].TTLSeconds)
+	}
+	// Each holding order is looked up once however many seats it holds
+	mockDB.AssertExpectations(t)
+}
+
+func TestCheckSeatsAvailability(t *testing.T) {
+	mockRedis := new(MockRedisLock)
+	orderSvc := order.NewOrderService(new(MockDBLayer), mockRedis, kafka.NewInMemoryProducer(), &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+
+	// Duplicates and blanks are dropped before Redis is asked
+	mockRedis.On("CheckSeatsAvailability", []string{"seat1", "seat2", "seat3"}).Return(false, []string{"seat2"}, nil).Once()
+
+	availability, err := orderSvc.CheckSeatsAvailability([]string{"seat1", "seat2", "", "seat1", "seat3"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"seat1", "seat3"}, availability.Available)
+	assert.Equal(t, []string{"seat2"}, availability.Locked)
+
+	availability, err = orderSvc.CheckSeatsAvailability(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, availability.Available)
+	assert.Empty(t, availability.Locked)
+	mockRedis.AssertExpectations(t)
+}
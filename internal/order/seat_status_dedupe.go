@@ -0,0 +1,43 @@
+package order
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+	"sync"
+)
+
+type seatStatusLogKey struct{}
+
+// seatStatusLog remembers the last status published for each seat under one context
+type seatStatusLog struct {
+	mu        sync.Mutex
+	published map[string]models.SeatStatus
+}
+
+// WithSeatStatusDedupe returns a context under which a seat status event is published at most once
+// per seat and status. Handlers that may touch the same seat through several orders, such as the
+// seat-expiry subscriber, wrap each invocation in one so the seating service sees one event per transition.
+func WithSeatStatusDedupe(ctx context.Context) context.Context {
+	return context.WithValue(ctx, seatStatusLogKey{}, &seatStatusLog{published: map[string]models.SeatStatus{}})
+}
+
+// SeatsToPublish returns the seats whose status event still has to go out under ctx and records them as
+// published. Without WithSeatStatusDedupe every seat is returned.
+func SeatsToPublish(ctx context.Context, status models.SeatStatus, seatIDs []string) []string {
+	log, ok := ctx.Value(seatStatusLogKey{}).(*seatStatusLog)
+	if !ok {
+		return seatIDs
+	}
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	pending := make([]string, 0, len(seatIDs))
+	for _, seatID := range seatIDs {
+		if last, seen := log.published[seatID]; seen && last == status {
+			continue
+		}
+		log.published[seatID] = status
+		pending = append(pending, seatID)
+	}
+	return pending
+}
@@ -0,0 +1,54 @@
+package order_test
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeatsToPublishDedupesWithinContext(t *testing.T) {
+	ctx := order.WithSeatStatusDedupe(context.Background())
+
+	assert.Equal(t, []string{"s1", "s2"}, order.SeatsToPublish(ctx, models.SeatStatusAvailable, []string{"s1", "s2"}))
+	// A second cancelled order holding s2 only announces its new seat
+	assert.Equal(t, []string{"s3"}, order.SeatsToPublish(ctx, models.SeatStatusAvailable, []string{"s2", "s3"}))
+	assert.Empty(t, order.SeatsToPublish(ctx, models.SeatStatusAvailable, []string{"s1"}))
+	// A different transition for the same seat still goes out
+	assert.Equal(t, []string{"s1"}, order.SeatsToPublish(ctx, models.SeatStatusBooked, []string{"s1"}))
+
+	// Contexts without dedupe publish everything, every time
+	plain := context.Background()
+	assert.Equal(t, []string{"s1"}, order.SeatsToPublish(plain, models.SeatStatusAvailable, []string{"s1"}))
+	assert.Equal(t, []string{"s1"}, order.SeatsToPublish(plain, models.SeatStatusAvailable, []string{"s1"}))
+}
+
+func TestCancelOrderSkipsSeatsAlreadyPublished(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(2)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+
+	resp, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+		SessionID: uuid.NewString(), EventID: "event-1", OrganizationID: "org-1", SeatIDs: seatIDs,
+	})
+	require.NoError(t, err)
+	env.Producer.Reset()
+
+	ctx := order.WithSeatStatusDedupe(context.Background())
+	order.SeatsToPublish(ctx, models.SeatStatusAvailable, seatIDs)
+	require.NoError(t, env.OrderService.CancelOrder(ctx, resp.OrderID))
+	assert.Empty(t, env.Producer.MessagesOn("ticketly.seats.status"))
+	assert.Len(t, env.Producer.MessagesOn("ticketly.order.canceled"), 1)
+
+	// Without dedupe the released seats are announced as usual
+	resp, err = env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+		SessionID: uuid.NewString(), EventID: "event-1", OrganizationID: "org-1", SeatIDs: seatIDs,
+	})
+	require.NoError(t, err)
+	env.Producer.Reset()
+	require.NoError(t, env.OrderService.CancelOrder(context.Background(), resp.OrderID))
+	assert.Equal(t, []models.SeatStatus{models.SeatStatusAvailable}, seatStatuses(t, env.Producer.Messages()))
+}
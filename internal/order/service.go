@@ -2,16 +2,19 @@ package order
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"ms-ticketing/internal/auth"
+	"ms-ticketing/internal/kafka"
 	"ms-ticketing/internal/models"
 	"ms-ticketing/internal/order/discount"
 	rediswrap "ms-ticketing/internal/order/redis"
 	tickets "ms-ticketing/internal/tickets/service"
 	"net/http"
+	"net/url"
 	"os"
 	"time"
 
@@ -24,27 +27,46 @@ import (
 )
 
 type DBLayer interface {
-	CreateOrder(order models.Order) error
-	GetOrderByID(id string) (*models.Order, error)
-	GetOrderWithSeats(id string) (*models.OrderWithSeats, error)
-	UpdateOrder(order models.Order) error
-	CancelOrder(id string) error
-	GetOrderBySeat(seatID string) (*models.Order, error)
-	GetPendingOrdersBySeat(seatID string) ([]*models.Order, error)
-	GetSeatsByOrder(orderID string) ([]string, error)
-	GetSessionIdBySeat(seatID string) (string, error)
-	GetOrdersWithTicketsByUserID(userID string) ([]models.OrderWithTickets, error)
-	GetOrdersWithTicketsAndQRByUserID(userID string) ([]models.OrderWithTicketsAndQR, error)
+	CreateOrder(ctx context.Context, order models.Order) error
+	CreateOrderWithTickets(ctx context.Context, order models.Order, tickets []models.Ticket) error
+	GetOrderByID(ctx context.Context, id string) (*models.Order, error)
+	GetOrderWithSeats(ctx context.Context, id string) (*models.OrderWithSeats, error)
+	UpdateOrder(ctx context.Context, order models.Order) error
+	CancelOrder(ctx context.Context, id string) error
+	GetOrderBySeat(ctx context.Context, seatID string) (*models.Order, error)
+	GetOrdersBySeats(ctx context.Context, seatIDs []string) (map[string]*models.Order, error)
+	GetPendingOrdersBySeat(ctx context.Context, seatID string) ([]*models.Order, error)
+	GetSeatsByOrder(ctx context.Context, orderID string) ([]string, error)
+	GetSessionIdBySeat(ctx context.Context, seatID string) (string, error)
+	GetOrdersWithTicketsByUserID(ctx context.Context, userID string) ([]models.OrderWithTickets, error)
+	GetOrdersWithTicketsAndQRByUserID(ctx context.Context, userID string) ([]models.OrderWithTicketsAndQR, error)
+	CountPendingOrdersByUserSession(ctx context.Context, userID, sessionID string) (int, error)
+	GetUnpaidPendingOrders(ctx context.Context, createdAfter, createdBefore time.Time) ([]models.Order, error)
+	ArchiveOrdersBefore(ctx context.Context, before time.Time) (int, error)
+	GetCompletedOrdersBetween(ctx context.Context, from, to time.Time) ([]models.Order, error)
+	CreateOrderNote(ctx context.Context, note models.OrderNote) error
+	GetOrderNotes(ctx context.Context, orderID string) ([]models.OrderNote, error)
+	NextOrderNumber(ctx context.Context, day string) (int64, error)
+	GetOverduePendingOrders(ctx context.Context, dueBefore time.Time) ([]models.Order, error)
+	GetOrderByNumber(ctx context.Context, number string) (*models.Order, error)
 }
 
 type RedisLock interface {
 	CheckSeatsAvailability(seatIDs []string) (bool, []string, error)
-	LockSeats(seatIDs []string, orderID string) (bool, error)
+	LockSeats(seatIDs []string, sessionID, orderID, userID string) (bool, error)
+	HoldSeats(seatIDs []string, userID string) (bool, error)
 	UnlockSeats(seatIDs []string, orderID string) error
+	MarkOrderReminded(orderID string, ttl time.Duration) (bool, error)
+	MarkConfirmationResent(orderID string, cooldown time.Duration) (bool, error)
+	GetSeatLock(seatID string) (*models.SeatLock, time.Duration, error)
+	ForceUnlockSeat(seatID string) (string, error)
+	SessionSeatLocks(sessionID string) ([]models.HeldSeat, error)
+	PruneSessionLockSets() (int, error)
 }
 
 type KafkaProducer interface {
 	Publish(topic string, key string, value []byte) error
+	PublishWithHeaders(topic string, key string, value []byte, headers kafka.Headers) error
 	Close() error
 }
 
@@ -57,15 +79,37 @@ type OrderService struct {
 	client               *http.Client
 	logger               *logger.Logger
 	CheckoutEventEmitter CheckoutEventEmitter
+	PaymentIntents       PaymentIntentSource
+	SeatAudit            SeatAuditLog
+	StatusHistory        OrderStatusHistory
+	PaymentProviders     map[string]PaymentProvider
+	EventQueryURL        *url.URL // Base URL of the event query service, set with SetServiceURLs
+	EventSeatingURL      *url.URL // Base URL of the event seating service, set with SetServiceURLs
 }
 
-// CheckoutEventEmitter is an interface for emitting checkout events
+// CheckoutEventEmitter is an interface for emitting checkout and cancellation events
 type CheckoutEventEmitter interface {
 	EmitCheckoutEvent(order models.OrderWithTickets)
+	EmitOrderCancelledEvent(order models.OrderWithTickets, releasedSeatIDs []string)
+}
+
+// MultiCheckoutEmitter forwards every event to each of its emitters in order
+type MultiCheckoutEmitter []CheckoutEventEmitter
+
+func (m MultiCheckoutEmitter) EmitCheckoutEvent(order models.OrderWithTickets) {
+	for _, emitter := range m {
+		emitter.EmitCheckoutEvent(order)
+	}
+}
+
+func (m MultiCheckoutEmitter) EmitOrderCancelledEvent(order models.OrderWithTickets, releasedSeatIDs []string) {
+	for _, emitter := range m {
+		emitter.EmitOrderCancelledEvent(order, releasedSeatIDs)
+	}
 }
 
 func NewOrderService(db DBLayer, redis RedisLock, kafka KafkaProducer, ticketService *tickets.TicketService, client *http.Client) *OrderService {
-	return &OrderService{
+	s := &OrderService{
 		DB:              db,
 		Redis:           redis,
 		Kafka:           kafka,
@@ -73,7 +117,10 @@ func NewOrderService(db DBLayer, redis RedisLock, kafka KafkaProducer, ticketSer
 		DiscountService: discount.NewDiscountService(),
 		client:          client,
 		logger:          logger.NewLogger(), // Initialize logger
+		PaymentIntents:  stripeIntentSource{},
 	}
+	s.RegisterPaymentProvider(stripeProvider{logger: s.logger})
+	return s
 }
 
 // SetCheckoutEventEmitter sets the checkout event emitter for SSE notifications
@@ -81,21 +128,41 @@ func (s *OrderService) SetCheckoutEventEmitter(emitter CheckoutEventEmitter) {
 	s.CheckoutEventEmitter = emitter
 }
 
+// SetServiceURLs sets the base URLs of the event query and seating services that orders are validated with
+func (s *OrderService) SetServiceURLs(eventQuery, eventSeating *url.URL) {
+	s.EventQueryURL = eventQuery
+	s.EventSeatingURL = eventSeating
+}
+
+// serviceEndpoint joins path onto a service's base URL; name identifies the service in errors
+func serviceEndpoint(base *url.URL, name, path string) (string, error) {
+	if base == nil {
+		return "", fmt.Errorf("%s service URL not configured", name)
+	}
+	return base.JoinPath(path).String(), nil
+}
+
 // ---------------- ORDERS ----------------
 
-func (s *OrderService) GetOrderBySeat(seatID string) (*models.Order, error) {
+func (s *OrderService) GetOrderBySeat(ctx context.Context, seatID string) (*models.Order, error) {
 	s.logger.Debug("ORDER", fmt.Sprintf("Getting order by seat: %s", seatID))
-	return s.DB.GetOrderBySeat(seatID)
+	return s.DB.GetOrderBySeat(ctx, seatID)
 }
 
-func (s *OrderService) GetOrder(id string) (*models.Order, error) {
+// GetOrdersBySeats resolves the orders holding a batch of seats, keyed by seat ID
+func (s *OrderService) GetOrdersBySeats(ctx context.Context, seatIDs []string) (map[string]*models.Order, error) {
+	s.logger.Debug("ORDER", fmt.Sprintf("Getting orders for %d seats", len(seatIDs)))
+	return s.DB.GetOrdersBySeats(ctx, seatIDs)
+}
+
+func (s *OrderService) GetOrder(ctx context.Context, id string) (*models.Order, error) {
 	s.logger.Debug("ORDER", fmt.Sprintf("Getting order by ID: %s", id))
-	return s.DB.GetOrderByID(id)
+	return s.DB.GetOrderByID(ctx, id)
 }
 
-func (s *OrderService) CancelOrder(id string) error {
+func (s *OrderService) CancelOrder(ctx context.Context, id string) error {
 	s.logger.Info("ORDER", fmt.Sprintf("Cancelling order: %s", id))
-	order, err := s.DB.GetOrderByID(id)
+	order, err := s.DB.GetOrderByID(ctx, id)
 	if err != nil {
 		s.logger.Error("ORDER", fmt.Sprintf("Order %s not found: %v", id, err))
 		return fmt.Errorf("order %s not found: %w", id, err)
@@ -106,17 +173,18 @@ func (s *OrderService) CancelOrder(id string) error {
 	}
 
 	// Get seat IDs associated with this order
-	seatIDs, err := s.DB.GetSeatsByOrder(id)
+	seatIDs, err := s.DB.GetSeatsByOrder(ctx, id)
 	if err != nil {
 		s.logger.Error("ORDER", fmt.Sprintf("Failed to get seat IDs for order %s: %v", id, err))
 		return fmt.Errorf("failed to get seat IDs: %w", err)
 	}
 
-	// Cancel the associated payment intent if it exists
+	// Cancel the associated payment with its provider if one was started
+	order.CancellationReason = CancellationReason(ctx)
 	if order.PaymentIntentID != "" {
-		s.logger.Info("PAYMENT", fmt.Sprintf("Cancelling payment intent %s for order %s", order.PaymentIntentID, id))
-		if err := s.CancelPaymentIntent(order.PaymentIntentID); err != nil {
-			s.logger.Error("PAYMENT", fmt.Sprintf("Failed to cancel payment intent %s: %v", order.PaymentIntentID, err))
+		s.logger.Info("PAYMENT", fmt.Sprintf("Cancelling %s payment %s for order %s", orderPaymentProvider(order), order.PaymentIntentID, id))
+		if err := s.cancelOrderPayment(order, order.CancellationReason); err != nil {
+			s.logger.Error("PAYMENT", fmt.Sprintf("Failed to cancel payment %s: %v", order.PaymentIntentID, err))
 			// Continue with order cancellation even if payment intent cancellation fails
 		}
 		// Reset the payment intent ID
@@ -124,20 +192,22 @@ func (s *OrderService) CancelOrder(id string) error {
 	}
 
 	order.Status = "cancelled"
-	if err := s.DB.UpdateOrder(*order); err != nil {
+	if err := s.DB.UpdateOrder(ctx, *order); err != nil {
 		s.logger.Error("ORDER", fmt.Sprintf("Failed to cancel order %s: %v", id, err))
 		return fmt.Errorf("failed to cancel order %s: %w", id, err)
 	}
+	s.recordStatusChange(ctx, id, "pending", order.Status)
 
 	// Unlock seats
 	if err := s.Redis.UnlockSeats(seatIDs, order.OrderID); err != nil {
 		s.logger.Error("REDIS", fmt.Sprintf("Failed to unlock seats for order %s: %v", id, err))
 	} else {
 		s.logger.Info("REDIS", fmt.Sprintf("Seats unlocked for cancelled order %s", id))
+		s.auditSeats(ctx, models.SeatAuditUnlocked, order.SessionID, order.OrderID, seatIDs)
 	}
 
 	// Try to get order with tickets for denormalized event
-	orderWithTickets, err := s.GetOrderWithTickets(id)
+	orderWithTickets, err := s.GetOrderWithTickets(ctx, id)
 	if err != nil {
 		// If we can't get the tickets, fall back to seats-only approach
 		s.logger.Warn("ORDER", fmt.Sprintf("Could not get tickets for order %s: %v, falling back to seats-only approach", id, err))
@@ -154,22 +224,36 @@ func (s *OrderService) CancelOrder(id string) error {
 			Order:   *order,
 			SeatIDs: seatIDs,
 		}
-		if err := s.publishSeatsReleased(*orderWithSeats); err != nil {
+		if err := s.publishSeatsReleased(ctx, *orderWithSeats); err != nil {
 			s.logger.Error("KAFKA", fmt.Sprintf("Kafka publish error (seats released): %v", err))
 		}
+
+		// Let organizer dashboards update their remaining seats live
+		if s.CheckoutEventEmitter != nil {
+			s.CheckoutEventEmitter.EmitOrderCancelledEvent(*orderWithTickets, seatIDs)
+		}
 	}
 
 	s.logger.Info("ORDER", fmt.Sprintf("Order %s cancelled successfully", id))
 	return nil
 }
 
-func (s *OrderService) Checkout(id string) error {
+// ErrAlreadyCompleted is returned by Checkout when the order was already completed, typically
+// because Stripe retried a webhook. Callers should treat it as a successful no-op.
+var ErrAlreadyCompleted = errors.New("order is already completed")
+
+func (s *OrderService) Checkout(ctx context.Context, id string) error {
 	s.logger.Info("ORDER", fmt.Sprintf("Checking out order: %s", id))
-	order, err := s.DB.GetOrderByID(id)
+	order, err := s.DB.GetOrderByID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to get order: %v", err)
 	}
 
+	if order.Status == "completed" {
+		s.logger.Info("ORDER", fmt.Sprintf("Order %s is already completed, skipping checkout", id))
+		return ErrAlreadyCompleted
+	}
+
 	if order.Status != "pending" {
 		return fmt.Errorf("order is not in pending status, current status: %s", order.Status)
 	}
@@ -180,7 +264,7 @@ func (s *OrderService) Checkout(id string) error {
 	}
 
 	// First get the tickets which contain seat IDs
-	orderWithTickets, err := s.GetOrderWithTickets(id)
+	orderWithTickets, err := s.GetOrderWithTickets(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to get tickets for order: %v", err)
 	} else {
@@ -192,10 +276,11 @@ func (s *OrderService) Checkout(id string) error {
 
 		// Update order status
 		order.Status = "completed"
-		err = s.DB.UpdateOrder(*order)
+		err = s.DB.UpdateOrder(ctx, *order)
 		if err != nil {
 			return fmt.Errorf("failed to update order status: %v", err)
 		}
+		s.recordStatusChange(ctx, id, "pending", order.Status)
 
 		// Update the order in orderWithTickets to reflect the status change
 		orderWithTickets.Order.Status = "completed"
@@ -207,7 +292,7 @@ func (s *OrderService) Checkout(id string) error {
 		}
 
 		// Publish seats booked event
-		err = s.publishSeatsBooked(orderWithSeats)
+		err = s.publishSeatsBooked(ctx, orderWithSeats)
 		if err != nil {
 			s.logger.Error("KAFKA", fmt.Sprintf("Failed to publish seats booked event: %v", err))
 			// Continue execution even if event publishing fails
@@ -233,6 +318,7 @@ func (s *OrderService) Checkout(id string) error {
 
 func (s *OrderService) SeatValidationAndPlaceOrder(r *http.Request, orderReq models.OrderRequest) (*models.OrderResponse, error) {
 	s.logger.Info("ORDER", "Starting seat validation and order placement process")
+	ctx := r.Context()
 
 	// Step 1: Check Redis seat availability FIRST before any other operations
 	s.logger.Debug("REDIS", "Checking seat availability in Redis before proceeding")
@@ -241,6 +327,7 @@ func (s *OrderService) SeatValidationAndPlaceOrder(r *http.Request, orderReq mod
 		s.logger.Error("REDIS", fmt.Sprintf("Failed to check seat availability: %v", err))
 		return nil, fmt.Errorf("failed to check seat availability: %w", err)
 	}
+	availability := models.NewSeatAvailabilitySnapshot(orderReq.SeatIDs, unavailableSeats, time.Now().UTC())
 	if !available {
 		s.logger.Warn("REDIS", fmt.Sprintf("One or more seats are already locked: %v", unavailableSeats))
 		return nil, fmt.Errorf("one or more seats are already locked: %v", unavailableSeats)
@@ -260,29 +347,19 @@ func (s *OrderService) SeatValidationAndPlaceOrder(r *http.Request, orderReq mod
 		return nil, fmt.Errorf("invalid token: %w", err)
 	}
 
-	s.logger.Debug("ORDER", fmt.Sprintf("Order request: %+v", orderReq))
-
-	var config models.Config
-	config.ClientID = os.Getenv("TICKET_CLIENT_ID")
-	config.ClientSecret = os.Getenv("TICKET_CLIENT_SECRET")
-	config.KeycloakURL = os.Getenv("KEYCLOAK_URL")
-	config.KeycloakRealm = os.Getenv("KEYCLOAK_REALM")
+	// The order's creation is attributed to the buyer in its status history
+	ctx = WithStatusActor(ctx, userID)
 
-	s.logger.Debug("AUTH", "Requesting M2M token for seat validation")
+	s.logger.Debug("ORDER", fmt.Sprintf("Order request: session=%s event=%s seats=%d", orderReq.SessionID, orderReq.EventID, len(orderReq.SeatIDs)))
 
-	// Get Redis client from the existing Redis object if available
-	var redisClient *redis.Client
-	if s.Redis != nil {
-		// Try to get the Redis client from our Redis wrapper
-		if redisWrapper, ok := s.Redis.(*rediswrap.Redis); ok && redisWrapper != nil {
-			redisClient = redisWrapper.Client
-		}
+	// Refuse before calling other services when the user already holds too many unpaid orders
+	if err := s.checkPendingOrderLimit(ctx, userID, orderReq.SessionID); err != nil {
+		return nil, err
 	}
 
-	m2m_token, err := auth.GetM2MToken(config, s.client, redisClient, s.logger)
+	m2m_token, err := s.getM2MToken()
 	if err != nil {
-		s.logger.Error("AUTH", fmt.Sprintf("Failed to get M2M token: %v", err))
-		return nil, fmt.Errorf("failed to get M2M token: %w", err)
+		return nil, err
 	}
 
 	// Step 3: Generate unique OrderID
@@ -290,62 +367,30 @@ func (s *OrderService) SeatValidationAndPlaceOrder(r *http.Request, orderReq mod
 	s.logger.Debug("ORDER", fmt.Sprintf("Generated order ID: %s", orderID))
 
 	// Step 4: Call Pre-validation Service (first HTTP request)
-	s.logger.Debug("PRE_VALIDATION", "Making first HTTP request to validate pre-order")
-	eventQueryServiceURL := os.Getenv("EVENT_QUERY_SERVICE_URL") // e.g., http://localhost:8082/api/event-query
-	if eventQueryServiceURL != "" && eventQueryServiceURL[len(eventQueryServiceURL)-1] == '/' {
-		eventQueryServiceURL = eventQueryServiceURL[:len(eventQueryServiceURL)-1]
-	}
-
-	preValidateURL := fmt.Sprintf("%s/internal/v1/validate-pre-order", eventQueryServiceURL)
-
-	// Prepare request body
 	reqBody, err := json.Marshal(orderReq)
 	if err != nil {
 		s.logger.Error("PRE_VALIDATION", fmt.Sprintf("Failed to marshal request: %v", err))
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
-
-	s.logger.Debug("PRE_VALIDATION", fmt.Sprintf("Pre-validation URL: %s", preValidateURL))
-	s.logger.Debug("PRE_VALIDATION", fmt.Sprintf("Request body: %s", string(reqBody)))
-
-	req, err := http.NewRequest("POST", preValidateURL, bytes.NewBuffer(reqBody))
+	orderDetailsDTO, err := s.preValidateOrder(reqBody, m2m_token)
 	if err != nil {
-		s.logger.Error("PRE_VALIDATION", fmt.Sprintf("Failed to create pre-validation request: %v", err))
-		return nil, fmt.Errorf("failed to create pre-validation request: %w", err)
+		return nil, err
 	}
-	req.Header.Set("Authorization", "Bearer "+m2m_token)
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		s.logger.Error("PRE_VALIDATION", fmt.Sprintf("Pre-validation service error: %v", err))
-		return nil, fmt.Errorf("pre-validation service error: %w", err)
-	}
-
-	// Read and store the response body
-	var orderDetailsDTO models.OrderDetailsDTO
-	err = json.NewDecoder(resp.Body).Decode(&orderDetailsDTO)
-	if err != nil {
-		s.logger.Error("PRE_VALIDATION", fmt.Sprintf("Failed to decode pre-validation response: %v", err))
-		return nil, fmt.Errorf("failed to decode pre-validation response: %w", err)
+	// Enforce the per-order seat cap before anything is locked, so a rejection needs no cleanup
+	if err := checkSeatLimit(len(orderReq.SeatIDs), orderDetailsDTO.MaxSeatsPerOrder); err != nil {
+		s.logger.Warn("ORDER", fmt.Sprintf("Rejecting order for user %s: %v", userID, err))
+		return nil, err
 	}
-
-	err = resp.Body.Close()
+	currency, err := models.ValidateCurrency(orderDetailsDTO.Currency)
 	if err != nil {
-		s.logger.Error("PRE_VALIDATION", fmt.Sprintf("Failed to close pre-validation response body: %v", err))
-		return nil, fmt.Errorf("failed to close pre-validation response body: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		s.logger.Error("PRE_VALIDATION", fmt.Sprintf("Pre-validation failed: status %d", resp.StatusCode))
-		return nil, fmt.Errorf("pre-validation failed: status %d", resp.StatusCode)
+		s.logger.Warn("ORDER", fmt.Sprintf("Rejecting order for user %s: %v", userID, err))
+		return nil, err
 	}
 
-	s.logger.Info("PRE_VALIDATION", "Pre-validation successful, OrderDetailsDTO received")
-
 	// Step 5: Lock seats in Redis
 	s.logger.Debug("REDIS", "Attempting to lock seats in Redis")
-	ok, err := s.Redis.LockSeats(orderReq.SeatIDs, orderID)
+	ok, err := s.Redis.LockSeats(orderReq.SeatIDs, orderReq.SessionID, orderID, userID)
 	if err != nil {
 		s.logger.Error("REDIS", fmt.Sprintf("Failed to lock seats: %v", err))
 		return nil, fmt.Errorf("failed to lock seats: %w", err)
@@ -355,105 +400,51 @@ func (s *OrderService) SeatValidationAndPlaceOrder(r *http.Request, orderReq mod
 		return nil, fmt.Errorf("one or more seats already locked")
 	}
 	s.logger.Info("REDIS", "Seats locked successfully")
+	s.auditSeats(ctx, models.SeatAuditLocked, orderReq.SessionID, orderID, orderReq.SeatIDs)
+	availability.LockedAt = time.Now().UTC()
 
 	// Transaction rollback helper
 	rollback := func() {
 		s.logger.Warn("TXN", "Rolling back: unlocking seats")
-		_ = s.Redis.UnlockSeats(orderReq.SeatIDs, orderID)
+		if err := s.Redis.UnlockSeats(orderReq.SeatIDs, orderID); err == nil {
+			s.auditSeats(ctx, models.SeatAuditUnlocked, orderReq.SessionID, orderID, orderReq.SeatIDs)
+		}
+		// Tickets created before the failure would otherwise be left behind
+		if s.TicketService != nil {
+			if err := s.TicketService.DeleteTicketsByOrder(orderID); err != nil {
+				s.logger.Error("TXN", fmt.Sprintf("Failed to delete tickets of rolled back order %s: %v", orderID, err))
+			}
+		}
 	}
 
 	// Step 6: Make second HTTP request to validate seats after locking
-	s.logger.Debug("SEAT_VALIDATION", "Making second HTTP request to validate seats after locking")
-	seatServiceBase := os.Getenv("EVENT_SEATING_SERVICE_URL") // e.g., http://localhost:8081/api/event-seating
-	if seatServiceBase != "" && seatServiceBase[len(seatServiceBase)-1] == '/' {
-		seatServiceBase = seatServiceBase[:len(seatServiceBase)-1]
-	}
-
-	finalValidateURL := fmt.Sprintf("%s/internal/v1/validate-pre-order", seatServiceBase)
-
-	reqFinal, err := http.NewRequest("POST", finalValidateURL, bytes.NewBuffer(reqBody))
-	if err != nil {
-		s.logger.Error("SEAT_VALIDATION", fmt.Sprintf("Failed to create seat validation request: %v", err))
+	if err := s.validateLockedSeats(reqBody, m2m_token); err != nil {
 		rollback()
-		return nil, fmt.Errorf("failed to create seat validation request: %w", err)
-	}
-	reqFinal.Header.Set("Authorization", "Bearer "+m2m_token)
-	reqFinal.Header.Set("Content-Type", "application/json")
-
-	respFinal, err := s.client.Do(reqFinal)
-	if err != nil {
-		s.logger.Error("SEAT_VALIDATION", fmt.Sprintf("Seat validation service error: %v", err))
-		rollback()
-		return nil, fmt.Errorf("seat validation service error: %w", err)
-	}
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-			s.logger.Error("SEAT_VALIDATION", fmt.Sprintf("Failed to close seat validation response body: %v", err))
-		}
-	}(respFinal.Body)
-
-	if respFinal.StatusCode != http.StatusOK {
-		s.logger.Error("SEAT_VALIDATION", fmt.Sprintf("Final seat validation failed: status %d", respFinal.StatusCode))
-		rollback()
-		return nil, fmt.Errorf("final seat validation failed: status %d", respFinal.StatusCode)
+		return nil, err
 	}
 
 	s.logger.Info("SEAT_VALIDATION", "Final seat validation successful")
 
 	// Step 7: Calculate prices and apply discount if available
-	var subtotal float64 = 0
-	for _, seat := range orderDetailsDTO.Seats {
-		subtotal += seat.Tier.Price
+	price, err := s.priceOrder(orderDetailsDTO, orderReq)
+	if err != nil {
+		rollback()
+		return nil, err
 	}
+	subtotal, discountAmount, finalPrice := price.SubTotal, price.DiscountAmount, price.Price
+	discountID, discountCode := price.DiscountID, price.DiscountCode
 
-	// Default values assuming no discount
-	discountAmount := 0.0
-	discountID := ""
-	discountCode := ""
-	finalPrice := subtotal
-
-	// Process discount if provided in the OrderDetailsDTO
-	if orderDetailsDTO.Discount != nil && orderDetailsDTO.Discount.ID != "" {
-		s.logger.Debug("DISCOUNT", fmt.Sprintf("Processing discount from OrderDetailsDTO: %s", orderDetailsDTO.Discount.Code))
-
-		// Validate and calculate discount
-		discountResult, err := s.DiscountService.ValidateAndCalculateDiscount(
-			orderDetailsDTO.Discount,
-			orderDetailsDTO.Seats,
-			orderReq.SessionID,
-		)
-
-		if err != nil {
-			s.logger.Error("DISCOUNT", fmt.Sprintf("Error calculating discount: %v", err))
-			rollback()
-			return nil, fmt.Errorf("error calculating discount: %w", err)
-		}
-
-		if !discountResult.IsValid {
-			s.logger.Warn("DISCOUNT", fmt.Sprintf("Discount not applicable: %s", discountResult.Reason))
-			rollback()
-			return nil, fmt.Errorf("discount not applicable: %s", discountResult.Reason)
-		}
-
-		// Apply discount
-		discountAmount = discountResult.DiscountAmount
-		discountID = orderDetailsDTO.Discount.ID
-		discountCode = orderDetailsDTO.Discount.Code
-		finalPrice = subtotal - discountAmount
-
-		if finalPrice < 0 {
-			finalPrice = 0
-		}
-
-		s.logger.Info("DISCOUNT", fmt.Sprintf("Applied discount: %.2f, final price: %.2f", discountAmount, finalPrice))
-	} else {
-		s.logger.Debug("DISCOUNT", "No discount applied to order")
+	// The friendly number is only for support; an order without one is still found by its ID
+	createdAt := time.Now()
+	orderNumber, err := s.nextOrderNumber(ctx, createdAt)
+	if err != nil {
+		s.logger.Warn("ORDER", fmt.Sprintf("Placing order %s without an order number: %v", orderID, err))
 	}
 
 	// Build the order object, ensuring empty discount values are treated as NULL in the database
 	order := models.Order{
 		OrderID:        orderID,
+		OrderNumber:    orderNumber,
 		UserID:         userID,
 		EventID:        orderReq.EventID,
 		OrganizationID: orderReq.OrganizationID,
@@ -462,7 +453,12 @@ func (s *OrderService) SeatValidationAndPlaceOrder(r *http.Request, orderReq mod
 		SubTotal:       subtotal,
 		DiscountAmount: discountAmount,
 		Price:          finalPrice,
-		CreatedAt:      time.Now(),
+		Currency:       currency,
+		CreatedAt:      createdAt,
+	}
+	order.AvailabilitySnapshot = availability
+	if window := PaymentWindow(orderDetailsDTO.PaymentWindowMinutes); window > 0 {
+		order.PaymentDueAt = createdAt.Add(window)
 	}
 
 	// Only set discount fields if they have values
@@ -474,23 +470,21 @@ func (s *OrderService) SeatValidationAndPlaceOrder(r *http.Request, orderReq mod
 	}
 
 	// Publish seats locked event
-	if err := s.publishSeatsLocked(orderReq); err != nil {
+	if err := s.publishSeatsLocked(orderReq, order.OrderID); err != nil {
 		s.logger.Error("KAFKA", fmt.Sprintf("Kafka publish error (seats locked): %v", err))
 	}
 
-	// Step 8: Save order to DB - skip locking since we already locked the seats
-	if err := s.SaveOrder(order, orderReq.SeatIDs); err != nil {
-		s.logger.Error("ORDER", fmt.Sprintf("Failed to place order: %v. Unlocking seats.", err))
+	// Step 8: Issue a ticket for each seat
+	if s.TicketService == nil {
+		s.logger.Warn("TICKET", "TicketService not configured, skipping ticket creation")
 		rollback()
-		return nil, fmt.Errorf("failed to place order: %w", err)
+		return nil, fmt.Errorf("ticket service not configured")
 	}
-
-	// Step 9: Create tickets for each seat
 	s.logger.Info("TICKET", "Creating tickets for each seat")
-	var createdTickets []models.TicketForStreaming
+	issued := make([]models.Ticket, 0, len(orderDetailsDTO.Seats))
 	for _, seat := range orderDetailsDTO.Seats {
-		ticket := models.Ticket{
-			TicketID:        uuid.NewString(),
+		ticket, err := s.TicketService.PrepareTicket(models.Ticket{
+			TicketID:        models.TicketIDFor(orderID, seat.SeatID),
 			OrderID:         orderID,
 			SeatID:          seat.SeatID,
 			SeatLabel:       seat.Label,
@@ -500,24 +494,24 @@ func (s *OrderService) SeatValidationAndPlaceOrder(r *http.Request, orderReq mod
 			PriceAtPurchase: seat.Tier.Price,
 			IssuedAt:        time.Now(),
 			CheckedIn:       false,
-		}
-
-		// Save the ticket using TicketService
-		if s.TicketService != nil {
-			if err := s.TicketService.PlaceTicket(ticket); err != nil {
-				s.logger.Warn("TICKET", fmt.Sprintf("Failed to create ticket for seat %s: %v", seat.SeatID, err))
-				rollback()
-				return nil, fmt.Errorf("failed to create ticket for seat %s: %w", seat.SeatID, err)
-			} else {
-				s.logger.Info("TICKET", fmt.Sprintf("Created ticket %s for seat %s", ticket.TicketID, ticket.SeatID))
-				// Add ticket to our local collection for event publishing
-				createdTickets = append(createdTickets, ticket.ToStreamingTicket())
-			}
-		} else {
-			s.logger.Warn("TICKET", "TicketService not configured, skipping ticket creation")
+		})
+		if err != nil {
+			s.logger.Warn("TICKET", fmt.Sprintf("Failed to create ticket for seat %s: %v", seat.SeatID, err))
 			rollback()
-			return nil, fmt.Errorf("ticket service not configured")
+			return nil, fmt.Errorf("failed to create ticket for seat %s: %w", seat.SeatID, err)
 		}
+		issued = append(issued, ticket)
+	}
+
+	// Step 9: Save the order and its tickets in one transaction - skip locking since we already locked the seats
+	if err := s.SaveOrderWithTickets(ctx, order, issued); err != nil {
+		s.logger.Error("ORDER", fmt.Sprintf("Failed to place order: %v. Unlocking seats.", err))
+		rollback()
+		return nil, fmt.Errorf("failed to place order: %w", err)
+	}
+	createdTickets := make([]models.TicketForStreaming, 0, len(issued))
+	for _, ticket := range issued {
+		createdTickets = append(createdTickets, ticket.ToStreamingTicket())
 	}
 
 	// Step 10: Now that we have the order and all tickets created, publish the event with full ticket details
@@ -528,7 +522,7 @@ func (s *OrderService) SeatValidationAndPlaceOrder(r *http.Request, orderReq mod
 		}
 
 		s.logger.Info("KAFKA", fmt.Sprintf("Publishing order created event with %d tickets", len(createdTickets)))
-		if err := s.publishOrderCreatedWithTickets(orderWithTickets); err != nil {
+		if err := s.publishOrderCreatedWithTickets(orderWithTickets, orderDetailsDTO.EventDetails()); err != nil {
 			s.logger.Error("KAFKA", fmt.Sprintf("Failed to publish order created event: %v", err))
 			// Continue anyway - don't fail the transaction if just the event publishing fails
 		}
@@ -544,6 +538,7 @@ func (s *OrderService) SeatValidationAndPlaceOrder(r *http.Request, orderReq mod
 	s.logger.Info("ORDER", fmt.Sprintf("Order %s completed successfully for user %s", orderID, userID))
 	return &models.OrderResponse{
 		OrderID:        orderID,
+		OrderNumber:    orderNumber,
 		SessionID:      orderReq.SessionID,
 		OrganizationID: orderReq.OrganizationID,
 		SeatIDs:        orderReq.SeatIDs,
@@ -551,32 +546,243 @@ func (s *OrderService) SeatValidationAndPlaceOrder(r *http.Request, orderReq mod
 	}, nil
 }
 
-func (s *OrderService) SaveOrder(order models.Order, seatIDs []string) error {
+// getM2MToken fetches the service token for calls to the event services, from the Redis cache when possible
+func (s *OrderService) getM2MToken() (string, error) {
+	var config models.Config
+	config.ClientID = os.Getenv("TICKET_CLIENT_ID")
+	config.ClientSecret = os.Getenv("TICKET_CLIENT_SECRET")
+	config.KeycloakURL = os.Getenv("KEYCLOAK_URL")
+	config.KeycloakRealm = os.Getenv("KEYCLOAK_REALM")
+
+	s.logger.Debug("AUTH", "Requesting M2M token for seat validation")
+
+	// Get Redis client from the existing Redis object if available
+	var redisClient redis.UniversalClient
+	if s.Redis != nil {
+		// Try to get the Redis client from our Redis wrapper
+		if redisWrapper, ok := s.Redis.(*rediswrap.Redis); ok && redisWrapper != nil {
+			redisClient = redisWrapper.Client
+		}
+	}
+
+	m2m_token, err := auth.GetM2MToken(config, s.client, redisClient, s.logger)
+	if err != nil {
+		s.logger.Error("AUTH", fmt.Sprintf("Failed to get M2M token: %v", err))
+		return "", fmt.Errorf("failed to get M2M token: %w", err)
+	}
+	return m2m_token, nil
+}
+
+// preValidateOrder asks the event query service whether the order can be placed and returns the seat,
+// pricing and discount details it resolved
+func (s *OrderService) preValidateOrder(reqBody []byte, m2m_token string) (*models.OrderDetailsDTO, error) {
+	s.logger.Debug("PRE_VALIDATION", "Making first HTTP request to validate pre-order")
+	preValidateURL, err := serviceEndpoint(s.EventQueryURL, "event query", "internal/v1/validate-pre-order")
+	if err != nil {
+		s.logger.Error("PRE_VALIDATION", err.Error())
+		return nil, err
+	}
+
+	s.logger.Debug("PRE_VALIDATION", fmt.Sprintf("Pre-validation URL: %s", preValidateURL))
+	s.logger.Debug("PRE_VALIDATION", fmt.Sprintf("Request body: %s", logger.RedactBody(reqBody)))
+
+	req, err := http.NewRequest("POST", preValidateURL, bytes.NewBuffer(reqBody))
+	if err != nil {
+		s.logger.Error("PRE_VALIDATION", fmt.Sprintf("Failed to create pre-validation request: %v", err))
+		return nil, fmt.Errorf("failed to create pre-validation request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+m2m_token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.logger.Error("PRE_VALIDATION", fmt.Sprintf("Pre-validation service error: %v", err))
+		return nil, fmt.Errorf("pre-validation service error: %w", err)
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			s.logger.Error("PRE_VALIDATION", fmt.Sprintf("Failed to close pre-validation response body: %v", err))
+		}
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		validationErr := newValidationError("pre_validation", resp)
+		s.logger.Error("PRE_VALIDATION", fmt.Sprintf("Pre-validation failed: %v", validationErr))
+		return nil, validationErr
+	}
+
+	// Read and store the response body
+	var orderDetailsDTO models.OrderDetailsDTO
+	err = json.NewDecoder(resp.Body).Decode(&orderDetailsDTO)
+	if err != nil {
+		s.logger.Error("PRE_VALIDATION", fmt.Sprintf("Failed to decode pre-validation response: %v", err))
+		return nil, fmt.Errorf("failed to decode pre-validation response: %w", err)
+	}
+
+	s.logger.Info("PRE_VALIDATION", "Pre-validation successful, OrderDetailsDTO received")
+	return &orderDetailsDTO, nil
+}
+
+// validateLockedSeats asks event seating whether seats this service just locked can still be sold
+func (s *OrderService) validateLockedSeats(reqBody []byte, m2m_token string) error {
+	s.logger.Debug("SEAT_VALIDATION", "Making second HTTP request to validate seats after locking")
+	finalValidateURL, err := serviceEndpoint(s.EventSeatingURL, "event seating", "internal/v1/validate-pre-order")
+	if err != nil {
+		s.logger.Error("SEAT_VALIDATION", err.Error())
+		return err
+	}
+
+	reqFinal, err := http.NewRequest("POST", finalValidateURL, bytes.NewBuffer(reqBody))
+	if err != nil {
+		s.logger.Error("SEAT_VALIDATION", fmt.Sprintf("Failed to create seat validation request: %v", err))
+		return fmt.Errorf("failed to create seat validation request: %w", err)
+	}
+	reqFinal.Header.Set("Authorization", "Bearer "+m2m_token)
+	reqFinal.Header.Set("Content-Type", "application/json")
+
+	respFinal, err := s.client.Do(reqFinal)
+	if err != nil {
+		s.logger.Error("SEAT_VALIDATION", fmt.Sprintf("Seat validation service error: %v", err))
+		return fmt.Errorf("seat validation service error: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			s.logger.Error("SEAT_VALIDATION", fmt.Sprintf("Failed to close seat validation response body: %v", err))
+		}
+	}(respFinal.Body)
+
+	if respFinal.StatusCode != http.StatusOK {
+		validationErr := newValidationError("seat_validation", respFinal)
+		s.logger.Error("SEAT_VALIDATION", fmt.Sprintf("Final seat validation failed: %v", validationErr))
+		return validationErr
+	}
+	return nil
+}
+
+// orderPrice is an order's total in minor units after any discount
+type orderPrice struct {
+	SubTotal       models.Money
+	DiscountAmount models.Money
+	Price          models.Money
+	DiscountID     string
+	DiscountCode   string
+	DiscountCapped bool
+}
+
+// priceOrder sums the seat prices and applies the discount resolved by pre-validation, if any
+func (s *OrderService) priceOrder(orderDetailsDTO *models.OrderDetailsDTO, orderReq models.OrderRequest) (orderPrice, error) {
+	// Amounts are summed in minor units so the order total matches what Stripe charges
+	var subtotal models.Money
+	for _, seat := range orderDetailsDTO.Seats {
+		subtotal += models.MoneyFromFloat(seat.Tier.Price)
+	}
+
+	// Default values assuming no discount
+	var discountAmount models.Money
+	discountID := ""
+	discountCode := ""
+	discountCapped := false
+	finalPrice := subtotal
+
+	// Process discount if provided in the OrderDetailsDTO
+	if orderDetailsDTO.Discount != nil && orderDetailsDTO.Discount.ID != "" {
+		s.logger.Debug("DISCOUNT", fmt.Sprintf("Processing discount from OrderDetailsDTO: %s", orderDetailsDTO.Discount.Code))
+
+		// Validate and calculate discount
+		discountResult, err := s.DiscountService.ValidateAndCalculateEventDiscount(
+			orderDetailsDTO.Discount,
+			orderDetailsDTO.Seats,
+			orderReq.SessionID,
+			orderReq.DiscountCode,
+			orderDetailsDTO.MaxDiscountAmount,
+		)
+
+		if err != nil {
+			s.logger.Error("DISCOUNT", fmt.Sprintf("Error calculating discount: %v", err))
+			return orderPrice{}, fmt.Errorf("error calculating discount: %w", err)
+		}
+
+		if !discountResult.IsValid {
+			s.logger.Warn("DISCOUNT", fmt.Sprintf("Discount not applicable: %s", discountResult.Reason))
+			return orderPrice{}, fmt.Errorf("discount not applicable: %s", discountResult.Reason)
+		}
+
+		// Apply discount
+		discountAmount = models.MoneyFromFloat(discountResult.DiscountAmount)
+		discountID = orderDetailsDTO.Discount.ID
+		// Store the canonical form so analytics group every spelling of a code together
+		discountCode = discount.NormalizeCode(orderDetailsDTO.Discount.Code)
+		discountCapped = discountResult.CapApplied
+		finalPrice = subtotal - discountAmount
+
+		if finalPrice < 0 {
+			finalPrice = 0
+		}
+
+		s.logger.Info("DISCOUNT", fmt.Sprintf("Applied discount: %s, final price: %s", discountAmount, finalPrice))
+	} else {
+		s.logger.Debug("DISCOUNT", "No discount applied to order")
+	}
+
+	return orderPrice{
+		SubTotal:       subtotal,
+		DiscountAmount: discountAmount,
+		Price:          finalPrice,
+		DiscountID:     discountID,
+		DiscountCode:   discountCode,
+		DiscountCapped: discountCapped,
+	}, nil
+}
+
+func (s *OrderService) SaveOrder(ctx context.Context, order models.Order, seatIDs []string) error {
 	s.logger.Info("ORDER", fmt.Sprintf("Placing order: %s for session: %s", order.OrderID, order.SessionID))
 
 	s.logger.Debug("ORDER", "Creating order in DB...")
-	if err := s.DB.CreateOrder(order); err != nil {
+	if err := s.DB.CreateOrder(ctx, order); err != nil {
 		s.logger.Error("ORDER", fmt.Sprintf("Failed to create order: %v. Rolling back seat locks.", err))
 		if len(seatIDs) > 0 {
-			_ = s.Redis.UnlockSeats(seatIDs, order.OrderID)
+			if err := s.Redis.UnlockSeats(seatIDs, order.OrderID); err == nil {
+				s.auditSeats(ctx, models.SeatAuditUnlocked, order.SessionID, order.OrderID, seatIDs)
+			}
 		}
 		return err
 	}
 
+	s.recordStatusChange(ctx, order.OrderID, "", order.Status)
+
 	s.logger.Info("ORDER", fmt.Sprintf("Order %s placed successfully", order.OrderID))
 	return nil
 }
 
+// SaveOrderWithTickets creates the order together with its tickets. Either both are saved or, on any
+// failure, neither is; releasing the order's seats is then up to the caller.
+func (s *OrderService) SaveOrderWithTickets(ctx context.Context, order models.Order, tickets []models.Ticket) error {
+	s.logger.Info("ORDER", fmt.Sprintf("Placing order: %s for session: %s with %d tickets", order.OrderID, order.SessionID, len(tickets)))
+
+	if err := s.DB.CreateOrderWithTickets(ctx, order, tickets); err != nil {
+		s.logger.Error("ORDER", fmt.Sprintf("Failed to create order %s with its tickets: %v", order.OrderID, err))
+		return err
+	}
+
+	s.recordStatusChange(ctx, order.OrderID, "", order.Status)
+
+	s.logger.Info("ORDER", fmt.Sprintf("Order %s placed successfully with %d tickets", order.OrderID, len(tickets)))
+	return nil
+}
+
 // PublishOrderCreatedEvent publishes relevant events after order creation
 // DEPRECATED: This method assumes tickets already exist, which is not always true at order creation time
 // Use direct calls to publishOrderCreated or publishOrderCreatedWithTickets instead
-func (s *OrderService) PublishOrderCreatedEvent(order models.Order, seatIDs []string) {
+func (s *OrderService) PublishOrderCreatedEvent(ctx context.Context, order models.Order, seatIDs []string) {
 	s.logger.Info("KAFKA", "Order created successfully, publishing to Kafka...")
 
 	// For orders with tickets, use the denormalized OrderWithTickets structure
 	if s.TicketService != nil && len(seatIDs) > 0 {
 		// Try to get the order with tickets
-		orderWithTickets, err := s.GetOrderWithTickets(order.OrderID)
+		orderWithTickets, err := s.GetOrderWithTickets(ctx, order.OrderID)
 		if err != nil {
 			s.logger.Warn("KAFKA", fmt.Sprintf("Could not get tickets for order %s: %v, falling back to basic event", order.OrderID, err))
 			// Fall back to basic order event
@@ -587,7 +793,7 @@ func (s *OrderService) PublishOrderCreatedEvent(order models.Order, seatIDs []st
 		}
 
 		// Publish the denormalized order with tickets
-		if err := s.publishOrderCreatedWithTickets(*orderWithTickets); err != nil {
+		if err := s.publishOrderCreatedWithTickets(*orderWithTickets, nil); err != nil {
 			s.logger.Error("KAFKA", fmt.Sprintf("Kafka publish error (order created with tickets): %v", err))
 		}
 	} else {
@@ -599,11 +805,11 @@ func (s *OrderService) PublishOrderCreatedEvent(order models.Order, seatIDs []st
 }
 
 // GetOrderWithTickets retrieves an order with all its associated tickets (without QR codes)
-func (s *OrderService) GetOrderWithTickets(orderID string) (*models.OrderWithTickets, error) {
+func (s *OrderService) GetOrderWithTickets(ctx context.Context, orderID string) (*models.OrderWithTickets, error) {
 	s.logger.Debug("ORDER", fmt.Sprintf("Getting order with ticketsByOrder for ID: %s", orderID))
 
 	// Get the order
-	order, err := s.DB.GetOrderByID(orderID)
+	order, err := s.DB.GetOrderByID(ctx, orderID)
 	if err != nil {
 		s.logger.Error("ORDER", fmt.Sprintf("Failed to get order %s: %v", orderID, err))
 		return nil, fmt.Errorf("failed to get order: %w", err)
@@ -635,10 +841,10 @@ func (s *OrderService) GetOrderWithTickets(orderID string) (*models.OrderWithTic
 }
 
 // GetOrdersWithTicketsByUserID retrieves all orders with their associated tickets for a given user
-func (s *OrderService) GetOrdersWithTicketsByUserID(userID string) ([]models.OrderWithTickets, error) {
+func (s *OrderService) GetOrdersWithTicketsByUserID(ctx context.Context, userID string) ([]models.OrderWithTickets, error) {
 	s.logger.Debug("ORDER", fmt.Sprintf("Getting orders with tickets for user: %s", userID))
 
-	ordersWithTickets, err := s.DB.GetOrdersWithTicketsByUserID(userID)
+	ordersWithTickets, err := s.DB.GetOrdersWithTicketsByUserID(ctx, userID)
 	if err != nil {
 		s.logger.Error("ORDER", fmt.Sprintf("Failed to get orders with tickets for user %s: %v", userID, err))
 		return nil, fmt.Errorf("failed to get orders with tickets for user: %w", err)
@@ -649,10 +855,10 @@ func (s *OrderService) GetOrdersWithTicketsByUserID(userID string) ([]models.Ord
 }
 
 // GetOrdersWithTicketsAndQRByUserID retrieves all orders with their associated tickets including QR codes for a given user
-func (s *OrderService) GetOrdersWithTicketsAndQRByUserID(userID string) ([]models.OrderWithTicketsAndQR, error) {
+func (s *OrderService) GetOrdersWithTicketsAndQRByUserID(ctx context.Context, userID string) ([]models.OrderWithTicketsAndQR, error) {
 	s.logger.Debug("ORDER", fmt.Sprintf("Getting orders with tickets and QR codes for user: %s", userID))
 
-	ordersWithTicketsAndQR, err := s.DB.GetOrdersWithTicketsAndQRByUserID(userID)
+	ordersWithTicketsAndQR, err := s.DB.GetOrdersWithTicketsAndQRByUserID(ctx, userID)
 	if err != nil {
 		s.logger.Error("ORDER", fmt.Sprintf("Failed to get orders with tickets and QR codes for user %s: %v", userID, err))
 		return nil, fmt.Errorf("failed to get orders with tickets and QR codes for user: %w", err)
@@ -670,7 +876,7 @@ func (s *OrderService) publishOrderCreated(order models.Order) error {
 		return fmt.Errorf("failed to marshal order: %w", err)
 	}
 
-	err = s.Kafka.Publish("ticketly.order.created", order.OrderID, payload)
+	err = s.Kafka.PublishWithHeaders("ticketly.order.created", order.OrderID, payload, kafka.EventHeaders(EventTypeOrderCreated, order.OrderID))
 	if err != nil {
 		s.logger.Error("KAFKA", fmt.Sprintf("Failed to publish order created event: %v", err))
 	} else {
@@ -686,7 +892,7 @@ func (s *OrderService) publishOrderUpdated(order models.Order) error {
 		return fmt.Errorf("failed to marshal order: %w", err)
 	}
 
-	err = s.Kafka.Publish("ticketly.order.updated", order.OrderID, payload)
+	err = s.Kafka.PublishWithHeaders("ticketly.order.updated", order.OrderID, payload, kafka.EventHeaders(EventTypeOrderUpdated, order.OrderID))
 	if err != nil {
 		s.logger.Error("KAFKA", fmt.Sprintf("Failed to publish order updated event: %v", err))
 	} else {
@@ -695,7 +901,8 @@ func (s *OrderService) publishOrderUpdated(order models.Order) error {
 	return err
 }
 
-// publishOrderCompletedWithTickets publishes an order completed event with full ticket details
+// publishOrderCompletedWithTickets publishes an order completed event with full ticket details.
+// Completions get their own topic; ticketly.order.updated only carries other changes to the order row.
 func (s *OrderService) publishOrderCompletedWithTickets(orderWithTickets models.OrderWithTickets) error {
 	payload, err := json.Marshal(orderWithTickets)
 	if err != nil {
@@ -703,7 +910,7 @@ func (s *OrderService) publishOrderCompletedWithTickets(orderWithTickets models.
 		return fmt.Errorf("failed to marshal order completed event: %w", err)
 	}
 
-	err = s.Kafka.Publish("ticketly.order.updated", orderWithTickets.OrderID, payload)
+	err = s.Kafka.PublishWithHeaders(OrderCompletedTopic, orderWithTickets.OrderID, payload, kafka.EventHeaders(EventTypeOrderCompleted, orderWithTickets.OrderID))
 	if err != nil {
 		s.logger.Error("KAFKA", fmt.Sprintf("Failed to publish order completed with tickets event: %v", err))
 	} else {
@@ -715,15 +922,23 @@ func (s *OrderService) publishOrderCompletedWithTickets(orderWithTickets models.
 
 // publishOrderCancelledWithTickets publishes an order cancelled event with full ticket details
 func (s *OrderService) publishOrderCancelledWithTickets(orderWithTickets models.OrderWithTickets, seatIDs []string) error {
-	// Add SeatIDs field to the event payload for backward compatibility
+	// Add SeatIDs field to the event payload for backward compatibility, and each seat's label
+	// so the seating UI can name the released seats without a lookup
 	type OrderCancelledEvent struct {
 		models.OrderWithTickets
-		SeatIDs []string `json:"seat_ids"`
+		SeatIDs    []string          `json:"seat_ids"`
+		SeatLabels map[string]string `json:"seat_labels"`
+	}
+
+	seatLabels := make(map[string]string, len(orderWithTickets.Tickets))
+	for _, ticket := range orderWithTickets.Tickets {
+		seatLabels[ticket.SeatID] = ticket.SeatLabel
 	}
 
 	event := OrderCancelledEvent{
 		OrderWithTickets: orderWithTickets,
 		SeatIDs:          seatIDs,
+		SeatLabels:       seatLabels,
 	}
 
 	payload, err := json.Marshal(event)
@@ -732,7 +947,7 @@ func (s *OrderService) publishOrderCancelledWithTickets(orderWithTickets models.
 		return fmt.Errorf("failed to marshal order cancelled event: %w", err)
 	}
 
-	err = s.Kafka.Publish("ticketly.order.canceled", orderWithTickets.OrderID, payload)
+	err = s.Kafka.PublishWithHeaders("ticketly.order.canceled", orderWithTickets.OrderID, payload, kafka.EventHeaders(EventTypeOrderCancelled, orderWithTickets.OrderID))
 	if err != nil {
 		s.logger.Error("KAFKA", fmt.Sprintf("Failed to publish order cancelled with tickets event: %v", err))
 	} else {
@@ -742,15 +957,16 @@ func (s *OrderService) publishOrderCancelledWithTickets(orderWithTickets models.
 	return err
 }
 
-// publishOrderCreatedWithTickets publishes a denormalized order with all ticket details
-func (s *OrderService) publishOrderCreatedWithTickets(orderWithTickets models.OrderWithTickets) error {
-	payload, err := json.Marshal(orderWithTickets)
+// publishOrderCreatedWithTickets publishes a denormalized order with all ticket details and, when known,
+// the event, session and venue it is for
+func (s *OrderService) publishOrderCreatedWithTickets(orderWithTickets models.OrderWithTickets, details *models.OrderEventDetails) error {
+	payload, err := json.Marshal(newOrderCreatedEvent(orderWithTickets, details))
 	if err != nil {
 		s.logger.Error("KAFKA", fmt.Sprintf("Failed to marshal order with tickets: %v", err))
 		return fmt.Errorf("failed to marshal order with tickets: %w", err)
 	}
 
-	err = s.Kafka.Publish("ticketly.order.created", orderWithTickets.OrderID, payload)
+	err = s.Kafka.PublishWithHeaders("ticketly.order.created", orderWithTickets.OrderID, payload, kafka.EventHeaders(EventTypeOrderCreated, orderWithTickets.OrderID))
 	if err != nil {
 		s.logger.Error("KAFKA", fmt.Sprintf("Failed to publish order created event: %v", err))
 	} else {
@@ -759,7 +975,7 @@ func (s *OrderService) publishOrderCreatedWithTickets(orderWithTickets models.Or
 	return err
 }
 
-func (s *OrderService) publishSeatsLocked(orderReq models.OrderRequest) error {
+func (s *OrderService) publishSeatsLocked(orderReq models.OrderRequest, orderID string) error {
 	seatEvent, err := models.NewSeatStatusChangeEventDto(orderReq.SessionID, orderReq.SeatIDs, models.SeatStatusLocked)
 	if err != nil {
 		s.logger.Error("KAFKA", fmt.Sprintf("Failed to create seat status event DTO: %v", err))
@@ -772,7 +988,7 @@ func (s *OrderService) publishSeatsLocked(orderReq models.OrderRequest) error {
 		return fmt.Errorf("failed to marshal seat status event: %w", err)
 	}
 
-	err = s.Kafka.Publish("ticketly.seats.status", orderReq.SessionID, payload)
+	err = s.Kafka.PublishWithHeaders("ticketly.seats.status", orderReq.SessionID, payload, kafka.EventHeaders(SeatStatusEventType(models.SeatStatusLocked), orderID))
 	if err != nil {
 		s.logger.Error("KAFKA", fmt.Sprintf("Failed to publish seat status event: %v", err))
 	} else {
@@ -781,7 +997,13 @@ func (s *OrderService) publishSeatsLocked(orderReq models.OrderRequest) error {
 	return err
 }
 
-func (s *OrderService) publishSeatsReleased(orderWithSeats models.OrderWithSeats) error {
+func (s *OrderService) publishSeatsReleased(ctx context.Context, orderWithSeats models.OrderWithSeats) error {
+	orderWithSeats.SeatIDs = SeatsToPublish(ctx, models.SeatStatusAvailable, orderWithSeats.SeatIDs)
+	if len(orderWithSeats.SeatIDs) == 0 {
+		s.logger.Debug("KAFKA", "Seat status (AVAILABLE) already published for every seat, skipping")
+		return nil
+	}
+
 	seatEvent, err := models.NewSeatStatusChangeEventDto(orderWithSeats.SessionID, orderWithSeats.SeatIDs, models.SeatStatusAvailable)
 	if err != nil {
 		s.logger.Error("KAFKA", fmt.Sprintf("Failed to create seat status event DTO: %v", err))
@@ -794,7 +1016,7 @@ func (s *OrderService) publishSeatsReleased(orderWithSeats models.OrderWithSeats
 		return fmt.Errorf("failed to marshal seat status event: %w", err)
 	}
 
-	err = s.Kafka.Publish("ticketly.seats.status", orderWithSeats.SessionID, payload)
+	err = s.Kafka.PublishWithHeaders("ticketly.seats.status", orderWithSeats.SessionID, payload, kafka.EventHeaders(SeatStatusEventType(models.SeatStatusAvailable), orderWithSeats.OrderID))
 	if err != nil {
 		s.logger.Error("KAFKA", fmt.Sprintf("Failed to publish seat status event: %v", err))
 	} else {
@@ -803,7 +1025,13 @@ func (s *OrderService) publishSeatsReleased(orderWithSeats models.OrderWithSeats
 	return err
 }
 
-func (s *OrderService) publishSeatsBooked(orderWithSeats models.OrderWithSeats) error {
+func (s *OrderService) publishSeatsBooked(ctx context.Context, orderWithSeats models.OrderWithSeats) error {
+	orderWithSeats.SeatIDs = SeatsToPublish(ctx, models.SeatStatusBooked, orderWithSeats.SeatIDs)
+	if len(orderWithSeats.SeatIDs) == 0 {
+		s.logger.Debug("KAFKA", "Seat status (BOOKED) already published for every seat, skipping")
+		return nil
+	}
+
 	seatEvent, err := models.NewSeatStatusChangeEventDto(orderWithSeats.SessionID, orderWithSeats.SeatIDs, models.SeatStatusBooked)
 	if err != nil {
 		s.logger.Error("KAFKA", fmt.Sprintf("Failed to create seat status event DTO: %v", err))
@@ -816,7 +1044,7 @@ func (s *OrderService) publishSeatsBooked(orderWithSeats models.OrderWithSeats)
 		return fmt.Errorf("failed to marshal seat status event: %w", err)
 	}
 
-	err = s.Kafka.Publish("ticketly.seats.status", orderWithSeats.SessionID, payload)
+	err = s.Kafka.PublishWithHeaders("ticketly.seats.status", orderWithSeats.SessionID, payload, kafka.EventHeaders(SeatStatusEventType(models.SeatStatusBooked), orderWithSeats.OrderID))
 	if err != nil {
 		s.logger.Error("KAFKA", fmt.Sprintf("Failed to publish seat status event: %v", err))
 	} else {
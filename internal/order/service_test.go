@@ -1,7 +1,9 @@
 package order_test
 
 import (
+	"context"
 	"errors"
+	"ms-ticketing/internal/kafka"
 	"ms-ticketing/internal/models"
 	"ms-ticketing/internal/order"
 	tickets "ms-ticketing/internal/tickets/service"
@@ -19,12 +21,12 @@ type MockDBLayer struct {
 	mock.Mock
 }
 
-func (m *MockDBLayer) CreateOrder(order models.Order) error {
+func (m *MockDBLayer) CreateOrder(ctx context.Context, order models.Order) error {
 	args := m.Called(order)
 	return args.Error(0)
 }
 
-func (m *MockDBLayer) GetOrderByID(id string) (*models.Order, error) {
+func (m *MockDBLayer) GetOrderByID(ctx context.Context, id string) (*models.Order, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -32,7 +34,7 @@ func (m *MockDBLayer) GetOrderByID(id string) (*models.Order, error) {
 	return args.Get(0).(*models.Order), args.Error(1)
 }
 
-func (m *MockDBLayer) GetOrderWithSeats(id string) (*models.OrderWithSeats, error) {
+func (m *MockDBLayer) GetOrderWithSeats(ctx context.Context, id string) (*models.OrderWithSeats, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -40,17 +42,17 @@ func (m *MockDBLayer) GetOrderWithSeats(id string) (*models.OrderWithSeats, erro
 	return args.Get(0).(*models.OrderWithSeats), args.Error(1)
 }
 
-func (m *MockDBLayer) UpdateOrder(order models.Order) error {
+func (m *MockDBLayer) UpdateOrder(ctx context.Context, order models.Order) error {
 	args := m.Called(order)
 	return args.Error(0)
 }
 
-func (m *MockDBLayer) CancelOrder(id string) error {
+func (m *MockDBLayer) CancelOrder(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockDBLayer) GetOrderBySeat(seatID string) (*models.Order, error) {
+func (m *MockDBLayer) GetOrderBySeat(ctx context.Context, seatID string) (*models.Order, error) {
 	args := m.Called(seatID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -58,7 +60,15 @@ func (m *MockDBLayer) GetOrderBySeat(seatID string) (*models.Order, error) {
 	return args.Get(0).(*models.Order), args.Error(1)
 }
 
-func (m *MockDBLayer) GetPendingOrdersBySeat(seatID string) ([]*models.Order, error) {
+func (m *MockDBLayer) GetOrdersBySeats(ctx context.Context, seatIDs []string) (map[string]*models.Order, error) {
+	args := m.Called(seatIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*models.Order), args.Error(1)
+}
+
+func (m *MockDBLayer) GetPendingOrdersBySeat(ctx context.Context, seatID string) ([]*models.Order, error) {
 	args := m.Called(seatID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -66,7 +76,7 @@ func (m *MockDBLayer) GetPendingOrdersBySeat(seatID string) ([]*models.Order, er
 	return args.Get(0).([]*models.Order), args.Error(1)
 }
 
-func (m *MockDBLayer) GetSeatsByOrder(orderID string) ([]string, error) {
+func (m *MockDBLayer) GetSeatsByOrder(ctx context.Context, orderID string) ([]string, error) {
 	args := m.Called(orderID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -74,12 +84,12 @@ func (m *MockDBLayer) GetSeatsByOrder(orderID string) ([]string, error) {
 	return args.Get(0).([]string), args.Error(1)
 }
 
-func (m *MockDBLayer) GetSessionIdBySeat(seatID string) (string, error) {
+func (m *MockDBLayer) GetSessionIdBySeat(ctx context.Context, seatID string) (string, error) {
 	args := m.Called(seatID)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockDBLayer) GetOrdersWithTicketsByUserID(userID string) ([]models.OrderWithTickets, error) {
+func (m *MockDBLayer) GetOrdersWithTicketsByUserID(ctx context.Context, userID string) ([]models.OrderWithTickets, error) {
 	args := m.Called(userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -87,7 +97,7 @@ func (m *MockDBLayer) GetOrdersWithTicketsByUserID(userID string) ([]models.Orde
 	return args.Get(0).([]models.OrderWithTickets), args.Error(1)
 }
 
-func (m *MockDBLayer) GetOrdersWithTicketsAndQRByUserID(userID string) ([]models.OrderWithTicketsAndQR, error) {
+func (m *MockDBLayer) GetOrdersWithTicketsAndQRByUserID(ctx context.Context, userID string) ([]models.OrderWithTicketsAndQR, error) {
 	args := m.Called(userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -95,12 +105,85 @@ func (m *MockDBLayer) GetOrdersWithTicketsAndQRByUserID(userID string) ([]models
 	return args.Get(0).([]models.OrderWithTicketsAndQR), args.Error(1)
 }
 
+func (m *MockDBLayer) CountPendingOrdersByUserSession(ctx context.Context, userID, sessionID string) (int, error) {
+	args := m.Called(userID, sessionID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockDBLayer) GetUnpaidPendingOrders(ctx context.Context, createdAfter, createdBefore time.Time) ([]models.Order, error) {
+	args := m.Called(createdAfter, createdBefore)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Order), args.Error(1)
+}
+
+func (m *MockDBLayer) GetOverduePendingOrders(ctx context.Context, dueBefore time.Time) ([]models.Order, error) {
+	args := m.Called(dueBefore)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Order), args.Error(1)
+}
+
+func (m *MockDBLayer) ArchiveOrdersBefore(ctx context.Context, before time.Time) (int, error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockDBLayer) GetCompletedOrdersBetween(ctx context.Context, from, to time.Time) ([]models.Order, error) {
+	args := m.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Order), args.Error(1)
+}
+
+func (m *MockDBLayer) CreateOrderNote(ctx context.Context, note models.OrderNote) error {
+	args := m.Called(note)
+	return args.Error(0)
+}
+
+func (m *MockDBLayer) GetOrderNotes(ctx context.Context, orderID string) ([]models.OrderNote, error) {
+	args := m.Called(orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.OrderNote), args.Error(1)
+}
+
+func (m *MockDBLayer) NextOrderNumber(ctx context.Context, day string) (int64, error) {
+	args := m.Called(day)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDBLayer) CreateOrderWithTickets(ctx context.Context, order models.Order, tickets []models.Ticket) error {
+	args := m.Called(ctx, order, tickets)
+	return args.Error(0)
+}
+
+func (m *MockDBLayer) GetOrderByNumber(ctx context.Context, number string) (*models.Order, error) {
+	args := m.Called(number)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
 type MockRedisLock struct {
 	mock.Mock
 }
 
-func (m *MockRedisLock) LockSeats(seatIDs []string, orderID string) (bool, error) {
-	args := m.Called(seatIDs, orderID)
+func (m *MockRedisLock) CheckSeatsAvailability(seatIDs []string) (bool, []string, error) {
+	args := m.Called(seatIDs)
+	if args.Get(1) == nil {
+		return args.Bool(0), nil, args.Error(2)
+	}
+	return args.Bool(0), args.Get(1).([]string), args.Error(2)
+}
+
+func (m *MockRedisLock) LockSeats(seatIDs []string, sessionID, orderID, userID string) (bool, error) {
+	args := m.Called(seatIDs, sessionID, orderID, userID)
 	return args.Bool(0), args.Error(1)
 }
 
@@ -109,18 +192,45 @@ func (m *MockRedisLock) UnlockSeats(seatIDs []string, orderID string) error {
 	return args.Error(0)
 }
 
-type MockKafkaProducer struct {
-	mock.Mock
+func (m *MockRedisLock) MarkOrderReminded(orderID string, ttl time.Duration) (bool, error) {
+	args := m.Called(orderID, ttl)
+	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockKafkaProducer) Publish(topic string, key string, value []byte) error {
-	args := m.Called(topic, key, value)
-	return args.Error(0)
+func (m *MockRedisLock) MarkConfirmationResent(orderID string, cooldown time.Duration) (bool, error) {
+	args := m.Called(orderID, cooldown)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRedisLock) GetSeatLock(seatID string) (*models.SeatLock, time.Duration, error) {
+	args := m.Called(seatID)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(time.Duration), args.Error(2)
+	}
+	return args.Get(0).(*models.SeatLock), args.Get(1).(time.Duration), args.Error(2)
 }
 
-func (m *MockKafkaProducer) Close() error {
+func (m *MockRedisLock) ForceUnlockSeat(seatID string) (string, error) {
+	args := m.Called(seatID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockRedisLock) SessionSeatLocks(sessionID string) ([]models.HeldSeat, error) {
+	args := m.Called(sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.HeldSeat), args.Error(1)
+}
+
+func (m *MockRedisLock) PruneSessionLockSets() (int, error) {
 	args := m.Called()
-	return args.Error(0)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRedisLock) HoldSeats(seatIDs []string, userID string) (bool, error) {
+	args := m.Called(seatIDs, userID)
+	return args.Bool(0), args.Error(1)
 }
 
 type MockTicketService struct {
@@ -138,6 +248,16 @@ type MockTicketDBLayer struct {
 	mock.Mock
 }
 
+func (m *MockTicketDBLayer) DeleteTicketsByOrder(orderID string) (int, error) {
+	args := m.Called(orderID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTicketDBLayer) DeleteOrphanedTickets(issuedBefore time.Time) (int, error) {
+	args := m.Called(issuedBefore)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *MockTicketDBLayer) CreateTicket(ticket models.Ticket) error {
 	args := m.Called(ticket)
 	return args.Error(0)
@@ -177,11 +297,61 @@ func (m *MockTicketDBLayer) GetTicketsByUser(userID string) ([]models.Ticket, er
 	return args.Get(0).([]models.Ticket), args.Error(1)
 }
 
+func (m *MockTicketDBLayer) GetTicketsBySession(sessionID string) ([]models.Ticket, error) {
+	args := m.Called(sessionID)
+	return args.Get(0).([]models.Ticket), args.Error(1)
+}
+
 func (m *MockTicketDBLayer) GetTotalTicketsCount() (int, error) {
 	args := m.Called()
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockTicketDBLayer) CheckinTicket(ticketID string, checkedIn bool, checkedInTime time.Time) error {
+	args := m.Called(ticketID, checkedIn, checkedInTime)
+	return args.Error(0)
+}
+
+func (m *MockTicketDBLayer) UpdateResaleStatus(ticketID string, status string, askingPrice float64, listedAt time.Time) error {
+	args := m.Called(ticketID, status, askingPrice, listedAt)
+	return args.Error(0)
+}
+
+func (m *MockTicketDBLayer) GetSessionIDByTicket(ticketID string) (string, error) {
+	args := m.Called(ticketID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTicketDBLayer) AdjustCheckinCount(sessionID string, delta int) error {
+	args := m.Called(sessionID, delta)
+	return args.Error(0)
+}
+
+func (m *MockTicketDBLayer) GetCheckedInCountBySession(sessionID string) (int, error) {
+	args := m.Called(sessionID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTicketDBLayer) ReconcileCheckinCount(sessionID string) (int, error) {
+	args := m.Called(sessionID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTicketDBLayer) CountUncheckedTicketsByEvent(eventID string) (int, error) {
+	args := m.Called(eventID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTicketDBLayer) GetUncheckedTicketsByEvent(eventID string, afterTicketID string, limit int) ([]models.Ticket, error) {
+	args := m.Called(eventID, afterTicketID, limit)
+	return args.Get(0).([]models.Ticket), args.Error(1)
+}
+
+func (m *MockTicketDBLayer) UpdateTicketQRCode(ticketID string, qrCode []byte) error {
+	args := m.Called(ticketID, qrCode)
+	return args.Error(0)
+}
+
 // MockHTTPClient is a mock implementation of the HTTP client
 type MockHTTPClient struct {
 	mock.Mock
@@ -197,7 +367,7 @@ func TestGetOrderByID(t *testing.T) {
 	// Set up mocks
 	mockDB := new(MockDBLayer)
 	mockRedis := new(MockRedisLock)
-	mockKafka := new(MockKafkaProducer)
+	mockKafka := kafka.NewInMemoryProducer()
 	mockClient := NewMockHTTPClient()
 
 	// Create service with mocks
@@ -210,13 +380,13 @@ func TestGetOrderByID(t *testing.T) {
 		EventID:   "event456",
 		SessionID: "session789",
 		Status:    "pending",
-		Price:     100.0,
+		Price:     models.MoneyFromFloat(100.0),
 		CreatedAt: time.Now(),
 	}
 
 	mockDB.On("GetOrderByID", testOrder.OrderID).Return(testOrder, nil)
 
-	result, err := orderSvc.GetOrder(testOrder.OrderID)
+	result, err := orderSvc.GetOrder(context.Background(), testOrder.OrderID)
 
 	assert.NoError(t, err)
 	assert.Equal(t, testOrder.OrderID, result.OrderID)
@@ -226,7 +396,7 @@ func TestGetOrderByID(t *testing.T) {
 	// Test case 2: Order doesn't exist
 	mockDB.On("GetOrderByID", "non-existent").Return(nil, errors.New("order not found"))
 
-	result, err = orderSvc.GetOrder("non-existent")
+	result, err = orderSvc.GetOrder(context.Background(), "non-existent")
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -239,11 +409,29 @@ func TestCancelOrder(t *testing.T) {
 	t.Skip("Skipping test due to ticket retrieval logic that needs reworking")
 }
 
+func TestCheckoutNonPendingOrders(t *testing.T) {
+	mockDB := new(MockDBLayer)
+	orderSvc := order.NewOrderService(mockDB, new(MockRedisLock), kafka.NewInMemoryProducer(), &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+
+	mockDB.On("GetOrderByID", "completed").Return(&models.Order{OrderID: "completed", Status: "completed", PaymentIntentID: "pi_1"}, nil)
+	mockDB.On("GetOrderByID", "cancelled").Return(&models.Order{OrderID: "cancelled", Status: "cancelled", PaymentIntentID: "pi_2"}, nil)
+
+	// A retried webhook for a completed order is a no-op, not a failure
+	assert.ErrorIs(t, orderSvc.Checkout(context.Background(), "completed"), order.ErrAlreadyCompleted)
+
+	err := orderSvc.Checkout(context.Background(), "cancelled")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, order.ErrAlreadyCompleted)
+
+	// Neither order may be written back
+	mockDB.AssertNotCalled(t, "UpdateOrder", mock.Anything)
+}
+
 func TestSaveOrder(t *testing.T) {
 	// Set up mocks
 	mockDB := new(MockDBLayer)
 	mockRedis := new(MockRedisLock)
-	mockKafka := new(MockKafkaProducer)
+	mockKafka := kafka.NewInMemoryProducer()
 	mockClient := NewMockHTTPClient()
 
 	// Create service with mocks
@@ -256,7 +444,7 @@ func TestSaveOrder(t *testing.T) {
 		EventID:   "event456",
 		SessionID: "session789",
 		Status:    "pending",
-		Price:     100.0,
+		Price:     models.MoneyFromFloat(100.0),
 		CreatedAt: time.Now(),
 	}
 	seatIDs := []string{"seat1", "seat2"}
@@ -267,18 +455,37 @@ func TestSaveOrder(t *testing.T) {
 	})).Return(nil)
 
 	// Execute test
-	err := orderSvc.SaveOrder(testOrder, seatIDs)
+	err := orderSvc.SaveOrder(context.Background(), testOrder, seatIDs)
 
 	// Assertions
 	assert.NoError(t, err)
 	mockDB.AssertExpectations(t)
 }
 
+func TestSaveOrderWithTickets(t *testing.T) {
+	mockDB := new(MockDBLayer)
+	mockRedis := new(MockRedisLock)
+	orderSvc := order.NewOrderService(mockDB, mockRedis, kafka.NewInMemoryProducer(), &tickets.TicketService{}, NewMockHTTPClient())
+
+	testOrder := models.Order{OrderID: uuid.New().String(), SessionID: "session789", Status: "pending", CreatedAt: time.Now()}
+	testTickets := []models.Ticket{{TicketID: uuid.New().String(), OrderID: testOrder.OrderID, SeatID: "seat1"}}
+
+	mockDB.On("CreateOrderWithTickets", mock.Anything, testOrder, testTickets).Return(nil).Once()
+	assert.NoError(t, orderSvc.SaveOrderWithTickets(context.Background(), testOrder, testTickets))
+
+	// A failed transaction is reported without touching the seat locks, which the caller releases
+	mockDB.On("CreateOrderWithTickets", mock.Anything, testOrder, testTickets).Return(errors.New("tx aborted")).Once()
+	assert.Error(t, orderSvc.SaveOrderWithTickets(context.Background(), testOrder, testTickets))
+
+	mockDB.AssertExpectations(t)
+	mockRedis.AssertNotCalled(t, "UnlockSeats", mock.Anything, mock.Anything)
+}
+
 func TestGetOrderWithTickets(t *testing.T) {
 	// Set up mocks
 	mockDB := new(MockDBLayer)
 	mockRedis := new(MockRedisLock)
-	mockKafka := new(MockKafkaProducer)
+	mockKafka := kafka.NewInMemoryProducer()
 	mockTicketSvc := NewMockTicketService()
 	mockClient := NewMockHTTPClient()
 
@@ -296,7 +503,7 @@ func TestGetOrderWithTickets(t *testing.T) {
 		EventID:   "event456",
 		SessionID: "session789",
 		Status:    "completed",
-		Price:     100.0,
+		Price:     models.MoneyFromFloat(100.0),
 		CreatedAt: time.Now(),
 	}
 
@@ -329,7 +536,7 @@ func TestGetOrderWithTickets(t *testing.T) {
 	ts.DB.(*MockTicketDBLayer).On("GetTicketsByOrder", orderID).Return(tickets, nil)
 
 	// Execute test
-	result, err := orderSvc.GetOrderWithTickets(orderID)
+	result, err := orderSvc.GetOrderWithTickets(context.Background(), orderID)
 
 	// Assertions
 	assert.NoError(t, err)
@@ -341,3 +548,49 @@ func TestGetOrderWithTickets(t *testing.T) {
 	mockDB.AssertExpectations(t)
 	ts.DB.(*MockTicketDBLayer).AssertExpectations(t)
 }
+
+func TestSendPaymentReminders(t *testing.T) {
+	// Set up mocks
+	mockDB := new(MockDBLayer)
+	mockRedis := new(MockRedisLock)
+	mockKafka := kafka.NewInMemoryProducer()
+	mockClient := NewMockHTTPClient()
+
+	orderSvc := order.NewOrderService(mockDB, mockRedis, mockKafka, &tickets.TicketService{}, mockClient)
+
+	remindAfter := 2 * time.Minute
+	lockTTL := 5 * time.Minute
+
+	newOrder := models.Order{
+		OrderID:   uuid.New().String(),
+		UserID:    "user123",
+		EventID:   "event456",
+		SessionID: "session789",
+		Status:    "pending",
+		Price:     models.MoneyFromFloat(100.0),
+		CreatedAt: time.Now().Add(-3 * time.Minute),
+	}
+	remindedOrder := newOrder
+	remindedOrder.OrderID = uuid.New().String()
+
+	// Set up expectations
+	mockDB.On("GetUnpaidPendingOrders", mock.Anything, mock.Anything).Return([]models.Order{newOrder, remindedOrder}, nil)
+	mockRedis.On("MarkOrderReminded", newOrder.OrderID, lockTTL).Return(true, nil)
+	mockRedis.On("MarkOrderReminded", remindedOrder.OrderID, lockTTL).Return(false, nil)
+
+	// Execute test
+	sent, err := orderSvc.SendPaymentReminders(context.Background(), remindAfter, lockTTL)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	mockDB.AssertExpectations(t)
+	mockRedis.AssertExpectations(t)
+	if reminders := mockKafka.MessagesOn("ticketly.order.reminder"); assert.Len(t, reminders, 1) {
+		assert.Equal(t, newOrder.OrderID, reminders[0].Key)
+	}
+
+	// A reminder delay that outlives the seat lock is rejected
+	_, err = orderSvc.SendPaymentReminders(context.Background(), lockTTL, lockTTL)
+	assert.Error(t, err)
+}
@@ -0,0 +1,39 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// PruneSessionLockSets drops seats whose locks are gone from the per-session lock sets, so held-seat
+// listings don't report expiries the keyspace subscriber never saw. It returns the number of seats dropped.
+func (s *OrderService) PruneSessionLockSets() (int, error) {
+	pruned, err := s.Redis.PruneSessionLockSets()
+	if err != nil {
+		s.logger.Error("SESSION_LOCKS", fmt.Sprintf("Failed to prune session lock sets: %v", err))
+		return pruned, err
+	}
+	if pruned > 0 {
+		s.logger.Info("SESSION_LOCKS", fmt.Sprintf("Pruned %d stale seats from session lock sets", pruned))
+	}
+	return pruned, nil
+}
+
+// StartSessionLockPruner runs PruneSessionLockSets every interval until ctx is cancelled
+func (s *OrderService) StartSessionLockPruner(ctx context.Context, interval time.Duration) {
+	s.logger.Info("SESSION_LOCKS", fmt.Sprintf("Session lock set pruning enabled, running every %s", interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("SESSION_LOCKS", "Stopping session lock set pruning")
+			return
+		case <-ticker.C:
+			s.PruneSessionLockSets()
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package order_test
+
+import (
+	"errors"
+	"ms-ticketing/internal/kafka"
+	"ms-ticketing/internal/order"
+	tickets "ms-ticketing/internal/tickets/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneSessionLockSets(t *testing.T) {
+	mockRedis := new(MockRedisLock)
+	orderSvc := order.NewOrderService(new(MockDBLayer), mockRedis, kafka.NewInMemoryProducer(), &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+
+	mockRedis.On("PruneSessionLockSets").Return(3, nil).Once()
+	pruned, err := orderSvc.PruneSessionLockSets()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, pruned)
+
+	mockRedis.On("PruneSessionLockSets").Return(1, errors.New("redis down")).Once()
+	pruned, err = orderSvc.PruneSessionLockSets()
+	assert.Error(t, err)
+	assert.Equal(t, 1, pruned, "seats pruned before the failure are still reported")
+}
@@ -0,0 +1,49 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// VerifySessionOwnership asks event seating whether the user's organization owns the session,
+// the same check the analytics endpoints make before showing session data
+func (s *OrderService) VerifySessionOwnership(ctx context.Context, sessionID, userID string) (bool, error) {
+	s.logger.Debug("ORDER", fmt.Sprintf("Verifying ownership of session %s by user %s", sessionID, userID))
+
+	m2mToken, err := s.getM2MToken()
+	if err != nil {
+		return false, err
+	}
+	if s.EventSeatingURL == nil {
+		return false, fmt.Errorf("event seating service URL not configured")
+	}
+	endpoint := s.EventSeatingURL.JoinPath("internal/v1/sessions/verify-ownership")
+	endpoint.RawQuery = url.Values{"sessionId": {sessionID}, "userId": {userID}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create session ownership request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+m2mToken)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.logger.Error("ORDER", fmt.Sprintf("Session ownership verification failed: %v", err))
+		return false, fmt.Errorf("session ownership verification failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		s.logger.Error("ORDER", fmt.Sprintf("Session ownership verification failed with status: %s", resp.Status))
+		return false, fmt.Errorf("session ownership verification failed with status: %s", resp.Status)
+	}
+
+	var isOwner bool
+	if err := json.NewDecoder(resp.Body).Decode(&isOwner); err != nil {
+		return false, fmt.Errorf("failed to parse session ownership response: %w", err)
+	}
+	return isOwner, nil
+}
@@ -0,0 +1,92 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"ms-ticketing/internal/auth"
+	"ms-ticketing/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Actors recorded for status changes nobody signed in made
+const (
+	ActorSystem        = "system"
+	ActorStripeWebhook = "stripe_webhook"
+	ActorSeatExpiry    = "seat_expiry"
+	ActorPaymentWindow = "payment_window"
+)
+
+// OrderStatusHistory stores the status timeline of orders
+type OrderStatusHistory interface {
+	CreateOrderStatusChange(ctx context.Context, change models.OrderStatusChange) error
+	GetOrderStatusHistory(ctx context.Context, orderID string) ([]models.OrderStatusChange, error)
+}
+
+// SetStatusHistory enables recording order status transitions
+func (s *OrderService) SetStatusHistory(history OrderStatusHistory) {
+	s.StatusHistory = history
+}
+
+type statusActorKey struct{}
+
+// WithStatusActor returns a context under which status changes are attributed to actor, for flows
+// such as webhooks and expiry that run without a signed-in user
+func WithStatusActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, statusActorKey{}, actor)
+}
+
+// withDefaultStatusActor attributes status changes under ctx to actor unless an actor is already set
+func withDefaultStatusActor(ctx context.Context, actor string) context.Context {
+	if _, ok := ctx.Value(statusActorKey{}).(string); ok {
+		return ctx
+	}
+	return WithStatusActor(ctx, actor)
+}
+
+// StatusActor is who status changes under ctx are attributed to: the actor set with WithStatusActor,
+// else the signed-in user, else ActorSystem
+func StatusActor(ctx context.Context) string {
+	if actor, ok := ctx.Value(statusActorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	if userID := auth.UserID(ctx); userID != "" {
+		return userID
+	}
+	return ActorSystem
+}
+
+// recordStatusChange appends a transition to the order's timeline. Like the seat audit trail it is
+// best effort, so a failed write never fails the transition itself.
+func (s *OrderService) recordStatusChange(ctx context.Context, orderID, oldStatus, newStatus string) {
+	if s.StatusHistory == nil {
+		return
+	}
+	change := models.OrderStatusChange{
+		ChangeID:  uuid.New().String(),
+		OrderID:   orderID,
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+		Actor:     StatusActor(ctx),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.StatusHistory.CreateOrderStatusChange(ctx, change); err != nil {
+		s.logger.Error("HISTORY", fmt.Sprintf("Failed to record %s → %s for order %s: %v", oldStatus, newStatus, orderID, err))
+	}
+}
+
+// GetOrderStatusHistory returns an order's status transitions, oldest first
+func (s *OrderService) GetOrderStatusHistory(ctx context.Context, orderID string) ([]models.OrderStatusChange, error) {
+	if _, err := s.lookupOrder(ctx, orderID); err != nil {
+		return nil, err
+	}
+	if s.StatusHistory == nil {
+		return []models.OrderStatusChange{}, nil
+	}
+	changes, err := s.StatusHistory.GetOrderStatusHistory(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load status history: %w", err)
+	}
+	return changes, nil
+}
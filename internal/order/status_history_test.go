@@ -0,0 +1,66 @@
+package order_test
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	order_db "ms-ticketing/internal/order/db"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func statusTransitions(changes []models.OrderStatusChange) [][3]string {
+	transitions := make([][3]string, 0, len(changes))
+	for _, change := range changes {
+		transitions = append(transitions, [3]string{change.OldStatus, change.NewStatus, change.Actor})
+	}
+	return transitions
+}
+
+func TestOrderStatusHistoryFollowsPaymentFlow(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(2)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	env.OrderService.SetStatusHistory(&order_db.DB{Bun: env.DB})
+	ctx := context.Background()
+	sessionID := uuid.NewString()
+
+	paid, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+		SessionID: sessionID, EventID: "event-1", SeatIDs: seatIDs[:1],
+	})
+	require.NoError(t, err)
+	intent, err := env.OrderService.CreatePaymentIntent(ctx, paid.OrderID)
+	require.NoError(t, err)
+	require.NoError(t, env.OrderService.HandleStripeWebhook(signedPaymentSucceededWebhook(intent.ID, paid.OrderID)))
+
+	history, err := env.OrderService.GetOrderStatusHistory(ctx, paid.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, [][3]string{
+		{"", "pending", "user-1"},
+		{"pending", "completed", order.ActorStripeWebhook},
+	}, statusTransitions(history))
+
+	// A cancellation by the buyer is attributed to them
+	cancelled, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-2"), models.OrderRequest{
+		SessionID: sessionID, EventID: "event-1", SeatIDs: seatIDs[1:],
+	})
+	require.NoError(t, err)
+	require.NoError(t, env.OrderService.CancelOrder(order.WithStatusActor(ctx, "user-2"), cancelled.OrderID))
+
+	history, err = env.OrderService.GetOrderStatusHistory(ctx, cancelled.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, [][3]string{
+		{"", "pending", "user-2"},
+		{"pending", "cancelled", "user-2"},
+	}, statusTransitions(history))
+
+	_, err = env.OrderService.GetOrderStatusHistory(ctx, uuid.NewString())
+	assert.ErrorIs(t, err, order.ErrOrderNotFound)
+}
+
+func TestStatusActorDefaultsToSystem(t *testing.T) {
+	assert.Equal(t, order.ActorSystem, order.StatusActor(context.Background()))
+	assert.Equal(t, order.ActorSeatExpiry, order.StatusActor(order.WithStatusActor(context.Background(), order.ActorSeatExpiry)))
+}
@@ -1,12 +1,14 @@
 package order
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"io"
+	"ms-ticketing/internal/models"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,12 +22,18 @@ func InitStripe() {
 	stripe.Key = os.Getenv("STRIPE_SECRET_KEY")
 }
 
+// ToMinorUnits converts a price to the integer cents Stripe expects.
+// Rounding instead of truncating keeps values like 19.99 (stored as 19.989999...) from becoming 1998.
+func ToMinorUnits(amount float64) int64 {
+	return models.MoneyFromFloat(amount).MinorUnits()
+}
+
 // Use a map to store locks for payment intents - thread safe
 var paymentIntentLocks = make(map[string]bool)
 var paymentIntentMutex = &sync.Mutex{}
 
 // CreatePaymentIntent creates a Stripe payment intent for an order
-func (s *OrderService) CreatePaymentIntent(orderID string) (*stripe.PaymentIntent, error) {
+func (s *OrderService) CreatePaymentIntent(ctx context.Context, orderID string) (*stripe.PaymentIntent, error) {
 	s.logger.Info("PAYMENT", fmt.Sprintf("Creating payment intent for order: %s", orderID))
 
 	// Use mutex to lock this order ID to prevent race conditions
@@ -34,8 +42,8 @@ func (s *OrderService) CreatePaymentIntent(orderID string) (*stripe.PaymentInten
 		// Order is already being processed by another request
 		paymentIntentMutex.Unlock()
 		s.logger.Warn("PAYMENT", fmt.Sprintf("Payment intent creation for order %s is already in progress", orderID))
-		time.Sleep(500 * time.Millisecond)    // Wait briefly
-		return s.CreatePaymentIntent(orderID) // Retry after waiting
+		time.Sleep(500 * time.Millisecond)         // Wait briefly
+		return s.CreatePaymentIntent(ctx, orderID) // Retry after waiting
 	}
 
 	// Mark this order as being processed
@@ -50,7 +58,7 @@ func (s *OrderService) CreatePaymentIntent(orderID string) (*stripe.PaymentInten
 	}()
 
 	// Get the order to fetch the price
-	order, err := s.DB.GetOrderByID(orderID)
+	order, err := s.DB.GetOrderByID(ctx, orderID)
 	if err != nil {
 		s.logger.Error("PAYMENT", fmt.Sprintf("Failed to find order %s: %v", orderID, err))
 		return nil, err
@@ -61,8 +69,21 @@ func (s *OrderService) CreatePaymentIntent(orderID string) (*stripe.PaymentInten
 		return nil, errors.New("cannot create payment intent for an order that is not pending")
 	}
 
+	// Money keeps two minor digits, so charging any other currency would use the wrong scale
+	if _, err := models.ValidateCurrency(order.Currency); err != nil {
+		s.logger.Error("PAYMENT", fmt.Sprintf("Cannot create payment intent for order %s: %v", orderID, err))
+		return nil, err
+	}
+
+	// Prices are already stored in cents
+	amountInCents := order.Price.MinorUnits()
+
+	if MockPaymentsEnabled() {
+		return s.createMockPaymentIntent(ctx, order, amountInCents)
+	}
+
 	// Check if the order already has a payment intent ID
-	if order.PaymentIntentID != "" {
+	if order.PaymentIntentID != "" && !IsMockPaymentIntent(order.PaymentIntentID) {
 		s.logger.Info("PAYMENT", fmt.Sprintf("Order %s already has a payment intent %s, retrieving it", orderID, order.PaymentIntentID))
 
 		// Retrieve the existing payment intent
@@ -80,23 +101,8 @@ func (s *OrderService) CreatePaymentIntent(orderID string) (*stripe.PaymentInten
 		}
 	}
 
-	// Convert to cents for Stripe
-	amountInCents := int64(order.Price * 100)
-
-	// Create payment intent parameters
-	params := &stripe.PaymentIntentParams{
-		Amount:   stripe.Int64(amountInCents),
-		Currency: stripe.String("lkr"), // Sri Lankan Rupee
-		AutomaticPaymentMethods: &stripe.PaymentIntentAutomaticPaymentMethodsParams{
-			Enabled: stripe.Bool(true),
-		},
-	}
-
-	// Add metadata
-	params.AddMetadata("order_id", orderID)
-
 	// Create the payment intent
-	intent, err := paymentintent.New(params)
+	intent, err := newStripePaymentIntent(order)
 	if err != nil {
 		s.logger.Error("PAYMENT", fmt.Sprintf("Failed to create Stripe payment intent: %v", err))
 		return nil, err
@@ -104,177 +110,149 @@ func (s *OrderService) CreatePaymentIntent(orderID string) (*stripe.PaymentInten
 
 	// Update order with payment intent ID
 	order.PaymentIntentID = intent.ID
-	err = s.DB.UpdateOrder(*order)
+	order.PaymentProvider = PaymentProviderStripe
+	err = s.DB.UpdateOrder(ctx, *order)
 	if err != nil {
 		s.logger.Error("PAYMENT", fmt.Sprintf("Failed to update order with payment intent ID: %v", err))
 		return nil, err
 	}
 
-	s.logger.Info("PAYMENT", fmt.Sprintf("Created payment intent %s for order %s (LKR %0.2f)", intent.ID, orderID, order.Price))
+	s.logger.Info("PAYMENT", fmt.Sprintf("Created payment intent %s for order %s (%s)", intent.ID, orderID, order.Price.Format(order.CurrencyCode())))
 	return intent, nil
 }
 
-// WebhookError represents an error that occurred during webhook processing
-type WebhookError struct {
-	Category      string // "configuration", "validation", "processing"
-	StatusCode    int    // HTTP status code
-	PublicError   string // Safe to expose to clients
-	InternalError string // Detailed error for logs only
-	OriginalErr   error  // Underlying error
-}
-
-func (e *WebhookError) Error() string {
-	return e.InternalError
+// newStripePaymentIntent creates a Stripe payment intent for the order's price, tagged with its order ID
+func newStripePaymentIntent(order *models.Order) (*stripe.PaymentIntent, error) {
+	params := &stripe.PaymentIntentParams{
+		Amount:   stripe.Int64(order.Price.MinorUnits()),
+		Currency: stripe.String(strings.ToLower(order.CurrencyCode())),
+		AutomaticPaymentMethods: &stripe.PaymentIntentAutomaticPaymentMethodsParams{
+			Enabled: stripe.Bool(true),
+		},
+	}
+	params.AddMetadata("order_id", order.OrderID)
+	return paymentintent.New(params)
 }
 
-// HandleStripeWebhook processes Stripe webhook events with enhanced error handling
-func (s *OrderService) HandleStripeWebhook(r *http.Request) error {
-	webhookSecret := os.Getenv("STRIPE_WEBHOOK_SECRET")
-	if webhookSecret == "" {
-		s.logger.Error("WEBHOOK", "Stripe webhook secret is not configured")
-		return &WebhookError{
-			Category:      "configuration",
-			StatusCode:    http.StatusInternalServerError,
-			PublicError:   "Webhook processing error",
-			InternalError: "Stripe webhook secret is not configured",
-		}
+// createMockPaymentIntent stands in for Stripe when PAYMENT_MODE=mock, reusing the order's mock intent if it has one
+func (s *OrderService) createMockPaymentIntent(ctx context.Context, order *models.Order, amount int64) (*stripe.PaymentIntent, error) {
+	existingID := ""
+	if IsMockPaymentIntent(order.PaymentIntentID) {
+		existingID = order.PaymentIntentID
 	}
-
-	// Read the entire request body
-	payload, err := io.ReadAll(r.Body)
-	if err != nil {
-		s.logger.Error("WEBHOOK", fmt.Sprintf("Failed to read webhook payload: %v", err))
-		return &WebhookError{
-			Category:      "validation",
-			StatusCode:    http.StatusBadRequest,
-			PublicError:   "Invalid webhook payload",
-			InternalError: fmt.Sprintf("Failed to read webhook payload: %v", err),
-			OriginalErr:   err,
+	intent := newMockPaymentIntent(existingID, order.OrderID, amount, order.CurrencyCode())
+
+	if existingID == "" {
+		order.PaymentIntentID = intent.ID
+		order.PaymentProvider = PaymentProviderStripe
+		if err := s.DB.UpdateOrder(ctx, *order); err != nil {
+			s.logger.Error("PAYMENT", fmt.Sprintf("Failed to update order with mock payment intent ID: %v", err))
+			return nil, err
 		}
 	}
 
-	// Verify signature with API version mismatch tolerance
-	opts := webhook.ConstructEventOptions{
-		IgnoreAPIVersionMismatch: true, // Allow API version mismatches
-	}
+	s.logger.Warn("PAYMENT", fmt.Sprintf("PAYMENT_MODE=mock: using synthetic payment intent %s for order %s (%s)", intent.ID, order.OrderID, order.Price.Format(order.CurrencyCode())))
+	return intent, nil
+}
 
-	event, err := webhook.ConstructEventWithOptions(payload, r.Header.Get("Stripe-Signature"), webhookSecret, opts)
-	if err != nil {
-		// Classify signature errors
-		var errorCategory, errorMessage string
-		if stripeErr, ok := err.(*stripe.Error); ok {
-			switch stripeErr.Code {
-			case "signature_verification_failed":
-				errorCategory = "validation"
-				errorMessage = "Webhook signature verification failed"
-			default:
-				errorCategory = "processing"
-				errorMessage = "Stripe API error"
-			}
-		} else {
-			errorCategory = "validation"
-			errorMessage = "Invalid webhook signature"
-		}
+// Normalized payment statuses exposed to the frontend
+const (
+	PaymentStatusRequiresPayment = "requires_payment"
+	PaymentStatusProcessing      = "processing"
+	PaymentStatusSucceeded       = "succeeded"
+	PaymentStatusCanceled        = "canceled"
+)
 
-		s.logger.Error("WEBHOOK", fmt.Sprintf("%s: %v", errorMessage, err))
-		return &WebhookError{
-			Category:      errorCategory,
-			StatusCode:    http.StatusBadRequest,
-			PublicError:   errorMessage,
-			InternalError: fmt.Sprintf("%s: %v", errorMessage, err),
-			OriginalErr:   err,
-		}
+// PaymentStatus is the server's view of an order's payment, safe to return to clients
+type PaymentStatus struct {
+	OrderID         string `json:"order_id"`
+	OrderStatus     string `json:"order_status"`
+	PaymentIntentID string `json:"payment_intent_id,omitempty"`
+	PaymentStatus   string `json:"payment_status"`
+}
+
+// NormalizePaymentIntentStatus collapses Stripe's intent statuses into the four states the frontend cares about
+func NormalizePaymentIntentStatus(status stripe.PaymentIntentStatus) string {
+	switch status {
+	case stripe.PaymentIntentStatusSucceeded:
+		return PaymentStatusSucceeded
+	case stripe.PaymentIntentStatusCanceled:
+		return PaymentStatusCanceled
+	case stripe.PaymentIntentStatusProcessing, stripe.PaymentIntentStatusRequiresCapture:
+		return PaymentStatusProcessing
+	default:
+		// requires_payment_method, requires_confirmation and requires_action all need the user to act
+		return PaymentStatusRequiresPayment
 	}
+}
 
-	s.logger.Info("WEBHOOK", fmt.Sprintf("Processing Stripe webhook event: %s", event.Type))
+// GetPaymentStatus retrieves the order's payment intent from Stripe and returns its normalized status
+func (s *OrderService) GetPaymentStatus(ctx context.Context, orderID string) (*PaymentStatus, error) {
+	order, err := s.DB.GetOrderByID(ctx, orderID)
+	if err != nil {
+		s.logger.Error("PAYMENT", fmt.Sprintf("Failed to find order %s: %v", orderID, err))
+		return nil, err
+	}
 
-	// Handle the event
-	switch event.Type {
-	case "payment_intent.succeeded":
-		// Payment was successful, extract payment intent from event
-		var paymentIntent stripe.PaymentIntent
-		err := json.Unmarshal(event.Data.Raw, &paymentIntent)
-		if err != nil {
-			s.logger.Error("WEBHOOK", fmt.Sprintf("Failed to unmarshal payment intent: %v", err))
-			return &WebhookError{
-				Category:      "processing",
-				StatusCode:    http.StatusBadRequest,
-				PublicError:   "Invalid event data",
-				InternalError: fmt.Sprintf("Failed to unmarshal payment intent: %v", err),
-				OriginalErr:   err,
-			}
-		}
+	status := &PaymentStatus{
+		OrderID:         order.OrderID,
+		OrderStatus:     order.Status,
+		PaymentIntentID: order.PaymentIntentID,
+		PaymentStatus:   PaymentStatusRequiresPayment,
+	}
 
-		// Get order ID from metadata
-		orderID, exists := paymentIntent.Metadata["order_id"]
-		if !exists {
-			s.logger.Error("WEBHOOK", "Payment intent has no order_id in metadata")
-			return &WebhookError{
-				Category:      "processing",
-				StatusCode:    http.StatusBadRequest,
-				PublicError:   "Invalid payment intent data",
-				InternalError: "Payment intent has no order_id in metadata",
-			}
-		}
+	// No intent yet means the user never reached the payment step
+	if order.PaymentIntentID == "" {
+		return status, nil
+	}
 
-		// Complete the order
-		err = s.Checkout(orderID)
-		if err != nil {
-			s.logger.Error("WEBHOOK", fmt.Sprintf("Failed to checkout order %s: %v", orderID, err))
-			return &WebhookError{
-				Category:      "processing",
-				StatusCode:    http.StatusInternalServerError,
-				PublicError:   "Failed to process payment",
-				InternalError: fmt.Sprintf("Failed to checkout order %s: %v", orderID, err),
-				OriginalErr:   err,
-			}
+	// Mock intents only exist locally and other providers report through their webhooks,
+	// so the order status is the source of truth
+	if IsMockPaymentIntent(order.PaymentIntentID) || orderPaymentProvider(order) != PaymentProviderStripe {
+		switch order.Status {
+		case "completed":
+			status.PaymentStatus = PaymentStatusSucceeded
+		case "cancelled":
+			status.PaymentStatus = PaymentStatusCanceled
 		}
+		return status, nil
+	}
 
-		s.logger.Info("WEBHOOK", fmt.Sprintf("Successfully processed payment for order %s", orderID))
-
-	case "payment_intent.payment_failed":
-		var paymentIntent stripe.PaymentIntent
-		err := json.Unmarshal(event.Data.Raw, &paymentIntent)
-		if err != nil {
-			s.logger.Error("WEBHOOK", fmt.Sprintf("Failed to unmarshal payment intent: %v", err))
-			return &WebhookError{
-				Category:      "processing",
-				StatusCode:    http.StatusBadRequest,
-				PublicError:   "Invalid event data",
-				InternalError: fmt.Sprintf("Failed to unmarshal payment intent: %v", err),
-				OriginalErr:   err,
-			}
-		}
+	intent, err := paymentintent.Get(order.PaymentIntentID, nil)
+	if err != nil {
+		s.logger.Error("PAYMENT", fmt.Sprintf("Failed to retrieve payment intent %s for order %s: %v", order.PaymentIntentID, orderID, err))
+		return nil, fmt.Errorf("failed to retrieve payment intent: %w", err)
+	}
 
-		orderID, exists := paymentIntent.Metadata["order_id"]
-		if !exists {
-			s.logger.Error("WEBHOOK", "Failed payment intent has no order_id in metadata")
-			return &WebhookError{
-				Category:      "processing",
-				StatusCode:    http.StatusBadRequest,
-				PublicError:   "Invalid payment intent data",
-				InternalError: "Failed payment intent has no order_id in metadata",
-			}
-		}
+	status.PaymentStatus = NormalizePaymentIntentStatus(intent.Status)
+	s.logger.Debug("PAYMENT", fmt.Sprintf("Payment intent %s for order %s has status %s (%s)", intent.ID, orderID, intent.Status, status.PaymentStatus))
+	return status, nil
+}
 
-		// Cancel the order
-		err = s.CancelOrder(orderID)
-		if err != nil {
-			s.logger.Error("WEBHOOK", fmt.Sprintf("Failed to cancel order %s after payment failure: %v", orderID, err))
-			return &WebhookError{
-				Category:      "processing",
-				StatusCode:    http.StatusInternalServerError,
-				PublicError:   "Failed to cancel order after payment failure",
-				InternalError: fmt.Sprintf("Failed to cancel order %s after payment failure: %v", orderID, err),
-				OriginalErr:   err,
-			}
-		}
+// webhookTolerance is how old a webhook signature timestamp may be before the event is rejected as a replay.
+// STRIPE_WEBHOOK_TOLERANCE_SECONDS overrides Stripe's default of 5 minutes.
+func webhookTolerance() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("STRIPE_WEBHOOK_TOLERANCE_SECONDS"))
+	if err != nil || seconds <= 0 {
+		return webhook.DefaultTolerance
+	}
+	return time.Duration(seconds) * time.Second
+}
 
-		s.logger.Info("WEBHOOK", fmt.Sprintf("Cancelled order %s due to payment failure", orderID))
+// WebhookError represents an error that occurred during webhook processing
+type WebhookError struct {
+	Category      string // "configuration", "validation", "processing"
+	StatusCode    int    // HTTP status code
+	PublicError   string // Safe to expose to clients
+	InternalError string // Detailed error for logs only
+	OriginalErr   error  // Underlying error
+}
 
-	default:
-		s.logger.Info("WEBHOOK", fmt.Sprintf("Unhandled event type: %s", event.Type))
-	}
+func (e *WebhookError) Error() string {
+	return e.InternalError
+}
 
-	return nil
+// HandleStripeWebhook processes Stripe webhook events with enhanced error handling
+func (s *OrderService) HandleStripeWebhook(r *http.Request) error {
+	return s.HandlePaymentWebhook(r, PaymentProviderStripe)
 }
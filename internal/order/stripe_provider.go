@@ -0,0 +1,167 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/logger"
+	"ms-ticketing/internal/models"
+	"net/http"
+
+	"github.com/stripe/stripe-go/v74"
+	"github.com/stripe/stripe-go/v74/paymentintent"
+	"github.com/stripe/stripe-go/v74/webhook"
+)
+
+// stripeProvider takes payments through Stripe payment intents
+type stripeProvider struct {
+	logger *logger.Logger
+}
+
+func (p stripeProvider) Name() string {
+	return PaymentProviderStripe
+}
+
+func (p stripeProvider) CreatePayment(ctx context.Context, order *models.Order) (*ProviderPayment, error) {
+	if _, err := models.ValidateCurrency(order.Currency); err != nil {
+		return nil, err
+	}
+	var intent *stripe.PaymentIntent
+	if MockPaymentsEnabled() {
+		intent = newMockPaymentIntent("", order.OrderID, order.Price.MinorUnits(), order.CurrencyCode())
+	} else {
+		var err error
+		if intent, err = newStripePaymentIntent(order); err != nil {
+			return nil, err
+		}
+	}
+	return &ProviderPayment{
+		Provider:     PaymentProviderStripe,
+		OrderID:      order.OrderID,
+		PaymentID:    intent.ID,
+		Status:       p.MapStatus(string(intent.Status)),
+		ClientSecret: intent.ClientSecret,
+	}, nil
+}
+
+func (p stripeProvider) CancelPayment(paymentIntentID string, reason string) error {
+	p.logger.Info("PAYMENT", fmt.Sprintf("Cancelling payment intent %s (%s)", paymentIntentID, reason))
+
+	// Mock intents were never created in Stripe, so there is nothing to cancel there
+	if IsMockPaymentIntent(paymentIntentID) {
+		return nil
+	}
+
+	params := &stripe.PaymentIntentCancelParams{
+		CancellationReason: stringPtr(reason),
+	}
+	if _, err := paymentintent.Cancel(paymentIntentID, params); err != nil {
+		p.logger.Error("PAYMENT", fmt.Sprintf("Failed to cancel payment intent %s: %v", paymentIntentID, err))
+		return fmt.Errorf("failed to cancel payment intent: %w", err)
+	}
+
+	p.logger.Info("PAYMENT", fmt.Sprintf("Successfully cancelled payment intent: %s", paymentIntentID))
+	return nil
+}
+
+func (p stripeProvider) MapStatus(status string) string {
+	return NormalizePaymentIntentStatus(stripe.PaymentIntentStatus(status))
+}
+
+// VerifyWebhook checks the Stripe-Signature of an event and reports payment_intent.succeeded and
+// payment_intent.payment_failed events for the order named in the intent's metadata
+func (p stripeProvider) VerifyWebhook(r *http.Request, payload []byte) (*PaymentUpdate, error) {
+	webhookSecret := webhookSigningSecret()
+	if webhookSecret == "" {
+		p.logger.Error("WEBHOOK", "Stripe webhook secret is not configured")
+		return nil, &WebhookError{
+			Category:      "configuration",
+			StatusCode:    http.StatusInternalServerError,
+			PublicError:   "Webhook processing error",
+			InternalError: "Stripe webhook secret is not configured",
+		}
+	}
+
+	// Verify signature with API version mismatch tolerance; signatures older than the
+	// tolerance window are rejected so a captured payload can't be replayed later
+	opts := webhook.ConstructEventOptions{
+		IgnoreAPIVersionMismatch: true, // Allow API version mismatches
+		Tolerance:                webhookTolerance(),
+	}
+
+	event, err := webhook.ConstructEventWithOptions(payload, r.Header.Get("Stripe-Signature"), webhookSecret, opts)
+	if err != nil {
+		// Classify signature errors
+		var errorCategory, errorMessage string
+		if errors.Is(err, webhook.ErrTooOld) {
+			errorCategory = "validation"
+			errorMessage = "Webhook timestamp outside tolerance"
+		} else if stripeErr, ok := err.(*stripe.Error); ok {
+			switch stripeErr.Code {
+			case "signature_verification_failed":
+				errorCategory = "validation"
+				errorMessage = "Webhook signature verification failed"
+			default:
+				errorCategory = "processing"
+				errorMessage = "Stripe API error"
+			}
+		} else {
+			errorCategory = "validation"
+			errorMessage = "Invalid webhook signature"
+		}
+
+		p.logger.Error("WEBHOOK", fmt.Sprintf("%s: %v", errorMessage, err))
+		return nil, &WebhookError{
+			Category:      errorCategory,
+			StatusCode:    http.StatusBadRequest,
+			PublicError:   errorMessage,
+			InternalError: fmt.Sprintf("%s: %v", errorMessage, err),
+			OriginalErr:   err,
+		}
+	}
+
+	p.logger.Info("WEBHOOK", fmt.Sprintf("Processing Stripe webhook event: %s", event.Type))
+
+	var status string
+	switch event.Type {
+	case "payment_intent.succeeded":
+		status = PaymentStatusSucceeded
+	case "payment_intent.payment_failed":
+		// The intent itself goes back to requires_payment_method, but the attempt failed
+		status = PaymentStatusFailed
+	default:
+		p.logger.Info("WEBHOOK", fmt.Sprintf("Unhandled event type: %s", event.Type))
+		return nil, nil
+	}
+
+	var paymentIntent stripe.PaymentIntent
+	if err := json.Unmarshal(event.Data.Raw, &paymentIntent); err != nil {
+		p.logger.Error("WEBHOOK", fmt.Sprintf("Failed to unmarshal payment intent: %v", err))
+		return nil, &WebhookError{
+			Category:      "processing",
+			StatusCode:    http.StatusBadRequest,
+			PublicError:   "Invalid event data",
+			InternalError: fmt.Sprintf("Failed to unmarshal payment intent: %v", err),
+			OriginalErr:   err,
+		}
+	}
+
+	orderID, exists := paymentIntent.Metadata["order_id"]
+	if !exists {
+		p.logger.Error("WEBHOOK", "Payment intent has no order_id in metadata")
+		return nil, &WebhookError{
+			Category:      "processing",
+			StatusCode:    http.StatusBadRequest,
+			PublicError:   "Invalid payment intent data",
+			InternalError: "Payment intent has no order_id in metadata",
+		}
+	}
+
+	return &PaymentUpdate{
+		OrderID:        orderID,
+		PaymentID:      paymentIntent.ID,
+		Status:         status,
+		ProviderStatus: string(paymentIntent.Status),
+	}, nil
+}
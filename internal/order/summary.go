@@ -0,0 +1,99 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"ms-ticketing/internal/models"
+	"time"
+)
+
+// PaymentSummary is the payment side of an order as support sees it
+type PaymentSummary struct {
+	Provider           string     `json:"provider"`
+	PaymentIntentID    string     `json:"payment_intent_id,omitempty"`
+	PaymentStatus      string     `json:"payment_status"`
+	IntentStatus       string     `json:"intent_status,omitempty"`
+	Amount             int64      `json:"amount,omitempty"`
+	Currency           string     `json:"currency,omitempty"`
+	CancellationReason string     `json:"cancellation_reason,omitempty"`
+	LastError          string     `json:"last_error,omitempty"`
+	IntentCreatedAt    *time.Time `json:"intent_created_at,omitempty"`
+	LookupError        string     `json:"lookup_error,omitempty"`
+}
+
+// OrderSummary joins an order with the live state of its payment
+type OrderSummary struct {
+	Order   models.PricedOrder `json:"order"`
+	Payment PaymentSummary     `json:"payment"`
+	// Seat availability when the order was placed, for staff settling disputes
+	AvailabilitySnapshot *models.SeatAvailabilitySnapshot `json:"availability_snapshot,omitempty"`
+}
+
+// GetOwnedOrder returns the order if it belongs to ownerID; an empty ownerID, used for staff, matches any order
+func (s *OrderService) GetOwnedOrder(ctx context.Context, orderID, ownerID string) (*models.Order, error) {
+	order, err := s.lookupOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if ownerID != "" && order.UserID != ownerID {
+		s.logger.Warn("ORDER", fmt.Sprintf("User %s attempted to access order %s", ownerID, orderID))
+		return nil, ErrNotOrderOwner
+	}
+	return order, nil
+}
+
+// GetOrderSummary returns an order together with its latest Stripe payment intent. The intent is the
+// payment record of an order, so a failed Stripe lookup is reported in the summary instead of failing it.
+// A non-empty ownerID limits the summary to that user's orders; staff pass an empty one and also get
+// the seat availability snapshot taken at placement.
+func (s *OrderService) GetOrderSummary(ctx context.Context, orderID, ownerID string) (*OrderSummary, error) {
+	order, err := s.GetOwnedOrder(ctx, orderID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &OrderSummary{
+		Order: models.NewPricedOrder(*order),
+		Payment: PaymentSummary{
+			Provider:        orderPaymentProvider(order),
+			PaymentIntentID: order.PaymentIntentID,
+			PaymentStatus:   PaymentStatusRequiresPayment,
+		},
+	}
+
+	if ownerID == "" {
+		summary.AvailabilitySnapshot = order.AvailabilitySnapshot
+	}
+
+	switch {
+	case order.PaymentIntentID == "":
+		// The buyer never reached the payment step
+	case IsMockPaymentIntent(order.PaymentIntentID), orderPaymentProvider(order) != PaymentProviderStripe:
+		switch order.Status {
+		case "completed":
+			summary.Payment.PaymentStatus = PaymentStatusSucceeded
+		case "cancelled":
+			summary.Payment.PaymentStatus = PaymentStatusCanceled
+		}
+	default:
+		intent, err := s.PaymentIntents.GetPaymentIntent(order.PaymentIntentID)
+		if err != nil {
+			s.logger.Warn("PAYMENT", fmt.Sprintf("Could not fetch payment intent %s for summary of order %s: %v", order.PaymentIntentID, orderID, err))
+			summary.Payment.PaymentStatus = ""
+			summary.Payment.LookupError = err.Error()
+			break
+		}
+		created := time.Unix(intent.Created, 0).UTC()
+		summary.Payment.PaymentStatus = NormalizePaymentIntentStatus(intent.Status)
+		summary.Payment.IntentStatus = string(intent.Status)
+		summary.Payment.Amount = intent.Amount
+		summary.Payment.Currency = string(intent.Currency)
+		summary.Payment.CancellationReason = string(intent.CancellationReason)
+		summary.Payment.IntentCreatedAt = &created
+		if intent.LastPaymentError != nil {
+			summary.Payment.LastError = intent.LastPaymentError.Msg
+		}
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,83 @@
+package order_test
+
+import (
+	"context"
+	"database/sql"
+	"ms-ticketing/internal/kafka"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order"
+	tickets "ms-ticketing/internal/tickets/service"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v74"
+)
+
+func TestGetOrderSummary(t *testing.T) {
+	mockDB := new(MockDBLayer)
+	orderSvc := order.NewOrderService(mockDB, new(MockRedisLock), kafka.NewInMemoryProducer(), &tickets.TicketService{DB: NewMockTicketService().DB}, NewMockHTTPClient())
+	orderSvc.PaymentIntents = &fakeIntentSource{byID: map[string]*stripe.PaymentIntent{
+		"pi_declined": {
+			ID: "pi_declined", Status: stripe.PaymentIntentStatusRequiresPaymentMethod, Amount: 1000, Currency: "usd", Created: 1700000000,
+			LastPaymentError: &stripe.Error{Msg: "Your card was declined."},
+		},
+	}}
+
+	mockDB.On("GetOrderByID", "order_declined").Return(&models.Order{OrderID: "order_declined", UserID: "user-1", Status: "pending", PaymentIntentID: "pi_declined", Price: models.MoneyFromFloat(10)}, nil)
+	mockDB.On("GetOrderByID", "order_gone").Return(&models.Order{OrderID: "order_gone", UserID: "user-1", Status: "pending", PaymentIntentID: "pi_gone"}, nil)
+	mockDB.On("GetOrderByID", "order_mock").Return(&models.Order{OrderID: "order_mock", UserID: "user-1", Status: "completed", PaymentIntentID: "pi_mock_1"}, nil)
+	mockDB.On("GetOrderByID", "missing").Return(nil, sql.ErrNoRows)
+	ctx := context.Background()
+
+	summary, err := orderSvc.GetOrderSummary(ctx, "order_declined", "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "order_declined", summary.Order.OrderID)
+	assert.Equal(t, order.PaymentStatusRequiresPayment, summary.Payment.PaymentStatus)
+	assert.Equal(t, "requires_payment_method", summary.Payment.IntentStatus)
+	assert.Equal(t, int64(1000), summary.Payment.Amount)
+	assert.Equal(t, "Your card was declined.", summary.Payment.LastError)
+	require.NotNil(t, summary.Payment.IntentCreatedAt)
+
+	// Stripe being unreachable still shows the order
+	summary, err = orderSvc.GetOrderSummary(ctx, "order_gone", "")
+	require.NoError(t, err)
+	assert.Empty(t, summary.Payment.PaymentStatus)
+	assert.NotEmpty(t, summary.Payment.LookupError)
+
+	summary, err = orderSvc.GetOrderSummary(ctx, "order_mock", "")
+	require.NoError(t, err)
+	assert.Equal(t, order.PaymentStatusSucceeded, summary.Payment.PaymentStatus)
+
+	_, err = orderSvc.GetOrderSummary(ctx, "order_declined", "user-2")
+	assert.ErrorIs(t, err, order.ErrNotOrderOwner)
+	_, err = orderSvc.GetOrderSummary(ctx, "missing", "")
+	assert.ErrorIs(t, err, order.ErrOrderNotFound)
+}
+
+func TestOrderSummaryShowsAvailabilitySnapshotToStaff(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(2)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+	ctx := context.Background()
+
+	resp, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+		SessionID: uuid.NewString(), EventID: "event-1", SeatIDs: seatIDs,
+	})
+	require.NoError(t, err)
+
+	summary, err := env.OrderService.GetOrderSummary(ctx, resp.OrderID, "")
+	require.NoError(t, err)
+	snapshot := summary.AvailabilitySnapshot
+	require.NotNil(t, snapshot)
+	assert.Equal(t, []models.SeatAvailability{
+		{SeatID: seatIDs[0], Status: models.SeatSnapshotAvailable},
+		{SeatID: seatIDs[1], Status: models.SeatSnapshotAvailable},
+	}, snapshot.Seats)
+	assert.False(t, snapshot.LockedAt.Before(snapshot.CheckedAt))
+
+	// Buyers see their order without it
+	summary, err = env.OrderService.GetOrderSummary(ctx, resp.OrderID, "user-1")
+	require.NoError(t, err)
+	assert.Nil(t, summary.AvailabilitySnapshot)
+}
@@ -0,0 +1,46 @@
+package order_test
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReprocessingOrderTicketsDoesNotDuplicate(t *testing.T) {
+	seats, seatIDs := seatsForLimitTest(3)
+	env := newLifecycleEnv(t, models.OrderDetailsDTO{Seats: seats})
+
+	resp, err := env.OrderService.SeatValidationAndPlaceOrder(placeOrderRequest(t, "user-1"), models.OrderRequest{
+		SessionID: uuid.NewString(),
+		EventID:   "event-1",
+		SeatIDs:   seatIDs,
+	})
+	require.NoError(t, err)
+
+	placed, err := env.TicketService.GetTicketsByOrder(resp.OrderID)
+	require.NoError(t, err)
+	require.Len(t, placed, 3)
+	for _, ticket := range placed {
+		assert.Equal(t, models.TicketIDFor(resp.OrderID, ticket.SeatID), ticket.TicketID)
+	}
+
+	// A retry of the ticket step after a crash writes the same tickets again
+	for _, seat := range seats {
+		require.NoError(t, env.TicketService.PlaceTicket(models.Ticket{
+			OrderID:         resp.OrderID,
+			SeatID:          seat.SeatID,
+			SeatLabel:       seat.Label,
+			TierID:          seat.Tier.ID,
+			TierName:        seat.Tier.Name,
+			PriceAtPurchase: seat.Tier.Price,
+		}))
+	}
+
+	count, err := env.DB.NewSelect().Model((*models.Ticket)(nil)).Where("order_id = ?", resp.OrderID).Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
@@ -0,0 +1,64 @@
+package order
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ValidationError is returned when an upstream validation service rejects an order.
+// It carries the upstream status and body so the API can tell the user why.
+type ValidationError struct {
+	Stage      string          // "pre_validation" or "seat_validation"
+	StatusCode int             // Status returned by the upstream service
+	Reason     string          // Human readable reason extracted from the upstream body
+	Details    json.RawMessage // Upstream body, always valid JSON
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s failed: status %d: %s", e.Stage, e.StatusCode, e.Reason)
+}
+
+// HTTPStatus maps the upstream status to the status we return to the client.
+// Upstream client errors are forwarded as is; anything else means the upstream broke. A 401 or 403
+// rejects our M2M credentials rather than the client, so it is an upstream failure too.
+func (e *ValidationError) HTTPStatus() int {
+	if e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden {
+		return http.StatusBadGateway
+	}
+	if e.StatusCode >= 400 && e.StatusCode < 500 {
+		return e.StatusCode
+	}
+	return http.StatusBadGateway
+}
+
+// newValidationError builds a ValidationError from a non-200 upstream response
+func newValidationError(stage string, resp *http.Response) *ValidationError {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil || len(body) == 0 {
+		reason := http.StatusText(resp.StatusCode)
+		details, _ := json.Marshal(reason)
+		return &ValidationError{Stage: stage, StatusCode: resp.StatusCode, Reason: reason, Details: details}
+	}
+
+	if !json.Valid(body) {
+		reason := string(body)
+		details, _ := json.Marshal(reason)
+		return &ValidationError{Stage: stage, StatusCode: resp.StatusCode, Reason: reason, Details: details}
+	}
+
+	// Upstream services report the reason under one of these keys
+	var parsed map[string]interface{}
+	reason := http.StatusText(resp.StatusCode)
+	if json.Unmarshal(body, &parsed) == nil {
+		for _, key := range []string{"message", "error", "detail", "reason"} {
+			if v, ok := parsed[key].(string); ok && v != "" {
+				reason = v
+				break
+			}
+		}
+	}
+
+	return &ValidationError{Stage: stage, StatusCode: resp.StatusCode, Reason: reason, Details: body}
+}
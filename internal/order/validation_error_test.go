@@ -0,0 +1,26 @@
+package order_test
+
+import (
+	"ms-ticketing/internal/order"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationErrorHTTPStatus(t *testing.T) {
+	cases := map[int]int{
+		http.StatusBadRequest:          http.StatusBadRequest,
+		http.StatusNotFound:            http.StatusNotFound,
+		http.StatusConflict:            http.StatusConflict,
+		http.StatusInternalServerError: http.StatusBadGateway,
+		http.StatusServiceUnavailable:  http.StatusBadGateway,
+		// Auth failures are about our M2M credentials, not the client
+		http.StatusUnauthorized: http.StatusBadGateway,
+		http.StatusForbidden:    http.StatusBadGateway,
+	}
+	for upstream, want := range cases {
+		err := &order.ValidationError{Stage: "seat validation", StatusCode: upstream}
+		assert.Equal(t, want, err.HTTPStatus(), "upstream %d", upstream)
+	}
+}
@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"math"
+	"ms-ticketing/internal/utils"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idleBucketTTL is how long a client's bucket is kept after its last request
+const idleBucketTTL = 10 * time.Minute
+
+// bucket is one client's token bucket
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// Limiter is a token bucket rate limiter keyed by client IP.
+// Each client may burst up to Burst requests and is refilled at Rate tokens per second.
+type Limiter struct {
+	Rate  float64
+	Burst int
+
+	// TrustForwardedFor keys clients by the last X-Forwarded-For address, the one our proxy appended,
+	// for deployments behind a proxy
+	TrustForwardedFor bool
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// New creates a limiter allowing perMinute requests per client per minute with the given burst
+func New(perMinute float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		Rate:    perMinute / 60,
+		Burst:   burst,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// FromEnv builds a limiter from {prefix}_PER_MINUTE and {prefix}_BURST, falling back to the given defaults.
+// RATE_LIMIT_TRUST_FORWARDED=true keys clients by X-Forwarded-For.
+func FromEnv(prefix string, defaultPerMinute float64, defaultBurst int) *Limiter {
+	perMinute := defaultPerMinute
+	if v, err := strconv.ParseFloat(os.Getenv(prefix+"_PER_MINUTE"), 64); err == nil && v > 0 {
+		perMinute = v
+	}
+	burst := defaultBurst
+	if v, err := strconv.Atoi(os.Getenv(prefix + "_BURST")); err == nil && v > 0 {
+		burst = v
+	}
+	l := New(perMinute, burst)
+	l.TrustForwardedFor = strings.EqualFold(os.Getenv("RATE_LIMIT_TRUST_FORWARDED"), "true")
+	return l
+}
+
+// Allow takes a token for the key. When none is left it returns false and how long until one is available.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst)}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(float64(l.Burst), b.tokens+now.Sub(b.lastSeen).Seconds()*l.Rate)
+	}
+	b.lastSeen = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if l.Rate <= 0 {
+		return false, time.Minute
+	}
+	wait := time.Duration((1 - b.tokens) / l.Rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep drops buckets of clients that have been idle for a while so the map doesn't grow without bound
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleBucketTTL {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idleBucketTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Middleware rejects requests over the limit with 429 and a Retry-After header
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, wait := l.Allow(l.clientIP(r))
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			utils.WriteError(w, utils.ErrCodeRateLimited, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP identifies the caller, by default from the connection's remote address
+func (l *Limiter) clientIP(r *http.Request) string {
+	if l.TrustForwardedFor {
+		// Clients can send any X-Forwarded-For they like; only the entry the proxy appended is trustworthy
+		if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			last := values[len(values)-1]
+			if i := strings.LastIndex(last, ","); i >= 0 {
+				last = last[i+1:]
+			}
+			if ip := strings.TrimSpace(last); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
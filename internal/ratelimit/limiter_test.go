@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowRefillsOverTime(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(60, 2) // one token per second
+	l.now = func() time.Time { return now }
+
+	ok, _ := l.Allow("1.2.3.4")
+	assert.True(t, ok)
+	ok, _ = l.Allow("1.2.3.4")
+	assert.True(t, ok)
+
+	ok, wait := l.Allow("1.2.3.4")
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, wait)
+
+	// Other clients have their own bucket
+	ok, _ = l.Allow("5.6.7.8")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	ok, _ = l.Allow("1.2.3.4")
+	assert.True(t, ok)
+}
+
+func TestMiddlewareReturns429WithRetryAfter(t *testing.T) {
+	l := New(30, 1)
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/order/tickets/count", nil)
+		req.RemoteAddr = "10.0.0.1:51234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, send().Code)
+	rec := send()
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+}
+
+func TestClientIPUsesTheAddressTheProxyAppended(t *testing.T) {
+	l := New(60, 1)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:51234"
+	req.Header.Add("X-Forwarded-For", "1.1.1.1, 2.2.2.2")
+	req.Header.Add("X-Forwarded-For", "6.6.6.6, 203.0.113.7")
+
+	// Without trusting the proxy the header is ignored
+	assert.Equal(t, "10.0.0.1", l.clientIP(req))
+
+	// Spoofed leading entries don't pick the bucket
+	l.TrustForwardedFor = true
+	assert.Equal(t, "203.0.113.7", l.clientIP(req))
+
+	req.Header.Set("X-Forwarded-For", "198.51.100.2")
+	assert.Equal(t, "198.51.100.2", l.clientIP(req))
+}
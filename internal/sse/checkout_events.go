@@ -4,36 +4,95 @@ import (
 	"context"
 	"ms-ticketing/internal/models"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// Event types, sent as the SSE "event:" field
+const (
+	EventTypeCheckout  = "checkout"
+	EventTypeCancelled = "cancelled"
+)
+
+// CheckoutEvent is a single order notification delivered to subscribers
+type CheckoutEvent struct {
+	// ID increases monotonically and is sent as the SSE "id:" field for resuming
+	ID        uint64
+	Type      string
+	EmittedAt time.Time
+	Order     models.OrderWithTickets
+	// ReleasedSeatIDs is only set for cancelled events
+	ReleasedSeatIDs []string
+}
+
+// OrderCancelledPayload is the data of a cancelled event
+type OrderCancelledPayload struct {
+	models.OrderWithTickets
+	ReleasedSeatIDs []string `json:"released_seat_ids"`
+}
+
+// Payload returns the value serialized as the event data
+func (ev CheckoutEvent) Payload() interface{} {
+	if ev.Type == EventTypeCancelled {
+		return OrderCancelledPayload{OrderWithTickets: ev.Order, ReleasedSeatIDs: ev.ReleasedSeatIDs}
+	}
+	return ev.Order
+}
+
 // CheckoutEventEmitter manages SSE connections and event broadcasting for checkout events
 type CheckoutEventEmitter struct {
 	// Organization channel clients map - key: organizationID, value: slice of client channels
-	orgClients     map[string][]chan models.OrderWithTickets
+	orgClients     map[string][]chan CheckoutEvent
 	orgClientMutex sync.RWMutex
 
 	// Event channel clients map - key: eventID, value: slice of client channels
-	eventClients     map[string][]chan models.OrderWithTickets
+	eventClients     map[string][]chan CheckoutEvent
 	eventClientMutex sync.RWMutex
+
+	// Recent events replayed to new subscribers, guarded by the matching client mutex
+	orgHistory       map[string]*eventRing
+	eventHistory     map[string]*eventRing
+	replayBufferSize int
+	replayTTL        time.Duration
+	orgSweptAt       time.Time
+	eventSweptAt     time.Time
+
+	lastID atomic.Uint64
 }
 
 // NewCheckoutEventEmitter creates a new SSE event emitter for checkout events
 func NewCheckoutEventEmitter() *CheckoutEventEmitter {
-	return &CheckoutEventEmitter{
-		orgClients:   make(map[string][]chan models.OrderWithTickets),
-		eventClients: make(map[string][]chan models.OrderWithTickets),
+	e := &CheckoutEventEmitter{
+		orgClients:       make(map[string][]chan CheckoutEvent),
+		eventClients:     make(map[string][]chan CheckoutEvent),
+		orgHistory:       make(map[string]*eventRing),
+		eventHistory:     make(map[string]*eventRing),
+		replayBufferSize: DefaultReplayBufferSize,
+		replayTTL:        DefaultReplayTTL,
 	}
+	// Seeding from the clock keeps IDs increasing across restarts, so a stale
+	// Last-Event-ID from before a restart never hides newer events
+	e.lastID.Store(uint64(time.Now().UnixMilli()) * 1000)
+	return e
 }
 
 // SubscribeToOrganization adds a client to the organization's checkout events
-func (e *CheckoutEventEmitter) SubscribeToOrganization(ctx context.Context, orgID string) chan models.OrderWithTickets {
-	clientChan := make(chan models.OrderWithTickets, 10)
+func (e *CheckoutEventEmitter) SubscribeToOrganization(ctx context.Context, orgID string) chan CheckoutEvent {
+	clientChan, _ := e.SubscribeToOrganizationFrom(ctx, orgID, NoReplay())
+	return clientChan
+}
+
+// SubscribeToOrganizationFrom adds a client to the organization's checkout events and returns the
+// buffered events selected by cursor. Both happen under one lock so no event is missed or duplicated.
+func (e *CheckoutEventEmitter) SubscribeToOrganizationFrom(ctx context.Context, orgID string, cursor ReplayCursor) (chan CheckoutEvent, []CheckoutEvent) {
+	clientChan := make(chan CheckoutEvent, 10)
 
 	e.orgClientMutex.Lock()
-	if e.orgClients[orgID] == nil {
-		e.orgClients[orgID] = []chan models.OrderWithTickets{}
-	}
 	e.orgClients[orgID] = append(e.orgClients[orgID], clientChan)
+	var replay []CheckoutEvent
+	if history := e.orgHistory[orgID]; history != nil && !history.idle(time.Now(), e.replayTTL) {
+		replay = history.after(cursor)
+	}
 	e.orgClientMutex.Unlock()
 
 	// Remove client when context is done
@@ -42,18 +101,26 @@ func (e *CheckoutEventEmitter) SubscribeToOrganization(ctx context.Context, orgI
 		e.removeOrgClient(orgID, clientChan)
 	}()
 
-	return clientChan
+	return clientChan, replay
 }
 
 // SubscribeToEvent adds a client to the event's checkout events
-func (e *CheckoutEventEmitter) SubscribeToEvent(ctx context.Context, eventID string) chan models.OrderWithTickets {
-	clientChan := make(chan models.OrderWithTickets, 10)
+func (e *CheckoutEventEmitter) SubscribeToEvent(ctx context.Context, eventID string) chan CheckoutEvent {
+	clientChan, _ := e.SubscribeToEventFrom(ctx, eventID, NoReplay())
+	return clientChan
+}
+
+// SubscribeToEventFrom adds a client to the event's checkout events and returns the buffered
+// events selected by cursor
+func (e *CheckoutEventEmitter) SubscribeToEventFrom(ctx context.Context, eventID string, cursor ReplayCursor) (chan CheckoutEvent, []CheckoutEvent) {
+	clientChan := make(chan CheckoutEvent, 10)
 
 	e.eventClientMutex.Lock()
-	if e.eventClients[eventID] == nil {
-		e.eventClients[eventID] = []chan models.OrderWithTickets{}
-	}
 	e.eventClients[eventID] = append(e.eventClients[eventID], clientChan)
+	var replay []CheckoutEvent
+	if history := e.eventHistory[eventID]; history != nil && !history.idle(time.Now(), e.replayTTL) {
+		replay = history.after(cursor)
+	}
 	e.eventClientMutex.Unlock()
 
 	// Remove client when context is done
@@ -62,37 +129,64 @@ func (e *CheckoutEventEmitter) SubscribeToEvent(ctx context.Context, eventID str
 		e.removeEventClient(eventID, clientChan)
 	}()
 
-	return clientChan
+	return clientChan, replay
 }
 
 // EmitCheckoutEvent broadcasts a checkout event to all subscribed clients
 func (e *CheckoutEventEmitter) EmitCheckoutEvent(order models.OrderWithTickets) {
-	// Broadcast to organization subscribers
-	e.orgClientMutex.RLock()
-	orgID := order.Order.OrganizationID
-	clients := e.orgClients[orgID]
-	e.orgClientMutex.RUnlock()
+	e.emit(CheckoutEvent{Type: EventTypeCheckout, Order: order})
+}
 
-	for _, clientChan := range clients {
-		// Non-blocking send to avoid slowing down emitter if client is slow
-		select {
-		case clientChan <- order:
-			// Successfully sent
-		default:
-			// Channel buffer full, skip this client for now
+// EmitOrderCancelledEvent broadcasts an order cancellation along with the seats it released
+func (e *CheckoutEventEmitter) EmitOrderCancelledEvent(order models.OrderWithTickets, releasedSeatIDs []string) {
+	e.emit(CheckoutEvent{Type: EventTypeCancelled, Order: order, ReleasedSeatIDs: releasedSeatIDs})
+}
+
+func (e *CheckoutEventEmitter) emit(ev CheckoutEvent) {
+	ev.ID = e.lastID.Add(1)
+	ev.EmittedAt = time.Now()
+
+	// Broadcast to organization subscribers. The write lock is held while sending so the event is
+	// recorded and delivered atomically with respect to new subscriptions; sends never block.
+	orgID := ev.Order.Order.OrganizationID
+	e.orgClientMutex.Lock()
+	e.record(e.orgHistory, &e.orgSweptAt, orgID, ev)
+	broadcast(e.orgClients[orgID], ev)
+	e.orgClientMutex.Unlock()
+
+	// Broadcast to event subscribers
+	eventID := ev.Order.Order.EventID
+	e.eventClientMutex.Lock()
+	e.record(e.eventHistory, &e.eventSweptAt, eventID, ev)
+	broadcast(e.eventClients[eventID], ev)
+	e.eventClientMutex.Unlock()
+}
+
+// record appends an event to the replay buffer for key and, at most once per replaySweepInterval,
+// drops buffers idle for longer than the replay TTL; the caller must hold the matching lock
+func (e *CheckoutEventEmitter) record(history map[string]*eventRing, sweptAt *time.Time, key string, ev CheckoutEvent) {
+	if ev.EmittedAt.Sub(*sweptAt) >= replaySweepInterval {
+		for k, ring := range history {
+			if ring.idle(ev.EmittedAt, e.replayTTL) {
+				delete(history, k)
+			}
 		}
+		*sweptAt = ev.EmittedAt
 	}
 
-	// Broadcast to event subscribers
-	e.eventClientMutex.RLock()
-	eventID := order.Order.EventID
-	eventClients := e.eventClients[eventID]
-	e.eventClientMutex.RUnlock()
+	ring := history[key]
+	if ring == nil {
+		ring = newEventRing(e.replayBufferSize)
+		history[key] = ring
+	}
+	ring.push(ev)
+}
 
-	for _, clientChan := range eventClients {
+func broadcast(clients []chan CheckoutEvent, ev CheckoutEvent) {
+	for _, clientChan := range clients {
 		// Non-blocking send to avoid slowing down emitter if client is slow
 		select {
-		case clientChan <- order:
+		case clientChan <- ev:
 			// Successfully sent
 		default:
 			// Channel buffer full, skip this client for now
@@ -101,7 +195,7 @@ func (e *CheckoutEventEmitter) EmitCheckoutEvent(order models.OrderWithTickets)
 }
 
 // Helper methods to remove clients when they disconnect
-func (e *CheckoutEventEmitter) removeOrgClient(orgID string, clientChan chan models.OrderWithTickets) {
+func (e *CheckoutEventEmitter) removeOrgClient(orgID string, clientChan chan CheckoutEvent) {
 	e.orgClientMutex.Lock()
 	defer e.orgClientMutex.Unlock()
 
@@ -121,7 +215,7 @@ func (e *CheckoutEventEmitter) removeOrgClient(orgID string, clientChan chan mod
 	}
 }
 
-func (e *CheckoutEventEmitter) removeEventClient(eventID string, clientChan chan models.OrderWithTickets) {
+func (e *CheckoutEventEmitter) removeEventClient(eventID string, clientChan chan CheckoutEvent) {
 	e.eventClientMutex.Lock()
 	defer e.eventClientMutex.Unlock()
 
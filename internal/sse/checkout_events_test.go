@@ -0,0 +1,122 @@
+package sse_test
+
+import (
+	"context"
+	"fmt"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/sse"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func checkoutOrder(id, orgID, eventID string) models.OrderWithTickets {
+	return models.OrderWithTickets{Order: models.Order{OrderID: id, OrganizationID: orgID, EventID: eventID}}
+}
+
+func orderIDs(events []sse.CheckoutEvent) []string {
+	ids := make([]string, 0, len(events))
+	for _, ev := range events {
+		ids = append(ids, ev.Order.OrderID)
+	}
+	return ids
+}
+
+func TestSubscribeReplaysBufferedEvents(t *testing.T) {
+	emitter := sse.NewCheckoutEventEmitter()
+	emitter.EmitCheckoutEvent(checkoutOrder("order1", "org1", "event1"))
+	emitter.EmitCheckoutEvent(checkoutOrder("order2", "org1", "event2"))
+	emitter.EmitCheckoutEvent(checkoutOrder("order3", "org2", "event3"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, replay := emitter.SubscribeToOrganizationFrom(ctx, "org1", sse.ReplayCursor{})
+	assert.Equal(t, []string{"order1", "order2"}, orderIDs(replay))
+
+	_, replay = emitter.SubscribeToEventFrom(ctx, "event2", sse.ReplayCursor{})
+	assert.Equal(t, []string{"order2"}, orderIDs(replay))
+}
+
+func TestSubscribeSinceSkipsOlderEvents(t *testing.T) {
+	emitter := sse.NewCheckoutEventEmitter()
+	emitter.EmitCheckoutEvent(checkoutOrder("old", "org1", "event1"))
+	time.Sleep(5 * time.Millisecond)
+	since := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	emitter.EmitCheckoutEvent(checkoutOrder("new", "org1", "event1"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clientChan, replay := emitter.SubscribeToOrganizationFrom(ctx, "org1", sse.ReplayCursor{Since: since})
+	assert.Equal(t, []string{"new"}, orderIDs(replay))
+
+	// Live events still arrive after the replay
+	emitter.EmitCheckoutEvent(checkoutOrder("live", "org1", "event1"))
+	select {
+	case ev := <-clientChan:
+		assert.Equal(t, "live", ev.Order.OrderID)
+	case <-time.After(time.Second):
+		t.Fatal("expected live checkout event")
+	}
+}
+
+func TestReplayBufferKeepsMostRecentEvents(t *testing.T) {
+	emitter := sse.NewCheckoutEventEmitter()
+	total := sse.DefaultReplayBufferSize + 5
+	for i := 0; i < total; i++ {
+		emitter.EmitCheckoutEvent(checkoutOrder(fmt.Sprintf("order%d", i), "org1", "event1"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, replay := emitter.SubscribeToOrganizationFrom(ctx, "org1", sse.ReplayCursor{})
+	assert.Len(t, replay, sse.DefaultReplayBufferSize)
+	assert.Equal(t, "order5", replay[0].Order.OrderID)
+	assert.Equal(t, fmt.Sprintf("order%d", total-1), replay[len(replay)-1].Order.OrderID)
+}
+
+func TestSubscribeResumesAfterLastEventID(t *testing.T) {
+	emitter := sse.NewCheckoutEventEmitter()
+	emitter.EmitCheckoutEvent(checkoutOrder("order1", "org1", "event1"))
+	emitter.EmitCheckoutEvent(checkoutOrder("order2", "org1", "event1"))
+	emitter.EmitCheckoutEvent(checkoutOrder("order3", "org1", "event1"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, all := emitter.SubscribeToOrganizationFrom(ctx, "org1", sse.ReplayCursor{})
+	assert.Len(t, all, 3)
+	assert.Less(t, all[0].ID, all[1].ID)
+	assert.Less(t, all[1].ID, all[2].ID)
+
+	_, replay := emitter.SubscribeToEventFrom(ctx, "event1", sse.ReplayCursor{AfterID: all[0].ID})
+	assert.Equal(t, []string{"order2", "order3"}, orderIDs(replay))
+
+	_, replay = emitter.SubscribeToEventFrom(ctx, "event1", sse.ReplayCursor{AfterID: all[2].ID})
+	assert.Empty(t, replay)
+}
+
+func TestEmitOrderCancelledEvent(t *testing.T) {
+	emitter := sse.NewCheckoutEventEmitter()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clientChan := emitter.SubscribeToEvent(ctx, "event1")
+
+	emitter.EmitOrderCancelledEvent(checkoutOrder("order1", "org1", "event1"), []string{"seat1", "seat2"})
+
+	select {
+	case ev := <-clientChan:
+		assert.Equal(t, sse.EventTypeCancelled, ev.Type)
+		payload, ok := ev.Payload().(sse.OrderCancelledPayload)
+		assert.True(t, ok)
+		assert.Equal(t, "order1", payload.OrderID)
+		assert.Equal(t, []string{"seat1", "seat2"}, payload.ReleasedSeatIDs)
+	case <-time.After(time.Second):
+		t.Fatal("expected cancelled event")
+	}
+}
@@ -0,0 +1,96 @@
+package sse
+
+import (
+	"errors"
+	"expvar"
+	"os"
+	"strconv"
+	"sync"
+)
+
+const (
+	// DefaultMaxConnectionsPerUser caps how many streams one user can hold open at once
+	DefaultMaxConnectionsPerUser = 10
+	// DefaultMaxConnectionsPerStream caps how many clients can follow one organization or event
+	DefaultMaxConnectionsPerStream = 50
+)
+
+// ErrTooManyConnections is returned when a user or stream already has the maximum number of connections
+var ErrTooManyConnections = errors.New("too many open event stream connections")
+
+// ActiveConnections is the number of currently open event stream connections, published via expvar
+var ActiveConnections = expvar.NewInt("sse_active_connections")
+
+// ConnectionLimiter tracks open stream connections per user and per stream.
+// A limit of zero or less disables that check.
+type ConnectionLimiter struct {
+	maxPerUser   int
+	maxPerStream int
+
+	mu       sync.Mutex
+	byUser   map[string]int
+	byStream map[string]int
+}
+
+// NewConnectionLimiter creates a limiter with the given caps
+func NewConnectionLimiter(maxPerUser, maxPerStream int) *ConnectionLimiter {
+	return &ConnectionLimiter{
+		maxPerUser:   maxPerUser,
+		maxPerStream: maxPerStream,
+		byUser:       make(map[string]int),
+		byStream:     make(map[string]int),
+	}
+}
+
+// NewConnectionLimiterFromEnv reads SSE_MAX_CONNECTIONS_PER_USER and SSE_MAX_CONNECTIONS_PER_STREAM
+func NewConnectionLimiterFromEnv() *ConnectionLimiter {
+	return NewConnectionLimiter(
+		intFromEnv("SSE_MAX_CONNECTIONS_PER_USER", DefaultMaxConnectionsPerUser),
+		intFromEnv("SSE_MAX_CONNECTIONS_PER_STREAM", DefaultMaxConnectionsPerStream),
+	)
+}
+
+// Acquire reserves a connection slot for the user on the stream. The returned release func
+// must be called once the connection closes; calling it more than once is safe.
+func (l *ConnectionLimiter) Acquire(userID, streamKey string) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxPerUser > 0 && l.byUser[userID] >= l.maxPerUser {
+		return nil, ErrTooManyConnections
+	}
+	if l.maxPerStream > 0 && l.byStream[streamKey] >= l.maxPerStream {
+		return nil, ErrTooManyConnections
+	}
+
+	l.byUser[userID]++
+	l.byStream[streamKey]++
+	ActiveConnections.Add(1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			decrement(l.byUser, userID)
+			decrement(l.byStream, streamKey)
+			ActiveConnections.Add(-1)
+		})
+	}, nil
+}
+
+func decrement(counts map[string]int, key string) {
+	counts[key]--
+	if counts[key] <= 0 {
+		delete(counts, key)
+	}
+}
+
+func intFromEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
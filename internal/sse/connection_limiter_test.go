@@ -0,0 +1,47 @@
+package sse_test
+
+import (
+	"ms-ticketing/internal/sse"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionLimiterCapsPerUser(t *testing.T) {
+	limiter := sse.NewConnectionLimiter(2, 0)
+
+	release1, err := limiter.Acquire("user1", "organization:org1")
+	assert.NoError(t, err)
+	_, err = limiter.Acquire("user1", "organization:org2")
+	assert.NoError(t, err)
+
+	_, err = limiter.Acquire("user1", "event:event1")
+	assert.ErrorIs(t, err, sse.ErrTooManyConnections)
+
+	// Other users are unaffected
+	_, err = limiter.Acquire("user2", "organization:org1")
+	assert.NoError(t, err)
+
+	// Releasing frees the slot, and a double release does not free another one
+	release1()
+	release1()
+	_, err = limiter.Acquire("user1", "event:event1")
+	assert.NoError(t, err)
+	_, err = limiter.Acquire("user1", "event:event2")
+	assert.ErrorIs(t, err, sse.ErrTooManyConnections)
+}
+
+func TestConnectionLimiterCapsPerStream(t *testing.T) {
+	limiter := sse.NewConnectionLimiter(0, 1)
+
+	before := sse.ActiveConnections.Value()
+	release, err := limiter.Acquire("user1", "organization:org1")
+	assert.NoError(t, err)
+	assert.Equal(t, before+1, sse.ActiveConnections.Value())
+
+	_, err = limiter.Acquire("user2", "organization:org1")
+	assert.ErrorIs(t, err, sse.ErrTooManyConnections)
+
+	release()
+	assert.Equal(t, before, sse.ActiveConnections.Value())
+}
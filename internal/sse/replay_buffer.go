@@ -0,0 +1,81 @@
+package sse
+
+import "time"
+
+// DefaultReplayBufferSize is how many recent checkout events are kept per organization and per event
+const DefaultReplayBufferSize = 50
+
+// DefaultReplayTTL is how long the buffer of an organization or event is kept after its last event.
+// Idle buffers are dropped so organizations and events that stopped selling don't hold memory forever.
+const DefaultReplayTTL = 15 * time.Minute
+
+// replaySweepInterval is how often emitting an event also drops idle buffers
+const replaySweepInterval = time.Minute
+
+// ReplayCursor selects which buffered events a new subscriber is caught up with
+type ReplayCursor struct {
+	// AfterID replays events with a greater ID, as sent back in the Last-Event-ID header.
+	// It takes precedence over Since when set.
+	AfterID uint64
+	// Since replays events emitted after this time; zero replays the whole buffer
+	Since time.Time
+}
+
+// NoReplay is a cursor that skips every buffered event
+func NoReplay() ReplayCursor {
+	return ReplayCursor{Since: time.Now()}
+}
+
+func (c ReplayCursor) includes(ev CheckoutEvent) bool {
+	if c.AfterID > 0 {
+		return ev.ID > c.AfterID
+	}
+	return ev.EmittedAt.After(c.Since)
+}
+
+// eventRing is a fixed size ring buffer of the most recent checkout events
+type eventRing struct {
+	entries  []CheckoutEvent
+	next     int
+	full     bool
+	lastPush time.Time
+}
+
+func newEventRing(size int) *eventRing {
+	return &eventRing{entries: make([]CheckoutEvent, size)}
+}
+
+// push stores an event, overwriting the oldest one once the buffer is full
+func (r *eventRing) push(ev CheckoutEvent) {
+	if len(r.entries) == 0 {
+		return
+	}
+	r.lastPush = ev.EmittedAt
+	r.entries[r.next] = ev
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// idle reports whether the last event was pushed more than ttl before now
+func (r *eventRing) idle(now time.Time, ttl time.Duration) bool {
+	return now.Sub(r.lastPush) > ttl
+}
+
+// after returns the buffered events selected by the cursor, oldest first
+func (r *eventRing) after(cursor ReplayCursor) []CheckoutEvent {
+	start, count := 0, r.next
+	if r.full {
+		start, count = r.next, len(r.entries)
+	}
+
+	var events []CheckoutEvent
+	for i := 0; i < count; i++ {
+		ev := r.entries[(start+i)%len(r.entries)]
+		if cursor.includes(ev) {
+			events = append(events, ev)
+		}
+	}
+	return events
+}
@@ -0,0 +1,45 @@
+package sse
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdleReplayBuffersAreDropped(t *testing.T) {
+	emitter := NewCheckoutEventEmitter()
+	order := func(id, orgID, eventID string) models.OrderWithTickets {
+		return models.OrderWithTickets{Order: models.Order{OrderID: id, OrganizationID: orgID, EventID: eventID}}
+	}
+
+	emitter.EmitCheckoutEvent(order("old", "org-old", "event-old"))
+	emitter.EmitCheckoutEvent(order("recent", "org-recent", "event-recent"))
+
+	// Age the first buffers past the TTL and make the next emit sweep
+	emitter.orgHistory["org-old"].lastPush = time.Now().Add(-2 * DefaultReplayTTL)
+	emitter.eventHistory["event-old"].lastPush = time.Now().Add(-2 * DefaultReplayTTL)
+	emitter.orgSweptAt = time.Time{}
+	emitter.eventSweptAt = time.Time{}
+	emitter.EmitCheckoutEvent(order("new", "org-new", "event-new"))
+
+	assert.NotContains(t, emitter.orgHistory, "org-old")
+	assert.NotContains(t, emitter.eventHistory, "event-old")
+	assert.Contains(t, emitter.orgHistory, "org-recent")
+	assert.Contains(t, emitter.eventHistory, "event-recent")
+	assert.Contains(t, emitter.orgHistory, "org-new")
+}
+
+func TestIdleReplayBufferIsNotReplayed(t *testing.T) {
+	emitter := NewCheckoutEventEmitter()
+	emitter.EmitCheckoutEvent(models.OrderWithTickets{Order: models.Order{OrderID: "old", OrganizationID: "org1", EventID: "event1"}})
+	// Not swept yet, but too old to replay
+	emitter.orgHistory["org1"].lastPush = time.Now().Add(-2 * DefaultReplayTTL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, replay := emitter.SubscribeToOrganizationFrom(ctx, "org1", ReplayCursor{})
+	assert.Empty(t, replay)
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"ms-ticketing/internal/models"
+	"time"
+)
+
+// AdjustCheckinCount adds delta to the session's checked-in counter, creating the row on first use
+func (d *DB) AdjustCheckinCount(sessionID string, delta int) error {
+	count := models.CheckinCount{
+		SessionID: sessionID,
+		CheckedIn: delta,
+		UpdatedAt: time.Now(),
+	}
+	_, err := d.Bun.NewInsert().
+		Model(&count).
+		On("CONFLICT (session_id) DO UPDATE").
+		Set("checked_in = cc.checked_in + EXCLUDED.checked_in").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(context.Background())
+	return err
+}
+
+// GetCheckedInCountBySession reads the materialized checked-in counter for a session.
+// Sessions without any check-ins yet have no row and report zero.
+func (d *DB) GetCheckedInCountBySession(sessionID string) (int, error) {
+	var count models.CheckinCount
+	err := d.Bun.NewSelect().
+		Model(&count).
+		Where("session_id = ?", sessionID).
+		Limit(1).
+		Scan(context.Background())
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return count.CheckedIn, nil
+}
+
+// ReconcileCheckinCount recounts checked-in tickets over the tickets+orders join and
+// overwrites the session's counter with the result, correcting any drift. It returns the recounted total.
+func (d *DB) ReconcileCheckinCount(sessionID string) (int, error) {
+	ctx := context.Background()
+
+	actual, err := d.Bun.NewSelect().
+		TableExpr("tickets AS t").
+		Join("JOIN orders AS o ON o.order_id = t.order_id").
+		Where("o.session_id = ?", sessionID).
+		Where("t.checked_in = ?", true).
+		Count(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := models.CheckinCount{
+		SessionID: sessionID,
+		CheckedIn: actual,
+		UpdatedAt: time.Now(),
+	}
+	_, err = d.Bun.NewInsert().
+		Model(&count).
+		On("CONFLICT (session_id) DO UPDATE").
+		Set("checked_in = EXCLUDED.checked_in").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return actual, nil
+}
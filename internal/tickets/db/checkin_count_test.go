@@ -0,0 +1,95 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/tickets/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	_ "github.com/uptrace/bun/driver/sqliteshim"
+)
+
+func setupCheckinCountDB(t *testing.T) (*db.DB, *bun.DB) {
+	// Connect to an in-memory SQLite DB for testing
+	sqldb, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to connect to in-memory database: %v", err)
+	}
+
+	// Create a Bun DB instance
+	bunDB := bun.NewDB(sqldb, sqlitedialect.New())
+
+	// Reconcile joins tickets with orders, so all three tables are needed
+	for _, model := range []interface{}{(*models.Order)(nil), (*models.Ticket)(nil), (*models.CheckinCount)(nil)} {
+		if _, err := bunDB.NewCreateTable().Model(model).Exec(context.Background()); err != nil {
+			t.Fatalf("Failed to create table: %v", err)
+		}
+	}
+
+	// Return test DB
+	return &db.DB{Bun: bunDB}, bunDB
+}
+
+func TestAdjustCheckinCount(t *testing.T) {
+	// Set up test DB
+	checkinDB, bunDB := setupCheckinCountDB(t)
+	defer bunDB.Close()
+
+	// Test case: a session without check-ins reports zero
+	count, err := checkinDB.GetCheckedInCountBySession("session1")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	// Test case: increments accumulate and a revoke decrements
+	assert.NoError(t, checkinDB.AdjustCheckinCount("session1", 1))
+	assert.NoError(t, checkinDB.AdjustCheckinCount("session1", 1))
+	assert.NoError(t, checkinDB.AdjustCheckinCount("session1", -1))
+	assert.NoError(t, checkinDB.AdjustCheckinCount("session2", 1))
+
+	count, err = checkinDB.GetCheckedInCountBySession("session1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = checkinDB.GetCheckedInCountBySession("session2")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestReconcileCheckinCount(t *testing.T) {
+	// Set up test DB
+	checkinDB, bunDB := setupCheckinCountDB(t)
+	defer bunDB.Close()
+
+	ctx := context.Background()
+	orders := []models.Order{
+		{OrderID: "order1", SessionID: "session1", Status: "completed"},
+		{OrderID: "order2", SessionID: "session2", Status: "completed"},
+	}
+	_, err := bunDB.NewInsert().Model(&orders).Exec(ctx)
+	assert.NoError(t, err)
+
+	tickets := []models.Ticket{
+		{TicketID: "ticket1", OrderID: "order1", CheckedIn: true, CheckedInTime: time.Now(), IssuedAt: time.Now()},
+		{TicketID: "ticket2", OrderID: "order1", CheckedIn: true, CheckedInTime: time.Now(), IssuedAt: time.Now()},
+		{TicketID: "ticket3", OrderID: "order1", IssuedAt: time.Now()},
+		{TicketID: "ticket4", OrderID: "order2", CheckedIn: true, CheckedInTime: time.Now(), IssuedAt: time.Now()},
+	}
+	_, err = bunDB.NewInsert().Model(&tickets).Exec(ctx)
+	assert.NoError(t, err)
+
+	// Simulate drift: the counter missed one check-in
+	assert.NoError(t, checkinDB.AdjustCheckinCount("session1", 1))
+
+	count, err := checkinDB.ReconcileCheckinCount("session1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	count, err = checkinDB.GetCheckedInCountBySession("session1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"ms-ticketing/internal/models"
@@ -50,14 +51,21 @@ func (d *DB) UpdateTicket(ticket models.Ticket) error {
 	return err
 }
 
-// CheckinTicket updates only the checkin-related fields for a ticket
+// CheckinTicket updates only the checkin-related fields for a ticket.
+// A zero checkedInTime clears the stored time, which is how a check-in is revoked.
 func (d *DB) CheckinTicket(ticketID string, checkedIn bool, checkedInTime time.Time) error {
-	_, err := d.Bun.NewUpdate().
+	q := d.Bun.NewUpdate().
 		Model((*models.Ticket)(nil)).
 		Set("checked_in = ?", checkedIn).
-		Set("checked_in_time = ?", checkedInTime).
-		Where("ticket_id = ?", ticketID).
-		Exec(context.Background())
+		Where("ticket_id = ?", ticketID)
+
+	if checkedInTime.IsZero() {
+		q = q.Set("checked_in_time = NULL")
+	} else {
+		q = q.Set("checked_in_time = ?", checkedInTime)
+	}
+
+	_, err := q.Exec(context.Background())
 	return err
 }
 
@@ -69,15 +77,74 @@ func (d *DB) CancelTicket(ticketID string) error {
 	return err
 }
 
+// DeleteTicketsByOrder deletes all tickets of an order and returns how many were removed
+func (d *DB) DeleteTicketsByOrder(orderID string) (int, error) {
+	res, err := d.Bun.NewDelete().
+		Model((*models.Ticket)(nil)).
+		Where("order_id = ?", orderID).
+		Exec(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
+
+// DeleteOrphanedTickets deletes tickets issued before the cutoff whose order is cancelled or no longer exists.
+// The orders foreign key normally removes a deleted order's tickets with it, but databases set up by hand
+// may lack it.
+func (d *DB) DeleteOrphanedTickets(issuedBefore time.Time) (int, error) {
+	res, err := d.Bun.NewDelete().
+		Model((*models.Ticket)(nil)).
+		Where("issued_at < ?", issuedBefore).
+		Where("order_id NOT IN (SELECT order_id FROM orders WHERE status <> ?)", "cancelled").
+		Exec(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
+
+// CreateTicket inserts a ticket, or updates the seat, tier, price and QR code of an existing ticket with
+// the same ID. Ticket IDs are derived from order and seat, so writing an order's tickets again is safe;
+// check-in and resale state of an existing ticket are kept.
 func (d *DB) CreateTicket(ticket models.Ticket) error {
+	return InsertTicket(context.Background(), d.Bun, ticket)
+}
+
+// InsertTicket writes a ticket like CreateTicket through db, so a transaction can create tickets together
+// with their order
+func InsertTicket(ctx context.Context, db bun.IDB, ticket models.Ticket) error {
 	// Ensure issued_at is set if empty
 	if ticket.IssuedAt.IsZero() {
 		ticket.IssuedAt = time.Now()
 	}
-	_, err := d.Bun.NewInsert().
+	res, err := db.NewInsert().
 		Model(&ticket).
-		Exec(context.Background())
-	return err
+		On("CONFLICT (ticket_id) DO UPDATE").
+		Set("seat_label = EXCLUDED.seat_label").
+		Set("colour = EXCLUDED.colour").
+		Set("tier_id = EXCLUDED.tier_id").
+		Set("tier_name = EXCLUDED.tier_name").
+		Set("price_at_purchase = EXCLUDED.price_at_purchase").
+		Set("qr_code = EXCLUDED.qr_code").
+		Where("ticket.order_id = EXCLUDED.order_id").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	// The conflict update is skipped when the existing ticket belongs to another order
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("ticket %s already belongs to another order", ticket.TicketID)
+	}
+	return nil
 }
 
 func (d *DB) GetTicketsByUser(userID string) ([]models.Ticket, error) {
@@ -108,3 +175,19 @@ func (d *DB) GetTicketsByUser(userID string) ([]models.Ticket, error) {
 
 	return tickets, nil
 }
+
+// GetTicketsBySession returns the tickets of completed orders for a session, ordered by seat label
+func (d *DB) GetTicketsBySession(sessionID string) ([]models.Ticket, error) {
+	tickets := []models.Ticket{}
+	err := d.Bun.NewSelect().
+		Model(&tickets).
+		Join("JOIN orders AS o ON o.order_id = ticket.order_id").
+		Where("o.session_id = ?", sessionID).
+		Where("o.status = ?", "completed").
+		OrderExpr("ticket.seat_label ASC, ticket.ticket_id ASC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
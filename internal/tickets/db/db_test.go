@@ -77,6 +77,46 @@ func TestCreateAndGetTicket(t *testing.T) {
 	assert.Nil(t, ticket)
 }
 
+func TestCreateTicketUpsertsByTicketID(t *testing.T) {
+	ticketDB, bunDB := setupTestDB(t)
+	defer bunDB.Close()
+
+	orderID := uuid.New().String()
+	ticket := models.Ticket{
+		TicketID:        models.TicketIDFor(orderID, "seat1"),
+		OrderID:         orderID,
+		SeatID:          "seat1",
+		SeatLabel:       "A1",
+		TierID:          "tier1",
+		TierName:        "VIP",
+		PriceAtPurchase: 50.0,
+		IssuedAt:        time.Now(),
+	}
+	assert.NoError(t, ticketDB.CreateTicket(ticket))
+	assert.NoError(t, ticketDB.CheckinTicket(ticket.TicketID, true, time.Now()))
+
+	// Writing the ticket again updates it in place and keeps its check-in
+	ticket.SeatLabel = "A1 (aisle)"
+	ticket.QRCode = []byte("qr-v2")
+	assert.NoError(t, ticketDB.CreateTicket(ticket))
+
+	tickets, err := ticketDB.GetTicketsByOrder(orderID)
+	assert.NoError(t, err)
+	if assert.Len(t, tickets, 1) {
+		assert.Equal(t, "A1 (aisle)", tickets[0].SeatLabel)
+		assert.Equal(t, []byte("qr-v2"), tickets[0].QRCode)
+		assert.True(t, tickets[0].CheckedIn)
+	}
+
+	// Another order can't take the ticket over by reusing its ID
+	stolen := ticket
+	stolen.OrderID = uuid.New().String()
+	assert.Error(t, ticketDB.CreateTicket(stolen))
+	got, err := ticketDB.GetTicketByID(ticket.TicketID)
+	assert.NoError(t, err)
+	assert.Equal(t, orderID, got.OrderID)
+}
+
 func TestUpdateTicket(t *testing.T) {
 	// Set up test DB
 	ticketDB, bunDB := setupTestDB(t)
@@ -299,4 +339,152 @@ func TestTicketCount(t *testing.T) {
 	assert.Equal(t, eventID, counts[0].EventID)
 	assert.Equal(t, sessionID, counts[0].SessionID)
 	assert.Equal(t, 2, counts[0].Count)
-}
\ No newline at end of file
+}
+func TestUpdateResaleStatus(t *testing.T) {
+	ticketDB, bunDB := setupTestDB(t)
+	defer bunDB.Close()
+
+	ticketID := uuid.New().String()
+	err := ticketDB.CreateTicket(models.Ticket{
+		TicketID:        ticketID,
+		OrderID:         uuid.New().String(),
+		SeatID:          "seat1",
+		SeatLabel:       "A1",
+		TierID:          "tier1",
+		TierName:        "VIP",
+		PriceAtPurchase: 50.0,
+	})
+	assert.NoError(t, err)
+
+	// New tickets start unlisted
+	ticket, err := ticketDB.GetTicketByID(ticketID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ResaleStatusNone, ticket.ResaleStatus)
+
+	listedAt := time.Now().UTC().Truncate(time.Second)
+	err = ticketDB.UpdateResaleStatus(ticketID, models.ResaleStatusListed, 65.0, listedAt)
+	assert.NoError(t, err)
+
+	ticket, err = ticketDB.GetTicketByID(ticketID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ResaleStatusListed, ticket.ResaleStatus)
+	assert.Equal(t, 65.0, ticket.ResalePrice)
+	assert.True(t, listedAt.Equal(ticket.ResaleListedAt))
+
+	err = ticketDB.UpdateResaleStatus(ticketID, models.ResaleStatusNone, 0, time.Time{})
+	assert.NoError(t, err)
+
+	ticket, err = ticketDB.GetTicketByID(ticketID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ResaleStatusNone, ticket.ResaleStatus)
+	assert.Zero(t, ticket.ResalePrice)
+	assert.True(t, ticket.ResaleListedAt.IsZero())
+}
+
+func TestGetTicketsBySession(t *testing.T) {
+	// The checked-in count setup has both the orders and tickets tables
+	ticketDB, bunDB := setupCheckinCountDB(t)
+	defer bunDB.Close()
+
+	ctx := context.Background()
+	orders := []models.Order{
+		{OrderID: "order1", SessionID: "session1", Status: "completed"},
+		{OrderID: "order2", SessionID: "session1", Status: "pending"},
+		{OrderID: "order3", SessionID: "session2", Status: "completed"},
+	}
+	_, err := bunDB.NewInsert().Model(&orders).Exec(ctx)
+	assert.NoError(t, err)
+
+	tickets := []models.Ticket{
+		{TicketID: "ticket1", OrderID: "order1", SeatLabel: "B1", IssuedAt: time.Now()},
+		{TicketID: "ticket2", OrderID: "order1", SeatLabel: "A1", CheckedIn: true, CheckedInTime: time.Now(), IssuedAt: time.Now()},
+		{TicketID: "ticket3", OrderID: "order2", SeatLabel: "A2", IssuedAt: time.Now()},
+		{TicketID: "ticket4", OrderID: "order3", SeatLabel: "A3", IssuedAt: time.Now()},
+	}
+	_, err = bunDB.NewInsert().Model(&tickets).Exec(ctx)
+	assert.NoError(t, err)
+
+	// Test case: only tickets of completed orders for the session, by seat label
+	roster, err := ticketDB.GetTicketsBySession("session1")
+	assert.NoError(t, err)
+	assert.Len(t, roster, 2)
+	assert.Equal(t, "ticket2", roster[0].TicketID)
+	assert.True(t, roster[0].CheckedIn)
+	assert.Equal(t, "ticket1", roster[1].TicketID)
+
+	// Test case: a session without sales has an empty roster
+	roster, err = ticketDB.GetTicketsBySession("session3")
+	assert.NoError(t, err)
+	assert.Empty(t, roster)
+}
+
+func TestDeleteTicketsByOrder(t *testing.T) {
+	ticketDB, bunDB := setupTestDB(t)
+	defer bunDB.Close()
+
+	orderID := uuid.New().String()
+	otherOrderID := uuid.New().String()
+	for _, ticket := range []models.Ticket{
+		{TicketID: uuid.New().String(), OrderID: orderID, SeatID: "seat1", IssuedAt: time.Now()},
+		{TicketID: uuid.New().String(), OrderID: orderID, SeatID: "seat2", IssuedAt: time.Now()},
+		{TicketID: uuid.New().String(), OrderID: otherOrderID, SeatID: "seat3", IssuedAt: time.Now()},
+	} {
+		assert.NoError(t, ticketDB.CreateTicket(ticket))
+	}
+
+	deleted, err := ticketDB.DeleteTicketsByOrder(orderID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	remaining, err := ticketDB.GetTicketsByOrder(orderID)
+	assert.NoError(t, err)
+	assert.Empty(t, remaining)
+	remaining, err = ticketDB.GetTicketsByOrder(otherOrderID)
+	assert.NoError(t, err)
+	assert.Len(t, remaining, 1)
+}
+
+func TestDeleteOrphanedTickets(t *testing.T) {
+	ticketDB, bunDB := setupTestDB(t)
+	defer bunDB.Close()
+
+	_, err := bunDB.NewCreateTable().Model((*models.Order)(nil)).Exec(context.Background())
+	assert.NoError(t, err)
+
+	liveOrder := uuid.New().String()
+	cancelledOrder := uuid.New().String()
+	missingOrder := uuid.New().String()
+	for _, order := range []models.Order{
+		{OrderID: liveOrder, Status: "completed", CreatedAt: time.Now()},
+		{OrderID: cancelledOrder, Status: "cancelled", CreatedAt: time.Now()},
+	} {
+		_, err := bunDB.NewInsert().Model(&order).Exec(context.Background())
+		assert.NoError(t, err)
+	}
+
+	old := time.Now().Add(-2 * time.Hour)
+	recentTicket := uuid.New().String()
+	for _, ticket := range []models.Ticket{
+		{TicketID: uuid.New().String(), OrderID: liveOrder, SeatID: "seat1", IssuedAt: old},
+		{TicketID: uuid.New().String(), OrderID: cancelledOrder, SeatID: "seat2", IssuedAt: old},
+		{TicketID: uuid.New().String(), OrderID: missingOrder, SeatID: "seat3", IssuedAt: old},
+		{TicketID: recentTicket, OrderID: missingOrder, SeatID: "seat4", IssuedAt: time.Now()},
+	} {
+		assert.NoError(t, ticketDB.CreateTicket(ticket))
+	}
+
+	deleted, err := ticketDB.DeleteOrphanedTickets(time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	remaining, err := ticketDB.GetTicketsByOrder(liveOrder)
+	assert.NoError(t, err)
+	assert.Len(t, remaining, 1)
+	remaining, err = ticketDB.GetTicketsByOrder(cancelledOrder)
+	assert.NoError(t, err)
+	assert.Empty(t, remaining)
+
+	// Tickets inside the grace period are kept even without an order
+	_, err = ticketDB.GetTicketByID(recentTicket)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+	"time"
+)
+
+// CountUncheckedTicketsByEvent counts the tickets of an event that have not been checked in yet
+func (d *DB) CountUncheckedTicketsByEvent(eventID string) (int, error) {
+	return d.Bun.NewSelect().
+		TableExpr("tickets AS t").
+		Join("JOIN orders AS o ON o.order_id = t.order_id").
+		Where("o.event_id = ?", eventID).
+		Where("t.checked_in = ?", false).
+		Count(context.Background())
+}
+
+// GetUncheckedTicketsByEvent returns up to limit tickets of an event that have not been checked in,
+// ordered by ticket ID and starting after afterTicketID, so a caller can page through the event.
+func (d *DB) GetUncheckedTicketsByEvent(eventID string, afterTicketID string, limit int) ([]models.Ticket, error) {
+	var tickets []models.Ticket
+	err := d.Bun.NewSelect().
+		Model(&tickets).
+		Join("JOIN orders AS o ON o.order_id = ticket.order_id").
+		Where("o.event_id = ?", eventID).
+		Where("ticket.checked_in = ?", false).
+		Where("ticket.ticket_id > ?", afterTicketID).
+		OrderExpr("ticket.ticket_id ASC").
+		Limit(limit).
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+// UpdateTicketQRCode replaces only the stored QR image of a ticket
+func (d *DB) UpdateTicketQRCode(ticketID string, qrCode []byte) error {
+	_, err := d.Bun.NewUpdate().
+		Model((*models.Ticket)(nil)).
+		Set("qr_code = ?", qrCode).
+		Where("ticket_id = ?", ticketID).
+		Exec(context.Background())
+	return err
+}
+
+// CreateQRRegenerationJob stores a new running job. It reports false without storing anything when the
+// event already has a running job, which the unique index on running jobs enforces across instances.
+func (d *DB) CreateQRRegenerationJob(job *models.QRRegenerationJob) (bool, error) {
+	res, err := d.Bun.NewInsert().
+		Model(job).
+		On("CONFLICT DO NOTHING").
+		Exec(context.Background())
+	if err != nil {
+		return false, err
+	}
+	created, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return created == 1, nil
+}
+
+// GetQRRegenerationJob returns a job by ID, or sql.ErrNoRows
+func (d *DB) GetQRRegenerationJob(jobID string) (*models.QRRegenerationJob, error) {
+	job := new(models.QRRegenerationJob)
+	err := d.Bun.NewSelect().
+		Model(job).
+		Where("job_id = ?", jobID).
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// GetRunningQRRegenerationJob returns the running job of an event, or sql.ErrNoRows
+func (d *DB) GetRunningQRRegenerationJob(eventID string) (*models.QRRegenerationJob, error) {
+	job := new(models.QRRegenerationJob)
+	err := d.Bun.NewSelect().
+		Model(job).
+		Where("event_id = ?", eventID).
+		Where("status = ?", "running").
+		Limit(1).
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// AddQRRegenerationProgress adds a finished batch to a job's counters
+func (d *DB) AddQRRegenerationProgress(jobID string, processed, failed int, at time.Time) error {
+	_, err := d.Bun.NewUpdate().
+		Model((*models.QRRegenerationJob)(nil)).
+		Set("processed = processed + ?", processed).
+		Set("failed = failed + ?", failed).
+		Set("updated_at = ?", at).
+		Where("job_id = ?", jobID).
+		Exec(context.Background())
+	return err
+}
+
+// FinishQRRegenerationJob records the final status, error and total of a job
+func (d *DB) FinishQRRegenerationJob(job models.QRRegenerationJob) error {
+	_, err := d.Bun.NewUpdate().
+		Model(&job).
+		Column("status", "error", "total", "updated_at", "completed_at").
+		WherePK().
+		Exec(context.Background())
+	return err
+}
+
+// FailStaleQRRegenerationJobs marks the running jobs of an event that made no progress since before
+// as failed, so a job whose instance died doesn't block the event forever. It returns how many were failed.
+func (d *DB) FailStaleQRRegenerationJobs(eventID string, before time.Time, reason string) (int, error) {
+	now := time.Now()
+	res, err := d.Bun.NewUpdate().
+		Model((*models.QRRegenerationJob)(nil)).
+		Set("status = ?", "failed").
+		Set("error = ?", reason).
+		Set("updated_at = ?", now).
+		Set("completed_at = ?", now).
+		Where("event_id = ?", eventID).
+		Where("status = ?", "running").
+		Where("updated_at < ?", before).
+		Exec(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,115 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"ms-ticketing/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUncheckedTicketsByEvent(t *testing.T) {
+	// The checked-in count setup has both the orders and tickets tables
+	ticketDB, bunDB := setupCheckinCountDB(t)
+	defer bunDB.Close()
+
+	ctx := context.Background()
+	orders := []models.Order{
+		{OrderID: "order1", EventID: "event1", Status: "completed"},
+		{OrderID: "order2", EventID: "event2", Status: "completed"},
+	}
+	_, err := bunDB.NewInsert().Model(&orders).Exec(ctx)
+	assert.NoError(t, err)
+
+	tickets := []models.Ticket{
+		{TicketID: "ticket1", OrderID: "order1", IssuedAt: time.Now()},
+		{TicketID: "ticket2", OrderID: "order1", CheckedIn: true, CheckedInTime: time.Now(), IssuedAt: time.Now()},
+		{TicketID: "ticket3", OrderID: "order1", IssuedAt: time.Now()},
+		{TicketID: "ticket4", OrderID: "order1", IssuedAt: time.Now()},
+		{TicketID: "ticket5", OrderID: "order2", IssuedAt: time.Now()},
+	}
+	_, err = bunDB.NewInsert().Model(&tickets).Exec(ctx)
+	assert.NoError(t, err)
+
+	count, err := ticketDB.CountUncheckedTicketsByEvent("event1")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	// Test case: pages skip checked-in tickets and other events
+	page, err := ticketDB.GetUncheckedTicketsByEvent("event1", "", 2)
+	assert.NoError(t, err)
+	assert.Len(t, page, 2)
+	assert.Equal(t, "ticket1", page[0].TicketID)
+	assert.Equal(t, "ticket3", page[1].TicketID)
+
+	page, err = ticketDB.GetUncheckedTicketsByEvent("event1", "ticket3", 2)
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, "ticket4", page[0].TicketID)
+
+	// Test case: only the QR image is replaced
+	assert.NoError(t, ticketDB.UpdateTicketQRCode("ticket1", []byte("new-qr")))
+	updated, err := ticketDB.GetTicketByID("ticket1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("new-qr"), updated.QRCode)
+	assert.Equal(t, "order1", updated.OrderID)
+}
+
+func TestQRRegenerationJobs(t *testing.T) {
+	ticketDB, bunDB := setupCheckinCountDB(t)
+	defer bunDB.Close()
+	ctx := context.Background()
+	_, err := bunDB.NewCreateTable().Model((*models.QRRegenerationJob)(nil)).Exec(ctx)
+	assert.NoError(t, err)
+	_, err = bunDB.Exec("CREATE UNIQUE INDEX qr_regeneration_jobs_running_event ON qr_regeneration_jobs (event_id) WHERE status = 'running'")
+	assert.NoError(t, err)
+
+	started := time.Now().Add(-time.Hour)
+	job := models.QRRegenerationJob{JobID: "job1", EventID: "event1", Status: "running", Total: 4, StartedAt: started, UpdatedAt: started}
+	created, err := ticketDB.CreateQRRegenerationJob(&job)
+	assert.NoError(t, err)
+	assert.True(t, created)
+
+	// Test case: a second running job of the same event is refused, other events are not
+	created, err = ticketDB.CreateQRRegenerationJob(&models.QRRegenerationJob{JobID: "job2", EventID: "event1", Status: "running", StartedAt: started, UpdatedAt: started})
+	assert.NoError(t, err)
+	assert.False(t, created)
+	created, err = ticketDB.CreateQRRegenerationJob(&models.QRRegenerationJob{JobID: "job3", EventID: "event2", Status: "running", StartedAt: started, UpdatedAt: started})
+	assert.NoError(t, err)
+	assert.True(t, created)
+
+	running, err := ticketDB.GetRunningQRRegenerationJob("event1")
+	assert.NoError(t, err)
+	assert.Equal(t, "job1", running.JobID)
+
+	// Test case: progress adds up and moves updated_at, so the job is no longer stale
+	assert.NoError(t, ticketDB.AddQRRegenerationProgress("job1", 2, 1, time.Now()))
+	assert.NoError(t, ticketDB.AddQRRegenerationProgress("job1", 2, 0, time.Now()))
+	stale, err := ticketDB.FailStaleQRRegenerationJobs("event1", time.Now().Add(-time.Minute), "abandoned")
+	assert.NoError(t, err)
+	assert.Zero(t, stale)
+
+	stored, err := ticketDB.GetQRRegenerationJob("job1")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, stored.Processed)
+	assert.Equal(t, 1, stored.Failed)
+
+	// Test case: a finished job frees the event for the next one
+	completedAt := time.Now()
+	stored.Status = "completed"
+	stored.CompletedAt = &completedAt
+	assert.NoError(t, ticketDB.FinishQRRegenerationJob(*stored))
+	_, err = ticketDB.GetRunningQRRegenerationJob("event1")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Test case: a job without recent progress is failed
+	stale, err = ticketDB.FailStaleQRRegenerationJobs("event2", time.Now().Add(-time.Minute), "abandoned")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stale)
+	abandoned, err := ticketDB.GetQRRegenerationJob("job3")
+	assert.NoError(t, err)
+	assert.Equal(t, "failed", abandoned.Status)
+	assert.Equal(t, "abandoned", abandoned.Error)
+}
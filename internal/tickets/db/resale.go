@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+	"time"
+)
+
+// UpdateResaleStatus updates only the resale listing fields of a ticket
+func (d *DB) UpdateResaleStatus(ticketID string, status string, askingPrice float64, listedAt time.Time) error {
+	q := d.Bun.NewUpdate().
+		Model((*models.Ticket)(nil)).
+		Set("resale_status = ?", status).
+		Where("ticket_id = ?", ticketID)
+
+	// Unlisting clears the price and listing time
+	if status == models.ResaleStatusNone {
+		q = q.Set("resale_price = NULL").Set("resale_listed_at = NULL")
+	} else {
+		q = q.Set("resale_price = ?", askingPrice).Set("resale_listed_at = ?", listedAt)
+	}
+
+	_, err := q.Exec(context.Background())
+	return err
+}
+
+// GetSessionIDByTicket returns the session of the order a ticket belongs to
+func (d *DB) GetSessionIDByTicket(ticketID string) (string, error) {
+	var sessionID string
+	err := d.Bun.NewSelect().
+		TableExpr("tickets AS t").
+		ColumnExpr("o.session_id").
+		Join("JOIN orders AS o ON o.order_id = t.order_id").
+		Where("t.ticket_id = ?", ticketID).
+		Limit(1).
+		Scan(context.Background(), &sessionID)
+	if err != nil {
+		return "", err
+	}
+	return sessionID, nil
+}
@@ -15,41 +15,23 @@ func (d *DB) GetTotalTicketsCount() (int, error) {
 	return count, err
 }
 
-// IncrementTicketCount increments the ticket count for a given event, session, and date
+// IncrementTicketCount increments the ticket count for a given event, session, and date.
+// It is a single upsert so concurrent increments for the same day can't lose updates.
 func (d *DB) IncrementTicketCount(eventID string, sessionID string, timestamp time.Time) error {
 	// Truncate timestamp to day precision
 	date := timestamp.Truncate(24 * time.Hour)
 
-	// Check if there's an existing count for this event/session/date
-	var existingCount models.TicketCount
-	err := d.Bun.NewSelect().
-		Model(&existingCount).
-		Where("event_id = ?", eventID).
-		Where("session_id = ?", sessionID).
-		Where("date = ?", date).
-		Limit(1).
-		Scan(context.Background())
-
-	if err != nil {
-		// If not found, create a new count record with count=1
-		newCount := models.TicketCount{
-			EventID:   eventID,
-			SessionID: sessionID,
-			Count:     1,
-			Date:      date,
-		}
-		_, err = d.Bun.NewInsert().Model(&newCount).Exec(context.Background())
-		return err
+	newCount := models.TicketCount{
+		EventID:   eventID,
+		SessionID: sessionID,
+		Count:     1,
+		Date:      date,
 	}
-
-	// If found, increment the count
-	existingCount.Count++
-	_, err = d.Bun.NewUpdate().
-		Model(&existingCount).
-		Column("count").
-		Where("event_id = ?", eventID).
-		Where("session_id = ?", sessionID).
-		Where("date = ?", date).
+	_, err := d.Bun.NewInsert().
+		Model(&newCount).
+		ExcludeColumn("id").
+		On("CONFLICT (event_id, session_id, date) DO UPDATE").
+		Set("count = tc.count + EXCLUDED.count").
 		Exec(context.Background())
 
 	return err
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"ms-ticketing/internal/models"
 	"ms-ticketing/internal/tickets/db"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -76,6 +78,49 @@ func TestIncrementTicketCount(t *testing.T) {
 	assert.Equal(t, 2, count.Count)
 }
 
+func TestIncrementTicketCountConcurrent(t *testing.T) {
+	// Use a file-backed DB so the goroutines really run on separate connections;
+	// every connection to ":memory:" would see its own empty database
+	sqldb, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "counts.db")+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	bunDB := bun.NewDB(sqldb, sqlitedialect.New())
+	defer bunDB.Close()
+	_, err = bunDB.NewCreateTable().Model((*models.TicketCount)(nil)).Exec(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to create ticket_count table: %v", err)
+	}
+	ticketCountDB := &db.DB{Bun: bunDB}
+
+	eventID := "event123"
+	sessionID := "session456"
+	timestamp := time.Now().Truncate(24 * time.Hour)
+
+	const workers = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- ticketCountDB.IncrementTicketCount(eventID, sessionID, timestamp)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	// Every increment must land on the same row
+	counts, err := ticketCountDB.GetTicketCountsForSession(sessionID)
+	assert.NoError(t, err)
+	if assert.Len(t, counts, 1) {
+		assert.Equal(t, workers, counts[0].Count)
+	}
+}
+
 func TestGetTicketCountsForEvent(t *testing.T) {
 	// Set up test DB
 	ticketCountDB, bunDB := setupTicketCountDB(t)
@@ -0,0 +1,132 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/png" // QR codes are stored as PNG
+	"ms-ticketing/internal/models"
+	"strings"
+)
+
+// TicketDetails holds the printable fields of a ticket
+type TicketDetails struct {
+	TicketID  string
+	OrderID   string
+	EventID   string
+	SessionID string
+	SeatLabel string
+	TierName  string
+	Price     float64
+	Currency  string // ISO 4217 code of the order; empty means the default currency
+	IssuedAt  string
+	QRCode    []byte // PNG image bytes
+}
+
+// Page size is A6 portrait, in points
+const (
+	pageWidth  = 298
+	pageHeight = 420
+	qrSize     = 180
+)
+
+// RenderTicket renders a single-page PDF ticket with the QR image embedded.
+// The PDF is written by hand so only the standard library is needed; it uses the
+// built-in Helvetica fonts, which every viewer ships with.
+func RenderTicket(details TicketDetails) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(details.QRCode))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode QR image: %w", err)
+	}
+
+	bounds := img.Bounds()
+	gray := make([]byte, 0, bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gray = append(gray, color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+		}
+	}
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(gray); err != nil {
+		return nil, fmt.Errorf("failed to compress QR image: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress QR image: %w", err)
+	}
+
+	content := pageContent(details)
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 4 0 R /F2 5 0 R >> /XObject << /QR 6 0 R >> >> /Contents 7 0 R >>", pageWidth, pageHeight),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream",
+			bounds.Dx(), bounds.Dy(), compressed.Len(), compressed.String()),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xrefStart := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xrefStart)
+
+	return out.Bytes(), nil
+}
+
+// pageContent lays out the text lines and places the QR image below them
+func pageContent(details TicketDetails) string {
+	var b strings.Builder
+
+	writeText := func(font string, size, y int, text string) {
+		fmt.Fprintf(&b, "BT /%s %d Tf 24 %d Td (%s) Tj ET\n", font, size, y, escapeText(text))
+	}
+
+	writeText("F2", 18, 385, "Ticketly Ticket")
+	writeText("F2", 14, 355, fmt.Sprintf("Seat %s", details.SeatLabel))
+	writeText("F1", 11, 335, fmt.Sprintf("Tier: %s", details.TierName))
+	writeText("F1", 11, 319, "Price: "+models.MoneyFromFloat(details.Price).Format(details.Currency))
+	writeText("F1", 8, 301, fmt.Sprintf("Event: %s", details.EventID))
+	writeText("F1", 8, 289, fmt.Sprintf("Session: %s", details.SessionID))
+	writeText("F1", 8, 277, fmt.Sprintf("Issued: %s", details.IssuedAt))
+
+	qrX := (pageWidth - qrSize) / 2
+	fmt.Fprintf(&b, "q %d 0 0 %d %d %d cm /QR Do Q\n", qrSize, qrSize, qrX, 70)
+
+	writeText("F1", 7, 50, fmt.Sprintf("Ticket: %s", details.TicketID))
+	writeText("F1", 7, 40, fmt.Sprintf("Order: %s", details.OrderID))
+
+	return b.String()
+}
+
+// escapeText escapes PDF string delimiters and drops characters the base fonts can't show
+func escapeText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteRune('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package pdf_test
+
+import (
+	"bytes"
+	"ms-ticketing/internal/tickets/pdf"
+	"testing"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderTicket(t *testing.T) {
+	qrPNG, err := qrcode.Encode("test-payload", qrcode.Medium, 256)
+	assert.NoError(t, err)
+
+	out, err := pdf.RenderTicket(pdf.TicketDetails{
+		TicketID:  "ticket123",
+		OrderID:   "order456",
+		EventID:   "event789",
+		SessionID: "session012",
+		SeatLabel: "A1 (front)",
+		TierName:  "VIP",
+		Price:     50.0,
+		IssuedAt:  "2025-01-01 10:00",
+		QRCode:    qrPNG,
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4")))
+	assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+	assert.Contains(t, string(out), `Seat A1 \(front\)`)
+	assert.Contains(t, string(out), "/Subtype /Image /Width 256 /Height 256")
+
+	// An invalid QR image is rejected
+	_, err = pdf.RenderTicket(pdf.TicketDetails{QRCode: []byte("not a png")})
+	assert.Error(t, err)
+}
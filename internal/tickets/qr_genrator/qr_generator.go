@@ -7,28 +7,182 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"ms-ticketing/internal/models"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/skip2/go-qrcode"
 )
 
-type QRGenerator struct {
+// DefaultRotationWindow is how long a rotating QR stays valid when rotation is enabled without
+// QR_ROTATION_WINDOW_SECONDS
+const DefaultRotationWindow = 60 * time.Second
+
+// DefaultKeyID names the key built from QR_SECRET_KEY when no key ID is configured
+const DefaultKeyID = "k1"
+
+// keyIDSeparator splits the key ID from the ciphertext; it is not in the URL-safe base64 alphabet
+const keyIDSeparator = "."
+
+// gcmMarker follows the key ID of QRs encrypted with AES-GCM. QRs without it were issued with
+// unauthenticated AES-CFB and are only still accepted when static.
+const gcmMarker = "g"
+
+// ErrQRExpired is returned when a rotating QR is older than the rotation window
+var ErrQRExpired = errors.New("QR code has expired, please refresh the ticket")
+
+// ErrUnknownQRKey is returned when a QR names a key that is not configured, e.g. one already retired
+var ErrUnknownQRKey = errors.New("QR code was signed with an unknown key")
+
+// QRKey is one QR encryption secret and the ID written in front of the QRs it encrypts
+type QRKey struct {
+	ID     string
+	Secret string
+}
+
+type qrKey struct {
+	id     string
 	secret []byte
 }
 
+type QRGenerator struct {
+	// keys are tried in order; the first encrypts new QRs, the rest only decrypt during a rotation
+	keys []qrKey
+	// rotationWindow is the max age of a QR at check-in; zero means static QRs (paper tickets)
+	rotationWindow time.Duration
+}
+
+// qrPayload is the encrypted QR content: the ticket plus, for rotating QRs, the moment the QR was issued.
+// Static QRs, the stored and printed ones, carry no issue time and never expire.
+type qrPayload struct {
+	models.Ticket
+	QRIssuedAt int64 `json:"qr_issued_at,omitempty"`
+}
+
 func NewQRGenerator(secret string) *QRGenerator {
-	hashed := sha256.Sum256([]byte(secret)) // normalize to 32 bytes
-	return &QRGenerator{secret: hashed[:]}
+	return NewQRGeneratorWithKeys([]QRKey{{ID: DefaultKeyID, Secret: secret}})
+}
+
+// NewQRGeneratorWithKeys creates a generator that encrypts with the first key and decrypts with any of them
+func NewQRGeneratorWithKeys(keys []QRKey) *QRGenerator {
+	gen := &QRGenerator{rotationWindow: rotationWindowFromEnv()}
+	for _, key := range keys {
+		hashed := sha256.Sum256([]byte(key.Secret)) // normalize to 32 bytes
+		gen.keys = append(gen.keys, qrKey{id: key.ID, secret: hashed[:]})
+	}
+	return gen
 }
 
+// NewQRGeneratorFromEnv creates a generator from QR_SECRET_KEYS, or from QR_SECRET_KEY when that is
+// unset or malformed. KeysFromEnv reports the malformed case, so startup can refuse it.
+func NewQRGeneratorFromEnv() *QRGenerator {
+	keys, err := KeysFromEnv()
+	if err != nil {
+		keys = []QRKey{{ID: DefaultKeyID, Secret: os.Getenv("QR_SECRET_KEY")}}
+	}
+	return NewQRGeneratorWithKeys(keys)
+}
+
+// KeysFromEnv reads QR_SECRET_KEYS, a comma-separated list of id:secret pairs with the current key first,
+// e.g. "k2:new-secret,k1:old-secret". Without it the single QR_SECRET_KEY is used under QR_SECRET_KEY_ID.
+func KeysFromEnv() ([]QRKey, error) {
+	value := strings.TrimSpace(os.Getenv("QR_SECRET_KEYS"))
+	if value == "" {
+		id := os.Getenv("QR_SECRET_KEY_ID")
+		if id == "" {
+			id = DefaultKeyID
+		}
+		return []QRKey{{ID: id, Secret: os.Getenv("QR_SECRET_KEY")}}, nil
+	}
+	return ParseKeys(value)
+}
+
+// ParseKeys parses an id:secret list as used by QR_SECRET_KEYS
+func ParseKeys(value string) ([]QRKey, error) {
+	var keys []QRKey
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(entry, ":")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" || secret == "" {
+			return nil, fmt.Errorf("QR key %q must be id:secret", id)
+		}
+		if strings.Contains(id, keyIDSeparator) {
+			return nil, fmt.Errorf("QR key ID %q must not contain %q", id, keyIDSeparator)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("QR key ID %q is listed twice", id)
+		}
+		seen[id] = true
+		keys = append(keys, QRKey{ID: id, Secret: secret})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no QR keys configured")
+	}
+	return keys, nil
+}
+
+// CurrentKeyID returns the ID of the key new QRs are encrypted with
+func (q *QRGenerator) CurrentKeyID() string {
+	return q.keys[0].id
+}
+
+// rotationWindowFromEnv reads QR_ROTATION_ENABLED and QR_ROTATION_WINDOW_SECONDS. QRs are static unless
+// rotation is enabled; QR_STATIC_MODE=true still forces static QRs.
+func rotationWindowFromEnv() time.Duration {
+	enabled, _ := strconv.ParseBool(os.Getenv("QR_ROTATION_ENABLED"))
+	if !enabled || os.Getenv("QR_STATIC_MODE") == "true" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(os.Getenv("QR_ROTATION_WINDOW_SECONDS"))
+	if err != nil || seconds <= 0 {
+		return DefaultRotationWindow
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Rotating reports whether QRs expire and must be re-fetched by the app
+func (q *QRGenerator) Rotating() bool {
+	return q.rotationWindow > 0
+}
+
+// CheckFresh rejects rotating QRs issued outside the rotation window. A QR without an issue time is
+// static, e.g. printed or issued before rotation was enabled, and is always accepted.
+func (q *QRGenerator) CheckFresh(issuedAt time.Time) error {
+	if !q.Rotating() || issuedAt.IsZero() {
+		return nil
+	}
+	age := time.Since(issuedAt)
+	// Allow a little clock skew between the issuing and scanning side
+	if age > q.rotationWindow || age < -5*time.Second {
+		return ErrQRExpired
+	}
+	return nil
+}
+
+// GenerateEncryptedQR returns a static QR image, as stored with the ticket and printed on its PDF
 func (q *QRGenerator) GenerateEncryptedQR(ticket models.Ticket) ([]byte, error) {
-	data, err := json.Marshal(ticket)
+	encrypted, err := q.EncryptTicket(ticket)
 	if err != nil {
 		return nil, err
 	}
 
-	encrypted, err := encryptAES(data, q.secret)
+	return qrcode.Encode(encrypted, qrcode.Medium, 256)
+}
+
+// GenerateRotatingQR returns a QR image that expires after the rotation window, for the app to show
+// at check-in. Without rotation it is a static QR like GenerateEncryptedQR's.
+func (q *QRGenerator) GenerateRotatingQR(ticket models.Ticket) ([]byte, error) {
+	encrypted, err := q.EncryptRotatingTicket(ticket)
 	if err != nil {
 		return nil, err
 	}
@@ -36,41 +190,148 @@ func (q *QRGenerator) GenerateEncryptedQR(ticket models.Ticket) ([]byte, error)
 	return qrcode.Encode(encrypted, qrcode.Medium, 256)
 }
 
-func encryptAES(data []byte, key []byte) (string, error) {
-	block, err := aes.NewCipher(key)
+// EncryptTicket returns the encrypted string that GenerateEncryptedQR encodes into the QR image
+func (q *QRGenerator) EncryptTicket(ticket models.Ticket) (string, error) {
+	return q.encryptPayload(qrPayload{Ticket: ticket})
+}
+
+// EncryptRotatingTicket is EncryptTicket stamped with the issue time when QRs rotate
+func (q *QRGenerator) EncryptRotatingTicket(ticket models.Ticket) (string, error) {
+	payload := qrPayload{Ticket: ticket}
+	if q.Rotating() {
+		payload.QRIssuedAt = time.Now().Unix()
+	}
+	return q.encryptPayload(payload)
+}
+
+func (q *QRGenerator) encryptPayload(payload qrPayload) (string, error) {
+	// Never nest a previously stored QR image inside the new one
+	payload.QRCode = nil
+	data, err := json.Marshal(payload)
 	if err != nil {
 		return "", err
 	}
 
-	ciphertext := make([]byte, aes.BlockSize+len(data))
-	iv := ciphertext[:aes.BlockSize]
-
-	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+	current := q.keys[0]
+	encrypted, err := encryptAES(data, current.secret)
+	if err != nil {
 		return "", err
 	}
+	return current.id + keyIDSeparator + gcmMarker + keyIDSeparator + encrypted, nil
+}
 
-	stream := cipher.NewCFBEncrypter(block, iv)
-	stream.XORKeyStream(ciphertext[aes.BlockSize:], data)
+// encryptAES seals data with AES-GCM, so any change to the QR, e.g. to its issue time, fails to decrypt
+func encryptAES(data []byte, key []byte) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
 
-	return base64.URLEncoding.EncodeToString(ciphertext), nil
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(gcm.Seal(nonce, nonce, data, nil)), nil
 }
 
 // DecryptQRData decrypts the encrypted QR code data and returns the ticket
 func (q *QRGenerator) DecryptQRData(encryptedData string) (*models.Ticket, error) {
-	decryptedData, err := decryptAES(encryptedData, q.secret)
+	ticket, _, err := q.DecryptQRToken(encryptedData)
+	return ticket, err
+}
+
+// DecryptQRToken decrypts the QR data and returns the ticket and when the QR was issued.
+// Static QRs have a zero issued-at time.
+func (q *QRGenerator) DecryptQRToken(encryptedData string) (*models.Ticket, time.Time, error) {
+	payload, err := q.decryptPayload(encryptedData)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var issuedAt time.Time
+	if payload.QRIssuedAt > 0 {
+		issuedAt = time.Unix(payload.QRIssuedAt, 0)
+	}
+	return &payload.Ticket, issuedAt, nil
+}
+
+// decryptPayload decrypts with the key the QR names. QRs from before key IDs carry no prefix and are
+// tried against every key; a wrong key yields bytes that don't parse as a payload. Both those and QRs
+// without the GCM marker are legacy CFB ones.
+func (q *QRGenerator) decryptPayload(encryptedData string) (*qrPayload, error) {
+	keys := q.keys
+	authenticated := false
+	if id, ciphertext, ok := strings.Cut(encryptedData, keyIDSeparator); ok {
+		keys = nil
+		for _, key := range q.keys {
+			if key.id == id {
+				keys = []qrKey{key}
+				break
+			}
+		}
+		if keys == nil {
+			return nil, ErrUnknownQRKey
+		}
+		encryptedData, authenticated = strings.CutPrefix(ciphertext, gcmMarker+keyIDSeparator)
+	}
+	decrypt := decryptLegacyCFB
+	if authenticated {
+		decrypt = decryptAES
+	}
+
+	var lastErr error
+	for _, key := range keys {
+		decryptedData, err := decrypt(encryptedData, key.secret)
+		if err != nil {
+			return nil, err
+		}
+		var payload qrPayload
+		if err := json.Unmarshal(decryptedData, &payload); err != nil {
+			lastErr = fmt.Errorf("invalid QR payload: %w", err)
+			continue
+		}
+		// A CFB QR's issue time could have been altered without the key, so rotating ones are refused
+		if payload.QRIssuedAt != 0 && !authenticated {
+			return nil, ErrQRExpired
+		}
+		return &payload, nil
+	}
+	return nil, lastErr
+}
+
+func decryptAES(encryptedData string, key []byte) ([]byte, error) {
+	ciphertext, err := base64.URLEncoding.DecodeString(encryptedData)
 	if err != nil {
 		return nil, err
 	}
 
-	var ticket models.Ticket
-	if err := json.Unmarshal(decryptedData, &ticket); err != nil {
+	block, err := aes.NewCipher(key)
+	if err != nil {
 		return nil, err
 	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("QR code is too short")
+	}
 
-	return &ticket, nil
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	data, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, errors.New("QR code failed authentication")
+	}
+	return data, nil
 }
 
-func decryptAES(encryptedData string, key []byte) ([]byte, error) {
+// decryptLegacyCFB reads QRs issued before AES-GCM. CFB has no authentication, so only static QRs,
+// e.g. printed tickets, are still accepted from it.
+func decryptLegacyCFB(encryptedData string, key []byte) ([]byte, error) {
 	ciphertext, err := base64.URLEncoding.DecodeString(encryptedData)
 	if err != nil {
 		return nil, err
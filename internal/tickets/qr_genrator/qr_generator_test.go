@@ -0,0 +1,185 @@
+package qr_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"ms-ticketing/internal/models"
+	qr "ms-ticketing/internal/tickets/qr_genrator"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptTicketRoundTrip(t *testing.T) {
+	gen := qr.NewQRGenerator("test-secret")
+
+	ticket := models.Ticket{
+		TicketID:  "ticket123",
+		OrderID:   "order456",
+		SeatLabel: "A1",
+		QRCode:    []byte("old qr image"),
+	}
+
+	encrypted, err := gen.EncryptTicket(ticket)
+	assert.NoError(t, err)
+
+	decrypted, issuedAt, err := gen.DecryptQRToken(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, ticket.TicketID, decrypted.TicketID)
+	assert.Equal(t, ticket.OrderID, decrypted.OrderID)
+	assert.Empty(t, decrypted.QRCode)
+	// Stored and printed QRs are static
+	assert.True(t, issuedAt.IsZero())
+
+	// A different secret can't read the QR
+	_, _, err = qr.NewQRGenerator("other-secret").DecryptQRToken(encrypted)
+	assert.Error(t, err)
+}
+
+func TestRotationOffByDefault(t *testing.T) {
+	t.Setenv("QR_ROTATION_ENABLED", "")
+	t.Setenv("QR_STATIC_MODE", "")
+	gen := qr.NewQRGenerator("test-secret")
+
+	assert.False(t, gen.Rotating())
+	encrypted, err := gen.EncryptRotatingTicket(models.Ticket{TicketID: "ticket123"})
+	assert.NoError(t, err)
+	_, issuedAt, err := gen.DecryptQRToken(encrypted)
+	assert.NoError(t, err)
+	assert.True(t, issuedAt.IsZero())
+}
+
+func TestCheckFreshRotating(t *testing.T) {
+	t.Setenv("QR_ROTATION_ENABLED", "true")
+	t.Setenv("QR_STATIC_MODE", "false")
+	t.Setenv("QR_ROTATION_WINDOW_SECONDS", "30")
+	gen := qr.NewQRGenerator("test-secret")
+
+	assert.True(t, gen.Rotating())
+	assert.NoError(t, gen.CheckFresh(time.Now().Add(-10*time.Second)))
+	assert.ErrorIs(t, gen.CheckFresh(time.Now().Add(-time.Minute)), qr.ErrQRExpired)
+	// Static QRs, printed or issued before rotation, carry no timestamp and stay valid
+	assert.NoError(t, gen.CheckFresh(time.Time{}))
+
+	ticket := models.Ticket{TicketID: "ticket123"}
+	encrypted, err := gen.EncryptRotatingTicket(ticket)
+	assert.NoError(t, err)
+	_, issuedAt, err := gen.DecryptQRToken(encrypted)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), issuedAt, 2*time.Second)
+
+	encrypted, err = gen.EncryptTicket(ticket)
+	assert.NoError(t, err)
+	_, issuedAt, err = gen.DecryptQRToken(encrypted)
+	assert.NoError(t, err)
+	assert.True(t, issuedAt.IsZero())
+}
+
+func TestCheckFreshStaticMode(t *testing.T) {
+	t.Setenv("QR_ROTATION_ENABLED", "true")
+	t.Setenv("QR_STATIC_MODE", "true")
+	gen := qr.NewQRGenerator("test-secret")
+
+	assert.False(t, gen.Rotating())
+	assert.NoError(t, gen.CheckFresh(time.Now().Add(-24*time.Hour)))
+	assert.NoError(t, gen.CheckFresh(time.Time{}))
+}
+
+func TestKeyRotation(t *testing.T) {
+	ticket := models.Ticket{TicketID: "ticket123", OrderID: "order456"}
+
+	old := qr.NewQRGeneratorWithKeys([]qr.QRKey{{ID: "k1", Secret: "old-secret"}})
+	issuedBefore, err := old.EncryptTicket(ticket)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(issuedBefore, "k1."))
+
+	// During rotation the new key encrypts and the old one still decrypts
+	rotating := qr.NewQRGeneratorWithKeys([]qr.QRKey{{ID: "k2", Secret: "new-secret"}, {ID: "k1", Secret: "old-secret"}})
+	assert.Equal(t, "k2", rotating.CurrentKeyID())
+	issuedAfter, err := rotating.EncryptTicket(ticket)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(issuedAfter, "k2."))
+	for _, encrypted := range []string{issuedBefore, issuedAfter} {
+		decrypted, err := rotating.DecryptQRData(encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, ticket.TicketID, decrypted.TicketID)
+	}
+
+	// Once the old key is retired its QRs are refused
+	retired := qr.NewQRGeneratorWithKeys([]qr.QRKey{{ID: "k2", Secret: "new-secret"}})
+	_, err = retired.DecryptQRData(issuedBefore)
+	assert.ErrorIs(t, err, qr.ErrUnknownQRKey)
+}
+
+// legacyCFBToken encrypts a QR payload the way QRs were issued before AES-GCM
+func legacyCFBToken(t *testing.T, secret string, payload map[string]interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	require.NoError(t, err)
+
+	ciphertext := make([]byte, aes.BlockSize+len(data))
+	_, err = rand.Read(ciphertext[:aes.BlockSize])
+	require.NoError(t, err)
+	cipher.NewCFBEncrypter(block, ciphertext[:aes.BlockSize]).XORKeyStream(ciphertext[aes.BlockSize:], data)
+	return base64.URLEncoding.EncodeToString(ciphertext)
+}
+
+func TestDecryptLegacyQRWithoutKeyID(t *testing.T) {
+	legacy := legacyCFBToken(t, "old-secret", map[string]interface{}{"TicketID": "ticket123", "OrderID": "order456"})
+
+	// QRs issued before key IDs are tried against every configured key
+	gen := qr.NewQRGeneratorWithKeys([]qr.QRKey{{ID: "k2", Secret: "new-secret"}, {ID: "k1", Secret: "old-secret"}})
+	decrypted, err := gen.DecryptQRData(legacy)
+	assert.NoError(t, err)
+	assert.Equal(t, "ticket123", decrypted.TicketID)
+
+	// Static CFB QRs issued with a key ID still work too
+	decrypted, err = gen.DecryptQRData("k1." + legacy)
+	assert.NoError(t, err)
+	assert.Equal(t, "ticket123", decrypted.TicketID)
+}
+
+func TestLegacyRotatingQRIsRefused(t *testing.T) {
+	// The issue time of a CFB QR can be changed without the key, so it can't be trusted
+	legacy := legacyCFBToken(t, "test-secret", map[string]interface{}{"TicketID": "ticket123", "qr_issued_at": time.Now().Unix()})
+	_, _, err := qr.NewQRGenerator("test-secret").DecryptQRToken("k1." + legacy)
+	assert.ErrorIs(t, err, qr.ErrQRExpired)
+}
+
+func TestTamperedQRIsRefused(t *testing.T) {
+	t.Setenv("QR_ROTATION_ENABLED", "true")
+	t.Setenv("QR_STATIC_MODE", "false")
+	gen := qr.NewQRGenerator("test-secret")
+
+	encrypted, err := gen.EncryptRotatingTicket(models.Ticket{TicketID: "ticket123"})
+	require.NoError(t, err)
+	prefix := "k1.g."
+	require.True(t, strings.HasPrefix(encrypted, prefix))
+
+	sealed, err := base64.URLEncoding.DecodeString(encrypted[len(prefix):])
+	require.NoError(t, err)
+	sealed[len(sealed)-20] ^= 0x01
+	_, _, err = gen.DecryptQRToken(prefix + base64.URLEncoding.EncodeToString(sealed))
+	assert.Error(t, err)
+}
+
+func TestParseKeys(t *testing.T) {
+	keys, err := qr.ParseKeys("k2:new-secret, k1:old:secret")
+	assert.NoError(t, err)
+	assert.Equal(t, []qr.QRKey{{ID: "k2", Secret: "new-secret"}, {ID: "k1", Secret: "old:secret"}}, keys)
+
+	for _, value := range []string{"", "no-secret", "k1:", "k.1:secret", "k1:a,k1:b"} {
+		_, err := qr.ParseKeys(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,75 @@
+package tickets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrCheckinNotOpen = errors.New("check-in for this session has not opened yet")
+	ErrCheckinClosed  = errors.New("check-in for this session has closed")
+)
+
+const (
+	// DefaultCheckinOpensBefore is how long before a session starts its tickets can be checked in
+	DefaultCheckinOpensBefore = 2 * time.Hour
+	// DefaultCheckinClosesAfter is how long after a session starts its tickets can still be checked in
+	DefaultCheckinClosesAfter = 6 * time.Hour
+)
+
+// CheckinOpensBefore returns CHECKIN_OPENS_BEFORE_MINUTES or the default
+func CheckinOpensBefore() time.Duration {
+	return checkinBound("CHECKIN_OPENS_BEFORE_MINUTES", DefaultCheckinOpensBefore)
+}
+
+// CheckinClosesAfter returns CHECKIN_CLOSES_AFTER_MINUTES or the default
+func CheckinClosesAfter() time.Duration {
+	return checkinBound("CHECKIN_CLOSES_AFTER_MINUTES", DefaultCheckinClosesAfter)
+}
+
+func checkinBound(name string, fallback time.Duration) time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || minutes < 0 {
+		return fallback
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// CheckinWindow is the span in which a session's tickets may be checked in
+type CheckinWindow struct {
+	Opens  time.Time
+	Closes time.Time
+}
+
+// GetCheckinWindow derives the check-in window of a session from its start time
+func (s *TicketService) GetCheckinWindow(sessionID string) (*CheckinWindow, error) {
+	if s.Sessions == nil {
+		return nil, fmt.Errorf("session schedule lookup is not configured")
+	}
+	startsAt, err := s.Sessions.SessionStartTime(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get start time of session %s: %w", sessionID, err)
+	}
+	return &CheckinWindow{
+		Opens:  startsAt.Add(-CheckinOpensBefore()),
+		Closes: startsAt.Add(CheckinClosesAfter()),
+	}, nil
+}
+
+// CheckCheckinWindow returns ErrCheckinNotOpen or ErrCheckinClosed when now is outside the session's check-in window
+func (s *TicketService) CheckCheckinWindow(sessionID string, now time.Time) error {
+	window, err := s.GetCheckinWindow(sessionID)
+	if err != nil {
+		return err
+	}
+	if now.Before(window.Opens) {
+		return fmt.Errorf("%w: opens at %s", ErrCheckinNotOpen, window.Opens.UTC().Format(time.RFC3339))
+	}
+	if now.After(window.Closes) {
+		return fmt.Errorf("%w: closed at %s", ErrCheckinClosed, window.Closes.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package tickets_test
+
+import (
+	"errors"
+	tickets "ms-ticketing/internal/tickets/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCheckinWindow(t *testing.T) {
+	t.Setenv("CHECKIN_OPENS_BEFORE_MINUTES", "120")
+	t.Setenv("CHECKIN_CLOSES_AFTER_MINUTES", "240")
+
+	startsAt := time.Date(2025, 6, 1, 19, 0, 0, 0, time.UTC)
+	sessions := new(MockSessionSchedule)
+	sessions.On("SessionStartTime", "session-1").Return(startsAt, nil)
+	sessions.On("SessionStartTime", "session-down").Return(time.Time{}, errors.New("seating service unavailable"))
+	ticketSvc := &tickets.TicketService{DB: new(MockTicketDBLayer), Sessions: sessions}
+
+	assert.ErrorIs(t, ticketSvc.CheckCheckinWindow("session-1", startsAt.Add(-3*time.Hour)), tickets.ErrCheckinNotOpen)
+	assert.NoError(t, ticketSvc.CheckCheckinWindow("session-1", startsAt.Add(-2*time.Hour)))
+	assert.NoError(t, ticketSvc.CheckCheckinWindow("session-1", startsAt.Add(4*time.Hour)))
+	assert.ErrorIs(t, ticketSvc.CheckCheckinWindow("session-1", startsAt.Add(4*time.Hour+time.Minute)), tickets.ErrCheckinClosed)
+
+	// Lookup failures are not mistaken for a closed window
+	err := ticketSvc.CheckCheckinWindow("session-down", startsAt)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, tickets.ErrCheckinClosed)
+	assert.NotErrorIs(t, err, tickets.ErrCheckinNotOpen)
+}
+
+func TestCheckinWindowBoundsDefaults(t *testing.T) {
+	t.Setenv("CHECKIN_OPENS_BEFORE_MINUTES", "")
+	t.Setenv("CHECKIN_CLOSES_AFTER_MINUTES", "-5")
+	assert.Equal(t, tickets.DefaultCheckinOpensBefore, tickets.CheckinOpensBefore())
+	assert.Equal(t, tickets.DefaultCheckinClosesAfter, tickets.CheckinClosesAfter())
+}
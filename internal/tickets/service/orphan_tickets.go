@@ -0,0 +1,54 @@
+package tickets
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DeleteTicketsByOrder removes every ticket of an order, e.g. when placing the order is rolled back
+func (s *TicketService) DeleteTicketsByOrder(orderID string) error {
+	deleted, err := s.DB.DeleteTicketsByOrder(orderID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tickets of order %s: %w", orderID, err)
+	}
+	if deleted > 0 {
+		fmt.Printf("🗑️ Deleted %d tickets of order %s\n", deleted, orderID)
+	}
+	return nil
+}
+
+// DeleteOrphanedTickets removes tickets issued before the cutoff whose order is cancelled or gone.
+// The cutoff keeps tickets of orders still being placed or just cancelled, whose cancellation event
+// is built from them, out of reach. It returns the number of tickets deleted.
+func (s *TicketService) DeleteOrphanedTickets(issuedBefore time.Time) (int, error) {
+	deleted, err := s.DB.DeleteOrphanedTickets(issuedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete orphaned tickets: %w", err)
+	}
+	if deleted > 0 {
+		fmt.Printf("🗑️ Deleted %d orphaned tickets issued before %s\n", deleted, issuedBefore.Format(time.RFC3339))
+	}
+	return deleted, nil
+}
+
+// StartOrphanTicketSweeper runs DeleteOrphanedTickets every interval for tickets older than grace,
+// until ctx is cancelled
+func (s *TicketService) StartOrphanTicketSweeper(ctx context.Context, interval, grace time.Duration) {
+	fmt.Printf("Orphaned ticket sweeper enabled, checking every %s\n", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("Stopping orphaned ticket sweeper")
+			return
+		case <-ticker.C:
+			if _, err := s.DeleteOrphanedTickets(time.Now().Add(-grace)); err != nil {
+				fmt.Printf("❌ Orphaned ticket sweep failed: %v\n", err)
+			}
+		}
+	}
+}
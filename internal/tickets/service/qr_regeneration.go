@@ -0,0 +1,222 @@
+package tickets
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/models"
+	qr_genrator "ms-ticketing/internal/tickets/qr_genrator"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TicketQRRegeneratedTopic receives an event for every ticket whose QR was regenerated, so apps refresh it
+const TicketQRRegeneratedTopic = "ticketly.ticket.qr_regenerated"
+
+// DefaultQRRegenerationBatchSize is how many tickets are regenerated per batch
+const DefaultQRRegenerationBatchSize = 100
+
+// States of a QR regeneration job
+const (
+	QRJobRunning   = "running"
+	QRJobCompleted = "completed"
+	QRJobFailed    = "failed"
+)
+
+// DefaultQRJobStaleAfter is how long a running job may go without progress before another start takes over
+const DefaultQRJobStaleAfter = 15 * time.Minute
+
+var (
+	ErrQRRegenerationRunning = errors.New("QR codes of this event are already being regenerated")
+	ErrQRJobNotFound         = errors.New("QR regeneration job not found")
+	ErrQRJobStoreMissing     = errors.New("QR regeneration job store not configured")
+)
+
+// QRJobStore keeps regeneration jobs where every instance sees them, so one job per event holds across
+// replicas and any instance can report a job's progress
+type QRJobStore interface {
+	CreateQRRegenerationJob(job *models.QRRegenerationJob) (bool, error)
+	GetQRRegenerationJob(jobID string) (*models.QRRegenerationJob, error)
+	GetRunningQRRegenerationJob(eventID string) (*models.QRRegenerationJob, error)
+	AddQRRegenerationProgress(jobID string, processed, failed int, at time.Time) error
+	FinishQRRegenerationJob(job models.QRRegenerationJob) error
+	FailStaleQRRegenerationJobs(eventID string, before time.Time, reason string) (int, error)
+}
+
+// TicketQRRegeneratedEvent is published to TicketQRRegeneratedTopic
+type TicketQRRegeneratedEvent struct {
+	TicketID      string    `json:"ticket_id"`
+	OrderID       string    `json:"order_id"`
+	EventID       string    `json:"event_id"`
+	KeyID         string    `json:"key_id"`
+	RegeneratedAt time.Time `json:"regenerated_at"`
+}
+
+// SetQRJobStore sets where QR regeneration jobs are kept
+func (s *TicketService) SetQRJobStore(store QRJobStore) {
+	s.QRJobs = store
+}
+
+// QRRegenerationBatchSize returns the batch size from QR_REGENERATION_BATCH_SIZE or the default
+func QRRegenerationBatchSize() int {
+	size, err := strconv.Atoi(os.Getenv("QR_REGENERATION_BATCH_SIZE"))
+	if err != nil || size < 1 {
+		return DefaultQRRegenerationBatchSize
+	}
+	return size
+}
+
+// StartQRRegeneration regenerates the QR codes of every ticket of an event that is not checked in yet,
+// encrypting them with the current QR key and a fresh nonce. The work runs in the background in batches;
+// the returned job is a snapshot whose progress is read with GetQRRegenerationJob.
+// Only one job per event runs at a time; a second start returns the running job with ErrQRRegenerationRunning.
+// A running job without progress for DefaultQRJobStaleAfter is taken to be abandoned and failed first.
+func (s *TicketService) StartQRRegeneration(eventID string) (models.QRRegenerationJob, error) {
+	if s.QRJobs == nil {
+		return models.QRRegenerationJob{}, ErrQRJobStoreMissing
+	}
+
+	total, err := s.DB.CountUncheckedTicketsByEvent(eventID)
+	if err != nil {
+		return models.QRRegenerationJob{}, fmt.Errorf("failed to count tickets of event %s: %w", eventID, err)
+	}
+
+	now := time.Now()
+	if stale, err := s.QRJobs.FailStaleQRRegenerationJobs(eventID, now.Add(-DefaultQRJobStaleAfter), "abandoned without progress"); err != nil {
+		fmt.Printf("❌ Failed to expire stale QR regeneration jobs of event %s: %v\n", eventID, err)
+	} else if stale > 0 {
+		fmt.Printf("⚠️ Failed %d stale QR regeneration jobs of event %s\n", stale, eventID)
+	}
+
+	qrGen := qr_genrator.NewQRGeneratorFromEnv()
+	job := models.QRRegenerationJob{
+		JobID:     uuid.New().String(),
+		EventID:   eventID,
+		Status:    QRJobRunning,
+		KeyID:     qrGen.CurrentKeyID(),
+		Total:     total,
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+	created, err := s.QRJobs.CreateQRRegenerationJob(&job)
+	if err != nil {
+		return models.QRRegenerationJob{}, fmt.Errorf("failed to store QR regeneration job: %w", err)
+	}
+	if !created {
+		running, err := s.QRJobs.GetRunningQRRegenerationJob(eventID)
+		if err != nil {
+			return models.QRRegenerationJob{}, fmt.Errorf("failed to load running QR regeneration job of event %s: %w", eventID, err)
+		}
+		return *running, ErrQRRegenerationRunning
+	}
+
+	go s.runQRRegeneration(qrGen, job, QRRegenerationBatchSize())
+	return job, nil
+}
+
+// GetQRRegenerationJob returns the current progress of a job started on any instance
+func (s *TicketService) GetQRRegenerationJob(jobID string) (models.QRRegenerationJob, error) {
+	if s.QRJobs == nil {
+		return models.QRRegenerationJob{}, ErrQRJobStoreMissing
+	}
+	job, err := s.QRJobs.GetQRRegenerationJob(jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.QRRegenerationJob{}, ErrQRJobNotFound
+	}
+	if err != nil {
+		return models.QRRegenerationJob{}, fmt.Errorf("failed to load QR regeneration job %s: %w", jobID, err)
+	}
+	return *job, nil
+}
+
+func (s *TicketService) runQRRegeneration(qrGen *qr_genrator.QRGenerator, job models.QRRegenerationJob, batchSize int) {
+	after := ""
+	var runErr error
+	for {
+		batch, err := s.DB.GetUncheckedTicketsByEvent(job.EventID, after, batchSize)
+		if err != nil {
+			runErr = fmt.Errorf("failed to load tickets after %q: %w", after, err)
+			break
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		processed, failed := 0, 0
+		for _, ticket := range batch {
+			if err := s.regenerateTicketQR(qrGen, ticket, job.EventID); err != nil {
+				fmt.Printf("❌ Failed to regenerate QR of ticket %s: %v\n", ticket.TicketID, err)
+				failed++
+			}
+			processed++
+		}
+		after = batch[len(batch)-1].TicketID
+
+		job.Processed += processed
+		job.Failed += failed
+		if err := s.QRJobs.AddQRRegenerationProgress(job.JobID, processed, failed, time.Now()); err != nil {
+			fmt.Printf("❌ Failed to record progress of QR regeneration %s: %v\n", job.JobID, err)
+		}
+
+		if len(batch) < batchSize {
+			break
+		}
+	}
+
+	completedAt := time.Now()
+	job.Status = QRJobCompleted
+	if runErr != nil {
+		job.Status = QRJobFailed
+		job.Error = runErr.Error()
+	}
+	// Tickets sold while the job ran count towards progress beyond the initial total
+	if job.Processed > job.Total {
+		job.Total = job.Processed
+	}
+	job.UpdatedAt = completedAt
+	job.CompletedAt = &completedAt
+	if err := s.QRJobs.FinishQRRegenerationJob(job); err != nil {
+		fmt.Printf("❌ Failed to record the end of QR regeneration %s: %v\n", job.JobID, err)
+	}
+
+	fmt.Printf("✅ QR regeneration %s for event %s finished: %d processed, %d failed\n", job.JobID, job.EventID, job.Processed, job.Failed)
+}
+
+func (s *TicketService) regenerateTicketQR(qrGen *qr_genrator.QRGenerator, ticket models.Ticket, eventID string) error {
+	qrBytes, err := qrGen.GenerateEncryptedQR(ticket)
+	if err != nil {
+		return fmt.Errorf("failed to generate QR: %w", err)
+	}
+	if err := s.DB.UpdateTicketQRCode(ticket.TicketID, qrBytes); err != nil {
+		return fmt.Errorf("failed to store QR: %w", err)
+	}
+	s.publishQRRegenerated(ticket, eventID, qrGen.CurrentKeyID())
+	return nil
+}
+
+// publishQRRegenerated is best effort; the new QR is already stored and served on the next fetch
+func (s *TicketService) publishQRRegenerated(ticket models.Ticket, eventID string, keyID string) {
+	if s.Publisher == nil {
+		return
+	}
+
+	payload, err := json.Marshal(TicketQRRegeneratedEvent{
+		TicketID:      ticket.TicketID,
+		OrderID:       ticket.OrderID,
+		EventID:       eventID,
+		KeyID:         keyID,
+		RegeneratedAt: time.Now(),
+	})
+	if err != nil {
+		fmt.Printf("❌ Failed to marshal QR regenerated event: %v\n", err)
+		return
+	}
+
+	if err := s.Publisher.Publish(TicketQRRegeneratedTopic, ticket.TicketID, payload); err != nil {
+		fmt.Printf("❌ Failed to publish QR regenerated event: %v\n", err)
+	}
+}
@@ -0,0 +1,148 @@
+package tickets_test
+
+import (
+	"context"
+	"database/sql"
+	"ms-ticketing/internal/models"
+	ticket_db "ms-ticketing/internal/tickets/db"
+	tickets "ms-ticketing/internal/tickets/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	_ "github.com/uptrace/bun/driver/sqliteshim"
+)
+
+// newQRJobStore keeps jobs in an in-memory database with the running-job index of the migration
+func newQRJobStore(t *testing.T) *ticket_db.DB {
+	t.Helper()
+	sqldb, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	sqldb.SetMaxOpenConns(1)
+	bunDB := bun.NewDB(sqldb, sqlitedialect.New())
+	t.Cleanup(func() { bunDB.Close() })
+
+	_, err = bunDB.NewCreateTable().Model((*models.QRRegenerationJob)(nil)).Exec(context.Background())
+	require.NoError(t, err)
+	_, err = bunDB.Exec("CREATE UNIQUE INDEX qr_regeneration_jobs_running_event ON qr_regeneration_jobs (event_id) WHERE status = 'running'")
+	require.NoError(t, err)
+	return &ticket_db.DB{Bun: bunDB}
+}
+
+func waitForQRJob(t *testing.T, ticketSvc *tickets.TicketService, jobID string) models.QRRegenerationJob {
+	var job models.QRRegenerationJob
+	assert.Eventually(t, func() bool {
+		var err error
+		job, err = ticketSvc.GetQRRegenerationJob(jobID)
+		return err == nil && job.Status != tickets.QRJobRunning
+	}, 2*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestStartQRRegeneration(t *testing.T) {
+	t.Setenv("QR_SECRET_KEYS", "k2:new-secret,k1:old-secret")
+	t.Setenv("QR_REGENERATION_BATCH_SIZE", "2")
+
+	mockDB := new(MockTicketDBLayer)
+	mockPublisher := new(MockPublisher)
+	ticketSvc := &tickets.TicketService{DB: mockDB, Publisher: mockPublisher, QRJobs: newQRJobStore(t)}
+
+	mockDB.On("CountUncheckedTicketsByEvent", "event1").Return(3, nil)
+	mockDB.On("GetUncheckedTicketsByEvent", "event1", "", 2).Return([]models.Ticket{
+		{TicketID: "ticket1", OrderID: "order1"},
+		{TicketID: "ticket2", OrderID: "order1"},
+	}, nil)
+	mockDB.On("GetUncheckedTicketsByEvent", "event1", "ticket2", 2).Return([]models.Ticket{
+		{TicketID: "ticket3", OrderID: "order2"},
+	}, nil)
+	mockDB.On("UpdateTicketQRCode", "ticket1", mock.Anything).Return(nil)
+	mockDB.On("UpdateTicketQRCode", "ticket2", mock.Anything).Return(assert.AnError)
+	mockDB.On("UpdateTicketQRCode", "ticket3", mock.Anything).Return(nil)
+	mockPublisher.On("Publish", tickets.TicketQRRegeneratedTopic, mock.Anything, mock.Anything).Return(nil)
+
+	job, err := ticketSvc.StartQRRegeneration("event1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, job.JobID)
+	assert.Equal(t, "k2", job.KeyID)
+	assert.Equal(t, 3, job.Total)
+
+	job = waitForQRJob(t, ticketSvc, job.JobID)
+	assert.Equal(t, tickets.QRJobCompleted, job.Status)
+	assert.Equal(t, 3, job.Processed)
+	assert.Equal(t, 1, job.Failed)
+	assert.NotNil(t, job.CompletedAt)
+	mockDB.AssertExpectations(t)
+	// Only tickets whose new QR was stored are announced
+	mockPublisher.AssertNumberOfCalls(t, "Publish", 2)
+}
+
+func TestStartQRRegenerationRunsOneJobPerEvent(t *testing.T) {
+	mockDB := new(MockTicketDBLayer)
+	store := newQRJobStore(t)
+	ticketSvc := &tickets.TicketService{DB: mockDB, QRJobs: store}
+
+	release := make(chan struct{})
+	mockDB.On("CountUncheckedTicketsByEvent", "event2").Return(0, nil)
+	mockDB.On("GetUncheckedTicketsByEvent", "event2", "", mock.Anything).
+		Run(func(mock.Arguments) { <-release }).
+		Return([]models.Ticket{}, nil)
+
+	first, err := ticketSvc.StartQRRegeneration("event2")
+	assert.NoError(t, err)
+
+	second, err := ticketSvc.StartQRRegeneration("event2")
+	assert.ErrorIs(t, err, tickets.ErrQRRegenerationRunning)
+	assert.Equal(t, first.JobID, second.JobID)
+
+	// Another instance sharing the store sees the running job too
+	replica := &tickets.TicketService{DB: mockDB, QRJobs: store}
+	_, err = replica.StartQRRegeneration("event2")
+	assert.ErrorIs(t, err, tickets.ErrQRRegenerationRunning)
+	running, err := replica.GetQRRegenerationJob(first.JobID)
+	assert.NoError(t, err)
+	assert.Equal(t, tickets.QRJobRunning, running.Status)
+
+	close(release)
+	assert.Equal(t, tickets.QRJobCompleted, waitForQRJob(t, ticketSvc, first.JobID).Status)
+
+	// Once finished the event can be regenerated again
+	third, err := ticketSvc.StartQRRegeneration("event2")
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.JobID, third.JobID)
+	waitForQRJob(t, ticketSvc, third.JobID)
+}
+
+func TestStartQRRegenerationTakesOverStaleJobs(t *testing.T) {
+	mockDB := new(MockTicketDBLayer)
+	store := newQRJobStore(t)
+	ticketSvc := &tickets.TicketService{DB: mockDB, QRJobs: store}
+
+	// A job whose instance died long ago still shows as running
+	abandoned := models.QRRegenerationJob{JobID: "abandoned", EventID: "event3", Status: tickets.QRJobRunning,
+		StartedAt: time.Now().Add(-time.Hour), UpdatedAt: time.Now().Add(-time.Hour)}
+	created, err := store.CreateQRRegenerationJob(&abandoned)
+	require.NoError(t, err)
+	require.True(t, created)
+
+	mockDB.On("CountUncheckedTicketsByEvent", "event3").Return(0, nil)
+	mockDB.On("GetUncheckedTicketsByEvent", "event3", "", mock.Anything).Return([]models.Ticket{}, nil)
+
+	job, err := ticketSvc.StartQRRegeneration("event3")
+	assert.NoError(t, err)
+	assert.Equal(t, tickets.QRJobCompleted, waitForQRJob(t, ticketSvc, job.JobID).Status)
+
+	stale, err := ticketSvc.GetQRRegenerationJob("abandoned")
+	assert.NoError(t, err)
+	assert.Equal(t, tickets.QRJobFailed, stale.Status)
+	assert.NotEmpty(t, stale.Error)
+}
+
+func TestGetQRRegenerationJobNotFound(t *testing.T) {
+	ticketSvc := &tickets.TicketService{DB: new(MockTicketDBLayer), QRJobs: newQRJobStore(t)}
+	_, err := ticketSvc.GetQRRegenerationJob("missing")
+	assert.ErrorIs(t, err, tickets.ErrQRJobNotFound)
+}
@@ -0,0 +1,151 @@
+package tickets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/models"
+	"time"
+)
+
+// TicketListedTopic receives an event whenever a ticket is listed for resale
+const TicketListedTopic = "ticketly.ticket.listed"
+
+var (
+	ErrTicketCheckedIn     = errors.New("checked in tickets cannot be resold")
+	ErrSessionStarted      = errors.New("tickets cannot be resold after the session has started")
+	ErrTicketAlreadyListed = errors.New("ticket is already listed for resale")
+	ErrTicketNotListed     = errors.New("ticket is not listed for resale")
+	ErrTicketResold        = errors.New("ticket has already been resold")
+)
+
+// EventPublisher publishes ticket events, typically the Kafka producer
+type EventPublisher interface {
+	Publish(topic string, key string, value []byte) error
+}
+
+// SessionScheduleLookup resolves when a session starts
+type SessionScheduleLookup interface {
+	SessionStartTime(sessionID string) (time.Time, error)
+}
+
+// TicketListedEvent is published to TicketListedTopic
+type TicketListedEvent struct {
+	TicketID    string    `json:"ticket_id"`
+	OrderID     string    `json:"order_id"`
+	SessionID   string    `json:"session_id"`
+	SeatID      string    `json:"seat_id"`
+	SeatLabel   string    `json:"seat_label"`
+	TierID      string    `json:"tier_id"`
+	AskingPrice float64   `json:"asking_price"`
+	ListedAt    time.Time `json:"listed_at"`
+}
+
+// SetPublisher sets the publisher used for ticket events
+func (s *TicketService) SetPublisher(publisher EventPublisher) {
+	s.Publisher = publisher
+}
+
+// SetSessionSchedule sets the lookup used to check session start times
+func (s *TicketService) SetSessionSchedule(sessions SessionScheduleLookup) {
+	s.Sessions = sessions
+}
+
+// ListForResale puts a ticket up for resale at the given price.
+// The ticket must not be checked in and its session must not have started yet.
+func (s *TicketService) ListForResale(ticketID string, askingPrice float64) (*models.Ticket, error) {
+	if askingPrice <= 0 {
+		return nil, fmt.Errorf("asking price must be greater than zero")
+	}
+
+	ticket, err := s.DB.GetTicketByID(ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("ticket %s not found: %w", ticketID, err)
+	}
+
+	switch ticket.ResaleStatus {
+	case models.ResaleStatusListed:
+		return nil, ErrTicketAlreadyListed
+	case models.ResaleStatusSold:
+		return nil, ErrTicketResold
+	}
+
+	if ticket.CheckedIn {
+		return nil, ErrTicketCheckedIn
+	}
+
+	sessionID, err := s.DB.GetSessionIDByTicket(ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve session for ticket %s: %w", ticketID, err)
+	}
+
+	if s.Sessions == nil {
+		return nil, fmt.Errorf("session schedule lookup is not configured")
+	}
+	startsAt, err := s.Sessions.SessionStartTime(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get start time of session %s: %w", sessionID, err)
+	}
+	if !time.Now().Before(startsAt) {
+		return nil, ErrSessionStarted
+	}
+
+	listedAt := time.Now()
+	if err := s.DB.UpdateResaleStatus(ticketID, models.ResaleStatusListed, askingPrice, listedAt); err != nil {
+		return nil, fmt.Errorf("failed to list ticket for resale: %w", err)
+	}
+
+	ticket.ResaleStatus = models.ResaleStatusListed
+	ticket.ResalePrice = askingPrice
+	ticket.ResaleListedAt = listedAt
+
+	s.publishTicketListed(*ticket, sessionID)
+
+	fmt.Printf("✅ Ticket %s listed for resale at %.2f\n", ticketID, askingPrice)
+	return ticket, nil
+}
+
+// UnlistFromResale takes a listed ticket off the resale market
+func (s *TicketService) UnlistFromResale(ticketID string) error {
+	ticket, err := s.DB.GetTicketByID(ticketID)
+	if err != nil {
+		return fmt.Errorf("ticket %s not found: %w", ticketID, err)
+	}
+
+	if ticket.ResaleStatus != models.ResaleStatusListed {
+		return ErrTicketNotListed
+	}
+
+	if err := s.DB.UpdateResaleStatus(ticketID, models.ResaleStatusNone, 0, time.Time{}); err != nil {
+		return fmt.Errorf("failed to unlist ticket: %w", err)
+	}
+
+	fmt.Printf("✅ Ticket %s removed from resale\n", ticketID)
+	return nil
+}
+
+// publishTicketListed is best effort; the listing itself is already stored
+func (s *TicketService) publishTicketListed(ticket models.Ticket, sessionID string) {
+	if s.Publisher == nil {
+		return
+	}
+
+	payload, err := json.Marshal(TicketListedEvent{
+		TicketID:    ticket.TicketID,
+		OrderID:     ticket.OrderID,
+		SessionID:   sessionID,
+		SeatID:      ticket.SeatID,
+		SeatLabel:   ticket.SeatLabel,
+		TierID:      ticket.TierID,
+		AskingPrice: ticket.ResalePrice,
+		ListedAt:    ticket.ResaleListedAt,
+	})
+	if err != nil {
+		fmt.Printf("❌ Failed to marshal ticket listed event: %v\n", err)
+		return
+	}
+
+	if err := s.Publisher.Publish(TicketListedTopic, ticket.TicketID, payload); err != nil {
+		fmt.Printf("❌ Failed to publish ticket listed event: %v\n", err)
+	}
+}
@@ -0,0 +1,100 @@
+package tickets_test
+
+import (
+	"ms-ticketing/internal/models"
+	tickets "ms-ticketing/internal/tickets/service"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSessionSchedule is a mock implementation of the SessionScheduleLookup interface
+type MockSessionSchedule struct {
+	mock.Mock
+}
+
+func (m *MockSessionSchedule) SessionStartTime(sessionID string) (time.Time, error) {
+	args := m.Called(sessionID)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+// MockPublisher is a mock implementation of the EventPublisher interface
+type MockPublisher struct {
+	mock.Mock
+}
+
+func (m *MockPublisher) Publish(topic string, key string, value []byte) error {
+	args := m.Called(topic, key, value)
+	return args.Error(0)
+}
+
+func TestListForResale(t *testing.T) {
+	mockDB := new(MockTicketDBLayer)
+	mockSessions := new(MockSessionSchedule)
+	mockPublisher := new(MockPublisher)
+	ticketSvc := &tickets.TicketService{DB: mockDB, Sessions: mockSessions, Publisher: mockPublisher}
+
+	ticketID := uuid.New().String()
+	mockDB.On("GetTicketByID", ticketID).Return(&models.Ticket{TicketID: ticketID, ResaleStatus: models.ResaleStatusNone}, nil)
+	mockDB.On("GetSessionIDByTicket", ticketID).Return("session1", nil)
+	mockSessions.On("SessionStartTime", "session1").Return(time.Now().Add(24*time.Hour), nil)
+	mockDB.On("UpdateResaleStatus", ticketID, models.ResaleStatusListed, 40.0, mock.AnythingOfType("time.Time")).Return(nil)
+	mockPublisher.On("Publish", tickets.TicketListedTopic, ticketID, mock.Anything).Return(nil)
+
+	listed, err := ticketSvc.ListForResale(ticketID, 40.0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.ResaleStatusListed, listed.ResaleStatus)
+	assert.Equal(t, 40.0, listed.ResalePrice)
+	mockDB.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestListForResaleRejectsInvalidTickets(t *testing.T) {
+	t.Run("checked in", func(t *testing.T) {
+		mockDB := new(MockTicketDBLayer)
+		ticketSvc := &tickets.TicketService{DB: mockDB, Sessions: new(MockSessionSchedule)}
+		mockDB.On("GetTicketByID", "ticket1").Return(&models.Ticket{TicketID: "ticket1", CheckedIn: true}, nil)
+
+		_, err := ticketSvc.ListForResale("ticket1", 40.0)
+		assert.ErrorIs(t, err, tickets.ErrTicketCheckedIn)
+	})
+
+	t.Run("session started", func(t *testing.T) {
+		mockDB := new(MockTicketDBLayer)
+		mockSessions := new(MockSessionSchedule)
+		ticketSvc := &tickets.TicketService{DB: mockDB, Sessions: mockSessions}
+		mockDB.On("GetTicketByID", "ticket1").Return(&models.Ticket{TicketID: "ticket1"}, nil)
+		mockDB.On("GetSessionIDByTicket", "ticket1").Return("session1", nil)
+		mockSessions.On("SessionStartTime", "session1").Return(time.Now().Add(-time.Minute), nil)
+
+		_, err := ticketSvc.ListForResale("ticket1", 40.0)
+		assert.ErrorIs(t, err, tickets.ErrSessionStarted)
+		mockDB.AssertNotCalled(t, "UpdateResaleStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("already listed", func(t *testing.T) {
+		mockDB := new(MockTicketDBLayer)
+		ticketSvc := &tickets.TicketService{DB: mockDB, Sessions: new(MockSessionSchedule)}
+		mockDB.On("GetTicketByID", "ticket1").Return(&models.Ticket{TicketID: "ticket1", ResaleStatus: models.ResaleStatusListed}, nil)
+
+		_, err := ticketSvc.ListForResale("ticket1", 40.0)
+		assert.ErrorIs(t, err, tickets.ErrTicketAlreadyListed)
+	})
+}
+
+func TestUnlistFromResale(t *testing.T) {
+	mockDB := new(MockTicketDBLayer)
+	ticketSvc := &tickets.TicketService{DB: mockDB}
+
+	mockDB.On("GetTicketByID", "listed").Return(&models.Ticket{TicketID: "listed", ResaleStatus: models.ResaleStatusListed}, nil)
+	mockDB.On("GetTicketByID", "unlisted").Return(&models.Ticket{TicketID: "unlisted", ResaleStatus: models.ResaleStatusNone}, nil)
+	mockDB.On("UpdateResaleStatus", "listed", models.ResaleStatusNone, 0.0, time.Time{}).Return(nil)
+
+	assert.NoError(t, ticketSvc.UnlistFromResale("listed"))
+	assert.ErrorIs(t, ticketSvc.UnlistFromResale("unlisted"), tickets.ErrTicketNotListed)
+	mockDB.AssertExpectations(t)
+}
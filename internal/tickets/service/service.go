@@ -5,7 +5,6 @@ import (
 	"ms-ticketing/internal/logger"
 	"ms-ticketing/internal/models"
 	qr_genrator "ms-ticketing/internal/tickets/qr_genrator"
-	"os"
 	"time"
 )
 
@@ -16,12 +15,26 @@ type TicketDBLayer interface {
 	CancelTicket(ticketID string) error
 	GetTicketsByOrder(orderID string) ([]models.Ticket, error)
 	GetTicketsByUser(userID string) ([]models.Ticket, error)
+	GetTicketsBySession(sessionID string) ([]models.Ticket, error)
 	GetTotalTicketsCount() (int, error)
 	CheckinTicket(ticketID string, checkedIn bool, checkedInTime time.Time) error
+	UpdateResaleStatus(ticketID string, status string, askingPrice float64, listedAt time.Time) error
+	GetSessionIDByTicket(ticketID string) (string, error)
+	AdjustCheckinCount(sessionID string, delta int) error
+	GetCheckedInCountBySession(sessionID string) (int, error)
+	ReconcileCheckinCount(sessionID string) (int, error)
+	CountUncheckedTicketsByEvent(eventID string) (int, error)
+	GetUncheckedTicketsByEvent(eventID string, afterTicketID string, limit int) ([]models.Ticket, error)
+	UpdateTicketQRCode(ticketID string, qrCode []byte) error
+	DeleteTicketsByOrder(orderID string) (int, error)
+	DeleteOrphanedTickets(issuedBefore time.Time) (int, error)
 }
 
 type TicketService struct {
-	DB TicketDBLayer
+	DB        TicketDBLayer
+	Publisher EventPublisher
+	Sessions  SessionScheduleLookup
+	QRJobs    QRJobStore
 }
 
 type Handler struct {
@@ -33,20 +46,15 @@ func NewTicketService(db TicketDBLayer) *TicketService {
 	return &TicketService{DB: db}
 }
 
+// PlaceTicket issues a ticket with its QR code. A ticket without an ID gets the one derived from its order
+// and seat, and placing a ticket that already exists updates it, so an interrupted order can be reprocessed
+// without duplicating tickets.
 func (s *TicketService) PlaceTicket(ticket models.Ticket) error {
-	fmt.Printf("Placing ticket: %s for order: %s\n", ticket.TicketID, ticket.OrderID)
-	secretKey := os.Getenv("QR_SECRET_KEY")
-	qrGen := qr_genrator.NewQRGenerator(secretKey)
-
-	qrBytes, err := qrGen.GenerateEncryptedQR(ticket)
+	ticket, err := s.PrepareTicket(ticket)
 	if err != nil {
-		return fmt.Errorf("failed to generate QR: %w", err)
-	}
-	// Ensure IssuedAt is set
-	if ticket.IssuedAt.IsZero() {
-		ticket.IssuedAt = time.Now()
+		return err
 	}
-	ticket.QRCode = qrBytes
+	fmt.Printf("Placing ticket: %s for order: %s\n", ticket.TicketID, ticket.OrderID)
 
 	if err := s.DB.CreateTicket(ticket); err != nil {
 		fmt.Printf("❌ Failed to create ticket: %v\n", err)
@@ -57,6 +65,27 @@ func (s *TicketService) PlaceTicket(ticket models.Ticket) error {
 	return nil
 }
 
+// PrepareTicket fills in what PlaceTicket adds before saving a ticket: its derived ID when it has none,
+// its issue time and its QR code. Callers that save tickets themselves, e.g. in one transaction with
+// their order, use it directly.
+func (s *TicketService) PrepareTicket(ticket models.Ticket) (models.Ticket, error) {
+	if ticket.TicketID == "" {
+		ticket.TicketID = models.TicketIDFor(ticket.OrderID, ticket.SeatID)
+	}
+	qrGen := qr_genrator.NewQRGeneratorFromEnv()
+
+	qrBytes, err := qrGen.GenerateEncryptedQR(ticket)
+	if err != nil {
+		return ticket, fmt.Errorf("failed to generate QR: %w", err)
+	}
+	// Ensure IssuedAt is set
+	if ticket.IssuedAt.IsZero() {
+		ticket.IssuedAt = time.Now()
+	}
+	ticket.QRCode = qrBytes
+	return ticket, nil
+}
+
 func (s *TicketService) GetTicket(ticketID string) (*models.Ticket, error) {
 	ticket, err := s.DB.GetTicketByID(ticketID)
 	if err != nil {
@@ -126,9 +155,61 @@ func (s *TicketService) Checkin(ticketID string) (bool, error) {
 	}
 
 	fmt.Printf("✅ Ticket %s checked in successfully at %v\n", ticketID, checkinTime)
+	s.adjustCheckinCount(ticketID, 1)
 	return true, nil
 }
 
+// RevokeCheckin undoes a check-in, e.g. after a ticket was scanned at the wrong door
+func (s *TicketService) RevokeCheckin(ticketID string) error {
+	ticket, err := s.DB.GetTicketByID(ticketID)
+	if err != nil {
+		return fmt.Errorf("ticket %s not found: %w", ticketID, err)
+	}
+
+	if !ticket.CheckedIn {
+		return fmt.Errorf("ticket %s is not checked in", ticketID)
+	}
+
+	if err := s.DB.CheckinTicket(ticketID, false, time.Time{}); err != nil {
+		return fmt.Errorf("failed to revoke checkin: %w", err)
+	}
+
+	fmt.Printf("✅ Checkin of ticket %s revoked\n", ticketID)
+	s.adjustCheckinCount(ticketID, -1)
+	return nil
+}
+
+// GetCheckedInCount returns the materialized checked-in count for a session
+func (s *TicketService) GetCheckedInCount(sessionID string) (int, error) {
+	count, err := s.DB.GetCheckedInCountBySession(sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get checked-in count for session %s: %w", sessionID, err)
+	}
+	return count, nil
+}
+
+// ReconcileCheckinCount recomputes a session's checked-in counter from the tickets themselves
+func (s *TicketService) ReconcileCheckinCount(sessionID string) (int, error) {
+	count, err := s.DB.ReconcileCheckinCount(sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reconcile checked-in count for session %s: %w", sessionID, err)
+	}
+	return count, nil
+}
+
+// adjustCheckinCount keeps the session counter in step with a check-in change.
+// The ticket itself is already updated, so failures are only logged and left for reconcile to fix.
+func (s *TicketService) adjustCheckinCount(ticketID string, delta int) {
+	sessionID, err := s.DB.GetSessionIDByTicket(ticketID)
+	if err != nil {
+		fmt.Printf("⚠️ Could not resolve session of ticket %s for checkin count: %v\n", ticketID, err)
+		return
+	}
+	if err := s.DB.AdjustCheckinCount(sessionID, delta); err != nil {
+		fmt.Printf("⚠️ Failed to adjust checkin count for session %s: %v\n", sessionID, err)
+	}
+}
+
 // GetTicketsByOrder returns tickets for a given order
 func (s *TicketService) GetTicketsByOrder(orderID string) ([]models.Ticket, error) {
 	tickets, err := s.DB.GetTicketsByOrder(orderID)
@@ -157,3 +238,13 @@ func (s *TicketService) GetTicketsByUser(userID string) ([]models.Ticket, error)
 
 	return tickets, nil
 }
+
+// GetTicketsBySession returns the valid tickets of a session, i.e. those of its completed orders,
+// as the roster gate staff scan against. A session without sales has an empty roster.
+func (s *TicketService) GetTicketsBySession(sessionID string) ([]models.Ticket, error) {
+	tickets, err := s.DB.GetTicketsBySession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tickets for session %s: %w", sessionID, err)
+	}
+	return tickets, nil
+}
@@ -17,6 +17,16 @@ type MockTicketDBLayer struct {
 	mock.Mock
 }
 
+func (m *MockTicketDBLayer) DeleteTicketsByOrder(orderID string) (int, error) {
+	args := m.Called(orderID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTicketDBLayer) DeleteOrphanedTickets(issuedBefore time.Time) (int, error) {
+	args := m.Called(issuedBefore)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *MockTicketDBLayer) CreateTicket(ticket models.Ticket) error {
 	args := m.Called(ticket)
 	return args.Error(0)
@@ -56,11 +66,64 @@ func (m *MockTicketDBLayer) GetTicketsByUser(userID string) ([]models.Ticket, er
 	return args.Get(0).([]models.Ticket), args.Error(1)
 }
 
+func (m *MockTicketDBLayer) GetTicketsBySession(sessionID string) ([]models.Ticket, error) {
+	args := m.Called(sessionID)
+	return args.Get(0).([]models.Ticket), args.Error(1)
+}
+
 func (m *MockTicketDBLayer) GetTotalTicketsCount() (int, error) {
 	args := m.Called()
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockTicketDBLayer) CheckinTicket(ticketID string, checkedIn bool, checkedInTime time.Time) error {
+	args := m.Called(ticketID, checkedIn, checkedInTime)
+	return args.Error(0)
+}
+
+func (m *MockTicketDBLayer) UpdateResaleStatus(ticketID string, status string, askingPrice float64, listedAt time.Time) error {
+	args := m.Called(ticketID, status, askingPrice, listedAt)
+	return args.Error(0)
+}
+
+func (m *MockTicketDBLayer) GetSessionIDByTicket(ticketID string) (string, error) {
+	args := m.Called(ticketID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTicketDBLayer) AdjustCheckinCount(sessionID string, delta int) error {
+	args := m.Called(sessionID, delta)
+	return args.Error(0)
+}
+
+func (m *MockTicketDBLayer) GetCheckedInCountBySession(sessionID string) (int, error) {
+	args := m.Called(sessionID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTicketDBLayer) ReconcileCheckinCount(sessionID string) (int, error) {
+	args := m.Called(sessionID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTicketDBLayer) CountUncheckedTicketsByEvent(eventID string) (int, error) {
+	args := m.Called(eventID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTicketDBLayer) GetUncheckedTicketsByEvent(eventID string, afterTicketID string, limit int) ([]models.Ticket, error) {
+	args := m.Called(eventID, afterTicketID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Ticket), args.Error(1)
+}
+
+func (m *MockTicketDBLayer) UpdateTicketQRCode(ticketID string, qrCode []byte) error {
+	args := m.Called(ticketID, qrCode)
+	return args.Error(0)
+}
+
 // Tests start here
 func TestCreateTicket(t *testing.T) {
 	// Set up mock
@@ -94,6 +157,26 @@ func TestCreateTicket(t *testing.T) {
 	mockDB.AssertExpectations(t)
 }
 
+func TestPlaceTicketDerivesTicketID(t *testing.T) {
+	mockDB := new(MockTicketDBLayer)
+	ticketSvc := &tickets.TicketService{
+		DB: mockDB,
+	}
+
+	orderID := uuid.New().String()
+	mockDB.On("CreateTicket", mock.MatchedBy(func(t models.Ticket) bool {
+		return t.TicketID == models.TicketIDFor(orderID, "seat1")
+	})).Return(nil).Twice()
+
+	// Placing the same order's seat twice targets the same ticket
+	assert.NoError(t, ticketSvc.PlaceTicket(models.Ticket{OrderID: orderID, SeatID: "seat1"}))
+	assert.NoError(t, ticketSvc.PlaceTicket(models.Ticket{OrderID: orderID, SeatID: "seat1"}))
+	mockDB.AssertExpectations(t)
+
+	assert.NotEqual(t, models.TicketIDFor(orderID, "seat1"), models.TicketIDFor(orderID, "seat2"))
+	assert.NotEqual(t, models.TicketIDFor(orderID, "seat1"), models.TicketIDFor(uuid.New().String(), "seat1"))
+}
+
 func TestGetTicket(t *testing.T) {
 	// Set up mock
 	mockDB := new(MockTicketDBLayer)
@@ -204,6 +287,31 @@ func TestCancelTicket(t *testing.T) {
 	mockDB.AssertExpectations(t)
 }
 
+func TestDeleteTicketsByOrder(t *testing.T) {
+	mockDB := new(MockTicketDBLayer)
+	ticketSvc := &tickets.TicketService{DB: mockDB}
+
+	mockDB.On("DeleteTicketsByOrder", "order123").Return(2, nil).Once()
+	assert.NoError(t, ticketSvc.DeleteTicketsByOrder("order123"))
+
+	mockDB.On("DeleteTicketsByOrder", "order456").Return(0, errors.New("db down")).Once()
+	assert.Error(t, ticketSvc.DeleteTicketsByOrder("order456"))
+	mockDB.AssertExpectations(t)
+}
+
+func TestDeleteOrphanedTickets(t *testing.T) {
+	mockDB := new(MockTicketDBLayer)
+	ticketSvc := &tickets.TicketService{DB: mockDB}
+
+	cutoff := time.Now().Add(-time.Hour)
+	mockDB.On("DeleteOrphanedTickets", cutoff).Return(3, nil)
+
+	deleted, err := ticketSvc.DeleteOrphanedTickets(cutoff)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+	mockDB.AssertExpectations(t)
+}
+
 func TestGetTicketsByOrder(t *testing.T) {
 	// Set up mock
 	mockDB := new(MockTicketDBLayer)
@@ -319,4 +427,32 @@ func TestGetTotalTicketsCount(t *testing.T) {
 	assert.Equal(t, expectedCount, count)
 
 	mockDB.AssertExpectations(t)
-}
\ No newline at end of file
+}
+func TestCheckinAdjustsSessionCount(t *testing.T) {
+	// Set up mock
+	mockDB := new(MockTicketDBLayer)
+	ticketSvc := &tickets.TicketService{
+		DB: mockDB,
+	}
+
+	ticketID := uuid.New().String()
+	mockDB.On("GetTicketByID", ticketID).Return(&models.Ticket{TicketID: ticketID}, nil).Once()
+	mockDB.On("CheckinTicket", ticketID, true, mock.AnythingOfType("time.Time")).Return(nil)
+	mockDB.On("GetSessionIDByTicket", ticketID).Return("session1", nil)
+	mockDB.On("AdjustCheckinCount", "session1", 1).Return(nil)
+
+	// Test case: check-in increments the session counter
+	ok, err := ticketSvc.Checkin(ticketID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// Test case: revoking decrements it again and clears the check-in time
+	mockDB.On("GetTicketByID", ticketID).Return(&models.Ticket{TicketID: ticketID, CheckedIn: true}, nil).Once()
+	mockDB.On("CheckinTicket", ticketID, false, time.Time{}).Return(nil)
+	mockDB.On("AdjustCheckinCount", "session1", -1).Return(nil)
+
+	err = ticketSvc.RevokeCheckin(ticketID)
+	assert.NoError(t, err)
+
+	mockDB.AssertExpectations(t)
+}
@@ -0,0 +1,111 @@
+package ticket_api
+
+import (
+	"encoding/json"
+	"ms-ticketing/internal/utils"
+	"net/http"
+
+	"ms-ticketing/internal/auth"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CheckedInCountResponse is the response format for the session checked-in count endpoints
+type CheckedInCountResponse struct {
+	SessionID string `json:"session_id"`
+	CheckedIn int    `json:"checked_in"`
+}
+
+// GetSessionCheckedInCount returns how many tickets of a session have been checked in.
+// It reads the materialized counter rather than counting tickets.
+func (h *Handler) GetSessionCheckedInCount(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionId")
+	if !h.authorizeScanner(w, r, sessionID) {
+		return
+	}
+
+	count, err := h.TicketService.GetCheckedInCount(sessionID)
+	if err != nil {
+		writeError(w, utils.ErrCodeInternal, "Error retrieving checked-in count: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CheckedInCountResponse{SessionID: sessionID, CheckedIn: count})
+}
+
+// ReconcileSessionCheckedInCount recounts the session's checked-in tickets and corrects the counter
+func (h *Handler) ReconcileSessionCheckedInCount(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionId")
+	if !h.authorizeScanner(w, r, sessionID) {
+		return
+	}
+
+	count, err := h.TicketService.ReconcileCheckinCount(sessionID)
+	if err != nil {
+		writeError(w, utils.ErrCodeInternal, "Error reconciling checked-in count: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CheckedInCountResponse{SessionID: sessionID, CheckedIn: count})
+}
+
+// RevokeCheckin undoes the check-in of a ticket
+func (h *Handler) RevokeCheckin(w http.ResponseWriter, r *http.Request) {
+	ticketID := chi.URLParam(r, "ticketId")
+
+	ticket, err := h.TicketService.GetTicket(ticketID)
+	if err != nil {
+		writeError(w, utils.ErrCodeNotFound, "Ticket not found: "+err.Error(), http.StatusNotFound)
+		return
+	}
+
+	order, err := h.OrderDB.GetOrderByID(r.Context(), ticket.OrderID)
+	if err != nil {
+		writeError(w, utils.ErrCodeNotFound, "Order not found: "+err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if !h.authorizeScanner(w, r, order.SessionID) {
+		return
+	}
+
+	if !ticket.CheckedIn {
+		writeError(w, "ticket_not_checked_in", "Ticket is not checked in", http.StatusConflict)
+		return
+	}
+
+	if err := h.TicketService.RevokeCheckin(ticketID); err != nil {
+		writeError(w, utils.ErrCodeInternal, "Failed to revoke checkin: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorizeScanner checks the caller holds the scanner role for the session, writing the error response if not
+func (h *Handler) authorizeScanner(w http.ResponseWriter, r *http.Request, sessionID string) bool {
+	if sessionID == "" {
+		writeError(w, utils.ErrCodeInvalidRequest, "session ID is required", http.StatusBadRequest)
+		return false
+	}
+
+	tokenString, err := auth.ExtractTokenFromRequest(r)
+	if err != nil {
+		writeError(w, utils.ErrCodeUnauthorized, "Authorization required: "+err.Error(), http.StatusUnauthorized)
+		return false
+	}
+
+	userID, err := auth.ExtractUserIDFromJWT(tokenString)
+	if err != nil {
+		writeError(w, utils.ErrCodeUnauthorized, "Invalid token: "+err.Error(), http.StatusUnauthorized)
+		return false
+	}
+
+	if err := h.verifyScannerRole(sessionID, userID); err != nil {
+		writeError(w, utils.ErrCodeForbidden, "Scanner verification failed: "+err.Error(), http.StatusForbidden)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,20 @@
+package ticket_api
+
+import (
+	"ms-ticketing/internal/utils"
+	"net/http"
+)
+
+// writeError replies with the shared JSON error envelope so clients can parse every failure the same way
+func writeError(w http.ResponseWriter, code, message string, status int) {
+	utils.WriteError(w, code, message, status)
+}
+
+// writeBodyError reports a request body that could not be decoded, answering 413 when it exceeded the size limit
+func writeBodyError(w http.ResponseWriter, err error, message string) {
+	if utils.IsBodyTooLarge(err) {
+		writeError(w, utils.ErrCodeBodyTooLarge, "Request body is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	writeError(w, utils.ErrCodeInvalidRequest, message, http.StatusBadRequest)
+}
@@ -1,16 +1,20 @@
 package ticket_api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"ms-ticketing/internal/auth"
 	"ms-ticketing/internal/config"
 	"ms-ticketing/internal/models"
 	qr_genrator "ms-ticketing/internal/tickets/qr_genrator"
 	tickets "ms-ticketing/internal/tickets/service" // Ensure this path matches the actual location of TicketService
+	"ms-ticketing/internal/utils"
 	"net/http"
 	"net/url"
 	"os"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-redis/redis/v8"
@@ -19,7 +23,7 @@ import (
 const SCANNER_ROLE = "SCANNER"
 
 type OrderDBLayer interface {
-	GetOrderByID(id string) (*models.Order, error)
+	GetOrderByID(ctx context.Context, id string) (*models.Order, error)
 }
 
 type Handler struct {
@@ -28,17 +32,16 @@ type Handler struct {
 	Config        *config.Config
 	QRGenerator   *qr_genrator.QRGenerator
 	HTTPClient    *http.Client
-	RedisClient   interface{} // Using interface{} to avoid import issues, can be *redis.Client
+	RedisClient   interface{} // Using interface{} to avoid import issues, can be redis.UniversalClient
 }
 
 // NewHandler creates a new Handler instance
 func NewHandler(ticketService *tickets.TicketService, orderDB OrderDBLayer, cfg *config.Config, httpClient *http.Client, redisClient interface{}) *Handler {
-	secretKey := os.Getenv("QR_SECRET_KEY")
 	return &Handler{
 		TicketService: ticketService,
 		OrderDB:       orderDB,
 		Config:        cfg,
-		QRGenerator:   qr_genrator.NewQRGenerator(secretKey),
+		QRGenerator:   qr_genrator.NewQRGeneratorFromEnv(),
 		HTTPClient:    httpClient,
 		RedisClient:   redisClient,
 	}
@@ -52,64 +55,87 @@ func (h *Handler) CheckinTicket(w http.ResponseWriter, r *http.Request) {
 		EncryptedQR string `json:"encrypted_qr"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+	if err := utils.DecodeJSONBody(w, r, &requestBody); err != nil {
+		writeBodyError(w, err, "Invalid request body: "+err.Error())
 		return
 	}
 
 	if requestBody.EncryptedQR == "" {
-		http.Error(w, "encrypted_qr is required", http.StatusBadRequest)
+		writeError(w, utils.ErrCodeInvalidRequest, "encrypted_qr is required", http.StatusBadRequest)
 		return
 	}
 
 	// Step 1: Extract token from request and get user ID
 	tokenString, err := auth.ExtractTokenFromRequest(r)
 	if err != nil {
-		http.Error(w, "Authorization required: "+err.Error(), http.StatusUnauthorized)
+		writeError(w, utils.ErrCodeUnauthorized, "Authorization required: "+err.Error(), http.StatusUnauthorized)
 		return
 	}
 
 	userID, err := auth.ExtractUserIDFromJWT(tokenString)
 	if err != nil {
-		http.Error(w, "Invalid token: "+err.Error(), http.StatusUnauthorized)
+		writeError(w, utils.ErrCodeUnauthorized, "Invalid token: "+err.Error(), http.StatusUnauthorized)
 		return
 	}
 
 	// Step 2: Decrypt QR code to get ticket information
 	if h.QRGenerator == nil {
-		secretKey := os.Getenv("QR_SECRET_KEY")
-		h.QRGenerator = qr_genrator.NewQRGenerator(secretKey)
+		h.QRGenerator = qr_genrator.NewQRGeneratorFromEnv()
 	}
 
-	ticket, err := h.QRGenerator.DecryptQRData(requestBody.EncryptedQR)
+	ticket, qrIssuedAt, err := h.QRGenerator.DecryptQRToken(requestBody.EncryptedQR)
 	if err != nil {
-		http.Error(w, "Invalid QR code: "+err.Error(), http.StatusBadRequest)
+		writeError(w, utils.ErrCodeInvalidRequest, "Invalid QR code: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Rotating QRs are only accepted within the rotation window, so shared screenshots stop working
+	if err := h.QRGenerator.CheckFresh(qrIssuedAt); err != nil {
+		writeError(w, utils.ErrCodeInvalidRequest, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Step 3: Get order information to find session_id
-	order, err := h.OrderDB.GetOrderByID(ticket.OrderID)
+	order, err := h.OrderDB.GetOrderByID(r.Context(), ticket.OrderID)
 	if err != nil {
-		http.Error(w, "Order not found: "+err.Error(), http.StatusNotFound)
+		writeError(w, utils.ErrCodeNotFound, "Order not found: "+err.Error(), http.StatusNotFound)
+		return
+	}
+	// Tickets of pending or cancelled orders were never paid for and don't admit anyone
+	if order.Status != "completed" {
+		writeError(w, "order_not_completed", errTicketNotPaidFor.Error(), http.StatusConflict)
 		return
 	}
-	fmt.Printf("%s", order.SessionID)
+
 	// Step 4: Verify scanner role with event seating service
 	err = h.verifyScannerRole(order.SessionID, userID)
 	if err != nil {
-		http.Error(w, "Scanner verification failed: "+err.Error(), http.StatusForbidden)
+		writeError(w, utils.ErrCodeForbidden, "Scanner verification failed: "+err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Step 5: Only accept scans within the session's check-in window
+	if err := h.TicketService.CheckCheckinWindow(order.SessionID, time.Now()); err != nil {
+		switch {
+		case errors.Is(err, tickets.ErrCheckinNotOpen):
+			writeError(w, "checkin_not_open", err.Error(), http.StatusForbidden)
+		case errors.Is(err, tickets.ErrCheckinClosed):
+			writeError(w, "checkin_closed", err.Error(), http.StatusForbidden)
+		default:
+			writeError(w, utils.ErrCodeUpstream, "Could not verify the check-in window: "+err.Error(), http.StatusBadGateway)
+		}
 		return
 	}
 
-	// Step 5: Proceed with ticket check-in
+	// Step 6: Proceed with ticket check-in
 	ok, err := h.TicketService.Checkin(ticket.TicketID)
 	if err != nil {
-		http.Error(w, "Checkin failed: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, utils.ErrCodeInternal, "Checkin failed: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	if !ok {
-		http.Error(w, "failed to checkin ticket", http.StatusInternalServerError)
+		writeError(w, utils.ErrCodeInternal, "failed to checkin ticket", http.StatusInternalServerError)
 		return
 	}
 
@@ -133,9 +159,9 @@ func (h *Handler) verifyScannerRole(sessionID, userID string) error {
 		ClientSecret:  h.Config.Auth.ClientSecret,
 	}
 
-	var redisClient *redis.Client
+	var redisClient redis.UniversalClient
 	if h.RedisClient != nil {
-		if rc, ok := h.RedisClient.(*redis.Client); ok {
+		if rc, ok := h.RedisClient.(redis.UniversalClient); ok {
 			redisClient = rc
 		}
 	}
@@ -185,12 +211,12 @@ func (h *Handler) verifyScannerRole(sessionID, userID string) error {
 
 func (h *Handler) CreateTicket(w http.ResponseWriter, r *http.Request) {
 	var ticket models.Ticket
-	if err := json.NewDecoder(r.Body).Decode(&ticket); err != nil {
-		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+	if err := utils.DecodeJSONBody(w, r, &ticket); err != nil {
+		writeBodyError(w, err, "Invalid request body: "+err.Error())
 		return
 	}
 	if err := h.TicketService.PlaceTicket(ticket); err != nil {
-		http.Error(w, "Failed to create ticket: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, utils.ErrCodeInternal, "Failed to create ticket: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 	w.WriteHeader(http.StatusCreated)
@@ -200,7 +226,7 @@ func (h *Handler) CreateTicket(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) DeleteTicket(w http.ResponseWriter, r *http.Request) {
 	ticketID := chi.URLParam(r, "ticketID")
 	if err := h.TicketService.CancelTicket(ticketID); err != nil {
-		http.Error(w, "Failed to delete ticket: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, utils.ErrCodeInternal, "Failed to delete ticket: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -209,12 +235,12 @@ func (h *Handler) DeleteTicket(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) UpdateTicket(w http.ResponseWriter, r *http.Request) {
 	ticketID := chi.URLParam(r, "ticketID")
 	var updateData models.Ticket
-	if err := json.NewDecoder(r.Body).Decode(&updateData); err != nil {
-		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+	if err := utils.DecodeJSONBody(w, r, &updateData); err != nil {
+		writeBodyError(w, err, "Invalid request body: "+err.Error())
 		return
 	}
 	if err := h.TicketService.UpdateTicket(ticketID, updateData); err != nil {
-		http.Error(w, "Failed to update ticket: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, utils.ErrCodeInternal, "Failed to update ticket: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -225,7 +251,7 @@ func (h *Handler) ViewTicket(w http.ResponseWriter, r *http.Request) {
 	ticketID := chi.URLParam(r, "ticketID")
 	ticket, err := h.TicketService.GetTicket(ticketID)
 	if err != nil {
-		http.Error(w, "Ticket not found: "+err.Error(), http.StatusNotFound)
+		writeError(w, utils.ErrCodeNotFound, "Ticket not found: "+err.Error(), http.StatusNotFound)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -236,7 +262,7 @@ func (h *Handler) ListTicketsByOrder(w http.ResponseWriter, r *http.Request) {
 	orderID := chi.URLParam(r, "orderID")
 	tickets, err := h.TicketService.GetTicketsByOrder(orderID)
 	if err != nil {
-		http.Error(w, "Failed to fetch tickets: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, utils.ErrCodeInternal, "Failed to fetch tickets: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -247,7 +273,7 @@ func (h *Handler) ListTicketsByUser(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "userID")
 	tickets, err := h.TicketService.GetTicketsByUser(userID)
 	if err != nil {
-		http.Error(w, "Failed to fetch tickets: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, utils.ErrCodeInternal, "Failed to fetch tickets: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,142 @@
+package ticket_api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"ms-ticketing/internal/models"
+	qr_genrator "ms-ticketing/internal/tickets/qr_genrator"
+	tickets "ms-ticketing/internal/tickets/service"
+	"ms-ticketing/internal/utils"
+	"net/http"
+	"time"
+)
+
+// Reasons an inspected QR would not check in
+const (
+	QRReasonExpired          = "qr_expired"
+	QRReasonTicketRevoked    = "ticket_revoked"
+	QRReasonAlreadyCheckedIn = "already_checked_in"
+	QRReasonWrongSession     = "wrong_session"
+	QRReasonOrderNotComplete = "order_not_completed"
+	QRReasonCheckinNotOpen   = "checkin_not_open"
+	QRReasonCheckinClosed    = "checkin_closed"
+)
+
+// QRInspection describes a scanned QR and whether it would check in, without checking it in
+type QRInspection struct {
+	TicketID      string     `json:"ticket_id"`
+	OrderID       string     `json:"order_id"`
+	OrderStatus   string     `json:"order_status"`
+	SessionID     string     `json:"session_id"`
+	EventID       string     `json:"event_id"`
+	SeatLabel     string     `json:"seat_label"`
+	TierName      string     `json:"tier_name"`
+	QRIssuedAt    *time.Time `json:"qr_issued_at,omitempty"`
+	CheckedIn     bool       `json:"checked_in"`
+	CheckedInTime *time.Time `json:"checked_in_time,omitempty"`
+	Valid         bool       `json:"valid"`
+	Reasons       []string   `json:"reasons"`
+}
+
+// InspectQR decodes a QR and reports the ticket behind it for gate staff troubleshooting failed scans.
+// It never checks the ticket in. The optional session_id is the session being scanned for, so a QR
+// for another session is flagged.
+// Expected POST request body: {"encrypted_qr": "...", "session_id": "..."}
+func (h *Handler) InspectQR(w http.ResponseWriter, r *http.Request) {
+	var requestBody struct {
+		EncryptedQR string `json:"encrypted_qr"`
+		SessionID   string `json:"session_id"`
+	}
+
+	if err := utils.DecodeJSONBody(w, r, &requestBody); err != nil {
+		writeBodyError(w, err, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if requestBody.EncryptedQR == "" {
+		writeError(w, utils.ErrCodeInvalidRequest, "encrypted_qr is required", http.StatusBadRequest)
+		return
+	}
+
+	if h.QRGenerator == nil {
+		h.QRGenerator = qr_genrator.NewQRGeneratorFromEnv()
+	}
+
+	scanned, qrIssuedAt, err := h.QRGenerator.DecryptQRToken(requestBody.EncryptedQR)
+	if err != nil {
+		writeError(w, utils.ErrCodeInvalidRequest, "Invalid QR code: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.OrderDB.GetOrderByID(r.Context(), scanned.OrderID)
+	if err != nil {
+		writeError(w, utils.ErrCodeNotFound, "Order not found: "+err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if !h.authorizeScanner(w, r, order.SessionID) {
+		return
+	}
+
+	// A ticket that no longer exists was revoked, e.g. with its cancelled order
+	current, err := h.TicketService.GetTicket(scanned.TicketID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		writeError(w, utils.ErrCodeInternal, "Failed to get ticket: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	inspection := inspectQR(scanned, current, order, qrIssuedAt, h.QRGenerator.CheckFresh(qrIssuedAt), requestBody.SessionID)
+	// A failed schedule lookup leaves the window unreported rather than failing the inspection
+	if err := h.TicketService.CheckCheckinWindow(order.SessionID, time.Now()); errors.Is(err, tickets.ErrCheckinNotOpen) {
+		inspection.Reasons = append(inspection.Reasons, QRReasonCheckinNotOpen)
+	} else if errors.Is(err, tickets.ErrCheckinClosed) {
+		inspection.Reasons = append(inspection.Reasons, QRReasonCheckinClosed)
+	}
+	inspection.Valid = len(inspection.Reasons) == 0
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(inspection)
+}
+
+// inspectQR builds the inspection of a scanned ticket. current is the stored ticket, nil if it is gone.
+func inspectQR(scanned, current *models.Ticket, order *models.Order, qrIssuedAt time.Time, freshErr error, sessionID string) QRInspection {
+	inspection := QRInspection{
+		TicketID:    scanned.TicketID,
+		OrderID:     order.OrderID,
+		OrderStatus: order.Status,
+		SessionID:   order.SessionID,
+		EventID:     order.EventID,
+		SeatLabel:   scanned.SeatLabel,
+		TierName:    scanned.TierName,
+		Reasons:     []string{},
+	}
+	if !qrIssuedAt.IsZero() {
+		inspection.QRIssuedAt = &qrIssuedAt
+	}
+
+	if freshErr != nil {
+		inspection.Reasons = append(inspection.Reasons, QRReasonExpired)
+	}
+	if current == nil {
+		inspection.Reasons = append(inspection.Reasons, QRReasonTicketRevoked)
+	} else {
+		inspection.SeatLabel = current.SeatLabel
+		inspection.TierName = current.TierName
+		inspection.CheckedIn = current.CheckedIn
+		if current.CheckedIn {
+			checkedInTime := current.CheckedInTime
+			inspection.CheckedInTime = &checkedInTime
+			inspection.Reasons = append(inspection.Reasons, QRReasonAlreadyCheckedIn)
+		}
+	}
+	if sessionID != "" && sessionID != order.SessionID {
+		inspection.Reasons = append(inspection.Reasons, QRReasonWrongSession)
+	}
+	if order.Status != "completed" {
+		inspection.Reasons = append(inspection.Reasons, QRReasonOrderNotComplete)
+	}
+
+	inspection.Valid = len(inspection.Reasons) == 0
+	return inspection
+}
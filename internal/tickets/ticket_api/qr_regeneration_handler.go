@@ -0,0 +1,47 @@
+package ticket_api
+
+import (
+	"encoding/json"
+	"errors"
+	tickets "ms-ticketing/internal/tickets/service"
+	"ms-ticketing/internal/utils"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegenerateEventQRCodes starts regenerating the QR codes of every ticket of an event that is not
+// checked in yet, e.g. after a QR key rotation. It answers 202 with the job to poll for progress.
+func (h *Handler) RegenerateEventQRCodes(w http.ResponseWriter, r *http.Request) {
+	eventID := chi.URLParam(r, "eventId")
+
+	job, err := h.TicketService.StartQRRegeneration(eventID)
+	switch {
+	case errors.Is(err, tickets.ErrQRRegenerationRunning):
+		writeError(w, utils.ErrCodeConflict, err.Error()+": job "+job.JobID, http.StatusConflict)
+		return
+	case err != nil:
+		writeError(w, utils.ErrCodeInternal, "Failed to start QR regeneration: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetQRRegenerationJob reports the progress of a QR regeneration job
+func (h *Handler) GetQRRegenerationJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.TicketService.GetQRRegenerationJob(chi.URLParam(r, "jobId"))
+	switch {
+	case errors.Is(err, tickets.ErrQRJobNotFound):
+		writeError(w, utils.ErrCodeNotFound, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		writeError(w, utils.ErrCodeInternal, "Failed to load QR regeneration job: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
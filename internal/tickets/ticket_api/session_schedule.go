@@ -0,0 +1,74 @@
+package ticket_api
+
+import (
+	"encoding/json"
+	"fmt"
+	"ms-ticketing/internal/auth"
+	"ms-ticketing/internal/config"
+	"ms-ticketing/internal/models"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// SessionScheduleClient looks up session start times from the event seating service
+type SessionScheduleClient struct {
+	Config      *config.Config
+	HTTPClient  *http.Client
+	RedisClient redis.UniversalClient
+}
+
+// NewSessionScheduleClient creates a client for session schedule lookups
+func NewSessionScheduleClient(cfg *config.Config, httpClient *http.Client, redisClient redis.UniversalClient) *SessionScheduleClient {
+	return &SessionScheduleClient{
+		Config:      cfg,
+		HTTPClient:  httpClient,
+		RedisClient: redisClient,
+	}
+}
+
+// SessionStartTime fetches the session from the event seating service and returns its start time
+func (c *SessionScheduleClient) SessionStartTime(sessionID string) (time.Time, error) {
+	m2mConfig := models.Config{
+		KeycloakURL:   c.Config.Auth.KeycloakURL,
+		KeycloakRealm: c.Config.Auth.KeycloakRealm,
+		ClientID:      c.Config.Auth.ClientID,
+		ClientSecret:  c.Config.Auth.ClientSecret,
+	}
+
+	token, err := auth.GetM2MToken(m2mConfig, c.HTTPClient, c.RedisClient, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get M2M token: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/internal/v1/sessions/%s", c.Config.EventSeatingService.URL, url.PathEscape(sessionID))
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to fetch session: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("session lookup failed with status: %d", resp.StatusCode)
+	}
+
+	var session struct {
+		StartTime time.Time `json:"startTime"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode session: %w", err)
+	}
+	if session.StartTime.IsZero() {
+		return time.Time{}, fmt.Errorf("session %s has no start time", sessionID)
+	}
+
+	return session.StartTime, nil
+}
@@ -0,0 +1,48 @@
+package ticket_api
+
+import (
+	"encoding/json"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/utils"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SessionTicketsResponse is the roster of valid tickets for a session
+type SessionTicketsResponse struct {
+	SessionID string                      `json:"session_id"`
+	Total     int                         `json:"total"`
+	CheckedIn int                         `json:"checked_in"`
+	Tickets   []models.TicketForStreaming `json:"tickets"`
+}
+
+// GetTicketsBySession returns every ticket of the session's completed orders with its seat, tier and
+// check-in status, so gate staff can print or cache a roster before doors open. QR codes are left out.
+func (h *Handler) GetTicketsBySession(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionId")
+	if !h.authorizeScanner(w, r, sessionID) {
+		return
+	}
+
+	tickets, err := h.TicketService.GetTicketsBySession(sessionID)
+	if err != nil {
+		writeError(w, utils.ErrCodeInternal, "Failed to fetch tickets: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := SessionTicketsResponse{
+		SessionID: sessionID,
+		Total:     len(tickets),
+		Tickets:   make([]models.TicketForStreaming, 0, len(tickets)),
+	}
+	for _, ticket := range tickets {
+		if ticket.CheckedIn {
+			response.CheckedIn++
+		}
+		response.Tickets = append(response.Tickets, ticket.ToStreamingTicket())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -2,6 +2,7 @@ package ticket_api
 
 import (
 	"encoding/json"
+	"ms-ticketing/internal/utils"
 	"net/http"
 )
 
@@ -14,7 +15,7 @@ type TicketCountResponse struct {
 func (h *Handler) GetTotalTicketsCount(w http.ResponseWriter, r *http.Request) {
 	count, err := h.TicketService.GetTotalTicketsCount()
 	if err != nil {
-		http.Error(w, "Error retrieving ticket count: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, utils.ErrCodeInternal, "Error retrieving ticket count: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -29,7 +30,7 @@ func (h *Handler) GetTotalTicketsCount(w http.ResponseWriter, r *http.Request) {
 
 	// Write the response
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, utils.ErrCodeInternal, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
@@ -0,0 +1,145 @@
+package ticket_api
+
+import (
+	"errors"
+	"fmt"
+	"ms-ticketing/internal/auth"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/tickets/pdf"
+	"ms-ticketing/internal/utils"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var (
+	errNotTicketOwner   = errors.New("ticket does not belong to the requesting user")
+	errTicketNotPaidFor = errors.New("ticket is not valid until its order is completed")
+)
+
+// loadOwnedTicket fetches the ticket and its order, and verifies the JWT user owns the order and
+// the order is completed, so pending or cancelled orders never hand out a usable ticket.
+// On failure it writes the HTTP error and returns nil.
+func (h *Handler) loadOwnedTicket(w http.ResponseWriter, r *http.Request) (*models.Ticket, *models.Order) {
+	tokenString, err := auth.ExtractTokenFromRequest(r)
+	if err != nil {
+		writeError(w, utils.ErrCodeUnauthorized, "Authorization required: "+err.Error(), http.StatusUnauthorized)
+		return nil, nil
+	}
+
+	userID, err := auth.ExtractUserIDFromJWT(tokenString)
+	if err != nil {
+		writeError(w, utils.ErrCodeUnauthorized, "Invalid token: "+err.Error(), http.StatusUnauthorized)
+		return nil, nil
+	}
+
+	ticketID := chi.URLParam(r, "ticketId")
+	ticket, err := h.TicketService.GetTicket(ticketID)
+	if err != nil {
+		writeError(w, utils.ErrCodeNotFound, "Ticket not found", http.StatusNotFound)
+		return nil, nil
+	}
+
+	order, err := h.OrderDB.GetOrderByID(r.Context(), ticket.OrderID)
+	if err != nil {
+		writeError(w, utils.ErrCodeNotFound, "Order not found", http.StatusNotFound)
+		return nil, nil
+	}
+
+	if order.UserID != userID {
+		writeError(w, utils.ErrCodeForbidden, errNotTicketOwner.Error(), http.StatusForbidden)
+		return nil, nil
+	}
+	if order.Status != "completed" {
+		writeError(w, "order_not_completed", errTicketNotPaidFor.Error(), http.StatusConflict)
+		return nil, nil
+	}
+
+	return ticket, order
+}
+
+// ticketQRImage returns the stored static QR PNG, generating and storing a fresh one if none was stored.
+// Printed tickets always use it, since a rotating QR would expire on paper.
+func (h *Handler) ticketQRImage(ticket *models.Ticket) ([]byte, error) {
+	if len(ticket.QRCode) > 0 {
+		return ticket.QRCode, nil
+	}
+	qrBytes, err := h.QRGenerator.GenerateEncryptedQR(*ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+
+	// Persisting is best effort; the caller still gets a valid image
+	ticket.QRCode = qrBytes
+	if err := h.TicketService.DB.UpdateTicket(*ticket); err != nil {
+		fmt.Printf("⚠️ Failed to store regenerated QR for ticket %s: %v\n", ticket.TicketID, err)
+	}
+	return qrBytes, nil
+}
+
+// GetTicketQRImage serves the ticket's QR code as a PNG for the owner
+func (h *Handler) GetTicketQRImage(w http.ResponseWriter, r *http.Request) {
+	ticket, _ := h.loadOwnedTicket(w, r)
+	if ticket == nil {
+		return
+	}
+
+	// With rotation the app is handed a QR that expires, fetched anew each time it is shown
+	var qrBytes []byte
+	var err error
+	if h.QRGenerator.Rotating() {
+		qrBytes, err = h.QRGenerator.GenerateRotatingQR(*ticket)
+	} else {
+		qrBytes, err = h.ticketQRImage(ticket)
+	}
+	if err != nil {
+		writeError(w, utils.ErrCodeInternal, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// QR codes can be revoked or rotated, so clients must never reuse a cached copy
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(qrBytes)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(qrBytes)
+}
+
+// DownloadTicketPDF renders a printable PDF for a ticket owned by the caller
+func (h *Handler) DownloadTicketPDF(w http.ResponseWriter, r *http.Request) {
+	ticket, order := h.loadOwnedTicket(w, r)
+	if ticket == nil {
+		return
+	}
+
+	qrBytes, err := h.ticketQRImage(ticket)
+	if err != nil {
+		writeError(w, utils.ErrCodeInternal, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	pdfBytes, err := pdf.RenderTicket(pdf.TicketDetails{
+		TicketID:  ticket.TicketID,
+		OrderID:   ticket.OrderID,
+		EventID:   order.EventID,
+		SessionID: order.SessionID,
+		SeatLabel: ticket.SeatLabel,
+		TierName:  ticket.TierName,
+		Price:     ticket.PriceAtPurchase,
+		Currency:  order.Currency,
+		IssuedAt:  ticket.IssuedAt.Format("2006-01-02 15:04"),
+		QRCode:    qrBytes,
+	})
+	if err != nil {
+		writeError(w, utils.ErrCodeInternal, "Failed to render ticket PDF: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"ticket-%s.pdf\"", ticket.TicketID))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(pdfBytes)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(pdfBytes)
+}
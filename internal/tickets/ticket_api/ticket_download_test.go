@@ -0,0 +1,184 @@
+package ticket_api_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/order/db"
+	ticket_db "ms-ticketing/internal/tickets/db"
+	qr_genrator "ms-ticketing/internal/tickets/qr_genrator"
+	tickets "ms-ticketing/internal/tickets/service"
+	"ms-ticketing/internal/tickets/ticket_api"
+	"ms-ticketing/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	_ "github.com/uptrace/bun/driver/sqliteshim"
+)
+
+// newDownloadRouter serves the ticket download endpoints over an in-memory database holding one
+// completed order of "buyer" with a ticket that has no stored QR yet
+func newDownloadRouter(t *testing.T) (http.Handler, *bun.DB) {
+	t.Helper()
+	t.Setenv("QR_SECRET_KEY", "test-secret")
+	t.Setenv("QR_SECRET_KEYS", "")
+
+	sqldb, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	sqldb.SetMaxOpenConns(1)
+	bunDB := bun.NewDB(sqldb, sqlitedialect.New())
+	t.Cleanup(func() { bunDB.Close() })
+	for _, model := range []interface{}{(*models.Order)(nil), (*models.Ticket)(nil)} {
+		_, err := bunDB.NewCreateTable().Model(model).Exec(context.Background())
+		require.NoError(t, err)
+	}
+
+	order := models.Order{OrderID: "order-1", UserID: "buyer", EventID: "event-1", SessionID: "session-1", Status: "completed", Price: models.MoneyFromFloat(25), CreatedAt: time.Now()}
+	_, err = bunDB.NewInsert().Model(&order).Exec(context.Background())
+	require.NoError(t, err)
+	ticket := models.Ticket{TicketID: "ticket-1", OrderID: "order-1", SeatID: "seat-1", SeatLabel: "A1", TierName: "GA", PriceAtPurchase: 25, IssuedAt: time.Now()}
+	_, err = bunDB.NewInsert().Model(&ticket).Exec(context.Background())
+	require.NoError(t, err)
+
+	ticketService := tickets.NewTicketService(&ticket_db.DB{Bun: bunDB})
+	handler := ticket_api.NewHandler(ticketService, &db.DB{Bun: bunDB}, nil, http.DefaultClient, nil)
+
+	r := chi.NewRouter()
+	r.Get("/api/order/tickets/{ticketId}/pdf", handler.DownloadTicketPDF)
+	r.Get("/api/order/tickets/{ticketId}/qr.png", handler.GetTicketQRImage)
+	r.Post("/api/order/tickets/checkin", handler.CheckinTicket)
+	return r, bunDB
+}
+
+// getAs sends the request with a bearer token for userID, or without one when userID is empty
+func getAs(t *testing.T, router http.Handler, path, userID string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if userID != "" {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": userID}).SignedString([]byte("test"))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func storedQRCode(t *testing.T, bunDB *bun.DB) []byte {
+	t.Helper()
+	ticket := new(models.Ticket)
+	require.NoError(t, bunDB.NewSelect().Model(ticket).Where("ticket_id = ?", "ticket-1").Scan(context.Background()))
+	return ticket.QRCode
+}
+
+func TestTicketDownloadsRequireTheBuyer(t *testing.T) {
+	router, bunDB := newDownloadRouter(t)
+
+	for _, path := range []string{"/api/order/tickets/ticket-1/pdf", "/api/order/tickets/ticket-1/qr.png"} {
+		t.Run(path, func(t *testing.T) {
+			rec := getAs(t, router, path, "")
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+			rec = getAs(t, router, path, "someone-else")
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			var envelope utils.ErrorEnvelope
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&envelope))
+			assert.Equal(t, utils.ErrCodeForbidden, envelope.Error.Code)
+		})
+	}
+
+	rec := getAs(t, router, "/api/order/tickets/missing/pdf", "buyer")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// No QR was generated for the rejected callers
+	assert.Empty(t, storedQRCode(t, bunDB))
+}
+
+func TestDownloadTicketPDF(t *testing.T) {
+	t.Setenv("QR_ROTATION_ENABLED", "")
+	router, bunDB := newDownloadRouter(t)
+
+	rec := getAs(t, router, "/api/order/tickets/ticket-1/pdf", "buyer")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "application/pdf", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "ticket-ticket-1.pdf")
+	assert.True(t, bytes.HasPrefix(rec.Body.Bytes(), []byte("%PDF")))
+
+	// The QR printed on the PDF is stored and served again as the ticket's QR image
+	stored := storedQRCode(t, bunDB)
+	require.NotEmpty(t, stored)
+	rec = getAs(t, router, "/api/order/tickets/ticket-1/qr.png", "buyer")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, stored, rec.Body.Bytes())
+}
+
+func TestDownloadTicketPDFWithRotationKeepsStaticQR(t *testing.T) {
+	t.Setenv("QR_ROTATION_ENABLED", "true")
+	router, bunDB := newDownloadRouter(t)
+
+	rec := getAs(t, router, "/api/order/tickets/ticket-1/pdf", "buyer")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	stored := storedQRCode(t, bunDB)
+	require.NotEmpty(t, stored)
+
+	// The app gets a rotating QR, never the printed static one
+	rec = getAs(t, router, "/api/order/tickets/ticket-1/qr.png", "buyer")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, stored, rec.Body.Bytes())
+	assert.Contains(t, rec.Header().Get("Cache-Control"), "no-store")
+}
+
+func TestTicketDownloadsRequireACompletedOrder(t *testing.T) {
+	router, bunDB := newDownloadRouter(t)
+
+	for _, status := range []string{"pending", "cancelled"} {
+		_, err := bunDB.NewUpdate().Model((*models.Order)(nil)).Set("status = ?", status).Where("order_id = ?", "order-1").Exec(context.Background())
+		require.NoError(t, err)
+
+		for _, path := range []string{"/api/order/tickets/ticket-1/pdf", "/api/order/tickets/ticket-1/qr.png"} {
+			rec := getAs(t, router, path, "buyer")
+			assert.Equal(t, http.StatusConflict, rec.Code, "%s with a %s order", path, status)
+			var envelope utils.ErrorEnvelope
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&envelope))
+			assert.Equal(t, "order_not_completed", envelope.Error.Code)
+		}
+	}
+	assert.Empty(t, storedQRCode(t, bunDB))
+}
+
+func TestCheckinRejectsTicketsOfIncompleteOrders(t *testing.T) {
+	router, bunDB := newDownloadRouter(t)
+	_, err := bunDB.NewUpdate().Model((*models.Order)(nil)).Set("status = ?", "pending").Where("order_id = ?", "order-1").Exec(context.Background())
+	require.NoError(t, err)
+
+	token, err := qr_genrator.NewQRGeneratorFromEnv().EncryptTicket(models.Ticket{TicketID: "ticket-1", OrderID: "order-1", SeatID: "seat-1"})
+	require.NoError(t, err)
+	body, err := json.Marshal(map[string]string{"encrypted_qr": token})
+	require.NoError(t, err)
+
+	scannerToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "scanner"}).SignedString([]byte("test"))
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/api/order/tickets/checkin", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+scannerToken)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+	var envelope utils.ErrorEnvelope
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&envelope))
+	assert.Equal(t, "order_not_completed", envelope.Error.Code)
+
+	ticket := new(models.Ticket)
+	require.NoError(t, bunDB.NewSelect().Model(ticket).Where("ticket_id = ?", "ticket-1").Scan(context.Background()))
+	assert.False(t, ticket.CheckedIn)
+}
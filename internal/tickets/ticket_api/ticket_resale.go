@@ -0,0 +1,71 @@
+package ticket_api
+
+import (
+	"encoding/json"
+	"errors"
+	"ms-ticketing/internal/utils"
+	"net/http"
+
+	tickets "ms-ticketing/internal/tickets/service"
+)
+
+// ListTicketForResale lists the caller's ticket on the resale market
+// Expected POST request body: {"asking_price": 25.00}
+func (h *Handler) ListTicketForResale(w http.ResponseWriter, r *http.Request) {
+	var requestBody struct {
+		AskingPrice float64 `json:"asking_price"`
+	}
+	if err := utils.DecodeJSONBody(w, r, &requestBody); err != nil {
+		writeBodyError(w, err, "Invalid request body: "+err.Error())
+		return
+	}
+
+	// Only tickets of completed orders are loaded, so pending or cancelled ones can't be resold
+	ticket, _ := h.loadOwnedTicket(w, r)
+	if ticket == nil {
+		return
+	}
+
+	listed, err := h.TicketService.ListForResale(ticket.TicketID, requestBody.AskingPrice)
+	if err != nil {
+		writeError(w, utils.ErrorCodeForStatus(resaleErrorStatus(err)), "Failed to list ticket: "+err.Error(), resaleErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"ticket_id":     listed.TicketID,
+		"resale_status": listed.ResaleStatus,
+		"asking_price":  listed.ResalePrice,
+		"listed_at":     listed.ResaleListedAt,
+	})
+}
+
+// UnlistTicketFromResale takes the caller's ticket off the resale market
+func (h *Handler) UnlistTicketFromResale(w http.ResponseWriter, r *http.Request) {
+	ticket, _ := h.loadOwnedTicket(w, r)
+	if ticket == nil {
+		return
+	}
+
+	if err := h.TicketService.UnlistFromResale(ticket.TicketID); err != nil {
+		writeError(w, utils.ErrorCodeForStatus(resaleErrorStatus(err)), "Failed to unlist ticket: "+err.Error(), resaleErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resaleErrorStatus maps resale rule violations to 409 and anything else to 500
+func resaleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, tickets.ErrTicketCheckedIn),
+		errors.Is(err, tickets.ErrSessionStarted),
+		errors.Is(err, tickets.ErrTicketAlreadyListed),
+		errors.Is(err, tickets.ErrTicketNotListed),
+		errors.Is(err, tickets.ErrTicketResold):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
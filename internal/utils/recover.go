@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"fmt"
+	"ms-ticketing/internal/logger"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Recoverer turns a panicking handler into a 500 JSON error instead of a dropped connection.
+// The panic is logged with the request ID (when middleware.RequestID runs first) and the stack.
+func Recoverer(log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rvr := recover()
+				if rvr == nil {
+					return
+				}
+				// The standard library uses this panic to abort a response on purpose
+				if rvr == http.ErrAbortHandler {
+					panic(rvr)
+				}
+
+				log.Error("HTTP", fmt.Sprintf("Panic serving %s %s (request %s): %v\n%s",
+					r.Method, r.URL.Path, middleware.GetReqID(r.Context()), rvr, debug.Stack()))
+				WriteError(w, ErrCodeInternal, "Internal server error", http.StatusInternalServerError)
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
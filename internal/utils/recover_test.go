@@ -0,0 +1,44 @@
+package utils_test
+
+import (
+	"encoding/json"
+	"ms-ticketing/internal/logger"
+	"ms-ticketing/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecovererAnswersPanicsWith500(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(utils.Recoverer(logger.NewLogger()))
+	r.Get("/boom", func(w http.ResponseWriter, r *http.Request) {
+		var m map[string]int
+		m["nil map"]++
+	})
+	r.Get("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	rec := httptest.NewRecorder()
+	assert.NotPanics(t, func() {
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/boom", nil))
+	})
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var body utils.ErrorEnvelope
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, utils.ErrCodeInternal, body.Error.Code)
+
+	// The router keeps serving after a panic
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// DefaultMaxBodyBytes bounds request bodies when MAX_REQUEST_BODY_BYTES is unset
+const DefaultMaxBodyBytes int64 = 1 << 20
+
+// MaxBodyBytes returns the largest request body handlers accept, from MAX_REQUEST_BODY_BYTES
+func MaxBodyBytes() int64 {
+	limit, err := strconv.ParseInt(os.Getenv("MAX_REQUEST_BODY_BYTES"), 10, 64)
+	if err != nil || limit <= 0 {
+		return DefaultMaxBodyBytes
+	}
+	return limit
+}
+
+// LimitBody caps how much of the request body can be read; reads past the limit fail with *http.MaxBytesError
+func LimitBody(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBodyBytes())
+}
+
+// DecodeJSONBody limits the request body and decodes it into dst.
+// Use IsBodyTooLarge on the error to tell an oversized body apart from malformed JSON.
+func DecodeJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	LimitBody(w, r)
+	return json.NewDecoder(r.Body).Decode(dst)
+}
+
+// IsBodyTooLarge reports whether err came from reading past the body limit
+func IsBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
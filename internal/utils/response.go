@@ -1,6 +1,10 @@
 package utils
 
-import "time"
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
 
 type APIResponse struct {
 	Success   bool        `json:"success"`
@@ -27,3 +31,74 @@ func ErrorResponse(message, error string) APIResponse {
 		Timestamp: time.Now(),
 	}
 }
+
+// Machine-readable codes used in error envelopes when no more specific code applies
+const (
+	ErrCodeInvalidRequest = "invalid_request"
+	ErrCodeUnauthorized   = "unauthorized"
+	ErrCodeForbidden      = "forbidden"
+	ErrCodeNotFound       = "not_found"
+	ErrCodeConflict       = "conflict"
+	ErrCodeBodyTooLarge   = "payload_too_large"
+	ErrCodeUnprocessable  = "unprocessable"
+	ErrCodeRateLimited    = "rate_limited"
+	ErrCodeInternal       = "internal_error"
+	ErrCodeUpstream       = "upstream_error"
+)
+
+// Codes for failures clients handle differently from the generic ones
+const (
+	// ErrCodeNotOrderOwner rejects access to an order placed by another user
+	ErrCodeNotOrderOwner = "not_order_owner"
+)
+
+// ErrorEnvelope is the body of every error response: {"error": {"code": ..., "message": ...}}
+type ErrorEnvelope struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes a single API error
+type ErrorDetail struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// ErrorCodeForStatus returns the generic error code for an HTTP status, for call sites whose status is only known at runtime
+func ErrorCodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrCodeInvalidRequest
+	case http.StatusUnauthorized:
+		return ErrCodeUnauthorized
+	case http.StatusForbidden:
+		return ErrCodeForbidden
+	case http.StatusNotFound:
+		return ErrCodeNotFound
+	case http.StatusConflict:
+		return ErrCodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return ErrCodeBodyTooLarge
+	case http.StatusUnprocessableEntity:
+		return ErrCodeUnprocessable
+	case http.StatusTooManyRequests:
+		return ErrCodeRateLimited
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return ErrCodeUpstream
+	default:
+		return ErrCodeInternal
+	}
+}
+
+// WriteError writes a JSON error envelope with the given status
+func WriteError(w http.ResponseWriter, code, message string, status int) {
+	WriteErrorDetails(w, code, message, status, nil)
+}
+
+// WriteErrorDetails writes a JSON error envelope carrying extra structured details
+func WriteErrorDetails(w http.ResponseWriter, code, message string, status int, details interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ErrorEnvelope{Error: ErrorDetail{Code: code, Message: message, Details: details}})
+}
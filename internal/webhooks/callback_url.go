@@ -0,0 +1,146 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrUnsafeCallbackURL is returned for callbacks that aren't https or that point into a private network
+var ErrUnsafeCallbackURL = errors.New("unsafe callback URL")
+
+// ValidateCallbackURL accepts absolute https URLs whose host resolves only to public addresses.
+// Partners choose the URL, so anything reaching loopback, link-local (cloud metadata) or private
+// ranges would let them make this service call internal endpoints.
+func ValidateCallbackURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnsafeCallbackURL, err)
+	}
+	if u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("%w: must be an absolute https URL", ErrUnsafeCallbackURL)
+	}
+	if _, err := resolvePublicIPs(ctx, u.Hostname()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// reservedNets are special-purpose ranges the net.IP predicates don't cover: carrier-grade NAT,
+// "this network", IETF protocol assignments, benchmarking and the NAT64 prefixes, which translate
+// to arbitrary IPv4 addresses including private ones
+var reservedNets = mustParseCIDRs(
+	"100.64.0.0/10",
+	"0.0.0.0/8",
+	"192.0.0.0/24",
+	"198.18.0.0/15",
+	"64:ff9b::/96",
+	"64:ff9b:1::/48",
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// publicIP reports whether a callback may connect to the address
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range reservedNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolvePublicIPs resolves the host and fails unless every address it resolves to is public
+func resolvePublicIPs(ctx context.Context, host string) ([]net.IP, error) {
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, fmt.Errorf("%w: could not resolve %s: %v", ErrUnsafeCallbackURL, host, err)
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("%w: %s has no addresses", ErrUnsafeCallbackURL, host)
+	}
+	for _, ip := range ips {
+		if !publicIP(ip) {
+			return nil, fmt.Errorf("%w: %s resolves to non-public address %s", ErrUnsafeCallbackURL, host, ip)
+		}
+	}
+	return ips, nil
+}
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// guardDial only lets dial connect to public addresses. The checked IP itself is dialed, so DNS
+// can't hand out a private address between the check and the connection.
+func guardDial(dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ips, err := resolvePublicIPs(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var lastErr error
+		for _, ip := range ips {
+			conn, err := dial(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		return nil, lastErr
+	}
+}
+
+// guardClient copies the client with a transport that refuses connections to non-public addresses,
+// covering callbacks registered before validation and redirects into the private network.
+// A nil client guards a new default one.
+func guardClient(client *http.Client) *http.Client {
+	if client == nil {
+		client = &http.Client{}
+	}
+	base, ok := client.Transport.(*http.Transport)
+	if !ok || base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	transport := base.Clone()
+	// A proxy would connect on our behalf and skip the address check
+	transport.Proxy = nil
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+	transport.DialContext = guardDial(dial)
+
+	guarded := *client
+	guarded.Transport = transport
+	return &guarded
+}
@@ -0,0 +1,50 @@
+package webhooks_test
+
+import (
+	"context"
+	"ms-ticketing/internal/logger"
+	"ms-ticketing/internal/webhooks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCallbackURL(t *testing.T) {
+	ctx := context.Background()
+	assert.NoError(t, webhooks.ValidateCallbackURL(ctx, "https://93.184.216.34/hooks/orders"))
+	assert.NoError(t, webhooks.ValidateCallbackURL(ctx, "https://[2606:2800:220:1:248:1893:25c8:1946]:8443/hook"))
+
+	for _, raw := range []string{
+		"",
+		"not a url",
+		"/relative/path",
+		"http://93.184.216.34/hook",
+		"ftp://93.184.216.34/hook",
+		"https://127.0.0.1/hook",
+		"https://localhost:8080/hook",
+		"https://[::1]/hook",
+		"https://10.0.0.5/hook",
+		"https://172.16.3.4/hook",
+		"https://192.168.1.10/hook",
+		"https://169.254.169.254/latest/meta-data",
+		"https://[fe80::1]/hook",
+		"https://[fd00::1]/hook",
+		"https://0.0.0.0/hook",
+		// Special-purpose ranges outside the private ones
+		"https://100.64.1.1/hook",
+		"https://0.1.2.3/hook",
+		"https://192.0.0.8/hook",
+		"https://198.18.0.1/hook",
+		"https://[64:ff9b::a00:1]/hook",
+		"https://[64:ff9b:1::1]/hook",
+		"https://[::ffff:100.64.1.1]/hook",
+	} {
+		assert.ErrorIs(t, webhooks.ValidateCallbackURL(ctx, raw), webhooks.ErrUnsafeCallbackURL, raw)
+	}
+}
+
+func TestNewDispatcherWithoutClient(t *testing.T) {
+	d := webhooks.NewDispatcher(nil, nil, logger.NewLogger())
+	assert.NotNil(t, d.Client)
+	assert.NotNil(t, d.Client.Transport)
+}
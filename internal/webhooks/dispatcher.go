@@ -0,0 +1,204 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"ms-ticketing/internal/logger"
+	"ms-ticketing/internal/models"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types delivered to partners
+const (
+	EventOrderCompleted = "order.completed"
+	EventOrderCancelled = "order.cancelled"
+)
+
+const (
+	DefaultMaxAttempts  = 5
+	DefaultRetryBackoff = 2 * time.Second
+	DefaultWorkers      = 2
+	queueSize           = 256
+)
+
+// Event is the JSON body POSTed to a partner callback
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// OrderCancelledData is the data of an order.cancelled event
+type OrderCancelledData struct {
+	models.OrderWithTickets
+	ReleasedSeatIDs []string `json:"released_seat_ids"`
+}
+
+// MaxAttempts reads PARTNER_WEBHOOK_MAX_ATTEMPTS, the number of tries per delivery including the first
+func MaxAttempts() int {
+	attempts, err := strconv.Atoi(os.Getenv("PARTNER_WEBHOOK_MAX_ATTEMPTS"))
+	if err != nil || attempts <= 0 {
+		return DefaultMaxAttempts
+	}
+	return attempts
+}
+
+// RetryBackoff reads PARTNER_WEBHOOK_RETRY_BACKOFF_SECONDS, the delay before the first retry.
+// The delay doubles on every further retry.
+func RetryBackoff() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("PARTNER_WEBHOOK_RETRY_BACKOFF_SECONDS"))
+	if err != nil || seconds <= 0 {
+		return DefaultRetryBackoff
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+type job struct {
+	organizationID string
+	event          Event
+}
+
+// Dispatcher delivers order events to the callbacks registered by the order's organization.
+// It satisfies order.CheckoutEventEmitter; emitting only enqueues, so checkout never waits on a partner.
+type Dispatcher struct {
+	Store        Store
+	Client       *http.Client
+	Logger       *logger.Logger
+	MaxAttempts  int
+	RetryBackoff time.Duration
+
+	queue chan job
+}
+
+// NewDispatcher delivers through a copy of client that only connects to public addresses
+func NewDispatcher(store Store, client *http.Client, logger *logger.Logger) *Dispatcher {
+	return &Dispatcher{
+		Store:        store,
+		Client:       guardClient(client),
+		Logger:       logger,
+		MaxAttempts:  MaxAttempts(),
+		RetryBackoff: RetryBackoff(),
+		queue:        make(chan job, queueSize),
+	}
+}
+
+// Start runs the delivery workers until ctx is cancelled
+func (d *Dispatcher) Start(ctx context.Context, workers int) {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	for i := 0; i < workers; i++ {
+		go d.work(ctx)
+	}
+}
+
+// EmitCheckoutEvent queues an order.completed delivery
+func (d *Dispatcher) EmitCheckoutEvent(order models.OrderWithTickets) {
+	d.enqueue(order.Order.OrganizationID, EventOrderCompleted, order)
+}
+
+// EmitOrderCancelledEvent queues an order.cancelled delivery
+func (d *Dispatcher) EmitOrderCancelledEvent(order models.OrderWithTickets, releasedSeatIDs []string) {
+	d.enqueue(order.Order.OrganizationID, EventOrderCancelled, OrderCancelledData{OrderWithTickets: order, ReleasedSeatIDs: releasedSeatIDs})
+}
+
+func (d *Dispatcher) enqueue(organizationID, eventType string, data interface{}) {
+	if organizationID == "" {
+		return
+	}
+	ev := Event{ID: uuid.New().String(), Type: eventType, CreatedAt: time.Now().UTC(), Data: data}
+	select {
+	case d.queue <- job{organizationID: organizationID, event: ev}:
+	default:
+		d.Logger.Warn("WEBHOOK", fmt.Sprintf("Delivery queue full, dropping %s event %s for organization %s", eventType, ev.ID, organizationID))
+	}
+}
+
+func (d *Dispatcher) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-d.queue:
+			d.dispatch(ctx, j)
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, j job) {
+	hooks, err := d.Store.ListWebhooks(ctx, j.organizationID)
+	if err != nil {
+		d.Logger.Error("WEBHOOK", fmt.Sprintf("Failed to load webhooks for organization %s: %v", j.organizationID, err))
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(j.event)
+	if err != nil {
+		d.Logger.Error("WEBHOOK", fmt.Sprintf("Failed to marshal %s event %s: %v", j.event.Type, j.event.ID, err))
+		return
+	}
+	for _, hook := range hooks {
+		if err := d.Deliver(ctx, hook, body); err != nil {
+			d.Logger.Error("WEBHOOK", fmt.Sprintf("Giving up on %s event %s for webhook %s: %v", j.event.Type, j.event.ID, hook.WebhookID, err))
+		}
+	}
+}
+
+// Deliver POSTs a signed body to one webhook, retrying network errors, 429 and 5xx responses with
+// exponential backoff. Other 4xx responses are treated as permanent failures.
+func (d *Dispatcher) Deliver(ctx context.Context, hook models.PartnerWebhook, body []byte) error {
+	backoff := d.RetryBackoff
+	var lastErr error
+	for attempt := 1; attempt <= d.MaxAttempts; attempt++ {
+		retry, err := d.post(ctx, hook, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry || attempt == d.MaxAttempts {
+			break
+		}
+
+		d.Logger.Warn("WEBHOOK", fmt.Sprintf("Delivery to webhook %s failed (attempt %d/%d): %v", hook.WebhookID, attempt, d.MaxAttempts, err))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return lastErr
+}
+
+// post makes one delivery attempt and reports whether a failure is worth retrying
+func (d *Dispatcher) post(ctx context.Context, hook models.PartnerWebhook, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, time.Now(), body))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook responded with status %s", resp.Status)
+}
@@ -0,0 +1,186 @@
+package webhooks_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"ms-ticketing/internal/logger"
+	"ms-ticketing/internal/models"
+	"ms-ticketing/internal/webhooks"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	_ "github.com/uptrace/bun/driver/sqliteshim"
+)
+
+func setupStore(t *testing.T) *webhooks.DB {
+	sqldb, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to connect to in-memory database: %v", err)
+	}
+	sqldb.SetMaxOpenConns(1)
+	bunDB := bun.NewDB(sqldb, sqlitedialect.New())
+	t.Cleanup(func() { bunDB.Close() })
+
+	if _, err := bunDB.NewCreateTable().Model((*models.PartnerWebhook)(nil)).Exec(context.Background()); err != nil {
+		t.Fatalf("Failed to create webhooks table: %v", err)
+	}
+	return &webhooks.DB{Bun: bunDB}
+}
+
+func newTestDispatcher(store webhooks.Store) *webhooks.Dispatcher {
+	d := webhooks.NewDispatcher(store, &http.Client{Timeout: time.Second}, logger.NewLogger())
+	// Test servers listen on loopback, which the dispatcher's own client refuses
+	d.Client = &http.Client{Timeout: time.Second}
+	d.MaxAttempts = 3
+	d.RetryBackoff = time.Millisecond
+	return d
+}
+
+func TestStoreScopesWebhooksToOrganization(t *testing.T) {
+	store := setupStore(t)
+	ctx := context.Background()
+
+	assert.NoError(t, store.CreateWebhook(ctx, models.PartnerWebhook{WebhookID: "wh-1", OrganizationID: "org-1", URL: "https://crm.example/a", Secret: "s1", CreatedAt: time.Now()}))
+	assert.NoError(t, store.CreateWebhook(ctx, models.PartnerWebhook{WebhookID: "wh-2", OrganizationID: "org-2", URL: "https://crm.example/b", Secret: "s2", CreatedAt: time.Now()}))
+
+	hooks, err := store.ListWebhooks(ctx, "org-1")
+	assert.NoError(t, err)
+	if assert.Len(t, hooks, 1) {
+		assert.Equal(t, "wh-1", hooks[0].WebhookID)
+		assert.Equal(t, "s1", hooks[0].Secret)
+	}
+
+	deleted, err := store.DeleteWebhook(ctx, "org-1", "wh-2")
+	assert.NoError(t, err)
+	assert.False(t, deleted, "another organization's webhook cannot be deleted")
+
+	deleted, err = store.DeleteWebhook(ctx, "org-1", "wh-1")
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	hooks, err = store.ListWebhooks(ctx, "org-1")
+	assert.NoError(t, err)
+	assert.Empty(t, hooks)
+}
+
+func TestDeliverRetriesServerErrorsAndSigns(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, webhooks.Verify("secret", r.Header.Get(webhooks.SignatureHeader), body, time.Minute, time.Now()))
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	d := newTestDispatcher(setupStore(t))
+	err := d.Deliver(context.Background(), models.PartnerWebhook{WebhookID: "wh-1", URL: server.URL, Secret: "secret"}, []byte(`{}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestDeliverGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	d := newTestDispatcher(setupStore(t))
+	err := d.Deliver(context.Background(), models.PartnerWebhook{WebhookID: "wh-1", URL: server.URL, Secret: "secret"}, []byte(`{}`))
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestDeliverDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	d := newTestDispatcher(setupStore(t))
+	err := d.Deliver(context.Background(), models.PartnerWebhook{WebhookID: "wh-1", URL: server.URL, Secret: "secret"}, []byte(`{}`))
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestDispatcherPostsOrderEventsToOrganizationWebhooks(t *testing.T) {
+	var mu sync.Mutex
+	var received []webhooks.Event
+	done := make(chan struct{}, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev webhooks.Event
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&ev))
+		mu.Lock()
+		received = append(received, ev)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+		done <- struct{}{}
+	}))
+	defer server.Close()
+
+	store := setupStore(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, store.CreateWebhook(ctx, models.PartnerWebhook{WebhookID: "wh-1", OrganizationID: "org-1", URL: server.URL, Secret: "secret", CreatedAt: time.Now()}))
+
+	d := newTestDispatcher(store)
+	d.Start(ctx, 1)
+
+	order := models.OrderWithTickets{Order: models.Order{OrderID: "order-1", OrganizationID: "org-1"}}
+	d.EmitCheckoutEvent(order)
+	d.EmitCheckoutEvent(models.OrderWithTickets{Order: models.Order{OrderID: "order-2", OrganizationID: "org-2"}})
+	d.EmitOrderCancelledEvent(order, []string{"seat-1"})
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for webhook delivery")
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.Len(t, received, 2) {
+		assert.Equal(t, webhooks.EventOrderCompleted, received[0].Type)
+		assert.Equal(t, webhooks.EventOrderCancelled, received[1].Type)
+		assert.NotEqual(t, received[0].ID, received[1].ID)
+		data := received[1].Data.(map[string]interface{})
+		assert.Equal(t, []interface{}{"seat-1"}, data["released_seat_ids"])
+	}
+}
+
+func TestDeliverRefusesPrivateAddresses(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	// Without the test client override, e.g. for a callback registered before URLs were checked
+	d := webhooks.NewDispatcher(setupStore(t), &http.Client{Timeout: time.Second}, logger.NewLogger())
+	d.MaxAttempts = 1
+
+	err := d.Deliver(context.Background(), models.PartnerWebhook{WebhookID: "wh-1", URL: server.URL, Secret: "secret"}, []byte(`{}`))
+	assert.ErrorIs(t, err, webhooks.ErrUnsafeCallbackURL)
+	assert.Zero(t, calls.Load())
+}
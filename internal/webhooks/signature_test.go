@@ -0,0 +1,42 @@
+package webhooks_test
+
+import (
+	"errors"
+	"ms-ticketing/internal/webhooks"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"type":"order.completed"}`)
+	sentAt := time.Unix(1700000000, 0)
+	header := webhooks.Sign("whsec_test", sentAt, body)
+
+	assert.True(t, strings.HasPrefix(header, "t=1700000000,v1="))
+	assert.NoError(t, webhooks.Verify("whsec_test", header, body, 5*time.Minute, sentAt.Add(time.Minute)))
+
+	err := webhooks.Verify("whsec_other", header, body, 5*time.Minute, sentAt)
+	assert.True(t, errors.Is(err, webhooks.ErrInvalidSignature))
+
+	err = webhooks.Verify("whsec_test", header, []byte(`{"type":"order.cancelled"}`), 5*time.Minute, sentAt)
+	assert.True(t, errors.Is(err, webhooks.ErrInvalidSignature))
+
+	err = webhooks.Verify("whsec_test", header, body, 5*time.Minute, sentAt.Add(time.Hour))
+	assert.True(t, errors.Is(err, webhooks.ErrInvalidSignature), "stale signatures are rejected")
+
+	err = webhooks.Verify("whsec_test", "garbage", body, 0, sentAt)
+	assert.True(t, errors.Is(err, webhooks.ErrInvalidSignature))
+}
+
+func TestNewSecretIsRandom(t *testing.T) {
+	first, err := webhooks.NewSecret()
+	assert.NoError(t, err)
+	second, err := webhooks.NewSecret()
+	assert.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(first, "whsec_"))
+	assert.NotEqual(t, first, second)
+}
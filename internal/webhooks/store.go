@@ -0,0 +1,57 @@
+package webhooks
+
+import (
+	"context"
+	"ms-ticketing/internal/models"
+
+	"github.com/uptrace/bun"
+)
+
+// Store persists partner webhook registrations
+type Store interface {
+	CreateWebhook(ctx context.Context, webhook models.PartnerWebhook) error
+	ListWebhooks(ctx context.Context, organizationID string) ([]models.PartnerWebhook, error)
+	DeleteWebhook(ctx context.Context, organizationID, webhookID string) (bool, error)
+}
+
+// DB is the bun-backed Store
+type DB struct {
+	Bun bun.IDB
+}
+
+// CreateWebhook → insert a registration
+func (d *DB) CreateWebhook(ctx context.Context, webhook models.PartnerWebhook) error {
+	_, err := d.Bun.NewInsert().Model(&webhook).Exec(ctx)
+	return err
+}
+
+// ListWebhooks → every registration of an organization, oldest first
+func (d *DB) ListWebhooks(ctx context.Context, organizationID string) ([]models.PartnerWebhook, error) {
+	webhooks := []models.PartnerWebhook{}
+	err := d.Bun.NewSelect().
+		Model(&webhooks).
+		Where("organization_id = ?", organizationID).
+		Order("created_at ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook → remove a registration, reporting whether it existed for that organization
+func (d *DB) DeleteWebhook(ctx context.Context, organizationID, webhookID string) (bool, error) {
+	res, err := d.Bun.NewDelete().
+		Model((*models.PartnerWebhook)(nil)).
+		Where("webhook_id = ?", webhookID).
+		Where("organization_id = ?", organizationID).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
@@ -14,18 +14,22 @@ import (
 	"ms-ticketing/internal/config"
 	"ms-ticketing/internal/database/migrations"
 	"ms-ticketing/internal/kafka"
-	"ms-ticketing/internal/models"
 	ticket_db "ms-ticketing/internal/tickets/db"
+	qr_genrator "ms-ticketing/internal/tickets/qr_genrator"
 	tickets "ms-ticketing/internal/tickets/service"
 	"ms-ticketing/internal/tickets/ticket_api"
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/go-redis/redis/v8"
 	_ "github.com/golang-migrate/migrate/v4"
@@ -40,366 +44,168 @@ import (
 	"ms-ticketing/internal/order/db"
 	"ms-ticketing/internal/order/order_api"
 	rediswrap "ms-ticketing/internal/order/redis"
+	"ms-ticketing/internal/ratelimit"
+	"ms-ticketing/internal/sse"
+	"ms-ticketing/internal/utils"
+	"ms-ticketing/internal/webhooks"
 
 	"ms-ticketing/internal/logger"
 )
 
-type DB interface {
-	GetSessionIdBySeat(seatID string) (string, error)
-	GetOrderBySeat(seatID string) (*models.Order, error)
-	GetPendingOrdersBySeat(seatID string) ([]*models.Order, error)
-	UpdateOrder(order models.Order) error
-}
-
-// DBAdapter adapts our local DB interface to satisfy order.DBLayer interface
-type DBAdapter struct {
-	DB DB
-}
-
-// Implement all methods required by order.DBLayer
-func (a *DBAdapter) GetOrderBySeat(seatID string) (*models.Order, error) {
-	return a.DB.GetOrderBySeat(seatID)
-}
-
-func (a *DBAdapter) GetPendingOrdersBySeat(seatID string) ([]*models.Order, error) {
-	return a.DB.GetPendingOrdersBySeat(seatID)
-}
-
-func (a *DBAdapter) UpdateOrder(order models.Order) error {
-	return a.DB.UpdateOrder(order)
-}
+// Build information, set at build time with
+// -ldflags "-X main.version=1.4.0 -X main.commit=$(git rev-parse HEAD) -X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildTime = "unknown"
+)
 
-func (a *DBAdapter) GetSessionIdBySeat(seatID string) (string, error) {
-	return a.DB.GetSessionIdBySeat(seatID)
+// versionHandler reports which build is running
+func versionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"version":    version,
+		"commit":     commit,
+		"build_time": buildTime,
+		"go_version": runtime.Version(),
+	})
 }
 
-func (a *DBAdapter) CreateOrder(order models.Order) error {
-	// Not needed for the seat unlock flow
-	return nil
+// NewOrderServiceForSeatUnlock creates the OrderService that handles seat lock expiries. It reuses the
+// application's bun.DB pool, since a burst of expiries would otherwise open a connection per lookup, and
+// needs the real seat locks: seats of orders still inside their payment window are locked again.
+func NewOrderServiceForSeatUnlock(bunDB *bun.DB, locks order.RedisLock, producer *kafka.Producer, client *http.Client) *order.OrderService {
+	ticketService := tickets.NewTicketService(&ticket_db.DB{Bun: bunDB})
+	return order.NewOrderService(&db.DB{Bun: bunDB}, locks, producer, ticketService, client)
 }
 
-func (a *DBAdapter) GetOrderByID(id string) (*models.Order, error) {
-	// We need to use a real DB connection here to get the order details
-	// Create a temporary db.DB
-	sqldb, err := sql.Open("postgres", os.Getenv("POSTGRES_DSN"))
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %v", err)
+// keyspaceNodes returns the servers whose keyspace notifications report seat lock expiries. A cluster
+// only notifies on the master that owns the expired key, so each master is returned; masters that join
+// later are not picked up until the service restarts.
+func keyspaceNodes(ctx context.Context, rdb redis.UniversalClient) ([]redis.UniversalClient, error) {
+	cluster, ok := rdb.(*redis.ClusterClient)
+	if !ok {
+		return []redis.UniversalClient{rdb}, nil
 	}
-	defer sqldb.Close()
 
-	bunDB := bun.NewDB(sqldb, pgdialect.New())
-	dbImpl := db.DB{Bun: bunDB}
-	return dbImpl.GetOrderByID(id)
+	var mu sync.Mutex
+	var nodes []redis.UniversalClient
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+		mu.Lock()
+		defer mu.Unlock()
+		nodes = append(nodes, master)
+		return nil
+	})
+	return nodes, err
 }
 
-func (a *DBAdapter) GetOrderWithSeats(id string) (*models.OrderWithSeats, error) {
-	// Not needed for the seat unlock flow
-	return nil, nil
-}
+func subscribeSeatUnlocks(rdb redis.UniversalClient, redisDB int, producer *kafka.Producer, bunDB *bun.DB, audit order.SeatAuditLog, history order.OrderStatusHistory, client *http.Client, logger *logger.Logger) {
+	ctx := context.Background()
 
-func (a *DBAdapter) CancelOrder(id string) error {
-	// Get the order first
-	order, err := a.GetOrderByID(id)
+	nodes, err := keyspaceNodes(ctx, rdb)
 	if err != nil {
-		return err
+		logger.Error("REDIS", fmt.Sprintf("Failed to list cluster masters for expiry notifications: %v", err))
 	}
 
-	// Update the status to cancelled
-	order.Status = "cancelled"
-	return a.DB.UpdateOrder(*order)
-}
+	// Keyspace notifications are published per database, so listen on the one the seat locks live in
+	channel := fmt.Sprintf("__keyevent@%d__:expired", redisDB)
 
-func (a *DBAdapter) GetSeatsByOrder(orderID string) ([]string, error) {
-	// For our simple use case in seat unlock, we'll implement this minimally
-	// Create a temporary db.DB to get seat IDs
-	sqldb, err := sql.Open("postgres", os.Getenv("POSTGRES_DSN"))
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %v", err)
-	}
-	defer sqldb.Close()
+	// Locks of a whole session often expire together, e.g. when an event is postponed, so expired
+	// seats are collected briefly and processed as one batch
+	expired := make(chan string, order.SeatExpiryBatchSize())
+	var subscribers sync.WaitGroup
+	for _, node := range nodes {
+		val, err := node.ConfigGet(ctx, "notify-keyspace-events").Result()
+		if err != nil {
+			logger.Error("REDIS", fmt.Sprintf("Failed to get keyspace config: %v", err))
+		} else {
+			logger.Info("REDIS", fmt.Sprintf("Current keyspace notifications setting: %v", val))
+			if len(val) < 2 || !strings.Contains(val[1].(string), "x") || !strings.Contains(val[1].(string), "E") {
+				logger.Warn("REDIS", "Keyspace notifications not properly configured for expiry events!")
+			}
+		}
 
-	bunDB := bun.NewDB(sqldb, pgdialect.New())
-	dbImpl := db.DB{Bun: bunDB}
-	return dbImpl.GetSeatsByOrder(orderID)
-}
+		pubsub := node.PSubscribe(ctx, channel)
+		subscribers.Add(1)
+		go func() {
+			defer subscribers.Done()
+			for msg := range pubsub.Channel() {
+				logger.Debug("REDIS", fmt.Sprintf("Received expired key event: %s", msg.Payload))
+				// The key name still carries the seat ID; the JSON lock value is already gone once it expires
+				if seatID, ok := rediswrap.SeatIDFromLockKey(msg.Payload); ok {
+					expired <- seatID
+				}
+			}
+		}()
+	}
+	logger.Info("REDIS", fmt.Sprintf("Subscribed to Redis keyevent expired notifications (DB %d) on %d node(s)", redisDB, len(nodes)))
+	go func() {
+		subscribers.Wait()
+		close(expired)
+	}()
 
-func (a *DBAdapter) GetOrdersWithTicketsByUserID(userID string) ([]models.OrderWithTickets, error) {
-	// Not needed for the seat unlock flow
-	return nil, nil
+	orderService := NewOrderServiceForSeatUnlock(bunDB, rediswrap.NewRedis(rdb, producer), producer, client)
+	orderService.SetSeatAuditLog(audit)
+	orderService.SetStatusHistory(history)
+	go order.BatchSeatIDs(expired, order.SeatExpiryBatchWindow(), order.SeatExpiryBatchSize(), func(seatIDs []string) {
+		processExpiredSeats(ctx, rdb, orderService, logger, seatIDs)
+	})
 }
 
-func (a *DBAdapter) GetOrdersWithTicketsAndQRByUserID(userID string) ([]models.OrderWithTicketsAndQR, error) {
-	// Not needed for the seat unlock flow
-	return nil, nil
-}
+// processExpiredSeats handles one batch of expired seat locks. Each seat is claimed with a short-lived
+// distributed lock first, so when several instances receive the same expiry only one processes the seat.
+func processExpiredSeats(ctx context.Context, rdb redis.UniversalClient, orderService *order.OrderService, logger *logger.Logger, seatIDs []string) {
+	// A lease bounds how long a crashed instance can hold a seat
+	const lockLease = 30 * time.Second
 
-// NewOrderServiceForSeatUnlock creates a minimal OrderService instance for handling seat unlock events
-func NewOrderServiceForSeatUnlock(db DB, producer *kafka.Producer, logger *logger.Logger) *order.OrderService {
-	// Create a DB adapter that converts our local DB interface to order.DBLayer
-	dbAdapter := &DBAdapter{
-		DB: db,
+	claimed := make([]string, 0, len(seatIDs))
+	for _, seatID := range seatIDs {
+		lockKey := fmt.Sprintf("lock:seat_unlock:%s", seatID)
+		lockAcquired, err := rdb.SetNX(ctx, lockKey, "processing", lockLease).Result()
+		if err != nil {
+			logger.Error("SEAT_UNLOCK_LOCK", fmt.Sprintf("Error trying to acquire lock for seat %s: %v", seatID, err))
+			continue
+		}
+		if !lockAcquired {
+			logger.Info("SEAT_UNLOCK_LOCK", fmt.Sprintf("Lock for seat %s already held by another instance. Skipping.", seatID))
+			continue
+		}
+		claimed = append(claimed, seatID)
+	}
+	if len(claimed) == 0 {
+		return
 	}
 
-	// Create a minimal Redis implementation that satisfies the RedisLock interface
-	redisLock := &MinimalRedisLock{}
+	logger.Info("SEAT_UNLOCK", fmt.Sprintf("Seat locks expired for %d seats", len(claimed)))
+	// Untracked before handling, so seats relocked for an order inside its payment window stay in the set
+	untrackExpiredSeats(ctx, rediswrap.NewRedis(rdb, nil), orderService, logger, claimed)
+	orderService.HandleExpiredSeats(ctx, claimed)
 
-	// Create a basic HTTP client
-	client := &http.Client{
-		Timeout: time.Second * 10,
+	lockKeys := make([]string, 0, len(claimed))
+	for _, seatID := range claimed {
+		lockKeys = append(lockKeys, fmt.Sprintf("lock:seat_unlock:%s", seatID))
 	}
-
-	// Initialize a ticket service with a properly initialized bun DB
-	sqldb, err := sql.Open("postgres", os.Getenv("POSTGRES_DSN"))
-	if err != nil {
-		logger.Error("DATABASE", fmt.Sprintf("Failed to initialize database for seat unlock: %v", err))
-		return nil
+	if err := rdb.Del(ctx, lockKeys...).Err(); err != nil {
+		logger.Error("SEAT_UNLOCK_LOCK", fmt.Sprintf("Failed to release locks for %d seats: %v", len(claimed), err))
 	}
-	bunDB := bun.NewDB(sqldb, pgdialect.New())
-	ticketService := tickets.NewTicketService(&ticket_db.DB{Bun: bunDB})
-
-	return order.NewOrderService(dbAdapter, redisLock, producer, ticketService, client)
-}
-
-// MinimalRedisLock implements the RedisLock interface with minimal functionality
-type MinimalRedisLock struct{}
-
-func (r *MinimalRedisLock) CheckSeatsAvailability(seatIDs []string) (bool, []string, error) {
-	// Not needed for seat unlock flow
-	return true, nil, nil
-}
-
-func (r *MinimalRedisLock) LockSeats(seatIDs []string, orderID string) (bool, error) {
-	// Not needed for seat unlock flow
-	return true, nil
 }
 
-func (r *MinimalRedisLock) UnlockSeats(seatIDs []string, orderID string) error {
-	// Not needed for seat unlock flow
-	return nil
-}
-
-func subscribeSeatUnlocks(rdb *redis.Client, producer *kafka.Producer, db DB, logger *logger.Logger, kafkaBrokers []string) {
-	ctx := context.Background()
-
-	val, err := rdb.ConfigGet(ctx, "notify-keyspace-events").Result()
-	if err != nil {
-		logger.Error("REDIS", fmt.Sprintf("Failed to get keyspace config: %v", err))
-	} else {
-		logger.Info("REDIS", fmt.Sprintf("Current keyspace notifications setting: %v", val))
-		if len(val) < 2 || !strings.Contains(val[1].(string), "x") || !strings.Contains(val[1].(string), "E") {
-			logger.Warn("REDIS", "Keyspace notifications not properly configured for expiry events!")
+// untrackExpiredSeats removes seats whose locks expired from their session's lock set. The expired key
+// no longer holds the lock's session, so it is looked up from the seat's order.
+func untrackExpiredSeats(ctx context.Context, locks *rediswrap.Redis, orderService *order.OrderService, logger *logger.Logger, seatIDs []string) {
+	for _, seatID := range seatIDs {
+		sessionID, err := orderService.DB.GetSessionIdBySeat(ctx, seatID)
+		if err != nil {
+			logger.Warn("SEAT_UNLOCK", fmt.Sprintf("No session found for expired seat %s, leaving it in its lock set: %v", seatID, err))
+			continue
 		}
-	}
-
-	pubsub := rdb.PSubscribe(ctx, "__keyevent@0__:expired")
-	logger.Info("REDIS", fmt.Sprintf("Subscribed to Redis keyevent expired notifications (DB %d)", rdb.Options().DB))
-
-	go func() {
-		for msg := range pubsub.Channel() {
-			logger.Info("REDIS", fmt.Sprintf("Received expired key event: %s", msg.Payload))
-			if strings.HasPrefix(msg.Payload, "seat_lock:") {
-				seatID := strings.TrimPrefix(msg.Payload, "seat_lock:")
-
-				// --- ADDED: DISTRIBUTED LOCK ACQUISITION ---
-
-				// 1. Define a unique lock key for this specific job
-				lockKey := fmt.Sprintf("lock:seat_unlock:%s", seatID)
-
-				// 2. Set a lease time (safety net for pod crashes)
-				//    Adjust this based on your expected processing time.
-				lockLease := 30 * time.Second
-
-				// 3. Try to acquire the lock atomically using SETNX
-				lockAcquired, err := rdb.SetNX(ctx, lockKey, "processing", lockLease).Result()
-
-				if err != nil {
-					// Failed to even attempt the lock, log and skip
-					logger.Error("SEAT_UNLOCK_LOCK", fmt.Sprintf("Error trying to acquire lock for seat %s: %v", seatID, err))
-					continue
-				}
-
-				if !lockAcquired {
-					// 4. Lock failed. Another pod already has it.
-					logger.Info("SEAT_UNLOCK_LOCK", fmt.Sprintf("Lock for seat %s already held by another instance. Skipping.", seatID))
-					continue // <- This is the fix. We stop processing.
-				}
-
-				// 5. We got the lock! Proceed with processing.
-				logger.Info("SEAT_UNLOCK_LOCK", fmt.Sprintf("Acquired lock for seat: %s", seatID))
-
-				// --- END: DISTRIBUTED LOCK ACQUISITION ---
-
-				// vvvv THIS IS YOUR EXISTING LOGIC (NO CHANGES) vvvv
-
-				logger.Info("SEAT_UNLOCK", fmt.Sprintf("Seat lock expired for seat: %s", seatID))
-
-				sessionID, err := db.GetSessionIdBySeat(seatID)
-				if err != nil {
-					logger.Error("SEAT_UNLOCK", fmt.Sprintf("Failed to get session ID for seat %s: %v", seatID, err))
-
-					// --- ADDED: Make sure to release lock on error ---
-					if err := rdb.Del(ctx, lockKey).Err(); err != nil {
-						logger.Error("SEAT_UNLOCK_LOCK", fmt.Sprintf("Failed to release lock for seat %s after error: %v", seatID, err))
-					}
-					// --- END ---
-					continue
-				}
-
-				// Get all pending orders that contain this seat
-				pendingOrders, err := db.GetPendingOrdersBySeat(seatID)
-				if err != nil {
-					logger.Error("SEAT_UNLOCK", fmt.Sprintf("Failed to get pending orders for seat %s: %v", seatID, err))
-
-					// --- ADDED: Make sure to release lock on error ---
-					if err := rdb.Del(ctx, lockKey).Err(); err != nil {
-						logger.Error("SEAT_UNLOCK_LOCK", fmt.Sprintf("Failed to release lock for seat %s after error: %v", seatID, err))
-					}
-					// --- END ---
-
-				} else if len(pendingOrders) == 0 {
-					logger.Info("SEAT_UNLOCK", fmt.Sprintf("No pending orders found for seat %s", seatID))
-
-					// No pending orders to cancel, publish seat status event directly
-					logger.Info("SEAT_UNLOCK", "Publishing seat status event directly since no pending orders were found")
-					seatEvent, err := models.NewSeatStatusChangeEventDto(sessionID, []string{seatID}, models.SeatStatusAvailable)
-					if err != nil {
-						logger.Error("SEAT_UNLOCK", fmt.Sprintf("Failed to create seat status event DTO: %v", err))
-
-						// --- ADDED: Make sure to release lock on error ---
-						if err := rdb.Del(ctx, lockKey).Err(); err != nil {
-							logger.Error("SEAT_UNLOCK_LOCK", fmt.Sprintf("Failed to release lock for seat %s after error: %v", seatID, err))
-						}
-						// --- END ---
-						continue
-					}
-
-					value, err := json.Marshal(seatEvent)
-					if err != nil {
-						logger.Error("SEAT_UNLOCK", fmt.Sprintf("Failed to marshal seat unlock payload: %v", err))
-
-						// --- ADDED: Make sure to release lock on error ---
-						if err := rdb.Del(ctx, lockKey).Err(); err != nil {
-							logger.Error("SEAT_UNLOCK_LOCK", fmt.Sprintf("Failed to release lock for seat %s after error: %v", seatID, err))
-						}
-						// --- END ---
-						continue
-					}
-
-					err = producer.Publish("ticketly.seats.status", seatID, value)
-					if err != nil {
-						logger.Error("SEAT_UNLOCK", fmt.Sprintf("Failed to publish seat unlock event: %v", err))
-						err = kafka.CreateTopicIfNotExists(kafkaBrokers, "ticketly.seats.status")
-						if err != nil {
-							logger.Error("KAFKA", fmt.Sprintf("Failed to create topic: %v", err))
-						} else {
-							err = producer.Publish("ticketly.seats.status", seatID, value)
-							if err != nil {
-								logger.Error("SEAT_UNLOCK", fmt.Sprintf("Still failed to publish after topic creation: %v", err))
-							} else {
-								logger.Info("KAFKA", fmt.Sprintf("Published seat unlock event for seat: %s after retry", seatID))
-							}
-						}
-					} else {
-						logger.Info("KAFKA", fmt.Sprintf("Published seat unlock event for seat: %s", seatID))
-					}
-				} else {
-					// Cancel all pending orders that contain this seat
-					logger.Info("SEAT_UNLOCK", fmt.Sprintf("Found %d pending orders for seat %s", len(pendingOrders), seatID))
-					orderService := NewOrderServiceForSeatUnlock(db, producer, logger)
-					ordersCancelled := false
-
-					// Loop through all pending orders and cancel them
-					for _, order := range pendingOrders {
-						logger.Info("SEAT_UNLOCK", fmt.Sprintf("Processing order %s with status: %s", order.OrderID, order.Status))
-
-						// Always cancel the order when seat lock expires
-						logger.Info("SEAT_UNLOCK", fmt.Sprintf("Cancelling order %s due to seat lock expiry", order.OrderID))
-						err = orderService.CancelOrder(order.OrderID)
-						if err != nil {
-							logger.Error("SEAT_UNLOCK", fmt.Sprintf("Failed to cancel order %s: %v", order.OrderID, err))
-						} else {
-							logger.Info("SEAT_UNLOCK", fmt.Sprintf("Order %s cancelled successfully due to seat lock expiry", order.OrderID))
-							// No need to publish seat status event here as CancelOrder already does it
-							ordersCancelled = true
-						}
-					}
-
-					// If we successfully cancelled at least one order, no need to publish seat event
-					// as the CancelOrder method already does it
-					if ordersCancelled {
-						logger.Debug("SEAT_UNLOCK", "Seat status event handled by CancelOrder method")
-
-						// --- ADDED: Release lock after processing ---
-						if err := rdb.Del(ctx, lockKey).Err(); err != nil {
-							logger.Error("SEAT_UNLOCK_LOCK", fmt.Sprintf("Failed to release lock for seat %s: %v", seatID, err))
-						} else {
-							logger.Info("SEAT_UNLOCK_LOCK", fmt.Sprintf("Released lock for seat: %s", seatID))
-						}
-						// --- END ---
-						continue
-					}
-
-					// If we didn't cancel any orders (unlikely but possible), publish seat status event directly
-					logger.Info("SEAT_UNLOCK", "Publishing seat status event directly since no orders were successfully cancelled")
-					seatEvent, err := models.NewSeatStatusChangeEventDto(sessionID, []string{seatID}, models.SeatStatusAvailable)
-					if err != nil {
-						logger.Error("SEAT_UNLOCK", fmt.Sprintf("Failed to create seat status event DTO: %v", err))
-
-						// --- ADDED: Make sure to release lock on error ---
-						if err := rdb.Del(ctx, lockKey).Err(); err != nil {
-							logger.Error("SEAT_UNLOCK_LOCK", fmt.Sprintf("Failed to release lock for seat %s after error: %v", seatID, err))
-						}
-						// --- END ---
-						continue
-					}
-
-					value, err := json.Marshal(seatEvent)
-					if err != nil {
-						logger.Error("SEAT_UNLOCK", fmt.Sprintf("Failed to marshal seat unlock payload: %v", err))
-
-						// --- ADDED: Make sure to release lock on error ---
-						if err := rdb.Del(ctx, lockKey).Err(); err != nil {
-							logger.Error("SEAT_UNLOCK_LOCK", fmt.Sprintf("Failed to release lock for seat %s after error: %v", seatID, err))
-						}
-						// --- END ---
-						continue
-					}
-
-					err = producer.Publish("ticketly.seats.status", seatID, value)
-					if err != nil {
-						logger.Error("SEAT_UNLOCK", fmt.Sprintf("Failed to publish seat unlock event: %v", err))
-						err = kafka.CreateTopicIfNotExists(kafkaBrokers, "ticketly.seats.status")
-						if err != nil {
-							logger.Error("KAFKA", fmt.Sprintf("Failed to create topic: %v", err))
-						} else {
-							err = producer.Publish("ticketly.seats.status", seatID, value)
-							if err != nil {
-								logger.Error("SEAT_UNLOCK", fmt.Sprintf("Still failed to publish after topic creation: %v", err))
-							} else {
-								logger.Info("KAFKA", fmt.Sprintf("Published seat unlock event for seat: %s after retry", seatID))
-							}
-						}
-					} else {
-						logger.Info("KAFKA", fmt.Sprintf("Published seat unlock event for seat: %s", seatID))
-					}
-				}
-
-				// ^^^^ THIS IS YOUR EXISTING LOGIC (NO CHANGES) ^^^^
-
-				// --- ADDED: DISTRIBUTED LOCK RELEASE ---
-				// 6. Manually release the lock now that processing is complete.
-				if err := rdb.Del(ctx, lockKey).Err(); err != nil {
-					logger.Error("SEAT_UNLOCK_LOCK", fmt.Sprintf("Failed to release lock for seat %s: %v", seatID, err))
-				} else {
-					logger.Info("SEAT_UNLOCK_LOCK", fmt.Sprintf("Released lock for seat: %s", seatID))
-				}
-				// --- END: DISTRIBUTED LOCK RELEASE ---
-			}
+		if _, err := locks.UntrackExpiredSeat(sessionID, seatID); err != nil {
+			logger.Error("SEAT_UNLOCK", fmt.Sprintf("Failed to remove expired seat %s from session %s lock set: %v", seatID, sessionID, err))
 		}
-	}()
+	}
 }
 
-func verifyConnections(ctx context.Context, logger *logger.Logger) (*bun.DB, *redis.Client) {
+func verifyConnections(ctx context.Context, redisCfg config.RedisConfig, logger *logger.Logger) (*bun.DB, redis.UniversalClient) {
 	dsn := os.Getenv("POSTGRES_DSN")
 	if dsn == "" {
 		logger.Fatal("CONFIG", "POSTGRES_DSN not set")
@@ -470,12 +276,13 @@ func verifyConnections(ctx context.Context, logger *logger.Logger) (*bun.DB, *re
 		migrationRunner := migrations.NewRunner(bunDB, migrationOpts)
 
 		// Initialize migration system
+		// Serving traffic against an outdated schema fails in confusing ways, so a migration error stops startup
 		if err := migrationRunner.Initialize(); err != nil {
-			logger.Error("MIGRATIONS", fmt.Sprintf("Failed to initialize migrations: %v", err))
+			logger.Fatal("MIGRATIONS", fmt.Sprintf("Failed to initialize migrations: %v", err))
 		} else {
 			// Run migrations
 			if err := migrationRunner.RunMigrations(); err != nil {
-				logger.Error("MIGRATIONS", fmt.Sprintf("Failed to run migrations: %v", err))
+				logger.Fatal("MIGRATIONS", fmt.Sprintf("Failed to run migrations: %v", err))
 			} else {
 				logger.Info("MIGRATIONS", "✅ Database migrations completed successfully")
 			}
@@ -488,25 +295,32 @@ func verifyConnections(ctx context.Context, logger *logger.Logger) (*bun.DB, *re
 		logger.Info("MIGRATIONS", "Automatic migrations disabled. Skipping.")
 	}
 
-	redisAddr := os.Getenv("REDIS_ADDR")
-	if redisAddr == "" {
+	if (redisCfg.Mode == "" || redisCfg.Mode == config.RedisModeStandalone) && os.Getenv("REDIS_ADDR") == "" {
 		logger.Fatal("CONFIG", "REDIS_ADDR not set")
 	}
-	redisClient := redis.NewClient(&redis.Options{
-		Addr: redisAddr,
-	})
+	redisClient, err := redisCfg.NewClient()
+	if err != nil {
+		logger.Fatal("CONFIG", fmt.Sprintf("Invalid Redis configuration: %v", err))
+	}
 	if err := redisClient.Ping(ctx).Err(); err != nil {
 		logger.Fatal("DATABASE", fmt.Sprintf("Redis connection error: %v", err))
 	}
 
-	_, err = redisClient.ConfigSet(ctx, "notify-keyspace-events", "Ex").Result()
+	// Every node that can own a seat lock has to publish its expiries. With Sentinel only the current
+	// master is configured here, so replicas need the setting in their own config to survive a failover.
+	nodes, err := keyspaceNodes(ctx, redisClient)
 	if err != nil {
-		logger.Warn("REDIS", fmt.Sprintf("Failed to enable keyspace notifications: %v", err))
-	} else {
-		logger.Info("REDIS", "Keyspace notifications enabled for expired events")
+		logger.Warn("REDIS", fmt.Sprintf("Failed to list cluster masters: %v", err))
+	}
+	for _, node := range nodes {
+		if _, err := node.ConfigSet(ctx, "notify-keyspace-events", "Ex").Result(); err != nil {
+			logger.Warn("REDIS", fmt.Sprintf("Failed to enable keyspace notifications: %v", err))
+		} else {
+			logger.Info("REDIS", "Keyspace notifications enabled for expired events")
+		}
 	}
 
-	logger.Info("DATABASE", fmt.Sprintf("✅ Redis connection successful to %s (DB: %d)", redisAddr, redisClient.Options().DB))
+	logger.Info("DATABASE", fmt.Sprintf("✅ Redis connection successful (mode: %s, DB: %d, TLS: %t)", redisCfg.Mode, redisCfg.DB, redisCfg.TLS))
 	return bunDB, redisClient
 }
 
@@ -519,11 +333,142 @@ func SecureHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// startOrderArchival launches the background job that archives old completed and cancelled orders.
+// ORDER_ARCHIVE_RETENTION_DAYS=0 disables it.
+func startOrderArchival(ctx context.Context, orderService *order.OrderService, logger *logger.Logger) {
+	retentionDays := 365
+	if v := os.Getenv("ORDER_ARCHIVE_RETENTION_DAYS"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			logger.Warn("ARCHIVE", fmt.Sprintf("Invalid ORDER_ARCHIVE_RETENTION_DAYS value '%s', using default %d", v, retentionDays))
+		} else {
+			retentionDays = parsed
+		}
+	}
+	if retentionDays == 0 {
+		logger.Info("ARCHIVE", "Order archival disabled")
+		return
+	}
+
+	intervalMin := 60
+	if v := os.Getenv("ORDER_ARCHIVE_INTERVAL_MINUTES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			intervalMin = parsed
+		} else {
+			logger.Warn("ARCHIVE", fmt.Sprintf("Invalid ORDER_ARCHIVE_INTERVAL_MINUTES value '%s', using default %d", v, intervalMin))
+		}
+	}
+
+	retention := time.Duration(retentionDays) * 24 * time.Hour
+	go orderService.StartOrderArchival(ctx, time.Duration(intervalMin)*time.Minute, retention)
+}
+
+// startPaymentReminders launches the unpaid-order reminder loop.
+// ORDER_REMINDER_AFTER_MINUTES=0 disables it.
+func startPaymentReminders(ctx context.Context, orderService *order.OrderService, logger *logger.Logger) {
+	remindAfterMin := 2
+	if v := os.Getenv("ORDER_REMINDER_AFTER_MINUTES"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			logger.Warn("REMINDER", fmt.Sprintf("Invalid ORDER_REMINDER_AFTER_MINUTES value '%s', using default %d", v, remindAfterMin))
+		} else {
+			remindAfterMin = parsed
+		}
+	}
+	if remindAfterMin == 0 {
+		logger.Info("REMINDER", "Payment reminders disabled")
+		return
+	}
+
+	intervalSec := 30
+	if v := os.Getenv("ORDER_REMINDER_INTERVAL_SECONDS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			intervalSec = parsed
+		} else {
+			logger.Warn("REMINDER", fmt.Sprintf("Invalid ORDER_REMINDER_INTERVAL_SECONDS value '%s', using default %d", v, intervalSec))
+		}
+	}
+
+	remindAfter := time.Duration(remindAfterMin) * time.Minute
+	lockTTL := rediswrap.SeatLockTTL()
+	if remindAfter >= lockTTL {
+		logger.Warn("REMINDER", fmt.Sprintf("Reminder delay %s is not shorter than seat lock TTL %s, payment reminders disabled", remindAfter, lockTTL))
+		return
+	}
+
+	go orderService.StartPaymentReminders(ctx, time.Duration(intervalSec)*time.Second, remindAfter, lockTTL)
+}
+
+// startPaymentWindowSweeper launches the loop that cancels orders whose payment window closed.
+// Events can set their own window, so it runs even when ORDER_PAYMENT_WINDOW_MINUTES is unset.
+func startPaymentWindowSweeper(ctx context.Context, orderService *order.OrderService, logger *logger.Logger) {
+	intervalSec := 30
+	if v := os.Getenv("ORDER_PAYMENT_WINDOW_CHECK_SECONDS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			intervalSec = parsed
+		} else {
+			logger.Warn("PAYMENT_WINDOW", fmt.Sprintf("Invalid ORDER_PAYMENT_WINDOW_CHECK_SECONDS value '%s', using default %d", v, intervalSec))
+		}
+	}
+
+	go orderService.StartPaymentWindowSweeper(ctx, time.Duration(intervalSec)*time.Second)
+}
+
+// startSessionLockPruner launches the periodic cleanup of per-session lock sets.
+// SESSION_LOCK_PRUNE_INTERVAL_SECONDS=0 disables it.
+func startSessionLockPruner(ctx context.Context, orderService *order.OrderService, logger *logger.Logger) {
+	intervalSec := 300
+	if v := os.Getenv("SESSION_LOCK_PRUNE_INTERVAL_SECONDS"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			logger.Warn("SESSION_LOCKS", fmt.Sprintf("Invalid SESSION_LOCK_PRUNE_INTERVAL_SECONDS value '%s', using default %d", v, intervalSec))
+		} else {
+			intervalSec = parsed
+		}
+	}
+	if intervalSec == 0 {
+		logger.Info("SESSION_LOCKS", "Session lock set pruning disabled")
+		return
+	}
+
+	go orderService.StartSessionLockPruner(ctx, time.Duration(intervalSec)*time.Second)
+}
+
+// startOrphanTicketSweeper launches the periodic deletion of tickets whose order is cancelled or gone.
+// ORPHAN_TICKET_SWEEP_INTERVAL_MINUTES of 0 disables it.
+func startOrphanTicketSweeper(ctx context.Context, ticketService *tickets.TicketService, logger *logger.Logger) {
+	intervalMin := 60
+	if v := os.Getenv("ORPHAN_TICKET_SWEEP_INTERVAL_MINUTES"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			logger.Warn("TICKETS", fmt.Sprintf("Invalid ORPHAN_TICKET_SWEEP_INTERVAL_MINUTES value '%s', using default %d", v, intervalMin))
+		} else {
+			intervalMin = parsed
+		}
+	}
+	if intervalMin == 0 {
+		logger.Info("TICKETS", "Orphaned ticket sweeper disabled")
+		return
+	}
+
+	graceMin := 60
+	if v := os.Getenv("ORPHAN_TICKET_GRACE_MINUTES"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			logger.Warn("TICKETS", fmt.Sprintf("Invalid ORPHAN_TICKET_GRACE_MINUTES value '%s', using default %d", v, graceMin))
+		} else {
+			graceMin = parsed
+		}
+	}
+
+	go ticketService.StartOrphanTicketSweeper(ctx, time.Duration(intervalMin)*time.Minute, time.Duration(graceMin)*time.Minute)
+}
+
 func main() {
 	logger := logger.NewLogger()
 	defer logger.Close()
 
-	logger.Info("APP", "Starting Order Service initialization")
+	logger.Info("APP", fmt.Sprintf("Starting Order Service initialization (version %s, commit %s, built %s)", version, commit, buildTime))
 
 	if err := godotenv.Load(); err != nil {
 		logger.Warn("CONFIG", ".env file not found, using environment variables")
@@ -535,19 +480,38 @@ func main() {
 	cfg := config.Load()
 	logger.Info("CONFIG", "Configuration loaded successfully")
 
-	client := &http.Client{
-		Timeout: time.Second * 10,
+	// Orders can't be validated without the event services, so a missing or malformed URL stops startup
+	serviceURLs, err := cfg.ServiceURLs()
+	if err != nil {
+		logger.Fatal("CONFIG", fmt.Sprintf("Invalid event service URL: %v", err))
 	}
+	logger.Info("CONFIG", fmt.Sprintf("Event query service at %s, event seating service at %s", serviceURLs.EventQuery, serviceURLs.EventSeating))
+
+	// One pooled client for every upstream call, so connections are reused across requests
+	client := cfg.HTTPClient.NewClient()
 	ctx := context.Background()
 
+	// A malformed key list would otherwise silently fall back to QR_SECRET_KEY
+	qrKeys, err := qr_genrator.KeysFromEnv()
+	if err != nil {
+		logger.Fatal("CONFIG", fmt.Sprintf("Invalid QR_SECRET_KEYS: %v", err))
+	}
+	logger.Info("CONFIG", fmt.Sprintf("QR codes are encrypted with key %s, %d key(s) accepted", qrKeys[0].ID, len(qrKeys)))
+
 	logger.Info("APP", "Verifying database connections")
-	bunDB, redisClient := verifyConnections(ctx, logger)
+	bunDB, redisClient := verifyConnections(ctx, cfg.Redis, logger)
 	defer bunDB.Close()
 	defer redisClient.Close()
 
 	// Initialize Stripe
 	logger.Info("PAYMENT", "Initializing Stripe")
+	if err := order.ValidatePaymentMode(); err != nil {
+		logger.Fatal("CONFIG", err.Error())
+	}
 	order.InitStripe()
+	if order.MockPaymentsEnabled() {
+		logger.Warn("PAYMENT", "PAYMENT_MODE=mock: payment intents are synthesized locally and Stripe is never called")
+	}
 
 	kafkaADDR := os.Getenv("KAFKA_ADDR")
 	logger.Info("KAFKA", fmt.Sprintf("Using Kafka address from environment variable: %s", kafkaADDR))
@@ -558,7 +522,11 @@ func main() {
 	requiredTopics := []string{
 		"ticketly.order.created",
 		"ticketly.order.updated",
+		"ticketly.order.completed",
 		"ticketly.order.canceled",
+		"ticketly.order.reminder",
+		"ticketly.order.resend",
+		"ticketly.ticket.listed",
 		"ticketly.seats.status",
 		"payment_succefully",
 		"payment_unseecuufull",
@@ -569,7 +537,11 @@ func main() {
 		logger.Info("KAFKA", "Required topics ensured successfully")
 	}
 
-	ticketService := tickets.NewTicketService(&ticket_db.DB{Bun: bunDB})
+	ticketDB := &ticket_db.DB{Bun: bunDB}
+	ticketService := tickets.NewTicketService(ticketDB)
+	ticketService.SetPublisher(kafkaProducer)
+	ticketService.SetQRJobStore(ticketDB)
+	ticketService.SetSessionSchedule(ticket_api.NewSessionScheduleClient(cfg, client, redisClient))
 	analyticsService := analytics.NewService(bunDB)
 
 	orderService := order.NewOrderService(
@@ -579,41 +551,79 @@ func main() {
 		ticketService,
 		client,
 	)
+	orderService.SetServiceURLs(serviceURLs.EventQuery, serviceURLs.EventSeating)
 
 	// Initialize SSE handler for checkout events
-	sseHandler := order_api.NewSSEHandler(logger, redisClient)
+	sseHandler := order_api.NewSSEHandler(logger, redisClient, client)
+	// Browsers don't apply CORS to WebSockets, so the upgrade checks the Origin itself
+	sseHandler.AllowOrigin = cfg.CORS.AllowsOrigin
 
 	handler := &order_api.Handler{
 		OrderService: orderService,
 		Logger:       logger,
 	}
 
-	// Register SSE handler as checkout event emitter for order service
-	orderService.SetCheckoutEventEmitter(sseHandler)
+	// Seat lock changes are kept in audit_seat_events for availability disputes
+	orderService.SetSeatAuditLog(&db.DB{Bun: bunDB})
+	orderService.SetStatusHistory(&db.DB{Bun: bunDB})
+
+	// PayHere is piloted alongside Stripe when its merchant credentials are configured
+	if payHere, ok := order.NewPayHereProviderFromEnv(); ok {
+		orderService.RegisterPaymentProvider(payHere)
+		logger.Info("PAYMENT", fmt.Sprintf("PayHere payments enabled (sandbox: %t)", payHere.Sandbox))
+	}
+
+	// Partner webhooks receive the same order events over signed HTTP callbacks
+	webhookStore := &webhooks.DB{Bun: bunDB}
+	webhookDispatcher := webhooks.NewDispatcher(webhookStore, client, logger)
+	webhookCtx, stopWebhooks := context.WithCancel(ctx)
+	defer stopWebhooks()
+	webhookDispatcher.Start(webhookCtx, webhooks.DefaultWorkers)
+	partnerWebhookHandler := order_api.NewPartnerWebhookHandler(webhookStore, logger, redisClient, client)
+
+	// Register SSE handler and partner webhooks as checkout event emitters for order service
+	orderService.SetCheckoutEventEmitter(order.MultiCheckoutEmitter{sseHandler, webhookDispatcher})
 
 	ticketHandler := ticket_api.NewHandler(ticketService, &db.DB{Bun: bunDB}, cfg, client, redisClient)
 
 	// Use Redis client for M2M token caching in analytics
-	analyticsHandler := analytics_api.NewHandlerWithRedis(analyticsService, logger, redisClient)
+	analyticsHandler := analytics_api.NewHandlerWithRedis(analyticsService, logger, redisClient, client)
+	analyticsHandler.SeatHolds = orderService
+	analyticsHandler.SeatingURL = serviceURLs.EventSeating
 
 	logger.Info("HTTP", "Setting up router and middleware")
 	r := chi.NewRouter()
 
+	// Tag every request and answer handler panics with a 500 instead of dropping the connection
+	r.Use(middleware.RequestID)
+	r.Use(utils.Recoverer(logger))
+
 	// Configure CORS middleware
 	corsMiddleware := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:8090", "http://ticketly.test:8090", "http://www.localhost:8090", "https://ticketly.dpiyumal.me"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"*"},
-		AllowCredentials: true,
-		MaxAge:           3600, // 1 hour in seconds
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.CORS.AllowedHeaders,
+		ExposedHeaders:   cfg.CORS.ExposedHeaders,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           cfg.CORS.MaxAge,
 	})
 	r.Use(corsMiddleware.Handler)
-	logger.Info("HTTP", "CORS middleware configured")
+	if cfg.CORS.AllowCredentials && cfg.CORS.AllowsOrigin("*") {
+		logger.Warn("HTTP", "CORS allows credentials from any origin; list the frontend origins in CORS_ALLOWED_ORIGINS instead of *")
+	}
+	logger.Info("HTTP", fmt.Sprintf("CORS middleware configured for origins: %s", strings.Join(cfg.CORS.AllowedOrigins, ", ")))
 
 	// --- Public Routes ---
-	r.Get("/api/order/tickets/count", ticketHandler.GetTotalTicketsCount)
+	// Unauthenticated endpoints are throttled per client IP. Stripe delivers webhooks in bursts
+	// from a handful of IPs, so its limiter is much more generous.
+	publicLimiter := ratelimit.FromEnv("PUBLIC_RATE_LIMIT", 60, 20)
+	webhookLimiter := ratelimit.FromEnv("WEBHOOK_RATE_LIMIT", 1200, 300)
+	r.With(publicLimiter.Middleware).Get("/api/order/tickets/count", ticketHandler.GetTotalTicketsCount)
 	// Stripe webhook endpoint doesn't require authentication
-	r.Post("/api/order/webhook/stripe", handler.StripeWebhook)
+	r.With(webhookLimiter.Middleware).Post("/api/order/webhook/stripe", handler.StripeWebhook)
+	r.With(webhookLimiter.Middleware).Post("/api/order/webhook/payhere", handler.PayHereWebhook)
+
+	r.Get("/version", versionHandler)
 
 	// Kubernetes health check endpoint for liveness and readiness probes
 	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
@@ -664,6 +674,8 @@ func main() {
 		// Check Kafka connection
 		healthStatus["kafka"] = "connected" // We assume Kafka is up as it's hard to test directly
 
+		healthStatus["sse_active_connections"] = sse.ActiveConnections.Value()
+
 		w.Header().Set("Content-Type", "application/json")
 		if healthStatus["status"] == "UP" {
 			w.WriteHeader(http.StatusOK)
@@ -673,6 +685,7 @@ func main() {
 	})
 	logger.Info("ROUTER", "Public ticket count endpoint registered at /api/order/tickets/count")
 	logger.Info("ROUTER", "Stripe webhook endpoint registered at /api/order/webhook/stripe")
+	logger.Info("ROUTER", "PayHere webhook endpoint registered at /api/order/webhook/payhere")
 	logger.Info("ROUTER", "Kubernetes health check endpoint registered at /healthz")
 	logger.Info("ROUTER", "Health check endpoint registered at /api/order/health")
 
@@ -686,20 +699,62 @@ func main() {
 
 			r.Route("/order", func(r chi.Router) {
 				r.Post("/", handler.SeatValidationAndPlaceOrder)
+				r.Get("/lookup", handler.LookupOrderByNumber)
 				r.Get("/{orderId}", handler.GetOrder)
 				r.Delete("/{orderId}", handler.DeleteOrder)
 				r.Post("/{orderId}/create-payment-intent", handler.CreatePaymentIntent)
+				r.Post("/{orderId}/wallet-payment-intent", handler.CreateWalletPaymentIntent)
+				r.Post("/{orderId}/payhere-checkout", handler.CreatePayHereCheckout)
+				r.Get("/{orderId}/payment-status", handler.GetPaymentStatus)
+				r.Get("/{orderId}/summary", handler.GetOrderSummary)
+				r.Post("/{orderId}/resend-confirmation", handler.ResendConfirmation)
+				r.With(auth.RequireAnyRole(auth.SupportRole(), auth.AdminRole())).Post("/{orderId}/notes", handler.AddOrderNote)
+				r.With(auth.RequireAnyRole(auth.SupportRole(), auth.AdminRole())).Get("/{orderId}/notes", handler.GetOrderNotes)
+				r.With(auth.RequireAnyRole(auth.SupportRole(), auth.AdminRole())).Get("/{orderId}/history", handler.GetOrderStatusHistory)
 				r.Get("/user/{userId}", handler.GetOrdersWithTicketsByUserID)
+				r.Post("/seats/lookup", handler.LookupSeatOrders)
+				r.Post("/seats/availability", handler.CheckSeatsAvailability)
+
+				r.Route("/admin", func(r chi.Router) {
+					// Admin-only operations, gated on the realm role from ADMIN_ROLE
+					r.Group(func(r chi.Router) {
+						r.Use(auth.RequireRole(auth.AdminRole()))
+						r.Post("/reconcile-payments", handler.ReconcilePayments)
+						r.Get("/seat-lock/{seatId}", handler.GetSeatLock)
+						r.Delete("/seat-lock/{seatId}", handler.ForceReleaseSeatLock)
+						r.Post("/{orderId}/complete", handler.CompleteOrder)
+						r.Get("/seat-audit", handler.GetSeatAuditEvents)
+						r.Post("/events/{eventId}/regenerate-qr", ticketHandler.RegenerateEventQRCodes)
+						r.Get("/qr-jobs/{jobId}", ticketHandler.GetQRRegenerationJob)
+					})
+
+					// Seat holds and comp tickets for VIPs and press, also open to organizers
+					r.Group(func(r chi.Router) {
+						r.Use(auth.RequireAnyRole(auth.AdminRole(), auth.OrganizerRole()))
+						r.Post("/hold-seats", handler.HoldSeats)
+						r.Post("/release-seats", handler.ReleaseHeldSeats)
+						r.Post("/comp-tickets", handler.CompTickets)
+					})
+				})
 			})
 			logger.Info("ROUTER", "Order routes registered under /api/order")
 
 			r.Route("/order/ticket", func(r chi.Router) {
 				r.Get("/", ticketHandler.ListTicketsByOrder)
 				r.Get("/{ticketId}", ticketHandler.ViewTicket)
+				r.Get("/{ticketId}/pdf", ticketHandler.DownloadTicketPDF)
+				r.Get("/{ticketId}/qr.png", ticketHandler.GetTicketQRImage)
+				r.Post("/{ticketId}/resale", ticketHandler.ListTicketForResale)
+				r.Delete("/{ticketId}/resale", ticketHandler.UnlistTicketFromResale)
 				r.Post("/", ticketHandler.CreateTicket)
 				r.Put("/{ticketId}", ticketHandler.UpdateTicket)
 				r.Delete("/{ticketId}", ticketHandler.DeleteTicket)
 				r.Post("/checkin", ticketHandler.CheckinTicket)
+				r.Post("/inspect-qr", ticketHandler.InspectQR)
+				r.Delete("/{ticketId}/checkin", ticketHandler.RevokeCheckin)
+				r.Get("/session/{sessionId}", ticketHandler.GetTicketsBySession)
+				r.Get("/session/{sessionId}/checked-in", ticketHandler.GetSessionCheckedInCount)
+				r.Post("/session/{sessionId}/checked-in/reconcile", ticketHandler.ReconcileSessionCheckedInCount)
 			})
 			logger.Info("ROUTER", "Ticket routes registered under /api/order/ticket")
 
@@ -712,6 +767,21 @@ func main() {
 				r.Get("/checkouts/event/{eventID}", sseHandler.HandleEventCheckouts)
 			})
 			logger.Info("ROUTER", "SSE checkout event routes registered under /api/order/sse")
+
+			// WebSocket alternative for clients whose proxies buffer SSE
+			r.Route("/order/ws", func(r chi.Router) {
+				r.Get("/checkouts/organization/{organizationID}", sseHandler.HandleOrganizationCheckoutsWS)
+				r.Get("/checkouts/event/{eventID}", sseHandler.HandleEventCheckoutsWS)
+			})
+			logger.Info("ROUTER", "WebSocket checkout event routes registered under /api/order/ws")
+
+			// Organization-managed HTTP callbacks for order events
+			r.Route("/order/partner-webhooks/organization/{organizationID}", func(r chi.Router) {
+				r.Post("/", partnerWebhookHandler.RegisterWebhook)
+				r.Get("/", partnerWebhookHandler.ListWebhooks)
+				r.Delete("/{webhookId}", partnerWebhookHandler.DeleteWebhook)
+			})
+			logger.Info("ROUTER", "Partner webhook routes registered under /api/order/partner-webhooks")
 		})
 	})
 
@@ -721,7 +791,15 @@ func main() {
 	}
 
 	logger.Info("REDIS", "Starting seat unlock subscription")
-	subscribeSeatUnlocks(redisClient, kafkaProducer, &db.DB{Bun: bunDB}, logger, kafkaBrokers)
+	subscribeSeatUnlocks(redisClient, cfg.Redis.DB, kafkaProducer, bunDB, &db.DB{Bun: bunDB}, &db.DB{Bun: bunDB}, client, logger)
+
+	reminderCtx, stopReminders := context.WithCancel(ctx)
+	defer stopReminders()
+	startPaymentReminders(reminderCtx, orderService, logger)
+	startOrderArchival(reminderCtx, orderService, logger)
+	startPaymentWindowSweeper(reminderCtx, orderService, logger)
+	startSessionLockPruner(reminderCtx, orderService, logger)
+	startOrphanTicketSweeper(reminderCtx, ticketService, logger)
 
 	go func() {
 		logger.Info("HTTP", "🚀 Order Service running on :8084")
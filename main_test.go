@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"ms-ticketing/internal/kafka"
+	"ms-ticketing/internal/models"
+	rediswrap "ms-ticketing/internal/order/redis"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	_ "github.com/uptrace/bun/driver/sqliteshim"
+)
+
+// newSeatUnlockTestDB creates the orders and tickets tables the expiry flow reads in an in-memory SQLite DB
+func newSeatUnlockTestDB(t *testing.T) *bun.DB {
+	t.Helper()
+	sqldb, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	sqldb.SetMaxOpenConns(1)
+	bunDB := bun.NewDB(sqldb, sqlitedialect.New())
+	t.Cleanup(func() { bunDB.Close() })
+
+	for _, model := range []interface{}{(*models.Order)(nil), (*models.Ticket)(nil)} {
+		_, err := bunDB.NewCreateTable().Model(model).Exec(context.Background())
+		require.NoError(t, err)
+	}
+	return bunDB
+}
+
+// newSeatUnlockTestRedis serves the seat locks from an in-process miniredis, which runs their Lua scripts
+func newSeatUnlockTestRedis(t *testing.T) redis.UniversalClient {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestSeatUnlockServiceUsesRealSeatLocks(t *testing.T) {
+	bunDB := newSeatUnlockTestDB(t)
+	locks := rediswrap.NewRedis(redis.NewClient(&redis.Options{Addr: "localhost:0"}), nil)
+
+	orderService := NewOrderServiceForSeatUnlock(bunDB, locks, kafka.NewProducer([]string{"localhost:0"}), http.DefaultClient)
+
+	assert.Same(t, locks, orderService.Redis)
+}
+
+func TestSeatUnlockServiceRelocksSeatInsidePaymentWindow(t *testing.T) {
+	t.Setenv("ORDER_EXPIRY_PAYMENT_GRACE_SECONDS", "0")
+	bunDB := newSeatUnlockTestDB(t)
+	rdb := newSeatUnlockTestRedis(t)
+	ctx := context.Background()
+
+	pending := models.Order{
+		OrderID:      uuid.NewString(),
+		UserID:       "user-1",
+		SessionID:    uuid.NewString(),
+		Status:       "pending",
+		CreatedAt:    time.Now(),
+		PaymentDueAt: time.Now().Add(10 * time.Minute),
+	}
+	_, err := bunDB.NewInsert().Model(&pending).Exec(ctx)
+	require.NoError(t, err)
+	seatID := uuid.NewString()
+	ticket := models.Ticket{TicketID: models.TicketIDFor(pending.OrderID, seatID), OrderID: pending.OrderID, SeatID: seatID, IssuedAt: time.Now()}
+	_, err = bunDB.NewInsert().Model(&ticket).Exec(ctx)
+	require.NoError(t, err)
+
+	locks := rediswrap.NewRedis(rdb, nil)
+	orderService := NewOrderServiceForSeatUnlock(bunDB, locks, kafka.NewProducer([]string{"localhost:0"}), http.DefaultClient)
+
+	// The seat's lock has already expired when the notification is handled
+	result := orderService.HandleExpiredSeats(ctx, []string{seatID})
+	assert.Equal(t, 1, result.SeatsRelocked)
+
+	// The relock must hold in Redis, or another buyer could take the seat while the order can still be paid
+	lock, ttl, err := locks.GetSeatLock(seatID)
+	require.NoError(t, err)
+	require.NotNil(t, lock)
+	assert.Equal(t, pending.OrderID, lock.OrderID)
+	assert.Positive(t, ttl)
+
+	ok, err := locks.LockSeats([]string{seatID}, pending.SessionID, uuid.NewString(), "user-2")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -1,9 +1,8 @@
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 
-DROP TABLE IF EXISTS tickets;
-DROP TABLE IF EXISTS orders;
-
-CREATE TABLE orders (
+-- Tables may already exist on databases that were set up by hand before migrations ran at startup,
+-- so never drop them here
+CREATE TABLE IF NOT EXISTS orders (
     order_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     user_id UUID NOT NULL,
     event_id UUID,
@@ -19,7 +18,7 @@ CREATE TABLE orders (
     payment_intent_id TEXT
 );
 
-CREATE TABLE tickets (
+CREATE TABLE IF NOT EXISTS tickets (
     ticket_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     order_id  UUID NOT NULL REFERENCES orders(order_id) ON DELETE CASCADE,
     seat_id   UUID NOT NULL,
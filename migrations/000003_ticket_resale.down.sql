@@ -0,0 +1,4 @@
+ALTER TABLE tickets
+    DROP COLUMN IF EXISTS resale_listed_at,
+    DROP COLUMN IF EXISTS resale_price,
+    DROP COLUMN IF EXISTS resale_status;
@@ -0,0 +1,4 @@
+ALTER TABLE tickets
+    ADD COLUMN IF NOT EXISTS resale_status TEXT NOT NULL DEFAULT 'none',
+    ADD COLUMN IF NOT EXISTS resale_price NUMERIC(10,2),
+    ADD COLUMN IF NOT EXISTS resale_listed_at TIMESTAMP;
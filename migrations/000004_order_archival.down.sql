@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_orders_archived_created_at;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS archived;
@@ -0,0 +1,4 @@
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS archived BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_orders_archived_created_at ON orders (archived, created_at);
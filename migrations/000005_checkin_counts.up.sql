@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS checkin_counts (
+    session_id TEXT PRIMARY KEY,
+    checked_in INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Seed the counters from tickets that were checked in before this table existed
+INSERT INTO checkin_counts (session_id, checked_in, updated_at)
+SELECT o.session_id, COUNT(*), NOW()
+FROM tickets t
+JOIN orders o ON o.order_id = t.order_id
+WHERE t.checked_in = TRUE AND o.session_id IS NOT NULL
+GROUP BY o.session_id
+ON CONFLICT (session_id) DO NOTHING;
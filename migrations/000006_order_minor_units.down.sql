@@ -0,0 +1,4 @@
+ALTER TABLE orders
+    ALTER COLUMN subtotal TYPE NUMERIC(10,2) USING subtotal / 100.0,
+    ALTER COLUMN discount_amount TYPE NUMERIC(10,2) USING discount_amount / 100.0,
+    ALTER COLUMN price TYPE NUMERIC(10,2) USING price / 100.0;
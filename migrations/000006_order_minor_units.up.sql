@@ -0,0 +1,12 @@
+-- Order amounts are stored as integer minor units (cents) to avoid float drift in sums.
+-- Only convert while the columns are still NUMERIC so re-running never multiplies amounts twice.
+DO $$
+BEGIN
+    IF (SELECT data_type FROM information_schema.columns
+        WHERE table_name = 'orders' AND column_name = 'price') = 'numeric' THEN
+        ALTER TABLE orders
+            ALTER COLUMN subtotal TYPE BIGINT USING ROUND(subtotal * 100)::BIGINT,
+            ALTER COLUMN discount_amount TYPE BIGINT USING ROUND(discount_amount * 100)::BIGINT,
+            ALTER COLUMN price TYPE BIGINT USING ROUND(price * 100)::BIGINT;
+    END IF;
+END $$;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS ticket_counts_event_session_date;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS ticket_counts (
+    id BIGSERIAL PRIMARY KEY,
+    event_id TEXT,
+    session_id TEXT,
+    count INTEGER NOT NULL DEFAULT 0,
+    date TIMESTAMP
+);
+
+-- Fold duplicate rows left behind by the old select-then-insert increment into the oldest row
+UPDATE ticket_counts tc
+SET count = agg.total
+FROM (
+    SELECT MIN(id) AS id, SUM(count) AS total
+    FROM ticket_counts
+    GROUP BY event_id, session_id, date
+) agg
+WHERE tc.id = agg.id;
+
+DELETE FROM ticket_counts
+WHERE id NOT IN (
+    SELECT MIN(id)
+    FROM ticket_counts
+    GROUP BY event_id, session_id, date
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ticket_counts_event_session_date
+    ON ticket_counts (event_id, session_id, date);
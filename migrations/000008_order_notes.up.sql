@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS order_notes (
+    note_id UUID PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders(order_id) ON DELETE CASCADE,
+    author_user_id TEXT NOT NULL,
+    note TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS order_notes_order_id_created_at ON order_notes (order_id, created_at);
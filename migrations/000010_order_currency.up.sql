@@ -0,0 +1,3 @@
+-- Orders placed before this migration have no currency and fall back to the CURRENCY setting
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS currency VARCHAR(3);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS audit_seat_events (
+    event_id UUID PRIMARY KEY,
+    seat_id TEXT NOT NULL,
+    order_id UUID,
+    session_id TEXT,
+    action VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS audit_seat_events_seat_id ON audit_seat_events (seat_id, created_at);
+CREATE INDEX IF NOT EXISTS audit_seat_events_session_id ON audit_seat_events (session_id, created_at);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS order_number_counters;
+
+DROP INDEX IF EXISTS orders_order_number;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS order_number;
@@ -0,0 +1,9 @@
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS order_number TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS orders_order_number ON orders (order_number);
+
+CREATE TABLE IF NOT EXISTS order_number_counters (
+    day TEXT PRIMARY KEY,
+    last_number BIGINT NOT NULL DEFAULT 0
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS order_status_history (
+    change_id UUID PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders(order_id) ON DELETE CASCADE,
+    old_status TEXT,
+    new_status TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS order_status_history_order_id_created_at ON order_status_history (order_id, created_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS orders_pending_payment_due_at;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS payment_due_at;
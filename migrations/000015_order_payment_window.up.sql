@@ -0,0 +1,4 @@
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS payment_due_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS orders_pending_payment_due_at ON orders (payment_due_at) WHERE status = 'pending';
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS qr_regeneration_jobs (
+    job_id UUID PRIMARY KEY,
+    event_id UUID NOT NULL,
+    status TEXT NOT NULL,
+    key_id TEXT,
+    total INTEGER NOT NULL DEFAULT 0,
+    processed INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP
+);
+
+-- At most one running job per event, across every instance
+CREATE UNIQUE INDEX IF NOT EXISTS qr_regeneration_jobs_running_event ON qr_regeneration_jobs (event_id) WHERE status = 'running';